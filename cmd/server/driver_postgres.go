@@ -0,0 +1,6 @@
+//go:build postgres
+
+package main
+
+// Links the Postgres driver: go build -tags postgres ./cmd/server
+import _ "github.com/jackc/pgx/v5/stdlib"
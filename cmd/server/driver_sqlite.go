@@ -0,0 +1,4 @@
+package main
+
+// Links the pure-Go SQLite driver, so STORAGE_BACKEND=sqlite works in every build.
+import _ "modernc.org/sqlite"
@@ -1,29 +1,452 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	httpadapter "github.com/jailtonjunior/pomelo/internal/adapters/input/http"
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/file"
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/instrumented"
 	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/publisher"
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/sqldb"
 	application "github.com/jailtonjunior/pomelo/internal/application"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/config"
+	"github.com/jailtonjunior/pomelo/internal/health"
+	"github.com/jailtonjunior/pomelo/internal/metrics"
+	"github.com/jailtonjunior/pomelo/internal/tracing"
 )
 
 func main() {
-	log := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplay(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		os.Exit(runReconcile(os.Args[2:]))
+	}
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "JSON or YAML config `file`; environment variables override it")
+	printConfig := flag.Bool("print-config", false, "print the effective config, secrets redacted, and exit")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %[1]s [flags]\n       %[1]s replay -to backend [flags]\n       %[1]s reconcile -file settlement [flags]\n\nFlags:\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\nEnvironment:\n  %s\n", strings.Join(config.EnvVars(), " "))
+	}
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath, os.Getenv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config:\n%v\n", err)
+		os.Exit(1)
+	}
+	if *printConfig {
+		out, _ := json.MarshalIndent(cfg.Redacted(), "", "  ")
+		fmt.Println(string(out))
+		return
+	}
+	log := newLogger(cfg.Log)
+	// SIGTERM or Ctrl-C starts the shutdown; background loops stop only after the last request.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	background, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	var workers sync.WaitGroup
+
+	repo, closeRepo, err := newRepository(cfg.Storage)
+	if err != nil {
+		log.Error("storage init failed", "err", err)
+		os.Exit(1)
+	}
 
-	repo := memory.NewRepository()
-	svc := application.NewService(repo)
-	handler := httpadapter.NewHandler(svc)
+	tracer, flushInterval := newTracer(cfg.Tracing)
+	if tracer != nil {
+		log.Info("span export enabled", "service", cfg.Tracing.ServiceName, "flush_interval", flushInterval)
+		workers.Go(func() {
+			tracer.Run(background, flushInterval, func(err error) {
+				log.Warn("span export failed", "err", err)
+			})
+		})
+	}
+
+	registry := metrics.NewRegistry()
+	// stores lists everything whose in-memory size is exported on /metrics.
+	stores := []any{repo}
+	checks := health.NewRegistry()
+	// The memory backend has nothing that can fail; file and SQL backends report on /health/ready.
+	if c, ok := repo.(health.Checker); ok {
+		checks.Register("storage", c)
+	}
+	attempts, err := newAttemptLog(cfg.Storage, repo)
+	if err != nil {
+		log.Error("attempt log init failed", "err", err)
+		os.Exit(1)
+	}
+	disputes, err := newDisputeStore(cfg.Storage, repo)
+	if err != nil {
+		log.Error("dispute store init failed", "err", err)
+		os.Exit(1)
+	}
+	stores = append(stores, attempts, disputes)
+	// Stores that live next to the transactions are picked from the unwrapped repository.
+	storage := repo
+	repo = instrumented.NewRepository(repo, registry, instrumented.WithTracer(tracer))
+
+	// The ledger is an in-memory projection of the repository, rebuilt below on every start.
+	ledger := memory.NewLedgerStore()
+	authorizations, err := newAuthorizationStore(cfg.Storage, storage)
+	if err != nil {
+		log.Error("authorization store init failed", "err", err)
+		os.Exit(1)
+	}
+	rejections, err := newRejectedWebhookStore(cfg.Storage)
+	if err != nil {
+		log.Error("rejected webhook store init failed", "err", err)
+		os.Exit(1)
+	}
+	stores = append(stores, ledger, authorizations, rejections)
+	svcOpts := []application.Option{
+		application.WithLedger(ledger),
+		application.WithAttemptLog(attempts),
+		application.WithRejectedWebhooks(rejections, httpadapter.DecodeWebhook),
+		application.WithDisputes(disputes),
+		application.WithTracer(tracer),
+	}
+	handlerOpts := []httpadapter.HandlerOption{
+		httpadapter.WithMetrics(registry),
+		httpadapter.WithHealthChecks(checks),
+		httpadapter.WithTracer(tracer),
+		httpadapter.WithLogger(log),
+		httpadapter.WithMaxBodyBytes(cfg.HTTP.MaxBodyBytes),
+	}
+	if limits := cfg.PurchaseLimits(); limits != nil {
+		svcOpts = append(svcOpts, application.WithPurchaseLimits(limits))
+		log.Info("purchase limits overridden", "currencies", slices.Sorted(maps.Keys(limits)))
+	}
+	if currency := cfg.Settlement.Currency; currency != "" {
+		svcOpts = append(svcOpts, application.WithSettlementCurrency(currency))
+		log.Info("settlement currency enforced", "currency", currency)
+	}
+	pendingTTL := time.Duration(cfg.Pending.TTL)
+	authDeadline := time.Duration(cfg.Authorization.Deadline)
+	holdTTL := time.Duration(cfg.Authorization.HoldTTL)
+	svcOpts = append(svcOpts, application.WithAuthorizations(authorizations, holdTTL))
+	if pendingTTL > 0 {
+		pending, err := newPendingStore(cfg.Storage, storage)
+		if err != nil {
+			log.Error("pending adjustment store init failed", "err", err)
+			os.Exit(1)
+		}
+		stores = append(stores, pending)
+		svcOpts = append(svcOpts, application.WithPendingAdjustments(pending, pendingTTL))
+		log.Info("out-of-order adjustments are parked", "ttl", pendingTTL)
+	}
+	if retention := time.Duration(cfg.Idempotency.Retention); retention > 0 {
+		records, err := newIdempotencyStore(cfg.Storage, storage)
+		if err != nil {
+			log.Error("idempotency store init failed", "err", err)
+			os.Exit(1)
+		}
+		stores = append(stores, records)
+		svcOpts = append(svcOpts, application.WithIdempotencyRecords(records, retention))
+		log.Info("webhook responses are replayed to retries", "retention", retention)
+	}
+	svc := application.NewService(repo, svcOpts...)
+	posted, err := svc.RebuildLedger(context.Background())
+	if err != nil {
+		log.Error("ledger rebuild failed", "err", err)
+		os.Exit(1)
+	}
+	log.Info("ledger rebuilt from storage", "postings", posted)
+	handlerOpts = append(handlerOpts, httpadapter.WithLedger(svc), httpadapter.WithAuthorizations(svc, authDeadline), httpadapter.WithHistory(svc), httpadapter.WithRejectedWebhooks(svc), httpadapter.WithDisputes(svc), httpadapter.WithSettlement(svc))
+	if pendingTTL > 0 {
+		handlerOpts = append(handlerOpts, httpadapter.WithPendingAdjustmentReview(svc))
+	}
+	if cfg.Idempotency.Retention > 0 {
+		handlerOpts = append(handlerOpts, httpadapter.WithIdempotencyRecords(svc))
+	}
+	if len(cfg.Webhook.Secrets) > 0 {
+		maxSkew := time.Duration(cfg.Webhook.MaxSkew)
+		verifier := httpadapter.NewSignatureVerifier(cfg.Webhook.Secrets, maxSkew)
+		handlerOpts = append(handlerOpts, httpadapter.WithSignatureVerifier(verifier))
+		log.Info("webhook signature verification enabled", "max_skew", maxSkew)
+	}
+	deadLetters, err := newDeadLetterStore(cfg.Storage, storage)
+	if err != nil {
+		log.Error("dead letter store init failed", "err", err)
+		os.Exit(1)
+	}
+	subscriptions, deliveries, err := newSubscriptionStores(cfg.Storage, storage)
+	if err != nil {
+		log.Error("subscription store init failed", "err", err)
+		os.Exit(1)
+	}
+	stores = append(stores, deadLetters, subscriptions, deliveries)
+	dispatcher := newDispatcher(cfg.Outbox, repo, deadLetters, application.WithSubscriptions(subscriptions, deliveries))
+	pollInterval := time.Duration(cfg.Outbox.PollInterval)
+	checks.RegisterLiveness("outbox_dispatcher", dispatcher)
+	log.Info("outbox dispatcher started", "subscribers", len(cfg.Outbox.Subscribers), "poll_interval", pollInterval)
+	workers.Go(func() {
+		dispatcher.Run(background, pollInterval, func(err error) {
+			log.Warn("outbox dispatch failed", "err", err)
+		})
+	})
+	handlerOpts = append(handlerOpts, httpadapter.WithOutbox(dispatcher), httpadapter.WithSubscriptions(dispatcher))
+	registerStoreSizes(registry, stores)
+	handler := httpadapter.NewHandler(svc, handlerOpts...)
 
 	mux := http.NewServeMux()
 	handler.RegisterRoutes(mux)
 
-	addr := ":8080"
-	log.Info("pomelo webhook server listening", "addr", addr)
-	if err := http.ListenAndServe(addr, mux); err != nil {
+	srv := &http.Server{
+		Addr:              cfg.HTTP.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: time.Duration(cfg.HTTP.ReadHeaderTimeout),
+		ReadTimeout:       time.Duration(cfg.HTTP.ReadTimeout),
+		WriteTimeout:      time.Duration(cfg.HTTP.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.HTTP.IdleTimeout),
+		ErrorLog:          slog.NewLogLogger(log.Handler(), slog.LevelWarn),
+	}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.ListenAndServe() }()
+	log.Info("pomelo webhook server listening", "addr", cfg.HTTP.Addr, "storage", cfg.Storage.Backend)
+
+	select {
+	case err := <-serveErr:
 		log.Error("server failed", "err", err)
+		stopBackground()
+		workers.Wait()
+		closeRepo()
 		os.Exit(1)
+	case <-ctx.Done():
+	}
+	// A second signal kills the process without waiting for the drain.
+	stop()
+	if err := shutdown(srv, handler, cfg.HTTP, log); err != nil {
+		log.Error("in-flight requests cut off", "err", err)
+	}
+	stopBackground()
+	workers.Wait()
+	if err := closeRepo(); err != nil {
+		log.Error("storage flush failed", "err", err)
+		os.Exit(1)
+	}
+	log.Info("shutdown complete")
+}
+
+// shutdown reports not-ready on /health, waits cfg.ShutdownDelay for load balancers to notice,
+// then stops accepting connections and waits up to cfg.ShutdownTimeout for in-flight requests.
+func shutdown(srv *http.Server, handler *httpadapter.Handler, cfg config.HTTP, log *slog.Logger) error {
+	delay, timeout := time.Duration(cfg.ShutdownDelay), time.Duration(cfg.ShutdownTimeout)
+	log.Info("shutting down", "delay", delay, "timeout", timeout)
+	handler.Drain()
+	time.Sleep(delay)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// newLogger writes text lines, or JSON objects with format json, to stdout at cfg.Level.
+func newLogger(cfg config.Log) *slog.Logger {
+	var level slog.Level
+	// Load already rejected unknown levels.
+	_ = level.UnmarshalText([]byte(cfg.Level))
+	opts := &slog.HandlerOptions{Level: level}
+	if cfg.Format == "json" {
+		return slog.New(slog.NewJSONHandler(os.Stdout, opts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stdout, opts))
+}
+
+// newDispatcher delivers the repository's outbox to the configured subscriber URLs and to the
+// subscriptions registered through POST /subscriptions. Without subscribers the dispatcher still
+// runs and simply drains the outbox.
+func newDispatcher(cfg config.Outbox, outbox ports.OutboxStore, deadLetters ports.DeadLetterStore, opts ...application.DispatcherOption) *application.Dispatcher {
+	opts = append(opts, application.WithRetryPolicy(cfg.MaxAttempts, application.DefaultDispatchBaseBackoff, application.DefaultDispatchMaxBackoff))
+	return application.NewDispatcher(outbox, publisher.NewHTTPPublisher(nil), deadLetters, cfg.Subscribers, opts...)
+}
+
+// newTracer exports spans over OTLP/HTTP to cfg.Endpoint every cfg.ExportInterval. Without an
+// endpoint it returns a nil tracer and tracing stays off.
+func newTracer(cfg config.Tracing) (*tracing.Tracer, time.Duration) {
+	if cfg.Endpoint == "" {
+		return nil, 0
+	}
+	return tracing.NewTracer(tracing.NewOTLPExporter(cfg.Endpoint, cfg.ServiceName, nil)), time.Duration(cfg.ExportInterval)
+}
+
+// registerStoreSizes exports the entry counts of the stores that keep their data in memory: the
+// memory adapters and the file repository's index. Database-backed stores are skipped.
+func registerStoreSizes(registry *metrics.Registry, stores []any) {
+	type sizer interface{ Sizes() map[string]int }
+	registry.NewGaugeFunc("pomelo_store_entries", "Entries held in memory, by store.", []string{"store"},
+		func(set func(float64, ...string)) {
+			for _, store := range stores {
+				if s, ok := store.(sizer); ok {
+					for name, n := range s.Sizes() {
+						set(float64(n), name)
+					}
+				}
+			}
+		})
+}
+
+// newPendingStore keeps parked adjustments next to the transactions they wait for: in pending.json
+// with the file backend, in the database with the SQL backends, and in memory otherwise. repo
+// must be the unwrapped repository.
+func newPendingStore(cfg config.Storage, repo ports.TransactionRepository) (ports.PendingAdjustmentStore, error) {
+	if cfg.Backend == "file" {
+		return file.OpenPendingStore(cfg.DataDir)
+	}
+	if db, ok := repo.(*sqldb.Repository); ok {
+		return db.PendingAdjustments(), nil
+	}
+	return memory.NewPendingStore(), nil
+}
+
+// newAuthorizationStore keeps card profiles and authorization decisions in authorizations.jsonl
+// with the file backend, in the database with the SQL backends, and in memory otherwise. repo must
+// be the unwrapped repository.
+func newAuthorizationStore(cfg config.Storage, repo ports.TransactionRepository) (ports.AuthorizationStore, error) {
+	if cfg.Backend == "file" {
+		return file.OpenAuthorizationStore(cfg.DataDir)
+	}
+	if store, ok := repo.(ports.AuthorizationStore); ok {
+		return store, nil
+	}
+	return memory.NewAuthorizationStore(), nil
+}
+
+// newDeadLetterStore keeps dead letters with the outbox they came from: in deadletters.jsonl with
+// the file backend, in the database with the SQL backends, and in memory otherwise. repo must be
+// the unwrapped repository.
+func newDeadLetterStore(cfg config.Storage, repo ports.TransactionRepository) (ports.DeadLetterStore, error) {
+	if cfg.Backend == "file" {
+		return file.OpenDeadLetterStore(cfg.DataDir)
+	}
+	if store, ok := repo.(ports.DeadLetterStore); ok {
+		return store, nil
+	}
+	return memory.NewDeadLetterStore(), nil
+}
+
+// newSubscriptionStores keeps subscriptions, with their secrets, and the delivery log in
+// subscriptions.json and deliveries.jsonl with the file backend, in the database with the SQL
+// backends, and in memory otherwise. The log keeps memory.DefaultDeliveriesPerSubscription
+// attempts per subscription everywhere. repo must be the unwrapped repository.
+func newSubscriptionStores(cfg config.Storage, repo ports.TransactionRepository) (ports.SubscriptionStore, ports.DeliveryLog, error) {
+	limit := memory.DefaultDeliveriesPerSubscription
+	if cfg.Backend == "file" {
+		subscriptions, err := file.OpenSubscriptionStore(cfg.DataDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		deliveries, err := file.OpenDeliveryLog(cfg.DataDir, limit)
+		if err != nil {
+			return nil, nil, err
+		}
+		return subscriptions, deliveries, nil
+	}
+	if db, ok := repo.(*sqldb.Repository); ok {
+		return db, db.Deliveries(limit), nil
+	}
+	return memory.NewSubscriptionStore(), memory.NewDeliveryLog(limit), nil
+}
+
+// newIdempotencyStore keeps the records next to the log with the file backend, in the database
+// with the SQL backends, and in memory otherwise. repo must be the unwrapped repository.
+func newIdempotencyStore(cfg config.Storage, repo ports.TransactionRepository) (ports.IdempotencyStore, error) {
+	if cfg.Backend == "file" {
+		return file.OpenIdempotencyStore(cfg.DataDir, time.Now())
+	}
+	if db, ok := repo.(*sqldb.Repository); ok {
+		return db.IdempotencyRecords(), nil
+	}
+	return memory.NewIdempotencyStore(), nil
+}
+
+// newRejectedWebhookStore keeps rejected webhooks next to the log with the file backend, and in
+// memory otherwise.
+func newRejectedWebhookStore(cfg config.Storage) (ports.RejectedWebhookStore, error) {
+	if cfg.Backend == "file" {
+		return file.OpenRejectedWebhookStore(cfg.DataDir)
+	}
+	return memory.NewRejectedWebhookStore(), nil
+}
+
+// newDisputeStore keeps disputes wherever the purchases they hold money from live, since the
+// ledger rebuild reposts their credits. repo must be the unwrapped repository.
+func newDisputeStore(cfg config.Storage, repo ports.TransactionRepository) (ports.DisputeStore, error) {
+	if cfg.Backend == "file" {
+		return file.OpenDisputeStore(cfg.DataDir)
+	}
+	if store, ok := repo.(ports.DisputeStore); ok {
+		return store, nil
+	}
+	return memory.NewDisputeStore(), nil
+}
+
+// newAttemptLog keeps the webhook attempts with the data they built: in attempts.jsonl with the
+// file backend, in the database with the SQL backends, and in memory otherwise. repo must be the
+// unwrapped repository.
+func newAttemptLog(cfg config.Storage, repo ports.TransactionRepository) (ports.AttemptLog, error) {
+	if cfg.Backend == "file" {
+		return file.OpenAttemptLog(cfg.DataDir)
+	}
+	if log, ok := repo.(ports.AttemptLog); ok {
+		return log, nil
+	}
+	return memory.NewAttemptLog(), nil
+}
+
+// newRepository selects the TransactionRepository from cfg.Backend (memory | file | sqlite | postgres).
+func newRepository(cfg config.Storage) (ports.TransactionRepository, func() error, error) {
+	switch backend := cfg.Backend; backend {
+	case "file":
+		repo, err := file.Open(cfg.DataDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Folding the log into a snapshot on the way out makes the next start replay nothing.
+		return repo, func() error { return errors.Join(repo.Compact(), repo.Close()) }, nil
+	case "sqlite", "postgres":
+		dialect, _ := sqldb.DialectByName(backend)
+		if !slices.Contains(sql.Drivers(), dialect.DriverName) {
+			return nil, nil, fmt.Errorf("%s driver not linked: build with -tags %s", dialect.DriverName, backend)
+		}
+		dsn := cfg.DatabaseURL
+		if dsn == "" && backend == "sqlite" {
+			dsn = "file:pomelo.db?_txlock=immediate&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+		}
+		db, err := sql.Open(dialect.DriverName, dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		repo, err := sqldb.Open(context.Background(), db, dialect)
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		return repo, repo.Close, nil
+	case "memory":
+		return memory.NewRepository(), func() error { return nil }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage backend %q", backend)
 	}
 }
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	httpadapter "github.com/jailtonjunior/pomelo/internal/adapters/input/http"
+	"github.com/jailtonjunior/pomelo/internal/adapters/input/settlement"
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/file"
+	application "github.com/jailtonjunior/pomelo/internal/application"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/config"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// runReconcile implements the reconcile subcommand: it reconciles a settlement file against the
+// configured storage, prints the report as JSON on stdout and exits 1 when the report is not
+// clean.
+func runReconcile(args []string) int {
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	configPath := fs.String("config", os.Getenv("CONFIG_FILE"), "JSON or YAML config `file` of the storage; environment variables override it")
+	path := fs.String("file", "", "settlement `file` to reconcile; - reads stdin")
+	format := fs.String("format", settlement.FormatCSV, "settlement file `format`: csv or fixed")
+	date := fs.String("date", "", "UTC `day` (YYYY-MM-DD) the file covers")
+	from := fs.String("from", "", "RFC 3339 `time` the file coverage starts at, instead of -date")
+	to := fs.String("to", "", "RFC 3339 `time` the file coverage ends before, instead of -date")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s reconcile -file settlement [flags]\n\nFlags:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	cfg, err := config.Load(*configPath, os.Getenv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config:\n%v\n", err)
+		return 1
+	}
+	log := newLogger(cfg.Log)
+	if *path == "" {
+		log.Error("invalid reconcile flags", "err", "-file is required")
+		return 2
+	}
+	start, end, err := settlement.ParseWindow(*date, *from, *to)
+	if err != nil {
+		log.Error("invalid reconcile flags", "err", err)
+		return 2
+	}
+	records, err := readSettlementFile(*path, *format)
+	if err != nil {
+		log.Error("settlement file rejected", "file", *path, "err", err)
+		return 1
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	repo, closeRepo, err := openReconcileRepository(cfg.Storage)
+	if err != nil {
+		log.Error("storage init failed", "storage", cfg.Storage.Backend, "err", err)
+		return 1
+	}
+	defer closeRepo()
+	report, err := application.NewService(repo).ReconcileSettlement(ctx, ports.ReconcileSettlementCommand{Records: records, From: start, To: end})
+	if err != nil {
+		log.Error("reconciliation failed", "err", err)
+		return 1
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(httpadapter.NewSettlementReportDTO(report)); err != nil {
+		log.Error("report write failed", "err", err)
+		return 1
+	}
+	log.Info("reconciliation finished", "records", report.Records, "matched", report.Matched,
+		"amount_mismatched", report.AmountMismatched, "missing_in_webhooks", report.MissingInWebhooks,
+		"missing_in_settlement", report.MissingInSettlement)
+	if !report.Clean() {
+		return 1
+	}
+	return 0
+}
+
+func readSettlementFile(path, format string) ([]domain.SettlementRecord, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return settlement.Parse(r, format)
+}
+
+// openReconcileRepository opens the configured storage for reading. The file backend is closed
+// without the compaction the server does on the way out, so the files of a running server are
+// not rewritten under it; a torn log tail is still cut off on open, so prefer the HTTP endpoint
+// while the server is up.
+func openReconcileRepository(cfg config.Storage) (ports.TransactionRepository, func() error, error) {
+	switch cfg.Backend {
+	case "memory":
+		return nil, nil, errors.New("the memory backend keeps no transactions between runs; use POST /settlements/reconcile")
+	case "file":
+		repo, err := file.Open(cfg.DataDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		return repo, repo.Close, nil
+	}
+	return newRepository(cfg)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	httpadapter "github.com/jailtonjunior/pomelo/internal/adapters/input/http"
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/file"
+	application "github.com/jailtonjunior/pomelo/internal/application"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/config"
+)
+
+// runReplay implements the replay subcommand: it rebuilds the repository from the attempt log of
+// the configured storage into the storage named by its flags, and exits 1 when an accepted
+// attempt could not be replayed with its original outcome.
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	configPath := fs.String("config", os.Getenv("CONFIG_FILE"), "JSON or YAML config `file` of the source storage; environment variables override it")
+	var target config.Storage
+	fs.StringVar(&target.Backend, "to", "", "target storage `backend`: memory (dry run), file, sqlite or postgres")
+	fs.StringVar(&target.DataDir, "to-data-dir", "", "target `dir` for the file backend")
+	fs.StringVar(&target.DatabaseURL, "to-database-url", "", "target `dsn` for the sqlite and postgres backends")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s replay -to backend [flags]\n\nFlags:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	cfg, err := config.Load(*configPath, os.Getenv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config:\n%v\n", err)
+		return 1
+	}
+	log := newLogger(cfg.Log)
+	if err := validateReplayTarget(cfg.Storage, target); err != nil {
+		log.Error("invalid replay target", "err", err)
+		return 2
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	source, closeSource, err := openSourceLog(cfg.Storage)
+	if err != nil {
+		log.Error("attempt log init failed", "storage", cfg.Storage.Backend, "err", err)
+		return 1
+	}
+	defer closeSource()
+	repo, closeRepo, err := newRepository(target)
+	if err != nil {
+		log.Error("target storage init failed", "storage", target.Backend, "err", err)
+		return 1
+	}
+	defer func() {
+		if err := closeRepo(); err != nil {
+			log.Error("target storage flush failed", "err", err)
+		}
+	}()
+
+	var opts []application.Option
+	if limits := cfg.PurchaseLimits(); limits != nil {
+		opts = append(opts, application.WithPurchaseLimits(limits))
+	}
+	if currency := cfg.Settlement.Currency; currency != "" {
+		opts = append(opts, application.WithSettlementCurrency(currency))
+	}
+	if ttl := time.Duration(cfg.Pending.TTL); ttl > 0 {
+		pending, err := newPendingStore(target, repo)
+		if err != nil {
+			log.Error("pending adjustment store init failed", "err", err)
+			return 1
+		}
+		opts = append(opts, application.WithPendingAdjustments(pending, ttl))
+	}
+
+	report, err := application.Replay(ctx, source, repo, httpadapter.DecodeWebhook, opts...)
+	if err != nil {
+		log.Error("replay failed", "err", err)
+		return 1
+	}
+	copied, err := copyAttempts(ctx, source, target, repo)
+	if err != nil {
+		log.Error("attempt log copy failed", "err", err)
+		return 1
+	}
+	for _, d := range report.Diverged {
+		log.Warn("attempt diverged on replay", "attempt_id", d.AttemptID, "transaction_id", d.TransactionID, "outcome", d.Outcome, "reason", d.Reason)
+	}
+	log.Info("replay finished", "from", cfg.Storage.Backend, "to", target.Backend,
+		"attempts", report.Attempts, "replayed", report.Replayed, "skipped", report.Skipped,
+		"diverged", len(report.Diverged), "attempts_copied", copied)
+	if len(report.Diverged) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// validateReplayTarget refuses a missing target and one that is the source itself, which replay
+// would only find full of duplicates.
+func validateReplayTarget(source, target config.Storage) error {
+	switch target.Backend {
+	case "memory", "sqlite", "postgres":
+	case "file":
+		if target.DataDir == "" {
+			return errors.New("-to-data-dir is required with -to file")
+		}
+	case "":
+		return errors.New("-to is required")
+	default:
+		return fmt.Errorf("unknown storage backend %q", target.Backend)
+	}
+	if source.Backend != target.Backend {
+		return nil
+	}
+	same := target.DatabaseURL == source.DatabaseURL
+	if target.Backend == "file" {
+		src, _ := filepath.Abs(source.DataDir)
+		dst, _ := filepath.Abs(target.DataDir)
+		same = src == dst
+	}
+	if same {
+		return errors.New("the target is the source storage")
+	}
+	return nil
+}
+
+// openSourceLog opens the attempt log of the configured storage. With the file backend only the
+// log is opened, leaving the repository files of a possibly running server alone.
+func openSourceLog(cfg config.Storage) (ports.AttemptLog, func() error, error) {
+	switch cfg.Backend {
+	case "memory":
+		return nil, nil, errors.New("the memory backend keeps no attempt log between runs")
+	case "file":
+		log, err := file.OpenAttemptLog(cfg.DataDir)
+		return log, func() error { return nil }, err
+	}
+	repo, closeRepo, err := newRepository(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	log, ok := repo.(ports.AttemptLog)
+	if !ok {
+		closeRepo()
+		return nil, nil, fmt.Errorf("%s storage keeps no attempt log", cfg.Backend)
+	}
+	return log, closeRepo, nil
+}
+
+// copyAttempts gives the target the source's attempt log, so it can serve the history and be
+// replayed in turn. A target that already has attempts, from an earlier replay, is left alone.
+func copyAttempts(ctx context.Context, source ports.AttemptLog, cfg config.Storage, repo ports.TransactionRepository) (int, error) {
+	if cfg.Backend == "memory" {
+		return 0, nil
+	}
+	dst, err := newAttemptLog(cfg, repo)
+	if err != nil {
+		return 0, err
+	}
+	if existing, err := dst.AllAttempts(ctx); err != nil || len(existing) > 0 {
+		return 0, err
+	}
+	attempts, err := source.AllAttempts(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, a := range attempts {
+		if err := dst.AppendAttempt(ctx, a); err != nil {
+			return i, err
+		}
+	}
+	return len(attempts), nil
+}
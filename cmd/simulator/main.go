@@ -11,6 +11,10 @@ func main() {
 	if baseURL == "" {
 		baseURL = "http://localhost:8080"
 	}
-	server := mcp.NewServer(baseURL)
+	var opts []mcp.Option
+	if secret := os.Getenv("WEBHOOK_SECRET"); secret != "" {
+		opts = append(opts, mcp.WithSigningSecret(secret))
+	}
+	server := mcp.NewServer(baseURL, opts...)
 	server.Run()
 }
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func (h *Handler) handleOpenDispute(w http.ResponseWriter, r *http.Request) {
+	var dto OpenDisputeRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	d, err := h.disputes.OpenDispute(r.Context(), dto.ToCommand(r.PathValue("id")))
+	if err != nil {
+		h.writeDisputeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, NewDisputeDTO(d))
+}
+
+func (h *Handler) handleListDisputes(w http.ResponseWriter, r *http.Request) {
+	disputes, err := h.disputes.ListDisputes(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.writeDisputeError(w, r, err)
+		return
+	}
+	dtos := make([]DisputeDTO, len(disputes))
+	for i, d := range disputes {
+		dtos[i] = NewDisputeDTO(d)
+	}
+	writeJSON(w, http.StatusOK, dtos)
+}
+
+func (h *Handler) handleGetDispute(w http.ResponseWriter, r *http.Request) {
+	d, err := h.disputes.GetDispute(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.writeDisputeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, NewDisputeDTO(d))
+}
+
+func (h *Handler) handleTransitionDispute(w http.ResponseWriter, r *http.Request) {
+	var dto DisputeTransitionRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	d, err := h.disputes.TransitionDispute(r.Context(), dto.ToCommand(r.PathValue("id")))
+	if err != nil {
+		h.writeDisputeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, NewDisputeDTO(d))
+}
+
+// writeDisputeError maps the dispute errors and leaves the purchase and budget errors to
+// handleDomainError, so a dispute is refused with the same codes as an adjustment.
+func (h *Handler) writeDisputeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, domain.ErrDisputeNotFound):
+		writeError(w, http.StatusNotFound, err.Error(), "NOT_FOUND")
+	case errors.Is(err, domain.ErrInvalidDisputeTransition):
+		writeError(w, http.StatusConflict, err.Error(), "INVALID_DISPUTE_TRANSITION")
+	default:
+		h.handleDomainError(w, r, err, ports.ProcessTransactionResult{})
+	}
+}
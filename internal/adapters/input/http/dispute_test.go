@@ -0,0 +1,174 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// fakeDisputes serves a single dispute and fails opens with openErr.
+type fakeDisputes struct {
+	dispute domain.Dispute
+	opened  []ports.OpenDisputeCommand
+	openErr error
+}
+
+func (f *fakeDisputes) OpenDispute(_ context.Context, cmd ports.OpenDisputeCommand) (domain.Dispute, error) {
+	f.opened = append(f.opened, cmd)
+	if f.openErr != nil {
+		return domain.Dispute{}, f.openErr
+	}
+	return f.dispute, nil
+}
+
+func (f *fakeDisputes) TransitionDispute(ctx context.Context, cmd ports.TransitionDisputeCommand) (domain.Dispute, error) {
+	d, err := f.GetDispute(ctx, cmd.DisputeID)
+	if err != nil {
+		return d, err
+	}
+	return d.Transition(domain.DisputeState(cmd.State), cmd.Note, d.OpenedAt.Add(time.Hour))
+}
+
+func (f *fakeDisputes) GetDispute(_ context.Context, id string) (domain.Dispute, error) {
+	if id != f.dispute.ID {
+		return domain.Dispute{}, domain.ErrDisputeNotFound
+	}
+	return f.dispute, nil
+}
+
+func (f *fakeDisputes) ListDisputes(_ context.Context, txID string) ([]domain.Dispute, error) {
+	if txID != f.dispute.TransactionID {
+		return nil, domain.ErrTransactionNotFound
+	}
+	return []domain.Dispute{f.dispute}, nil
+}
+
+func newFakeDisputes(t *testing.T) *fakeDisputes {
+	t.Helper()
+	purchase := domain.Transaction{
+		ID: "tx1", Type: domain.TypePurchase, Status: domain.StatusApproved,
+		UserID: "u1", CardID: "c1", Merchant: domain.Merchant{ID: "m1"},
+		Amount: domain.AmountBreakdown{Local: domain.Money{Amount: 1000, Currency: "BRL"}},
+	}
+	openedAt := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	d, err := domain.OpenDispute("dsp1", purchase, domain.Money{Amount: 400, Currency: "BRL"}, "fraud", domain.Money{Currency: "BRL"}, openedAt)
+	if err != nil {
+		t.Fatalf("open dispute: %v", err)
+	}
+	return &fakeDisputes{dispute: d}
+}
+
+func serveRoute(h *Handler, method, target, body string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(method, target, bytes.NewBufferString(body)))
+	return rec
+}
+
+func TestOpenDispute(t *testing.T) {
+	disputes := newFakeDisputes(t)
+	h := NewHandler(&mockUseCase{}, WithDisputes(disputes))
+
+	rec := serveRoute(h, http.MethodPost, "/transactions/tx1/disputes", `{"amount":400,"reason":"fraud"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	want := ports.OpenDisputeCommand{TransactionID: "tx1", Amount: 400, Reason: "fraud"}
+	if len(disputes.opened) != 1 || disputes.opened[0] != want {
+		t.Errorf("expected %+v, got %+v", want, disputes.opened)
+	}
+	var dto DisputeDTO
+	json.NewDecoder(rec.Body).Decode(&dto)
+	if dto.ID != "dsp1" || dto.State != domain.DisputeOpened || dto.Amount != 400 || dto.ResolvedAt != nil || len(dto.History) != 1 {
+		t.Errorf("unexpected dispute: %+v", dto)
+	}
+}
+
+func TestOpenDisputeErrors(t *testing.T) {
+	cases := []struct {
+		name       string
+		body       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"bad body", `{"amount":`, nil, http.StatusBadRequest, "BAD_REQUEST"},
+		{"unknown purchase", `{"amount":1}`, domain.ErrTransactionNotFound, http.StatusNotFound, "NOT_FOUND"},
+		{"exceeds budget", `{"amount":1}`, domain.ErrExceedsOriginalAmount, http.StatusConflict, "EXCEEDS_ORIGINAL_AMOUNT"},
+		{"not a purchase", `{"amount":1}`, domain.ErrOriginalTypeMismatch, http.StatusUnprocessableEntity, "ORIGINAL_TYPE_MISMATCH"},
+		{"invalid input", `{"amount":1}`, domain.ErrInvalidInput, http.StatusBadRequest, "INVALID_INPUT"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			disputes := newFakeDisputes(t)
+			disputes.openErr = tc.err
+			rec := serveRoute(NewHandler(&mockUseCase{}, WithDisputes(disputes)), http.MethodPost, "/transactions/tx1/disputes", tc.body)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tc.wantStatus, rec.Code, rec.Body)
+			}
+			var body ErrorResponseDTO
+			json.NewDecoder(rec.Body).Decode(&body)
+			if body.Code != tc.wantCode {
+				t.Errorf("expected code %s, got %s", tc.wantCode, body.Code)
+			}
+		})
+	}
+}
+
+func TestTransitionDispute(t *testing.T) {
+	h := NewHandler(&mockUseCase{}, WithDisputes(newFakeDisputes(t)))
+
+	rec := serveRoute(h, http.MethodPost, "/disputes/dsp1/transitions", `{"state":"WON","note":"issuer accepted"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var dto DisputeDTO
+	json.NewDecoder(rec.Body).Decode(&dto)
+	if dto.State != domain.DisputeWon || dto.ResolvedAt == nil || len(dto.History) != 2 || dto.History[1].Note != "issuer accepted" {
+		t.Errorf("unexpected dispute: %+v", dto)
+	}
+
+	rec = serveRoute(h, http.MethodPost, "/disputes/dsp1/transitions", `{"state":"OPENED"}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for OPENED -> OPENED, got %d", rec.Code)
+	}
+	var body ErrorResponseDTO
+	json.NewDecoder(rec.Body).Decode(&body)
+	if body.Code != "INVALID_DISPUTE_TRANSITION" {
+		t.Errorf("expected INVALID_DISPUTE_TRANSITION, got %s", body.Code)
+	}
+}
+
+func TestGetAndListDisputes(t *testing.T) {
+	h := NewHandler(&mockUseCase{}, WithDisputes(newFakeDisputes(t)))
+
+	if rec := serveRoute(h, http.MethodGet, "/disputes/dsp1", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+	if rec := serveRoute(h, http.MethodGet, "/disputes/nope", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown dispute, got %d", rec.Code)
+	}
+	rec := serveRoute(h, http.MethodGet, "/transactions/tx1/disputes", "")
+	var dtos []DisputeDTO
+	json.NewDecoder(rec.Body).Decode(&dtos)
+	if rec.Code != http.StatusOK || len(dtos) != 1 || dtos[0].ID != "dsp1" {
+		t.Errorf("expected dsp1 listed, got %d %+v", rec.Code, dtos)
+	}
+	if rec := serveRoute(h, http.MethodGet, "/transactions/nope/disputes", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown purchase, got %d", rec.Code)
+	}
+}
+
+func TestDisputeRoutesRequireOption(t *testing.T) {
+	if rec := serveRoute(NewHandler(&mockUseCase{}), http.MethodGet, "/disputes/dsp1", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected the routes to be absent, got %d", rec.Code)
+	}
+}
@@ -1,10 +1,13 @@
 package http
 
 import (
+	"cmp"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
 )
 
 // WebhookRequestDTO mirrors the exact Pomelo webhook payload structure.
@@ -108,11 +111,553 @@ func (d *WebhookRequestDTO) ToCommand() (ports.ProcessTransactionCommand, error)
 type WebhookResponseDTO struct {
 	TransactionID string `json:"transaction_id"`
 	Idempotent    bool   `json:"idempotent"`
+	Parked        bool   `json:"parked,omitempty"`
 	Message       string `json:"message,omitempty"`
 }
 
+// TransactionDetailDTO is a purchase with the totals derived from its adjustments. The transaction
+// is embedded so its fields stay at the top level, as GET /transactions/{id} always returned them.
+type TransactionDetailDTO struct {
+	domain.Transaction
+	TotalReversed       domain.Money         `json:"total_reversed"`
+	TotalRefunded       domain.Money         `json:"total_refunded"`
+	TotalDisputed       domain.Money         `json:"total_disputed"`
+	RemainingRefundable domain.Money         `json:"remaining_refundable"`
+	State               domain.PurchaseState `json:"state"`
+	AdjustmentCount     int                  `json:"adjustment_count"`
+}
+
+func NewTransactionDetailDTO(s ports.TransactionSummary) TransactionDetailDTO {
+	return TransactionDetailDTO{
+		Transaction:         s.Transaction,
+		TotalReversed:       s.Balance.TotalReversed,
+		TotalRefunded:       s.Balance.TotalRefunded,
+		TotalDisputed:       s.Balance.TotalDisputed,
+		RemainingRefundable: s.Balance.Remaining,
+		State:               s.Balance.State,
+		AdjustmentCount:     len(s.Adjustments),
+	}
+}
+
+// PendingAdjustmentDTO is a parked adjustment awaiting manual review.
+type PendingAdjustmentDTO struct {
+	State      domain.PendingState `json:"state"`
+	Reason     string              `json:"reason,omitempty"`
+	ParkedAt   time.Time           `json:"parked_at"`
+	ExpiresAt  time.Time           `json:"expires_at"`
+	Adjustment domain.Adjustment   `json:"adjustment"`
+}
+
+func NewPendingAdjustmentDTO(p domain.PendingAdjustment) PendingAdjustmentDTO {
+	return PendingAdjustmentDTO{
+		// Entries under review are rejected or past their deadline, so evaluating the state at
+		// ExpiresAt yields the right one without depending on the server clock.
+		State:      p.State(p.ExpiresAt),
+		Reason:     p.Reason,
+		ParkedAt:   p.ParkedAt,
+		ExpiresAt:  p.ExpiresAt,
+		Adjustment: p.Adjustment,
+	}
+}
+
+// BalanceDTO is a net position in one currency, in cents. Net is debits minus credits: for a card
+// or user, what was spent net of reversals, refunds and credits.
+type BalanceDTO struct {
+	Currency string `json:"currency"`
+	Debits   int64  `json:"debits"`
+	Credits  int64  `json:"credits"`
+	Net      int64  `json:"net"`
+}
+
+func NewBalanceDTOs(balances []domain.AccountBalance) []BalanceDTO {
+	out := make([]BalanceDTO, len(balances))
+	for i, b := range balances {
+		out[i] = BalanceDTO{Currency: b.Currency, Debits: b.Debits, Credits: b.Credits, Net: b.Net}
+	}
+	return out
+}
+
+type UserBalanceDTO struct {
+	UserID   string       `json:"user_id"`
+	Balances []BalanceDTO `json:"balances"`
+}
+
+// LedgerEntryDTO is one ledger entry of a card account.
+type LedgerEntryDTO struct {
+	PostingID      string                 `json:"posting_id"`
+	TransactionID  string                 `json:"transaction_id"`
+	Type           domain.TransactionType `json:"type"`
+	Account        string                 `json:"account"`
+	Side           domain.Direction       `json:"side"`
+	Amount         int64                  `json:"amount"`
+	Currency       string                 `json:"currency"`
+	PostedAt       time.Time              `json:"posted_at"`
+	RunningBalance int64                  `json:"running_balance"`
+}
+
+type CardLedgerDTO struct {
+	CardID   string           `json:"card_id"`
+	Balances []BalanceDTO     `json:"balances"`
+	Entries  []LedgerEntryDTO `json:"entries"`
+}
+
+func NewCardLedgerDTO(l ports.CardLedger) CardLedgerDTO {
+	entries := make([]LedgerEntryDTO, len(l.Entries))
+	for i, e := range l.Entries {
+		entries[i] = LedgerEntryDTO{
+			PostingID:      e.PostingID,
+			TransactionID:  e.TransactionID,
+			Type:           e.Type,
+			Account:        e.Account,
+			Side:           e.Side,
+			Amount:         e.Amount.Amount,
+			Currency:       e.Amount.Currency,
+			PostedAt:       e.PostedAt,
+			RunningBalance: e.RunningBalance,
+		}
+	}
+	return CardLedgerDTO{CardID: l.CardID, Balances: NewBalanceDTOs(l.Balances), Entries: entries}
+}
+
+// LedgerVerificationDTO reports whether the ledger sums to zero, with the totals per currency.
+type LedgerVerificationDTO struct {
+	Balanced bool         `json:"balanced"`
+	Totals   []BalanceDTO `json:"totals"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// AuthorizationRequestDTO mirrors the subset of Pomelo's authorizer request the decision needs.
+type AuthorizationRequestDTO struct {
+	Transaction struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	} `json:"transaction"`
+	Merchant struct {
+		ID   string `json:"id"`
+		MCC  string `json:"mcc"`
+		Name string `json:"name"`
+	} `json:"merchant"`
+	Card struct {
+		ID string `json:"id"`
+	} `json:"card"`
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	Amount struct {
+		Local struct {
+			Total    int64  `json:"total"`
+			Currency string `json:"currency"`
+		} `json:"local"`
+	} `json:"amount"`
+}
+
+func (d *AuthorizationRequestDTO) ToCommand() (ports.AuthorizeCommand, error) {
+	if d.Transaction.ID == "" {
+		return ports.AuthorizeCommand{}, fmt.Errorf("transaction.id is required")
+	}
+	if d.Transaction.Type == "" {
+		return ports.AuthorizeCommand{}, fmt.Errorf("transaction.type is required")
+	}
+	if d.Card.ID == "" {
+		return ports.AuthorizeCommand{}, fmt.Errorf("card.id is required")
+	}
+	if d.Amount.Local.Currency == "" {
+		return ports.AuthorizeCommand{}, fmt.Errorf("amount.local.currency is required")
+	}
+	return ports.AuthorizeCommand{
+		TransactionID:   d.Transaction.ID,
+		TransactionType: d.Transaction.Type,
+		Amount:          d.Amount.Local.Total,
+		Currency:        d.Amount.Local.Currency,
+		CardID:          d.Card.ID,
+		UserID:          d.User.ID,
+		MerchantID:      d.Merchant.ID,
+		MerchantMCC:     d.Merchant.MCC,
+		MerchantName:    d.Merchant.Name,
+	}, nil
+}
+
+// AuthorizationResponseDTO is the authorizer answer: status is APPROVED or REJECTED and
+// status_detail the reason.
+type AuthorizationResponseDTO struct {
+	TransactionID string                     `json:"transaction_id"`
+	Status        domain.TransactionStatus   `json:"status"`
+	StatusDetail  domain.AuthorizationReason `json:"status_detail"`
+	Idempotent    bool                       `json:"idempotent"`
+}
+
+// AuthorizationDTO is a recorded decision and, once the webhook arrived, how it matched.
+type AuthorizationDTO struct {
+	TransactionID string                     `json:"transaction_id"`
+	Type          domain.TransactionType     `json:"type"`
+	CardID        string                     `json:"card_id"`
+	UserID        string                     `json:"user_id"`
+	MerchantID    string                     `json:"merchant_id"`
+	Amount        int64                      `json:"amount"`
+	Currency      string                     `json:"currency"`
+	Status        domain.TransactionStatus   `json:"status"`
+	StatusDetail  domain.AuthorizationReason `json:"status_detail"`
+	DecidedAt     time.Time                  `json:"decided_at"`
+	HoldExpiresAt *time.Time                 `json:"hold_expires_at,omitempty"`
+	Match         *AuthorizationMatchDTO     `json:"match,omitempty"`
+}
+
+type AuthorizationMatchDTO struct {
+	Status        domain.TransactionStatus `json:"status"`
+	Amount        int64                    `json:"amount"`
+	Currency      string                   `json:"currency"`
+	MatchedAt     time.Time                `json:"matched_at"`
+	Consistent    bool                     `json:"consistent"`
+	Discrepancies []string                 `json:"discrepancies,omitempty"`
+}
+
+func NewAuthorizationDTO(a domain.Authorization) AuthorizationDTO {
+	dto := AuthorizationDTO{
+		TransactionID: a.Request.ID,
+		Type:          a.Request.Type,
+		CardID:        a.Request.CardID,
+		UserID:        a.Request.UserID,
+		MerchantID:    a.Request.Merchant.ID,
+		Amount:        a.Request.Amount.Amount,
+		Currency:      a.Request.Amount.Currency,
+		Status:        a.Decision,
+		StatusDetail:  a.Reason,
+		DecidedAt:     a.DecidedAt,
+	}
+	if !a.HoldExpiresAt.IsZero() {
+		dto.HoldExpiresAt = &a.HoldExpiresAt
+	}
+	if m := a.Match; m != nil {
+		dto.Match = &AuthorizationMatchDTO{
+			Status:        m.Status,
+			Amount:        m.Amount.Amount,
+			Currency:      m.Amount.Currency,
+			MatchedAt:     m.MatchedAt,
+			Consistent:    len(m.Discrepancies) == 0,
+			Discrepancies: m.Discrepancies,
+		}
+	}
+	return dto
+}
+
+// CardDTO is a card profile. Amounts are in cents; zero limits are disabled.
+type CardDTO struct {
+	CardID           string            `json:"card_id"`
+	UserID           string            `json:"user_id"`
+	Status           domain.CardStatus `json:"status"`
+	Currency         string            `json:"currency"`
+	Balance          int64             `json:"balance"`
+	TransactionLimit int64             `json:"transaction_limit"`
+	DailyLimit       int64             `json:"daily_limit"`
+}
+
+func NewCardDTO(c domain.Card) CardDTO {
+	return CardDTO{
+		CardID:           c.ID,
+		UserID:           c.UserID,
+		Status:           c.Status,
+		Currency:         c.Currency,
+		Balance:          c.Balance,
+		TransactionLimit: c.TransactionLimit,
+		DailyLimit:       c.DailyLimit,
+	}
+}
+
+// ToCommand builds the save command for the card in the request path; the body's card_id is
+// ignored and a missing status means ACTIVE.
+func (d CardDTO) ToCommand(cardID string) ports.SaveCardCommand {
+	return ports.SaveCardCommand{
+		CardID:           cardID,
+		UserID:           d.UserID,
+		Status:           string(cmp.Or(d.Status, domain.CardActive)),
+		Currency:         d.Currency,
+		Balance:          d.Balance,
+		TransactionLimit: d.TransactionLimit,
+		DailyLimit:       d.DailyLimit,
+	}
+}
+
+// DeadLetterDTO is an outbox event a subscriber did not acknowledge within the retry budget.
+type DeadLetterDTO struct {
+	EventID    string             `json:"event_id"`
+	EventType  string             `json:"event_type"`
+	CardID     string             `json:"card_id"`
+	Subscriber string             `json:"subscriber"`
+	Attempts   int                `json:"attempts"`
+	LastError  string             `json:"last_error"`
+	FailedAt   time.Time          `json:"failed_at"`
+	Event      domain.OutboxEvent `json:"event"`
+}
+
+func NewDeadLetterDTO(dl domain.DeadLetter) DeadLetterDTO {
+	return DeadLetterDTO{
+		EventID:    dl.Event.ID,
+		EventType:  string(dl.Event.Type),
+		CardID:     dl.Event.CardID,
+		Subscriber: dl.Subscriber,
+		Attempts:   dl.Attempts,
+		LastError:  dl.LastError,
+		FailedAt:   dl.FailedAt,
+		Event:      dl.Event,
+	}
+}
+
+// SubscriptionRequestDTO registers a partner endpoint. Empty types or statuses match every event.
+type SubscriptionRequestDTO struct {
+	URL      string   `json:"url"`
+	Types    []string `json:"types"`
+	Statuses []string `json:"statuses"`
+	Secret   string   `json:"secret"`
+}
+
+func (d SubscriptionRequestDTO) ToCommand() ports.CreateSubscriptionCommand {
+	return ports.CreateSubscriptionCommand{URL: d.URL, Types: d.Types, Statuses: d.Statuses, Secret: d.Secret}
+}
+
+// SubscriptionDTO is a registered subscription. The secret is never returned.
+type SubscriptionDTO struct {
+	ID        string                     `json:"id"`
+	URL       string                     `json:"url"`
+	Types     []domain.TransactionType   `json:"types"`
+	Statuses  []domain.TransactionStatus `json:"statuses"`
+	CreatedAt time.Time                  `json:"created_at"`
+}
+
+func NewSubscriptionDTO(s domain.Subscription) SubscriptionDTO {
+	return SubscriptionDTO{
+		ID:        s.ID,
+		URL:       s.URL,
+		Types:     append([]domain.TransactionType{}, s.Types...),
+		Statuses:  append([]domain.TransactionStatus{}, s.Statuses...),
+		CreatedAt: s.CreatedAt,
+	}
+}
+
+// DeliveryDTO is one attempt to deliver an event to a subscription.
+type DeliveryDTO struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	EventID        string    `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	Attempt        int       `json:"attempt"`
+	Manual         bool      `json:"manual"`
+	Succeeded      bool      `json:"succeeded"`
+	ResponseStatus int       `json:"response_status,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	AttemptedAt    time.Time `json:"attempted_at"`
+	DurationMS     int64     `json:"duration_ms"`
+}
+
+func NewDeliveryDTO(d domain.Delivery) DeliveryDTO {
+	return DeliveryDTO{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.Event.ID,
+		EventType:      string(d.Event.Type),
+		Attempt:        d.Attempt,
+		Manual:         d.Manual,
+		Succeeded:      d.Succeeded(),
+		ResponseStatus: d.ResponseStatus,
+		Error:          d.Error,
+		AttemptedAt:    d.AttemptedAt,
+		DurationMS:     d.Duration.Milliseconds(),
+	}
+}
+
+// WebhookAttemptDTO is one webhook attempt in a transaction's history, with the payload as it
+// was received.
+type WebhookAttemptDTO struct {
+	ID             string          `json:"id"`
+	TransactionID  string          `json:"transaction_id"`
+	IdempotencyKey string          `json:"idempotency_key"`
+	ReceivedAt     time.Time       `json:"received_at"`
+	Outcome        string          `json:"outcome"`
+	StatusCode     int             `json:"status_code"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+func NewWebhookAttemptDTO(a domain.WebhookAttempt) WebhookAttemptDTO {
+	payload := json.RawMessage(a.Payload)
+	if !json.Valid(payload) {
+		// Only bodies that decoded are recorded, but a log written by another adapter may differ.
+		payload, _ = json.Marshal(string(a.Payload))
+	}
+	return WebhookAttemptDTO{
+		ID:             a.ID,
+		TransactionID:  a.TransactionID,
+		IdempotencyKey: a.IdempotencyKey,
+		ReceivedAt:     a.ReceivedAt,
+		Outcome:        a.Outcome,
+		StatusCode:     a.StatusCode,
+		Payload:        payload,
+	}
+}
+
+// OpenDisputeRequestDTO disputes amount of a purchase, in minor units. An empty currency is the
+// purchase's local currency.
+type OpenDisputeRequestDTO struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Reason   string `json:"reason"`
+}
+
+func (d OpenDisputeRequestDTO) ToCommand(transactionID string) ports.OpenDisputeCommand {
+	return ports.OpenDisputeCommand{TransactionID: transactionID, Amount: d.Amount, Currency: d.Currency, Reason: d.Reason}
+}
+
+// DisputeTransitionRequestDTO moves a dispute to state.
+type DisputeTransitionRequestDTO struct {
+	State string `json:"state"`
+	Note  string `json:"note"`
+}
+
+func (d DisputeTransitionRequestDTO) ToCommand(disputeID string) ports.TransitionDisputeCommand {
+	return ports.TransitionDisputeCommand{DisputeID: disputeID, State: d.State, Note: d.Note}
+}
+
+// DisputeDTO is a cardholder dispute with its history.
+type DisputeDTO struct {
+	ID            string                 `json:"id"`
+	TransactionID string                 `json:"transaction_id"`
+	UserID        string                 `json:"user_id"`
+	CardID        string                 `json:"card_id"`
+	MerchantID    string                 `json:"merchant_id"`
+	Amount        int64                  `json:"amount"`
+	Currency      string                 `json:"currency"`
+	Reason        string                 `json:"reason"`
+	State         domain.DisputeState    `json:"state"`
+	OpenedAt      time.Time              `json:"opened_at"`
+	ResolvedAt    *time.Time             `json:"resolved_at,omitempty"`
+	History       []DisputeTransitionDTO `json:"history"`
+}
+
+type DisputeTransitionDTO struct {
+	From domain.DisputeState `json:"from,omitempty"`
+	To   domain.DisputeState `json:"to"`
+	At   time.Time           `json:"at"`
+	Note string              `json:"note,omitempty"`
+}
+
+func NewDisputeDTO(d domain.Dispute) DisputeDTO {
+	dto := DisputeDTO{
+		ID:            d.ID,
+		TransactionID: d.TransactionID,
+		UserID:        d.UserID,
+		CardID:        d.CardID,
+		MerchantID:    d.MerchantID,
+		Amount:        d.Amount.Amount,
+		Currency:      d.Amount.Currency,
+		Reason:        d.Reason,
+		State:         d.State,
+		OpenedAt:      d.OpenedAt,
+		History:       make([]DisputeTransitionDTO, len(d.History)),
+	}
+	if !d.ResolvedAt.IsZero() {
+		dto.ResolvedAt = &d.ResolvedAt
+	}
+	for i, h := range d.History {
+		dto.History[i] = DisputeTransitionDTO{From: h.From, To: h.To, At: h.At, Note: h.Note}
+	}
+	return dto
+}
+
+// SettlementReportDTO is the outcome of reconciling a settlement file.
+type SettlementReportDTO struct {
+	Records             int                     `json:"records"`
+	Matched             int                     `json:"matched"`
+	AmountMismatched    int                     `json:"amount_mismatched"`
+	MissingInWebhooks   int                     `json:"missing_in_webhooks"`
+	MissingInSettlement int                     `json:"missing_in_settlement"`
+	Clean               bool                    `json:"clean"`
+	Items               []ReconciliationItemDTO `json:"items"`
+}
+
+// ReconciliationItemDTO omits expected for a row without a webhook, and line and settled for a
+// transaction without a row.
+type ReconciliationItemDTO struct {
+	TransactionID string                      `json:"transaction_id"`
+	Status        domain.ReconciliationStatus `json:"status"`
+	Type          domain.TransactionType      `json:"type,omitempty"`
+	Line          int                         `json:"line,omitempty"`
+	Expected      *domain.Money               `json:"expected,omitempty"`
+	Settled       *domain.Money               `json:"settled,omitempty"`
+}
+
+func NewSettlementReportDTO(r domain.SettlementReport) SettlementReportDTO {
+	dto := SettlementReportDTO{
+		Records:             r.Records,
+		Matched:             r.Matched,
+		AmountMismatched:    r.AmountMismatched,
+		MissingInWebhooks:   r.MissingInWebhooks,
+		MissingInSettlement: r.MissingInSettlement,
+		Clean:               r.Clean(),
+		Items:               make([]ReconciliationItemDTO, len(r.Items)),
+	}
+	for i, item := range r.Items {
+		dto.Items[i] = ReconciliationItemDTO{TransactionID: item.TransactionID, Status: item.Status, Type: item.Type, Line: item.Line}
+		if item.Status != domain.ReconciledMissingInWebhooks {
+			dto.Items[i].Expected = &item.Expected
+		}
+		if item.Status != domain.ReconciledMissingInSettlement {
+			dto.Items[i].Settled = &item.Settled
+		}
+	}
+	return dto
+}
+
+// RejectedWebhookDTO is a webhook kept in the dead-letter store after a 404, 409 or 422, with
+// the payload as it was received.
+type RejectedWebhookDTO struct {
+	ID                 string          `json:"id"`
+	TransactionID      string          `json:"transaction_id"`
+	IdempotencyKey     string          `json:"idempotency_key"`
+	StatusCode         int             `json:"status_code"`
+	Code               string          `json:"code"`
+	Error              string          `json:"error"`
+	FirstRejectedAt    time.Time       `json:"first_rejected_at"`
+	LastRejectedAt     time.Time       `json:"last_rejected_at"`
+	Rejections         int             `json:"rejections"`
+	Reprocesses        int             `json:"reprocesses"`
+	LastReprocessedAt  *time.Time      `json:"last_reprocessed_at,omitempty"`
+	LastReprocessError string          `json:"last_reprocess_error,omitempty"`
+	Resolved           bool            `json:"resolved"`
+	ResolvedAt         *time.Time      `json:"resolved_at,omitempty"`
+	Payload            json.RawMessage `json:"payload"`
+}
+
+func NewRejectedWebhookDTO(rw domain.RejectedWebhook) RejectedWebhookDTO {
+	payload := json.RawMessage(rw.Payload)
+	if !json.Valid(payload) {
+		payload, _ = json.Marshal(string(rw.Payload))
+	}
+	dto := RejectedWebhookDTO{
+		ID:                 rw.ID,
+		TransactionID:      rw.TransactionID,
+		IdempotencyKey:     rw.IdempotencyKey,
+		StatusCode:         rw.StatusCode,
+		Code:               rw.Code,
+		Error:              rw.Error,
+		FirstRejectedAt:    rw.FirstRejectedAt,
+		LastRejectedAt:     rw.LastRejectedAt,
+		Rejections:         rw.Rejections,
+		Reprocesses:        rw.Reprocesses,
+		LastReprocessError: rw.LastReprocessError,
+		Resolved:           rw.Resolved(),
+		Payload:            payload,
+	}
+	if !rw.LastReprocessedAt.IsZero() {
+		dto.LastReprocessedAt = &rw.LastReprocessedAt
+	}
+	if rw.Resolved() {
+		dto.ResolvedAt = &rw.ResolvedAt
+	}
+	return dto
+}
+
 // ErrorResponseDTO is the error response.
 type ErrorResponseDTO struct {
 	Error string `json:"error"`
 	Code  string `json:"code"`
+	// Field is the request field at fault, for errors about a single field.
+	Field string `json:"field,omitempty"`
 }
@@ -1,50 +1,314 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/jailtonjunior/pomelo/internal/application/ports"
 	"github.com/jailtonjunior/pomelo/internal/domain"
+	"github.com/jailtonjunior/pomelo/internal/health"
+	"github.com/jailtonjunior/pomelo/internal/logging"
+	"github.com/jailtonjunior/pomelo/internal/metrics"
+	"github.com/jailtonjunior/pomelo/internal/tracing"
 )
 
 // Handler wires HTTP routes to the use case.
 type Handler struct {
-	useCase ports.WebhookUseCase
+	useCase        ports.WebhookUseCase
+	verifier       *SignatureVerifier
+	pending        ports.PendingAdjustmentUseCase
+	ledger         ports.LedgerUseCase
+	authorizations ports.AuthorizationUseCase
+	authDeadline   time.Duration
+	outbox         ports.OutboxUseCase
+	subscriptions  ports.SubscriptionUseCase
+	idempotency    ports.IdempotencyUseCase
+	history        ports.HistoryUseCase
+	rejections     ports.RejectionUseCase
+	disputes       ports.DisputeUseCase
+	settlement     ports.SettlementUseCase
+	registry       *metrics.Registry
+	health         *health.Registry
+	webhookMetrics *webhookMetrics
+	tracer         *tracing.Tracer
+	logger         *slog.Logger
+	maxBodyBytes   int64
+	draining       atomic.Bool
 }
 
-func NewHandler(useCase ports.WebhookUseCase) *Handler {
-	return &Handler{useCase: useCase}
+// HandlerOption configures optional Handler behaviour.
+type HandlerOption func(*Handler)
+
+// WithSignatureVerifier requires a valid Pomelo HMAC signature on POST /webhook/transactions.
+func WithSignatureVerifier(v *SignatureVerifier) HandlerOption {
+	return func(h *Handler) { h.verifier = v }
+}
+
+// WithPendingAdjustmentReview exposes GET /adjustments/review for parked adjustments that
+// expired or failed validation.
+func WithPendingAdjustmentReview(uc ports.PendingAdjustmentUseCase) HandlerOption {
+	return func(h *Handler) { h.pending = uc }
+}
+
+// WithLedger exposes GET /users/{id}/balance, GET /cards/{id}/ledger and GET /ledger/verify.
+func WithLedger(uc ports.LedgerUseCase) HandlerOption {
+	return func(h *Handler) { h.ledger = uc }
+}
+
+// WithAuthorizations exposes POST /transactions/authorizations, GET /authorizations/{id} and
+// PUT and GET /cards/{id}. A decision not taken within deadline is a PROCESSING_TIMEOUT rejection.
+func WithAuthorizations(uc ports.AuthorizationUseCase, deadline time.Duration) HandlerOption {
+	return func(h *Handler) {
+		h.authorizations = uc
+		h.authDeadline = deadline
+	}
+}
+
+// WithOutbox exposes GET /outbox/dead-letters for events subscribers never acknowledged.
+func WithOutbox(uc ports.OutboxUseCase) HandlerOption {
+	return func(h *Handler) { h.outbox = uc }
+}
+
+// WithIdempotencyRecords replays the recorded response to webhooks retried with the same
+// idempotency key and payload, and rejects a key reused with a different payload.
+func WithIdempotencyRecords(uc ports.IdempotencyUseCase) HandlerOption {
+	return func(h *Handler) { h.idempotency = uc }
+}
+
+// WithHistory records every decoded webhook, with its raw payload and outcome, and exposes
+// GET /transactions/{id}/events.
+func WithHistory(uc ports.HistoryUseCase) HandlerOption {
+	return func(h *Handler) { h.history = uc }
+}
+
+// WithRejectedWebhooks keeps webhooks answered with 404, 409 or 422 in a dead-letter store and
+// exposes GET /webhooks/rejected, GET /webhooks/rejected/{id} and
+// POST /webhooks/rejected/{id}/reprocess.
+func WithRejectedWebhooks(uc ports.RejectionUseCase) HandlerOption {
+	return func(h *Handler) { h.rejections = uc }
+}
+
+// WithDisputes exposes POST and GET /transactions/{id}/disputes, GET /disputes/{id} and
+// POST /disputes/{id}/transitions.
+func WithDisputes(uc ports.DisputeUseCase) HandlerOption {
+	return func(h *Handler) { h.disputes = uc }
+}
+
+// WithSettlement exposes POST /settlements/reconcile.
+func WithSettlement(uc ports.SettlementUseCase) HandlerOption {
+	return func(h *Handler) { h.settlement = uc }
+}
+
+// WithSubscriptions exposes the /subscriptions routes: registration of partner endpoints, their
+// delivery log and manual redelivery.
+func WithSubscriptions(uc ports.SubscriptionUseCase) HandlerOption {
+	return func(h *Handler) { h.subscriptions = uc }
+}
+
+// WithMetrics records webhook outcomes and latency in registry and serves the registry on
+// GET /metrics in the Prometheus text format.
+func WithMetrics(registry *metrics.Registry) HandlerOption {
+	return func(h *Handler) {
+		h.registry = registry
+		h.webhookMetrics = newWebhookMetrics(registry)
+	}
+}
+
+// WithTracer records a span for every webhook, continuing the trace of an incoming W3C
+// traceparent header, and returns the span's traceparent in the response.
+func WithTracer(t *tracing.Tracer) HandlerOption {
+	return func(h *Handler) { h.tracer = t }
+}
+
+// WithLogger logs every request through log and hands the use cases a logger carrying the
+// request ID, returned in the X-Request-Id response header.
+func WithLogger(log *slog.Logger) HandlerOption {
+	return func(h *Handler) { h.logger = log }
+}
+
+// WithMaxBodyBytes rejects request bodies larger than n bytes with 413 PAYLOAD_TOO_LARGE.
+func WithMaxBodyBytes(n int64) HandlerOption {
+	return func(h *Handler) { h.maxBodyBytes = n }
+}
+
+// WithHealthChecks runs the checks in r on GET /health/live and GET /health/ready. Without it both
+// report healthy with no checks.
+func WithHealthChecks(r *health.Registry) HandlerOption {
+	return func(h *Handler) { h.health = r }
+}
+
+func NewHandler(useCase ports.WebhookUseCase, opts ...HandlerOption) *Handler {
+	h := &Handler{useCase: useCase}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // RegisterRoutes attaches all routes to the given mux.
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("POST /webhook/transactions", h.handleWebhook)
-	mux.HandleFunc("GET /transactions/{id}", h.handleGetTransaction)
-	mux.HandleFunc("GET /transactions", h.handleListTransactions)
-	mux.HandleFunc("GET /health", h.handleHealth)
+	var webhook http.Handler = http.HandlerFunc(h.handleWebhook)
+	if h.verifier != nil {
+		webhook = h.verifier.Middleware(webhook)
+	}
+	if h.webhookMetrics != nil {
+		webhook = h.webhookMetrics.middleware(webhook)
+	}
+	h.handle(mux, "POST /webhook/transactions", webhook)
+	h.handle(mux, "GET /transactions/{id}", http.HandlerFunc(h.handleGetTransaction))
+	h.handle(mux, "GET /transactions/{id}/adjustments", http.HandlerFunc(h.handleGetTransactionAdjustments))
+	h.handle(mux, "GET /transactions", http.HandlerFunc(h.handleListTransactions))
+	h.handle(mux, "GET /health", http.HandlerFunc(h.handleHealth))
+	h.handle(mux, "GET /health/live", http.HandlerFunc(h.handleLive))
+	h.handle(mux, "GET /health/ready", http.HandlerFunc(h.handleReady))
+	if h.registry != nil {
+		h.handle(mux, "GET /metrics", h.registry)
+	}
+	if h.history != nil {
+		h.handle(mux, "GET /transactions/{id}/events", http.HandlerFunc(h.handleListTransactionEvents))
+	}
+	if h.rejections != nil {
+		h.handle(mux, "GET /webhooks/rejected", http.HandlerFunc(h.handleListRejections))
+		h.handle(mux, "GET /webhooks/rejected/{id}", http.HandlerFunc(h.handleGetRejection))
+		h.handle(mux, "POST /webhooks/rejected/{id}/reprocess", http.HandlerFunc(h.handleReprocessRejection))
+	}
+	if h.disputes != nil {
+		h.handle(mux, "POST /transactions/{id}/disputes", http.HandlerFunc(h.handleOpenDispute))
+		h.handle(mux, "GET /transactions/{id}/disputes", http.HandlerFunc(h.handleListDisputes))
+		h.handle(mux, "GET /disputes/{id}", http.HandlerFunc(h.handleGetDispute))
+		h.handle(mux, "POST /disputes/{id}/transitions", http.HandlerFunc(h.handleTransitionDispute))
+	}
+	if h.settlement != nil {
+		h.handle(mux, "POST /settlements/reconcile", http.HandlerFunc(h.handleReconcileSettlement))
+	}
+	if h.pending != nil {
+		h.handle(mux, "GET /adjustments/review", http.HandlerFunc(h.handleListAdjustmentsForReview))
+	}
+	if h.ledger != nil {
+		h.handle(mux, "GET /users/{id}/balance", http.HandlerFunc(h.handleGetUserBalance))
+		h.handle(mux, "GET /cards/{id}/ledger", http.HandlerFunc(h.handleGetCardLedger))
+		h.handle(mux, "GET /ledger/verify", http.HandlerFunc(h.handleVerifyLedger))
+	}
+	if h.authorizations != nil {
+		h.handle(mux, "POST /transactions/authorizations", http.HandlerFunc(h.handleAuthorize))
+		h.handle(mux, "GET /authorizations/{id}", http.HandlerFunc(h.handleGetAuthorization))
+		h.handle(mux, "PUT /cards/{id}", http.HandlerFunc(h.handleSaveCard))
+		h.handle(mux, "GET /cards/{id}", http.HandlerFunc(h.handleGetCard))
+	}
+	if h.outbox != nil {
+		h.handle(mux, "GET /outbox/dead-letters", http.HandlerFunc(h.handleListDeadLetters))
+	}
+	if h.subscriptions != nil {
+		h.handle(mux, "POST /subscriptions", http.HandlerFunc(h.handleCreateSubscription))
+		h.handle(mux, "GET /subscriptions", http.HandlerFunc(h.handleListSubscriptions))
+		h.handle(mux, "GET /subscriptions/{id}", http.HandlerFunc(h.handleGetSubscription))
+		h.handle(mux, "DELETE /subscriptions/{id}", http.HandlerFunc(h.handleDeleteSubscription))
+		h.handle(mux, "GET /subscriptions/{id}/deliveries", http.HandlerFunc(h.handleListDeliveries))
+		h.handle(mux, "POST /subscriptions/{id}/deliveries/{delivery_id}/redeliver", http.HandlerFunc(h.handleRedeliver))
+	}
+}
+
+// Drain makes GET /health and GET /health/ready answer 503 so load balancers stop routing new requests here while the
+// server shuts down. It cannot be undone.
+func (h *Handler) Drain() {
+	h.draining.Store(true)
+}
+
+// handle registers handler on mux for pattern, limiting its body and logging its requests when
+// configured.
+func (h *Handler) handle(mux *http.ServeMux, pattern string, handler http.Handler) {
+	if h.maxBodyBytes > 0 {
+		handler = http.MaxBytesHandler(handler, h.maxBodyBytes)
+	}
+	if h.logger != nil {
+		handler = h.logRequests(handler)
+	}
+	mux.Handle(pattern, handler)
 }
 
 func (h *Handler) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(tracing.Extract(r.Context(), r.Header), "Handler.handleWebhook")
+	defer span.End()
+	if span != nil {
+		tracing.Inject(ctx, w.Header())
+		ctx = logging.With(ctx, "trace_id", span.SpanContext().TraceID.String())
+	}
+
+	receivedAt := time.Now()
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		span.RecordError(err)
+		writeBodyError(w, err)
+		return
+	}
 	var dto WebhookRequestDTO
-	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body", "BAD_REQUEST")
+	if err := json.Unmarshal(payload, &dto); err != nil {
+		span.RecordError(err)
+		writeBodyError(w, err)
 		return
 	}
+	recordTransaction(w, dto.ID, dto.Type, dto.Status)
+	span.SetAttributes(
+		tracing.String("transaction.id", dto.ID),
+		tracing.String("transaction.type", dto.Type),
+		tracing.String("transaction.idempotency_key", dto.Event.IdempotencyKey),
+	)
+
+	if h.history != nil {
+		rec := newResponseRecorder(w)
+		defer h.recordAttempt(ctx, rec, &dto, receivedAt, payload)
+		w = rec
+	}
+
+	if key := dto.Event.IdempotencyKey; h.idempotency != nil && key != "" {
+		payloadHash := dto.payloadHash()
+		if h.replayResponse(w, r.WithContext(ctx), key, payloadHash) {
+			span.SetAttributes(tracing.Bool("transaction.replayed", true))
+			return
+		}
+		capture := &responseCapture{ResponseWriter: w, status: http.StatusOK}
+		defer h.rememberResponse(ctx, capture, key, payloadHash)
+		w = capture
+	}
 
 	cmd, err := dto.ToCommand()
 	if err != nil {
+		span.RecordError(err)
 		writeError(w, http.StatusBadRequest, err.Error(), "VALIDATION_ERROR")
 		return
 	}
 
-	result, err := h.useCase.ProcessTransaction(r.Context(), cmd)
+	result, err := h.useCase.ProcessTransaction(ctx, cmd)
+	if h.rejections != nil {
+		rec := newResponseRecorder(w)
+		defer h.trackRejection(ctx, rec, &dto, payload, err)
+		w = rec
+	}
 	if err != nil {
-		h.handleDomainError(w, err, result)
+		if !errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+			span.RecordError(err)
+		}
+		h.handleDomainError(w, r, err, result)
 		return
 	}
 
+	if result.Parked {
+		recordOutcome(w, outcomeParked)
+		writeJSON(w, http.StatusAccepted, WebhookResponseDTO{
+			TransactionID: result.TransactionID,
+			Parked:        true,
+			Message:       "original transaction not received yet, adjustment parked",
+		})
+		return
+	}
+	recordOutcome(w, outcomeProcessed)
 	writeJSON(w, http.StatusOK, WebhookResponseDTO{
 		TransactionID: result.TransactionID,
 		Idempotent:    false,
@@ -52,34 +316,55 @@ func (h *Handler) handleWebhook(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (h *Handler) handleDomainError(w http.ResponseWriter, err error, result ports.ProcessTransactionResult) {
+// handleDomainError maps a use case error to its status and code, with the message in the
+// client's Accept-Language when the error can be localized.
+func (h *Handler) handleDomainError(w http.ResponseWriter, r *http.Request, err error, result ports.ProcessTransactionResult) {
+	msg := localizedMessage(r, err)
 	switch {
+	case errors.Is(err, domain.ErrDuplicateIdempotencyKey) && result.Parked:
+		recordOutcome(w, outcomeIdempotent)
+		writeJSON(w, http.StatusAccepted, WebhookResponseDTO{
+			TransactionID: result.TransactionID,
+			Idempotent:    true,
+			Parked:        true,
+			Message:       "duplicate event, adjustment still parked",
+		})
 	case errors.Is(err, domain.ErrDuplicateIdempotencyKey):
+		recordOutcome(w, outcomeIdempotent)
 		writeJSON(w, http.StatusOK, WebhookResponseDTO{
 			TransactionID: result.TransactionID,
 			Idempotent:    true,
 			Message:       "duplicate event, already processed",
 		})
 	case errors.Is(err, domain.ErrTransactionNotFound):
-		writeError(w, http.StatusNotFound, err.Error(), "NOT_FOUND")
+		writeError(w, http.StatusNotFound, msg, "NOT_FOUND")
 	case errors.Is(err, domain.ErrExceedsOriginalAmount):
-		writeError(w, http.StatusConflict, err.Error(), "EXCEEDS_ORIGINAL_AMOUNT")
+		writeError(w, http.StatusConflict, msg, "EXCEEDS_ORIGINAL_AMOUNT")
 	case errors.Is(err, domain.ErrPurchaseNotApproved):
-		writeError(w, http.StatusConflict, err.Error(), "PURCHASE_NOT_APPROVED")
+		writeError(w, http.StatusConflict, msg, "PURCHASE_NOT_APPROVED")
+	case errors.Is(err, domain.ErrOriginalTypeMismatch):
+		writeError(w, http.StatusUnprocessableEntity, msg, "ORIGINAL_TYPE_MISMATCH")
 	case errors.Is(err, domain.ErrDuplicateTransactionID):
-		writeError(w, http.StatusConflict, err.Error(), "DUPLICATE_TRANSACTION_ID")
+		writeError(w, http.StatusConflict, msg, "DUPLICATE_TRANSACTION_ID")
 	case errors.Is(err, domain.ErrAmountOutOfRange):
-		writeError(w, http.StatusUnprocessableEntity, err.Error(), "AMOUNT_OUT_OF_RANGE")
+		writeError(w, http.StatusUnprocessableEntity, msg, "AMOUNT_OUT_OF_RANGE")
 	case errors.Is(err, domain.ErrNegativeAmount):
-		writeError(w, http.StatusBadRequest, err.Error(), "NEGATIVE_AMOUNT")
+		writeError(w, http.StatusBadRequest, msg, "NEGATIVE_AMOUNT")
 	case errors.Is(err, domain.ErrOriginalTransactionRequired):
-		writeError(w, http.StatusBadRequest, err.Error(), "ORIGINAL_TRANSACTION_REQUIRED")
+		writeError(w, http.StatusBadRequest, msg, "ORIGINAL_TRANSACTION_REQUIRED")
+	case errors.Is(err, domain.ErrInvalidCurrency):
+		writeError(w, http.StatusBadRequest, msg, "INVALID_CURRENCY")
 	case errors.Is(err, domain.ErrCurrencyMismatch):
-		writeError(w, http.StatusBadRequest, err.Error(), "CURRENCY_MISMATCH")
+		var mismatch *domain.CurrencyMismatchError
+		if errors.As(err, &mismatch) {
+			writeFieldError(w, http.StatusBadRequest, msg, "CURRENCY_MISMATCH", mismatch.Field)
+			return
+		}
+		writeError(w, http.StatusBadRequest, msg, "CURRENCY_MISMATCH")
 	case errors.Is(err, domain.ErrInvalidTransactionType):
-		writeError(w, http.StatusBadRequest, err.Error(), "INVALID_TRANSACTION_TYPE")
+		writeError(w, http.StatusBadRequest, msg, "INVALID_TRANSACTION_TYPE")
 	case errors.Is(err, domain.ErrInvalidInput):
-		writeError(w, http.StatusBadRequest, err.Error(), "INVALID_INPUT")
+		writeError(w, http.StatusBadRequest, msg, "INVALID_INPUT")
 	default:
 		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
 	}
@@ -87,7 +372,7 @@ func (h *Handler) handleDomainError(w http.ResponseWriter, err error, result por
 
 func (h *Handler) handleGetTransaction(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	tx, err := h.useCase.GetTransaction(r.Context(), id)
+	summary, err := h.useCase.GetTransactionSummary(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, domain.ErrTransactionNotFound) {
 			writeError(w, http.StatusNotFound, err.Error(), "NOT_FOUND")
@@ -96,28 +381,314 @@ func (h *Handler) handleGetTransaction(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
 		return
 	}
-	writeJSON(w, http.StatusOK, tx)
+	writeJSON(w, http.StatusOK, NewTransactionDetailDTO(summary))
+}
+
+func (h *Handler) handleGetTransactionAdjustments(w http.ResponseWriter, r *http.Request) {
+	adjs, err := h.useCase.GetTransactionAdjustments(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, domain.ErrTransactionNotFound) {
+			writeError(w, http.StatusNotFound, err.Error(), "NOT_FOUND")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, adjs)
+}
+
+func (h *Handler) handleListTransactionEvents(w http.ResponseWriter, r *http.Request) {
+	attempts, err := h.history.ListTransactionEvents(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, domain.ErrTransactionNotFound) {
+			writeError(w, http.StatusNotFound, err.Error(), "NOT_FOUND")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
+		return
+	}
+	dtos := make([]WebhookAttemptDTO, len(attempts))
+	for i, a := range attempts {
+		dtos[i] = NewWebhookAttemptDTO(a)
+	}
+	writeJSON(w, http.StatusOK, dtos)
 }
 
 func (h *Handler) handleListTransactions(w http.ResponseWriter, r *http.Request) {
-	txs, err := h.useCase.ListTransactions(r.Context())
+	q, sort, err := parseTransactionQuery(r.URL.Query())
 	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "INVALID_QUERY")
+		return
+	}
+	page, err := h.useCase.ListTransactions(r.Context(), q)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			writeError(w, http.StatusBadRequest, err.Error(), "INVALID_QUERY")
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
 		return
 	}
-	writeJSON(w, http.StatusOK, txs)
+	if page.Next != nil {
+		w.Header().Set(HeaderNextCursor, encodeCursor(sort, *page.Next))
+	}
+	writeJSON(w, http.StatusOK, page.Transactions)
+}
+
+func (h *Handler) handleListAdjustmentsForReview(w http.ResponseWriter, r *http.Request) {
+	pending, err := h.pending.ListAdjustmentsForReview(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
+		return
+	}
+	dtos := make([]PendingAdjustmentDTO, len(pending))
+	for i, p := range pending {
+		dtos[i] = NewPendingAdjustmentDTO(p)
+	}
+	writeJSON(w, http.StatusOK, dtos)
+}
+
+func (h *Handler) handleGetUserBalance(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	balances, err := h.ledger.GetUserBalance(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, UserBalanceDTO{UserID: id, Balances: NewBalanceDTOs(balances)})
+}
+
+func (h *Handler) handleGetCardLedger(w http.ResponseWriter, r *http.Request) {
+	ledger, err := h.ledger.GetCardLedger(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, NewCardLedgerDTO(ledger))
+}
+
+// handleVerifyLedger answers 500 when the ledger does not sum to zero, so it can back an alert.
+func (h *Handler) handleVerifyLedger(w http.ResponseWriter, r *http.Request) {
+	totals, err := h.ledger.VerifyLedger(r.Context())
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, LedgerVerificationDTO{Balanced: true, Totals: NewBalanceDTOs(totals)})
+	case errors.Is(err, domain.ErrLedgerUnbalanced):
+		writeJSON(w, http.StatusInternalServerError, LedgerVerificationDTO{Balanced: false, Totals: NewBalanceDTOs(totals), Error: err.Error()})
+	default:
+		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
+	}
+}
+
+// handleAuthorize answers 200 with the decision, approved or rejected; errors are reserved for
+// requests that cannot be decided at all.
+func (h *Handler) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	var dto AuthorizationRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	cmd, err := dto.ToCommand()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "VALIDATION_ERROR")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.authDeadline)
+	defer cancel()
+	result, err := h.authorizations.Authorize(ctx, cmd)
+	if err != nil && !errors.Is(err, domain.ErrDuplicateAuthorization) {
+		h.handleDomainError(w, r, err, ports.ProcessTransactionResult{})
+		return
+	}
+	writeJSON(w, http.StatusOK, AuthorizationResponseDTO{
+		TransactionID: result.Authorization.Request.ID,
+		Status:        result.Authorization.Decision,
+		StatusDetail:  result.Authorization.Reason,
+		Idempotent:    result.Idempotent,
+	})
+}
+
+func (h *Handler) handleGetAuthorization(w http.ResponseWriter, r *http.Request) {
+	auth, err := h.authorizations.GetAuthorization(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, domain.ErrAuthorizationNotFound) {
+			writeError(w, http.StatusNotFound, err.Error(), "NOT_FOUND")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, NewAuthorizationDTO(auth))
+}
+
+func (h *Handler) handleSaveCard(w http.ResponseWriter, r *http.Request) {
+	var dto CardDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	card, err := h.authorizations.SaveCard(r.Context(), dto.ToCommand(r.PathValue("id")))
+	if err != nil {
+		h.handleDomainError(w, r, err, ports.ProcessTransactionResult{})
+		return
+	}
+	writeJSON(w, http.StatusOK, NewCardDTO(card))
+}
+
+func (h *Handler) handleGetCard(w http.ResponseWriter, r *http.Request) {
+	card, err := h.authorizations.GetCard(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, domain.ErrCardNotFound) {
+			writeError(w, http.StatusNotFound, err.Error(), "NOT_FOUND")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, NewCardDTO(card))
+}
+
+func (h *Handler) handleListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	letters, err := h.outbox.ListDeadLetters(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
+		return
+	}
+	dtos := make([]DeadLetterDTO, len(letters))
+	for i, dl := range letters {
+		dtos[i] = NewDeadLetterDTO(dl)
+	}
+	writeJSON(w, http.StatusOK, dtos)
+}
+
+func (h *Handler) handleCreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var dto SubscriptionRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	sub, err := h.subscriptions.CreateSubscription(r.Context(), dto.ToCommand())
+	if err != nil {
+		h.handleDomainError(w, r, err, ports.ProcessTransactionResult{})
+		return
+	}
+	writeJSON(w, http.StatusCreated, NewSubscriptionDTO(sub))
+}
+
+func (h *Handler) handleListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.subscriptions.ListSubscriptions(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
+		return
+	}
+	dtos := make([]SubscriptionDTO, len(subs))
+	for i, sub := range subs {
+		dtos[i] = NewSubscriptionDTO(sub)
+	}
+	writeJSON(w, http.StatusOK, dtos)
+}
+
+func (h *Handler) handleGetSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, err := h.subscriptions.GetSubscription(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, NewSubscriptionDTO(sub))
+}
+
+func (h *Handler) handleDeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	if err := h.subscriptions.DeleteSubscription(r.Context(), r.PathValue("id")); err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleListDeliveries(w http.ResponseWriter, r *http.Request) {
+	deliveries, err := h.subscriptions.ListDeliveries(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	dtos := make([]DeliveryDTO, len(deliveries))
+	for i, d := range deliveries {
+		dtos[i] = NewDeliveryDTO(d)
+	}
+	writeJSON(w, http.StatusOK, dtos)
+}
+
+// handleRedeliver answers 200 with the new attempt whether or not the subscriber accepted it.
+func (h *Handler) handleRedeliver(w http.ResponseWriter, r *http.Request) {
+	d, err := h.subscriptions.Redeliver(r.Context(), r.PathValue("id"), r.PathValue("delivery_id"))
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, NewDeliveryDTO(d))
+}
+
+func writeSubscriptionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrSubscriptionNotFound) || errors.Is(err, domain.ErrDeliveryNotFound) {
+		writeError(w, http.StatusNotFound, err.Error(), "NOT_FOUND")
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
 }
 
 func (h *Handler) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	if h.draining.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "shutting_down"})
+		return
+	}
 	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
+// handleLive answers 200 while the process can make progress; draining does not affect it.
+func (h *Handler) handleLive(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, h.health.Live(r.Context()))
+}
+
+// handleReady answers 200 when every check passes and the server is not shutting down.
+func (h *Handler) handleReady(w http.ResponseWriter, r *http.Request) {
+	if h.draining.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, health.Report{Status: "shutting_down", Checks: []health.Result{}})
+		return
+	}
+	writeHealthReport(w, h.health.Ready(r.Context()))
+}
+
+func writeHealthReport(w http.ResponseWriter, report health.Report) {
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(v)
 }
 
+// writeBodyError answers a request whose body could not be read or decoded.
+func writeBodyError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit), "PAYLOAD_TOO_LARGE")
+		return
+	}
+	writeError(w, http.StatusBadRequest, "invalid request body", "BAD_REQUEST")
+}
+
 func writeError(w http.ResponseWriter, status int, msg, code string) {
-	writeJSON(w, status, ErrorResponseDTO{Error: msg, Code: code})
+	writeFieldError(w, status, msg, code, "")
+}
+
+// writeFieldError is writeError naming the request field at fault.
+func writeFieldError(w http.ResponseWriter, status int, msg, code, field string) {
+	recordOutcome(w, code)
+	writeJSON(w, status, ErrorResponseDTO{Error: msg, Code: code, Field: field})
 }
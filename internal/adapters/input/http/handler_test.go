@@ -5,12 +5,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jailtonjunior/pomelo/internal/application/ports"
 	"github.com/jailtonjunior/pomelo/internal/domain"
+	"github.com/jailtonjunior/pomelo/internal/health"
+	"github.com/jailtonjunior/pomelo/internal/tracing"
 )
 
 // --- Mock Use Case ---
@@ -20,8 +25,30 @@ type mockUseCase struct {
 	processErr    error
 	getTx         domain.Transaction
 	getErr        error
-	listTxs       []domain.Transaction
+	listPage      ports.TransactionPage
 	listErr       error
+	listQuery     ports.TransactionQuery
+	review        []domain.PendingAdjustment
+	reviewErr     error
+	summary       ports.TransactionSummary
+	adjustments   []domain.Adjustment
+	balances      []domain.AccountBalance
+	cardLedger    ports.CardLedger
+	ledgerTotals  []domain.AccountBalance
+	ledgerErr     error
+	authResult    ports.AuthorizeResult
+	authErr       error
+	authCmd       ports.AuthorizeCommand
+	authDeadline  time.Time
+	card          domain.Card
+	cardErr       error
+	cardCmd       ports.SaveCardCommand
+	deadLetters   []domain.DeadLetter
+	subscription  domain.Subscription
+	subErr        error
+	subCmd        ports.CreateSubscriptionCommand
+	deliveries    []domain.Delivery
+	redelivery    domain.Delivery
 }
 
 func (m *mockUseCase) ProcessTransaction(_ context.Context, _ ports.ProcessTransactionCommand) (ports.ProcessTransactionResult, error) {
@@ -32,8 +59,83 @@ func (m *mockUseCase) GetTransaction(_ context.Context, _ string) (domain.Transa
 	return m.getTx, m.getErr
 }
 
-func (m *mockUseCase) ListTransactions(_ context.Context) ([]domain.Transaction, error) {
-	return m.listTxs, m.listErr
+func (m *mockUseCase) GetTransactionSummary(_ context.Context, _ string) (ports.TransactionSummary, error) {
+	return m.summary, m.getErr
+}
+
+func (m *mockUseCase) GetTransactionAdjustments(_ context.Context, _ string) ([]domain.Adjustment, error) {
+	return m.adjustments, m.getErr
+}
+
+func (m *mockUseCase) ListTransactions(_ context.Context, q ports.TransactionQuery) (ports.TransactionPage, error) {
+	m.listQuery = q
+	return m.listPage, m.listErr
+}
+
+func (m *mockUseCase) GetUserBalance(_ context.Context, _ string) ([]domain.AccountBalance, error) {
+	return m.balances, nil
+}
+
+func (m *mockUseCase) GetCardLedger(_ context.Context, cardID string) (ports.CardLedger, error) {
+	l := m.cardLedger
+	l.CardID = cardID
+	return l, nil
+}
+
+func (m *mockUseCase) VerifyLedger(_ context.Context) ([]domain.AccountBalance, error) {
+	return m.ledgerTotals, m.ledgerErr
+}
+
+func (m *mockUseCase) Authorize(ctx context.Context, cmd ports.AuthorizeCommand) (ports.AuthorizeResult, error) {
+	m.authCmd = cmd
+	m.authDeadline, _ = ctx.Deadline()
+	return m.authResult, m.authErr
+}
+
+func (m *mockUseCase) GetAuthorization(_ context.Context, _ string) (domain.Authorization, error) {
+	return m.authResult.Authorization, m.authErr
+}
+
+func (m *mockUseCase) SaveCard(_ context.Context, cmd ports.SaveCardCommand) (domain.Card, error) {
+	m.cardCmd = cmd
+	return m.card, m.cardErr
+}
+
+func (m *mockUseCase) GetCard(_ context.Context, _ string) (domain.Card, error) {
+	return m.card, m.cardErr
+}
+
+func (m *mockUseCase) ListAdjustmentsForReview(_ context.Context) ([]domain.PendingAdjustment, error) {
+	return m.review, m.reviewErr
+}
+
+func (m *mockUseCase) ListDeadLetters(_ context.Context) ([]domain.DeadLetter, error) {
+	return m.deadLetters, nil
+}
+
+func (m *mockUseCase) CreateSubscription(_ context.Context, cmd ports.CreateSubscriptionCommand) (domain.Subscription, error) {
+	m.subCmd = cmd
+	return m.subscription, m.subErr
+}
+
+func (m *mockUseCase) GetSubscription(_ context.Context, _ string) (domain.Subscription, error) {
+	return m.subscription, m.subErr
+}
+
+func (m *mockUseCase) ListSubscriptions(_ context.Context) ([]domain.Subscription, error) {
+	return []domain.Subscription{m.subscription}, m.subErr
+}
+
+func (m *mockUseCase) DeleteSubscription(_ context.Context, _ string) error {
+	return m.subErr
+}
+
+func (m *mockUseCase) ListDeliveries(_ context.Context, _ string) ([]domain.Delivery, error) {
+	return m.deliveries, m.subErr
+}
+
+func (m *mockUseCase) Redeliver(_ context.Context, _, _ string) (domain.Delivery, error) {
+	return m.redelivery, m.subErr
 }
 
 // --- Helpers ---
@@ -112,6 +214,37 @@ func TestWebhookTransactionNotFound(t *testing.T) {
 	}
 }
 
+func TestWebhookAdjustmentParked(t *testing.T) {
+	mock := &mockUseCase{processResult: ports.ProcessTransactionResult{TransactionID: "tx1", Parked: true}}
+	h := NewHandler(mock)
+	w := doPost(h, buildWebhookBody("REFUND", "APPROVED", "tx-original"))
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected 202, got %d", w.Code)
+	}
+	var resp WebhookResponseDTO
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !resp.Parked || resp.Idempotent {
+		t.Errorf("expected parked=true idempotent=false, got %+v", resp)
+	}
+}
+
+func TestWebhookDuplicateOfParkedAdjustment(t *testing.T) {
+	mock := &mockUseCase{
+		processResult: ports.ProcessTransactionResult{TransactionID: "tx1", Idempotent: true, Parked: true},
+		processErr:    domain.ErrDuplicateIdempotencyKey,
+	}
+	h := NewHandler(mock)
+	w := doPost(h, buildWebhookBody("REFUND", "APPROVED", "tx-original"))
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected 202, got %d", w.Code)
+	}
+	var resp WebhookResponseDTO
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !resp.Parked || !resp.Idempotent {
+		t.Errorf("expected parked=true idempotent=true, got %+v", resp)
+	}
+}
+
 func TestWebhookExceedsOriginalAmount(t *testing.T) {
 	mock := &mockUseCase{processErr: domain.ErrExceedsOriginalAmount}
 	h := NewHandler(mock)
@@ -130,6 +263,13 @@ func TestWebhookPurchaseNotApproved(t *testing.T) {
 	}
 }
 
+func TestWebhookOriginalTypeMismatch(t *testing.T) {
+	mock := &mockUseCase{processErr: domain.ErrOriginalTypeMismatch}
+	h := NewHandler(mock)
+	w := doPost(h, buildWebhookBody("REVERSAL_WITHDRAWAL", "APPROVED", "tx-original"))
+	assertErrorCode(t, w, http.StatusUnprocessableEntity, "ORIGINAL_TYPE_MISMATCH")
+}
+
 func TestWebhookAmountOutOfRange(t *testing.T) {
 	mock := &mockUseCase{processErr: domain.ErrAmountOutOfRange}
 	h := NewHandler(mock)
@@ -240,6 +380,146 @@ func TestGetTransactionNotFound(t *testing.T) {
 	}
 }
 
+func TestGetTransactionDetail(t *testing.T) {
+	brl := func(amount int64) domain.Money { return domain.Money{Amount: amount, Currency: "BRL"} }
+	mock := &mockUseCase{summary: ports.TransactionSummary{
+		Transaction: domain.Transaction{ID: "tx1", Type: domain.TypePurchase, Status: domain.StatusApproved},
+		Adjustments: []domain.Adjustment{{ID: "adj1"}, {ID: "adj2"}},
+		Balance: domain.PurchaseBalance{
+			TotalReversed: brl(0),
+			TotalRefunded: brl(300),
+			Remaining:     brl(700),
+			State:         domain.PurchaseStatePartiallyRefunded,
+		},
+	}}
+	h := NewHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/transactions/tx1", nil)
+	w := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp map[string]any
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp["ID"] != "tx1" {
+		t.Errorf("expected transaction fields at top level, got %v", resp)
+	}
+	if resp["state"] != "PARTIALLY_REFUNDED" || resp["adjustment_count"] != float64(2) {
+		t.Errorf("unexpected derived fields: %v", resp)
+	}
+	remaining, _ := resp["remaining_refundable"].(map[string]any)
+	if remaining["Amount"] != float64(700) {
+		t.Errorf("expected remaining 700, got %v", resp["remaining_refundable"])
+	}
+}
+
+func TestGetTransactionAdjustments(t *testing.T) {
+	mock := &mockUseCase{adjustments: []domain.Adjustment{{ID: "adj1"}, {ID: "adj2"}}}
+	h := NewHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/transactions/tx1/adjustments", nil)
+	w := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp []domain.Adjustment
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp) != 2 || resp[0].ID != "adj1" {
+		t.Errorf("unexpected adjustments: %v", resp)
+	}
+}
+
+func TestGetTransactionAdjustmentsNotFound(t *testing.T) {
+	mock := &mockUseCase{getErr: domain.ErrTransactionNotFound}
+	h := NewHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/transactions/tx999/adjustments", nil)
+	w := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestListTransactionsPaged(t *testing.T) {
+	next := ports.TransactionCursor{Amount: 1000, ID: "tx2"}
+	mock := &mockUseCase{listPage: ports.TransactionPage{
+		Transactions: []domain.Transaction{{ID: "tx1"}, {ID: "tx2"}},
+		Next:         &next,
+	}}
+	h := NewHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/transactions?user_id=u1&sort=-amount&limit=2", nil)
+	w := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if mock.listQuery.UserID != "u1" || mock.listQuery.Limit != 2 || !mock.listQuery.Descending {
+		t.Errorf("query not passed through: %+v", mock.listQuery)
+	}
+	var resp []domain.Transaction
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp) != 2 {
+		t.Errorf("expected a JSON array of 2, got %d", len(resp))
+	}
+	cursor := w.Header().Get(HeaderNextCursor)
+	if cursor == "" {
+		t.Fatal("expected next cursor header")
+	}
+	after, err := decodeCursor("-amount", cursor)
+	if err != nil || after.ID != "tx2" {
+		t.Errorf("expected cursor for tx2, got %+v (err %v)", after, err)
+	}
+}
+
+func TestListTransactionsLastPageHasNoCursor(t *testing.T) {
+	mock := &mockUseCase{listPage: ports.TransactionPage{Transactions: []domain.Transaction{}}}
+	h := NewHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/transactions", nil)
+	w := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if w.Header().Get(HeaderNextCursor) != "" {
+		t.Error("expected no cursor on the last page")
+	}
+}
+
+func TestListTransactionsInvalidQuery(t *testing.T) {
+	for _, target := range []string{"/transactions?limit=abc", "/transactions?status=PENDING"} {
+		h := NewHandler(&mockUseCase{})
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		mux := http.NewServeMux()
+		h.RegisterRoutes(mux)
+		mux.ServeHTTP(w, req)
+		assertErrorCode(t, w, http.StatusBadRequest, "INVALID_QUERY")
+	}
+}
+
+func TestListTransactionsUseCaseValidation(t *testing.T) {
+	mock := &mockUseCase{listErr: fmt.Errorf("%w: unknown sort field", domain.ErrInvalidInput)}
+	h := NewHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/transactions?sort=merchant", nil)
+	w := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
 func TestHealth(t *testing.T) {
 	mock := &mockUseCase{}
 	h := NewHandler(mock)
@@ -252,3 +532,468 @@ func TestHealth(t *testing.T) {
 		t.Errorf("expected 200, got %d", w.Code)
 	}
 }
+
+func TestHealthWhileDraining(t *testing.T) {
+	h := NewHandler(&mockUseCase{})
+	h.Drain()
+	w := doGet(h, "/health")
+	var body map[string]string
+	json.NewDecoder(w.Body).Decode(&body)
+	if w.Code != http.StatusServiceUnavailable || body["status"] != "shutting_down" {
+		t.Errorf("expected 503 shutting_down, got %d %v", w.Code, body)
+	}
+}
+
+func TestHealthLiveAndReady(t *testing.T) {
+	checks := health.NewRegistry()
+	checks.Register("storage", health.CheckerFunc(func(context.Context) error { return errors.New("database is down") }))
+	checks.RegisterLiveness("dispatcher", health.CheckerFunc(func(context.Context) error { return nil }))
+	h := NewHandler(&mockUseCase{}, WithHealthChecks(checks))
+
+	w := doGet(h, "/health/live")
+	var live health.Report
+	json.NewDecoder(w.Body).Decode(&live)
+	if w.Code != http.StatusOK || live.Status != health.StatusOK || len(live.Checks) != 1 {
+		t.Errorf("expected a passing liveness report, got %d %+v", w.Code, live)
+	}
+
+	w = doGet(h, "/health/ready")
+	var ready health.Report
+	json.NewDecoder(w.Body).Decode(&ready)
+	if w.Code != http.StatusServiceUnavailable || ready.Status != health.StatusFail || len(ready.Checks) != 2 {
+		t.Fatalf("expected a failing readiness report, got %d %+v", w.Code, ready)
+	}
+	if c := ready.Checks[0]; c.Name != "storage" || c.Error != "database is down" {
+		t.Errorf("unexpected storage check %+v", c)
+	}
+	if w := doGet(h, "/health"); w.Code != http.StatusOK {
+		t.Errorf("GET /health should stay independent of the checks, got %d", w.Code)
+	}
+}
+
+func TestHealthReadyWhileDraining(t *testing.T) {
+	h := NewHandler(&mockUseCase{})
+	if w := doGet(h, "/health/ready"); w.Code != http.StatusOK {
+		t.Fatalf("expected ready without checks, got %d", w.Code)
+	}
+	h.Drain()
+	w := doGet(h, "/health/ready")
+	var body health.Report
+	json.NewDecoder(w.Body).Decode(&body)
+	if w.Code != http.StatusServiceUnavailable || body.Status != "shutting_down" {
+		t.Errorf("expected 503 shutting_down, got %d %+v", w.Code, body)
+	}
+	if w := doGet(h, "/health/live"); w.Code != http.StatusOK {
+		t.Errorf("draining should not fail liveness, got %d", w.Code)
+	}
+}
+
+func TestWebhookBodyTooLarge(t *testing.T) {
+	mock := &mockUseCase{processResult: ports.ProcessTransactionResult{TransactionID: "tx1"}}
+	body := buildWebhookBody("PURCHASE", "APPROVED", "")
+	h := NewHandler(mock, WithMaxBodyBytes(int64(len(body)-1)))
+	w := doPost(h, body)
+	var resp ErrorResponseDTO
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusRequestEntityTooLarge || resp.Code != "PAYLOAD_TOO_LARGE" {
+		t.Errorf("expected 413 PAYLOAD_TOO_LARGE, got %d %+v", w.Code, resp)
+	}
+
+	signed := NewHandler(mock, WithMaxBodyBytes(int64(len(body)-1)), WithSignatureVerifier(NewSignatureVerifier([]string{"s"}, time.Minute)))
+	if w := doSignedPost(signed, body, signedHeaders("s", time.Now(), "/webhook/transactions", body)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 from the signature check, got %d", w.Code)
+	}
+	if w := doPost(NewHandler(mock, WithMaxBodyBytes(int64(len(body)))), body); w.Code != http.StatusOK {
+		t.Errorf("expected a body at the limit to pass, got %d", w.Code)
+	}
+}
+
+func TestListAdjustmentsForReview(t *testing.T) {
+	parkedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	expired := domain.PendingAdjustment{Adjustment: domain.Adjustment{ID: "adj1"}, ParkedAt: parkedAt, ExpiresAt: parkedAt.Add(time.Hour)}
+	rejected := domain.PendingAdjustment{Adjustment: domain.Adjustment{ID: "adj2"}, ParkedAt: parkedAt, ExpiresAt: parkedAt.Add(time.Hour), Reason: "exceeds"}
+	mock := &mockUseCase{review: []domain.PendingAdjustment{expired, rejected}}
+	h := NewHandler(mock, WithPendingAdjustmentReview(mock))
+
+	req := httptest.NewRequest(http.MethodGet, "/adjustments/review", nil)
+	w := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp []PendingAdjustmentDTO
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(resp))
+	}
+	if resp[0].State != domain.PendingExpired || resp[1].State != domain.PendingRejected || resp[1].Reason != "exceeds" {
+		t.Errorf("unexpected states: %+v", resp)
+	}
+}
+
+func TestListAdjustmentsForReviewNotRegisteredByDefault(t *testing.T) {
+	h := NewHandler(&mockUseCase{})
+	req := httptest.NewRequest(http.MethodGet, "/adjustments/review", nil)
+	w := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func doGet(handler *Handler, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	w := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetUserBalance(t *testing.T) {
+	mock := &mockUseCase{balances: []domain.AccountBalance{{Currency: "BRL", Debits: 1000, Credits: 300, Net: 700}}}
+	w := doGet(NewHandler(mock, WithLedger(mock)), "/users/u1/balance")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp UserBalanceDTO
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.UserID != "u1" || len(resp.Balances) != 1 || resp.Balances[0].Net != 700 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
+
+func TestGetUserBalanceUnknownUserIsEmpty(t *testing.T) {
+	mock := &mockUseCase{balances: []domain.AccountBalance{}}
+	w := doGet(NewHandler(mock, WithLedger(mock)), "/users/nobody/balance")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"balances":[]`) {
+		t.Errorf("expected 200 with empty balances, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetCardLedger(t *testing.T) {
+	amount, _ := domain.NewMoney(1000, "BRL")
+	mock := &mockUseCase{cardLedger: ports.CardLedger{
+		Entries: []domain.LedgerEntry{{PostingID: "tx1", TransactionID: "tx1", Type: domain.TypePurchase, Account: "card:card1",
+			Side: domain.DirectionDebit, Amount: amount, RunningBalance: 1000}},
+		Balances: []domain.AccountBalance{{Currency: "BRL", Debits: 1000, Net: 1000}},
+	}}
+	w := doGet(NewHandler(mock, WithLedger(mock)), "/cards/card1/ledger")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp CardLedgerDTO
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.CardID != "card1" || len(resp.Entries) != 1 || len(resp.Balances) != 1 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	if e := resp.Entries[0]; e.Side != domain.DirectionDebit || e.Amount != 1000 || e.Currency != "BRL" || e.RunningBalance != 1000 {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
+
+func TestVerifyLedger(t *testing.T) {
+	mock := &mockUseCase{ledgerTotals: []domain.AccountBalance{{Currency: "BRL", Debits: 500, Credits: 500}}}
+	w := doGet(NewHandler(mock, WithLedger(mock)), "/ledger/verify")
+	var resp LedgerVerificationDTO
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || !resp.Balanced || len(resp.Totals) != 1 {
+		t.Errorf("expected 200 balanced, got %d %+v", w.Code, resp)
+	}
+
+	mock.ledgerErr = fmt.Errorf("%w: BRL", domain.ErrLedgerUnbalanced)
+	w = doGet(NewHandler(mock, WithLedger(mock)), "/ledger/verify")
+	resp = LedgerVerificationDTO{}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusInternalServerError || resp.Balanced || resp.Error == "" {
+		t.Errorf("expected 500 unbalanced, got %d %+v", w.Code, resp)
+	}
+}
+
+func TestLedgerRoutesNotRegisteredByDefault(t *testing.T) {
+	h := NewHandler(&mockUseCase{})
+	for _, path := range []string{"/users/u1/balance", "/cards/card1/ledger", "/ledger/verify"} {
+		if w := doGet(h, path); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", path, w.Code)
+		}
+	}
+}
+
+func doAuthorize(handler *Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/transactions/authorizations", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+const authorizationBody = `{"transaction":{"id":"tx1","type":"PURCHASE"},"merchant":{"id":"m1"},"card":{"id":"card1"},
+	"user":{"id":"u1"},"amount":{"local":{"total":1000,"currency":"BRL"}}}`
+
+func makeAuthorization(decision domain.TransactionStatus, reason domain.AuthorizationReason) domain.Authorization {
+	amount, _ := domain.NewMoney(1000, "BRL")
+	return domain.Authorization{
+		Request:  domain.AuthorizationRequest{ID: "tx1", Type: domain.TypePurchase, CardID: "card1", UserID: "u1", Amount: amount},
+		Decision: decision,
+		Reason:   reason,
+	}
+}
+
+func TestAuthorizeDecisions(t *testing.T) {
+	for _, auth := range []domain.Authorization{
+		makeAuthorization(domain.StatusApproved, domain.ReasonApproved),
+		makeAuthorization(domain.StatusRejected, domain.ReasonInsufficientBalance),
+	} {
+		mock := &mockUseCase{authResult: ports.AuthorizeResult{Authorization: auth}}
+		before := time.Now()
+		w := doAuthorize(NewHandler(mock, WithAuthorizations(mock, time.Second)), authorizationBody)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		var resp AuthorizationResponseDTO
+		json.NewDecoder(w.Body).Decode(&resp)
+		if resp.TransactionID != "tx1" || resp.Status != auth.Decision || resp.StatusDetail != auth.Reason {
+			t.Errorf("unexpected response %+v", resp)
+		}
+		if mock.authCmd.CardID != "card1" || mock.authCmd.Amount != 1000 || mock.authCmd.Currency != "BRL" {
+			t.Errorf("unexpected command %+v", mock.authCmd)
+		}
+		if d := mock.authDeadline.Sub(before); d < time.Second || d > 2*time.Second {
+			t.Errorf("expected a deadline within 1s, got %v", d)
+		}
+	}
+}
+
+func TestAuthorizeDuplicate(t *testing.T) {
+	mock := &mockUseCase{
+		authResult: ports.AuthorizeResult{Authorization: makeAuthorization(domain.StatusApproved, domain.ReasonApproved), Idempotent: true},
+		authErr:    domain.ErrDuplicateAuthorization,
+	}
+	w := doAuthorize(NewHandler(mock, WithAuthorizations(mock, time.Second)), authorizationBody)
+	var resp AuthorizationResponseDTO
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || !resp.Idempotent || resp.Status != domain.StatusApproved {
+		t.Errorf("expected 200 with the recorded decision, got %d %+v", w.Code, resp)
+	}
+}
+
+func TestAuthorizeInvalidRequest(t *testing.T) {
+	mock := &mockUseCase{}
+	h := NewHandler(mock, WithAuthorizations(mock, time.Second))
+	if w := doAuthorize(h, `{"transaction":{"id":"tx1","type":"PURCHASE"}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("missing card: expected 400, got %d", w.Code)
+	}
+	if w := doAuthorize(h, `{`); w.Code != http.StatusBadRequest {
+		t.Errorf("bad JSON: expected 400, got %d", w.Code)
+	}
+	mock.authErr = fmt.Errorf("%w: too small", domain.ErrAmountOutOfRange)
+	if w := doAuthorize(h, authorizationBody); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("out of range: expected 422, got %d", w.Code)
+	}
+}
+
+func TestGetAuthorization(t *testing.T) {
+	auth := makeAuthorization(domain.StatusApproved, domain.ReasonApproved)
+	auth.Match = &domain.AuthorizationMatch{Status: domain.StatusApproved, Amount: auth.Request.Amount, Discrepancies: []string{"amount"}}
+	mock := &mockUseCase{authResult: ports.AuthorizeResult{Authorization: auth}}
+	h := NewHandler(mock, WithAuthorizations(mock, time.Second))
+
+	w := doGet(h, "/authorizations/tx1")
+	var resp AuthorizationDTO
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || resp.StatusDetail != domain.ReasonApproved || resp.Match == nil || resp.Match.Consistent {
+		t.Errorf("expected 200 with an inconsistent match, got %d %+v", w.Code, resp)
+	}
+
+	mock.authErr = domain.ErrAuthorizationNotFound
+	if w := doGet(h, "/authorizations/nope"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestSaveCard(t *testing.T) {
+	mock := &mockUseCase{card: domain.Card{ID: "card1", Status: domain.CardActive, Currency: "BRL", Balance: 5000}}
+	h := NewHandler(mock, WithAuthorizations(mock, time.Second))
+	req := httptest.NewRequest(http.MethodPut, "/cards/card1", strings.NewReader(`{"card_id":"other","currency":"BRL","balance":5000}`))
+	w := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if mock.cardCmd.CardID != "card1" || mock.cardCmd.Status != "ACTIVE" || mock.cardCmd.Balance != 5000 {
+		t.Errorf("expected the path ID and a default ACTIVE status, got %+v", mock.cardCmd)
+	}
+
+	mock.cardErr = domain.ErrCardNotFound
+	if w := doGet(h, "/cards/nope"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestAuthorizationRoutesNotRegisteredByDefault(t *testing.T) {
+	h := NewHandler(&mockUseCase{})
+	if w := doAuthorize(h, authorizationBody); w.Code != http.StatusMethodNotAllowed && w.Code != http.StatusNotFound {
+		t.Errorf("expected the route to be missing, got %d", w.Code)
+	}
+	for _, path := range []string{"/authorizations/tx1", "/cards/card1"} {
+		if w := doGet(h, path); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestListDeadLetters(t *testing.T) {
+	amount, _ := domain.NewMoney(1000, "BRL")
+	tx := domain.Transaction{ID: "tx1", Type: domain.TypePurchase, CardID: "card1", Amount: domain.AmountBreakdown{Local: amount}}
+	failedAt := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	mock := &mockUseCase{deadLetters: []domain.DeadLetter{{
+		Event: domain.TransactionProcessed(tx), Subscriber: "http://sink", Attempts: 8, LastError: "subscriber responded 503", FailedAt: failedAt,
+	}}}
+	w := doGet(NewHandler(mock, WithOutbox(mock)), "/outbox/dead-letters")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var got []DeadLetterDTO
+	json.NewDecoder(w.Body).Decode(&got)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(got))
+	}
+	if dl := got[0]; dl.EventID != "TransactionProcessed:tx1" || dl.EventType != "TransactionProcessed" || dl.CardID != "card1" ||
+		dl.Subscriber != "http://sink" || dl.Attempts != 8 || !dl.FailedAt.Equal(failedAt) || dl.Event.Transaction == nil {
+		t.Errorf("unexpected dead letter %+v", dl)
+	}
+
+	if w := doGet(NewHandler(mock), "/outbox/dead-letters"); w.Code != http.StatusNotFound {
+		t.Errorf("expected the route to be missing without WithOutbox, got %d", w.Code)
+	}
+}
+
+func doSubscriptionRequest(handler *Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateSubscription(t *testing.T) {
+	created := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	mock := &mockUseCase{subscription: domain.Subscription{
+		ID: "sub_1", URL: "https://partner/hooks", Types: []domain.TransactionType{domain.TypeRefund}, Secret: "s3cret", CreatedAt: created,
+	}}
+	h := NewHandler(mock, WithSubscriptions(mock))
+	w := doSubscriptionRequest(h, http.MethodPost, "/subscriptions", `{"url":"https://partner/hooks","types":["REFUND"],"secret":"s3cret"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body)
+	}
+	if mock.subCmd.URL != "https://partner/hooks" || len(mock.subCmd.Types) != 1 || mock.subCmd.Secret != "s3cret" {
+		t.Errorf("unexpected command %+v", mock.subCmd)
+	}
+	if strings.Contains(w.Body.String(), "s3cret") {
+		t.Error("the secret must not be echoed back")
+	}
+	var got SubscriptionDTO
+	json.NewDecoder(w.Body).Decode(&got)
+	if got.ID != "sub_1" || len(got.Types) != 1 || got.Statuses == nil || !got.CreatedAt.Equal(created) {
+		t.Errorf("unexpected subscription %+v", got)
+	}
+
+	mock.subErr = fmt.Errorf("%w: subscription secret is required", domain.ErrInvalidInput)
+	if w := doSubscriptionRequest(h, http.MethodPost, "/subscriptions", `{"url":"https://partner/hooks"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestSubscriptionNotFound(t *testing.T) {
+	mock := &mockUseCase{subErr: domain.ErrSubscriptionNotFound}
+	h := NewHandler(mock, WithSubscriptions(mock))
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/subscriptions/sub_x"},
+		{http.MethodDelete, "/subscriptions/sub_x"},
+		{http.MethodGet, "/subscriptions/sub_x/deliveries"},
+		{http.MethodPost, "/subscriptions/sub_x/deliveries/dlv_1/redeliver"},
+	} {
+		if w := doSubscriptionRequest(h, req.method, req.path, ""); w.Code != http.StatusNotFound {
+			t.Errorf("%s %s: expected 404, got %d", req.method, req.path, w.Code)
+		}
+	}
+	if w := doGet(NewHandler(mock), "/subscriptions"); w.Code != http.StatusNotFound {
+		t.Errorf("expected the route to be missing without WithSubscriptions, got %d", w.Code)
+	}
+}
+
+func TestListDeliveriesAndRedeliver(t *testing.T) {
+	amount, _ := domain.NewMoney(1000, "BRL")
+	event := domain.TransactionProcessed(domain.Transaction{ID: "tx1", Type: domain.TypePurchase, Amount: domain.AmountBreakdown{Local: amount}})
+	mock := &mockUseCase{
+		deliveries: []domain.Delivery{{
+			ID: "dlv_1", SubscriptionID: "sub_1", Event: event, Attempt: 1, ResponseStatus: 503,
+			Error: "subscriber responded 503 Service Unavailable", Duration: 120 * time.Millisecond,
+		}},
+		redelivery: domain.Delivery{ID: "dlv_2", SubscriptionID: "sub_1", Event: event, Attempt: 1, Manual: true, ResponseStatus: 204},
+	}
+	h := NewHandler(mock, WithSubscriptions(mock))
+
+	w := doGet(h, "/subscriptions/sub_1/deliveries")
+	var log []DeliveryDTO
+	json.NewDecoder(w.Body).Decode(&log)
+	if w.Code != http.StatusOK || len(log) != 1 {
+		t.Fatalf("expected 1 delivery, got %d: %+v", w.Code, log)
+	}
+	if d := log[0]; d.EventID != "TransactionProcessed:tx1" || d.Succeeded || d.ResponseStatus != 503 || d.DurationMS != 120 {
+		t.Errorf("unexpected delivery %+v", d)
+	}
+
+	w = doSubscriptionRequest(h, http.MethodPost, "/subscriptions/sub_1/deliveries/dlv_1/redeliver", "")
+	var redelivered DeliveryDTO
+	json.NewDecoder(w.Body).Decode(&redelivered)
+	if w.Code != http.StatusOK || redelivered.ID != "dlv_2" || !redelivered.Manual || !redelivered.Succeeded {
+		t.Errorf("unexpected redelivery %d: %+v", w.Code, redelivered)
+	}
+}
+
+// spanRecorder is a tracing.Exporter that keeps the exported spans.
+type spanRecorder struct{ spans []tracing.SpanData }
+
+func (r *spanRecorder) Export(_ context.Context, spans []tracing.SpanData) error {
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func TestWebhookContinuesIncomingTrace(t *testing.T) {
+	exp := &spanRecorder{}
+	tracer := tracing.NewTracer(exp)
+	mock := &mockUseCase{processErr: domain.ErrExceedsOriginalAmount}
+	h := NewHandler(mock, WithTracer(tracer))
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodPost, "/webhook/transactions", bytes.NewReader(buildWebhookBody("REFUND", "APPROVED", "tx0")))
+	req.Header.Set(tracing.HeaderTraceparent, parent)
+	w := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	mux.ServeHTTP(w, req)
+	tracer.Flush(context.Background())
+
+	if len(exp.spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(exp.spans))
+	}
+	s := exp.spans[0]
+	if s.Name != "Handler.handleWebhook" || s.SpanContext.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || s.Parent.String() != "00f067aa0ba902b7" {
+		t.Errorf("span does not continue the incoming trace: %+v", s)
+	}
+	if s.Error == "" {
+		t.Error("expected the domain error to be recorded")
+	}
+	if got := w.Header().Get(tracing.HeaderTraceparent); got != s.SpanContext.Traceparent() {
+		t.Errorf("expected traceparent %q in the response, got %q", s.SpanContext.Traceparent(), got)
+	}
+}
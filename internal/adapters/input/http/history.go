@@ -0,0 +1,39 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/logging"
+)
+
+// DecodeWebhook turns a raw POST /webhook/transactions body into the command the handler would
+// process it as. Replay uses it to read payloads back from the attempt log.
+func DecodeWebhook(payload []byte) (ports.ProcessTransactionCommand, error) {
+	var dto WebhookRequestDTO
+	if err := json.Unmarshal(payload, &dto); err != nil {
+		return ports.ProcessTransactionCommand{}, err
+	}
+	return dto.ToCommand()
+}
+
+// recordAttempt appends an answered webhook to the history, with the outcome code the handler
+// labelled the response with. A failure is only logged: the response already went out.
+func (h *Handler) recordAttempt(ctx context.Context, rec *responseRecorder, dto *WebhookRequestDTO, receivedAt time.Time, payload []byte) {
+	if dto.ID == "" {
+		return
+	}
+	err := h.history.RecordAttempt(context.WithoutCancel(ctx), ports.RecordAttemptCommand{
+		TransactionID:  dto.ID,
+		IdempotencyKey: dto.Event.IdempotencyKey,
+		ReceivedAt:     receivedAt,
+		Outcome:        rec.code,
+		StatusCode:     rec.status,
+		Payload:        payload,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("webhook attempt not recorded", "transaction_id", dto.ID, "err", err)
+	}
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// fakeHistory keeps recorded attempts in order.
+type fakeHistory struct {
+	attempts []domain.WebhookAttempt
+}
+
+func (f *fakeHistory) RecordAttempt(_ context.Context, cmd ports.RecordAttemptCommand) error {
+	a, err := domain.NewWebhookAttempt(fmt.Sprintf("att%d", len(f.attempts)+1), cmd.TransactionID, cmd.IdempotencyKey, cmd.ReceivedAt, cmd.Outcome, cmd.StatusCode, cmd.Payload)
+	if err == nil {
+		f.attempts = append(f.attempts, a)
+	}
+	return err
+}
+
+func (f *fakeHistory) ListTransactionEvents(_ context.Context, transactionID string) ([]domain.WebhookAttempt, error) {
+	var out []domain.WebhookAttempt
+	for _, a := range f.attempts {
+		if a.TransactionID == transactionID {
+			out = append(out, a)
+		}
+	}
+	if out == nil {
+		return nil, domain.ErrTransactionNotFound
+	}
+	return out, nil
+}
+
+func TestWebhookAttemptsRecorded(t *testing.T) {
+	uc := &mockUseCase{processResult: ports.ProcessTransactionResult{TransactionID: "tx1"}}
+	history := &fakeHistory{}
+	records := &fakeIdempotency{records: make(map[string]domain.IdempotencyRecord)}
+	h := NewHandler(uc, WithHistory(history), WithIdempotencyRecords(records))
+	body := buildWebhookBody("PURCHASE", "APPROVED", "")
+
+	before := time.Now()
+	doSignedPost(h, body, nil)
+	doSignedPost(h, body, nil) // replayed from the idempotency record
+	doSignedPost(h, buildWebhookBody("PURCHASE", "REJECTED", ""), nil)
+	doSignedPost(h, []byte(`{"id":"tx1","type":"PURCHASE"}`), nil)
+	doSignedPost(h, []byte(`not json`), nil)
+
+	want := []struct {
+		outcome string
+		status  int
+	}{
+		{domain.OutcomeProcessed, http.StatusOK},
+		{domain.OutcomeIdempotent, http.StatusOK},
+		{"IDEMPOTENCY_KEY_REUSED", http.StatusUnprocessableEntity},
+		{"VALIDATION_ERROR", http.StatusBadRequest},
+	}
+	if len(history.attempts) != len(want) {
+		t.Fatalf("expected %d attempts (the undecodable body belongs to no transaction), got %+v", len(want), history.attempts)
+	}
+	for i, w := range want {
+		a := history.attempts[i]
+		if a.Outcome != w.outcome || a.StatusCode != w.status || a.TransactionID != "tx1" {
+			t.Errorf("attempt %d: expected %s %d, got %+v", i, w.outcome, w.status, a)
+		}
+	}
+	if first := history.attempts[0]; string(first.Payload) != string(body) || first.ReceivedAt.Before(before) {
+		t.Errorf("expected the raw payload and arrival time, got %+v", first)
+	}
+}
+
+func TestListTransactionEvents(t *testing.T) {
+	history := &fakeHistory{}
+	h := NewHandler(&mockUseCase{processResult: ports.ProcessTransactionResult{TransactionID: "tx1"}}, WithHistory(history))
+	body := buildWebhookBody("PURCHASE", "APPROVED", "")
+	doSignedPost(h, body, nil)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux := http.NewServeMux()
+		h.RegisterRoutes(mux)
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+	w := get("/transactions/tx1/events")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", w.Code, w.Body)
+	}
+	var events []map[string]json.RawMessage
+	json.NewDecoder(w.Body).Decode(&events)
+	if len(events) != 1 || string(events[0]["outcome"]) != `"PROCESSED"` || string(events[0]["idempotency_key"]) != `"idem1"` {
+		t.Fatalf("unexpected events %v", events)
+	}
+	var payload WebhookRequestDTO
+	if err := json.Unmarshal(events[0]["payload"], &payload); err != nil || payload.ID != "tx1" {
+		t.Errorf("expected the payload as JSON, got %s (err %v)", events[0]["payload"], err)
+	}
+
+	if w := get("/transactions/tx999/events"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestDecodeWebhook(t *testing.T) {
+	cmd, err := DecodeWebhook(buildWebhookBody("REFUND", "APPROVED", "tx0"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cmd.TransactionID != "tx1" || cmd.TransactionType != "REFUND" || cmd.OriginalTransactionID != "tx0" || cmd.LocalAmount != 1000 {
+		t.Errorf("unexpected command %+v", cmd)
+	}
+	if _, err := DecodeWebhook([]byte(`{`)); err == nil {
+		t.Error("expected an error for a truncated payload")
+	}
+}
+
+func TestWebhookAttemptDTOKeepsInvalidPayloadReadable(t *testing.T) {
+	dto := NewWebhookAttemptDTO(domain.WebhookAttempt{ID: "att1", Payload: []byte(`{"id":`)})
+	if string(dto.Payload) != `"{\"id\":"` {
+		t.Errorf("expected the payload as a JSON string, got %s", dto.Payload)
+	}
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+	"github.com/jailtonjunior/pomelo/internal/logging"
+)
+
+// replayedHeader marks a response replayed from an idempotency record.
+const replayedHeader = "Idempotent-Replayed"
+
+// payloadHash identifies a webhook payload by its canonical form: the decoded DTO encoded again,
+// so whitespace, key order and unknown fields do not make a retry look like a different request.
+func (d *WebhookRequestDTO) payloadHash() string {
+	b, _ := json.Marshal(d)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// replayable reports whether a response may be replayed to retries. Server errors are worth
+// retrying, and a parked (202) or not-yet-arrived (404) adjustment changes state once its
+// purchase arrives, so none of them are recorded; the key stays claimed for its payload anyway.
+func replayable(status int) bool {
+	return status < http.StatusInternalServerError && status != http.StatusAccepted && status != http.StatusNotFound
+}
+
+// replayResponse claims key for the payload, then answers a retry from its idempotency record or
+// rejects a key reused with a different payload. It reports whether it wrote a response.
+func (h *Handler) replayResponse(w http.ResponseWriter, r *http.Request, key, payloadHash string) bool {
+	rec, ok, err := h.idempotency.ClaimKey(r.Context(), key, payloadHash)
+	switch {
+	case errors.Is(err, domain.ErrIdempotencyKeyReused):
+		writeError(w, http.StatusUnprocessableEntity, err.Error(), "IDEMPOTENCY_KEY_REUSED")
+		return true
+	case err != nil:
+		logging.FromContext(r.Context()).Error("idempotency lookup failed", "idempotency_key", key, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
+		return true
+	case !ok:
+		return false
+	}
+	recordOutcome(w, outcomeIdempotent)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(replayedHeader, "true")
+	w.WriteHeader(rec.StatusCode)
+	w.Write(rec.Body)
+	return true
+}
+
+// rememberResponse records the captured response for retries of key. A failure is only logged:
+// the request itself was handled, and the repository still recognizes the retry as a duplicate.
+func (h *Handler) rememberResponse(ctx context.Context, capture *responseCapture, key, payloadHash string) {
+	if !replayable(capture.status) {
+		return
+	}
+	if err := h.idempotency.RecordResponse(ctx, key, payloadHash, capture.status, capture.body.Bytes()); err != nil {
+		logging.FromContext(ctx).Warn("idempotency record not saved", "idempotency_key", key, "err", err)
+	}
+}
+
+// responseCapture keeps a copy of the status and body written through it.
+type responseCapture struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *responseCapture) WriteHeader(status int) {
+	c.status = status
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *responseCapture) Write(b []byte) (int, error) {
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+func (c *responseCapture) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
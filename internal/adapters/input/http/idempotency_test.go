@@ -0,0 +1,113 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// fakeIdempotency keeps records in a map, checking payloads the way the service does.
+type fakeIdempotency struct {
+	records map[string]domain.IdempotencyRecord
+}
+
+func (f *fakeIdempotency) ClaimKey(_ context.Context, key, payloadHash string) (domain.IdempotencyRecord, bool, error) {
+	rec, ok := f.records[key]
+	if !ok {
+		rec, _ = domain.NewIdempotencyRecord(key, payloadHash, 0, nil, time.Now(), time.Hour)
+		f.records[key] = rec
+	}
+	return rec, rec.HasResponse(), rec.Match(payloadHash)
+}
+
+func (f *fakeIdempotency) RecordResponse(_ context.Context, key, payloadHash string, statusCode int, body []byte) error {
+	rec, err := domain.NewIdempotencyRecord(key, payloadHash, statusCode, body, time.Now(), time.Hour)
+	if err == nil && !f.records[key].HasResponse() {
+		f.records[key] = rec
+	}
+	return err
+}
+
+func newIdempotentHandler(uc *mockUseCase) (*Handler, *fakeIdempotency) {
+	records := &fakeIdempotency{records: make(map[string]domain.IdempotencyRecord)}
+	return NewHandler(uc, WithIdempotencyRecords(records)), records
+}
+
+func TestWebhookReplaysOriginalResponse(t *testing.T) {
+	uc := &mockUseCase{processErr: domain.ErrAmountOutOfRange}
+	h, _ := newIdempotentHandler(uc)
+	body := buildWebhookBody("PURCHASE", "APPROVED", "")
+
+	first := doSignedPost(h, body, nil)
+	if first.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", first.Code)
+	}
+	// The retry is not reprocessed: a now-passing use case does not change the answer.
+	uc.processErr, uc.processResult = nil, ports.ProcessTransactionResult{TransactionID: "tx1"}
+	retry := doSignedPost(h, body, nil)
+	if retry.Code != first.Code || retry.Body.String() != first.Body.String() {
+		t.Errorf("expected the original response replayed, got %d %s", retry.Code, retry.Body)
+	}
+	if retry.Header().Get(replayedHeader) != "true" || first.Header().Get(replayedHeader) != "" {
+		t.Errorf("expected only the replay to carry %s", replayedHeader)
+	}
+}
+
+func TestWebhookPayloadHashIsCanonical(t *testing.T) {
+	h, _ := newIdempotentHandler(&mockUseCase{processResult: ports.ProcessTransactionResult{TransactionID: "tx1"}})
+	body := buildWebhookBody("PURCHASE", "APPROVED", "")
+	doSignedPost(h, body, nil)
+
+	// Same payload, different whitespace and key order.
+	var fields map[string]any
+	json.Unmarshal(body, &fields)
+	reordered, _ := json.MarshalIndent(fields, "", "  ")
+	if w := doSignedPost(h, reordered, nil); w.Code != http.StatusOK || w.Header().Get(replayedHeader) != "true" {
+		t.Errorf("expected a replay for the same payload, got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestWebhookIdempotencyKeyReused(t *testing.T) {
+	h, _ := newIdempotentHandler(&mockUseCase{processResult: ports.ProcessTransactionResult{TransactionID: "tx1"}})
+	doSignedPost(h, buildWebhookBody("PURCHASE", "APPROVED", ""), nil)
+
+	w := doSignedPost(h, buildWebhookBody("PURCHASE", "REJECTED", ""), nil)
+	var resp ErrorResponseDTO
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusUnprocessableEntity || resp.Code != "IDEMPOTENCY_KEY_REUSED" || !strings.Contains(resp.Error, `"idem1"`) {
+		t.Errorf("expected 422 IDEMPOTENCY_KEY_REUSED, got %d %+v", w.Code, resp)
+	}
+}
+
+func TestWebhookTransientResponsesNotRecorded(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		uc   *mockUseCase
+	}{
+		{"parked", &mockUseCase{processResult: ports.ProcessTransactionResult{TransactionID: "tx1", Parked: true}}},
+		{"original not found", &mockUseCase{processErr: domain.ErrTransactionNotFound}},
+		{"internal error", &mockUseCase{processErr: context.DeadlineExceeded}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h, records := newIdempotentHandler(tc.uc)
+			doSignedPost(h, buildWebhookBody("REFUND", "APPROVED", "tx0"), nil)
+			if rec := records.records["idem1"]; rec.HasResponse() || rec.PayloadHash == "" {
+				t.Errorf("expected the key claimed with no response, got %+v", rec)
+			}
+
+			// The claim still refuses another payload under the key.
+			w := doSignedPost(h, buildWebhookBody("REFUND", "APPROVED", "tx9"), nil)
+			var resp ErrorResponseDTO
+			json.NewDecoder(w.Body).Decode(&resp)
+			if w.Code != http.StatusUnprocessableEntity || resp.Code != "IDEMPOTENCY_KEY_REUSED" {
+				t.Errorf("expected 422 IDEMPOTENCY_KEY_REUSED, got %d %+v", w.Code, resp)
+			}
+		})
+	}
+}
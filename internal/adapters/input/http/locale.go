@@ -0,0 +1,50 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// supportedLanguages are the languages domain errors can be explained in.
+var supportedLanguages = []string{domain.LangEnglish, domain.LangPortuguese, domain.LangSpanish}
+
+// localizedMessage returns err's message in the client's preferred language, or err.Error() when
+// err cannot be localized or no supported language was asked for.
+func localizedMessage(r *http.Request, err error) string {
+	var localized domain.LocalizedError
+	if !errors.As(err, &localized) {
+		return err.Error()
+	}
+	if lang := preferredLanguage(r.Header.Get("Accept-Language")); lang != "" {
+		return localized.Localize(lang)
+	}
+	return err.Error()
+}
+
+// preferredLanguage picks the supported language with the highest quality in an Accept-Language
+// header ("es-MX,es;q=0.9,en;q=0.5"), matching on the primary subtag. Earlier entries win ties.
+func preferredLanguage(header string) string {
+	best, bestQ := "", 0.0
+	for part := range strings.SplitSeq(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		for _, lang := range supportedLanguages {
+			if primary == lang && q > bestQ {
+				best, bestQ = lang, q
+			}
+		}
+	}
+	return best
+}
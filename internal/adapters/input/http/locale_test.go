@@ -0,0 +1,71 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func TestPreferredLanguage(t *testing.T) {
+	cases := map[string]string{
+		"":                          "",
+		"de-DE":                     "",
+		"pt-BR":                     domain.LangPortuguese,
+		"es-MX,es;q=0.9,en;q=0.5":   domain.LangSpanish,
+		"en;q=0.4, pt-BR;q=0.8, *":  domain.LangPortuguese,
+		"fr, ES-co;q=0.7, en;q=0.7": domain.LangSpanish,
+		"pt;q=bogus, en":            domain.LangEnglish,
+		"de;q=1, pt;q=0":            "",
+	}
+	for header, want := range cases {
+		if got := preferredLanguage(header); got != want {
+			t.Errorf("%q: got %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestWebhookErrorInAcceptLanguage(t *testing.T) {
+	brl, _ := domain.LookupCurrency("BRL")
+	mock := &mockUseCase{processErr: &domain.AmountOutOfRangeError{
+		Type: domain.TypePurchase, Currency: brl, Range: domain.AmountRange{Min: 100, Max: 500_000},
+	}}
+	h := NewHandler(mock)
+	body := buildWebhookBody("PURCHASE", "APPROVED", "")
+
+	for lang, want := range map[string]string{
+		"pt-BR,pt;q=0.9": "valor fora da faixa: o valor de PURCHASE deve estar entre R$1,00 e R$5.000,00",
+		"":               "amount out of range: PURCHASE amount must be between R$1,00 and R$5.000,00",
+	} {
+		w := doSignedPost(h, body, map[string]string{"Accept-Language": lang})
+		var resp ErrorResponseDTO
+		json.NewDecoder(w.Body).Decode(&resp)
+		if w.Code != http.StatusUnprocessableEntity || resp.Code != "AMOUNT_OUT_OF_RANGE" || resp.Error != want {
+			t.Errorf("Accept-Language %q: got %d %+v", lang, w.Code, resp)
+		}
+	}
+}
+
+func TestWebhookInvalidCurrency(t *testing.T) {
+	h := NewHandler(&mockUseCase{processErr: &domain.CurrencyError{Code: "XYZ"}})
+	w := doSignedPost(h, buildWebhookBody("PURCHASE", "APPROVED", ""), map[string]string{"Accept-Language": "es"})
+	var resp ErrorResponseDTO
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusBadRequest || resp.Code != "INVALID_CURRENCY" || resp.Error != `moneda inválida: "XYZ" no es un código ISO 4217` {
+		t.Errorf("expected 400 INVALID_CURRENCY in Spanish, got %d %+v", w.Code, resp)
+	}
+}
+
+func TestWebhookCurrencyMismatchNamesField(t *testing.T) {
+	h := NewHandler(&mockUseCase{processErr: &domain.CurrencyMismatchError{Field: domain.FieldSettlementCurrency, Currency: "BRL", Expected: "USD"}})
+	w := doSignedPost(h, buildWebhookBody("PURCHASE", "APPROVED", ""), map[string]string{"Accept-Language": "pt-BR"})
+	var resp ErrorResponseDTO
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusBadRequest || resp.Code != "CURRENCY_MISMATCH" || resp.Field != "amount.settlement.currency" {
+		t.Errorf("expected 400 CURRENCY_MISMATCH on amount.settlement.currency, got %d %+v", w.Code, resp)
+	}
+	if resp.Error != "moeda divergente: amount.settlement.currency é BRL, esperado USD" {
+		t.Errorf("expected the Portuguese message, got %q", resp.Error)
+	}
+}
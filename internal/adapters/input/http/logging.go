@@ -0,0 +1,77 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/logging"
+)
+
+// HeaderRequestID correlates a request with its log lines. A valid incoming value is kept, so
+// callers can follow one request across services; otherwise the server assigns one.
+const HeaderRequestID = "X-Request-Id"
+
+// maxRequestIDLength bounds an incoming request ID before it reaches the logs.
+const maxRequestIDLength = 128
+
+// logRequests assigns the request ID, stores a logger carrying it in the request context and
+// logs one line per request once next returns. Server errors log at ERROR, client errors at WARN.
+func (h *Handler) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(HeaderRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(HeaderRequestID, id)
+		log := h.logger.With("request_id", id)
+		rec := newResponseRecorder(w)
+		next.ServeHTTP(rec, r.WithContext(logging.NewContext(r.Context(), log)))
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Duration("latency", time.Since(start)),
+		}
+		if rec.txID != "" {
+			attrs = append(attrs, slog.String("transaction_id", rec.txID), slog.String("type", rec.txType))
+		}
+		if rec.code != "" {
+			attrs = append(attrs, slog.String("code", rec.code))
+		}
+		level := slog.LevelInfo
+		switch {
+		case rec.status >= 500:
+			level = slog.LevelError
+		case rec.status >= 400:
+			level = slog.LevelWarn
+		}
+		log.LogAttrs(r.Context(), level, "http request", attrs...)
+	})
+}
+
+// validRequestID accepts up to maxRequestIDLength letters, digits and "-_.:" — enough for UUIDs
+// and most tracing IDs, and nothing that could forge a log field.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range []byte(id) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
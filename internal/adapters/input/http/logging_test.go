@@ -0,0 +1,62 @@
+package http
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func TestRequestLog(t *testing.T) {
+	var buf bytes.Buffer
+	mock := &mockUseCase{processErr: domain.ErrExceedsOriginalAmount}
+	h := NewHandler(mock, WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook/transactions", bytes.NewReader(buildWebhookBody("REFUND", "APPROVED", "tx0")))
+	req.Header.Set(HeaderRequestID, "req-42")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if got := w.Header().Get(HeaderRequestID); got != "req-42" {
+		t.Errorf("expected the incoming request ID to be kept, got %q", got)
+	}
+	line := buf.String()
+	for _, want := range []string{
+		"level=WARN", `msg="http request"`, "request_id=req-42", "method=POST", "path=/webhook/transactions",
+		"status=409", "transaction_id=tx1", "type=REFUND", "code=EXCEEDS_ORIGINAL_AMOUNT",
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("expected %q in %q", want, line)
+		}
+	}
+}
+
+func TestRequestLogAssignsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	mock := &mockUseCase{processResult: ports.ProcessTransactionResult{TransactionID: "tx1"}}
+	h := NewHandler(mock, WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	for _, incoming := range []string{"", "bad id\nlevel=ERROR", strings.Repeat("a", maxRequestIDLength+1)} {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.Header.Set(HeaderRequestID, incoming)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		id := w.Header().Get(HeaderRequestID)
+		if !validRequestID(id) || id == incoming {
+			t.Errorf("%q: expected a fresh request ID, got %q", incoming, id)
+		}
+		if !strings.Contains(buf.String(), "level=INFO") || !strings.Contains(buf.String(), "request_id="+id) {
+			t.Errorf("%q: unexpected log %q", incoming, buf.String())
+		}
+	}
+}
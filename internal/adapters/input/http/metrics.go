@@ -0,0 +1,64 @@
+package http
+
+import (
+	"cmp"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+	"github.com/jailtonjunior/pomelo/internal/metrics"
+)
+
+// Outcome codes of successful webhooks; failures use the error response code.
+const (
+	outcomeProcessed  = domain.OutcomeProcessed
+	outcomeParked     = domain.OutcomeParked
+	outcomeIdempotent = domain.OutcomeIdempotent
+)
+
+// webhookMetrics are the instruments POST /webhook/transactions records.
+type webhookMetrics struct {
+	requests   *metrics.CounterVec
+	idempotent *metrics.CounterVec
+	duration   *metrics.HistogramVec
+}
+
+func newWebhookMetrics(r *metrics.Registry) *webhookMetrics {
+	return &webhookMetrics{
+		requests: r.NewCounterVec("pomelo_webhook_requests_total",
+			"Webhooks handled, by transaction type, status and outcome code.", "type", "status", "code"),
+		idempotent: r.NewCounterVec("pomelo_webhook_idempotent_hits_total",
+			"Webhooks answered from a previous delivery of the same idempotency key.", "type"),
+		duration: r.NewHistogramVec("pomelo_webhook_duration_seconds",
+			"Webhook processing latency in seconds, signature check included.", metrics.DefBuckets, "type"),
+	}
+}
+
+// middleware records the outcome of next. Requests rejected before the body is decoded, and
+// unknown types or statuses, are labelled "unknown" to keep the series bounded.
+func (m *webhookMetrics) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := newResponseRecorder(w)
+		next.ServeHTTP(rec, r)
+		txType, txStatus := knownOrUnknown(rec.txType, rec.txStatus)
+		code := cmp.Or(rec.code, "HTTP_"+strconv.Itoa(rec.status))
+		m.requests.Inc(txType, txStatus, code)
+		if code == outcomeIdempotent {
+			m.idempotent.Inc(txType)
+		}
+		m.duration.Observe(time.Since(start).Seconds(), txType)
+	})
+}
+
+// knownOrUnknown replaces a type or status the domain does not define with "unknown".
+func knownOrUnknown(txType, status string) (string, string) {
+	if t := domain.TransactionType(txType); !t.IsOriginal() && !t.IsAdjustment() {
+		txType = "unknown"
+	}
+	if s := domain.TransactionStatus(status); s != domain.StatusApproved && s != domain.StatusRejected {
+		status = "unknown"
+	}
+	return txType, status
+}
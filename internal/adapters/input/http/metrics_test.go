@@ -0,0 +1,75 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+	"github.com/jailtonjunior/pomelo/internal/metrics"
+)
+
+func TestWebhookMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	mock := &mockUseCase{processResult: ports.ProcessTransactionResult{TransactionID: "tx1"}}
+	h := NewHandler(mock, WithMetrics(registry))
+
+	doPost(h, buildWebhookBody("PURCHASE", "APPROVED", ""))
+	mock.processErr = domain.ErrDuplicateIdempotencyKey
+	doPost(h, buildWebhookBody("PURCHASE", "APPROVED", ""))
+	mock.processErr = domain.ErrExceedsOriginalAmount
+	doPost(h, buildWebhookBody("REFUND", "APPROVED", "tx0"))
+	doPost(h, []byte("not json"))
+
+	requests, idempotent := h.webhookMetrics.requests, h.webhookMetrics.idempotent
+	for _, c := range []struct {
+		labels []string
+		want   float64
+	}{
+		{[]string{"PURCHASE", "APPROVED", "PROCESSED"}, 1},
+		{[]string{"PURCHASE", "APPROVED", "IDEMPOTENT"}, 1},
+		{[]string{"REFUND", "APPROVED", "EXCEEDS_ORIGINAL_AMOUNT"}, 1},
+		{[]string{"unknown", "unknown", "BAD_REQUEST"}, 1},
+	} {
+		if got := requests.Value(c.labels...); got != c.want {
+			t.Errorf("requests %v: expected %v, got %v", c.labels, c.want, got)
+		}
+	}
+	if got := idempotent.Value("PURCHASE"); got != 1 {
+		t.Errorf("expected 1 idempotent hit, got %v", got)
+	}
+	if got := h.webhookMetrics.duration.Count("PURCHASE"); got != 2 {
+		t.Errorf("expected 2 PURCHASE latency observations, got %d", got)
+	}
+
+	w := doGet(h, "/metrics")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != metrics.ContentType {
+		t.Fatalf("unexpected /metrics response %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if body := w.Body.String(); !strings.Contains(body, `pomelo_webhook_requests_total{type="REFUND",status="APPROVED",code="EXCEEDS_ORIGINAL_AMOUNT"} 1`) {
+		t.Errorf("unexpected exposition:\n%s", body)
+	}
+}
+
+func TestWebhookMetricsCountSignatureRejections(t *testing.T) {
+	registry := metrics.NewRegistry()
+	mock := &mockUseCase{}
+	h := NewHandler(mock, WithMetrics(registry), WithSignatureVerifier(NewSignatureVerifier([]string{"s"}, 0)))
+	req := httptest.NewRequest(http.MethodPost, "/webhook/transactions", bytes.NewReader(buildWebhookBody("PURCHASE", "APPROVED", "")))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := h.webhookMetrics.requests.Value("unknown", "unknown", "MISSING_SIGNATURE"); got != 1 {
+		t.Errorf("expected the rejection to be counted, got %v", got)
+	}
+}
+
+func TestMetricsRouteRequiresOption(t *testing.T) {
+	if w := doGet(NewHandler(&mockUseCase{}), "/metrics"); w.Code != http.StatusNotFound {
+		t.Errorf("expected the route to be missing without WithMetrics, got %d", w.Code)
+	}
+}
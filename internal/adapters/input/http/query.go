@@ -0,0 +1,128 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// HeaderNextCursor carries the cursor of the next GET /transactions page; absent on the last page.
+const HeaderNextCursor = "X-Next-Cursor"
+
+// cursorToken is the decoded form of the opaque cursor. It records the sort it was issued for,
+// since a keyset position is meaningless under a different order.
+type cursorToken struct {
+	Sort      string `json:"s"`
+	CreatedAt int64  `json:"t"`
+	Amount    int64  `json:"a"`
+	ID        string `json:"i"`
+}
+
+func encodeCursor(sort string, c ports.TransactionCursor) string {
+	b, _ := json.Marshal(cursorToken{Sort: sort, CreatedAt: c.CreatedAt.UnixNano(), Amount: c.Amount, ID: c.ID})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(sort, s string) (*ports.TransactionCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	var tok cursorToken
+	if err := json.Unmarshal(b, &tok); err != nil || tok.ID == "" {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	if tok.Sort != sort {
+		return nil, fmt.Errorf("cursor was issued for sort %q, not %q", tok.Sort, sort)
+	}
+	return &ports.TransactionCursor{CreatedAt: time.Unix(0, tok.CreatedAt).UTC(), Amount: tok.Amount, ID: tok.ID}, nil
+}
+
+// parseTransactionQuery reads the GET /transactions query string. It returns the normalized sort
+// (e.g. "-amount") so the next cursor can be bound to it.
+func parseTransactionQuery(v url.Values) (ports.TransactionQuery, string, error) {
+	q := ports.TransactionQuery{
+		UserID:      v.Get("user_id"),
+		CardID:      v.Get("card_id"),
+		MerchantID:  v.Get("merchant_id"),
+		MerchantMCC: v.Get("mcc"),
+		Currency:    v.Get("currency"),
+	}
+
+	if status := v.Get("status"); status != "" {
+		q.Status = domain.TransactionStatus(status)
+		if q.Status != domain.StatusApproved && q.Status != domain.StatusRejected {
+			return q, "", fmt.Errorf("status must be APPROVED or REJECTED, got: %q", status)
+		}
+	}
+	if txType := v.Get("type"); txType != "" {
+		q.Type = domain.TransactionType(txType)
+		if !q.Type.IsOriginal() && !q.Type.IsAdjustment() {
+			return q, "", fmt.Errorf("unknown type %q", txType)
+		}
+	}
+
+	var err error
+	if q.MinAmount, err = parseAmount(v, "min_amount"); err != nil {
+		return q, "", err
+	}
+	if q.MaxAmount, err = parseAmount(v, "max_amount"); err != nil {
+		return q, "", err
+	}
+	if q.CreatedFrom, err = parseTime(v, "created_from"); err != nil {
+		return q, "", err
+	}
+	if q.CreatedTo, err = parseTime(v, "created_to"); err != nil {
+		return q, "", err
+	}
+
+	sort := v.Get("sort")
+	if sort == "" {
+		sort = string(ports.SortByCreatedAt)
+	}
+	q.SortBy = ports.TransactionSortField(strings.TrimPrefix(sort, "-"))
+	q.Descending = strings.HasPrefix(sort, "-")
+
+	if limit := v.Get("limit"); limit != "" {
+		if q.Limit, err = strconv.Atoi(limit); err != nil || q.Limit <= 0 {
+			return q, "", fmt.Errorf("limit must be a positive integer, got: %q", limit)
+		}
+	}
+	if cursor := v.Get("cursor"); cursor != "" {
+		if q.After, err = decodeCursor(sort, cursor); err != nil {
+			return q, "", err
+		}
+	}
+	return q, sort, nil
+}
+
+func parseAmount(v url.Values, key string) (*int64, error) {
+	s := v.Get(key)
+	if s == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative amount in cents, got: %q", key, s)
+	}
+	return &n, nil
+}
+
+func parseTime(v url.Values, key string) (time.Time, error) {
+	s := v.Get(key)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be RFC3339: %w", key, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func TestParseTransactionQuery(t *testing.T) {
+	v := url.Values{
+		"user_id":      {"u1"},
+		"card_id":      {"card1"},
+		"merchant_id":  {"m1"},
+		"mcc":          {"5411"},
+		"status":       {"APPROVED"},
+		"type":         {"PURCHASE"},
+		"currency":     {"BRL"},
+		"min_amount":   {"100"},
+		"max_amount":   {"5000"},
+		"created_from": {"2024-06-01T00:00:00Z"},
+		"created_to":   {"2024-06-02T00:00:00Z"},
+		"sort":         {"-amount"},
+		"limit":        {"20"},
+	}
+	q, sort, err := parseTransactionQuery(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sort != "-amount" || q.SortBy != ports.SortByAmount || !q.Descending {
+		t.Errorf("unexpected sort: %q %+v", sort, q)
+	}
+	if q.UserID != "u1" || q.CardID != "card1" || q.MerchantID != "m1" || q.MerchantMCC != "5411" || q.Currency != "BRL" {
+		t.Errorf("unexpected string filters: %+v", q)
+	}
+	if q.Status != domain.StatusApproved || q.Type != domain.TypePurchase {
+		t.Errorf("unexpected status/type: %+v", q)
+	}
+	if *q.MinAmount != 100 || *q.MaxAmount != 5000 || q.Limit != 20 {
+		t.Errorf("unexpected amounts/limit: %+v", q)
+	}
+	if !q.CreatedFrom.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) || !q.CreatedTo.Equal(time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected created range: %+v", q)
+	}
+}
+
+func TestParseTransactionQueryDefaults(t *testing.T) {
+	q, sort, err := parseTransactionQuery(url.Values{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sort != "created_at" || q.SortBy != ports.SortByCreatedAt || q.Descending || q.Limit != 0 || q.After != nil {
+		t.Errorf("unexpected defaults: %q %+v", sort, q)
+	}
+	if q.MinAmount != nil || q.MaxAmount != nil {
+		t.Errorf("expected no amount bounds, got %+v", q)
+	}
+}
+
+func TestParseTransactionQueryInvalid(t *testing.T) {
+	cases := map[string]url.Values{
+		"status":       {"status": {"PENDING"}},
+		"type":         {"type": {"CASHBACK"}},
+		"min_amount":   {"min_amount": {"abc"}},
+		"negative max": {"max_amount": {"-1"}},
+		"created_from": {"created_from": {"yesterday"}},
+		"limit zero":   {"limit": {"0"}},
+		"limit text":   {"limit": {"ten"}},
+		"cursor":       {"cursor": {"%%%"}},
+	}
+	for name, v := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := parseTransactionQuery(v); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := ports.TransactionCursor{CreatedAt: time.Date(2024, 6, 1, 10, 0, 0, 123, time.UTC), Amount: 1500, ID: "tx1"}
+	v := url.Values{"sort": {"-created_at"}, "cursor": {encodeCursor("-created_at", want)}}
+	q, _, err := parseTransactionQuery(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.After == nil || !q.After.CreatedAt.Equal(want.CreatedAt) || q.After.Amount != want.Amount || q.After.ID != want.ID {
+		t.Errorf("expected %+v, got %+v", want, q.After)
+	}
+}
+
+func TestCursorBoundToSort(t *testing.T) {
+	cursor := encodeCursor("amount", ports.TransactionCursor{ID: "tx1"})
+	if _, _, err := parseTransactionQuery(url.Values{"sort": {"-amount"}, "cursor": {cursor}}); err == nil {
+		t.Error("expected error for a cursor issued under another sort")
+	}
+}
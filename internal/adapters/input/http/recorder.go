@@ -0,0 +1,54 @@
+package http
+
+import "net/http"
+
+// responseRecorder captures the status of a response and the annotations handlers attach through
+// recordOutcome and recordTransaction, for the metrics and request log middlewares.
+type responseRecorder struct {
+	http.ResponseWriter
+	status                 int
+	code                   string
+	txID, txType, txStatus string
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// recorders returns every responseRecorder w wraps, outermost last.
+func recorders(w http.ResponseWriter) []*responseRecorder {
+	var recs []*responseRecorder
+	for {
+		if rec, ok := w.(*responseRecorder); ok {
+			recs = append(recs, rec)
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return recs
+		}
+		w = u.Unwrap()
+	}
+}
+
+// recordOutcome labels the response with code; it does nothing outside a recorded request.
+func recordOutcome(w http.ResponseWriter, code string) {
+	for _, rec := range recorders(w) {
+		rec.code = code
+	}
+}
+
+// recordTransaction labels the response with the webhook's transaction.
+func recordTransaction(w http.ResponseWriter, id, txType, status string) {
+	for _, rec := range recorders(w) {
+		rec.txID, rec.txType, rec.txStatus = id, txType, status
+	}
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+	"github.com/jailtonjunior/pomelo/internal/logging"
+)
+
+// rejectable reports whether a webhook answered with status may be accepted later, once the
+// purchase arrives or the data is corrected, and so is worth keeping for reprocessing.
+func rejectable(status int) bool {
+	return status == http.StatusNotFound || status == http.StatusConflict || status == http.StatusUnprocessableEntity
+}
+
+// trackRejection keeps a rejected webhook in the dead-letter store and closes the open entry of
+// an accepted retry. A failure is only logged: the response already went out.
+func (h *Handler) trackRejection(ctx context.Context, rec *responseRecorder, dto *WebhookRequestDTO, payload []byte, processErr error) {
+	ctx = context.WithoutCancel(ctx)
+	var err error
+	switch {
+	case processErr != nil && rejectable(rec.status):
+		err = h.rejections.RecordRejection(ctx, ports.RecordRejectionCommand{
+			TransactionID:  dto.ID,
+			IdempotencyKey: dto.Event.IdempotencyKey,
+			Payload:        payload,
+			StatusCode:     rec.status,
+			Code:           rec.code,
+			Error:          processErr.Error(),
+		})
+	case rec.code == outcomeProcessed || rec.code == outcomeParked || rec.code == outcomeIdempotent:
+		err = h.rejections.ResolveRejection(ctx, dto.Event.IdempotencyKey)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("rejected webhook not tracked", "transaction_id", dto.ID, "err", err)
+	}
+}
+
+func (h *Handler) handleListRejections(w http.ResponseWriter, r *http.Request) {
+	rejections, err := h.rejections.ListRejections(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			writeError(w, http.StatusBadRequest, err.Error(), "INVALID_QUERY")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
+		return
+	}
+	dtos := make([]RejectedWebhookDTO, len(rejections))
+	for i, rw := range rejections {
+		dtos[i] = NewRejectedWebhookDTO(rw)
+	}
+	writeJSON(w, http.StatusOK, dtos)
+}
+
+func (h *Handler) handleGetRejection(w http.ResponseWriter, r *http.Request) {
+	rw, err := h.rejections.GetRejection(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeRejectionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, NewRejectedWebhookDTO(rw))
+}
+
+// handleReprocessRejection answers 200 with the updated entry whether or not the webhook went
+// through this time; resolved and last_reprocess_error tell which.
+func (h *Handler) handleReprocessRejection(w http.ResponseWriter, r *http.Request) {
+	rw, err := h.rejections.ReprocessRejection(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeRejectionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, NewRejectedWebhookDTO(rw))
+}
+
+func writeRejectionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrRejectionNotFound):
+		writeError(w, http.StatusNotFound, err.Error(), "NOT_FOUND")
+	case errors.Is(err, domain.ErrRejectionResolved):
+		writeError(w, http.StatusConflict, err.Error(), "REJECTION_RESOLVED")
+	default:
+		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
+	}
+}
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// fakeRejections records the calls the handler makes and serves a fixed entry.
+type fakeRejections struct {
+	recorded   []ports.RecordRejectionCommand
+	resolved   []string
+	entry      domain.RejectedWebhook
+	reprocess  error
+	listStatus string
+}
+
+func (f *fakeRejections) RecordRejection(_ context.Context, cmd ports.RecordRejectionCommand) error {
+	f.recorded = append(f.recorded, cmd)
+	return nil
+}
+
+func (f *fakeRejections) ResolveRejection(_ context.Context, key string) error {
+	f.resolved = append(f.resolved, key)
+	return nil
+}
+
+func (f *fakeRejections) ListRejections(_ context.Context, status string) ([]domain.RejectedWebhook, error) {
+	if status == "bogus" {
+		return nil, domain.ErrInvalidInput
+	}
+	f.listStatus = status
+	return []domain.RejectedWebhook{f.entry}, nil
+}
+
+func (f *fakeRejections) GetRejection(_ context.Context, id string) (domain.RejectedWebhook, error) {
+	if id != f.entry.ID {
+		return domain.RejectedWebhook{}, domain.ErrRejectionNotFound
+	}
+	return f.entry, nil
+}
+
+func (f *fakeRejections) ReprocessRejection(ctx context.Context, id string) (domain.RejectedWebhook, error) {
+	rw, err := f.GetRejection(ctx, id)
+	if err != nil {
+		return rw, err
+	}
+	if f.reprocess != nil {
+		return rw, f.reprocess
+	}
+	return rw.Reprocessed(time.Now(), nil)
+}
+
+func TestWebhookRejectionsCaptured(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		result     ports.ProcessTransactionResult
+		wantCode   string
+		wantStatus int
+		resolved   bool
+	}{
+		{"not found", domain.ErrTransactionNotFound, ports.ProcessTransactionResult{}, "NOT_FOUND", http.StatusNotFound, false},
+		{"conflict", domain.ErrExceedsOriginalAmount, ports.ProcessTransactionResult{}, "EXCEEDS_ORIGINAL_AMOUNT", http.StatusConflict, false},
+		{"unprocessable", domain.ErrOriginalTypeMismatch, ports.ProcessTransactionResult{}, "ORIGINAL_TYPE_MISMATCH", http.StatusUnprocessableEntity, false},
+		{"bad request", domain.ErrNegativeAmount, ports.ProcessTransactionResult{}, "", 0, false},
+		{"processed", nil, ports.ProcessTransactionResult{TransactionID: "tx1"}, "", 0, true},
+		{"duplicate", domain.ErrDuplicateIdempotencyKey, ports.ProcessTransactionResult{TransactionID: "tx1", Idempotent: true}, "", 0, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rejections := &fakeRejections{}
+			h := NewHandler(&mockUseCase{processErr: tc.err, processResult: tc.result}, WithRejectedWebhooks(rejections))
+			body := buildWebhookBody("REFUND", "APPROVED", "tx0")
+			doSignedPost(h, body, nil)
+
+			if tc.wantCode == "" {
+				if len(rejections.recorded) != 0 {
+					t.Errorf("expected nothing captured, got %+v", rejections.recorded)
+				}
+			} else if len(rejections.recorded) != 1 {
+				t.Fatalf("expected one capture, got %+v", rejections.recorded)
+			} else if cmd := rejections.recorded[0]; cmd.Code != tc.wantCode || cmd.StatusCode != tc.wantStatus ||
+				cmd.TransactionID != "tx1" || cmd.IdempotencyKey != "idem1" || string(cmd.Payload) != string(body) || cmd.Error == "" {
+				t.Errorf("unexpected capture %+v", cmd)
+			}
+			if got := len(rejections.resolved) == 1 && rejections.resolved[0] == "idem1"; got != tc.resolved {
+				t.Errorf("expected resolved %v, got %v", tc.resolved, rejections.resolved)
+			}
+		})
+	}
+}
+
+func TestRejectedWebhookRoutes(t *testing.T) {
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	entry, _ := domain.NewRejectedWebhook("rej1", "adj1", "idem1", buildWebhookBody("REFUND", "APPROVED", "tx0"), 404, "NOT_FOUND", "transaction not found", at)
+	rejections := &fakeRejections{entry: entry}
+	h := NewHandler(&mockUseCase{}, WithRejectedWebhooks(rejections))
+	do := func(method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux := http.NewServeMux()
+		h.RegisterRoutes(mux)
+		mux.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	w := do(http.MethodGet, "/webhooks/rejected?status=open")
+	var list []map[string]json.RawMessage
+	json.NewDecoder(w.Body).Decode(&list)
+	if w.Code != http.StatusOK || len(list) != 1 || string(list[0]["code"]) != `"NOT_FOUND"` || rejections.listStatus != "open" {
+		t.Fatalf("unexpected list %d %v", w.Code, list)
+	}
+	var payload WebhookRequestDTO
+	if err := json.Unmarshal(list[0]["payload"], &payload); err != nil || payload.ID != "tx1" {
+		t.Errorf("expected the payload as JSON, got %s (err %v)", list[0]["payload"], err)
+	}
+	if w := do(http.MethodGet, "/webhooks/rejected?status=bogus"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown status, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/webhooks/rejected/rej1"); w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/webhooks/rejected/nope"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+
+	w = do(http.MethodPost, "/webhooks/rejected/rej1/reprocess")
+	var dto RejectedWebhookDTO
+	json.NewDecoder(w.Body).Decode(&dto)
+	if w.Code != http.StatusOK || !dto.Resolved || dto.ResolvedAt == nil || dto.Reprocesses != 1 {
+		t.Errorf("expected the entry resolved, got %d %+v", w.Code, dto)
+	}
+	rejections.reprocess = domain.ErrRejectionResolved
+	if w := do(http.MethodPost, "/webhooks/rejected/rej1/reprocess"); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a resolved entry, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/webhooks/rejected/nope/reprocess"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestRejectedWebhookRoutesDisabled(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHandler(&mockUseCase{}).RegisterRoutes(mux)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks/rejected", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without a dead-letter store, got %d", w.Code)
+	}
+}
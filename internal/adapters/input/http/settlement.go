@@ -0,0 +1,50 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/input/settlement"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// handleReconcileSettlement reconciles the settlement file sent as the request body, in the
+// format named by ?format (csv by default), over the window of ?date or ?from and ?to.
+func (h *Handler) handleReconcileSettlement(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, to, err := settlement.ParseWindow(q.Get("date"), q.Get("from"), q.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "INVALID_QUERY")
+		return
+	}
+	format := q.Get("format")
+	if format == "" {
+		format = settlement.FormatCSV
+	}
+	records, err := settlement.Parse(r.Body, format)
+	if err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+	report, err := h.settlement.ReconcileSettlement(r.Context(), ports.ReconcileSettlementCommand{Records: records, From: from, To: to})
+	if err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, NewSettlementReportDTO(report))
+}
+
+func writeSettlementError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeBodyError(w, err)
+	case errors.Is(err, domain.ErrInvalidSettlementFile):
+		writeError(w, http.StatusBadRequest, err.Error(), "INVALID_SETTLEMENT_FILE")
+	case errors.Is(err, domain.ErrInvalidInput):
+		writeError(w, http.StatusBadRequest, err.Error(), "INVALID_QUERY")
+	default:
+		writeError(w, http.StatusInternalServerError, "internal server error", "INTERNAL_ERROR")
+	}
+}
@@ -0,0 +1,100 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// fakeSettlement reconciles with domain.Reconcile against a fixed set of transactions and,
+// like the service, refuses an empty window.
+type fakeSettlement struct {
+	expected []domain.ExpectedSettlement
+	cmd      ports.ReconcileSettlementCommand
+}
+
+func (f *fakeSettlement) ReconcileSettlement(_ context.Context, cmd ports.ReconcileSettlementCommand) (domain.SettlementReport, error) {
+	f.cmd = cmd
+	if !cmd.From.IsZero() && !cmd.From.Before(cmd.To) {
+		return domain.SettlementReport{}, domain.ErrInvalidInput
+	}
+	return domain.Reconcile(cmd.Records, f.expected, cmd.From, cmd.To)
+}
+
+func newFakeSettlement() *fakeSettlement {
+	at := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	brl := func(n int64) domain.Money { return domain.Money{Amount: n, Currency: "BRL"} }
+	return &fakeSettlement{expected: []domain.ExpectedSettlement{
+		{TransactionID: "tx1", Type: domain.TypePurchase, Amount: brl(1000), OccurredAt: at},
+		{TransactionID: "tx2", Type: domain.TypePurchase, Amount: brl(500), OccurredAt: at},
+	}}
+}
+
+func TestReconcileSettlement(t *testing.T) {
+	fake := newFakeSettlement()
+	h := NewHandler(&mockUseCase{}, WithSettlement(fake))
+
+	rec := serveRoute(h, http.MethodPost, "/settlements/reconcile?date=2024-06-01", "transaction_id,amount,currency\ntx1,1000,BRL\ntx9,10,BRL\n")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if !fake.cmd.From.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) || !fake.cmd.To.Equal(time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the window of 2024-06-01, got %v..%v", fake.cmd.From, fake.cmd.To)
+	}
+	var dto SettlementReportDTO
+	json.NewDecoder(rec.Body).Decode(&dto)
+	if dto.Records != 2 || dto.Matched != 1 || dto.MissingInWebhooks != 1 || dto.MissingInSettlement != 1 || dto.Clean || len(dto.Items) != 3 {
+		t.Fatalf("unexpected report %+v", dto)
+	}
+	if ghost := dto.Items[1]; ghost.Status != domain.ReconciledMissingInWebhooks || ghost.Expected != nil || ghost.Settled == nil || ghost.Line != 3 {
+		t.Errorf("unexpected missing-in-webhooks item %+v", ghost)
+	}
+	if missing := dto.Items[2]; missing.TransactionID != "tx2" || missing.Settled != nil || missing.Expected == nil || missing.Line != 0 {
+		t.Errorf("unexpected missing-in-settlement item %+v", missing)
+	}
+
+	fixed := "D" + "tx1" + strings.Repeat(" ", 33) + "000000000001000BRL\nD" + "tx2" + strings.Repeat(" ", 33) + "000000000000500BRL\nT000000002\n"
+	rec = serveRoute(h, http.MethodPost, "/settlements/reconcile?format=fixed", fixed)
+	json.NewDecoder(rec.Body).Decode(&dto)
+	if rec.Code != http.StatusOK || !dto.Clean || dto.Matched != 2 {
+		t.Errorf("expected a clean fixed-width report, got %d %+v", rec.Code, dto)
+	}
+}
+
+func TestReconcileSettlementErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		target   string
+		body     string
+		wantCode string
+	}{
+		{"bad date", "/settlements/reconcile?date=yesterday", "", "INVALID_QUERY"},
+		{"unknown format", "/settlements/reconcile?format=xml", "", "INVALID_QUERY"},
+		{"empty window", "/settlements/reconcile?from=2024-06-01T00:00:00Z&to=2024-06-01T00:00:00Z", "transaction_id,amount,currency\n", "INVALID_QUERY"},
+		{"malformed file", "/settlements/reconcile", "transaction_id,amount\n", "INVALID_SETTLEMENT_FILE"},
+		{"repeated row", "/settlements/reconcile", "transaction_id,amount,currency\ntx1,1000,BRL\ntx1,1000,BRL\n", "INVALID_SETTLEMENT_FILE"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHandler(&mockUseCase{}, WithSettlement(newFakeSettlement()))
+			rec := serveRoute(h, http.MethodPost, tc.target, tc.body)
+			var body ErrorResponseDTO
+			json.NewDecoder(rec.Body).Decode(&body)
+			if rec.Code != http.StatusBadRequest || body.Code != tc.wantCode {
+				t.Errorf("expected 400 %s, got %d %s", tc.wantCode, rec.Code, body.Code)
+			}
+		})
+	}
+
+	h := NewHandler(&mockUseCase{}, WithSettlement(newFakeSettlement()), WithMaxBodyBytes(16))
+	rec := serveRoute(h, http.MethodPost, "/settlements/reconcile", "transaction_id,amount,currency\ntx1,1000,BRL\n")
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for a file over the body limit, got %d", rec.Code)
+	}
+}
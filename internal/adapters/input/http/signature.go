@@ -0,0 +1,77 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/signature"
+)
+
+// DefaultMaxClockSkew is how far X-Timestamp may drift from the server clock.
+const DefaultMaxClockSkew = 5 * time.Minute
+
+// SignatureVerifier rejects webhooks whose HMAC signature does not match any active secret
+// or whose timestamp is outside the allowed window (replay protection).
+type SignatureVerifier struct {
+	secrets [][]byte
+	maxSkew time.Duration
+	now     func() time.Time
+}
+
+// NewSignatureVerifier builds a verifier for the given secrets. Several secrets may be active
+// at once to support key rotation. maxSkew <= 0 uses DefaultMaxClockSkew.
+func NewSignatureVerifier(secrets []string, maxSkew time.Duration) *SignatureVerifier {
+	if maxSkew <= 0 {
+		maxSkew = DefaultMaxClockSkew
+	}
+	v := &SignatureVerifier{maxSkew: maxSkew, now: time.Now}
+	for _, s := range secrets {
+		if s != "" {
+			v.secrets = append(v.secrets, []byte(s))
+		}
+	}
+	return v
+}
+
+// Middleware verifies X-Signature / X-Timestamp / X-Endpoint before calling next.
+// The body is buffered and restored so next can decode it as usual.
+func (v *SignatureVerifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sig := r.Header.Get(signature.HeaderSignature)
+		ts := r.Header.Get(signature.HeaderTimestamp)
+		endpoint := r.Header.Get(signature.HeaderEndpoint)
+		if sig == "" || ts == "" || endpoint == "" {
+			writeError(w, http.StatusUnauthorized, "missing signature headers", "MISSING_SIGNATURE")
+			return
+		}
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "invalid signature timestamp", "INVALID_SIGNATURE")
+			return
+		}
+		if skew := v.now().Sub(time.Unix(unix, 0)); skew > v.maxSkew || skew < -v.maxSkew {
+			writeError(w, http.StatusUnauthorized, "signature timestamp outside allowed window", "STALE_SIGNATURE")
+			return
+		}
+		// The signed endpoint must be the one being called, otherwise a signature captured
+		// for another route could be replayed here.
+		if endpoint != r.URL.Path {
+			writeError(w, http.StatusUnauthorized, "signed endpoint does not match request path", "INVALID_SIGNATURE")
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		if !signature.Verify(v.secrets, ts, endpoint, body, sig) {
+			writeError(w, http.StatusUnauthorized, "invalid webhook signature", "INVALID_SIGNATURE")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
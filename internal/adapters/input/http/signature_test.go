@@ -0,0 +1,111 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/signature"
+)
+
+func doSignedPost(h *Handler, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhook/transactions", bytes.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func signedHeaders(secret string, ts time.Time, endpoint string, body []byte) map[string]string {
+	unix := strconv.FormatInt(ts.Unix(), 10)
+	return map[string]string{
+		signature.HeaderSignature: signature.Sign([]byte(secret), unix, endpoint, body),
+		signature.HeaderTimestamp: unix,
+		signature.HeaderEndpoint:  endpoint,
+	}
+}
+
+func newSignedHandler(secrets ...string) *Handler {
+	mock := &mockUseCase{processResult: ports.ProcessTransactionResult{TransactionID: "tx1"}}
+	return NewHandler(mock, WithSignatureVerifier(NewSignatureVerifier(secrets, time.Minute)))
+}
+
+func assertErrorCode(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if w.Code != status {
+		t.Errorf("expected %d, got %d", status, w.Code)
+	}
+	var resp ErrorResponseDTO
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Code != code {
+		t.Errorf("expected %s, got %s", code, resp.Code)
+	}
+}
+
+func TestSignatureValid(t *testing.T) {
+	body := buildWebhookBody("PURCHASE", "APPROVED", "")
+	w := doSignedPost(newSignedHandler("secret"), body, signedHeaders("secret", time.Now(), "/webhook/transactions", body))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSignatureKeyRotation(t *testing.T) {
+	body := buildWebhookBody("PURCHASE", "APPROVED", "")
+	h := newSignedHandler("new-secret", "old-secret")
+	w := doSignedPost(h, body, signedHeaders("old-secret", time.Now(), "/webhook/transactions", body))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestSignatureMissingHeaders(t *testing.T) {
+	w := doSignedPost(newSignedHandler("secret"), buildWebhookBody("PURCHASE", "APPROVED", ""), nil)
+	assertErrorCode(t, w, http.StatusUnauthorized, "MISSING_SIGNATURE")
+}
+
+func TestSignatureInvalid(t *testing.T) {
+	body := buildWebhookBody("PURCHASE", "APPROVED", "")
+	w := doSignedPost(newSignedHandler("secret"), body, signedHeaders("wrong", time.Now(), "/webhook/transactions", body))
+	assertErrorCode(t, w, http.StatusUnauthorized, "INVALID_SIGNATURE")
+}
+
+func TestSignatureTamperedBody(t *testing.T) {
+	body := buildWebhookBody("PURCHASE", "APPROVED", "")
+	headers := signedHeaders("secret", time.Now(), "/webhook/transactions", body)
+	tampered := buildWebhookBody("REFUND", "APPROVED", "tx0")
+	w := doSignedPost(newSignedHandler("secret"), tampered, headers)
+	assertErrorCode(t, w, http.StatusUnauthorized, "INVALID_SIGNATURE")
+}
+
+func TestSignatureStaleTimestamp(t *testing.T) {
+	body := buildWebhookBody("PURCHASE", "APPROVED", "")
+	w := doSignedPost(newSignedHandler("secret"), body, signedHeaders("secret", time.Now().Add(-time.Hour), "/webhook/transactions", body))
+	assertErrorCode(t, w, http.StatusUnauthorized, "STALE_SIGNATURE")
+}
+
+func TestSignatureEndpointMismatch(t *testing.T) {
+	body := buildWebhookBody("PURCHASE", "APPROVED", "")
+	w := doSignedPost(newSignedHandler("secret"), body, signedHeaders("secret", time.Now(), "/other", body))
+	assertErrorCode(t, w, http.StatusUnauthorized, "INVALID_SIGNATURE")
+}
+
+func TestSignatureNotRequiredForQueries(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	w := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	newSignedHandler("secret").RegisterRoutes(mux)
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+}
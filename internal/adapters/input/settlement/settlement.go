@@ -0,0 +1,191 @@
+// Package settlement reads the processor's daily settlement files into domain.SettlementRecord
+// rows, for the reconciliation endpoint and the reconcile subcommand.
+package settlement
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// Supported file formats.
+const (
+	FormatCSV        = "csv"
+	FormatFixedWidth = "fixed"
+)
+
+// Parse reads a file in format. Every error other than an unknown format wraps
+// domain.ErrInvalidSettlementFile and names the offending line.
+func Parse(r io.Reader, format string) ([]domain.SettlementRecord, error) {
+	switch format {
+	case FormatCSV:
+		return ParseCSV(r)
+	case FormatFixedWidth:
+		return ParseFixedWidth(r)
+	}
+	return nil, fmt.Errorf("%w: unknown settlement format %q, want %s or %s", domain.ErrInvalidInput, format, FormatCSV, FormatFixedWidth)
+}
+
+// csvColumns are the header names ParseCSV requires, in any order; other columns are ignored.
+var csvColumns = []string{"transaction_id", "amount", "currency"}
+
+// ParseCSV reads a comma-separated file whose first row names the columns. amount is in minor
+// units of currency.
+func ParseCSV(r io.Reader) ([]domain.SettlementRecord, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: missing header row", domain.ErrInvalidSettlementFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidSettlementFile, err)
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	var cols [3]int
+	for i, name := range csvColumns {
+		col, ok := index[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: line 1: missing column %s", domain.ErrInvalidSettlementFile, name)
+		}
+		cols[i] = col
+	}
+	records := []domain.SettlementRecord{}
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", domain.ErrInvalidSettlementFile, err)
+		}
+		line, _ := cr.FieldPos(0)
+		rec, err := newRecord(line, row[cols[0]], row[cols[1]], row[cols[2]])
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+}
+
+// Fixed-width layout: the first character is the record type and the fields sit at fixed
+// positions, padded with spaces (text) or zeros (numbers).
+//
+//	H<yyyymmdd>                                        header, optional
+//	D<transaction id:36><amount:15><currency:3>        one per settled transaction
+//	T<detail count:9>                                  trailer, optional
+const (
+	fixedIDWidth       = 36
+	fixedAmountWidth   = 15
+	fixedCurrencyWidth = 3
+	fixedDetailWidth   = 1 + fixedIDWidth + fixedAmountWidth + fixedCurrencyWidth
+	fixedCountWidth    = 9
+)
+
+// ParseFixedWidth reads a fixed-width file. A trailer, when present, must be the last record and
+// carry the number of detail records, so a truncated transfer is refused instead of reconciled.
+func ParseFixedWidth(r io.Reader) ([]domain.SettlementRecord, error) {
+	scanner := bufio.NewScanner(r)
+	records := []domain.SettlementRecord{}
+	line := 0
+	trailer := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if trailer > 0 {
+			return nil, fmt.Errorf("%w: line %d: record after the trailer on line %d", domain.ErrInvalidSettlementFile, line, trailer)
+		}
+		switch text[0] {
+		case 'H':
+		case 'D':
+			if len(text) < fixedDetailWidth {
+				return nil, fmt.Errorf("%w: line %d: detail record has %d characters, want %d", domain.ErrInvalidSettlementFile, line, len(text), fixedDetailWidth)
+			}
+			id := text[1 : 1+fixedIDWidth]
+			amount := text[1+fixedIDWidth : 1+fixedIDWidth+fixedAmountWidth]
+			currency := text[1+fixedIDWidth+fixedAmountWidth : fixedDetailWidth]
+			rec, err := newRecord(line, id, amount, currency)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, rec)
+		case 'T':
+			trailer = line
+			count, err := strconv.Atoi(strings.TrimSpace(text[1:min(len(text), 1+fixedCountWidth)]))
+			if err != nil || count != len(records) {
+				return nil, fmt.Errorf("%w: line %d: trailer count %q does not match the %d detail records", domain.ErrInvalidSettlementFile, line, strings.TrimSpace(text[1:]), len(records))
+			}
+		default:
+			return nil, fmt.Errorf("%w: line %d: unknown record type %q", domain.ErrInvalidSettlementFile, line, text[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidSettlementFile, err)
+	}
+	return records, nil
+}
+
+func newRecord(line int, id, amount, currency string) (domain.SettlementRecord, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return domain.SettlementRecord{}, fmt.Errorf("%w: line %d: transaction id is required", domain.ErrInvalidSettlementFile, line)
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(amount), 10, 64)
+	if err != nil {
+		return domain.SettlementRecord{}, fmt.Errorf("%w: line %d: amount must be an integer in minor units, got %q", domain.ErrInvalidSettlementFile, line, strings.TrimSpace(amount))
+	}
+	m, err := domain.NewMoney(n, strings.TrimSpace(currency))
+	if err != nil {
+		return domain.SettlementRecord{}, fmt.Errorf("%w: line %d: %w", domain.ErrInvalidSettlementFile, line, err)
+	}
+	return domain.SettlementRecord{Line: line, TransactionID: id, Amount: m}, nil
+}
+
+// ParseWindow turns the reconciliation bounds into the [from, to) window of
+// ports.ReconcileSettlementCommand. date is a UTC day (2006-01-02) and excludes from and to,
+// which are RFC 3339; all empty is an open window.
+func ParseWindow(date, from, to string) (time.Time, time.Time, error) {
+	if date != "" {
+		if from != "" || to != "" {
+			return time.Time{}, time.Time{}, errors.New("date cannot be combined with from or to")
+		}
+		day, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("date must be YYYY-MM-DD, got %q", date)
+		}
+		return day, day.AddDate(0, 0, 1), nil
+	}
+	start, err := parseBound("from", from)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := parseBound("to", to)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, end, nil
+}
+
+func parseBound(name, s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be RFC3339: %w", name, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,110 @@
+package settlement
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func detail(id string, amount int64, currency string) string {
+	return fmt.Sprintf("D%-36s%015d%s", id, amount, currency)
+}
+
+func TestParseCSV(t *testing.T) {
+	file := "Currency,Transaction_ID,settled_on,Amount\r\n" +
+		"BRL,tx1,2024-06-01,1000\r\n" +
+		"\r\n" +
+		"MXN, adj1 ,2024-06-01,250\r\n"
+	records, err := Parse(strings.NewReader(file), FormatCSV)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []domain.SettlementRecord{
+		{Line: 2, TransactionID: "tx1", Amount: domain.Money{Amount: 1000, Currency: "BRL"}},
+		{Line: 4, TransactionID: "adj1", Amount: domain.Money{Amount: 250, Currency: "MXN"}},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("expected %d records, got %+v", len(want), records)
+	}
+	for i := range want {
+		if records[i] != want[i] {
+			t.Errorf("record %d: expected %+v, got %+v", i, want[i], records[i])
+		}
+	}
+}
+
+func TestParseFixedWidth(t *testing.T) {
+	file := strings.Join([]string{
+		"H20240601",
+		detail("tx1", 1000, "BRL"),
+		"",
+		detail("adj1", 250, "BRL") + "   ",
+		"T000000002",
+	}, "\n")
+	records, err := Parse(strings.NewReader(file), FormatFixedWidth)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 2 || records[0].TransactionID != "tx1" || records[0].Amount.Amount != 1000 ||
+		records[1].Line != 4 || records[1].TransactionID != "adj1" || records[1].Amount.Currency != "BRL" {
+		t.Errorf("unexpected records %+v", records)
+	}
+
+	noTrailer, err := ParseFixedWidth(strings.NewReader(detail("tx1", 1, "BRL")))
+	if err != nil || len(noTrailer) != 1 {
+		t.Errorf("expected the trailer to be optional, got %+v (err %v)", noTrailer, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		format string
+		file   string
+		want   string
+	}{
+		{"empty csv", FormatCSV, "", "missing header row"},
+		{"missing column", FormatCSV, "transaction_id,amount\ntx1,1", "missing column currency"},
+		{"ragged row", FormatCSV, "transaction_id,amount,currency\ntx1,1", "line 2"},
+		{"bad amount", FormatCSV, "transaction_id,amount,currency\ntx1,1.50,BRL", `line 2: amount must be an integer in minor units, got "1.50"`},
+		{"negative amount", FormatCSV, "transaction_id,amount,currency\ntx1,-1,BRL", "line 2: amount cannot be negative"},
+		{"unknown currency", FormatCSV, "transaction_id,amount,currency\ntx1,1,XXY", "line 2: invalid currency"},
+		{"missing id", FormatCSV, "transaction_id,amount,currency\n ,1,BRL", "line 2: transaction id is required"},
+		{"short detail", FormatFixedWidth, "H20240601\nDtx1 100 BRL", "line 2: detail record has 12 characters, want 55"},
+		{"unknown record", FormatFixedWidth, "X", "line 1: unknown record type 'X'"},
+		{"truncated transfer", FormatFixedWidth, detail("tx1", 1, "BRL") + "\nT000000002", "line 2: trailer count"},
+		{"after trailer", FormatFixedWidth, "T000000000\n" + detail("tx1", 1, "BRL"), "line 2: record after the trailer"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.file), tc.format)
+			if !errors.Is(err, domain.ErrInvalidSettlementFile) || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected ErrInvalidSettlementFile mentioning %q, got %v", tc.want, err)
+			}
+		})
+	}
+
+	if _, err := Parse(strings.NewReader(""), "xml"); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for an unknown format, got %v", err)
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	from, to, err := ParseWindow("2024-06-01", "", "")
+	if err != nil || !from.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the UTC day, got %v..%v (err %v)", from, to, err)
+	}
+	from, to, err = ParseWindow("", "2024-06-01T03:00:00Z", "")
+	if err != nil || from.Hour() != 3 || !to.IsZero() {
+		t.Errorf("expected an open end, got %v..%v (err %v)", from, to, err)
+	}
+	for _, args := range [][3]string{{"2024-06-01", "2024-06-01T00:00:00Z", ""}, {"06/01/2024", "", ""}, {"", "", "tomorrow"}} {
+		if _, _, err := ParseWindow(args[0], args[1], args[2]); err == nil {
+			t.Errorf("expected an error for %q", args)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+const attemptsFileName = "attempts.jsonl"
+
+// AttemptLog is a durable implementation of ports.AttemptLog. Attempts are appended one JSON
+// line at a time and never rewritten; the whole log is read back into memory when opened.
+type AttemptLog struct {
+	*memory.AttemptLog
+
+	mu   sync.Mutex
+	path string
+}
+
+// OpenAttemptLog loads the attempts stored in dir, cutting off a torn last line.
+func OpenAttemptLog(dir string) (*AttemptLog, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	l := &AttemptLog{AttemptLog: memory.NewAttemptLog(), path: filepath.Join(dir, attemptsFileName)}
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *AttemptLog) AppendAttempt(ctx context.Context, a domain.WebhookAttempt) error {
+	line, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("encode webhook attempt: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open webhook attempts: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("append webhook attempt: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("fsync webhook attempts: %w", err)
+	}
+	return l.AttemptLog.AppendAttempt(ctx, a)
+}
+
+// load reads the attempts in append order. A line that does not decode is a torn write:
+// nothing after it was acknowledged, so the file is truncated there and appends resume cleanly.
+func (l *AttemptLog) load() error {
+	f, err := os.OpenFile(l.path, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read webhook attempts: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	var valid int64
+	torn := false
+	for scanner.Scan() {
+		var a domain.WebhookAttempt
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil {
+			torn = true
+			break
+		}
+		l.AttemptLog.AppendAttempt(context.Background(), a)
+		valid += int64(len(scanner.Bytes())) + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read webhook attempts: %w", err)
+	}
+	if !torn {
+		return nil
+	}
+	if err := f.Truncate(valid); err != nil {
+		return fmt.Errorf("truncate torn webhook attempt: %w", err)
+	}
+	return f.Sync()
+}
@@ -0,0 +1,79 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func TestAttemptLogContract(t *testing.T) {
+	repotest.RunAttemptLog(t, func(t *testing.T) ports.AttemptLog {
+		l, err := OpenAttemptLog(t.TempDir())
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		return l
+	})
+}
+
+func TestAttemptLogSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	receivedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	appendAttempt := func(l *AttemptLog, id, txID string) {
+		t.Helper()
+		a, _ := domain.NewWebhookAttempt(id, txID, "idem-"+id, receivedAt, domain.OutcomeProcessed, 200, []byte(`{"id":"`+txID+`"}`))
+		if err := l.AppendAttempt(ctx, a); err != nil {
+			t.Fatalf("append %s: %v", id, err)
+		}
+	}
+	ids := func(l *AttemptLog) []string {
+		all, _ := l.AllAttempts(ctx)
+		var out []string
+		for _, a := range all {
+			out = append(out, a.ID)
+		}
+		return out
+	}
+
+	l, err := OpenAttemptLog(dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	appendAttempt(l, "att1", "tx1")
+	appendAttempt(l, "att2", "tx2")
+
+	// Simulate a torn final write.
+	f, _ := os.OpenFile(filepath.Join(dir, attemptsFileName), os.O_WRONLY|os.O_APPEND, 0o644)
+	f.WriteString(`{"ID":"att3","Trans`)
+	f.Close()
+
+	reopened, err := OpenAttemptLog(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if got := ids(reopened); !slices.Equal(got, []string{"att1", "att2"}) {
+		t.Fatalf("expected att1 and att2 restored, got %v", got)
+	}
+	attempts, _ := reopened.ListAttempts(ctx, "tx2")
+	if len(attempts) != 1 || string(attempts[0].Payload) != `{"id":"tx2"}` {
+		t.Errorf("expected the tx2 payload restored, got %+v", attempts)
+	}
+
+	// The torn line was cut off, so a new append is readable after the next restart.
+	appendAttempt(reopened, "att4", "tx1")
+	again, err := OpenAttemptLog(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if got := ids(again); !slices.Equal(got, []string{"att1", "att2", "att4"}) {
+		t.Errorf("expected att4 after the restored attempts, got %v", got)
+	}
+}
@@ -0,0 +1,92 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+const authorizationsFileName = "authorizations.jsonl"
+
+// AuthorizationStore is a durable implementation of ports.AuthorizationStore. Every saved card,
+// decision and match is appended to a JSON lines journal before the in-memory copy applies it,
+// and the journal is replayed when opened. A match appends the whole authorization again; the
+// last line for an ID wins.
+type AuthorizationStore struct {
+	mem  *memory.AuthorizationStore
+	path string
+}
+
+var _ ports.AuthorizationStore = (*AuthorizationStore)(nil)
+
+// authorizationRecord is one journal line.
+type authorizationRecord struct {
+	Card          *domain.Card          `json:"card,omitempty"`
+	Authorization *domain.Authorization `json:"authorization,omitempty"`
+}
+
+// OpenAuthorizationStore loads the cards and authorizations stored in dir, cutting off a torn
+// last line.
+func OpenAuthorizationStore(dir string) (*AuthorizationStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	s := &AuthorizationStore{path: filepath.Join(dir, authorizationsFileName)}
+	s.mem = memory.NewJournaledAuthorizationStore(s.append)
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *AuthorizationStore) SaveCard(ctx context.Context, card domain.Card) error {
+	return s.mem.SaveCard(ctx, card)
+}
+
+func (s *AuthorizationStore) GetCard(ctx context.Context, id string) (domain.Card, error) {
+	return s.mem.GetCard(ctx, id)
+}
+
+func (s *AuthorizationStore) Decide(ctx context.Context, id, cardID string, decide ports.AuthorizationDecider) (domain.Authorization, error) {
+	return s.mem.Decide(ctx, id, cardID, decide)
+}
+
+func (s *AuthorizationStore) RecordAuthorization(ctx context.Context, auth domain.Authorization) (domain.Authorization, error) {
+	return s.mem.RecordAuthorization(ctx, auth)
+}
+
+func (s *AuthorizationStore) GetAuthorization(ctx context.Context, id string) (domain.Authorization, error) {
+	return s.mem.GetAuthorization(ctx, id)
+}
+
+func (s *AuthorizationStore) Match(ctx context.Context, id string, match func(domain.Authorization) domain.Authorization) (domain.Authorization, error) {
+	return s.mem.Match(ctx, id, match)
+}
+
+// Sizes reports the in-memory copy for the store gauges.
+func (s *AuthorizationStore) Sizes() map[string]int {
+	return s.mem.Sizes()
+}
+
+// append is the memory store's journal: it runs under the store's lock, so lines land in the
+// order the changes are applied.
+func (s *AuthorizationStore) append(e memory.AuthorizationEntry) error {
+	return appendLine(s.path, "authorization record", authorizationRecord{Card: e.Card, Authorization: e.Authorization})
+}
+
+func (s *AuthorizationStore) load() error {
+	return readLines(s.path, "authorization record", func(line []byte) error {
+		var rec authorizationRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return err
+		}
+		s.mem.Apply(memory.AuthorizationEntry{Card: rec.Card, Authorization: rec.Authorization})
+		return nil
+	})
+}
@@ -0,0 +1,75 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func openAuthorizationStore(t *testing.T, dir string) *AuthorizationStore {
+	t.Helper()
+	s, err := OpenAuthorizationStore(dir)
+	if err != nil {
+		t.Fatalf("open authorization store: %v", err)
+	}
+	return s
+}
+
+func TestAuthorizationStoreContract(t *testing.T) {
+	repotest.RunAuthorizationStore(t, func(t *testing.T) ports.AuthorizationStore { return openAuthorizationStore(t, t.TempDir()) })
+}
+
+func TestAuthorizationStoreSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	s := openAuthorizationStore(t, dir)
+	card, _ := domain.NewCard("card1", "u1", domain.CardActive, "BRL", 1500, 0, 0)
+	s.SaveCard(ctx, card)
+	s.Decide(ctx, "tx1", "card1", repotest.DecideFor(t, "tx1"))
+	s.Match(ctx, "tx1", func(a domain.Authorization) domain.Authorization {
+		return a.MatchWebhook(repotest.MakePurchase("tx1", "idem1", 1000), a.DecidedAt)
+	})
+	s.Decide(ctx, "tx2", "card1", repotest.DecideFor(t, "tx2"))
+
+	// A write torn by a crash is cut off on open.
+	f, err := os.OpenFile(filepath.Join(dir, authorizationsFileName), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"card":{"ID":"ca`)
+	f.Close()
+
+	reopened := openAuthorizationStore(t, dir)
+	if got, err := reopened.GetCard(ctx, "card1"); err != nil || got != card {
+		t.Errorf("expected the card back, got %+v (%v)", got, err)
+	}
+	if auth, _ := reopened.GetAuthorization(ctx, "tx1"); auth.Match == nil {
+		t.Error("expected the match to survive the restart")
+	}
+	// tx2 still holds its amount, so the card has 500 left.
+	if auth, _ := reopened.Decide(ctx, "tx3", "card1", repotest.DecideFor(t, "tx3")); auth.Reason != domain.ReasonInsufficientBalance {
+		t.Errorf("expected the history replayed in order, got %s", auth.Reason)
+	}
+	if sizes := reopened.Sizes(); sizes["cards"] != 1 || sizes["authorizations"] != 3 {
+		t.Errorf("expected 1 card and 3 authorizations, got %v", sizes)
+	}
+}
+
+func TestAuthorizationStoreDoesNotApplyAFailedWrite(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	s := openAuthorizationStore(t, dir)
+	s.path = filepath.Join(dir, "missing", authorizationsFileName)
+	card, _ := domain.NewCard("card1", "u1", domain.CardActive, "BRL", 1500, 0, 0)
+	if err := s.SaveCard(ctx, card); err == nil {
+		t.Fatal("expected the write to fail")
+	}
+	if _, err := s.GetCard(ctx, "card1"); err == nil {
+		t.Error("a card that was not written must not be saved")
+	}
+}
@@ -0,0 +1,64 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+const deadLettersFileName = "deadletters.jsonl"
+
+// DeadLetterStore is a durable implementation of ports.DeadLetterStore. Dead letters are appended
+// one JSON line at a time and never rewritten; the whole file is read back into memory when
+// opened.
+type DeadLetterStore struct {
+	mu   sync.Mutex
+	mem  *memory.DeadLetterStore
+	path string
+}
+
+var _ ports.DeadLetterStore = (*DeadLetterStore)(nil)
+
+// OpenDeadLetterStore loads the dead letters stored in dir, cutting off a torn last line.
+func OpenDeadLetterStore(dir string) (*DeadLetterStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	s := &DeadLetterStore{mem: memory.NewDeadLetterStore(), path: filepath.Join(dir, deadLettersFileName)}
+	err := readLines(s.path, "dead letter", func(line []byte) error {
+		var dl domain.DeadLetter
+		if err := json.Unmarshal(line, &dl); err != nil {
+			return err
+		}
+		return s.mem.AddDeadLetter(context.Background(), dl)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *DeadLetterStore) AddDeadLetter(ctx context.Context, dl domain.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := appendLine(s.path, "dead letter", dl); err != nil {
+		return err
+	}
+	return s.mem.AddDeadLetter(ctx, dl)
+}
+
+func (s *DeadLetterStore) ListDeadLetters(ctx context.Context) ([]domain.DeadLetter, error) {
+	return s.mem.ListDeadLetters(ctx)
+}
+
+// Sizes reports the in-memory copy for the store gauges.
+func (s *DeadLetterStore) Sizes() map[string]int {
+	return s.mem.Sizes()
+}
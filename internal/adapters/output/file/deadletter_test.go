@@ -0,0 +1,57 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func openDeadLetterStore(t *testing.T, dir string) *DeadLetterStore {
+	t.Helper()
+	s, err := OpenDeadLetterStore(dir)
+	if err != nil {
+		t.Fatalf("open dead letter store: %v", err)
+	}
+	return s
+}
+
+func TestDeadLetterStoreContract(t *testing.T) {
+	repotest.RunDeadLetterStore(t, func(t *testing.T) ports.DeadLetterStore { return openDeadLetterStore(t, t.TempDir()) })
+}
+
+func TestDeadLetterStoreSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	s := openDeadLetterStore(t, dir)
+	for _, id := range []string{"tx1", "tx2"} {
+		dl := domain.DeadLetter{Event: domain.TransactionProcessed(repotest.MakePurchase(id, "idem-"+id, 1000)), Subscriber: "http://a", Attempts: 3}
+		if err := s.AddDeadLetter(ctx, dl); err != nil {
+			t.Fatalf("add %s: %v", id, err)
+		}
+	}
+	// A write torn by a crash is cut off on open.
+	f, err := os.OpenFile(filepath.Join(dir, deadLettersFileName), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"Event":{"ID":"transaction.pro`)
+	f.Close()
+
+	reopened := openDeadLetterStore(t, dir)
+	letters, _ := reopened.ListDeadLetters(ctx)
+	if len(letters) != 2 || letters[0].Event.Transaction.ID != "tx1" || letters[1].Event.Transaction.ID != "tx2" {
+		t.Fatalf("expected tx1 and tx2 back in order, got %+v", letters)
+	}
+	dl := domain.DeadLetter{Event: domain.TransactionProcessed(repotest.MakePurchase("tx3", "idem-tx3", 1000)), Subscriber: "http://a", Attempts: 3}
+	if err := reopened.AddDeadLetter(ctx, dl); err != nil {
+		t.Fatalf("add after reopen: %v", err)
+	}
+	if letters, _ := openDeadLetterStore(t, dir).ListDeadLetters(ctx); len(letters) != 3 {
+		t.Errorf("expected appends to resume after the torn line was cut, got %d letters", len(letters))
+	}
+}
@@ -0,0 +1,84 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+const disputesFileName = "disputes.json"
+
+// DisputeStore is a durable implementation of ports.DisputeStore. Disputes are few and change
+// state, so like the pending store every change rewrites the whole file.
+type DisputeStore struct {
+	*memory.DisputeStore
+
+	mu  sync.Mutex
+	dir string
+}
+
+// OpenDisputeStore loads the disputes stored in dir, if any.
+func OpenDisputeStore(dir string) (*DisputeStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	s := &DisputeStore{DisputeStore: memory.NewDisputeStore(), dir: dir}
+	b, err := os.ReadFile(filepath.Join(dir, disputesFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read disputes: %w", err)
+	}
+	var disputes []domain.Dispute
+	if err := json.Unmarshal(b, &disputes); err != nil {
+		return nil, fmt.Errorf("decode disputes: %w", err)
+	}
+	for _, d := range disputes {
+		if err := s.DisputeStore.SaveDispute(context.Background(), d); err != nil {
+			return nil, fmt.Errorf("restore dispute %s: %w", d.ID, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *DisputeStore) SaveDispute(ctx context.Context, d domain.Dispute) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.DisputeStore.SaveDispute(ctx, d); err != nil {
+		return err
+	}
+	return s.persist(ctx)
+}
+
+func (s *DisputeStore) UpdateDispute(ctx context.Context, d domain.Dispute) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.DisputeStore.UpdateDispute(ctx, d); err != nil {
+		return err
+	}
+	return s.persist(ctx)
+}
+
+func (s *DisputeStore) persist(ctx context.Context) error {
+	all, _ := s.DisputeStore.AllDisputes(ctx)
+	b, err := json.Marshal(all)
+	if err != nil {
+		return fmt.Errorf("encode disputes: %w", err)
+	}
+	tmp := filepath.Join(s.dir, disputesFileName+".tmp")
+	if err := writeFileSync(tmp, b); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, disputesFileName)); err != nil {
+		return fmt.Errorf("install disputes: %w", err)
+	}
+	return syncDir(s.dir)
+}
@@ -0,0 +1,56 @@
+package file
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func TestDisputeStoreContract(t *testing.T) {
+	repotest.RunDisputeStore(t, func(t *testing.T) ports.DisputeStore {
+		s, err := OpenDisputeStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		return s
+	})
+}
+
+func TestDisputeStoreSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	s, err := OpenDisputeStore(dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	purchase, _ := domain.NewPurchase("tx1", domain.StatusApproved, domain.AmountBreakdown{
+		Local: domain.Money{Amount: 1000, Currency: "BRL"}, Transaction: domain.Money{Amount: 1000, Currency: "BRL"},
+		Settlement: domain.Money{Amount: 1000, Currency: "BRL"}, Original: domain.Money{Amount: 1000, Currency: "BRL"},
+	}, domain.Merchant{ID: "m1"}, domain.Event{ID: "evt1", CreatedAt: at, IdempotencyKey: "idem1"}, "u1", "card1", "BR", "BRL", "POS")
+	d, err := domain.OpenDispute("dsp1", purchase, domain.Money{Amount: 400, Currency: "BRL"}, "fraud", domain.Money{Currency: "BRL"}, at)
+	if err != nil {
+		t.Fatalf("open dispute: %v", err)
+	}
+	s.SaveDispute(ctx, d)
+	lost, _ := d.Transition(domain.DisputeLost, "merchant proved delivery", at.Add(time.Hour))
+	if err := s.UpdateDispute(ctx, lost); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	reopened, err := OpenDisputeStore(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	got, err := reopened.ListDisputes(ctx, "tx1")
+	if err != nil || len(got) != 1 {
+		t.Fatalf("expected the dispute restored, got %+v (err %v)", got, err)
+	}
+	if got[0].State != domain.DisputeLost || got[0].CardID != "card1" || len(got[0].History) != 2 || !got[0].ResolvedAt.Equal(at.Add(time.Hour)) {
+		t.Errorf("unexpected restored dispute %+v", got[0])
+	}
+}
@@ -0,0 +1,139 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+const idempotencyFileName = "idempotency.jsonl"
+
+// IdempotencyStore is a durable implementation of ports.IdempotencyStore.
+// Every webhook saves a record, so records are appended one JSON line at a time instead of
+// rewriting the file; expired records are dropped when the store is opened.
+type IdempotencyStore struct {
+	*memory.IdempotencyStore
+
+	mu   sync.Mutex
+	path string
+}
+
+// OpenIdempotencyStore loads the records stored in dir that are still live at now and rewrites
+// the file without the expired ones.
+func OpenIdempotencyStore(dir string, now time.Time) (*IdempotencyStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	s := &IdempotencyStore{IdempotencyStore: memory.NewIdempotencyStore(), path: filepath.Join(dir, idempotencyFileName)}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.rewrite(s.All(now)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *IdempotencyStore) ClaimIdempotencyKey(_ context.Context, rec domain.IdempotencyRecord) (domain.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	held, claimed := s.Claim(rec)
+	if !claimed {
+		return held, nil
+	}
+	return held, s.append(held)
+}
+
+func (s *IdempotencyStore) SaveIdempotencyRecord(_ context.Context, rec domain.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.Put(rec)
+	if !ok {
+		return nil
+	}
+	return s.append(stored)
+}
+
+func (s *IdempotencyStore) ClearIdempotencyResponse(_ context.Context, key string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.Clear(key, now)
+	if !ok {
+		return nil
+	}
+	return s.append(rec)
+}
+
+// append writes rec as the latest line for its key.
+func (s *IdempotencyStore) append(rec domain.IdempotencyRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encode idempotency record: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open idempotency records: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("append idempotency record: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("fsync idempotency records: %w", err)
+	}
+	return nil
+}
+
+// load reads the records in save order; a later line for a key replaces the earlier one. A line
+// that does not decode is a torn write, and nothing after it was acknowledged.
+func (s *IdempotencyStore) load() error {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read idempotency records: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		var rec domain.IdempotencyRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			break
+		}
+		s.Restore(rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read idempotency records: %w", err)
+	}
+	return nil
+}
+
+func (s *IdempotencyStore) rewrite(records []domain.IdempotencyRecord) error {
+	var b []byte
+	for _, rec := range records {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return fmt.Errorf("encode idempotency record: %w", err)
+		}
+		b = append(append(b, line...), '\n')
+	}
+	tmp := s.path + ".tmp"
+	if err := writeFileSync(tmp, b); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("install idempotency records: %w", err)
+	}
+	return syncDir(filepath.Dir(s.path))
+}
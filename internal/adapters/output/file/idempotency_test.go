@@ -0,0 +1,95 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func TestIdempotencyStoreContract(t *testing.T) {
+	repotest.RunIdempotencyStore(t, func(t *testing.T) ports.IdempotencyStore {
+		s, err := OpenIdempotencyStore(t.TempDir(), time.Now())
+		if err != nil {
+			t.Fatalf("open idempotency store: %v", err)
+		}
+		return s
+	})
+}
+
+func TestIdempotencyStoreSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	createdAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	save := func(s *IdempotencyStore, key string, at time.Time) {
+		t.Helper()
+		rec, _ := domain.NewIdempotencyRecord(key, "hash-"+key, 200, []byte(`{"transaction_id":"`+key+`"}`), at, time.Hour)
+		if err := s.SaveIdempotencyRecord(ctx, rec); err != nil {
+			t.Fatalf("save %s: %v", key, err)
+		}
+	}
+
+	s, err := OpenIdempotencyStore(dir, createdAt)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	save(s, "idem1", createdAt)
+	save(s, "idem2", createdAt.Add(30*time.Minute))
+
+	// Simulate a torn final write.
+	f, _ := os.OpenFile(filepath.Join(dir, idempotencyFileName), os.O_WRONLY|os.O_APPEND, 0o644)
+	f.WriteString(`{"Key":"idem3","Pay`)
+	f.Close()
+
+	reopened, err := OpenIdempotencyStore(dir, createdAt.Add(45*time.Minute))
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	rec, ok, _ := reopened.GetIdempotencyRecord(ctx, "idem1", createdAt.Add(45*time.Minute))
+	if !ok || rec.PayloadHash != "hash-idem1" || string(rec.Body) != `{"transaction_id":"idem1"}` {
+		t.Errorf("expected idem1 restored, got %+v (ok %v)", rec, ok)
+	}
+
+	// Reopening after idem1 expired drops it, and the torn line, from the file.
+	if _, err := OpenIdempotencyStore(dir, createdAt.Add(time.Hour)); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, idempotencyFileName))
+	if lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n"); len(lines) != 1 || !strings.Contains(lines[0], `"idem2"`) {
+		t.Errorf("expected only idem2 left in the file, got %q", b)
+	}
+}
+
+func TestIdempotencyStoreClearSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	createdAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	s, err := OpenIdempotencyStore(dir, createdAt)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	rec, _ := domain.NewIdempotencyRecord("idem1", "hash-idem1", 409, []byte(`{"code":"EXCEEDS_ORIGINAL_AMOUNT"}`), createdAt, time.Hour)
+	s.SaveIdempotencyRecord(ctx, rec)
+	if err := s.ClearIdempotencyResponse(ctx, "idem1", createdAt.Add(time.Minute)); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+
+	reopened, err := OpenIdempotencyStore(dir, createdAt.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	got, ok, _ := reopened.GetIdempotencyRecord(ctx, "idem1", createdAt.Add(2*time.Minute))
+	if !ok || got.HasResponse() || got.PayloadHash != "hash-idem1" || !got.ExpiresAt.Equal(rec.ExpiresAt) {
+		t.Errorf("expected the cleared record restored, got %+v (ok %v)", got, ok)
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, idempotencyFileName))
+	if n := strings.Count(string(b), "\n"); n != 1 {
+		t.Errorf("expected the reopen to compact the file to one line, got %d", n)
+	}
+}
@@ -0,0 +1,91 @@
+package file
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// appendLine appends v as one JSON line to path and fsyncs it. A failed write is cut from the
+// file, so the next line does not follow a torn one. what names the records in errors.
+func appendLine(path, what string, v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", what, err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", what, err)
+	}
+	defer f.Close()
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", what, err)
+	}
+	if _, err = f.Write(append(line, '\n')); err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		return errors.Join(fmt.Errorf("append %s: %w", what, err), f.Truncate(offset))
+	}
+	return nil
+}
+
+// readLines calls apply with each line of path in append order. A line that does not decode is a
+// torn write: nothing after it was acknowledged, so the file is truncated there and appends resume
+// cleanly. A missing file reads as empty.
+func readLines(path, what string, apply func(line []byte) error) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", what, err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	var valid int64
+	torn := false
+	for scanner.Scan() {
+		if err := apply(scanner.Bytes()); err != nil {
+			torn = true
+			break
+		}
+		valid += int64(len(scanner.Bytes())) + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", what, err)
+	}
+	if !torn {
+		return nil
+	}
+	if err := f.Truncate(valid); err != nil {
+		return fmt.Errorf("truncate torn %s: %w", what, err)
+	}
+	return f.Sync()
+}
+
+// rewriteLines replaces path with one JSON line per value, through a temp file and a rename.
+func rewriteLines[T any](path, what string, values []T) error {
+	var b []byte
+	for _, v := range values {
+		line, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", what, err)
+		}
+		b = append(append(b, line...), '\n')
+	}
+	tmp := path + ".tmp"
+	if err := writeFileSync(tmp, b); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("install %s: %w", what, err)
+	}
+	return syncDir(filepath.Dir(path))
+}
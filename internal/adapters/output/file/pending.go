@@ -0,0 +1,145 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+const pendingFileName = "pending.json"
+
+// PendingStore is a durable implementation of ports.PendingAdjustmentStore.
+// The set of parked adjustments is small and short-lived, so every change rewrites the whole
+// file (temp file + rename) instead of going through the write-ahead log. The in-memory copy is
+// wrapped rather than embedded, and a change whose rewrite fails is rolled back from it.
+type PendingStore struct {
+	mu  sync.Mutex
+	mem *memory.PendingStore
+	dir string
+}
+
+var _ ports.PendingAdjustmentStore = (*PendingStore)(nil)
+
+// OpenPendingStore loads the parked adjustments stored in dir, if any.
+func OpenPendingStore(dir string) (*PendingStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, pendingFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &PendingStore{mem: memory.NewPendingStore(), dir: dir}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read pending adjustments: %w", err)
+	}
+	var entries []domain.PendingAdjustment
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("decode pending adjustments: %w", err)
+	}
+	mem, err := restorePending(entries)
+	if err != nil {
+		return nil, err
+	}
+	return &PendingStore{mem: mem, dir: dir}, nil
+}
+
+func (s *PendingStore) Park(ctx context.Context, p domain.PendingAdjustment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before := s.mem.All()
+	if err := s.mem.Park(ctx, p); err != nil {
+		return err
+	}
+	return s.persist(before)
+}
+
+func (s *PendingStore) GetByIdempotencyKey(ctx context.Context, key string) (domain.PendingAdjustment, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mem.GetByIdempotencyKey(ctx, key)
+}
+
+// TakeParked removes the entries and persists the removal. If the write fails the entries stay
+// parked, but they are still returned alongside the error so the caller can apply them; taking
+// them again later is harmless because the repository rejects duplicate keys.
+func (s *PendingStore) TakeParked(ctx context.Context, originalTxID string, now time.Time) ([]domain.PendingAdjustment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before := s.mem.All()
+	taken, err := s.mem.TakeParked(ctx, originalTxID, now)
+	if err != nil || len(taken) == 0 {
+		return taken, err
+	}
+	return taken, s.persist(before)
+}
+
+func (s *PendingStore) Reject(ctx context.Context, p domain.PendingAdjustment, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before := s.mem.All()
+	if err := s.mem.Reject(ctx, p, reason); err != nil {
+		return err
+	}
+	return s.persist(before)
+}
+
+func (s *PendingStore) ListForReview(ctx context.Context, now time.Time) ([]domain.PendingAdjustment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mem.ListForReview(ctx, now)
+}
+
+// Sizes reports the in-memory copy for the store gauges.
+func (s *PendingStore) Sizes() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mem.Sizes()
+}
+
+// persist rewrites the file with the current entries. On failure the in-memory copy is reset to
+// before, the entries the file still holds.
+func (s *PendingStore) persist(before []domain.PendingAdjustment) error {
+	err := s.write()
+	if err == nil {
+		return nil
+	}
+	if mem, rerr := restorePending(before); rerr == nil {
+		s.mem = mem
+	}
+	return err
+}
+
+func (s *PendingStore) write() error {
+	b, err := json.Marshal(s.mem.All())
+	if err != nil {
+		return fmt.Errorf("encode pending adjustments: %w", err)
+	}
+	tmp := filepath.Join(s.dir, pendingFileName+".tmp")
+	if err := writeFileSync(tmp, b); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, pendingFileName)); err != nil {
+		return fmt.Errorf("install pending adjustments: %w", err)
+	}
+	return syncDir(s.dir)
+}
+
+// restorePending rebuilds an in-memory store holding entries, in order.
+func restorePending(entries []domain.PendingAdjustment) (*memory.PendingStore, error) {
+	mem := memory.NewPendingStore()
+	for _, p := range entries {
+		if err := mem.Park(context.Background(), p); err != nil {
+			return nil, fmt.Errorf("restore pending adjustment %s: %w", p.Adjustment.ID, err)
+		}
+	}
+	return mem, nil
+}
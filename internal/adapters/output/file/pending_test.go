@@ -0,0 +1,103 @@
+package file
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func openPendingStore(t *testing.T, dir string) *PendingStore {
+	t.Helper()
+	s, err := OpenPendingStore(dir)
+	if err != nil {
+		t.Fatalf("open pending store: %v", err)
+	}
+	return s
+}
+
+func TestPendingStoreContract(t *testing.T) {
+	repotest.RunPendingStore(t, func(t *testing.T) ports.PendingAdjustmentStore { return openPendingStore(t, t.TempDir()) })
+}
+
+func TestPendingStoreSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	parkedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	park := func(s *PendingStore, id, originalID string) {
+		t.Helper()
+		p, _ := domain.NewPendingAdjustment(repotest.MakeAdjustment(id, originalID, "idem-"+id, 100), parkedAt, time.Hour)
+		if err := s.Park(ctx, p); err != nil {
+			t.Fatalf("park %s: %v", id, err)
+		}
+	}
+
+	s := openPendingStore(t, dir)
+	park(s, "adj1", "tx1")
+	park(s, "adj2", "tx2")
+	park(s, "adj3", "tx1")
+	taken, err := s.TakeParked(ctx, "tx2", parkedAt)
+	if err != nil || len(taken) != 1 {
+		t.Fatalf("expected to take adj2, got %v (err %v)", taken, err)
+	}
+	if err := s.Reject(ctx, taken[0], "exceeds"); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+
+	reopened := openPendingStore(t, dir)
+	got, err := reopened.TakeParked(ctx, "tx1", parkedAt)
+	if err != nil {
+		t.Fatalf("take after reopen: %v", err)
+	}
+	if len(got) != 2 || got[0].Adjustment.ID != "adj1" || got[1].Adjustment.ID != "adj3" {
+		t.Fatalf("expected [adj1 adj3] in arrival order after reopen, got %v", got)
+	}
+	review, _ := reopened.ListForReview(ctx, parkedAt)
+	if len(review) != 1 || review[0].Adjustment.ID != "adj2" || review[0].Reason != "exceeds" {
+		t.Errorf("expected rejected adj2 kept for review, got %v", review)
+	}
+}
+
+func TestPendingStoreTakeIsDurable(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	parkedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	s := openPendingStore(t, dir)
+	p, _ := domain.NewPendingAdjustment(repotest.MakeAdjustment("adj1", "tx1", "idem-adj1", 100), parkedAt, time.Hour)
+	s.Park(ctx, p)
+	s.TakeParked(ctx, "tx1", parkedAt)
+
+	reopened := openPendingStore(t, dir)
+	if _, ok := reopened.GetByIdempotencyKey(ctx, "idem-adj1"); ok {
+		t.Error("expected taken adjustment to stay removed after reopen")
+	}
+}
+
+func TestPendingStoreRollsBackAFailedRewrite(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	parkedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	p1, _ := domain.NewPendingAdjustment(repotest.MakeAdjustment("adj1", "tx1", "idem-adj1", 100), parkedAt, time.Hour)
+	p2, _ := domain.NewPendingAdjustment(repotest.MakeAdjustment("adj2", "tx1", "idem-adj2", 100), parkedAt, time.Hour)
+
+	s := openPendingStore(t, dir)
+	s.Park(ctx, p1)
+	s.dir = filepath.Join(dir, "missing")
+	if err := s.Park(ctx, p2); err == nil {
+		t.Fatal("expected the failed rewrite to be reported")
+	}
+	if _, ok := s.GetByIdempotencyKey(ctx, "idem-adj2"); ok {
+		t.Error("expected adj2 to be rolled back from memory")
+	}
+	if taken, err := s.TakeParked(ctx, "tx1", parkedAt); err == nil || len(taken) != 1 {
+		t.Fatalf("expected adj1 returned with the rewrite error, got %v (err %v)", taken, err)
+	}
+	if _, ok := s.GetByIdempotencyKey(ctx, "idem-adj1"); !ok {
+		t.Error("expected adj1 to stay parked while the file still holds it")
+	}
+}
@@ -0,0 +1,128 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+const rejectionsFileName = "rejected.json"
+
+// RejectedWebhookStore is a durable implementation of ports.RejectedWebhookStore. Retries fold
+// into one entry and rejections are the exception, so like the pending store every change
+// rewrites the whole file, and a change whose rewrite fails is rolled back from memory.
+type RejectedWebhookStore struct {
+	// mu guards mem, which a failed rewrite replaces.
+	mu  sync.Mutex
+	mem *memory.RejectedWebhookStore
+	dir string
+}
+
+var _ ports.RejectedWebhookStore = (*RejectedWebhookStore)(nil)
+
+// OpenRejectedWebhookStore loads the rejected webhooks stored in dir, if any.
+func OpenRejectedWebhookStore(dir string) (*RejectedWebhookStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, rejectionsFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &RejectedWebhookStore{mem: memory.NewRejectedWebhookStore(), dir: dir}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read rejected webhooks: %w", err)
+	}
+	var entries []domain.RejectedWebhook
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("decode rejected webhooks: %w", err)
+	}
+	return &RejectedWebhookStore{mem: restoreRejections(entries), dir: dir}, nil
+}
+
+func (s *RejectedWebhookStore) SaveRejection(ctx context.Context, rw domain.RejectedWebhook) (domain.RejectedWebhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before := s.mem.All()
+	saved, err := s.mem.SaveRejection(ctx, rw)
+	if err != nil {
+		return saved, err
+	}
+	return saved, s.persist(before)
+}
+
+func (s *RejectedWebhookStore) UpdateRejection(ctx context.Context, rw domain.RejectedWebhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before := s.mem.All()
+	if err := s.mem.UpdateRejection(ctx, rw); err != nil {
+		return err
+	}
+	return s.persist(before)
+}
+
+func (s *RejectedWebhookStore) GetRejection(ctx context.Context, id string) (domain.RejectedWebhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mem.GetRejection(ctx, id)
+}
+
+func (s *RejectedWebhookStore) OpenRejection(ctx context.Context, idempotencyKey string) (domain.RejectedWebhook, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mem.OpenRejection(ctx, idempotencyKey)
+}
+
+func (s *RejectedWebhookStore) ListRejections(ctx context.Context) ([]domain.RejectedWebhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mem.ListRejections(ctx)
+}
+
+// Sizes reports the in-memory copy for the store gauges.
+func (s *RejectedWebhookStore) Sizes() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mem.Sizes()
+}
+
+// persist rewrites the file with the current entries. On failure the in-memory copy is reset to
+// before, the entries the file still holds.
+func (s *RejectedWebhookStore) persist(before []domain.RejectedWebhook) error {
+	err := s.write()
+	if err != nil {
+		s.mem = restoreRejections(before)
+	}
+	return err
+}
+
+func (s *RejectedWebhookStore) write() error {
+	b, err := json.Marshal(s.mem.All())
+	if err != nil {
+		return fmt.Errorf("encode rejected webhooks: %w", err)
+	}
+	tmp := filepath.Join(s.dir, rejectionsFileName+".tmp")
+	if err := writeFileSync(tmp, b); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, rejectionsFileName)); err != nil {
+		return fmt.Errorf("install rejected webhooks: %w", err)
+	}
+	return syncDir(s.dir)
+}
+
+// restoreRejections rebuilds an in-memory store holding entries, oldest first.
+func restoreRejections(entries []domain.RejectedWebhook) *memory.RejectedWebhookStore {
+	mem := memory.NewRejectedWebhookStore()
+	for _, rw := range entries {
+		mem.SaveRejection(context.Background(), rw)
+	}
+	return mem
+}
@@ -0,0 +1,65 @@
+package file
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func TestRejectedWebhookStoreSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	s, err := OpenRejectedWebhookStore(dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	for i, key := range []string{"idem1", "idem2"} {
+		rw, _ := domain.NewRejectedWebhook("rej"+key, "adj"+key, key, []byte(`{"id":"adj"}`), 404, "NOT_FOUND", "transaction not found", at.Add(time.Duration(i)*time.Minute))
+		if _, err := s.SaveRejection(ctx, rw); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+	first, _ := s.GetRejection(ctx, "rejidem1")
+	if err := s.UpdateRejection(ctx, first.Resolve(at.Add(time.Hour))); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	reopened, err := OpenRejectedWebhookStore(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	all, _ := reopened.ListRejections(ctx)
+	if len(all) != 2 || all[0].ID != "rejidem1" || !all[0].Resolved() || string(all[1].Payload) != `{"id":"adj"}` {
+		t.Fatalf("expected both entries restored in order, got %+v", all)
+	}
+	if open, ok, _ := reopened.OpenRejection(ctx, "idem2"); !ok || open.ID != "rejidem2" {
+		t.Errorf("expected idem2 still open, got %+v (ok %v)", open, ok)
+	}
+	if _, ok, _ := reopened.OpenRejection(ctx, "idem1"); ok {
+		t.Error("expected idem1 resolved after restart")
+	}
+}
+
+func TestRejectedWebhookStoreRollsBackAFailedRewrite(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	s, err := OpenRejectedWebhookStore(dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	rw, _ := domain.NewRejectedWebhook("rej1", "adj1", "idem1", []byte(`{"id":"adj1"}`), 404, "NOT_FOUND", "transaction not found", at)
+	s.SaveRejection(ctx, rw)
+
+	s.dir = filepath.Join(dir, "missing")
+	if err := s.UpdateRejection(ctx, rw.Resolve(at.Add(time.Hour))); err == nil {
+		t.Fatal("expected the failed rewrite to be reported")
+	}
+	if open, ok, _ := s.OpenRejection(ctx, "idem1"); !ok || open.Resolved() {
+		t.Errorf("expected rej1 still open in memory, as in the file, got %+v (ok %v)", open, ok)
+	}
+}
@@ -0,0 +1,521 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+const (
+	logFileName      = "wal.log"
+	snapshotFileName = "snapshot.json"
+
+	opTransaction = "transaction"
+	opAdjustment  = "adjustment"
+	opDispatched  = "dispatched"
+
+	// DefaultCompactionThreshold is the number of log records after which the log is folded into a snapshot.
+	DefaultCompactionThreshold = 10_000
+)
+
+// record is a single write-ahead log entry.
+type record struct {
+	Op          string              `json:"op"`
+	Transaction *domain.Transaction `json:"transaction,omitempty"`
+	Adjustment  *domain.Adjustment  `json:"adjustment,omitempty"`
+	// Dispatched lists the outbox event IDs an opDispatched record removes.
+	Dispatched []string `json:"dispatched,omitempty"`
+}
+
+// snapshot is the compacted state written by Compact.
+type snapshot struct {
+	Transactions []domain.Transaction `json:"transactions"`
+	Adjustments  []domain.Adjustment  `json:"adjustments"`
+	// Outbox holds the undispatched events in write order.
+	Outbox []domain.OutboxEvent `json:"outbox,omitempty"`
+}
+
+// logFile is the part of *os.File the log is written through.
+type logFile interface {
+	io.Writer
+	io.Seeker
+	Sync() error
+	Truncate(size int64) error
+	Close() error
+}
+
+// Repository is a durable implementation of ports.TransactionRepository.
+// Every write is appended to an fsync'd log before it becomes visible; reads are served
+// from an in-memory index (memory.Repository) rebuilt from snapshot + log on Open.
+// Outbox events need no record of their own: replaying a save re-appends its event and an
+// opDispatched record removes it again.
+//
+// The index is wrapped rather than embedded, so a write method added to memory.Repository is
+// not exposed here without going through the log.
+type Repository struct {
+	mem *memory.Repository
+
+	// mu serializes writers so the idempotency/ID checks, the log append and the
+	// in-memory apply happen as one atomic step.
+	mu         sync.Mutex
+	dir        string
+	log        logFile
+	logRecords int
+	threshold  int
+	closed     bool
+	// appendErr is the error of the last log append, cleared by the next successful one.
+	appendErr error
+	// unusable is set when a failed append could not be cut from the log; every later write
+	// fails with it.
+	unusable error
+}
+
+// Option configures a Repository.
+type Option func(*Repository)
+
+// WithCompactionThreshold sets how many log records trigger an automatic compaction.
+// A threshold <= 0 disables automatic compaction.
+func WithCompactionThreshold(n int) Option {
+	return func(r *Repository) { r.threshold = n }
+}
+
+// Open loads the snapshot and replays the log found in dir, creating both if absent.
+// A torn record at the tail of the log (crash mid-write) is truncated away.
+func Open(dir string, opts ...Option) (*Repository, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	r := &Repository{
+		mem:       memory.NewRepository(),
+		dir:       dir,
+		threshold: DefaultCompactionThreshold,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if err := r.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open log: %w", err)
+	}
+	if err := r.replay(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.log = f
+	return r, nil
+}
+
+var _ ports.TransactionRepository = (*Repository)(nil)
+
+// ErrCorruptLog is returned by Open when a log record fails its checksum but valid records
+// follow it: that is damage, not a torn write, and dropping the rest would lose acknowledged data.
+var ErrCorruptLog = errors.New("corrupt write-ahead log")
+
+func (r *Repository) GetTransactionByID(ctx context.Context, id string) (domain.Transaction, error) {
+	return r.mem.GetTransactionByID(ctx, id)
+}
+
+func (r *Repository) GetAdjustmentsByTransactionID(ctx context.Context, originalTxID string) ([]domain.Adjustment, error) {
+	return r.mem.GetAdjustmentsByTransactionID(ctx, originalTxID)
+}
+
+func (r *Repository) GetAdjustmentByID(ctx context.Context, id string) (domain.Adjustment, error) {
+	return r.mem.GetAdjustmentByID(ctx, id)
+}
+
+func (r *Repository) GetByIdempotencyKey(ctx context.Context, key string) (string, bool) {
+	return r.mem.GetByIdempotencyKey(ctx, key)
+}
+
+func (r *Repository) ListTransactions(ctx context.Context, q ports.TransactionQuery) (ports.TransactionPage, error) {
+	return r.mem.ListTransactions(ctx, q)
+}
+
+func (r *Repository) PendingEvents(ctx context.Context, limit int) ([]domain.OutboxEvent, error) {
+	return r.mem.PendingEvents(ctx, limit)
+}
+
+// Sizes reports the in-memory index for the store gauges.
+func (r *Repository) Sizes() map[string]int {
+	return r.mem.Sizes()
+}
+
+// SaveTransaction checks idempotency and ID uniqueness, appends the record to the log,
+// fsyncs and only then applies it in memory — all under the writer lock.
+func (r *Repository) SaveTransaction(ctx context.Context, tx domain.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.GetByIdempotencyKey(ctx, tx.Event.IdempotencyKey); exists {
+		return domain.ErrDuplicateIdempotencyKey
+	}
+	if _, err := r.GetTransactionByID(ctx, tx.ID); err == nil {
+		return domain.ErrDuplicateTransactionID
+	}
+	if err := r.append(record{Op: opTransaction, Transaction: &tx}); err != nil {
+		return err
+	}
+	if err := r.mem.SaveTransaction(ctx, tx); err != nil {
+		return err
+	}
+	return r.maybeCompact()
+}
+
+// SaveAdjustment checks idempotency, appends the record to the log, fsyncs and applies it in memory.
+func (r *Repository) SaveAdjustment(ctx context.Context, adj domain.Adjustment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.GetByIdempotencyKey(ctx, adj.Event.IdempotencyKey); exists {
+		return domain.ErrDuplicateIdempotencyKey
+	}
+	if err := r.append(record{Op: opAdjustment, Adjustment: &adj}); err != nil {
+		return err
+	}
+	if err := r.mem.SaveAdjustment(ctx, adj); err != nil {
+		return err
+	}
+	return r.maybeCompact()
+}
+
+// AppendAdjustment runs check against the in-memory state and, if it passes, logs and applies
+// the adjustment — all under the writer lock, so no other write can slip in between.
+func (r *Repository) AppendAdjustment(ctx context.Context, adj domain.Adjustment, check ports.AdjustmentCheck) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.GetByIdempotencyKey(ctx, adj.Event.IdempotencyKey); exists {
+		return domain.ErrDuplicateIdempotencyKey
+	}
+	original, err := r.GetTransactionByID(ctx, adj.OriginalTransactionID)
+	if err != nil {
+		return err
+	}
+	existing, err := r.GetAdjustmentsByTransactionID(ctx, adj.OriginalTransactionID)
+	if err != nil {
+		return err
+	}
+	if err := check(original, existing); err != nil {
+		return err
+	}
+	if err := r.append(record{Op: opAdjustment, Adjustment: &adj}); err != nil {
+		return err
+	}
+	if err := r.mem.SaveAdjustment(ctx, adj); err != nil {
+		return err
+	}
+	return r.maybeCompact()
+}
+
+// MarkDispatched logs the dispatched IDs and then removes the events from memory.
+func (r *Repository) MarkDispatched(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.append(record{Op: opDispatched, Dispatched: ids}); err != nil {
+		return err
+	}
+	if err := r.mem.MarkDispatched(ctx, ids...); err != nil {
+		return err
+	}
+	return r.maybeCompact()
+}
+
+// Compact writes the full state to a new snapshot and truncates the log.
+// The snapshot is written to a temp file and renamed, so a crash leaves either the old
+// or the new snapshot in place; replaying a log already folded into the snapshot is harmless
+// because duplicates are skipped.
+func (r *Repository) Compact() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.compact()
+}
+
+// Close flushes and closes the log. Further writes fail.
+func (r *Repository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if err := r.log.Sync(); err != nil {
+		r.log.Close()
+		return err
+	}
+	return r.log.Close()
+}
+
+// CheckHealth fails once the repository is closed or its data directory is gone, and while the
+// last log append failed, so a full or broken disk takes the instance out of rotation.
+func (r *Repository) CheckHealth(context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return errors.New("file repository is closed")
+	}
+	if r.unusable != nil {
+		return r.unusable
+	}
+	if r.appendErr != nil {
+		return r.appendErr
+	}
+	if _, err := os.Stat(r.dir); err != nil {
+		return fmt.Errorf("data directory: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) append(rec record) error {
+	if r.closed {
+		return errors.New("file repository is closed")
+	}
+	if r.unusable != nil {
+		return r.unusable
+	}
+	line, err := encodeRecord(rec)
+	if err != nil {
+		return err
+	}
+	offset, err := r.log.Seek(0, io.SeekCurrent)
+	if err != nil {
+		r.appendErr = fmt.Errorf("seek log: %w", err)
+		return r.appendErr
+	}
+	if _, err := r.log.Write(line); err != nil {
+		return r.undoAppend(offset, fmt.Errorf("append log: %w", err))
+	}
+	if err := r.log.Sync(); err != nil {
+		return r.undoAppend(offset, fmt.Errorf("fsync log: %w", err))
+	}
+	r.appendErr = nil
+	r.logRecords++
+	return nil
+}
+
+// undoAppend cuts a failed append back to offset, so the next record does not land after a
+// partial one and turn it into corruption that Open refuses. If the log cannot be cut, the
+// repository is marked unusable.
+func (r *Repository) undoAppend(offset int64, err error) error {
+	r.appendErr = err
+	if terr := r.log.Truncate(offset); terr != nil {
+		r.unusable = fmt.Errorf("file repository is unusable: %w (truncate log: %v)", err, terr)
+		return r.unusable
+	}
+	if _, serr := r.log.Seek(offset, io.SeekStart); serr != nil {
+		r.unusable = fmt.Errorf("file repository is unusable: %w (seek log: %v)", err, serr)
+		return r.unusable
+	}
+	return err
+}
+
+// maybeCompact compacts once the log passes the threshold. The triggering write is already
+// durable, so a failed compaction is not reported to its caller; the log simply keeps growing
+// and compaction is retried on the next write.
+func (r *Repository) maybeCompact() error {
+	if r.threshold <= 0 || r.logRecords < r.threshold {
+		return nil
+	}
+	_ = r.compact()
+	return nil
+}
+
+func (r *Repository) compact() error {
+	txs, adjs := r.mem.Snapshot()
+	outbox, err := r.mem.PendingEvents(context.Background(), 0)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(snapshot{Transactions: txs, Adjustments: adjs, Outbox: outbox})
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	tmp := filepath.Join(r.dir, snapshotFileName+".tmp")
+	if err := writeFileSync(tmp, b); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(r.dir, snapshotFileName)); err != nil {
+		return fmt.Errorf("install snapshot: %w", err)
+	}
+	if err := syncDir(r.dir); err != nil {
+		return err
+	}
+	if err := r.log.Truncate(0); err != nil {
+		return fmt.Errorf("truncate log: %w", err)
+	}
+	if _, err := r.log.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("rewind log: %w", err)
+	}
+	if err := r.log.Sync(); err != nil {
+		return fmt.Errorf("fsync log: %w", err)
+	}
+	r.logRecords = 0
+	return nil
+}
+
+func (r *Repository) loadSnapshot() error {
+	b, err := os.ReadFile(filepath.Join(r.dir, snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+	var snap snapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+	ctx := context.Background()
+	for _, tx := range snap.Transactions {
+		if err := r.mem.SaveTransaction(ctx, tx); err != nil {
+			return fmt.Errorf("restore transaction %s: %w", tx.ID, err)
+		}
+	}
+	for _, adj := range snap.Adjustments {
+		if err := r.mem.SaveAdjustment(ctx, adj); err != nil {
+			return fmt.Errorf("restore adjustment %s: %w", adj.ID, err)
+		}
+	}
+	r.mem.RestoreOutbox(snap.Outbox)
+	return nil
+}
+
+// replay applies every valid record in the log and truncates a torn tail: a partial last line,
+// or records that fail their checksum with nothing valid after them. A bad record followed by a
+// valid one is ErrCorruptLog and the file is left untouched.
+func (r *Repository) replay(f *os.File) error {
+	ctx := context.Background()
+	reader := bufio.NewReader(f)
+	var offset int64
+	lineNo, badLine := 0, 0
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// A partial line without newline is a torn write — drop it.
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read log: %w", err)
+		}
+		lineNo++
+		rec, ok := decodeRecord(line)
+		if !ok {
+			if badLine == 0 {
+				badLine = lineNo
+			}
+			continue
+		}
+		if badLine > 0 {
+			return fmt.Errorf("%w: record %d is damaged and record %d after it is valid", ErrCorruptLog, badLine, lineNo)
+		}
+		if err := r.apply(ctx, rec); err != nil {
+			return err
+		}
+		offset += int64(len(line))
+		r.logRecords++
+	}
+	if err := f.Truncate(offset); err != nil {
+		return fmt.Errorf("truncate torn log tail: %w", err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("seek log: %w", err)
+	}
+	return nil
+}
+
+// apply replays a record into memory. Duplicates are expected after a crash between
+// snapshot install and log truncation, so they are skipped.
+func (r *Repository) apply(ctx context.Context, rec record) error {
+	var err error
+	switch {
+	case rec.Op == opTransaction && rec.Transaction != nil:
+		err = r.mem.SaveTransaction(ctx, *rec.Transaction)
+	case rec.Op == opAdjustment && rec.Adjustment != nil:
+		err = r.mem.SaveAdjustment(ctx, *rec.Adjustment)
+	case rec.Op == opDispatched:
+		err = r.mem.MarkDispatched(ctx, rec.Dispatched...)
+	default:
+		return fmt.Errorf("unknown log record op %q", rec.Op)
+	}
+	if errors.Is(err, domain.ErrDuplicateIdempotencyKey) || errors.Is(err, domain.ErrDuplicateTransactionID) {
+		return nil
+	}
+	return err
+}
+
+// encodeRecord renders a record as "<crc32 hex> <json>\n".
+func encodeRecord(rec record) ([]byte, error) {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return nil, fmt.Errorf("encode log record: %w", err)
+	}
+	line := fmt.Appendf(nil, "%08x ", crc32.ChecksumIEEE(payload))
+	line = append(line, payload...)
+	return append(line, '\n'), nil
+}
+
+func decodeRecord(line []byte) (record, bool) {
+	// 8 hex chars + space + at least "{}" + newline
+	if len(line) < 12 || line[8] != ' ' {
+		return record{}, false
+	}
+	var sum uint32
+	if _, err := fmt.Sscanf(string(line[:8]), "%08x", &sum); err != nil {
+		return record{}, false
+	}
+	payload := line[9 : len(line)-1]
+	if crc32.ChecksumIEEE(payload) != sum {
+		return record{}, false
+	}
+	var rec record
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return record{}, false
+	}
+	return rec, true
+}
+
+func writeFileSync(path string, b []byte) error {
+	return writeFileSyncPerm(path, b, 0o644)
+}
+
+// writeFileSyncPerm is writeFileSync for a file created with perm.
+func writeFileSyncPerm(path string, b []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", path, err)
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("fsync %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("open dir: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("fsync dir: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func openRepo(t *testing.T, dir string, opts ...Option) *Repository {
+	t.Helper()
+	repo, err := Open(dir, opts...)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func TestRepositoryContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) ports.TransactionRepository { return openRepo(t, t.TempDir()) })
+}
+
+func TestRecoverAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openRepo(t, dir)
+	if err := repo.SaveTransaction(ctx, repotest.MakePurchase("tx1", "idem1", 1000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.SaveAdjustment(ctx, repotest.MakeAdjustment("adj1", "tx1", "adj-idem1", 400)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo.Close()
+
+	reopened := openRepo(t, dir)
+	got, err := reopened.GetTransactionByID(ctx, "tx1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Amount.Local.Amount != 1000 {
+		t.Errorf("expected 1000, got %d", got.Amount.Local.Amount)
+	}
+	adjs, _ := reopened.GetAdjustmentsByTransactionID(ctx, "tx1")
+	if len(adjs) != 1 {
+		t.Fatalf("expected 1 adjustment, got %d", len(adjs))
+	}
+	// Idempotency keys survive the restart — a Pomelo retry after deploy is a duplicate.
+	if err := reopened.SaveTransaction(ctx, repotest.MakePurchase("tx1", "idem1", 1000)); !errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+		t.Errorf("expected ErrDuplicateIdempotencyKey, got %v", err)
+	}
+	if err := reopened.SaveAdjustment(ctx, repotest.MakeAdjustment("adj1", "tx1", "adj-idem1", 400)); !errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+		t.Errorf("expected ErrDuplicateIdempotencyKey, got %v", err)
+	}
+}
+
+func TestTornTailIsTruncated(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openRepo(t, dir)
+	repo.SaveTransaction(ctx, repotest.MakePurchase("tx1", "idem1", 1000))
+	repo.Close()
+
+	// Simulate a crash mid-append: half a record without trailing newline.
+	f, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`0badc0de {"op":"transaction","transac`)
+	f.Close()
+
+	reopened := openRepo(t, dir)
+	if _, err := reopened.GetTransactionByID(ctx, "tx1"); err != nil {
+		t.Fatalf("record before torn tail lost: %v", err)
+	}
+	if err := reopened.SaveTransaction(ctx, repotest.MakePurchase("tx2", "idem2", 2000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reopened.Close()
+
+	again := openRepo(t, dir)
+	page, _ := again.ListTransactions(ctx, ports.TransactionQuery{})
+	if len(page.Transactions) != 2 {
+		t.Errorf("expected 2 transactions after truncation, got %d", len(page.Transactions))
+	}
+}
+
+func TestCorruptRecordIsDropped(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openRepo(t, dir)
+	repo.SaveTransaction(ctx, repotest.MakePurchase("tx1", "idem1", 1000))
+	repo.Close()
+
+	f, _ := os.OpenFile(filepath.Join(dir, logFileName), os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString("00000000 {\"op\":\"transaction\"}\n")
+	f.Close()
+
+	reopened := openRepo(t, dir)
+	page, _ := reopened.ListTransactions(ctx, ports.TransactionQuery{})
+	if len(page.Transactions) != 1 {
+		t.Errorf("expected 1 transaction, got %d", len(page.Transactions))
+	}
+}
+
+func TestCorruptRecordBeforeValidOnesFailsOpen(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openRepo(t, dir)
+	repo.SaveTransaction(ctx, repotest.MakePurchase("tx1", "idem1", 1000))
+	repo.SaveTransaction(ctx, repotest.MakePurchase("tx2", "idem2", 2000))
+	repo.SaveTransaction(ctx, repotest.MakePurchase("tx3", "idem3", 3000))
+	repo.Close()
+
+	// Flip a byte inside the second record's payload, keeping its length and newline.
+	path := filepath.Join(dir, logFileName)
+	b, _ := os.ReadFile(path)
+	second := slices.Index(b, '\n') + 20
+	b[second] ^= 0x01
+	os.WriteFile(path, b, 0o644)
+
+	if _, err := Open(dir); !errors.Is(err, ErrCorruptLog) {
+		t.Fatalf("expected ErrCorruptLog, got %v", err)
+	}
+	after, _ := os.ReadFile(path)
+	if !slices.Equal(after, b) {
+		t.Error("expected the damaged log to be left untouched")
+	}
+}
+
+func TestCompaction(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openRepo(t, dir, WithCompactionThreshold(3))
+	repo.SaveTransaction(ctx, repotest.MakePurchase("tx1", "idem1", 1000))
+	repo.SaveTransaction(ctx, repotest.MakePurchase("tx2", "idem2", 1000))
+	repo.SaveAdjustment(ctx, repotest.MakeAdjustment("adj1", "tx1", "adj-idem1", 500)) // triggers compaction
+	repo.SaveTransaction(ctx, repotest.MakePurchase("tx3", "idem3", 1000))
+
+	if _, err := os.Stat(filepath.Join(dir, snapshotFileName)); err != nil {
+		t.Fatalf("expected snapshot file: %v", err)
+	}
+	info, _ := os.Stat(filepath.Join(dir, logFileName))
+	if info.Size() == 0 {
+		t.Error("expected post-compaction record in log")
+	}
+	repo.Close()
+
+	reopened := openRepo(t, dir)
+	page, _ := reopened.ListTransactions(ctx, ports.TransactionQuery{})
+	if len(page.Transactions) != 3 {
+		t.Errorf("expected 3 transactions, got %d", len(page.Transactions))
+	}
+	adjs, _ := reopened.GetAdjustmentsByTransactionID(ctx, "tx1")
+	if len(adjs) != 1 {
+		t.Errorf("expected 1 adjustment, got %d", len(adjs))
+	}
+}
+
+func TestReplayAfterSnapshotSkipsDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openRepo(t, dir, WithCompactionThreshold(0))
+	repo.SaveTransaction(ctx, repotest.MakePurchase("tx1", "idem1", 1000))
+	logBytes, _ := os.ReadFile(filepath.Join(dir, logFileName))
+	if err := repo.Compact(); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	repo.Close()
+
+	// Crash between snapshot install and log truncation: the log still holds folded records.
+	os.WriteFile(filepath.Join(dir, logFileName), logBytes, 0o644)
+
+	reopened := openRepo(t, dir)
+	page, _ := reopened.ListTransactions(ctx, ports.TransactionQuery{})
+	if len(page.Transactions) != 1 {
+		t.Errorf("expected 1 transaction, got %d", len(page.Transactions))
+	}
+}
+
+func pendingIDs(t *testing.T, repo *Repository) []string {
+	t.Helper()
+	events, err := repo.PendingEvents(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("pending events: %v", err)
+	}
+	var ids []string
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestOutboxSurvivesRestartAndCompaction(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openRepo(t, dir, WithCompactionThreshold(0))
+	repo.SaveTransaction(ctx, repotest.MakePurchase("tx1", "idem1", 1000))
+	repo.SaveTransaction(ctx, repotest.MakePurchase("tx2", "idem2", 1000))
+	repo.SaveAdjustment(ctx, repotest.MakeAdjustment("adj1", "tx1", "adj-idem1", 500))
+	if err := repo.MarkDispatched(ctx, "TransactionProcessed:tx1"); err != nil {
+		t.Fatalf("mark dispatched: %v", err)
+	}
+	repo.Close()
+
+	want := []string{"TransactionProcessed:tx2", "AdjustmentApplied:adj1"}
+	reopened := openRepo(t, dir, WithCompactionThreshold(0))
+	if got := pendingIDs(t, reopened); !slices.Equal(got, want) {
+		t.Fatalf("after replay: expected %v, got %v", want, got)
+	}
+
+	// The snapshot must keep the write order, which the map-backed entities do not.
+	if err := reopened.Compact(); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	reopened.MarkDispatched(ctx, "TransactionProcessed:tx2")
+	reopened.Close()
+
+	again := openRepo(t, dir)
+	if got := pendingIDs(t, again); !slices.Equal(got, want[1:]) {
+		t.Errorf("after snapshot: expected %v, got %v", want[1:], got)
+	}
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	repo := openRepo(t, t.TempDir())
+	repo.Close()
+	if err := repo.SaveTransaction(context.Background(), repotest.MakePurchase("tx1", "idem1", 1000)); err == nil {
+		t.Error("expected error writing to closed repository")
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	dir := t.TempDir()
+	repo := openRepo(t, dir)
+	ctx := context.Background()
+	if err := repo.CheckHealth(ctx); err != nil {
+		t.Fatalf("expected an open repository to be healthy, got %v", err)
+	}
+	repo.Close()
+	if err := repo.CheckHealth(ctx); err == nil {
+		t.Error("expected a closed repository to be unhealthy")
+	}
+}
+
+// failingLog writes half of each record and fails while failWrites is set, like a disk that
+// fills up mid-write; truncateErr makes cutting the log fail too.
+type failingLog struct {
+	logFile
+	failWrites  bool
+	truncateErr error
+}
+
+func (f *failingLog) Write(b []byte) (int, error) {
+	if !f.failWrites {
+		return f.logFile.Write(b)
+	}
+	n, _ := f.logFile.Write(b[:len(b)/2])
+	return n, errors.New("no space left on device")
+}
+
+func (f *failingLog) Truncate(size int64) error {
+	if f.truncateErr != nil {
+		return f.truncateErr
+	}
+	return f.logFile.Truncate(size)
+}
+
+func TestFailedAppendIsCutFromTheLog(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	repo := openRepo(t, dir)
+	repo.SaveTransaction(ctx, repotest.MakePurchase("tx1", "idem1", 1000))
+
+	log := &failingLog{logFile: repo.log, failWrites: true}
+	repo.log = log
+	if err := repo.SaveTransaction(ctx, repotest.MakePurchase("tx2", "idem2", 1000)); err == nil {
+		t.Fatal("expected the failed append to be reported")
+	}
+	if err := repo.CheckHealth(ctx); err == nil {
+		t.Error("expected the failed append to make the repository unhealthy")
+	}
+	log.failWrites = false
+	if err := repo.SaveTransaction(ctx, repotest.MakePurchase("tx3", "idem3", 1000)); err != nil {
+		t.Fatalf("expected the next append to succeed, got %v", err)
+	}
+	if err := repo.CheckHealth(ctx); err != nil {
+		t.Errorf("expected a successful append to restore health, got %v", err)
+	}
+	repo.Close()
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatalf("expected the log to open after a failed append, got %v", err)
+	}
+	defer reopened.Close()
+	for id, want := range map[string]bool{"tx1": true, "tx2": false, "tx3": true} {
+		if _, err := reopened.GetTransactionByID(ctx, id); (err == nil) != want {
+			t.Errorf("%s: expected stored=%v, got err %v", id, want, err)
+		}
+	}
+}
+
+func TestFailedAppendThatCannotBeCutMakesTheRepositoryUnusable(t *testing.T) {
+	repo := openRepo(t, t.TempDir())
+	ctx := context.Background()
+	log := &failingLog{logFile: repo.log, failWrites: true, truncateErr: errors.New("input/output error")}
+	repo.log = log
+	if err := repo.SaveTransaction(ctx, repotest.MakePurchase("tx1", "idem1", 1000)); err == nil {
+		t.Fatal("expected the failed append to be reported")
+	}
+	log.failWrites, log.truncateErr = false, nil
+	if err := repo.SaveTransaction(ctx, repotest.MakePurchase("tx2", "idem2", 1000)); err == nil {
+		t.Error("expected writes after a damaged log to be refused")
+	}
+	if err := repo.CheckHealth(ctx); err == nil {
+		t.Error("expected an unusable repository to be unhealthy")
+	}
+}
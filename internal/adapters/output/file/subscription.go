@@ -0,0 +1,181 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+const (
+	subscriptionsFileName = "subscriptions.json"
+	deliveriesFileName    = "deliveries.jsonl"
+)
+
+// SubscriptionStore is a durable implementation of ports.SubscriptionStore. Subscriptions are few
+// and can be deleted, so like the dispute store every change rewrites the whole file, which is
+// readable by its owner only because it holds the signing secrets. A change whose rewrite fails is
+// rolled back from the in-memory copy.
+type SubscriptionStore struct {
+	mu  sync.Mutex
+	mem *memory.SubscriptionStore
+	dir string
+}
+
+var _ ports.SubscriptionStore = (*SubscriptionStore)(nil)
+
+// OpenSubscriptionStore loads the subscriptions stored in dir, if any.
+func OpenSubscriptionStore(dir string) (*SubscriptionStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, subscriptionsFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &SubscriptionStore{mem: memory.NewSubscriptionStore(), dir: dir}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read subscriptions: %w", err)
+	}
+	var subs []domain.Subscription
+	if err := json.Unmarshal(b, &subs); err != nil {
+		return nil, fmt.Errorf("decode subscriptions: %w", err)
+	}
+	return &SubscriptionStore{mem: restoreSubscriptions(subs), dir: dir}, nil
+}
+
+func (s *SubscriptionStore) SaveSubscription(ctx context.Context, sub domain.Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before, _ := s.mem.ListSubscriptions(ctx)
+	if err := s.mem.SaveSubscription(ctx, sub); err != nil {
+		return err
+	}
+	return s.persist(ctx, before)
+}
+
+func (s *SubscriptionStore) GetSubscription(ctx context.Context, id string) (domain.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mem.GetSubscription(ctx, id)
+}
+
+func (s *SubscriptionStore) DeleteSubscription(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before, _ := s.mem.ListSubscriptions(ctx)
+	if err := s.mem.DeleteSubscription(ctx, id); err != nil {
+		return err
+	}
+	return s.persist(ctx, before)
+}
+
+func (s *SubscriptionStore) ListSubscriptions(ctx context.Context) ([]domain.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mem.ListSubscriptions(ctx)
+}
+
+// Sizes reports the in-memory copy for the store gauges.
+func (s *SubscriptionStore) Sizes() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mem.Sizes()
+}
+
+// persist rewrites the file with the current subscriptions. On failure the in-memory copy is
+// reset to before, the subscriptions the file still holds.
+func (s *SubscriptionStore) persist(ctx context.Context, before []domain.Subscription) error {
+	subs, _ := s.mem.ListSubscriptions(ctx)
+	err := s.write(subs)
+	if err != nil {
+		s.mem = restoreSubscriptions(before)
+	}
+	return err
+}
+
+func (s *SubscriptionStore) write(subs []domain.Subscription) error {
+	b, err := json.Marshal(subs)
+	if err != nil {
+		return fmt.Errorf("encode subscriptions: %w", err)
+	}
+	tmp := filepath.Join(s.dir, subscriptionsFileName+".tmp")
+	if err := writeFileSyncPerm(tmp, b, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, subscriptionsFileName)); err != nil {
+		return fmt.Errorf("install subscriptions: %w", err)
+	}
+	return syncDir(s.dir)
+}
+
+// restoreSubscriptions rebuilds an in-memory store holding subs, in creation order.
+func restoreSubscriptions(subs []domain.Subscription) *memory.SubscriptionStore {
+	mem := memory.NewSubscriptionStore()
+	for _, sub := range subs {
+		mem.SaveSubscription(context.Background(), sub)
+	}
+	return mem
+}
+
+// DeliveryLog is a durable implementation of ports.DeliveryLog. Every attempt is appended as one
+// JSON line; opening the log keeps the most recent attempts of each subscription, up to the
+// limit, and rewrites the file with them.
+type DeliveryLog struct {
+	mu   sync.Mutex
+	mem  *memory.DeliveryLog
+	path string
+}
+
+var _ ports.DeliveryLog = (*DeliveryLog)(nil)
+
+// OpenDeliveryLog loads the attempts stored in dir, keeping up to limit per subscription; limit
+// <= 0 keeps them all.
+func OpenDeliveryLog(dir string, limit int) (*DeliveryLog, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	l := &DeliveryLog{mem: memory.NewDeliveryLog(limit), path: filepath.Join(dir, deliveriesFileName)}
+	err := readLines(l.path, "delivery", func(line []byte) error {
+		var d domain.Delivery
+		if err := json.Unmarshal(line, &d); err != nil {
+			return err
+		}
+		return l.mem.RecordDelivery(context.Background(), d)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := rewriteLines(l.path, "deliveries", l.mem.All()); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *DeliveryLog) RecordDelivery(ctx context.Context, d domain.Delivery) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := appendLine(l.path, "delivery", d); err != nil {
+		return err
+	}
+	return l.mem.RecordDelivery(ctx, d)
+}
+
+func (l *DeliveryLog) GetDelivery(ctx context.Context, id string) (domain.Delivery, error) {
+	return l.mem.GetDelivery(ctx, id)
+}
+
+func (l *DeliveryLog) ListDeliveries(ctx context.Context, subscriptionID string) ([]domain.Delivery, error) {
+	return l.mem.ListDeliveries(ctx, subscriptionID)
+}
+
+// Sizes reports the in-memory copy for the store gauges.
+func (l *DeliveryLog) Sizes() map[string]int {
+	return l.mem.Sizes()
+}
@@ -0,0 +1,108 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func openSubscriptionStore(t *testing.T, dir string) *SubscriptionStore {
+	t.Helper()
+	s, err := OpenSubscriptionStore(dir)
+	if err != nil {
+		t.Fatalf("open subscription store: %v", err)
+	}
+	return s
+}
+
+func openDeliveryLog(t *testing.T, dir string, limit int) *DeliveryLog {
+	t.Helper()
+	l, err := OpenDeliveryLog(dir, limit)
+	if err != nil {
+		t.Fatalf("open delivery log: %v", err)
+	}
+	return l
+}
+
+func TestSubscriptionStoreContract(t *testing.T) {
+	repotest.RunSubscriptionStore(t, func(t *testing.T) ports.SubscriptionStore { return openSubscriptionStore(t, t.TempDir()) })
+}
+
+func TestDeliveryLogContract(t *testing.T) {
+	repotest.RunDeliveryLog(t, func(t *testing.T, limit int) ports.DeliveryLog { return openDeliveryLog(t, t.TempDir(), limit) })
+}
+
+func TestSubscriptionStoreSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	s := openSubscriptionStore(t, dir)
+	for _, id := range []string{"sub1", "sub2", "sub3"} {
+		if err := s.SaveSubscription(ctx, domain.Subscription{ID: id, URL: "http://" + id, Secret: "secret-" + id}); err != nil {
+			t.Fatalf("save %s: %v", id, err)
+		}
+	}
+	s.DeleteSubscription(ctx, "sub2")
+
+	info, err := os.Stat(filepath.Join(dir, subscriptionsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected the file holding the secrets to be 0600, got %o", perm)
+	}
+	subs, _ := openSubscriptionStore(t, dir).ListSubscriptions(ctx)
+	if len(subs) != 2 || subs[0].ID != "sub1" || subs[1].ID != "sub3" || subs[1].Secret != "secret-sub3" {
+		t.Errorf("expected sub1 and sub3 with their secrets, got %+v", subs)
+	}
+}
+
+func TestSubscriptionStoreRollsBackAFailedRewrite(t *testing.T) {
+	ctx := context.Background()
+	s := openSubscriptionStore(t, t.TempDir())
+	s.SaveSubscription(ctx, domain.Subscription{ID: "sub1", URL: "http://sub1"})
+	s.dir = filepath.Join(s.dir, "missing")
+
+	if err := s.SaveSubscription(ctx, domain.Subscription{ID: "sub2", URL: "http://sub2"}); err == nil {
+		t.Fatal("expected the rewrite to fail")
+	}
+	if err := s.DeleteSubscription(ctx, "sub1"); err == nil {
+		t.Fatal("expected the rewrite to fail")
+	}
+	subs, _ := s.ListSubscriptions(ctx)
+	if len(subs) != 1 || subs[0].ID != "sub1" {
+		t.Errorf("expected only sub1, as on disk, got %+v", subs)
+	}
+}
+
+func TestDeliveryLogKeepsTheLimitAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	l := openDeliveryLog(t, dir, 0)
+	for i := range 5 {
+		l.RecordDelivery(ctx, domain.Delivery{ID: fmt.Sprintf("d%d", i), SubscriptionID: "sub1", Attempt: i + 1})
+	}
+	l.RecordDelivery(ctx, domain.Delivery{ID: "other", SubscriptionID: "sub2"})
+
+	reopened := openDeliveryLog(t, dir, 2)
+	list, _ := reopened.ListDeliveries(ctx, "sub1")
+	if len(list) != 2 || list[0].ID != "d3" || list[1].ID != "d4" {
+		t.Errorf("expected d3 and d4, got %+v", list)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, deliveriesFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(b), "\n"); lines != 3 {
+		t.Errorf("expected the file rewritten with the 3 kept attempts, got %d lines", lines)
+	}
+	if d, err := reopened.GetDelivery(ctx, "other"); err != nil || d.SubscriptionID != "sub2" {
+		t.Errorf("unexpected delivery %+v (%v)", d, err)
+	}
+}
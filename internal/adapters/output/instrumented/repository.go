@@ -0,0 +1,120 @@
+// Package instrumented decorates output ports with Prometheus metrics and tracing spans.
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+	"github.com/jailtonjunior/pomelo/internal/metrics"
+	"github.com/jailtonjunior/pomelo/internal/tracing"
+)
+
+// RepositoryDurationMetric is the histogram of repository call latencies, labelled by operation.
+const RepositoryDurationMetric = "pomelo_repository_operation_duration_seconds"
+
+// Repository implements ports.TransactionRepository by timing every call to the wrapped
+// repository and, with WithTracer, recording a "TransactionRepository.<Method>" span for it.
+type Repository struct {
+	next     ports.TransactionRepository
+	duration *metrics.HistogramVec
+	tracer   *tracing.Tracer
+}
+
+// Option configures a Repository.
+type Option func(*Repository)
+
+func WithTracer(t *tracing.Tracer) Option {
+	return func(r *Repository) { r.tracer = t }
+}
+
+func NewRepository(next ports.TransactionRepository, registry *metrics.Registry, opts ...Option) *Repository {
+	r := &Repository{
+		next: next,
+		duration: registry.NewHistogramVec(RepositoryDurationMetric,
+			"Latency of transaction repository operations in seconds.", metrics.DefBuckets, "operation"),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// start opens the span of method; the returned func records err and the latency under operation.
+// Calls outside a traced operation, such as the outbox poller's, get no span of their own.
+func (r *Repository) start(ctx context.Context, method, operation string, attrs ...tracing.Attribute) (context.Context, func(error)) {
+	begin := time.Now()
+	var span *tracing.Span
+	if tracing.SpanFromContext(ctx) != nil {
+		ctx, span = r.tracer.Start(ctx, "TransactionRepository."+method, attrs...)
+	}
+	return ctx, func(err error) {
+		r.duration.Observe(time.Since(begin).Seconds(), operation)
+		span.RecordError(err)
+		span.End()
+	}
+}
+
+func (r *Repository) SaveTransaction(ctx context.Context, tx domain.Transaction) (err error) {
+	ctx, done := r.start(ctx, "SaveTransaction", "save_transaction",
+		tracing.String("transaction.id", tx.ID), tracing.String("transaction.idempotency_key", tx.Event.IdempotencyKey))
+	defer func() { done(err) }()
+	return r.next.SaveTransaction(ctx, tx)
+}
+
+func (r *Repository) SaveAdjustment(ctx context.Context, adj domain.Adjustment) (err error) {
+	ctx, done := r.start(ctx, "SaveAdjustment", "save_adjustment",
+		tracing.String("transaction.id", adj.ID), tracing.String("transaction.idempotency_key", adj.Event.IdempotencyKey))
+	defer func() { done(err) }()
+	return r.next.SaveAdjustment(ctx, adj)
+}
+
+func (r *Repository) AppendAdjustment(ctx context.Context, adj domain.Adjustment, check ports.AdjustmentCheck) (err error) {
+	ctx, done := r.start(ctx, "AppendAdjustment", "append_adjustment",
+		tracing.String("transaction.id", adj.ID), tracing.String("transaction.idempotency_key", adj.Event.IdempotencyKey))
+	defer func() { done(err) }()
+	return r.next.AppendAdjustment(ctx, adj, check)
+}
+
+func (r *Repository) GetTransactionByID(ctx context.Context, id string) (_ domain.Transaction, err error) {
+	ctx, done := r.start(ctx, "GetTransactionByID", "get_transaction", tracing.String("transaction.id", id))
+	defer func() { done(err) }()
+	return r.next.GetTransactionByID(ctx, id)
+}
+
+func (r *Repository) GetAdjustmentsByTransactionID(ctx context.Context, originalTxID string) (_ []domain.Adjustment, err error) {
+	ctx, done := r.start(ctx, "GetAdjustmentsByTransactionID", "get_adjustments", tracing.String("transaction.id", originalTxID))
+	defer func() { done(err) }()
+	return r.next.GetAdjustmentsByTransactionID(ctx, originalTxID)
+}
+
+func (r *Repository) GetAdjustmentByID(ctx context.Context, id string) (_ domain.Adjustment, err error) {
+	ctx, done := r.start(ctx, "GetAdjustmentByID", "get_adjustment", tracing.String("transaction.id", id))
+	defer func() { done(err) }()
+	return r.next.GetAdjustmentByID(ctx, id)
+}
+
+func (r *Repository) GetByIdempotencyKey(ctx context.Context, key string) (string, bool) {
+	ctx, done := r.start(ctx, "GetByIdempotencyKey", "get_by_idempotency_key", tracing.String("transaction.idempotency_key", key))
+	defer done(nil)
+	return r.next.GetByIdempotencyKey(ctx, key)
+}
+
+func (r *Repository) ListTransactions(ctx context.Context, q ports.TransactionQuery) (_ ports.TransactionPage, err error) {
+	ctx, done := r.start(ctx, "ListTransactions", "list_transactions")
+	defer func() { done(err) }()
+	return r.next.ListTransactions(ctx, q)
+}
+
+func (r *Repository) PendingEvents(ctx context.Context, limit int) (_ []domain.OutboxEvent, err error) {
+	ctx, done := r.start(ctx, "PendingEvents", "pending_events")
+	defer func() { done(err) }()
+	return r.next.PendingEvents(ctx, limit)
+}
+
+func (r *Repository) MarkDispatched(ctx context.Context, ids ...string) (err error) {
+	ctx, done := r.start(ctx, "MarkDispatched", "mark_dispatched")
+	defer func() { done(err) }()
+	return r.next.MarkDispatched(ctx, ids...)
+}
@@ -0,0 +1,68 @@
+package instrumented
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+	"github.com/jailtonjunior/pomelo/internal/metrics"
+	"github.com/jailtonjunior/pomelo/internal/tracing"
+)
+
+func TestRepositoryContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) ports.TransactionRepository {
+		return NewRepository(memory.NewRepository(), metrics.NewRegistry())
+	})
+}
+
+func TestRepositoryRecordsOperationLatency(t *testing.T) {
+	registry := metrics.NewRegistry()
+	repo := NewRepository(memory.NewRepository(), registry)
+	ctx := context.Background()
+	repo.GetTransactionByID(ctx, "missing")
+	repo.GetTransactionByID(ctx, "missing")
+	repo.PendingEvents(ctx, 0)
+
+	if got := repo.duration.Count("get_transaction"); got != 2 {
+		t.Errorf("expected 2 get_transaction observations, got %d", got)
+	}
+	var b strings.Builder
+	registry.WriteTo(&b)
+	if !strings.Contains(b.String(), RepositoryDurationMetric+`_count{operation="pending_events"} 1`) {
+		t.Errorf("unexpected exposition:\n%s", b.String())
+	}
+}
+
+// spanRecorder is a tracing.Exporter that keeps the exported spans.
+type spanRecorder struct{ spans []tracing.SpanData }
+
+func (r *spanRecorder) Export(_ context.Context, spans []tracing.SpanData) error {
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func TestRepositoryRecordsSpans(t *testing.T) {
+	exp := &spanRecorder{}
+	tracer := tracing.NewTracer(exp)
+	repo := NewRepository(memory.NewRepository(), metrics.NewRegistry(), WithTracer(tracer))
+	ctx, parent := tracer.Start(context.Background(), "Service.processOriginal")
+	repo.GetTransactionByID(ctx, "tx1")
+	repo.PendingEvents(context.Background(), 10) // no parent span: not traced
+	parent.End()
+	tracer.Flush(ctx)
+
+	if len(exp.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(exp.spans))
+	}
+	span := exp.spans[0]
+	if span.Name != "TransactionRepository.GetTransactionByID" || span.Parent != parent.SpanContext().SpanID {
+		t.Errorf("unexpected span %+v", span)
+	}
+	if span.Error != domain.ErrTransactionNotFound.Error() || span.Attributes[0] != tracing.String("transaction.id", "tx1") {
+		t.Errorf("unexpected span outcome %+v", span)
+	}
+}
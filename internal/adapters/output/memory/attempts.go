@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// AttemptLog is a thread-safe in-memory implementation of ports.AttemptLog.
+type AttemptLog struct {
+	mu       sync.RWMutex
+	attempts []domain.WebhookAttempt
+	byTx     map[string][]int // transaction ID -> positions in attempts
+}
+
+func NewAttemptLog() *AttemptLog {
+	return &AttemptLog{byTx: make(map[string][]int)}
+}
+
+func (l *AttemptLog) AppendAttempt(_ context.Context, a domain.WebhookAttempt) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.byTx[a.TransactionID] = append(l.byTx[a.TransactionID], len(l.attempts))
+	l.attempts = append(l.attempts, a)
+	return nil
+}
+
+func (l *AttemptLog) ListAttempts(_ context.Context, transactionID string) ([]domain.WebhookAttempt, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := make([]domain.WebhookAttempt, 0, len(l.byTx[transactionID]))
+	for _, i := range l.byTx[transactionID] {
+		out = append(out, l.attempts[i])
+	}
+	return out, nil
+}
+
+func (l *AttemptLog) AllAttempts(context.Context) ([]domain.WebhookAttempt, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.attempts == nil {
+		return []domain.WebhookAttempt{}, nil
+	}
+	return slices.Clone(l.attempts), nil
+}
+
+// Sizes reports how many attempts are held, for metrics.
+func (l *AttemptLog) Sizes() map[string]int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return map[string]int{"webhook_attempts": len(l.attempts)}
+}
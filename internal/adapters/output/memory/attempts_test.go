@@ -0,0 +1,12 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+)
+
+func TestAttemptLogContract(t *testing.T) {
+	repotest.RunAttemptLog(t, func(*testing.T) ports.AttemptLog { return NewAttemptLog() })
+}
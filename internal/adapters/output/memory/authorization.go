@@ -0,0 +1,179 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+	"github.com/jailtonjunior/pomelo/internal/keylock"
+)
+
+// AuthorizationStore is a thread-safe in-memory implementation of ports.AuthorizationStore.
+// mu only guards the maps; decisions are serialized per card by a key lock, so a busy card does
+// not hold up decisions for the others.
+type AuthorizationStore struct {
+	mu             sync.Mutex
+	cards          map[string]domain.Card
+	authorizations map[string]domain.Authorization
+	// byCard lists each card's authorization IDs in decision order.
+	byCard map[string][]string
+	// deciding serializes decisions per card ID.
+	deciding keylock.Locks
+	journal  AuthorizationJournal
+}
+
+// AuthorizationEntry is one change to an AuthorizationStore: a saved card, or an authorization
+// recorded or replaced by a match. Exactly one field is set.
+type AuthorizationEntry struct {
+	Card          *domain.Card
+	Authorization *domain.Authorization
+}
+
+// AuthorizationJournal is handed every change under the store's lock, in order, before it is
+// applied. A change the journal fails is not applied.
+type AuthorizationJournal func(AuthorizationEntry) error
+
+func NewAuthorizationStore() *AuthorizationStore {
+	return NewJournaledAuthorizationStore(nil)
+}
+
+// NewJournaledAuthorizationStore returns a store that writes every change to journal first, so a
+// durable adapter can replay the journal into Apply.
+func NewJournaledAuthorizationStore(journal AuthorizationJournal) *AuthorizationStore {
+	return &AuthorizationStore{
+		cards:          make(map[string]domain.Card),
+		authorizations: make(map[string]domain.Authorization),
+		byCard:         make(map[string][]string),
+		journal:        journal,
+	}
+}
+
+// Apply replays a journal entry without journaling it again. The last entry for a card or an
+// authorization wins.
+func (s *AuthorizationStore) Apply(e AuthorizationEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e.Card != nil {
+		s.cards[e.Card.ID] = *e.Card
+	}
+	if auth := e.Authorization; auth != nil {
+		if _, ok := s.authorizations[auth.Request.ID]; !ok {
+			s.byCard[auth.Request.CardID] = append(s.byCard[auth.Request.CardID], auth.Request.ID)
+		}
+		s.authorizations[auth.Request.ID] = *auth
+	}
+}
+
+func (s *AuthorizationStore) SaveCard(_ context.Context, card domain.Card) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.write(AuthorizationEntry{Card: &card}); err != nil {
+		return err
+	}
+	s.cards[card.ID] = card
+	return nil
+}
+
+func (s *AuthorizationStore) GetCard(_ context.Context, id string) (domain.Card, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	card, ok := s.cards[id]
+	if !ok {
+		return domain.Card{}, domain.ErrCardNotFound
+	}
+	return card, nil
+}
+
+// Decide runs decide on a snapshot of the card and its history taken under the card's
+// lock, outside mu.
+func (s *AuthorizationStore) Decide(ctx context.Context, id, cardID string, decide ports.AuthorizationDecider) (domain.Authorization, error) {
+	release, err := s.deciding.LockContext(ctx, cardID)
+	if err != nil {
+		return domain.Authorization{}, err
+	}
+	defer release()
+
+	s.mu.Lock()
+	if existing, ok := s.authorizations[id]; ok {
+		s.mu.Unlock()
+		return existing, domain.ErrDuplicateAuthorization
+	}
+	card := s.cards[cardID]
+	history := make([]domain.Authorization, len(s.byCard[cardID]))
+	for i, authID := range s.byCard[cardID] {
+		history[i] = s.authorizations[authID]
+	}
+	s.mu.Unlock()
+
+	auth, err := decide(card, history)
+	if err != nil {
+		return domain.Authorization{}, err
+	}
+	if auth.Request.ID != id || auth.Request.CardID != cardID {
+		return domain.Authorization{}, fmt.Errorf("%w: decided %s for card %s, want %s for card %s",
+			domain.ErrInvalidInput, auth.Request.ID, auth.Request.CardID, id, cardID)
+	}
+	return s.record(auth)
+}
+
+func (s *AuthorizationStore) RecordAuthorization(_ context.Context, auth domain.Authorization) (domain.Authorization, error) {
+	return s.record(auth)
+}
+
+// record stores auth unless its ID was decided meanwhile, for another card or by
+// RecordAuthorization.
+func (s *AuthorizationStore) record(auth domain.Authorization) (domain.Authorization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.authorizations[auth.Request.ID]; ok {
+		return existing, domain.ErrDuplicateAuthorization
+	}
+	if err := s.write(AuthorizationEntry{Authorization: &auth}); err != nil {
+		return domain.Authorization{}, err
+	}
+	s.authorizations[auth.Request.ID] = auth
+	s.byCard[auth.Request.CardID] = append(s.byCard[auth.Request.CardID], auth.Request.ID)
+	return auth, nil
+}
+
+func (s *AuthorizationStore) GetAuthorization(_ context.Context, id string) (domain.Authorization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	auth, ok := s.authorizations[id]
+	if !ok {
+		return domain.Authorization{}, domain.ErrAuthorizationNotFound
+	}
+	return auth, nil
+}
+
+func (s *AuthorizationStore) Match(_ context.Context, id string, match func(domain.Authorization) domain.Authorization) (domain.Authorization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	auth, ok := s.authorizations[id]
+	if !ok {
+		return domain.Authorization{}, domain.ErrAuthorizationNotFound
+	}
+	auth = match(auth)
+	if err := s.write(AuthorizationEntry{Authorization: &auth}); err != nil {
+		return domain.Authorization{}, err
+	}
+	s.authorizations[id] = auth
+	return auth, nil
+}
+
+// write hands e to the journal, if any. Callers hold mu.
+func (s *AuthorizationStore) write(e AuthorizationEntry) error {
+	if s.journal == nil {
+		return nil
+	}
+	return s.journal(e)
+}
+
+// Sizes reports how many cards and authorizations are held, for metrics.
+func (s *AuthorizationStore) Sizes() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return map[string]int{"cards": len(s.cards), "authorizations": len(s.authorizations)}
+}
@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func TestAuthorizationStoreContract(t *testing.T) {
+	repotest.RunAuthorizationStore(t, func(*testing.T) ports.AuthorizationStore { return NewAuthorizationStore() })
+}
+
+func TestAuthorizationStoreDecideWaitsOnlyForItsCard(t *testing.T) {
+	store := NewAuthorizationStore()
+	ctx := context.Background()
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		store.Decide(ctx, "tx1", "card1", func(card domain.Card, history []domain.Authorization) (domain.Authorization, error) {
+			close(started)
+			<-release
+			return repotest.DecideFor(t, "tx1")(card, history)
+		})
+	}()
+	<-started
+
+	// Another card is decided while card1 is busy.
+	req, _ := domain.NewAuthorizationRequest("tx2", domain.TypePurchase, "card2", "u1", domain.Merchant{ID: "m1"}, makeMoney(1000))
+	if _, err := store.Decide(ctx, "tx2", "card2", func(card domain.Card, history []domain.Authorization) (domain.Authorization, error) {
+		return domain.Authorize(req, card, 0, history, parkedAt, time.Hour), nil
+	}); err != nil {
+		t.Fatalf("expected card2 decided while card1 is busy, got %v", err)
+	}
+
+	close(release)
+	<-done
+}
+
+func TestJournaledAuthorizationStoreSkipsAChangeTheJournalFails(t *testing.T) {
+	boom := errors.New("boom")
+	var journaled []AuthorizationEntry
+	fail := false
+	store := NewJournaledAuthorizationStore(func(e AuthorizationEntry) error {
+		if fail {
+			return boom
+		}
+		journaled = append(journaled, e)
+		return nil
+	})
+	ctx := context.Background()
+	card, _ := domain.NewCard("card1", "u1", domain.CardActive, "BRL", 1500, 0, 0)
+	store.SaveCard(ctx, card)
+	store.Decide(ctx, "tx1", "card1", repotest.DecideFor(t, "tx1"))
+	if len(journaled) != 2 || journaled[0].Card == nil || journaled[1].Authorization == nil {
+		t.Fatalf("expected the card then the decision journaled, got %+v", journaled)
+	}
+
+	fail = true
+	if _, err := store.Decide(ctx, "tx2", "card1", repotest.DecideFor(t, "tx2")); !errors.Is(err, boom) {
+		t.Errorf("expected the journal's error, got %v", err)
+	}
+	if _, err := store.GetAuthorization(ctx, "tx2"); !errors.Is(err, domain.ErrAuthorizationNotFound) {
+		t.Errorf("a decision the journal failed must not be recorded, got %v", err)
+	}
+	if _, err := store.Match(ctx, "tx1", func(a domain.Authorization) domain.Authorization {
+		return a.MatchWebhook(makePurchase("tx1", "idem1", 1000), parkedAt)
+	}); !errors.Is(err, boom) {
+		t.Errorf("expected the journal's error, got %v", err)
+	}
+	if auth, _ := store.GetAuthorization(ctx, "tx1"); auth.Match != nil {
+		t.Error("a match the journal failed must not be applied")
+	}
+}
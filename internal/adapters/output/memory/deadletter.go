@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// DeadLetterStore is a thread-safe in-memory implementation of ports.DeadLetterStore.
+type DeadLetterStore struct {
+	mu      sync.RWMutex
+	letters []domain.DeadLetter
+}
+
+func NewDeadLetterStore() *DeadLetterStore {
+	return &DeadLetterStore{}
+}
+
+func (s *DeadLetterStore) AddDeadLetter(_ context.Context, dl domain.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.letters = append(s.letters, dl)
+	return nil
+}
+
+func (s *DeadLetterStore) ListDeadLetters(context.Context) ([]domain.DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.letters == nil {
+		return []domain.DeadLetter{}, nil
+	}
+	return slices.Clone(s.letters), nil
+}
+
+// Sizes reports how many dead letters are held, for metrics.
+func (s *DeadLetterStore) Sizes() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return map[string]int{"dead_letters": len(s.letters)}
+}
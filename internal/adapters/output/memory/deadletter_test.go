@@ -0,0 +1,12 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+)
+
+func TestDeadLetterStoreContract(t *testing.T) {
+	repotest.RunDeadLetterStore(t, func(*testing.T) ports.DeadLetterStore { return NewDeadLetterStore() })
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// DisputeStore is a thread-safe in-memory implementation of ports.DisputeStore.
+type DisputeStore struct {
+	mu       sync.RWMutex
+	disputes map[string]domain.Dispute
+	order    []string            // IDs, in opening order
+	byTx     map[string][]string // transaction ID -> IDs of its disputes
+}
+
+func NewDisputeStore() *DisputeStore {
+	return &DisputeStore{disputes: make(map[string]domain.Dispute), byTx: make(map[string][]string)}
+}
+
+func (s *DisputeStore) SaveDispute(_ context.Context, d domain.Dispute) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.disputes[d.ID]; exists {
+		return fmt.Errorf("%w: dispute %s already exists", domain.ErrInvalidInput, d.ID)
+	}
+	s.disputes[d.ID] = d
+	s.order = append(s.order, d.ID)
+	s.byTx[d.TransactionID] = append(s.byTx[d.TransactionID], d.ID)
+	return nil
+}
+
+func (s *DisputeStore) UpdateDispute(_ context.Context, d domain.Dispute) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.disputes[d.ID]; !exists {
+		return domain.ErrDisputeNotFound
+	}
+	s.disputes[d.ID] = d
+	return nil
+}
+
+func (s *DisputeStore) GetDispute(_ context.Context, id string) (domain.Dispute, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.disputes[id]
+	if !ok {
+		return domain.Dispute{}, domain.ErrDisputeNotFound
+	}
+	return d, nil
+}
+
+func (s *DisputeStore) ListDisputes(_ context.Context, transactionID string) ([]domain.Dispute, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.collect(s.byTx[transactionID]), nil
+}
+
+func (s *DisputeStore) AllDisputes(context.Context) ([]domain.Dispute, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.collect(s.order), nil
+}
+
+func (s *DisputeStore) collect(ids []string) []domain.Dispute {
+	out := make([]domain.Dispute, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, s.disputes[id])
+	}
+	return out
+}
+
+// Sizes reports how many disputes are held, for metrics.
+func (s *DisputeStore) Sizes() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return map[string]int{"disputes": len(s.disputes)}
+}
@@ -0,0 +1,12 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+)
+
+func TestDisputeStoreContract(t *testing.T) {
+	repotest.RunDisputeStore(t, func(*testing.T) ports.DisputeStore { return NewDisputeStore() })
+}
@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// IdempotencyStore is a thread-safe in-memory implementation of ports.IdempotencyStore.
+// Records are kept in save order; with a fixed retention that is also expiry order, so each
+// save drops the expired records at the front without scanning the rest.
+type IdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]domain.IdempotencyRecord
+	order   []string // keys, oldest first
+}
+
+func NewIdempotencyStore() *IdempotencyStore {
+	return &IdempotencyStore{records: make(map[string]domain.IdempotencyRecord)}
+}
+
+func (s *IdempotencyStore) SaveIdempotencyRecord(_ context.Context, rec domain.IdempotencyRecord) error {
+	s.Put(rec)
+	return nil
+}
+
+func (s *IdempotencyStore) ClaimIdempotencyKey(_ context.Context, rec domain.IdempotencyRecord) (domain.IdempotencyRecord, error) {
+	held, _ := s.Claim(rec)
+	return held, nil
+}
+
+// Claim stores rec unless a live record for its key exists, returning the record the key holds
+// and whether rec was stored.
+func (s *IdempotencyStore) Claim(rec domain.IdempotencyRecord) (domain.IdempotencyRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evict(rec.CreatedAt)
+	if cur, exists := s.records[rec.Key]; exists {
+		return cur, false
+	}
+	s.records[rec.Key] = rec
+	s.order = append(s.order, rec.Key)
+	return rec, true
+}
+
+func (s *IdempotencyStore) ClearIdempotencyResponse(_ context.Context, key string, now time.Time) error {
+	s.Clear(key, now)
+	return nil
+}
+
+// Put stores rec unless a live record for its key exists, returning what it stored and whether
+// it did. A record without a response is filled in by a rec for the same payload; it keeps its
+// place and expiry. The file store calls Put to know whether a save must be persisted.
+func (s *IdempotencyStore) Put(rec domain.IdempotencyRecord) (domain.IdempotencyRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evict(rec.CreatedAt)
+	if cur, exists := s.records[rec.Key]; exists {
+		if cur.HasResponse() || cur.PayloadHash != rec.PayloadHash {
+			return domain.IdempotencyRecord{}, false
+		}
+		rec.CreatedAt, rec.ExpiresAt = cur.CreatedAt, cur.ExpiresAt
+		s.records[rec.Key] = rec
+		return rec, true
+	}
+	s.records[rec.Key] = rec
+	s.order = append(s.order, rec.Key)
+	return rec, true
+}
+
+// Clear drops the response of the live record for key and returns the record as it is now,
+// reporting whether there was a response to drop.
+func (s *IdempotencyStore) Clear(key string, now time.Time) (domain.IdempotencyRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[key]
+	if !ok || rec.Expired(now) || !rec.HasResponse() {
+		return domain.IdempotencyRecord{}, false
+	}
+	rec = rec.WithoutResponse()
+	s.records[key] = rec
+	return rec, true
+}
+
+// Restore puts rec back as saved earlier, replacing the record for its key. The file store
+// reloads its records with it, so the latest line for a key wins.
+func (s *IdempotencyStore) Restore(rec domain.IdempotencyRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cur, exists := s.records[rec.Key]; exists {
+		if cur.CreatedAt.Equal(rec.CreatedAt) {
+			s.records[rec.Key] = rec
+			return
+		}
+		s.order = slices.DeleteFunc(s.order, func(key string) bool { return key == rec.Key })
+	}
+	s.records[rec.Key] = rec
+	s.order = append(s.order, rec.Key)
+}
+
+func (s *IdempotencyStore) GetIdempotencyRecord(_ context.Context, key string, now time.Time) (domain.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[key]
+	if !ok || rec.Expired(now) {
+		return domain.IdempotencyRecord{}, false, nil
+	}
+	return rec, true, nil
+}
+
+// All returns the live records at now, oldest first.
+func (s *IdempotencyStore) All(now time.Time) []domain.IdempotencyRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evict(now)
+	out := make([]domain.IdempotencyRecord, 0, len(s.order))
+	for _, key := range s.order {
+		out = append(out, s.records[key])
+	}
+	return out
+}
+
+func (s *IdempotencyStore) Sizes() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return map[string]int{"idempotency_records": len(s.records)}
+}
+
+// evict drops the records at the front of the order that expired at now.
+func (s *IdempotencyStore) evict(now time.Time) {
+	n := 0
+	for _, key := range s.order {
+		if !s.records[key].Expired(now) {
+			break
+		}
+		delete(s.records, key)
+		n++
+	}
+	s.order = s.order[n:]
+}
@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func makeIdempotencyRecord(key string, status int, createdAt time.Time) domain.IdempotencyRecord {
+	rec, _ := domain.NewIdempotencyRecord(key, "hash-"+key, status, []byte(`{"key":"`+key+`"}`), createdAt, time.Hour)
+	return rec
+}
+
+func TestIdempotencyStoreContract(t *testing.T) {
+	repotest.RunIdempotencyStore(t, func(*testing.T) ports.IdempotencyStore { return NewIdempotencyStore() })
+}
+
+func TestIdempotencyStoreExpiry(t *testing.T) {
+	store := NewIdempotencyStore()
+	ctx := context.Background()
+	store.SaveIdempotencyRecord(ctx, makeIdempotencyRecord("idem1", 200, parkedAt))
+	store.SaveIdempotencyRecord(ctx, makeIdempotencyRecord("idem2", 200, parkedAt.Add(30*time.Minute)))
+
+	if _, ok, _ := store.GetIdempotencyRecord(ctx, "idem1", parkedAt.Add(time.Hour)); ok {
+		t.Error("expected idem1 to have expired")
+	}
+	// A save after the expiry evicts idem1 and lets its key be recorded again.
+	store.SaveIdempotencyRecord(ctx, makeIdempotencyRecord("idem1", 422, parkedAt.Add(time.Hour)))
+	if got := store.Sizes()["idempotency_records"]; got != 2 {
+		t.Errorf("expected 2 records after eviction, got %d", got)
+	}
+	if rec, ok, _ := store.GetIdempotencyRecord(ctx, "idem1", parkedAt.Add(time.Hour)); !ok || rec.StatusCode != 422 {
+		t.Errorf("expected the new idem1 record, got %+v", rec)
+	}
+	all := store.All(parkedAt.Add(time.Hour))
+	if len(all) != 2 || all[0].Key != "idem2" || all[1].Key != "idem1" {
+		t.Errorf("expected [idem2 idem1] in save order, got %v", all)
+	}
+}
@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// LedgerStore is a thread-safe in-memory implementation of ports.LedgerStore. Balances are kept
+// up to date on every Post, so reads never rescan the entries.
+type LedgerStore struct {
+	mu      sync.RWMutex
+	entries []domain.LedgerEntry
+	posted  map[string]struct{}
+	// accounts and users map to one balance per currency.
+	accounts map[string]map[string]domain.AccountBalance
+	users    map[string]map[string]domain.AccountBalance
+	// cardEntries indexes entries by card for the card account's side only.
+	cardEntries map[string][]int
+}
+
+func NewLedgerStore() *LedgerStore {
+	return &LedgerStore{
+		posted:      make(map[string]struct{}),
+		accounts:    make(map[string]map[string]domain.AccountBalance),
+		users:       make(map[string]map[string]domain.AccountBalance),
+		cardEntries: make(map[string][]int),
+	}
+}
+
+// Post validates the posting before taking the lock, then records it and updates the balances.
+func (s *LedgerStore) Post(_ context.Context, entries []domain.LedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if _, err := domain.VerifyBalanced(entries); err != nil {
+		return err
+	}
+	postingID := entries[0].PostingID
+	for _, e := range entries[1:] {
+		if e.PostingID != postingID {
+			return fmt.Errorf("%w: posting mixes %s and %s", domain.ErrInvalidInput, postingID, e.PostingID)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.posted[postingID]; exists {
+		return domain.ErrDuplicatePosting
+	}
+	s.posted[postingID] = struct{}{}
+	for _, e := range entries {
+		bal := applyBalance(s.accounts, e.Account, e)
+		e.RunningBalance = bal.Net
+		if e.Account == domain.CardAccount(e.CardID) {
+			applyBalance(s.users, e.UserID, e)
+			s.cardEntries[e.CardID] = append(s.cardEntries[e.CardID], len(s.entries))
+		}
+		s.entries = append(s.entries, e)
+	}
+	return nil
+}
+
+func (s *LedgerStore) EntriesByCard(_ context.Context, cardID string) ([]domain.LedgerEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]domain.LedgerEntry, 0, len(s.cardEntries[cardID]))
+	for _, i := range s.cardEntries[cardID] {
+		out = append(out, s.entries[i])
+	}
+	return out, nil
+}
+
+func (s *LedgerStore) BalancesByCard(_ context.Context, cardID string) ([]domain.AccountBalance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedBalances(s.accounts[domain.CardAccount(cardID)]), nil
+}
+
+func (s *LedgerStore) BalancesByUser(_ context.Context, userID string) ([]domain.AccountBalance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedBalances(s.users[userID]), nil
+}
+
+func (s *LedgerStore) Entries(_ context.Context) ([]domain.LedgerEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.entries), nil
+}
+
+// applyBalance adds e to the balance of key in e's currency and returns the new balance.
+func applyBalance(balances map[string]map[string]domain.AccountBalance, key string, e domain.LedgerEntry) domain.AccountBalance {
+	byCurrency, ok := balances[key]
+	if !ok {
+		byCurrency = make(map[string]domain.AccountBalance)
+		balances[key] = byCurrency
+	}
+	bal := byCurrency[e.Amount.Currency]
+	bal.Currency = e.Amount.Currency
+	bal = bal.Apply(e)
+	byCurrency[e.Amount.Currency] = bal
+	return bal
+}
+
+func sortedBalances(byCurrency map[string]domain.AccountBalance) []domain.AccountBalance {
+	out := slices.SortedFunc(maps.Values(byCurrency), func(a, b domain.AccountBalance) int {
+		return cmp.Compare(a.Currency, b.Currency)
+	})
+	if out == nil {
+		out = []domain.AccountBalance{}
+	}
+	return out
+}
+
+// Sizes reports how many ledger entries are held, for metrics.
+func (s *LedgerStore) Sizes() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return map[string]int{"ledger_entries": len(s.entries)}
+}
@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func TestLedgerPostAndBalances(t *testing.T) {
+	store := NewLedgerStore()
+	ctx := context.Background()
+	if err := store.Post(ctx, domain.PostTransaction(makePurchase("tx1", "idem1", 1000))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Post(ctx, domain.PostAdjustment(makeAdjustment("adj1", "tx1", "idem-adj1", 300))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, _ := store.EntriesByCard(ctx, "card1")
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 card entries, got %d", len(entries))
+	}
+	if entries[0].RunningBalance != 1000 || entries[1].RunningBalance != 700 {
+		t.Errorf("expected running balances 1000 then 700, got %d and %d", entries[0].RunningBalance, entries[1].RunningBalance)
+	}
+
+	card, _ := store.BalancesByCard(ctx, "card1")
+	user, _ := store.BalancesByUser(ctx, "u1")
+	want := domain.AccountBalance{Currency: "BRL", Debits: 1000, Credits: 300, Net: 700}
+	if len(card) != 1 || card[0] != want {
+		t.Errorf("card balance: expected %+v, got %+v", want, card)
+	}
+	if len(user) != 1 || user[0] != want {
+		t.Errorf("user balance: expected %+v, got %+v", want, user)
+	}
+
+	all, _ := store.Entries(ctx)
+	if _, err := domain.VerifyBalanced(all); err != nil {
+		t.Errorf("ledger should balance: %v", err)
+	}
+}
+
+func TestLedgerPostDuplicate(t *testing.T) {
+	store := NewLedgerStore()
+	ctx := context.Background()
+	posting := domain.PostTransaction(makePurchase("tx1", "idem1", 1000))
+	store.Post(ctx, posting)
+	if err := store.Post(ctx, posting); !errors.Is(err, domain.ErrDuplicatePosting) {
+		t.Errorf("expected ErrDuplicatePosting, got %v", err)
+	}
+	if all, _ := store.Entries(ctx); len(all) != 2 {
+		t.Errorf("duplicate must not be stored, got %d entries", len(all))
+	}
+}
+
+func TestLedgerPostUnbalanced(t *testing.T) {
+	store := NewLedgerStore()
+	posting := domain.PostTransaction(makePurchase("tx1", "idem1", 1000))
+	if err := store.Post(context.Background(), posting[:1]); !errors.Is(err, domain.ErrLedgerUnbalanced) {
+		t.Errorf("expected ErrLedgerUnbalanced, got %v", err)
+	}
+}
+
+func TestLedgerUnknownAccountIsEmpty(t *testing.T) {
+	store := NewLedgerStore()
+	ctx := context.Background()
+	entries, _ := store.EntriesByCard(ctx, "nope")
+	balances, _ := store.BalancesByUser(ctx, "nope")
+	if entries == nil || len(entries) != 0 || balances == nil || len(balances) != 0 {
+		t.Errorf("expected empty non-nil slices, got %v and %v", entries, balances)
+	}
+}
+
+func TestLedgerConcurrentPostsStayBalanced(t *testing.T) {
+	store := NewLedgerStore()
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Go(func() {
+			id := fmt.Sprintf("tx%d", i)
+			store.Post(ctx, domain.PostTransaction(makePurchase(id, "idem-"+id, 100)))
+		})
+	}
+	wg.Wait()
+
+	all, _ := store.Entries(ctx)
+	totals, err := domain.VerifyBalanced(all)
+	if err != nil {
+		t.Fatalf("ledger should balance: %v", err)
+	}
+	if totals[0].Debits != 5000 {
+		t.Errorf("expected 5000 debited, got %d", totals[0].Debits)
+	}
+	entries, _ := store.EntriesByCard(ctx, "card1")
+	if last := entries[len(entries)-1]; last.RunningBalance != 5000 {
+		t.Errorf("expected final running balance 5000, got %d", last.RunningBalance)
+	}
+}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// pendingEntry keeps the arrival sequence so entries parked in the same instant stay ordered.
+type pendingEntry struct {
+	seq     uint64
+	pending domain.PendingAdjustment
+}
+
+// PendingStore is a thread-safe in-memory implementation of ports.PendingAdjustmentStore.
+type PendingStore struct {
+	mu      sync.Mutex
+	seq     uint64
+	entries map[string]pendingEntry // idempotency key → entry
+}
+
+func NewPendingStore() *PendingStore {
+	return &PendingStore{entries: make(map[string]pendingEntry)}
+}
+
+func (s *PendingStore) Park(_ context.Context, p domain.PendingAdjustment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := p.Adjustment.Event.IdempotencyKey
+	if _, exists := s.entries[key]; exists {
+		return domain.ErrDuplicateIdempotencyKey
+	}
+	s.put(p)
+	return nil
+}
+
+func (s *PendingStore) GetByIdempotencyKey(_ context.Context, key string) (domain.PendingAdjustment, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	return e.pending, ok
+}
+
+// TakeParked removes the live entries for originalTxID under the lock, so two callers draining
+// the same purchase never receive the same adjustment.
+func (s *PendingStore) TakeParked(_ context.Context, originalTxID string, now time.Time) ([]domain.PendingAdjustment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var taken []pendingEntry
+	for key, e := range s.entries {
+		if e.pending.Adjustment.OriginalTransactionID != originalTxID || e.pending.NeedsReview(now) {
+			continue
+		}
+		taken = append(taken, e)
+		delete(s.entries, key)
+	}
+	return pendingValues(taken, false), nil
+}
+
+func (s *PendingStore) Reject(_ context.Context, p domain.PendingAdjustment, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p.Reason = reason
+	s.put(p)
+	return nil
+}
+
+func (s *PendingStore) ListForReview(_ context.Context, now time.Time) ([]domain.PendingAdjustment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var review []pendingEntry
+	for _, e := range s.entries {
+		if e.pending.NeedsReview(now) {
+			review = append(review, e)
+		}
+	}
+	return pendingValues(review, true), nil
+}
+
+// All returns every held entry in arrival order. Durable adapters use it to persist the store.
+func (s *PendingStore) All() []domain.PendingAdjustment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return pendingValues(slices.Collect(maps.Values(s.entries)), false)
+}
+
+func (s *PendingStore) put(p domain.PendingAdjustment) {
+	s.seq++
+	s.entries[p.Adjustment.Event.IdempotencyKey] = pendingEntry{seq: s.seq, pending: p}
+}
+
+// pendingValues sorts entries by arrival sequence, or by ParkedAt first when byParkedAt is set.
+func pendingValues(entries []pendingEntry, byParkedAt bool) []domain.PendingAdjustment {
+	slices.SortFunc(entries, func(a, b pendingEntry) int {
+		if byParkedAt {
+			if c := a.pending.ParkedAt.Compare(b.pending.ParkedAt); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.seq, b.seq)
+	})
+	out := make([]domain.PendingAdjustment, len(entries))
+	for i, e := range entries {
+		out[i] = e.pending
+	}
+	return out
+}
+
+// Sizes reports how many adjustments are parked or awaiting review, for metrics.
+func (s *PendingStore) Sizes() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return map[string]int{"pending_adjustments": len(s.entries)}
+}
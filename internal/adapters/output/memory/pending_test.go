@@ -0,0 +1,16 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+)
+
+// parkedAt is the fixed clock the store tests share.
+var parkedAt = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func TestPendingStoreContract(t *testing.T) {
+	repotest.RunPendingStore(t, func(*testing.T) ports.PendingAdjustmentStore { return NewPendingStore() })
+}
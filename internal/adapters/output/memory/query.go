@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"cmp"
+	"strings"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func matchesQuery(q ports.TransactionQuery, tx domain.Transaction) bool {
+	switch {
+	case q.UserID != "" && tx.UserID != q.UserID,
+		q.CardID != "" && tx.CardID != q.CardID,
+		q.MerchantID != "" && tx.Merchant.ID != q.MerchantID,
+		q.MerchantMCC != "" && tx.Merchant.MCC != q.MerchantMCC,
+		q.Status != "" && tx.Status != q.Status,
+		q.Type != "" && tx.Type != q.Type,
+		q.Currency != "" && tx.Amount.Local.Currency != q.Currency,
+		q.MinAmount != nil && tx.Amount.Local.Amount < *q.MinAmount,
+		q.MaxAmount != nil && tx.Amount.Local.Amount > *q.MaxAmount,
+		!q.CreatedFrom.IsZero() && tx.Event.CreatedAt.Before(q.CreatedFrom),
+		!q.CreatedTo.IsZero() && !tx.Event.CreatedAt.Before(q.CreatedTo):
+		return false
+	}
+	return true
+}
+
+// compareCursors orders two positions by the query's sort field, then by ID, honouring Descending.
+func compareCursors(q ports.TransactionQuery, a, b ports.TransactionCursor) int {
+	var c int
+	switch q.SortBy {
+	case ports.SortByAmount:
+		c = cmp.Compare(a.Amount, b.Amount)
+	case ports.SortByID:
+	default:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if c == 0 {
+		c = strings.Compare(a.ID, b.ID)
+	}
+	if q.Descending {
+		return -c
+	}
+	return c
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// RejectedWebhookStore is a thread-safe in-memory implementation of ports.RejectedWebhookStore.
+type RejectedWebhookStore struct {
+	mu      sync.RWMutex
+	entries map[string]domain.RejectedWebhook
+	order   []string          // IDs, oldest first
+	open    map[string]string // idempotency key -> ID of its unresolved entry
+}
+
+func NewRejectedWebhookStore() *RejectedWebhookStore {
+	return &RejectedWebhookStore{entries: make(map[string]domain.RejectedWebhook), open: make(map[string]string)}
+}
+
+func (s *RejectedWebhookStore) SaveRejection(_ context.Context, rw domain.RejectedWebhook) (domain.RejectedWebhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id, ok := s.open[rw.IdempotencyKey]; ok && rw.IdempotencyKey != "" {
+		rw = s.entries[id].Repeat(rw)
+	} else {
+		s.order = append(s.order, rw.ID)
+	}
+	s.put(rw)
+	return rw, nil
+}
+
+func (s *RejectedWebhookStore) UpdateRejection(_ context.Context, rw domain.RejectedWebhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[rw.ID]; !ok {
+		return domain.ErrRejectionNotFound
+	}
+	s.put(rw)
+	return nil
+}
+
+func (s *RejectedWebhookStore) GetRejection(_ context.Context, id string) (domain.RejectedWebhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rw, ok := s.entries[id]
+	if !ok {
+		return domain.RejectedWebhook{}, domain.ErrRejectionNotFound
+	}
+	return rw, nil
+}
+
+func (s *RejectedWebhookStore) OpenRejection(_ context.Context, idempotencyKey string) (domain.RejectedWebhook, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	id, ok := s.open[idempotencyKey]
+	if !ok {
+		return domain.RejectedWebhook{}, false, nil
+	}
+	return s.entries[id], true, nil
+}
+
+func (s *RejectedWebhookStore) ListRejections(context.Context) ([]domain.RejectedWebhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.all(), nil
+}
+
+// All returns every entry, oldest first. The file store persists it.
+func (s *RejectedWebhookStore) All() []domain.RejectedWebhook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.all()
+}
+
+// Sizes reports how many rejected webhooks are held, for metrics.
+func (s *RejectedWebhookStore) Sizes() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return map[string]int{"rejected_webhooks": len(s.entries)}
+}
+
+func (s *RejectedWebhookStore) all() []domain.RejectedWebhook {
+	out := make([]domain.RejectedWebhook, 0, len(s.order))
+	for _, id := range s.order {
+		out = append(out, s.entries[id])
+	}
+	return out
+}
+
+// put stores rw and keeps the open index in step with its state.
+func (s *RejectedWebhookStore) put(rw domain.RejectedWebhook) {
+	s.entries[rw.ID] = rw
+	if rw.IdempotencyKey == "" {
+		return
+	}
+	if rw.Resolved() {
+		if s.open[rw.IdempotencyKey] == rw.ID {
+			delete(s.open, rw.IdempotencyKey)
+		}
+		return
+	}
+	s.open[rw.IdempotencyKey] = rw.ID
+}
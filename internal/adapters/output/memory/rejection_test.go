@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func makeRejection(id, key, code string, at time.Time) domain.RejectedWebhook {
+	rw, _ := domain.NewRejectedWebhook(id, "adj1", key, []byte(`{"id":"adj1"}`), 404, code, "transaction not found", at)
+	return rw
+}
+
+func TestRejectedWebhookStoreFoldsRetries(t *testing.T) {
+	ctx := context.Background()
+	store := NewRejectedWebhookStore()
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	store.SaveRejection(ctx, makeRejection("rej1", "idem1", "NOT_FOUND", at))
+	saved, err := store.SaveRejection(ctx, makeRejection("rej2", "idem1", "EXCEEDS_ORIGINAL_AMOUNT", at.Add(time.Minute)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if saved.ID != "rej1" || saved.Rejections != 2 || saved.Code != "EXCEEDS_ORIGINAL_AMOUNT" || !saved.FirstRejectedAt.Equal(at) {
+		t.Errorf("expected the retry folded into rej1, got %+v", saved)
+	}
+	if _, err := store.GetRejection(ctx, "rej2"); !errors.Is(err, domain.ErrRejectionNotFound) {
+		t.Errorf("expected no separate entry for the retry, got %v", err)
+	}
+
+	// Once resolved, a new rejection of the same key opens a new entry.
+	store.UpdateRejection(ctx, saved.Resolve(at.Add(2*time.Minute)))
+	if _, ok, _ := store.OpenRejection(ctx, "idem1"); ok {
+		t.Error("expected no open entry after resolution")
+	}
+	store.SaveRejection(ctx, makeRejection("rej3", "idem1", "NOT_FOUND", at.Add(3*time.Minute)))
+	open, ok, _ := store.OpenRejection(ctx, "idem1")
+	if !ok || open.ID != "rej3" {
+		t.Errorf("expected rej3 open, got %+v (ok %v)", open, ok)
+	}
+	all, _ := store.ListRejections(ctx)
+	if len(all) != 2 || all[0].ID != "rej1" || all[1].ID != "rej3" {
+		t.Errorf("expected rej1 and rej3 in order, got %+v", all)
+	}
+}
+
+func TestRejectedWebhookStoreUpdateUnknown(t *testing.T) {
+	store := NewRejectedWebhookStore()
+	err := store.UpdateRejection(context.Background(), makeRejection("rej1", "idem1", "NOT_FOUND", time.Now()))
+	if !errors.Is(err, domain.ErrRejectionNotFound) {
+		t.Errorf("expected ErrRejectionNotFound, got %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"slices"
 	"sync"
 
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
 	"github.com/jailtonjunior/pomelo/internal/domain"
 )
 
@@ -15,13 +16,18 @@ type Repository struct {
 	transactions    map[string]domain.Transaction
 	adjustments     map[string][]domain.Adjustment
 	idempotencyKeys map[string]string
+	// adjustmentOriginals maps an adjustment ID to the original transaction it is filed under.
+	adjustmentOriginals map[string]string
+	// outbox holds the undispatched events in write order.
+	outbox []domain.OutboxEvent
 }
 
 func NewRepository() *Repository {
 	return &Repository{
-		transactions:    make(map[string]domain.Transaction),
-		adjustments:     make(map[string][]domain.Adjustment),
-		idempotencyKeys: make(map[string]string),
+		transactions:        make(map[string]domain.Transaction),
+		adjustments:         make(map[string][]domain.Adjustment),
+		adjustmentOriginals: make(map[string]string),
+		idempotencyKeys:     make(map[string]string),
 	}
 }
 
@@ -38,6 +44,7 @@ func (r *Repository) SaveTransaction(_ context.Context, tx domain.Transaction) e
 	}
 	r.idempotencyKeys[tx.Event.IdempotencyKey] = tx.ID
 	r.transactions[tx.ID] = tx
+	r.outbox = append(r.outbox, domain.TransactionProcessed(tx))
 	return nil
 }
 
@@ -49,9 +56,37 @@ func (r *Repository) SaveAdjustment(_ context.Context, adj domain.Adjustment) er
 	if _, exists := r.idempotencyKeys[adj.Event.IdempotencyKey]; exists {
 		return domain.ErrDuplicateIdempotencyKey
 	}
+	r.saveAdjustment(adj)
+	return nil
+}
+
+// AppendAdjustment runs check and saves the adjustment under the same WLock, so concurrent
+// adjustments for a purchase observe each other's budget consumption.
+func (r *Repository) AppendAdjustment(_ context.Context, adj domain.Adjustment, check ports.AdjustmentCheck) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.idempotencyKeys[adj.Event.IdempotencyKey]; exists {
+		return domain.ErrDuplicateIdempotencyKey
+	}
+	original, ok := r.transactions[adj.OriginalTransactionID]
+	if !ok {
+		return domain.ErrTransactionNotFound
+	}
+	if err := check(original, slices.Clone(r.adjustments[adj.OriginalTransactionID])); err != nil {
+		return err
+	}
+	r.saveAdjustment(adj)
+	return nil
+}
+
+// saveAdjustment stores adj and its outbox event. Callers hold the write lock.
+func (r *Repository) saveAdjustment(adj domain.Adjustment) {
 	r.idempotencyKeys[adj.Event.IdempotencyKey] = adj.ID
 	r.adjustments[adj.OriginalTransactionID] = append(r.adjustments[adj.OriginalTransactionID], adj)
-	return nil
+	if _, ok := r.adjustmentOriginals[adj.ID]; !ok {
+		r.adjustmentOriginals[adj.ID] = adj.OriginalTransactionID
+	}
+	r.outbox = append(r.outbox, domain.AdjustmentApplied(adj))
 }
 
 func (r *Repository) GetTransactionByID(_ context.Context, id string) (domain.Transaction, error) {
@@ -71,6 +106,20 @@ func (r *Repository) GetAdjustmentsByTransactionID(_ context.Context, originalTx
 	return slices.Clone(r.adjustments[originalTxID]), nil
 }
 
+// GetAdjustmentByID returns the first adjustment stored with id.
+func (r *Repository) GetAdjustmentByID(_ context.Context, id string) (domain.Adjustment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if originalTxID, ok := r.adjustmentOriginals[id]; ok {
+		for _, adj := range r.adjustments[originalTxID] {
+			if adj.ID == id {
+				return adj, nil
+			}
+		}
+	}
+	return domain.Adjustment{}, domain.ErrTransactionNotFound
+}
+
 // GetByIdempotencyKey is a read under RLock.
 // NOTE: The atomicity guarantee (check+write) is enforced in SaveTransaction/SaveAdjustment
 // which hold the write lock. Callers in the service layer must treat this as advisory.
@@ -81,8 +130,85 @@ func (r *Repository) GetByIdempotencyKey(_ context.Context, key string) (string,
 	return id, ok
 }
 
-func (r *Repository) ListTransactions(_ context.Context) ([]domain.Transaction, error) {
+// ListTransactions filters under the read lock, then sorts and pages the matches.
+func (r *Repository) ListTransactions(_ context.Context, q ports.TransactionQuery) (ports.TransactionPage, error) {
+	r.mu.RLock()
+	var txs []domain.Transaction
+	for _, tx := range r.transactions {
+		if matchesQuery(q, tx) && (q.After == nil || compareCursors(q, ports.CursorFor(tx), *q.After) > 0) {
+			txs = append(txs, tx)
+		}
+	}
+	r.mu.RUnlock()
+
+	slices.SortFunc(txs, func(a, b domain.Transaction) int {
+		return compareCursors(q, ports.CursorFor(a), ports.CursorFor(b))
+	})
+	page := ports.TransactionPage{Transactions: txs}
+	if q.Limit > 0 && len(txs) > q.Limit {
+		page.Transactions = txs[:q.Limit]
+		next := ports.CursorFor(txs[q.Limit-1])
+		page.Next = &next
+	}
+	if page.Transactions == nil {
+		page.Transactions = []domain.Transaction{}
+	}
+	return page, nil
+}
+
+// Snapshot returns copies of every stored transaction and adjustment.
+// Durable adapters built on top of Repository use it to write compacted snapshots.
+func (r *Repository) Snapshot() ([]domain.Transaction, []domain.Adjustment) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	txs := slices.Collect(maps.Values(r.transactions))
+	var adjs []domain.Adjustment
+	for _, list := range r.adjustments {
+		adjs = append(adjs, list...)
+	}
+	return txs, adjs
+}
+
+// PendingEvents returns a copy of the oldest undispatched events.
+func (r *Repository) PendingEvents(_ context.Context, limit int) ([]domain.OutboxEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	events := r.outbox
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
+	return slices.Clone(events), nil
+}
+
+func (r *Repository) MarkDispatched(_ context.Context, ids ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outbox = slices.DeleteFunc(r.outbox, func(e domain.OutboxEvent) bool {
+		return slices.Contains(ids, e.ID)
+	})
+	return nil
+}
+
+// RestoreOutbox replaces the undispatched events. Durable adapters built on top of Repository
+// call it after restoring a snapshot, whose saves would otherwise re-announce dispatched events.
+func (r *Repository) RestoreOutbox(events []domain.OutboxEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outbox = slices.Clone(events)
+}
+
+// Sizes reports how many entries the repository holds, for metrics.
+func (r *Repository) Sizes() map[string]int {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	return slices.Collect(maps.Values(r.transactions)), nil
+	adjustments := 0
+	for _, list := range r.adjustments {
+		adjustments += len(list)
+	}
+	return map[string]int{
+		"transactions":     len(r.transactions),
+		"adjustments":      adjustments,
+		"idempotency_keys": len(r.idempotencyKeys),
+		"outbox":           len(r.outbox),
+	}
 }
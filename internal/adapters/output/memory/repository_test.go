@@ -3,10 +3,13 @@ package memory
 import (
 	"context"
 	"errors"
+	"maps"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
 	"github.com/jailtonjunior/pomelo/internal/domain"
 )
 
@@ -105,12 +108,12 @@ func TestListTransactions(t *testing.T) {
 	repo.SaveTransaction(ctx, makePurchase("tx1", "idem1", 1000))
 	repo.SaveTransaction(ctx, makePurchase("tx2", "idem2", 2000))
 
-	txs, err := repo.ListTransactions(ctx)
+	page, err := repo.ListTransactions(ctx, ports.TransactionQuery{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if len(txs) != 2 {
-		t.Errorf("expected 2, got %d", len(txs))
+	if len(page.Transactions) != 2 {
+		t.Errorf("expected 2, got %d", len(page.Transactions))
 	}
 }
 
@@ -165,9 +168,27 @@ func TestConcurrentAccess(t *testing.T) {
 	// 100 concurrent readers
 	for range 100 {
 		wg.Go(func() {
-			repo.ListTransactions(ctx)
+			repo.ListTransactions(ctx, ports.TransactionQuery{})
 		})
 	}
 
 	wg.Wait()
 }
+
+func TestRepositorySizes(t *testing.T) {
+	repo := NewRepository()
+	ctx := context.Background()
+	repo.SaveTransaction(ctx, makePurchase("tx1", "idem1", 1000))
+	repo.SaveAdjustment(ctx, makeAdjustment("adj1", "tx1", "idem-adj1", 300))
+	repo.SaveAdjustment(ctx, makeAdjustment("adj2", "tx1", "idem-adj2", 300))
+	repo.MarkDispatched(ctx, "TransactionProcessed:tx1")
+
+	want := map[string]int{"transactions": 1, "adjustments": 2, "idempotency_keys": 3, "outbox": 2}
+	if got := repo.Sizes(); !maps.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestRepositoryContract(t *testing.T) {
+	repotest.Run(t, func(*testing.T) ports.TransactionRepository { return NewRepository() })
+}
@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// DefaultDeliveriesPerSubscription caps how many attempts DeliveryLog keeps per subscription.
+const DefaultDeliveriesPerSubscription = 1000
+
+// SubscriptionStore is a thread-safe in-memory implementation of ports.SubscriptionStore.
+type SubscriptionStore struct {
+	mu    sync.RWMutex
+	subs  map[string]domain.Subscription
+	order []string
+}
+
+func NewSubscriptionStore() *SubscriptionStore {
+	return &SubscriptionStore{subs: make(map[string]domain.Subscription)}
+}
+
+func (s *SubscriptionStore) SaveSubscription(_ context.Context, sub domain.Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.subs[sub.ID]; !exists {
+		s.order = append(s.order, sub.ID)
+	}
+	s.subs[sub.ID] = sub
+	return nil
+}
+
+func (s *SubscriptionStore) GetSubscription(_ context.Context, id string) (domain.Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sub, ok := s.subs[id]
+	if !ok {
+		return domain.Subscription{}, domain.ErrSubscriptionNotFound
+	}
+	return sub, nil
+}
+
+func (s *SubscriptionStore) DeleteSubscription(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[id]; !ok {
+		return domain.ErrSubscriptionNotFound
+	}
+	delete(s.subs, id)
+	s.order = slices.DeleteFunc(s.order, func(v string) bool { return v == id })
+	return nil
+}
+
+func (s *SubscriptionStore) ListSubscriptions(context.Context) ([]domain.Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]domain.Subscription, len(s.order))
+	for i, id := range s.order {
+		out[i] = s.subs[id]
+	}
+	return out, nil
+}
+
+// DeliveryLog is a thread-safe in-memory implementation of ports.DeliveryLog. It keeps the most
+// recent attempts of each subscription, up to a fixed limit.
+type DeliveryLog struct {
+	mu             sync.RWMutex
+	limit          int
+	bySubscription map[string][]domain.Delivery
+	byID           map[string]domain.Delivery
+}
+
+// NewDeliveryLog keeps up to limit attempts per subscription; limit <= 0 keeps them all.
+func NewDeliveryLog(limit int) *DeliveryLog {
+	return &DeliveryLog{
+		limit:          limit,
+		bySubscription: make(map[string][]domain.Delivery),
+		byID:           make(map[string]domain.Delivery),
+	}
+}
+
+func (l *DeliveryLog) RecordDelivery(_ context.Context, d domain.Delivery) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	list := append(l.bySubscription[d.SubscriptionID], d)
+	if l.limit > 0 && len(list) > l.limit {
+		for _, old := range list[:len(list)-l.limit] {
+			delete(l.byID, old.ID)
+		}
+		list = slices.Clone(list[len(list)-l.limit:])
+	}
+	l.bySubscription[d.SubscriptionID] = list
+	l.byID[d.ID] = d
+	return nil
+}
+
+func (l *DeliveryLog) GetDelivery(_ context.Context, id string) (domain.Delivery, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	d, ok := l.byID[id]
+	if !ok {
+		return domain.Delivery{}, domain.ErrDeliveryNotFound
+	}
+	return d, nil
+}
+
+func (l *DeliveryLog) ListDeliveries(_ context.Context, subscriptionID string) ([]domain.Delivery, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if list := l.bySubscription[subscriptionID]; list != nil {
+		return slices.Clone(list), nil
+	}
+	return []domain.Delivery{}, nil
+}
+
+// All returns the attempts kept, each subscription's oldest first, for a durable adapter to
+// rewrite its file with.
+func (l *DeliveryLog) All() []domain.Delivery {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var all []domain.Delivery
+	for _, id := range slices.Sorted(maps.Keys(l.bySubscription)) {
+		all = append(all, l.bySubscription[id]...)
+	}
+	return all
+}
+
+// Sizes reports how many subscriptions are registered, for metrics.
+func (s *SubscriptionStore) Sizes() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return map[string]int{"subscriptions": len(s.subs)}
+}
+
+// Sizes reports how many delivery attempts are logged, for metrics.
+func (l *DeliveryLog) Sizes() map[string]int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return map[string]int{"deliveries": len(l.byID)}
+}
@@ -0,0 +1,16 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+)
+
+func TestSubscriptionStoreContract(t *testing.T) {
+	repotest.RunSubscriptionStore(t, func(*testing.T) ports.SubscriptionStore { return NewSubscriptionStore() })
+}
+
+func TestDeliveryLogContract(t *testing.T) {
+	repotest.RunDeliveryLog(t, func(_ *testing.T, limit int) ports.DeliveryLog { return NewDeliveryLog(limit) })
+}
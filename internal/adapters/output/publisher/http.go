@@ -0,0 +1,156 @@
+// Package publisher delivers outbox events to downstream subscribers over HTTP.
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+	"github.com/jailtonjunior/pomelo/internal/signature"
+)
+
+// DefaultTimeout bounds one delivery attempt when NewHTTPPublisher is given no client.
+const DefaultTimeout = 10 * time.Second
+
+const (
+	HeaderEventID   = "X-Event-Id"
+	HeaderEventType = "X-Event-Type"
+)
+
+// HTTPPublisher implements ports.EventPublisher by POSTing each event as JSON to the subscriber
+// URL. Any 2xx response acknowledges the event; everything else is a failed attempt. Subscribers
+// with a secret receive the X-Signature, X-Timestamp and X-Endpoint headers Pomelo itself
+// verifies on inbound webhooks.
+type HTTPPublisher struct {
+	client *http.Client
+}
+
+func NewHTTPPublisher(client *http.Client) *HTTPPublisher {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &HTTPPublisher{client: client}
+}
+
+func (p *HTTPPublisher) Publish(ctx context.Context, sub domain.Subscription, event domain.OutboxEvent) (int, error) {
+	body, err := json.Marshal(newEventDTO(event))
+	if err != nil {
+		return 0, fmt.Errorf("encode event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, event.ID)
+	req.Header.Set(HeaderEventType, string(event.Type))
+	if sub.Secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(signature.HeaderTimestamp, ts)
+		req.Header.Set(signature.HeaderEndpoint, req.URL.Path)
+		req.Header.Set(signature.HeaderSignature, signature.Sign([]byte(sub.Secret), ts, req.URL.Path, body))
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// eventDTO is the wire format subscribers receive.
+type eventDTO struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	CardID      string          `json:"card_id"`
+	Transaction *transactionDTO `json:"transaction,omitempty"`
+	Adjustment  *transactionDTO `json:"adjustment,omitempty"`
+}
+
+type transactionDTO struct {
+	ID                    string      `json:"id"`
+	Type                  string      `json:"type"`
+	Status                string      `json:"status"`
+	OriginalTransactionID string      `json:"original_transaction_id,omitempty"`
+	UserID                string      `json:"user_id"`
+	CardID                string      `json:"card_id"`
+	Merchant              merchantDTO `json:"merchant"`
+	Amount                amountDTO   `json:"amount"`
+	Country               string      `json:"country"`
+	PointOfSale           string      `json:"point_of_sale"`
+	EventID               string      `json:"event_id"`
+	CreatedAt             time.Time   `json:"created_at"`
+}
+
+type merchantDTO struct {
+	ID   string `json:"id"`
+	MCC  string `json:"mcc"`
+	Name string `json:"name"`
+	City string `json:"city"`
+}
+
+type amountDTO struct {
+	Local       moneyDTO `json:"local"`
+	Transaction moneyDTO `json:"transaction"`
+	Settlement  moneyDTO `json:"settlement"`
+	Original    moneyDTO `json:"original"`
+}
+
+type moneyDTO struct {
+	Total    int64  `json:"total"`
+	Currency string `json:"currency"`
+}
+
+func newEventDTO(e domain.OutboxEvent) eventDTO {
+	dto := eventDTO{ID: e.ID, Type: string(e.Type), OccurredAt: e.OccurredAt, CardID: e.CardID}
+	if tx := e.Transaction; tx != nil {
+		out := newTransactionDTO(*tx)
+		dto.Transaction = &out
+	}
+	if adj := e.Adjustment; adj != nil {
+		out := newTransactionDTO(domain.Transaction{
+			ID: adj.ID, Type: adj.Type, Status: adj.Status, Amount: adj.Amount, Merchant: adj.Merchant, Event: adj.Event,
+			UserID: adj.UserID, CardID: adj.CardID, Country: adj.Country, Currency: adj.Currency, PointOfSale: adj.PointOfSale,
+		})
+		out.OriginalTransactionID = adj.OriginalTransactionID
+		dto.Adjustment = &out
+	}
+	return dto
+}
+
+func newTransactionDTO(tx domain.Transaction) transactionDTO {
+	return transactionDTO{
+		ID:       tx.ID,
+		Type:     string(tx.Type),
+		Status:   string(tx.Status),
+		UserID:   tx.UserID,
+		CardID:   tx.CardID,
+		Merchant: merchantDTO{ID: tx.Merchant.ID, MCC: tx.Merchant.MCC, Name: tx.Merchant.Name, City: tx.Merchant.City},
+		Amount: amountDTO{
+			Local:       newMoneyDTO(tx.Amount.Local),
+			Transaction: newMoneyDTO(tx.Amount.Transaction),
+			Settlement:  newMoneyDTO(tx.Amount.Settlement),
+			Original:    newMoneyDTO(tx.Amount.Original),
+		},
+		Country:     tx.Country,
+		PointOfSale: tx.PointOfSale,
+		EventID:     tx.Event.ID,
+		CreatedAt:   tx.Event.CreatedAt,
+	}
+}
+
+func newMoneyDTO(m domain.Money) moneyDTO {
+	return moneyDTO{Total: m.Amount, Currency: m.Currency}
+}
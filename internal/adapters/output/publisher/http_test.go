@@ -0,0 +1,119 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/application"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+	"github.com/jailtonjunior/pomelo/internal/signature"
+)
+
+func makeAdjustment() domain.Adjustment {
+	m, _ := domain.NewMoney(400, "BRL")
+	event := domain.Event{ID: "evt-adj1", CreatedAt: time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC), IdempotencyKey: "idem-adj1"}
+	adj, _ := domain.NewAdjustment("adj1", domain.TypeRefund, domain.StatusApproved,
+		domain.AmountBreakdown{Local: m, Transaction: m, Settlement: m, Original: m},
+		domain.Merchant{ID: "m1", Name: "Store"}, event, "tx1", "u1", "card1", "BR", "BRL", "POS")
+	return adj
+}
+
+func TestPublishPostsEvent(t *testing.T) {
+	var got eventDTO
+	var header http.Header
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer sink.Close()
+
+	event := domain.AdjustmentApplied(makeAdjustment())
+	status, err := NewHTTPPublisher(nil).Publish(context.Background(), domain.Subscription{URL: sink.URL}, event)
+	if err != nil || status != http.StatusAccepted {
+		t.Fatalf("unexpected result %d (%v)", status, err)
+	}
+	if header.Get(HeaderEventID) != event.ID || header.Get(HeaderEventType) != "AdjustmentApplied" {
+		t.Errorf("unexpected headers %v", header)
+	}
+	if header.Get(signature.HeaderSignature) != "" {
+		t.Error("subscribers without a secret should receive unsigned events")
+	}
+	if got.ID != event.ID || got.CardID != "card1" || got.Transaction != nil || got.Adjustment == nil {
+		t.Fatalf("unexpected body %+v", got)
+	}
+	if adj := got.Adjustment; adj.OriginalTransactionID != "tx1" || adj.Amount.Local.Total != 400 || adj.Status != "APPROVED" {
+		t.Errorf("unexpected adjustment %+v", adj)
+	}
+}
+
+func TestPublishFailsOnNon2xx(t *testing.T) {
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer sink.Close()
+	status, err := NewHTTPPublisher(nil).Publish(context.Background(), domain.Subscription{URL: sink.URL}, domain.AdjustmentApplied(makeAdjustment()))
+	if err == nil || status != http.StatusServiceUnavailable {
+		t.Errorf("expected an error for a 503, got %d (%v)", status, err)
+	}
+}
+
+func TestPublishSignsForSubscriptionSecret(t *testing.T) {
+	var valid bool
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		valid = r.Header.Get(signature.HeaderEndpoint) == "/hooks/pomelo" &&
+			signature.Verify([][]byte{[]byte("s3cret")}, r.Header.Get(signature.HeaderTimestamp),
+				r.Header.Get(signature.HeaderEndpoint), body, r.Header.Get(signature.HeaderSignature))
+	}))
+	defer sink.Close()
+
+	sub := domain.Subscription{ID: "sub1", URL: sink.URL + "/hooks/pomelo", Secret: "s3cret"}
+	if _, err := NewHTTPPublisher(nil).Publish(context.Background(), sub, domain.AdjustmentApplied(makeAdjustment())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !valid {
+		t.Error("expected a signature the subscriber can verify with its secret")
+	}
+}
+
+// TestDispatcherRetriesUntilSinkRecovers runs the dispatcher against a local HTTP stand-in that
+// refuses the first delivery.
+func TestDispatcherRetriesUntilSinkRecovers(t *testing.T) {
+	var calls int
+	var received []string
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		received = append(received, r.Header.Get(HeaderEventID))
+	}))
+	defer sink.Close()
+
+	repo := memory.NewRepository()
+	repo.SaveAdjustment(context.Background(), makeAdjustment())
+	d := application.NewDispatcher(repo, NewHTTPPublisher(sink.Client()), memory.NewDeadLetterStore(), []string{sink.URL},
+		application.WithRetryPolicy(3, time.Millisecond, time.Millisecond))
+
+	ctx := context.Background()
+	if n, _ := d.DispatchOnce(ctx); n != 0 {
+		t.Fatalf("expected the refused event to stay in the outbox, got %d dispatched", n)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if n, err := d.DispatchOnce(ctx); err != nil || n != 1 {
+		t.Fatalf("expected the retry to succeed, got %d (%v)", n, err)
+	}
+	if len(received) != 1 || received[0] != "AdjustmentApplied:adj1" {
+		t.Errorf("unexpected deliveries %v", received)
+	}
+}
@@ -0,0 +1,89 @@
+package repotest
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// AttemptLogFactory returns an empty attempt log. It is called once per subtest.
+type AttemptLogFactory func(t *testing.T) ports.AttemptLog
+
+func makeAttempt(id, txID, outcome string, status int, receivedAt time.Time) domain.WebhookAttempt {
+	a, _ := domain.NewWebhookAttempt(id, txID, "idem-"+txID, receivedAt, outcome, status, []byte(`{"id":"`+txID+`"}`))
+	return a
+}
+
+func attemptIDs(attempts []domain.WebhookAttempt) []string {
+	ids := make([]string, len(attempts))
+	for i, a := range attempts {
+		ids[i] = a.ID
+	}
+	return ids
+}
+
+// RunAttemptLog executes the ports.AttemptLog contract against logs produced by newLog.
+func RunAttemptLog(t *testing.T, newLog AttemptLogFactory) {
+	ctx := context.Background()
+	receivedAt := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("attempts are listed in append order", func(t *testing.T) {
+		log := newLog(t)
+		for _, a := range []domain.WebhookAttempt{
+			makeAttempt("att1", "tx1", domain.OutcomeProcessed, 200, receivedAt),
+			makeAttempt("att2", "adj1", domain.OutcomeParked, 202, receivedAt.Add(time.Second)),
+			makeAttempt("att3", "tx1", domain.OutcomeIdempotent, 200, receivedAt.Add(2*time.Second)),
+			makeAttempt("att4", "tx1", "DUPLICATE_TRANSACTION_ID", 409, receivedAt.Add(3*time.Second)),
+		} {
+			if err := log.AppendAttempt(ctx, a); err != nil {
+				t.Fatalf("append %s: %v", a.ID, err)
+			}
+		}
+
+		got, err := log.ListAttempts(ctx, "tx1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ids := attemptIDs(got); !slices.Equal(ids, []string{"att1", "att3", "att4"}) {
+			t.Errorf("expected the tx1 attempts in order, got %v", ids)
+		}
+		all, err := log.AllAttempts(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ids := attemptIDs(all); !slices.Equal(ids, []string{"att1", "att2", "att3", "att4"}) {
+			t.Errorf("expected every attempt in order, got %v", ids)
+		}
+	})
+
+	t.Run("attempt round trip", func(t *testing.T) {
+		log := newLog(t)
+		want := makeAttempt("att1", "tx1", "EXCEEDS_ORIGINAL_AMOUNT", 409, receivedAt)
+		log.AppendAttempt(ctx, want)
+		got, _ := log.ListAttempts(ctx, "tx1")
+		if len(got) != 1 {
+			t.Fatalf("expected one attempt, got %d", len(got))
+		}
+		a := got[0]
+		if a.ID != want.ID || a.IdempotencyKey != want.IdempotencyKey || a.Outcome != want.Outcome ||
+			a.StatusCode != want.StatusCode || string(a.Payload) != string(want.Payload) || !a.ReceivedAt.Equal(want.ReceivedAt) {
+			t.Errorf("round trip mismatch:\nwant %+v\ngot  %+v", want, a)
+		}
+	})
+
+	t.Run("unknown transaction has no attempts", func(t *testing.T) {
+		log := newLog(t)
+		got, err := log.ListAttempts(ctx, "nope")
+		if err != nil || got == nil || len(got) != 0 {
+			t.Errorf("expected an empty list, got %v (err %v)", got, err)
+		}
+		all, err := log.AllAttempts(ctx)
+		if err != nil || all == nil || len(all) != 0 {
+			t.Errorf("expected an empty log, got %v (err %v)", all, err)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// AuthorizationStoreFactory returns an empty authorization store. It is called once per subtest.
+type AuthorizationStoreFactory func(t *testing.T) ports.AuthorizationStore
+
+var decidedAt = time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+
+// DecideFor returns a decider that authorizes a 1000 BRL purchase with ID id on card1.
+func DecideFor(t *testing.T, id string) ports.AuthorizationDecider {
+	t.Helper()
+	return func(card domain.Card, history []domain.Authorization) (domain.Authorization, error) {
+		req, err := domain.NewAuthorizationRequest(id, domain.TypePurchase, "card1", "u1", domain.Merchant{ID: "m1"}, domain.Money{Amount: 1000, Currency: "BRL"})
+		if err != nil {
+			return domain.Authorization{}, err
+		}
+		return domain.Authorize(req, card, 0, history, decidedAt, time.Hour), nil
+	}
+}
+
+// RunAuthorizationStore executes the ports.AuthorizationStore contract against stores produced
+// by newStore.
+func RunAuthorizationStore(t *testing.T, newStore AuthorizationStoreFactory) {
+	ctx := context.Background()
+
+	t.Run("decisions see the card and its history", func(t *testing.T) {
+		store := newStore(t)
+		auth, err := store.Decide(ctx, "tx1", "card1", DecideFor(t, "tx1"))
+		if err != nil || auth.Reason != domain.ReasonCardNotFound {
+			t.Fatalf("expected CARD_NOT_FOUND for an unknown card, got %+v (%v)", auth, err)
+		}
+
+		card, _ := domain.NewCard("card1", "u1", domain.CardActive, "BRL", 1500, 0, 0)
+		if err := store.SaveCard(ctx, card); err != nil {
+			t.Fatalf("save card: %v", err)
+		}
+		if auth, _ := store.Decide(ctx, "tx2", "card1", DecideFor(t, "tx2")); auth.Decision != domain.StatusApproved {
+			t.Errorf("expected approval, got %s", auth.Reason)
+		}
+		if auth, _ := store.Decide(ctx, "tx3", "card1", DecideFor(t, "tx3")); auth.Reason != domain.ReasonInsufficientBalance {
+			t.Errorf("expected the history to include tx2's hold, got %s", auth.Reason)
+		}
+
+		dup, err := store.Decide(ctx, "tx2", "card1", func(domain.Card, []domain.Authorization) (domain.Authorization, error) {
+			t.Error("decide must not run for a duplicate")
+			return domain.Authorization{}, nil
+		})
+		if !errors.Is(err, domain.ErrDuplicateAuthorization) || dup.Decision != domain.StatusApproved {
+			t.Errorf("expected the recorded approval and ErrDuplicateAuthorization, got %+v (%v)", dup, err)
+		}
+	})
+
+	t.Run("saving a card replaces it", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.GetCard(ctx, "card1"); !errors.Is(err, domain.ErrCardNotFound) {
+			t.Errorf("expected ErrCardNotFound, got %v", err)
+		}
+		card, _ := domain.NewCard("card1", "u1", domain.CardActive, "BRL", 1500, 500, 0)
+		store.SaveCard(ctx, card)
+		card.Status = domain.CardBlocked
+		if err := store.SaveCard(ctx, card); err != nil {
+			t.Fatalf("save card: %v", err)
+		}
+		got, err := store.GetCard(ctx, "card1")
+		if err != nil || got != card {
+			t.Errorf("expected %+v, got %+v (%v)", card, got, err)
+		}
+	})
+
+	t.Run("a failed decision is not recorded", func(t *testing.T) {
+		store := newStore(t)
+		boom := errors.New("boom")
+		_, err := store.Decide(ctx, "tx1", "card1", func(domain.Card, []domain.Authorization) (domain.Authorization, error) {
+			return domain.Authorization{}, boom
+		})
+		if !errors.Is(err, boom) {
+			t.Errorf("expected decide's error, got %v", err)
+		}
+		if _, err := store.GetAuthorization(ctx, "tx1"); !errors.Is(err, domain.ErrAuthorizationNotFound) {
+			t.Errorf("a failed decision must not be recorded, got %v", err)
+		}
+	})
+
+	t.Run("a recorded authorization is not decided again", func(t *testing.T) {
+		store := newStore(t)
+		req, _ := domain.NewAuthorizationRequest("tx1", domain.TypePurchase, "card1", "u1", domain.Merchant{ID: "m1"}, domain.Money{Amount: 1000, Currency: "BRL"})
+		timedOut := domain.Authorization{Request: req, DecidedAt: decidedAt}.TimedOut()
+		if _, err := store.RecordAuthorization(ctx, timedOut); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+		got, err := store.RecordAuthorization(ctx, timedOut)
+		if !errors.Is(err, domain.ErrDuplicateAuthorization) || got.Reason != timedOut.Reason {
+			t.Errorf("expected the recorded timeout and ErrDuplicateAuthorization, got %+v (%v)", got, err)
+		}
+		if _, err := store.Decide(ctx, "tx1", "card1", DecideFor(t, "tx1")); !errors.Is(err, domain.ErrDuplicateAuthorization) {
+			t.Errorf("expected ErrDuplicateAuthorization, got %v", err)
+		}
+	})
+
+	t.Run("match replaces the authorization", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.Match(ctx, "tx1", func(a domain.Authorization) domain.Authorization { return a }); !errors.Is(err, domain.ErrAuthorizationNotFound) {
+			t.Errorf("expected ErrAuthorizationNotFound, got %v", err)
+		}
+
+		store.Decide(ctx, "tx1", "card1", DecideFor(t, "tx1"))
+		if _, err := store.Match(ctx, "tx1", func(a domain.Authorization) domain.Authorization {
+			return a.MatchWebhook(MakePurchase("tx1", "idem1", 1000), decidedAt)
+		}); err != nil {
+			t.Fatalf("match: %v", err)
+		}
+		auth, _ := store.GetAuthorization(ctx, "tx1")
+		if auth.Match == nil {
+			t.Error("expected the match to be stored")
+		}
+	})
+
+	t.Run("a decision gives up when its deadline passes", func(t *testing.T) {
+		store := newStore(t)
+		started, release := make(chan struct{}), make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			store.Decide(ctx, "tx1", "card1", func(card domain.Card, history []domain.Authorization) (domain.Authorization, error) {
+				close(started)
+				<-release
+				return DecideFor(t, "tx1")(card, history)
+			})
+		}()
+		<-started
+
+		short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err := store.Decide(short, "tx2", "card1", func(domain.Card, []domain.Authorization) (domain.Authorization, error) {
+			t.Error("decide must not run after the deadline")
+			return domain.Authorization{}, nil
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+
+		close(release)
+		<-done
+		if _, err := store.GetAuthorization(ctx, "tx1"); err != nil {
+			t.Errorf("expected tx1 recorded once card1 was released, got %v", err)
+		}
+	})
+}
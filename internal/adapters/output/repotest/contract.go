@@ -0,0 +1,396 @@
+// Package repotest holds the behavioural contract every ports.TransactionRepository
+// implementation must satisfy. Adapters run it from their own _test.go files.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// Factory returns an empty repository. It is called once per subtest.
+type Factory func(t *testing.T) ports.TransactionRepository
+
+// MakeAmountBreakdown returns a BRL breakdown with amount in every field.
+func MakeAmountBreakdown(amount int64) domain.AmountBreakdown {
+	m, _ := domain.NewMoney(amount, "BRL")
+	return domain.AmountBreakdown{Local: m, Transaction: m, Settlement: m, Original: m}
+}
+
+func makeEvent(id, idemKey string) domain.Event {
+	return domain.Event{ID: "evt-" + id, CreatedAt: time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC), IdempotencyKey: idemKey}
+}
+
+func makeMerchant() domain.Merchant {
+	return domain.Merchant{ID: "m1", MCC: "5411", Address: "Rua A, 1", Name: "Store", City: "SP", State: "SP"}
+}
+
+// MakePurchase returns an approved BRL purchase by user u1 on card1.
+func MakePurchase(id, idemKey string, amount int64) domain.Transaction {
+	tx, _ := domain.NewPurchase(id, domain.StatusApproved, MakeAmountBreakdown(amount),
+		makeMerchant(), makeEvent(id, idemKey), "u1", "card1", "BR", "BRL", "POS")
+	return tx
+}
+
+// MakeAdjustment returns an approved REFUND of originalID, shaped like MakePurchase.
+func MakeAdjustment(id, originalID, idemKey string, amount int64) domain.Adjustment {
+	adj, _ := domain.NewAdjustment(id, domain.TypeRefund, domain.StatusApproved, MakeAmountBreakdown(amount),
+		makeMerchant(), makeEvent(id, idemKey), originalID, "u1", "card1", "BR", "BRL", "POS")
+	return adj
+}
+
+// Run executes the contract suite against repositories produced by newRepo.
+func Run(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("save and get transaction", func(t *testing.T) {
+		repo := newRepo(t)
+		want := MakePurchase("tx1", "idem1", 1000)
+		if err := repo.SaveTransaction(ctx, want); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, err := repo.GetTransactionByID(ctx, "tx1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !got.Event.CreatedAt.Equal(want.Event.CreatedAt) {
+			t.Errorf("created_at: expected %v, got %v", want.Event.CreatedAt, got.Event.CreatedAt)
+		}
+		got.Event.CreatedAt = want.Event.CreatedAt
+		if got != want {
+			t.Errorf("round trip mismatch:\nwant %+v\ngot  %+v", want, got)
+		}
+	})
+
+	t.Run("exchange rate round trip", func(t *testing.T) {
+		repo := newRepo(t)
+		amount := MakeAmountBreakdown(5235)
+		amount.Transaction = domain.Money{Amount: 1000, Currency: "USD"}
+		tx, err := domain.NewPurchase("tx1", domain.StatusApproved, amount,
+			makeMerchant(), makeEvent("tx1", "idem1"), "u1", "card1", "BR", "BRL", "POS")
+		if err != nil {
+			t.Fatalf("build purchase: %v", err)
+		}
+		if err := repo.SaveTransaction(ctx, tx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got, _ := repo.GetTransactionByID(ctx, "tx1"); got.ExchangeRate != "5.235000" {
+			t.Errorf("expected exchange rate 5.235000, got %q", got.ExchangeRate)
+		}
+	})
+
+	t.Run("save and get other transaction types", func(t *testing.T) {
+		repo := newRepo(t)
+		withdrawal, err := domain.NewTransaction("tx1", domain.TypeWithdrawal, domain.StatusApproved, MakeAmountBreakdown(20000),
+			makeMerchant(), makeEvent("tx1", "idem1"), "u1", "card1", "BR", "BRL", "ATM")
+		if err != nil {
+			t.Fatalf("build withdrawal: %v", err)
+		}
+		if err := repo.SaveTransaction(ctx, withdrawal); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		reversal, _ := domain.NewAdjustment("adj1", domain.TypeReversalWithdrawal, domain.StatusApproved, MakeAmountBreakdown(20000),
+			makeMerchant(), makeEvent("adj1", "idem-adj1"), "tx1", "u1", "card1", "BR", "BRL", "ATM")
+		if err := repo.SaveAdjustment(ctx, reversal); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got, err := repo.GetTransactionByID(ctx, "tx1")
+		if err != nil || got.Type != domain.TypeWithdrawal {
+			t.Errorf("expected WITHDRAWAL, got %q (%v)", got.Type, err)
+		}
+		adjs, err := repo.GetAdjustmentsByTransactionID(ctx, "tx1")
+		if err != nil || len(adjs) != 1 || adjs[0].Type != domain.TypeReversalWithdrawal {
+			t.Errorf("expected one REVERSAL_WITHDRAWAL, got %+v (%v)", adjs, err)
+		}
+	})
+
+	t.Run("get transaction not found", func(t *testing.T) {
+		repo := newRepo(t)
+		_, err := repo.GetTransactionByID(ctx, "nonexistent")
+		if !errors.Is(err, domain.ErrTransactionNotFound) {
+			t.Errorf("expected ErrTransactionNotFound, got %v", err)
+		}
+	})
+
+	t.Run("save and get adjustments in arrival order", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveTransaction(ctx, MakePurchase("tx1", "idem1", 1000))
+		for i := range 3 {
+			id := fmt.Sprintf("adj%d", i)
+			if err := repo.SaveAdjustment(ctx, MakeAdjustment(id, "tx1", "idem-"+id, 100)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		adjs, err := repo.GetAdjustmentsByTransactionID(ctx, "tx1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(adjs) != 3 {
+			t.Fatalf("expected 3 adjustments, got %d", len(adjs))
+		}
+		for i, adj := range adjs {
+			if want := fmt.Sprintf("adj%d", i); adj.ID != want {
+				t.Errorf("position %d: expected %s, got %s", i, want, adj.ID)
+			}
+			if adj.OriginalTransactionID != "tx1" || adj.Amount.Local.Amount != 100 {
+				t.Errorf("unexpected adjustment %+v", adj)
+			}
+		}
+	})
+
+	t.Run("adjustments for unknown purchase are empty", func(t *testing.T) {
+		repo := newRepo(t)
+		adjs, err := repo.GetAdjustmentsByTransactionID(ctx, "nonexistent")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(adjs) != 0 {
+			t.Errorf("expected no adjustments, got %d", len(adjs))
+		}
+	})
+
+	t.Run("get adjustment by id", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveTransaction(ctx, MakePurchase("tx1", "idem1", 1000))
+		repo.SaveAdjustment(ctx, MakeAdjustment("adj1", "tx1", "adj-idem1", 300))
+		repo.SaveAdjustment(ctx, MakeAdjustment("adj2", "tx1", "adj-idem2", 200))
+		adj, err := repo.GetAdjustmentByID(ctx, "adj2")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if adj.OriginalTransactionID != "tx1" || adj.Amount.Local.Amount != 200 {
+			t.Errorf("unexpected adjustment %+v", adj)
+		}
+		if _, err := repo.GetAdjustmentByID(ctx, "tx1"); !errors.Is(err, domain.ErrTransactionNotFound) {
+			t.Errorf("expected ErrTransactionNotFound for a transaction ID, got %v", err)
+		}
+	})
+
+	t.Run("get by idempotency key", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveTransaction(ctx, MakePurchase("tx1", "idem1", 1000))
+		repo.SaveAdjustment(ctx, MakeAdjustment("adj1", "tx1", "adj-idem1", 500))
+
+		if id, ok := repo.GetByIdempotencyKey(ctx, "idem1"); !ok || id != "tx1" {
+			t.Errorf("expected tx1, got %q (exists=%v)", id, ok)
+		}
+		if id, ok := repo.GetByIdempotencyKey(ctx, "adj-idem1"); !ok || id != "adj1" {
+			t.Errorf("expected adj1, got %q (exists=%v)", id, ok)
+		}
+		if _, ok := repo.GetByIdempotencyKey(ctx, "nonexistent"); ok {
+			t.Error("expected idempotency key to not exist")
+		}
+	})
+
+	t.Run("list transactions", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveTransaction(ctx, MakePurchase("tx1", "idem1", 1000))
+		repo.SaveTransaction(ctx, MakePurchase("tx2", "idem2", 2000))
+		page, err := repo.ListTransactions(ctx, ports.TransactionQuery{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(page.Transactions) != 2 || page.Next != nil {
+			t.Errorf("expected 2 on a single page, got %d (next %v)", len(page.Transactions), page.Next)
+		}
+	})
+
+	t.Run("duplicate transaction id", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveTransaction(ctx, MakePurchase("tx1", "idem1", 1000))
+		err := repo.SaveTransaction(ctx, MakePurchase("tx1", "idem2", 1000))
+		if !errors.Is(err, domain.ErrDuplicateTransactionID) {
+			t.Errorf("expected ErrDuplicateTransactionID, got %v", err)
+		}
+		// The rejected write must not leak its idempotency key.
+		if _, ok := repo.GetByIdempotencyKey(ctx, "idem2"); ok {
+			t.Error("idempotency key of rejected transaction was stored")
+		}
+	})
+
+	t.Run("duplicate idempotency key", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveTransaction(ctx, MakePurchase("tx1", "idem1", 1000))
+		if err := repo.SaveTransaction(ctx, MakePurchase("tx2", "idem1", 1000)); !errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+			t.Errorf("transaction: expected ErrDuplicateIdempotencyKey, got %v", err)
+		}
+		if err := repo.SaveAdjustment(ctx, MakeAdjustment("adj1", "tx1", "idem1", 100)); !errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+			t.Errorf("adjustment: expected ErrDuplicateIdempotencyKey, got %v", err)
+		}
+		adjs, _ := repo.GetAdjustmentsByTransactionID(ctx, "tx1")
+		if len(adjs) != 0 {
+			t.Errorf("duplicate adjustment was stored")
+		}
+	})
+
+	t.Run("append adjustment runs check and saves", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveTransaction(ctx, MakePurchase("tx1", "idem1", 1000))
+		repo.SaveAdjustment(ctx, MakeAdjustment("adj1", "tx1", "adj-idem1", 300))
+
+		var seenOriginal domain.Transaction
+		var seenExisting []domain.Adjustment
+		err := repo.AppendAdjustment(ctx, MakeAdjustment("adj2", "tx1", "adj-idem2", 200),
+			func(original domain.Transaction, existing []domain.Adjustment) error {
+				seenOriginal, seenExisting = original, existing
+				return nil
+			})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if seenOriginal.ID != "tx1" || len(seenExisting) != 1 || seenExisting[0].ID != "adj1" {
+			t.Errorf("check saw original %q and %d existing adjustments", seenOriginal.ID, len(seenExisting))
+		}
+		adjs, _ := repo.GetAdjustmentsByTransactionID(ctx, "tx1")
+		if len(adjs) != 2 {
+			t.Errorf("expected 2 adjustments, got %d", len(adjs))
+		}
+	})
+
+	t.Run("append adjustment rejected by check is not saved", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveTransaction(ctx, MakePurchase("tx1", "idem1", 1000))
+		err := repo.AppendAdjustment(ctx, MakeAdjustment("adj1", "tx1", "adj-idem1", 300),
+			func(domain.Transaction, []domain.Adjustment) error { return domain.ErrExceedsOriginalAmount })
+		if !errors.Is(err, domain.ErrExceedsOriginalAmount) {
+			t.Errorf("expected ErrExceedsOriginalAmount, got %v", err)
+		}
+		if _, ok := repo.GetByIdempotencyKey(ctx, "adj-idem1"); ok {
+			t.Error("idempotency key of rejected adjustment was stored")
+		}
+		adjs, _ := repo.GetAdjustmentsByTransactionID(ctx, "tx1")
+		if len(adjs) != 0 {
+			t.Errorf("expected no adjustments, got %d", len(adjs))
+		}
+	})
+
+	t.Run("append adjustment for unknown original", func(t *testing.T) {
+		repo := newRepo(t)
+		err := repo.AppendAdjustment(ctx, MakeAdjustment("adj1", "missing", "adj-idem1", 300),
+			func(domain.Transaction, []domain.Adjustment) error { return nil })
+		if !errors.Is(err, domain.ErrTransactionNotFound) {
+			t.Errorf("expected ErrTransactionNotFound, got %v", err)
+		}
+	})
+
+	t.Run("append adjustment duplicate idempotency key", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveTransaction(ctx, MakePurchase("tx1", "idem1", 1000))
+		repo.SaveAdjustment(ctx, MakeAdjustment("adj1", "tx1", "adj-idem1", 300))
+		err := repo.AppendAdjustment(ctx, MakeAdjustment("adj1", "tx1", "adj-idem1", 300),
+			func(domain.Transaction, []domain.Adjustment) error { return nil })
+		if !errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+			t.Errorf("expected ErrDuplicateIdempotencyKey, got %v", err)
+		}
+	})
+
+	t.Run("concurrent appends never exceed the budget", func(t *testing.T) {
+		repo := newRepo(t)
+		const purchaseAmount, refundAmount, attempts = 10_000, 300, 300
+		repo.SaveTransaction(ctx, MakePurchase("tx1", "idem1", purchaseAmount))
+		budget := func(original domain.Transaction, existing []domain.Adjustment) error {
+			var total int64
+			for _, adj := range existing {
+				total += adj.Amount.Local.Amount
+			}
+			if total+refundAmount > original.Amount.Local.Amount {
+				return domain.ErrExceedsOriginalAmount
+			}
+			return nil
+		}
+		var wg sync.WaitGroup
+		for i := range attempts {
+			wg.Go(func() {
+				id := fmt.Sprintf("adj%d", i)
+				err := repo.AppendAdjustment(ctx, MakeAdjustment(id, "tx1", "idem-"+id, refundAmount), budget)
+				if err != nil && !errors.Is(err, domain.ErrExceedsOriginalAmount) {
+					t.Errorf("unexpected error: %v", err)
+				}
+			})
+		}
+		wg.Wait()
+		adjs, _ := repo.GetAdjustmentsByTransactionID(ctx, "tx1")
+		var total int64
+		for _, adj := range adjs {
+			total += adj.Amount.Local.Amount
+		}
+		if total > purchaseAmount {
+			t.Fatalf("adjusted %d exceeds purchase amount %d", total, purchaseAmount)
+		}
+		if want := purchaseAmount / refundAmount; len(adjs) != want {
+			t.Errorf("expected %d adjustments, got %d", want, len(adjs))
+		}
+	})
+
+	t.Run("returned adjustments are copies", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveTransaction(ctx, MakePurchase("tx1", "idem1", 1000))
+		repo.SaveAdjustment(ctx, MakeAdjustment("adj1", "tx1", "adj-idem1", 500))
+
+		adjs, _ := repo.GetAdjustmentsByTransactionID(ctx, "tx1")
+		if len(adjs) > 0 {
+			adjs[0].ID = "mutated"
+		}
+		adjs2, _ := repo.GetAdjustmentsByTransactionID(ctx, "tx1")
+		if len(adjs2) > 0 && adjs2[0].ID == "mutated" {
+			t.Error("internal slice was mutated by external modification")
+		}
+	})
+
+	t.Run("concurrent saves with the same idempotency key store exactly one", func(t *testing.T) {
+		repo := newRepo(t)
+		var wg sync.WaitGroup
+		var saved, duplicates atomic.Int64
+		for i := range 50 {
+			wg.Go(func() {
+				err := repo.SaveTransaction(ctx, MakePurchase(fmt.Sprintf("tx%d", i), "idem-shared", 1000))
+				switch {
+				case err == nil:
+					saved.Add(1)
+				case errors.Is(err, domain.ErrDuplicateIdempotencyKey):
+					duplicates.Add(1)
+				default:
+					t.Errorf("unexpected error: %v", err)
+				}
+			})
+		}
+		wg.Wait()
+		if saved.Load() != 1 || duplicates.Load() != 49 {
+			t.Errorf("expected 1 saved / 49 duplicates, got %d / %d", saved.Load(), duplicates.Load())
+		}
+	})
+
+	t.Run("concurrent readers and writers", func(t *testing.T) {
+		repo := newRepo(t)
+		var wg sync.WaitGroup
+		for i := range 100 {
+			wg.Go(func() {
+				id := fmt.Sprintf("tx%d", i)
+				if err := repo.SaveTransaction(ctx, MakePurchase(id, "idem-"+id, int64((i+1)*100))); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			})
+		}
+		for range 100 {
+			wg.Go(func() {
+				repo.ListTransactions(ctx, ports.TransactionQuery{})
+			})
+		}
+		wg.Wait()
+		page, _ := repo.ListTransactions(ctx, ports.TransactionQuery{})
+		if len(page.Transactions) != 100 {
+			t.Errorf("expected 100 transactions, got %d", len(page.Transactions))
+		}
+	})
+
+	runQueryContract(t, newRepo)
+	runOutboxContract(t, newRepo)
+}
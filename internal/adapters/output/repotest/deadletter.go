@@ -0,0 +1,60 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// DeadLetterStoreFactory returns an empty dead letter store. It is called once per subtest.
+type DeadLetterStoreFactory func(t *testing.T) ports.DeadLetterStore
+
+// RunDeadLetterStore executes the ports.DeadLetterStore contract against stores produced by
+// newStore.
+func RunDeadLetterStore(t *testing.T, newStore DeadLetterStoreFactory) {
+	ctx := context.Background()
+	failedAt := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("dead letters are listed oldest first", func(t *testing.T) {
+		store := newStore(t)
+		if letters, err := store.ListDeadLetters(ctx); err != nil || letters == nil || len(letters) != 0 {
+			t.Fatalf("expected an empty, non-nil list, got %v (%v)", letters, err)
+		}
+
+		first := domain.DeadLetter{Event: domain.TransactionProcessed(MakePurchase("tx1", "idem1", 1000)),
+			Subscriber: "http://a", Attempts: 3, LastError: "503", FailedAt: failedAt}
+		second := domain.DeadLetter{Event: domain.TransactionProcessed(MakePurchase("tx2", "idem2", 1000)),
+			Subscriber: "http://a", Attempts: 3, LastError: "timeout", FailedAt: failedAt.Add(time.Second)}
+		for _, dl := range []domain.DeadLetter{first, second} {
+			if err := store.AddDeadLetter(ctx, dl); err != nil {
+				t.Fatalf("add %s: %v", dl.Event.ID, err)
+			}
+		}
+
+		letters, err := store.ListDeadLetters(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(letters) != 2 || letters[0].Event.ID != first.Event.ID || letters[1].Event.ID != second.Event.ID {
+			t.Fatalf("expected both letters oldest first, got %+v", letters)
+		}
+		if got := letters[1]; got.Subscriber != second.Subscriber || got.Attempts != 3 || got.LastError != "timeout" ||
+			!got.FailedAt.Equal(second.FailedAt) || got.Event.Transaction == nil || got.Event.Transaction.ID != "tx2" {
+			t.Errorf("expected %+v back, got %+v", second, got)
+		}
+	})
+
+	t.Run("listed dead letters are copies", func(t *testing.T) {
+		store := newStore(t)
+		store.AddDeadLetter(ctx, domain.DeadLetter{Event: domain.TransactionProcessed(MakePurchase("tx1", "idem1", 1000)),
+			Subscriber: "http://a", Attempts: 3, FailedAt: failedAt})
+		letters, _ := store.ListDeadLetters(ctx)
+		letters[0].Subscriber = "mutated"
+		if again, _ := store.ListDeadLetters(ctx); again[0].Subscriber != "http://a" {
+			t.Error("internal slice was mutated by external modification")
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// DisputeStoreFactory returns an empty dispute store. It is called once per subtest.
+type DisputeStoreFactory func(t *testing.T) ports.DisputeStore
+
+func makeDispute(id, txID string, amount int64, openedAt time.Time) domain.Dispute {
+	return domain.Dispute{
+		ID: id, TransactionID: txID, UserID: "user1", CardID: "card1", MerchantID: "m1",
+		Amount: domain.Money{Amount: amount, Currency: "BRL"}, Reason: "item not received",
+		State: domain.DisputeOpened, OpenedAt: openedAt,
+		History: []domain.DisputeTransition{{To: domain.DisputeOpened, At: openedAt, Note: "item not received"}},
+	}
+}
+
+func disputeIDs(disputes []domain.Dispute) []string {
+	ids := make([]string, len(disputes))
+	for i, d := range disputes {
+		ids[i] = d.ID
+	}
+	return ids
+}
+
+// RunDisputeStore executes the ports.DisputeStore contract against stores produced by newStore.
+func RunDisputeStore(t *testing.T, newStore DisputeStoreFactory) {
+	ctx := context.Background()
+	openedAt := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("disputes are listed in opening order", func(t *testing.T) {
+		store := newStore(t)
+		for _, d := range []domain.Dispute{
+			makeDispute("dsp1", "tx1", 300, openedAt),
+			makeDispute("dsp2", "tx2", 100, openedAt.Add(time.Second)),
+			makeDispute("dsp3", "tx1", 200, openedAt.Add(2*time.Second)),
+		} {
+			if err := store.SaveDispute(ctx, d); err != nil {
+				t.Fatalf("save %s: %v", d.ID, err)
+			}
+		}
+		got, err := store.ListDisputes(ctx, "tx1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ids := disputeIDs(got); !slices.Equal(ids, []string{"dsp1", "dsp3"}) {
+			t.Errorf("expected the tx1 disputes in order, got %v", ids)
+		}
+		all, err := store.AllDisputes(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ids := disputeIDs(all); !slices.Equal(ids, []string{"dsp1", "dsp2", "dsp3"}) {
+			t.Errorf("expected every dispute in order, got %v", ids)
+		}
+	})
+
+	t.Run("update round trip", func(t *testing.T) {
+		store := newStore(t)
+		d := makeDispute("dsp1", "tx1", 300, openedAt)
+		store.SaveDispute(ctx, d)
+		won, _ := d.Transition(domain.DisputeWon, "merchant did not respond", openedAt.Add(time.Hour))
+		if err := store.UpdateDispute(ctx, won); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		got, err := store.GetDispute(ctx, "dsp1")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if got.State != domain.DisputeWon || got.Amount != won.Amount || got.CardID != "card1" || got.MerchantID != "m1" ||
+			!got.OpenedAt.Equal(openedAt) || !got.ResolvedAt.Equal(won.ResolvedAt) || len(got.History) != 2 ||
+			got.History[1].Note != "merchant did not respond" || got.History[1].From != domain.DisputeOpened {
+			t.Errorf("round trip mismatch:\nwant %+v\ngot  %+v", won, got)
+		}
+	})
+
+	t.Run("unknown dispute", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.GetDispute(ctx, "nope"); !errors.Is(err, domain.ErrDisputeNotFound) {
+			t.Errorf("expected ErrDisputeNotFound, got %v", err)
+		}
+		if err := store.UpdateDispute(ctx, makeDispute("nope", "tx1", 100, openedAt)); !errors.Is(err, domain.ErrDisputeNotFound) {
+			t.Errorf("expected ErrDisputeNotFound, got %v", err)
+		}
+		got, err := store.ListDisputes(ctx, "tx1")
+		if err != nil || got == nil || len(got) != 0 {
+			t.Errorf("expected an empty list, got %v (err %v)", got, err)
+		}
+	})
+}
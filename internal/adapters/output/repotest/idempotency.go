@@ -0,0 +1,97 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// IdempotencyStoreFactory returns an empty idempotency store. It is called once per subtest.
+type IdempotencyStoreFactory func(t *testing.T) ports.IdempotencyStore
+
+func makeIdempotencyRecord(key string, status int, createdAt time.Time) domain.IdempotencyRecord {
+	rec, _ := domain.NewIdempotencyRecord(key, "hash-"+key, status, []byte(`{"key":"`+key+`"}`), createdAt, time.Hour)
+	return rec
+}
+
+// RunIdempotencyStore executes the ports.IdempotencyStore contract against stores produced by
+// newStore.
+func RunIdempotencyStore(t *testing.T, newStore IdempotencyStoreFactory) {
+	ctx := context.Background()
+	savedAt := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("the first response wins", func(t *testing.T) {
+		store := newStore(t)
+		store.SaveIdempotencyRecord(ctx, makeIdempotencyRecord("idem1", 200, savedAt))
+		store.SaveIdempotencyRecord(ctx, makeIdempotencyRecord("idem1", 409, savedAt.Add(time.Second)))
+
+		rec, ok, err := store.GetIdempotencyRecord(ctx, "idem1", savedAt)
+		if err != nil || !ok || rec.StatusCode != 200 || string(rec.Body) != `{"key":"idem1"}` {
+			t.Errorf("expected the first response, got %+v (ok %v, err %v)", rec, ok, err)
+		}
+		if _, ok, _ := store.GetIdempotencyRecord(ctx, "idem2", savedAt); ok {
+			t.Error("expected no record for an unknown key")
+		}
+	})
+
+	t.Run("an expired key can be recorded again", func(t *testing.T) {
+		store := newStore(t)
+		store.SaveIdempotencyRecord(ctx, makeIdempotencyRecord("idem1", 200, savedAt))
+		if _, ok, _ := store.GetIdempotencyRecord(ctx, "idem1", savedAt.Add(time.Hour)); ok {
+			t.Error("expected idem1 to have expired")
+		}
+		store.SaveIdempotencyRecord(ctx, makeIdempotencyRecord("idem1", 422, savedAt.Add(time.Hour)))
+		if rec, ok, _ := store.GetIdempotencyRecord(ctx, "idem1", savedAt.Add(time.Hour)); !ok || rec.StatusCode != 422 {
+			t.Errorf("expected the new idem1 record, got %+v", rec)
+		}
+	})
+
+	t.Run("a claim holds the key for its payload", func(t *testing.T) {
+		store := newStore(t)
+		claim := makeIdempotencyRecord("idem1", 0, savedAt)
+		held, err := store.ClaimIdempotencyKey(ctx, claim)
+		if err != nil || held.PayloadHash != "hash-idem1" || held.HasResponse() {
+			t.Fatalf("expected the claim stored, got %+v (%v)", held, err)
+		}
+		other := makeIdempotencyRecord("idem1", 0, savedAt.Add(time.Second))
+		other.PayloadHash = "other"
+		if held, _ := store.ClaimIdempotencyKey(ctx, other); held.PayloadHash != "hash-idem1" {
+			t.Errorf("expected the first claim to hold the key, got %+v", held)
+		}
+
+		store.SaveIdempotencyRecord(ctx, makeIdempotencyRecord("idem1", 201, savedAt.Add(2*time.Second)))
+		rec, _, _ := store.GetIdempotencyRecord(ctx, "idem1", savedAt.Add(2*time.Second))
+		if rec.StatusCode != 201 || !rec.ExpiresAt.Equal(claim.ExpiresAt) {
+			t.Errorf("expected the claim filled in with its expiry, got %+v", rec)
+		}
+		if held, _ := store.ClaimIdempotencyKey(ctx, makeIdempotencyRecord("idem1", 0, savedAt.Add(time.Hour))); held.CreatedAt.Equal(savedAt) {
+			t.Errorf("expected an expired claim to be replaced, got %+v", held)
+		}
+	})
+
+	t.Run("a cleared response keeps the payload", func(t *testing.T) {
+		store := newStore(t)
+		store.SaveIdempotencyRecord(ctx, makeIdempotencyRecord("idem1", 409, savedAt))
+		if err := store.ClearIdempotencyResponse(ctx, "idem1", savedAt.Add(time.Minute)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rec, ok, _ := store.GetIdempotencyRecord(ctx, "idem1", savedAt.Add(time.Minute))
+		if !ok || rec.HasResponse() || rec.PayloadHash != "hash-idem1" {
+			t.Fatalf("expected the record kept without its response, got %+v (ok %v)", rec, ok)
+		}
+
+		// The next response for the same payload fills the record in and keeps its expiry; another
+		// payload cannot.
+		other := makeIdempotencyRecord("idem1", 200, savedAt.Add(2*time.Minute))
+		other.PayloadHash = "other"
+		store.SaveIdempotencyRecord(ctx, other)
+		store.SaveIdempotencyRecord(ctx, makeIdempotencyRecord("idem1", 200, savedAt.Add(2*time.Minute)))
+		rec, _, _ = store.GetIdempotencyRecord(ctx, "idem1", savedAt.Add(2*time.Minute))
+		if rec.StatusCode != 200 || rec.PayloadHash != "hash-idem1" || !rec.ExpiresAt.Equal(savedAt.Add(time.Hour)) {
+			t.Errorf("expected the record filled in with its first expiry, got %+v", rec)
+		}
+	})
+}
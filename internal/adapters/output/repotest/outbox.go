@@ -0,0 +1,86 @@
+package repotest
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func eventIDs(events []domain.OutboxEvent) []string {
+	ids := make([]string, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+func runOutboxContract(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("saves append outbox events in write order", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveTransaction(ctx, MakePurchase("tx1", "idem1", 1000))
+		repo.SaveAdjustment(ctx, MakeAdjustment("adj1", "tx1", "adj-idem1", 300))
+		repo.AppendAdjustment(ctx, MakeAdjustment("adj2", "tx1", "adj-idem2", 200),
+			func(domain.Transaction, []domain.Adjustment) error { return nil })
+		repo.SaveTransaction(ctx, MakePurchase("tx2", "idem2", 500))
+
+		events, err := repo.PendingEvents(ctx, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []string{"TransactionProcessed:tx1", "AdjustmentApplied:adj1", "AdjustmentApplied:adj2", "TransactionProcessed:tx2"}
+		if got := eventIDs(events); !slices.Equal(got, want) {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+		if tx := events[0].Transaction; tx == nil || tx.Amount.Local.Amount != 1000 || events[0].CardID != "card1" {
+			t.Errorf("unexpected transaction event %+v", events[0])
+		}
+		if adj := events[1].Adjustment; adj == nil || adj.OriginalTransactionID != "tx1" {
+			t.Errorf("unexpected adjustment event %+v", events[1])
+		}
+		if !events[0].OccurredAt.Equal(makeEvent("tx1", "idem1").CreatedAt) {
+			t.Errorf("occurred_at: expected the webhook time, got %v", events[0].OccurredAt)
+		}
+
+		first, _ := repo.PendingEvents(ctx, 2)
+		if got := eventIDs(first); !slices.Equal(got, want[:2]) {
+			t.Errorf("limit 2: expected %v, got %v", want[:2], got)
+		}
+	})
+
+	t.Run("rejected saves append no outbox event", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveTransaction(ctx, MakePurchase("tx1", "idem1", 1000))
+		repo.SaveTransaction(ctx, MakePurchase("tx1", "idem2", 1000))
+		repo.SaveTransaction(ctx, MakePurchase("tx2", "idem1", 1000))
+		repo.AppendAdjustment(ctx, MakeAdjustment("adj1", "tx1", "adj-idem1", 300),
+			func(domain.Transaction, []domain.Adjustment) error { return domain.ErrExceedsOriginalAmount })
+		repo.AppendAdjustment(ctx, MakeAdjustment("adj2", "missing", "adj-idem2", 300),
+			func(domain.Transaction, []domain.Adjustment) error { return nil })
+
+		events, _ := repo.PendingEvents(ctx, 0)
+		if got := eventIDs(events); !slices.Equal(got, []string{"TransactionProcessed:tx1"}) {
+			t.Errorf("expected only tx1's event, got %v", got)
+		}
+	})
+
+	t.Run("mark dispatched removes events", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveTransaction(ctx, MakePurchase("tx1", "idem1", 1000))
+		repo.SaveTransaction(ctx, MakePurchase("tx2", "idem2", 1000))
+		repo.SaveTransaction(ctx, MakePurchase("tx3", "idem3", 1000))
+		if err := repo.MarkDispatched(ctx, "TransactionProcessed:tx1", "TransactionProcessed:tx3", "unknown"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := repo.MarkDispatched(ctx); err != nil {
+			t.Fatalf("no IDs: unexpected error: %v", err)
+		}
+		events, _ := repo.PendingEvents(ctx, 0)
+		if got := eventIDs(events); !slices.Equal(got, []string{"TransactionProcessed:tx2"}) {
+			t.Errorf("expected only tx2's event, got %v", got)
+		}
+	})
+}
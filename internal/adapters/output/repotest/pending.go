@@ -0,0 +1,132 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// PendingStoreFactory returns an empty parked adjustment store. It is called once per subtest.
+type PendingStoreFactory func(t *testing.T) ports.PendingAdjustmentStore
+
+// RunPendingStore executes the ports.PendingAdjustmentStore contract against stores produced by
+// newStore.
+func RunPendingStore(t *testing.T, newStore PendingStoreFactory) {
+	ctx := context.Background()
+	parkedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	makePending := func(id, originalID string, ttl time.Duration) domain.PendingAdjustment {
+		p, _ := domain.NewPendingAdjustment(MakeAdjustment(id, originalID, "idem-"+id, 100), parkedAt, ttl)
+		return p
+	}
+
+	t.Run("park rejects a duplicate key", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.Park(ctx, makePending("adj1", "tx1", time.Hour)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := store.Park(ctx, makePending("adj1", "tx1", time.Hour)); !errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+			t.Errorf("expected ErrDuplicateIdempotencyKey, got %v", err)
+		}
+		got, ok := store.GetByIdempotencyKey(ctx, "idem-adj1")
+		if !ok {
+			t.Fatal("expected parked adjustment to be found by idempotency key")
+		}
+		if got.Adjustment.ID != "adj1" || !got.ParkedAt.Equal(parkedAt) || !got.ExpiresAt.Equal(parkedAt.Add(time.Hour)) {
+			t.Errorf("unexpected parked adjustment %+v", got)
+		}
+	})
+
+	t.Run("take returns the live entries of one purchase in arrival order", func(t *testing.T) {
+		store := newStore(t)
+		store.Park(ctx, makePending("adj1", "tx1", time.Hour))
+		store.Park(ctx, makePending("adj2", "tx2", time.Hour))
+		store.Park(ctx, makePending("adj3", "tx1", time.Hour))
+		store.Park(ctx, makePending("adj4", "tx1", time.Minute))
+
+		taken, err := store.TakeParked(ctx, "tx1", parkedAt.Add(30*time.Minute))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(taken) != 2 || taken[0].Adjustment.ID != "adj1" || taken[1].Adjustment.ID != "adj3" {
+			t.Fatalf("expected [adj1 adj3] in arrival order, got %v", taken)
+		}
+		if again, _ := store.TakeParked(ctx, "tx1", parkedAt.Add(30*time.Minute)); len(again) != 0 {
+			t.Errorf("expected taken entries to be removed, got %d", len(again))
+		}
+		if _, ok := store.GetByIdempotencyKey(ctx, "idem-adj4"); !ok {
+			t.Error("expected expired entry to stay for review")
+		}
+		if _, ok := store.GetByIdempotencyKey(ctx, "idem-adj2"); !ok {
+			t.Error("expected entry for another purchase to stay parked")
+		}
+	})
+
+	t.Run("review lists rejected and expired entries oldest first", func(t *testing.T) {
+		store := newStore(t)
+		store.Park(ctx, makePending("live", "tx1", time.Hour))
+		store.Park(ctx, makePending("expired", "tx2", time.Minute))
+		rejected := makePending("rejected", "tx3", time.Hour)
+		rejected.ParkedAt = parkedAt.Add(-time.Minute)
+		store.Reject(ctx, rejected, "exceeds")
+
+		review, err := store.ListForReview(ctx, parkedAt.Add(10*time.Minute))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(review) != 2 {
+			t.Fatalf("expected 2 entries for review, got %d", len(review))
+		}
+		if review[0].Adjustment.ID != "rejected" || review[0].Reason != "exceeds" {
+			t.Errorf("expected oldest rejected entry first, got %+v", review[0])
+		}
+		if review[1].Adjustment.ID != "expired" {
+			t.Errorf("expected expired entry second, got %s", review[1].Adjustment.ID)
+		}
+	})
+
+	t.Run("a taken entry can be rejected back", func(t *testing.T) {
+		store := newStore(t)
+		store.Park(ctx, makePending("adj1", "tx1", time.Hour))
+		taken, _ := store.TakeParked(ctx, "tx1", parkedAt)
+		if len(taken) != 1 {
+			t.Fatalf("expected adj1 taken, got %v", taken)
+		}
+		if err := store.Reject(ctx, taken[0], "exceeds"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if again, _ := store.TakeParked(ctx, "tx1", parkedAt); len(again) != 0 {
+			t.Errorf("expected a rejected entry not to be taken again, got %v", again)
+		}
+		review, _ := store.ListForReview(ctx, parkedAt)
+		if len(review) != 1 || review[0].Reason != "exceeds" {
+			t.Errorf("expected adj1 kept for review, got %v", review)
+		}
+	})
+
+	t.Run("concurrent takes hand out each entry once", func(t *testing.T) {
+		store := newStore(t)
+		const n = 100
+		for i := range n {
+			store.Park(ctx, makePending(fmt.Sprintf("adj%d", i), "tx1", time.Hour))
+		}
+		var total atomic.Int64
+		var wg sync.WaitGroup
+		for range 20 {
+			wg.Go(func() {
+				taken, _ := store.TakeParked(ctx, "tx1", parkedAt)
+				total.Add(int64(len(taken)))
+			})
+		}
+		wg.Wait()
+		if total.Load() != n {
+			t.Errorf("expected %d entries handed out exactly once, got %d", n, total.Load())
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package repotest
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+var queryBase = time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+
+// seedQueryData stores 12 purchases with varied owners, merchants, statuses, currencies, amounts
+// and timestamps. Several share a created_at or an amount so the ID tiebreaker is exercised.
+func seedQueryData(t *testing.T, repo ports.TransactionRepository) []domain.Transaction {
+	t.Helper()
+	var txs []domain.Transaction
+	for i := range 12 {
+		id := fmt.Sprintf("q%02d", i)
+		currency := "BRL"
+		if i%4 == 3 {
+			currency = "USD"
+		}
+		m, _ := domain.NewMoney(int64(1000+(i%5)*500), currency)
+		status := domain.StatusApproved
+		if i%3 == 2 {
+			status = domain.StatusRejected
+		}
+		event := domain.Event{ID: "evt-" + id, CreatedAt: queryBase.Add(time.Duration(i/2) * time.Hour), IdempotencyKey: "idem-" + id}
+		merchant := domain.Merchant{ID: fmt.Sprintf("m%d", i%3), MCC: []string{"5411", "5812"}[i%2], Name: "Store"}
+		tx, err := domain.NewPurchase(id, status, domain.AmountBreakdown{Local: m, Transaction: m, Settlement: m, Original: m},
+			merchant, event, fmt.Sprintf("u%d", i%2), fmt.Sprintf("card%d", i%4), "BR", currency, "POS")
+		if err != nil {
+			t.Fatalf("build %s: %v", id, err)
+		}
+		if err := repo.SaveTransaction(context.Background(), tx); err != nil {
+			t.Fatalf("save %s: %v", id, err)
+		}
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+func ids(txs []domain.Transaction) []string {
+	out := make([]string, len(txs))
+	for i, tx := range txs {
+		out[i] = tx.ID
+	}
+	return out
+}
+
+// expectedOrder sorts the seed the way ListTransactions must for the given sort.
+func expectedOrder(txs []domain.Transaction, field ports.TransactionSortField, desc bool) []string {
+	sorted := slices.Clone(txs)
+	slices.SortFunc(sorted, func(a, b domain.Transaction) int {
+		var c int
+		switch field {
+		case ports.SortByAmount:
+			c = cmp.Compare(a.Amount.Local.Amount, b.Amount.Local.Amount)
+		case ports.SortByCreatedAt:
+			c = a.Event.CreatedAt.Compare(b.Event.CreatedAt)
+		}
+		if c == 0 {
+			c = strings.Compare(a.ID, b.ID)
+		}
+		if desc {
+			return -c
+		}
+		return c
+	})
+	return ids(sorted)
+}
+
+// listAll follows cursors until the last page and returns every ID seen.
+func listAll(t *testing.T, repo ports.TransactionRepository, q ports.TransactionQuery) []string {
+	t.Helper()
+	var got []string
+	for range 100 {
+		page, err := repo.ListTransactions(context.Background(), q)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		if q.Limit > 0 && len(page.Transactions) > q.Limit {
+			t.Fatalf("page of %d exceeds limit %d", len(page.Transactions), q.Limit)
+		}
+		got = append(got, ids(page.Transactions)...)
+		if page.Next == nil {
+			return got
+		}
+		q.After = page.Next
+	}
+	t.Fatal("pagination did not terminate")
+	return nil
+}
+
+func runQueryContract(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+	amount := func(v int64) *int64 { return &v }
+
+	t.Run("query filters", func(t *testing.T) {
+		repo := newRepo(t)
+		seed := seedQueryData(t, repo)
+		filter := func(keep func(domain.Transaction) bool) []string {
+			var out []domain.Transaction
+			for _, tx := range seed {
+				if keep(tx) {
+					out = append(out, tx)
+				}
+			}
+			return expectedOrder(out, ports.SortByCreatedAt, false)
+		}
+
+		cases := []struct {
+			name string
+			q    ports.TransactionQuery
+			want []string
+		}{
+			{"user", ports.TransactionQuery{UserID: "u1"}, filter(func(tx domain.Transaction) bool { return tx.UserID == "u1" })},
+			{"card", ports.TransactionQuery{CardID: "card2"}, filter(func(tx domain.Transaction) bool { return tx.CardID == "card2" })},
+			{"merchant", ports.TransactionQuery{MerchantID: "m0"}, filter(func(tx domain.Transaction) bool { return tx.Merchant.ID == "m0" })},
+			{"mcc", ports.TransactionQuery{MerchantMCC: "5812"}, filter(func(tx domain.Transaction) bool { return tx.Merchant.MCC == "5812" })},
+			{"status", ports.TransactionQuery{Status: domain.StatusRejected}, filter(func(tx domain.Transaction) bool { return tx.Status == domain.StatusRejected })},
+			{"type", ports.TransactionQuery{Type: domain.TypePurchase}, ids(seed)},
+			{"type without matches", ports.TransactionQuery{Type: domain.TypeRefund}, nil},
+			{"currency", ports.TransactionQuery{Currency: "USD"}, filter(func(tx domain.Transaction) bool { return tx.Amount.Local.Currency == "USD" })},
+			{"amount range", ports.TransactionQuery{MinAmount: amount(1500), MaxAmount: amount(2000)}, filter(func(tx domain.Transaction) bool {
+				return tx.Amount.Local.Amount >= 1500 && tx.Amount.Local.Amount <= 2000
+			})},
+			{"created range", ports.TransactionQuery{CreatedFrom: queryBase.Add(time.Hour), CreatedTo: queryBase.Add(3 * time.Hour)}, filter(func(tx domain.Transaction) bool {
+				return !tx.Event.CreatedAt.Before(queryBase.Add(time.Hour)) && tx.Event.CreatedAt.Before(queryBase.Add(3*time.Hour))
+			})},
+			{"combined", ports.TransactionQuery{UserID: "u0", Status: domain.StatusApproved, Currency: "BRL"}, filter(func(tx domain.Transaction) bool {
+				return tx.UserID == "u0" && tx.Status == domain.StatusApproved && tx.Amount.Local.Currency == "BRL"
+			})},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				page, err := repo.ListTransactions(ctx, tc.q)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got := ids(page.Transactions); !slices.Equal(got, tc.want) {
+					t.Errorf("expected %v, got %v", tc.want, got)
+				}
+			})
+		}
+	})
+
+	t.Run("query sorting and pagination", func(t *testing.T) {
+		repo := newRepo(t)
+		seed := seedQueryData(t, repo)
+		for _, field := range []ports.TransactionSortField{ports.SortByCreatedAt, ports.SortByAmount, ports.SortByID} {
+			for _, desc := range []bool{false, true} {
+				want := expectedOrder(seed, field, desc)
+				for _, limit := range []int{0, 1, 5, 12, 50} {
+					t.Run(fmt.Sprintf("%s desc=%v limit=%d", field, desc, limit), func(t *testing.T) {
+						got := listAll(t, repo, ports.TransactionQuery{SortBy: field, Descending: desc, Limit: limit})
+						if !slices.Equal(got, want) {
+							t.Errorf("expected %v, got %v", want, got)
+						}
+					})
+				}
+			}
+		}
+	})
+
+	t.Run("query pagination with filters", func(t *testing.T) {
+		repo := newRepo(t)
+		seed := seedQueryData(t, repo)
+		var brl []domain.Transaction
+		for _, tx := range seed {
+			if tx.Amount.Local.Currency == "BRL" {
+				brl = append(brl, tx)
+			}
+		}
+		got := listAll(t, repo, ports.TransactionQuery{Currency: "BRL", SortBy: ports.SortByAmount, Descending: true, Limit: 4})
+		if want := expectedOrder(brl, ports.SortByAmount, true); !slices.Equal(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("query last page has no cursor", func(t *testing.T) {
+		repo := newRepo(t)
+		seedQueryData(t, repo)
+		page, err := repo.ListTransactions(ctx, ports.TransactionQuery{Limit: 12})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(page.Transactions) != 12 || page.Next != nil {
+			t.Errorf("expected a single full page without cursor, got %d (next %v)", len(page.Transactions), page.Next)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// SubscriptionStoreFactory returns an empty subscription store. It is called once per subtest.
+type SubscriptionStoreFactory func(t *testing.T) ports.SubscriptionStore
+
+// DeliveryLogFactory returns an empty delivery log keeping up to limit attempts per
+// subscription. It is called once per subtest.
+type DeliveryLogFactory func(t *testing.T, limit int) ports.DeliveryLog
+
+// RunSubscriptionStore executes the ports.SubscriptionStore contract against stores produced by
+// newStore.
+func RunSubscriptionStore(t *testing.T, newStore SubscriptionStoreFactory) {
+	ctx := context.Background()
+	createdAt := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("subscriptions are listed in creation order", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.GetSubscription(ctx, "sub1"); !errors.Is(err, domain.ErrSubscriptionNotFound) {
+			t.Errorf("expected ErrSubscriptionNotFound, got %v", err)
+		}
+		for _, id := range []string{"sub1", "sub2", "sub3"} {
+			if err := store.SaveSubscription(ctx, domain.Subscription{ID: id, URL: "http://" + id, CreatedAt: createdAt}); err != nil {
+				t.Fatalf("save %s: %v", id, err)
+			}
+		}
+		if err := store.DeleteSubscription(ctx, "sub2"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := store.DeleteSubscription(ctx, "sub2"); !errors.Is(err, domain.ErrSubscriptionNotFound) {
+			t.Errorf("expected ErrSubscriptionNotFound deleting twice, got %v", err)
+		}
+		subs, err := store.ListSubscriptions(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(subs) != 2 || subs[0].ID != "sub1" || subs[1].ID != "sub3" {
+			t.Errorf("expected sub1 and sub3 in creation order, got %+v", subs)
+		}
+	})
+
+	t.Run("a subscription is stored with its filters and secret", func(t *testing.T) {
+		store := newStore(t)
+		sub := domain.Subscription{
+			ID: "sub1", URL: "https://partner.example/events",
+			Types:    []domain.TransactionType{domain.TypePurchase, domain.TypeRefund},
+			Statuses: []domain.TransactionStatus{domain.StatusApproved},
+			Secret:   "s3cret", CreatedAt: createdAt,
+		}
+		store.SaveSubscription(ctx, sub)
+		got, err := store.GetSubscription(ctx, "sub1")
+		if err != nil || got.URL != sub.URL || got.Secret != sub.Secret || !got.CreatedAt.Equal(createdAt) ||
+			!slices.Equal(got.Types, sub.Types) || !slices.Equal(got.Statuses, sub.Statuses) {
+			t.Errorf("expected %+v back, got %+v (%v)", sub, got, err)
+		}
+	})
+
+	t.Run("saving an existing subscription keeps its place", func(t *testing.T) {
+		store := newStore(t)
+		store.SaveSubscription(ctx, domain.Subscription{ID: "sub1", URL: "http://a", CreatedAt: createdAt})
+		store.SaveSubscription(ctx, domain.Subscription{ID: "sub2", URL: "http://b", CreatedAt: createdAt})
+		store.SaveSubscription(ctx, domain.Subscription{ID: "sub1", URL: "http://c", CreatedAt: createdAt})
+		subs, _ := store.ListSubscriptions(ctx)
+		if len(subs) != 2 || subs[0].ID != "sub1" || subs[0].URL != "http://c" {
+			t.Errorf("expected sub1 replaced in place, got %+v", subs)
+		}
+	})
+}
+
+// RunDeliveryLog executes the ports.DeliveryLog contract against logs produced by newLog.
+func RunDeliveryLog(t *testing.T, newLog DeliveryLogFactory) {
+	ctx := context.Background()
+	attemptedAt := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("the most recent attempts are kept", func(t *testing.T) {
+		log := newLog(t, 2)
+		for i := range 3 {
+			d := domain.Delivery{ID: fmt.Sprintf("d%d", i), SubscriptionID: "sub1", Attempt: i + 1, AttemptedAt: attemptedAt}
+			if err := log.RecordDelivery(ctx, d); err != nil {
+				t.Fatalf("record %s: %v", d.ID, err)
+			}
+		}
+		log.RecordDelivery(ctx, domain.Delivery{ID: "other", SubscriptionID: "sub2", AttemptedAt: attemptedAt})
+
+		list, err := log.ListDeliveries(ctx, "sub1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(list) != 2 || list[0].ID != "d1" || list[1].ID != "d2" {
+			t.Errorf("expected d1 and d2, got %+v", list)
+		}
+		if _, err := log.GetDelivery(ctx, "d0"); !errors.Is(err, domain.ErrDeliveryNotFound) {
+			t.Errorf("expected the evicted attempt to be gone, got %v", err)
+		}
+		if d, err := log.GetDelivery(ctx, "other"); err != nil || d.SubscriptionID != "sub2" {
+			t.Errorf("unexpected delivery %+v (%v)", d, err)
+		}
+		if list, _ := log.ListDeliveries(ctx, "unknown"); list == nil || len(list) != 0 {
+			t.Errorf("expected an empty, non-nil list, got %v", list)
+		}
+	})
+
+	t.Run("an attempt is stored with its outcome", func(t *testing.T) {
+		log := newLog(t, 0)
+		d := domain.Delivery{
+			ID: "d1", SubscriptionID: "sub1", Event: domain.TransactionProcessed(MakePurchase("tx1", "idem1", 1000)),
+			Attempt: 2, Manual: true, ResponseStatus: 503, Error: "subscriber responded 503",
+			AttemptedAt: attemptedAt, Duration: 150 * time.Millisecond,
+		}
+		log.RecordDelivery(ctx, d)
+		got, err := log.GetDelivery(ctx, "d1")
+		if err != nil || got.Event.ID != d.Event.ID || got.Attempt != 2 || !got.Manual || got.ResponseStatus != 503 ||
+			got.Error != d.Error || !got.AttemptedAt.Equal(attemptedAt) || got.Duration != d.Duration {
+			t.Errorf("expected %+v back, got %+v (%v)", d, got, err)
+		}
+	})
+}
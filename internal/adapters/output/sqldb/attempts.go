@@ -0,0 +1,56 @@
+package sqldb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+const attemptColumns = "id, transaction_id, idempotency_key, received_at, outcome, status_code, payload"
+
+// AppendAttempt inserts a into the webhook_attempts table, making Repository a ports.AttemptLog
+// that shares the database with the state it records.
+func (r *Repository) AppendAttempt(ctx context.Context, a domain.WebhookAttempt) error {
+	payload := a.Payload
+	if payload == nil {
+		payload = []byte{}
+	}
+	_, err := r.db.ExecContext(ctx,
+		r.dialect.rebind(`INSERT INTO webhook_attempts (`+attemptColumns+`) VALUES (`+placeholders(7)+`)`),
+		a.ID, a.TransactionID, a.IdempotencyKey, a.ReceivedAt.UnixNano(), a.Outcome, a.StatusCode, payload)
+	if err != nil {
+		return fmt.Errorf("insert webhook attempt: %w", err)
+	}
+	return nil
+}
+
+// ListAttempts returns the attempts for transactionID in arrival order.
+func (r *Repository) ListAttempts(ctx context.Context, transactionID string) ([]domain.WebhookAttempt, error) {
+	return r.queryAttempts(ctx, `SELECT `+attemptColumns+` FROM webhook_attempts WHERE transaction_id = ? ORDER BY seq`, transactionID)
+}
+
+// AllAttempts returns every attempt in arrival order.
+func (r *Repository) AllAttempts(ctx context.Context) ([]domain.WebhookAttempt, error) {
+	return r.queryAttempts(ctx, `SELECT `+attemptColumns+` FROM webhook_attempts ORDER BY seq`)
+}
+
+func (r *Repository) queryAttempts(ctx context.Context, query string, args ...any) ([]domain.WebhookAttempt, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query webhook attempts: %w", err)
+	}
+	defer rows.Close()
+	attempts := []domain.WebhookAttempt{}
+	for rows.Next() {
+		var a domain.WebhookAttempt
+		var receivedAt int64
+		if err := rows.Scan(&a.ID, &a.TransactionID, &a.IdempotencyKey, &receivedAt, &a.Outcome, &a.StatusCode, &a.Payload); err != nil {
+			return nil, err
+		}
+		a.ReceivedAt = time.Unix(0, receivedAt).UTC()
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
@@ -0,0 +1,205 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+const cardColumns = "id, user_id, status, currency, balance, transaction_limit, daily_limit"
+
+var _ ports.AuthorizationStore = (*Repository)(nil)
+
+// SaveCard inserts or replaces card, making Repository a ports.AuthorizationStore.
+func (r *Repository) SaveCard(ctx context.Context, card domain.Card) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(
+		`INSERT INTO cards (`+cardColumns+`) VALUES (`+placeholders(7)+`)
+		ON CONFLICT (id) DO UPDATE SET user_id = excluded.user_id, status = excluded.status,
+			currency = excluded.currency, balance = excluded.balance,
+			transaction_limit = excluded.transaction_limit, daily_limit = excluded.daily_limit`),
+		card.ID, card.UserID, string(card.Status), card.Currency, card.Balance, card.TransactionLimit, card.DailyLimit)
+	if err != nil {
+		return fmt.Errorf("save card: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetCard(ctx context.Context, id string) (domain.Card, error) {
+	return r.queryCard(ctx, r.db, `SELECT `+cardColumns+` FROM cards WHERE id = ?`, id)
+}
+
+// Decide runs decide inside a database transaction that locks the card row (SELECT ... FOR
+// UPDATE on Postgres; SQLite serializes writers), so instances sharing the database never decide
+// for the same card at once. An unknown card has no row to lock, but its decision holds nothing.
+// decide must not query the database: on SQLite the transaction owns the only connection.
+func (r *Repository) Decide(ctx context.Context, id, cardID string, decide ports.AuthorizationDecider) (domain.Authorization, error) {
+	var auth domain.Authorization
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		card, err := r.queryCard(ctx, tx, `SELECT `+cardColumns+` FROM cards WHERE id = ?`+r.dialect.lockForUpdate, cardID)
+		if err != nil && !errors.Is(err, domain.ErrCardNotFound) {
+			return err
+		}
+		existing, err := r.queryAuthorizations(ctx, tx, `SELECT decision FROM authorizations WHERE id = ?`, id)
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			auth = existing[0]
+			return domain.ErrDuplicateAuthorization
+		}
+		history, err := r.queryAuthorizations(ctx, tx, `SELECT decision FROM authorizations WHERE card_id = ? ORDER BY seq`, cardID)
+		if err != nil {
+			return err
+		}
+		// The wait for the lock may have outlived the caller's deadline.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		decided, err := decide(card, history)
+		if err != nil {
+			return err
+		}
+		if decided.Request.ID != id || decided.Request.CardID != cardID {
+			return fmt.Errorf("%w: decided %s for card %s, want %s for card %s",
+				domain.ErrInvalidInput, decided.Request.ID, decided.Request.CardID, id, cardID)
+		}
+		auth, err = r.insertAuthorization(ctx, tx, decided)
+		return err
+	})
+	if errors.Is(err, domain.ErrDuplicateAuthorization) {
+		return auth, err
+	}
+	if err != nil {
+		return domain.Authorization{}, err
+	}
+	return auth, nil
+}
+
+func (r *Repository) RecordAuthorization(ctx context.Context, auth domain.Authorization) (domain.Authorization, error) {
+	var recorded domain.Authorization
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		recorded, err = r.insertAuthorization(ctx, tx, auth)
+		return err
+	})
+	if err != nil && !errors.Is(err, domain.ErrDuplicateAuthorization) {
+		return domain.Authorization{}, err
+	}
+	return recorded, err
+}
+
+func (r *Repository) GetAuthorization(ctx context.Context, id string) (domain.Authorization, error) {
+	auths, err := r.queryAuthorizations(ctx, r.db, `SELECT decision FROM authorizations WHERE id = ?`, id)
+	if err != nil {
+		return domain.Authorization{}, err
+	}
+	if len(auths) == 0 {
+		return domain.Authorization{}, domain.ErrAuthorizationNotFound
+	}
+	return auths[0], nil
+}
+
+// Match rewrites authorization id with match(current) under a row lock.
+func (r *Repository) Match(ctx context.Context, id string, match func(domain.Authorization) domain.Authorization) (domain.Authorization, error) {
+	var auth domain.Authorization
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		auths, err := r.queryAuthorizations(ctx, tx, `SELECT decision FROM authorizations WHERE id = ?`+r.dialect.lockForUpdate, id)
+		if err != nil {
+			return err
+		}
+		if len(auths) == 0 {
+			return domain.ErrAuthorizationNotFound
+		}
+		auth = match(auths[0])
+		decision, err := json.Marshal(auth)
+		if err != nil {
+			return fmt.Errorf("encode authorization: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(`UPDATE authorizations SET decision = ? WHERE id = ?`), string(decision), id)
+		if err != nil {
+			return fmt.Errorf("update authorization: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Authorization{}, err
+	}
+	return auth, nil
+}
+
+// insertAuthorization stores auth unless its ID was decided already, in which case it returns
+// the recorded authorization and domain.ErrDuplicateAuthorization.
+func (r *Repository) insertAuthorization(ctx context.Context, tx *sql.Tx, auth domain.Authorization) (domain.Authorization, error) {
+	decision, err := json.Marshal(auth)
+	if err != nil {
+		return domain.Authorization{}, fmt.Errorf("encode authorization: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, r.dialect.rebind(
+		`INSERT INTO authorizations (id, card_id, decision) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`),
+		auth.Request.ID, auth.Request.CardID, string(decision))
+	if err != nil {
+		return domain.Authorization{}, fmt.Errorf("insert authorization: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return domain.Authorization{}, err
+	}
+	if n == 1 {
+		return auth, nil
+	}
+	existing, err := r.queryAuthorizations(ctx, tx, `SELECT decision FROM authorizations WHERE id = ?`, auth.Request.ID)
+	if err != nil {
+		return domain.Authorization{}, err
+	}
+	if len(existing) == 0 {
+		return domain.Authorization{}, fmt.Errorf("authorization %s conflicted but was not found", auth.Request.ID)
+	}
+	return existing[0], domain.ErrDuplicateAuthorization
+}
+
+func (r *Repository) queryCard(ctx context.Context, q queryer, query string, args ...any) (domain.Card, error) {
+	rows, err := q.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return domain.Card{}, fmt.Errorf("query card: %w", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return domain.Card{}, err
+		}
+		return domain.Card{}, domain.ErrCardNotFound
+	}
+	var c domain.Card
+	var status string
+	if err := rows.Scan(&c.ID, &c.UserID, &status, &c.Currency, &c.Balance, &c.TransactionLimit, &c.DailyLimit); err != nil {
+		return domain.Card{}, err
+	}
+	c.Status = domain.CardStatus(status)
+	return c, rows.Close()
+}
+
+func (r *Repository) queryAuthorizations(ctx context.Context, q queryer, query string, args ...any) ([]domain.Authorization, error) {
+	rows, err := q.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query authorizations: %w", err)
+	}
+	defer rows.Close()
+	var auths []domain.Authorization
+	for rows.Next() {
+		var decision string
+		if err := rows.Scan(&decision); err != nil {
+			return nil, err
+		}
+		var a domain.Authorization
+		if err := json.Unmarshal([]byte(decision), &a); err != nil {
+			return nil, fmt.Errorf("decode authorization: %w", err)
+		}
+		auths = append(auths, a)
+	}
+	return auths, rows.Err()
+}
@@ -0,0 +1,53 @@
+package sqldb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+var _ ports.DeadLetterStore = (*Repository)(nil)
+
+// AddDeadLetter inserts dl into the dead_letters table, making Repository a ports.DeadLetterStore
+// kept in the same database as the outbox it drains.
+func (r *Repository) AddDeadLetter(ctx context.Context, dl domain.DeadLetter) error {
+	event, err := json.Marshal(dl.Event)
+	if err != nil {
+		return fmt.Errorf("encode dead letter event: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind(
+		`INSERT INTO dead_letters (event_id, subscriber, attempts, last_error, failed_at, event) VALUES (?, ?, ?, ?, ?, ?)`),
+		dl.Event.ID, dl.Subscriber, dl.Attempts, dl.LastError, dl.FailedAt.UnixNano(), string(event))
+	if err != nil {
+		return fmt.Errorf("insert dead letter: %w", err)
+	}
+	return nil
+}
+
+// ListDeadLetters returns every dead letter in failure order.
+func (r *Repository) ListDeadLetters(ctx context.Context) ([]domain.DeadLetter, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT subscriber, attempts, last_error, failed_at, event FROM dead_letters ORDER BY seq`)
+	if err != nil {
+		return nil, fmt.Errorf("query dead letters: %w", err)
+	}
+	defer rows.Close()
+	letters := []domain.DeadLetter{}
+	for rows.Next() {
+		var dl domain.DeadLetter
+		var failedAt int64
+		var event string
+		if err := rows.Scan(&dl.Subscriber, &dl.Attempts, &dl.LastError, &failedAt, &event); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(event), &dl.Event); err != nil {
+			return nil, fmt.Errorf("decode dead letter event: %w", err)
+		}
+		dl.FailedAt = time.Unix(0, failedAt).UTC()
+		letters = append(letters, dl)
+	}
+	return letters, rows.Err()
+}
@@ -0,0 +1,86 @@
+package sqldb
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect captures the handful of SQL differences between the supported databases.
+// Queries are written with "?" placeholders and rebound per dialect.
+type Dialect struct {
+	// Name is informational and used in error messages.
+	Name string
+	// DriverName is the database/sql driver the dialect is normally opened with.
+	DriverName string
+	// numbered is true for "$1, $2" placeholders (Postgres), false for "?" (SQLite).
+	numbered bool
+	// serialPK is the column definition for an auto-incrementing primary key.
+	serialPK string
+	// bytes is the column type for raw binary values.
+	bytes string
+	// lockForUpdate is appended to SELECTs that must lock the rows they read.
+	lockForUpdate string
+	// byteOrder is appended to text columns compared or sorted the way Go compares
+	// strings, so keyset cursors do not depend on the database collation.
+	byteOrder string
+}
+
+var (
+	// SQLite targets the pure-Go modernc.org/sqlite driver. Writers are serialized by the
+	// database itself, so no row-level lock clause is needed; open the DSN with
+	// "_txlock=immediate" so transactions take the write lock up front. Its default
+	// BINARY collation already orders text bytewise.
+	SQLite = Dialect{
+		Name:       "sqlite",
+		DriverName: "sqlite",
+		serialPK:   "INTEGER PRIMARY KEY AUTOINCREMENT",
+		bytes:      "BLOB",
+	}
+	// Postgres targets the pgx stdlib driver.
+	Postgres = Dialect{
+		Name:          "postgres",
+		DriverName:    "pgx",
+		numbered:      true,
+		serialPK:      "BIGSERIAL PRIMARY KEY",
+		bytes:         "BYTEA",
+		lockForUpdate: " FOR UPDATE",
+		byteOrder:     ` COLLATE "C"`,
+	}
+)
+
+// DialectByName resolves "sqlite" or "postgres".
+func DialectByName(name string) (Dialect, bool) {
+	switch name {
+	case SQLite.Name:
+		return SQLite, true
+	case Postgres.Name:
+		return Postgres, true
+	default:
+		return Dialect{}, false
+	}
+}
+
+// rebind rewrites "?" placeholders into the dialect's placeholder style.
+func (d Dialect) rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// render expands the {{serial}}, {{bytes}} and {{bytewise}} macros used by migrations.
+func (d Dialect) render(stmt string) string {
+	return strings.NewReplacer("{{serial}}", d.serialPK, "{{bytes}}", d.bytes, "{{bytewise}}", d.byteOrder).Replace(stmt)
+}
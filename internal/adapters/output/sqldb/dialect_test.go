@@ -0,0 +1,69 @@
+package sqldb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func TestRebind(t *testing.T) {
+	query := `INSERT INTO t (a, b, c) VALUES (?, ?, ?)`
+	if got := SQLite.rebind(query); got != query {
+		t.Errorf("sqlite should keep ? placeholders, got %s", got)
+	}
+	if got, want := Postgres.rebind(query), `INSERT INTO t (a, b, c) VALUES ($1, $2, $3)`; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	if got := placeholders(3); got != "?, ?, ?" {
+		t.Errorf("expected '?, ?, ?', got %q", got)
+	}
+	if got := strings.Count(placeholders(len(entityColumns)), "?"); got != len(entityColumns) {
+		t.Errorf("expected %d placeholders, got %d", len(entityColumns), got)
+	}
+}
+
+func TestMigrationsRenderPerDialect(t *testing.T) {
+	for _, d := range []Dialect{SQLite, Postgres} {
+		for _, m := range migrations {
+			for _, stmt := range m.stmts {
+				if strings.Contains(d.render(stmt), "{{") {
+					t.Errorf("%s: unrendered macro in migration %d", d.Name, m.version)
+				}
+			}
+		}
+	}
+}
+
+func TestMigrationVersionsIncrease(t *testing.T) {
+	last := 0
+	for _, m := range migrations {
+		if m.version <= last {
+			t.Errorf("migration %d is not greater than %d", m.version, last)
+		}
+		last = m.version
+	}
+}
+
+func TestArgsMatchColumns(t *testing.T) {
+	columns := func(list string) int { return strings.Count(list, ",") + 1 }
+	if got, want := len(transactionArgs(makeTestTransaction())), columns(transactionColumns); got != want {
+		t.Errorf("transactionArgs returns %d values for %d columns", got, want)
+	}
+	// insertAdjustment appends the original transaction ID.
+	if got, want := len(adjustmentArgs(domain.Adjustment{}))+1, columns(adjustmentColumns); got != want {
+		t.Errorf("adjustment inserts bind %d values for %d columns", got, want)
+	}
+}
+
+func TestDialectByName(t *testing.T) {
+	if d, ok := DialectByName("postgres"); !ok || d.DriverName != "pgx" {
+		t.Errorf("unexpected postgres dialect %+v", d)
+	}
+	if _, ok := DialectByName("oracle"); ok {
+		t.Error("expected unknown dialect")
+	}
+}
@@ -0,0 +1,117 @@
+package sqldb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+const disputeColumns = "id, transaction_id, user_id, card_id, merchant_id, amount, currency, reason, state, opened_at, resolved_at, history"
+
+// SaveDispute inserts d into the disputes table, making Repository a ports.DisputeStore kept in
+// the same database as the purchases it disputes.
+func (r *Repository) SaveDispute(ctx context.Context, d domain.Dispute) error {
+	args, err := disputeArgs(d)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx,
+		r.dialect.rebind(`INSERT INTO disputes (`+disputeColumns+`) VALUES (`+placeholders(12)+`)`), args...)
+	if err != nil {
+		return fmt.Errorf("insert dispute: %w", err)
+	}
+	return nil
+}
+
+// UpdateDispute rewrites the state, resolution time and history of d.
+func (r *Repository) UpdateDispute(ctx context.Context, d domain.Dispute) error {
+	history, err := json.Marshal(d.History)
+	if err != nil {
+		return fmt.Errorf("encode dispute history: %w", err)
+	}
+	res, err := r.db.ExecContext(ctx,
+		r.dialect.rebind(`UPDATE disputes SET state = ?, resolved_at = ?, history = ? WHERE id = ?`),
+		string(d.State), optionalUnixNano(d.ResolvedAt), string(history), d.ID)
+	if err != nil {
+		return fmt.Errorf("update dispute: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrDisputeNotFound
+	}
+	return nil
+}
+
+func (r *Repository) GetDispute(ctx context.Context, id string) (domain.Dispute, error) {
+	disputes, err := r.queryDisputes(ctx, `SELECT `+disputeColumns+` FROM disputes WHERE id = ?`, id)
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+	if len(disputes) == 0 {
+		return domain.Dispute{}, domain.ErrDisputeNotFound
+	}
+	return disputes[0], nil
+}
+
+// ListDisputes returns the disputes of transactionID in opening order.
+func (r *Repository) ListDisputes(ctx context.Context, transactionID string) ([]domain.Dispute, error) {
+	return r.queryDisputes(ctx, `SELECT `+disputeColumns+` FROM disputes WHERE transaction_id = ? ORDER BY seq`, transactionID)
+}
+
+// AllDisputes returns every dispute in opening order.
+func (r *Repository) AllDisputes(ctx context.Context) ([]domain.Dispute, error) {
+	return r.queryDisputes(ctx, `SELECT `+disputeColumns+` FROM disputes ORDER BY seq`)
+}
+
+func (r *Repository) queryDisputes(ctx context.Context, query string, args ...any) ([]domain.Dispute, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query disputes: %w", err)
+	}
+	defer rows.Close()
+	disputes := []domain.Dispute{}
+	for rows.Next() {
+		var d domain.Dispute
+		var state, history string
+		var openedAt, resolvedAt int64
+		if err := rows.Scan(&d.ID, &d.TransactionID, &d.UserID, &d.CardID, &d.MerchantID, &d.Amount.Amount, &d.Amount.Currency,
+			&d.Reason, &state, &openedAt, &resolvedAt, &history); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(history), &d.History); err != nil {
+			return nil, fmt.Errorf("decode dispute history: %w", err)
+		}
+		d.State = domain.DisputeState(state)
+		d.OpenedAt = time.Unix(0, openedAt).UTC()
+		if resolvedAt != 0 {
+			d.ResolvedAt = time.Unix(0, resolvedAt).UTC()
+		}
+		disputes = append(disputes, d)
+	}
+	return disputes, rows.Err()
+}
+
+func disputeArgs(d domain.Dispute) ([]any, error) {
+	history, err := json.Marshal(d.History)
+	if err != nil {
+		return nil, fmt.Errorf("encode dispute history: %w", err)
+	}
+	return []any{
+		d.ID, d.TransactionID, d.UserID, d.CardID, d.MerchantID, d.Amount.Amount, d.Amount.Currency,
+		d.Reason, string(d.State), d.OpenedAt.UnixNano(), optionalUnixNano(d.ResolvedAt), string(history),
+	}, nil
+}
+
+// optionalUnixNano stores the zero time as 0, which UnixNano does not.
+func optionalUnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
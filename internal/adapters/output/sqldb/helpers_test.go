@@ -0,0 +1,15 @@
+package sqldb
+
+import (
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func makeTestTransaction() domain.Transaction {
+	m, _ := domain.NewMoney(1000, "BRL")
+	event := domain.Event{ID: "evt1", CreatedAt: time.Now(), IdempotencyKey: "idem1"}
+	tx, _ := domain.NewPurchase("tx1", domain.StatusApproved, domain.AmountBreakdown{Local: m, Transaction: m, Settlement: m, Original: m},
+		domain.Merchant{ID: "m1"}, event, "u1", "card1", "BR", "BRL", "POS")
+	return tx
+}
@@ -0,0 +1,159 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+const idempotencyRecordColumns = "idempotency_key, payload_hash, status_code, body, created_at, expires_at"
+
+// idempotencySweepInterval is how often the store deletes the expired records of every key.
+const idempotencySweepInterval = time.Minute
+
+// IdempotencyStore is a ports.IdempotencyStore kept in the repository's database, so the
+// responses replayed to retries survive a restart and are shared by every instance. An expired
+// record stops counting at once; its row is deleted by the next claim on its key or by the sweep
+// that claims run at most once per idempotencySweepInterval.
+type IdempotencyStore struct {
+	r *Repository
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+var _ ports.IdempotencyStore = (*IdempotencyStore)(nil)
+
+// IdempotencyRecords returns the idempotency record store sharing r's database.
+func (r *Repository) IdempotencyRecords() *IdempotencyStore {
+	return &IdempotencyStore{r: r}
+}
+
+// ClaimIdempotencyKey inserts rec unless a live record holds its key, replacing an expired one.
+func (s *IdempotencyStore) ClaimIdempotencyKey(ctx context.Context, rec domain.IdempotencyRecord) (domain.IdempotencyRecord, error) {
+	if err := s.sweep(ctx, rec.CreatedAt); err != nil {
+		return domain.IdempotencyRecord{}, err
+	}
+	var held domain.IdempotencyRecord
+	err := s.r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.insert(ctx, tx, rec); err != nil {
+			return err
+		}
+		var err error
+		held, err = s.get(ctx, tx, rec.Key)
+		return err
+	})
+	return held, err
+}
+
+// SaveIdempotencyRecord inserts rec, or fills in the response of a claim for the same payload,
+// which keeps its expiry. A record that already holds a response is left alone.
+func (s *IdempotencyStore) SaveIdempotencyRecord(ctx context.Context, rec domain.IdempotencyRecord) error {
+	return s.r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.deleteExpired(ctx, tx, rec); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, s.r.dialect.rebind(
+			`INSERT INTO idempotency_records (`+idempotencyRecordColumns+`) VALUES (`+placeholders(6)+`)
+			ON CONFLICT (idempotency_key) DO UPDATE SET status_code = excluded.status_code, body = excluded.body
+			WHERE idempotency_records.status_code = 0 AND idempotency_records.payload_hash = excluded.payload_hash`),
+			idempotencyRecordArgs(rec)...)
+		if err != nil {
+			return fmt.Errorf("save idempotency record: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *IdempotencyStore) ClearIdempotencyResponse(ctx context.Context, key string, now time.Time) error {
+	_, err := s.r.db.ExecContext(ctx, s.r.dialect.rebind(
+		`UPDATE idempotency_records SET status_code = 0, body = NULL
+		WHERE idempotency_key = ? AND expires_at > ? AND status_code <> 0`), key, now.UnixNano())
+	if err != nil {
+		return fmt.Errorf("clear idempotency response: %w", err)
+	}
+	return nil
+}
+
+func (s *IdempotencyStore) GetIdempotencyRecord(ctx context.Context, key string, now time.Time) (domain.IdempotencyRecord, bool, error) {
+	rec, err := s.get(ctx, s.r.db, key)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && rec.Expired(now)) {
+		return domain.IdempotencyRecord{}, false, nil
+	}
+	if err != nil {
+		return domain.IdempotencyRecord{}, false, err
+	}
+	return rec, true, nil
+}
+
+// insert stores rec unless a live record holds its key. Callers run it in a transaction.
+func (s *IdempotencyStore) insert(ctx context.Context, tx *sql.Tx, rec domain.IdempotencyRecord) error {
+	if err := s.deleteExpired(ctx, tx, rec); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.r.dialect.rebind(
+		`INSERT INTO idempotency_records (`+idempotencyRecordColumns+`) VALUES (`+placeholders(6)+`) ON CONFLICT DO NOTHING`),
+		idempotencyRecordArgs(rec)...); err != nil {
+		return fmt.Errorf("claim idempotency key: %w", err)
+	}
+	return nil
+}
+
+// deleteExpired deletes the record for rec's key if it expired by rec.CreatedAt.
+func (s *IdempotencyStore) deleteExpired(ctx context.Context, tx *sql.Tx, rec domain.IdempotencyRecord) error {
+	if _, err := tx.ExecContext(ctx, s.r.dialect.rebind(
+		`DELETE FROM idempotency_records WHERE idempotency_key = ? AND expires_at <= ?`),
+		rec.Key, rec.CreatedAt.UnixNano()); err != nil {
+		return fmt.Errorf("delete expired idempotency record: %w", err)
+	}
+	return nil
+}
+
+func (s *IdempotencyStore) get(ctx context.Context, q queryer, key string) (domain.IdempotencyRecord, error) {
+	rows, err := q.QueryContext(ctx, s.r.dialect.rebind(
+		`SELECT `+idempotencyRecordColumns+` FROM idempotency_records WHERE idempotency_key = ?`), key)
+	if err != nil {
+		return domain.IdempotencyRecord{}, fmt.Errorf("query idempotency record: %w", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return domain.IdempotencyRecord{}, err
+		}
+		return domain.IdempotencyRecord{}, sql.ErrNoRows
+	}
+	var rec domain.IdempotencyRecord
+	var createdAt, expiresAt int64
+	if err := rows.Scan(&rec.Key, &rec.PayloadHash, &rec.StatusCode, &rec.Body, &createdAt, &expiresAt); err != nil {
+		return domain.IdempotencyRecord{}, err
+	}
+	rec.CreatedAt = time.Unix(0, createdAt).UTC()
+	rec.ExpiresAt = time.Unix(0, expiresAt).UTC()
+	return rec, rows.Close()
+}
+
+// sweep deletes every record expired at now, unless the last sweep ran less than
+// idempotencySweepInterval ago.
+func (s *IdempotencyStore) sweep(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < idempotencySweepInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+	if _, err := s.r.db.ExecContext(ctx, s.r.dialect.rebind(`DELETE FROM idempotency_records WHERE expires_at <= ?`), now.UnixNano()); err != nil {
+		return fmt.Errorf("sweep idempotency records: %w", err)
+	}
+	return nil
+}
+
+func idempotencyRecordArgs(rec domain.IdempotencyRecord) []any {
+	return []any{rec.Key, rec.PayloadHash, rec.StatusCode, rec.Body, rec.CreatedAt.UnixNano(), rec.ExpiresAt.UnixNano()}
+}
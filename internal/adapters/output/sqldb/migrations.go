@@ -0,0 +1,330 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migration is a numbered, forward-only schema change. Versions must be strictly increasing;
+// never edit a migration that has shipped — append a new one.
+type migration struct {
+	version int
+	name    string
+	stmts   []string
+}
+
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create transactions, adjustments and idempotency keys",
+		stmts: []string{
+			`CREATE TABLE transactions (
+				id                  TEXT PRIMARY KEY,
+				type                TEXT NOT NULL,
+				status              TEXT NOT NULL,
+				local_amount        BIGINT NOT NULL,
+				local_currency      TEXT NOT NULL,
+				tx_amount           BIGINT NOT NULL,
+				tx_currency         TEXT NOT NULL,
+				settlement_amount   BIGINT NOT NULL,
+				settlement_currency TEXT NOT NULL,
+				original_amount     BIGINT NOT NULL,
+				original_currency   TEXT NOT NULL,
+				merchant_id         TEXT NOT NULL,
+				merchant_mcc        TEXT NOT NULL,
+				merchant_address    TEXT NOT NULL,
+				merchant_name       TEXT NOT NULL,
+				merchant_city       TEXT NOT NULL,
+				merchant_state      TEXT NOT NULL,
+				event_id            TEXT NOT NULL,
+				event_created_at    BIGINT NOT NULL,
+				idempotency_key     TEXT NOT NULL UNIQUE,
+				user_id             TEXT NOT NULL,
+				card_id             TEXT NOT NULL,
+				country             TEXT NOT NULL,
+				currency            TEXT NOT NULL,
+				point_of_sale       TEXT NOT NULL
+			)`,
+			`CREATE TABLE adjustments (
+				seq                     {{serial}},
+				id                      TEXT NOT NULL,
+				original_transaction_id TEXT NOT NULL,
+				type                    TEXT NOT NULL,
+				status                  TEXT NOT NULL,
+				local_amount            BIGINT NOT NULL,
+				local_currency          TEXT NOT NULL,
+				tx_amount               BIGINT NOT NULL,
+				tx_currency             TEXT NOT NULL,
+				settlement_amount       BIGINT NOT NULL,
+				settlement_currency     TEXT NOT NULL,
+				original_amount         BIGINT NOT NULL,
+				original_currency       TEXT NOT NULL,
+				merchant_id             TEXT NOT NULL,
+				merchant_mcc            TEXT NOT NULL,
+				merchant_address        TEXT NOT NULL,
+				merchant_name           TEXT NOT NULL,
+				merchant_city           TEXT NOT NULL,
+				merchant_state          TEXT NOT NULL,
+				event_id                TEXT NOT NULL,
+				event_created_at        BIGINT NOT NULL,
+				idempotency_key         TEXT NOT NULL UNIQUE,
+				user_id                 TEXT NOT NULL,
+				card_id                 TEXT NOT NULL,
+				country                 TEXT NOT NULL,
+				currency                TEXT NOT NULL,
+				point_of_sale           TEXT NOT NULL
+			)`,
+			`CREATE INDEX idx_adjustments_original ON adjustments (original_transaction_id, seq)`,
+			// Transactions and adjustments share one idempotency namespace, exactly like the
+			// memory adapter's idempotencyKeys map.
+			`CREATE TABLE idempotency_keys (
+				idempotency_key TEXT PRIMARY KEY,
+				entity_id       TEXT NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 2,
+		name:    "index transactions for filtered and paginated listing",
+		stmts: []string{
+			// One index per sort order (keyset pagination walks it), plus the most selective filters.
+			`CREATE INDEX idx_transactions_created ON transactions (event_created_at, id)`,
+			`CREATE INDEX idx_transactions_amount ON transactions (local_amount, id)`,
+			`CREATE INDEX idx_transactions_user ON transactions (user_id, event_created_at, id)`,
+			`CREATE INDEX idx_transactions_card ON transactions (card_id, event_created_at, id)`,
+		},
+	},
+	{
+		version: 3,
+		name:    "create transactional outbox",
+		stmts: []string{
+			// payload is the JSON-encoded domain.OutboxEvent; seq is the write order.
+			`CREATE TABLE outbox (
+				seq     {{serial}},
+				id      TEXT NOT NULL,
+				type    TEXT NOT NULL,
+				payload TEXT NOT NULL
+			)`,
+			`CREATE INDEX idx_outbox_id ON outbox (id)`,
+		},
+	},
+	{
+		version: 4,
+		name:    "store the implied exchange rate of cross-border transactions",
+		stmts: []string{
+			`ALTER TABLE transactions ADD COLUMN exchange_rate TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version: 5,
+		name:    "create the webhook attempt log",
+		stmts: []string{
+			// seq is the arrival order replay follows.
+			`CREATE TABLE webhook_attempts (
+				seq             {{serial}},
+				id              TEXT NOT NULL UNIQUE,
+				transaction_id  TEXT NOT NULL,
+				idempotency_key TEXT NOT NULL,
+				received_at     BIGINT NOT NULL,
+				outcome         TEXT NOT NULL,
+				status_code     INTEGER NOT NULL,
+				payload         {{bytes}} NOT NULL
+			)`,
+			`CREATE INDEX idx_webhook_attempts_transaction ON webhook_attempts (transaction_id, seq)`,
+		},
+	},
+	{
+		version: 6,
+		name:    "create the dispute table",
+		stmts: []string{
+			// resolved_at is 0 while the dispute is undecided; history is the JSON list of
+			// transitions, read and written whole.
+			`CREATE TABLE disputes (
+				seq            {{serial}},
+				id             TEXT NOT NULL UNIQUE,
+				transaction_id TEXT NOT NULL,
+				user_id        TEXT NOT NULL,
+				card_id        TEXT NOT NULL,
+				merchant_id    TEXT NOT NULL,
+				amount         BIGINT NOT NULL,
+				currency       TEXT NOT NULL,
+				reason         TEXT NOT NULL,
+				state          TEXT NOT NULL,
+				opened_at      BIGINT NOT NULL,
+				resolved_at    BIGINT NOT NULL,
+				history        TEXT NOT NULL
+			)`,
+			`CREATE INDEX idx_disputes_transaction ON disputes (transaction_id, seq)`,
+		},
+	},
+	{
+		version: 7,
+		name:    "index the transaction list tiebreaker bytewise",
+		stmts: []string{
+			// ListTransactions compares and sorts id bytewise; the indexes must use the same
+			// collation or Postgres cannot serve the keyset scan from them.
+			`DROP INDEX idx_transactions_created`,
+			`DROP INDEX idx_transactions_amount`,
+			`DROP INDEX idx_transactions_user`,
+			`DROP INDEX idx_transactions_card`,
+			`CREATE INDEX idx_transactions_created ON transactions (event_created_at, id{{bytewise}})`,
+			`CREATE INDEX idx_transactions_amount ON transactions (local_amount, id{{bytewise}})`,
+			`CREATE INDEX idx_transactions_user ON transactions (user_id, event_created_at, id{{bytewise}})`,
+			`CREATE INDEX idx_transactions_card ON transactions (card_id, event_created_at, id{{bytewise}})`,
+		},
+	},
+	{
+		version: 8,
+		name:    "index adjustments by id",
+		stmts: []string{
+			// Reconciliation looks up the adjustments a settlement file names outside its window.
+			`CREATE INDEX idx_adjustments_id ON adjustments (id, seq)`,
+		},
+	},
+	{
+		version: 9,
+		name:    "create the parked adjustment table",
+		stmts: []string{
+			// adjustment is the JSON-encoded domain.Adjustment; reason is empty until the
+			// adjustment fails to apply.
+			`CREATE TABLE pending_adjustments (
+				seq                     {{serial}},
+				idempotency_key         TEXT NOT NULL UNIQUE,
+				original_transaction_id TEXT NOT NULL,
+				parked_at               BIGINT NOT NULL,
+				expires_at              BIGINT NOT NULL,
+				reason                  TEXT NOT NULL,
+				adjustment              TEXT NOT NULL
+			)`,
+			`CREATE INDEX idx_pending_adjustments_original ON pending_adjustments (original_transaction_id, seq)`,
+		},
+	},
+	{
+		version: 10,
+		name:    "create the card and authorization tables",
+		stmts: []string{
+			`CREATE TABLE cards (
+				id                TEXT PRIMARY KEY,
+				user_id           TEXT NOT NULL,
+				status            TEXT NOT NULL,
+				currency          TEXT NOT NULL,
+				balance           BIGINT NOT NULL,
+				transaction_limit BIGINT NOT NULL,
+				daily_limit       BIGINT NOT NULL
+			)`,
+			// decision is the JSON-encoded domain.Authorization, rewritten when the webhook matches it.
+			`CREATE TABLE authorizations (
+				seq      {{serial}},
+				id       TEXT NOT NULL UNIQUE,
+				card_id  TEXT NOT NULL,
+				decision TEXT NOT NULL
+			)`,
+			`CREATE INDEX idx_authorizations_card ON authorizations (card_id, seq)`,
+		},
+	},
+	{
+		version: 11,
+		name:    "create the dead letter table",
+		stmts: []string{
+			// event is the JSON-encoded domain.OutboxEvent; seq is the failure order.
+			`CREATE TABLE dead_letters (
+				seq        {{serial}},
+				event_id   TEXT NOT NULL,
+				subscriber TEXT NOT NULL,
+				attempts   INTEGER NOT NULL,
+				last_error TEXT NOT NULL,
+				failed_at  BIGINT NOT NULL,
+				event      TEXT NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 12,
+		name:    "create the subscription and delivery tables",
+		stmts: []string{
+			// types and statuses are JSON arrays; seq is the creation order, kept when a
+			// subscription is saved again.
+			`CREATE TABLE subscriptions (
+				seq        {{serial}},
+				id         TEXT NOT NULL UNIQUE,
+				url        TEXT NOT NULL,
+				types      TEXT NOT NULL,
+				statuses   TEXT NOT NULL,
+				secret     TEXT NOT NULL,
+				created_at BIGINT NOT NULL
+			)`,
+			// delivery is the JSON-encoded domain.Delivery.
+			`CREATE TABLE deliveries (
+				seq             {{serial}},
+				id              TEXT NOT NULL,
+				subscription_id TEXT NOT NULL,
+				delivery        TEXT NOT NULL
+			)`,
+			`CREATE INDEX idx_deliveries_subscription ON deliveries (subscription_id, seq)`,
+			`CREATE INDEX idx_deliveries_id ON deliveries (id)`,
+		},
+	},
+	{
+		version: 13,
+		name:    "create the idempotency record table",
+		stmts: []string{
+			// status_code is 0 while the key is only claimed for its payload.
+			`CREATE TABLE idempotency_records (
+				idempotency_key TEXT PRIMARY KEY,
+				payload_hash    TEXT NOT NULL,
+				status_code     INTEGER NOT NULL,
+				body            {{bytes}},
+				created_at      BIGINT NOT NULL,
+				expires_at      BIGINT NOT NULL
+			)`,
+			`CREATE INDEX idx_idempotency_records_expires ON idempotency_records (expires_at)`,
+		},
+	},
+}
+
+// Migrate applies every migration newer than the recorded schema version, each in its own
+// database transaction.
+func Migrate(ctx context.Context, db *sql.DB, d Dialect) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at BIGINT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	var current int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(ctx, db, d, m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, d Dialect, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, stmt := range m.stmts {
+		if _, err := tx.ExecContext(ctx, d.render(stmt)); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx,
+		d.rebind(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`),
+		m.version, m.name, time.Now().UnixNano(),
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,146 @@
+package sqldb
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+const pendingColumns = "seq, idempotency_key, original_transaction_id, parked_at, expires_at, reason, adjustment"
+
+// PendingStore is a ports.PendingAdjustmentStore kept in the repository's database, so an
+// adjustment answered 202 survives a restart and is applied by whichever instance stores its
+// purchase. It is a type of its own because its GetByIdempotencyKey differs from the repository's.
+type PendingStore struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+var _ ports.PendingAdjustmentStore = (*PendingStore)(nil)
+
+// PendingAdjustments returns the parked adjustment store sharing r's database.
+func (r *Repository) PendingAdjustments() *PendingStore {
+	return &PendingStore{db: r.db, dialect: r.dialect}
+}
+
+func (s *PendingStore) Park(ctx context.Context, p domain.PendingAdjustment) error {
+	args, err := pendingArgs(p)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, s.dialect.rebind(
+		`INSERT INTO pending_adjustments (idempotency_key, original_transaction_id, parked_at, expires_at, reason, adjustment)
+		VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`), args...)
+	if err != nil {
+		return fmt.Errorf("park adjustment: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrDuplicateIdempotencyKey
+	}
+	return nil
+}
+
+func (s *PendingStore) GetByIdempotencyKey(ctx context.Context, key string) (domain.PendingAdjustment, bool) {
+	pending, err := s.query(ctx, `SELECT `+pendingColumns+` FROM pending_adjustments WHERE idempotency_key = ?`, key)
+	if err != nil || len(pending) == 0 {
+		return domain.PendingAdjustment{}, false
+	}
+	return pending[0], true
+}
+
+// TakeParked deletes and returns the live entries in one statement, so two instances draining the
+// same purchase never receive the same adjustment.
+func (s *PendingStore) TakeParked(ctx context.Context, originalTxID string, now time.Time) ([]domain.PendingAdjustment, error) {
+	return s.query(ctx, `DELETE FROM pending_adjustments
+		WHERE original_transaction_id = ? AND reason = '' AND expires_at > ?
+		RETURNING `+pendingColumns, originalTxID, now.UnixNano())
+}
+
+// Reject stores p back, replacing any entry with its idempotency key, with the reason it could
+// not be applied.
+func (s *PendingStore) Reject(ctx context.Context, p domain.PendingAdjustment, reason string) error {
+	p.Reason = reason
+	args, err := pendingArgs(p)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, s.dialect.rebind(
+		`INSERT INTO pending_adjustments (idempotency_key, original_transaction_id, parked_at, expires_at, reason, adjustment)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (idempotency_key) DO UPDATE SET reason = excluded.reason`), args...)
+	if err != nil {
+		return fmt.Errorf("reject parked adjustment: %w", err)
+	}
+	return nil
+}
+
+// ListForReview returns the expired and rejected entries, oldest parked first.
+func (s *PendingStore) ListForReview(ctx context.Context, now time.Time) ([]domain.PendingAdjustment, error) {
+	pending, err := s.query(ctx, `SELECT `+pendingColumns+` FROM pending_adjustments
+		WHERE reason <> '' OR expires_at <= ?`, now.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(pending, func(a, b domain.PendingAdjustment) int { return a.ParkedAt.Compare(b.ParkedAt) })
+	return pending, nil
+}
+
+// query returns the matching entries in parking order.
+func (s *PendingStore) query(ctx context.Context, query string, args ...any) ([]domain.PendingAdjustment, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query pending adjustments: %w", err)
+	}
+	defer rows.Close()
+	type entry struct {
+		seq     int64
+		pending domain.PendingAdjustment
+	}
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		var key, originalTxID, adjustment string
+		var parkedAt, expiresAt int64
+		if err := rows.Scan(&e.seq, &key, &originalTxID, &parkedAt, &expiresAt, &e.pending.Reason, &adjustment); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(adjustment), &e.pending.Adjustment); err != nil {
+			return nil, fmt.Errorf("decode parked adjustment %s: %w", key, err)
+		}
+		e.pending.ParkedAt = time.Unix(0, parkedAt).UTC()
+		e.pending.ExpiresAt = time.Unix(0, expiresAt).UTC()
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// RETURNING does not promise an order.
+	slices.SortFunc(entries, func(a, b entry) int { return cmp.Compare(a.seq, b.seq) })
+	pending := make([]domain.PendingAdjustment, len(entries))
+	for i, e := range entries {
+		pending[i] = e.pending
+	}
+	return pending, nil
+}
+
+func pendingArgs(p domain.PendingAdjustment) ([]any, error) {
+	adjustment, err := json.Marshal(p.Adjustment)
+	if err != nil {
+		return nil, fmt.Errorf("encode parked adjustment: %w", err)
+	}
+	return []any{
+		p.Adjustment.Event.IdempotencyKey, p.Adjustment.OriginalTransactionID,
+		p.ParkedAt.UnixNano(), p.ExpiresAt.UnixNano(), p.Reason, string(adjustment),
+	}, nil
+}
@@ -0,0 +1,96 @@
+package sqldb
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+)
+
+// sortColumns maps sort fields to columns; every order ends with id as the tiebreaker.
+var sortColumns = map[ports.TransactionSortField]string{
+	ports.SortByCreatedAt: "event_created_at",
+	ports.SortByAmount:    "local_amount",
+	ports.SortByID:        "",
+}
+
+// buildListQuery renders q as a SELECT with "?" placeholders and its arguments. The id
+// tiebreaker is compared and sorted bytewise in every dialect, matching the in-memory
+// strings.Compare order so cursors behave the same on every backend.
+func buildListQuery(q ports.TransactionQuery, d Dialect) (string, []any) {
+	id := "id" + d.byteOrder
+	var where []string
+	var args []any
+	eq := func(column, value string) {
+		if value != "" {
+			where = append(where, column+" = ?")
+			args = append(args, value)
+		}
+	}
+	eq("user_id", q.UserID)
+	eq("card_id", q.CardID)
+	eq("merchant_id", q.MerchantID)
+	eq("merchant_mcc", q.MerchantMCC)
+	eq("status", string(q.Status))
+	eq("type", string(q.Type))
+	eq("local_currency", q.Currency)
+	if q.MinAmount != nil {
+		where = append(where, "local_amount >= ?")
+		args = append(args, *q.MinAmount)
+	}
+	if q.MaxAmount != nil {
+		where = append(where, "local_amount <= ?")
+		args = append(args, *q.MaxAmount)
+	}
+	if !q.CreatedFrom.IsZero() {
+		where = append(where, "event_created_at >= ?")
+		args = append(args, q.CreatedFrom.UnixNano())
+	}
+	if !q.CreatedTo.IsZero() {
+		where = append(where, "event_created_at < ?")
+		args = append(args, q.CreatedTo.UnixNano())
+	}
+
+	column, ok := sortColumns[q.SortBy]
+	if !ok {
+		column = sortColumns[ports.SortByCreatedAt]
+	}
+	op, dir := ">", "ASC"
+	if q.Descending {
+		op, dir = "<", "DESC"
+	}
+	if q.After != nil {
+		// Keyset condition (column, id) > (?, ?), spelled out because row-value comparison
+		// is not uniformly supported.
+		if column == "" {
+			where = append(where, id+" "+op+" ?")
+			args = append(args, q.After.ID)
+		} else {
+			where = append(where, "("+column+" "+op+" ? OR ("+column+" = ? AND "+id+" "+op+" ?))")
+			v := cursorValue(column, *q.After)
+			args = append(args, v, v, q.After.ID)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT " + transactionColumns + " FROM transactions")
+	if len(where) > 0 {
+		b.WriteString(" WHERE " + strings.Join(where, " AND "))
+	}
+	b.WriteString(" ORDER BY ")
+	if column != "" {
+		b.WriteString(column + " " + dir + ", ")
+	}
+	b.WriteString(id + " " + dir)
+	if q.Limit > 0 {
+		b.WriteString(" LIMIT " + strconv.Itoa(q.Limit+1))
+	}
+	return b.String(), args
+}
+
+func cursorValue(column string, c ports.TransactionCursor) int64 {
+	if column == "local_amount" {
+		return c.Amount
+	}
+	return c.CreatedAt.UnixNano()
+}
@@ -0,0 +1,98 @@
+package sqldb
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+)
+
+func TestBuildListQueryPlaceholdersMatchArgs(t *testing.T) {
+	lo, hi := int64(100), int64(500)
+	after := &ports.TransactionCursor{CreatedAt: time.Unix(0, 42), Amount: 300, ID: "tx1"}
+	queries := []ports.TransactionQuery{
+		{},
+		{UserID: "u1", CardID: "c1", MerchantID: "m1", MerchantMCC: "5411", Status: "APPROVED", Type: "PURCHASE", Currency: "BRL"},
+		{MinAmount: &lo, MaxAmount: &hi, CreatedFrom: time.Unix(10, 0), CreatedTo: time.Unix(20, 0)},
+		{SortBy: ports.SortByAmount, Descending: true, After: after, Limit: 10},
+		{SortBy: ports.SortByID, After: after, Limit: 10},
+		{UserID: "u1", SortBy: ports.SortByCreatedAt, After: after, Limit: 5},
+	}
+	for _, q := range queries {
+		query, args := buildListQuery(q, SQLite)
+		if n := strings.Count(query, "?"); n != len(args) {
+			t.Errorf("%d placeholders but %d args in %q", n, len(args), query)
+		}
+	}
+}
+
+func TestBuildListQueryOrderAndKeyset(t *testing.T) {
+	after := &ports.TransactionCursor{CreatedAt: time.Unix(0, 42), Amount: 300, ID: "tx1"}
+	cases := []struct {
+		name     string
+		q        ports.TransactionQuery
+		contains []string
+		args     []any
+	}{
+		{
+			name:     "default",
+			q:        ports.TransactionQuery{},
+			contains: []string{"ORDER BY event_created_at ASC, id ASC"},
+		},
+		{
+			name:     "amount desc after cursor",
+			q:        ports.TransactionQuery{SortBy: ports.SortByAmount, Descending: true, After: after, Limit: 10},
+			contains: []string{"(local_amount < ? OR (local_amount = ? AND id < ?))", "ORDER BY local_amount DESC, id DESC", "LIMIT 11"},
+			args:     []any{int64(300), int64(300), "tx1"},
+		},
+		{
+			name:     "created_at after cursor",
+			q:        ports.TransactionQuery{After: after},
+			contains: []string{"(event_created_at > ? OR (event_created_at = ? AND id > ?))"},
+			args:     []any{int64(42), int64(42), "tx1"},
+		},
+		{
+			name:     "id",
+			q:        ports.TransactionQuery{SortBy: ports.SortByID, After: after},
+			contains: []string{"WHERE id > ?", "ORDER BY id ASC"},
+			args:     []any{"tx1"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, args := buildListQuery(tc.q, SQLite)
+			for _, want := range tc.contains {
+				if !strings.Contains(query, want) {
+					t.Errorf("expected %q in %q", want, query)
+				}
+			}
+			if tc.args != nil {
+				if len(args) != len(tc.args) {
+					t.Fatalf("expected args %v, got %v", tc.args, args)
+				}
+				for i := range args {
+					if args[i] != tc.args[i] {
+						t.Errorf("arg %d: expected %v, got %v", i, tc.args[i], args[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBuildListQueryComparesIDsBytewiseOnPostgres(t *testing.T) {
+	after := &ports.TransactionCursor{Amount: 300, ID: "tx1"}
+	query, _ := buildListQuery(ports.TransactionQuery{SortBy: ports.SortByAmount, After: after}, Postgres)
+	for _, want := range []string{`AND id COLLATE "C" > ?`, `ORDER BY local_amount ASC, id COLLATE "C" ASC`} {
+		if !strings.Contains(query, want) {
+			t.Errorf("expected %q in %q", want, query)
+		}
+	}
+	query, _ = buildListQuery(ports.TransactionQuery{SortBy: ports.SortByID, After: after}, Postgres)
+	for _, want := range []string{`WHERE id COLLATE "C" > ?`, `ORDER BY id COLLATE "C" ASC`} {
+		if !strings.Contains(query, want) {
+			t.Errorf("expected %q in %q", want, query)
+		}
+	}
+}
@@ -0,0 +1,398 @@
+// Package sqldb implements ports.TransactionRepository on top of database/sql.
+// It works with SQLite (modernc.org/sqlite, pure Go) and Postgres (pgx); the driver is
+// linked by the binary, keeping this package free of third-party imports.
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// Columns shared by the transactions and adjustments tables, in scan order.
+var entityColumns = []string{
+	"id", "type", "status",
+	"local_amount", "local_currency", "tx_amount", "tx_currency",
+	"settlement_amount", "settlement_currency", "original_amount", "original_currency",
+	"merchant_id", "merchant_mcc", "merchant_address", "merchant_name", "merchant_city", "merchant_state",
+	"event_id", "event_created_at", "idempotency_key",
+	"user_id", "card_id", "country", "currency", "point_of_sale",
+}
+
+var (
+	sharedColumns      = strings.Join(entityColumns, ", ")
+	transactionColumns = sharedColumns + ", exchange_rate"
+	adjustmentColumns  = sharedColumns + ", original_transaction_id"
+)
+
+// Repository is a database/sql implementation of ports.TransactionRepository.
+// Idempotency and ID uniqueness are enforced by unique constraints; each save runs in a
+// single database transaction, together with its outbox row, so a rejected write never leaves
+// a claimed key or an event behind.
+type Repository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// Open runs pending migrations and returns a ready repository.
+func Open(ctx context.Context, db *sql.DB, d Dialect) (*Repository, error) {
+	if d == SQLite {
+		// SQLite allows a single writer; one connection avoids SQLITE_BUSY under load and
+		// keeps ":memory:" databases from being split across connections.
+		db.SetMaxOpenConns(1)
+	}
+	if err := Migrate(ctx, db, d); err != nil {
+		return nil, err
+	}
+	return &Repository{db: db, dialect: d}, nil
+}
+
+// Close closes the underlying database handle.
+func (r *Repository) Close() error {
+	return r.db.Close()
+}
+
+// CheckHealth pings the database.
+func (r *Repository) CheckHealth(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// SaveTransaction claims the idempotency key and inserts the transaction in one database transaction.
+func (r *Repository) SaveTransaction(ctx context.Context, t domain.Transaction) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := r.claimIdempotencyKey(ctx, tx, t.Event.IdempotencyKey, t.ID); err != nil {
+			return err
+		}
+		inserted, err := r.insert(ctx, tx,
+			`INSERT INTO transactions (`+transactionColumns+`) VALUES (`+placeholders(len(entityColumns)+1)+`) ON CONFLICT DO NOTHING`,
+			transactionArgs(t)...)
+		if err != nil {
+			return fmt.Errorf("insert transaction: %w", err)
+		}
+		if !inserted {
+			return domain.ErrDuplicateTransactionID
+		}
+		return r.insertOutbox(ctx, tx, domain.TransactionProcessed(t))
+	})
+}
+
+// SaveAdjustment claims the idempotency key and inserts the adjustment in one database transaction.
+func (r *Repository) SaveAdjustment(ctx context.Context, adj domain.Adjustment) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := r.claimIdempotencyKey(ctx, tx, adj.Event.IdempotencyKey, adj.ID); err != nil {
+			return err
+		}
+		return r.insertAdjustment(ctx, tx, adj)
+	})
+}
+
+// AppendAdjustment locks the original transaction row (SELECT ... FOR UPDATE on Postgres; SQLite
+// serializes writers via the immediate transaction), reads existing adjustments, runs check and
+// inserts — all in one database transaction.
+func (r *Repository) AppendAdjustment(ctx context.Context, adj domain.Adjustment, check ports.AdjustmentCheck) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx,
+			r.dialect.rebind(`SELECT `+transactionColumns+` FROM transactions WHERE id = ?`+r.dialect.lockForUpdate),
+			adj.OriginalTransactionID)
+		original, err := scanTransaction(row)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrTransactionNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("lock original transaction: %w", err)
+		}
+		if err := r.claimIdempotencyKey(ctx, tx, adj.Event.IdempotencyKey, adj.ID); err != nil {
+			return err
+		}
+		existing, err := r.queryAdjustments(ctx, tx, adj.OriginalTransactionID)
+		if err != nil {
+			return err
+		}
+		if err := check(original, existing); err != nil {
+			return err
+		}
+		return r.insertAdjustment(ctx, tx, adj)
+	})
+}
+
+func (r *Repository) GetTransactionByID(ctx context.Context, id string) (domain.Transaction, error) {
+	row := r.db.QueryRowContext(ctx, r.dialect.rebind(`SELECT `+transactionColumns+` FROM transactions WHERE id = ?`), id)
+	t, err := scanTransaction(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Transaction{}, domain.ErrTransactionNotFound
+	}
+	return t, err
+}
+
+// GetAdjustmentsByTransactionID returns adjustments in arrival order.
+func (r *Repository) GetAdjustmentsByTransactionID(ctx context.Context, originalTxID string) ([]domain.Adjustment, error) {
+	return r.queryAdjustments(ctx, r.db, originalTxID)
+}
+
+// GetAdjustmentByID returns the first adjustment stored with id.
+func (r *Repository) GetAdjustmentByID(ctx context.Context, id string) (domain.Adjustment, error) {
+	row := r.db.QueryRowContext(ctx, r.dialect.rebind(`SELECT `+adjustmentColumns+` FROM adjustments WHERE id = ? ORDER BY seq LIMIT 1`), id)
+	adj, err := scanAdjustment(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Adjustment{}, domain.ErrTransactionNotFound
+	}
+	return adj, err
+}
+
+// GetByIdempotencyKey is advisory, like its memory counterpart; lookup errors read as "not found"
+// and the unique constraint in Save* remains the source of truth.
+func (r *Repository) GetByIdempotencyKey(ctx context.Context, key string) (string, bool) {
+	var id string
+	err := r.db.QueryRowContext(ctx, r.dialect.rebind(`SELECT entity_id FROM idempotency_keys WHERE idempotency_key = ?`), key).Scan(&id)
+	if err != nil {
+		return "", false
+	}
+	return id, true
+}
+
+// ListTransactions pushes filters, ordering and keyset pagination into a single SELECT.
+// It fetches one row past the limit to learn whether another page exists.
+func (r *Repository) ListTransactions(ctx context.Context, q ports.TransactionQuery) (ports.TransactionPage, error) {
+	query, args := buildListQuery(q, r.dialect)
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return ports.TransactionPage{}, fmt.Errorf("list transactions: %w", err)
+	}
+	defer rows.Close()
+	txs := []domain.Transaction{}
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return ports.TransactionPage{}, err
+		}
+		txs = append(txs, t)
+	}
+	if err := rows.Err(); err != nil {
+		return ports.TransactionPage{}, err
+	}
+	page := ports.TransactionPage{Transactions: txs}
+	if q.Limit > 0 && len(txs) > q.Limit {
+		page.Transactions = txs[:q.Limit]
+		next := ports.CursorFor(txs[q.Limit-1])
+		page.Next = &next
+	}
+	return page, nil
+}
+
+// PendingEvents reads the oldest outbox rows in write order.
+func (r *Repository) PendingEvents(ctx context.Context, limit int) ([]domain.OutboxEvent, error) {
+	query, args := `SELECT payload FROM outbox ORDER BY seq`, []any{}
+	if limit > 0 {
+		query, args = query+` LIMIT ?`, append(args, limit)
+	}
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query outbox: %w", err)
+	}
+	defer rows.Close()
+	events := []domain.OutboxEvent{}
+	for rows.Next() {
+		var payload string
+		if err := rows.Scan(&payload); err != nil {
+			return nil, err
+		}
+		var event domain.OutboxEvent
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			return nil, fmt.Errorf("decode outbox event: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// MarkDispatched deletes the outbox rows of the given events.
+func (r *Repository) MarkDispatched(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(`DELETE FROM outbox WHERE id IN (`+placeholders(len(ids))+`)`), args...)
+	if err != nil {
+		return fmt.Errorf("delete dispatched outbox events: %w", err)
+	}
+	return nil
+}
+
+// queryer is satisfied by *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func (r *Repository) queryAdjustments(ctx context.Context, q queryer, originalTxID string) ([]domain.Adjustment, error) {
+	rows, err := q.QueryContext(ctx,
+		r.dialect.rebind(`SELECT `+adjustmentColumns+` FROM adjustments WHERE original_transaction_id = ? ORDER BY seq`),
+		originalTxID)
+	if err != nil {
+		return nil, fmt.Errorf("query adjustments: %w", err)
+	}
+	defer rows.Close()
+	var adjs []domain.Adjustment
+	for rows.Next() {
+		adj, err := scanAdjustment(rows)
+		if err != nil {
+			return nil, err
+		}
+		adjs = append(adjs, adj)
+	}
+	return adjs, rows.Err()
+}
+
+func (r *Repository) insertAdjustment(ctx context.Context, tx *sql.Tx, adj domain.Adjustment) error {
+	args := append(adjustmentArgs(adj), adj.OriginalTransactionID)
+	inserted, err := r.insert(ctx, tx,
+		`INSERT INTO adjustments (`+adjustmentColumns+`) VALUES (`+placeholders(len(entityColumns)+1)+`) ON CONFLICT DO NOTHING`,
+		args...)
+	if err != nil {
+		return fmt.Errorf("insert adjustment: %w", err)
+	}
+	if !inserted {
+		return domain.ErrDuplicateIdempotencyKey
+	}
+	return r.insertOutbox(ctx, tx, domain.AdjustmentApplied(adj))
+}
+
+// claimIdempotencyKey inserts the key or reports ErrDuplicateIdempotencyKey if it already exists.
+// Concurrent claims of the same key block on the primary key until the first commits.
+func (r *Repository) claimIdempotencyKey(ctx context.Context, tx *sql.Tx, key, entityID string) error {
+	inserted, err := r.insert(ctx, tx,
+		`INSERT INTO idempotency_keys (idempotency_key, entity_id) VALUES (?, ?) ON CONFLICT DO NOTHING`,
+		key, entityID)
+	if err != nil {
+		return fmt.Errorf("claim idempotency key: %w", err)
+	}
+	if !inserted {
+		return domain.ErrDuplicateIdempotencyKey
+	}
+	return nil
+}
+
+func (r *Repository) insert(ctx context.Context, tx *sql.Tx, query string, args ...any) (bool, error) {
+	res, err := tx.ExecContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *Repository) insertOutbox(ctx context.Context, tx *sql.Tx, event domain.OutboxEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode outbox event: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, r.dialect.rebind(`INSERT INTO outbox (id, type, payload) VALUES (?, ?, ?)`),
+		event.ID, string(event.Type), string(payload))
+	if err != nil {
+		return fmt.Errorf("insert outbox event: %w", err)
+	}
+	return nil
+}
+
+// inTx runs fn in a database transaction, committing on nil and rolling back otherwise.
+func (r *Repository) inTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func transactionArgs(t domain.Transaction) []any {
+	return append(entityArgs(t), t.ExchangeRate)
+}
+
+func entityArgs(t domain.Transaction) []any {
+	return []any{
+		t.ID, string(t.Type), string(t.Status),
+		t.Amount.Local.Amount, t.Amount.Local.Currency,
+		t.Amount.Transaction.Amount, t.Amount.Transaction.Currency,
+		t.Amount.Settlement.Amount, t.Amount.Settlement.Currency,
+		t.Amount.Original.Amount, t.Amount.Original.Currency,
+		t.Merchant.ID, t.Merchant.MCC, t.Merchant.Address, t.Merchant.Name, t.Merchant.City, t.Merchant.State,
+		t.Event.ID, t.Event.CreatedAt.UnixNano(), t.Event.IdempotencyKey,
+		t.UserID, t.CardID, t.Country, t.Currency, t.PointOfSale,
+	}
+}
+
+func adjustmentArgs(a domain.Adjustment) []any {
+	return entityArgs(domain.Transaction{
+		ID: a.ID, Type: a.Type, Status: a.Status, Amount: a.Amount, Merchant: a.Merchant, Event: a.Event,
+		UserID: a.UserID, CardID: a.CardID, Country: a.Country, Currency: a.Currency, PointOfSale: a.PointOfSale,
+	})
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTransaction(row rowScanner) (domain.Transaction, error) {
+	var t domain.Transaction
+	var createdAt int64
+	err := row.Scan(
+		&t.ID, &t.Type, &t.Status,
+		&t.Amount.Local.Amount, &t.Amount.Local.Currency,
+		&t.Amount.Transaction.Amount, &t.Amount.Transaction.Currency,
+		&t.Amount.Settlement.Amount, &t.Amount.Settlement.Currency,
+		&t.Amount.Original.Amount, &t.Amount.Original.Currency,
+		&t.Merchant.ID, &t.Merchant.MCC, &t.Merchant.Address, &t.Merchant.Name, &t.Merchant.City, &t.Merchant.State,
+		&t.Event.ID, &createdAt, &t.Event.IdempotencyKey,
+		&t.UserID, &t.CardID, &t.Country, &t.Currency, &t.PointOfSale,
+		&t.ExchangeRate,
+	)
+	if err != nil {
+		return domain.Transaction{}, err
+	}
+	t.Event.CreatedAt = time.Unix(0, createdAt).UTC()
+	return t, nil
+}
+
+func scanAdjustment(row rowScanner) (domain.Adjustment, error) {
+	var a domain.Adjustment
+	var createdAt int64
+	err := row.Scan(
+		&a.ID, &a.Type, &a.Status,
+		&a.Amount.Local.Amount, &a.Amount.Local.Currency,
+		&a.Amount.Transaction.Amount, &a.Amount.Transaction.Currency,
+		&a.Amount.Settlement.Amount, &a.Amount.Settlement.Currency,
+		&a.Amount.Original.Amount, &a.Amount.Original.Currency,
+		&a.Merchant.ID, &a.Merchant.MCC, &a.Merchant.Address, &a.Merchant.Name, &a.Merchant.City, &a.Merchant.State,
+		&a.Event.ID, &createdAt, &a.Event.IdempotencyKey,
+		&a.UserID, &a.CardID, &a.Country, &a.Currency, &a.PointOfSale,
+		&a.OriginalTransactionID,
+	)
+	if err != nil {
+		return domain.Adjustment{}, err
+	}
+	a.Event.CreatedAt = time.Unix(0, createdAt).UTC()
+	return a, nil
+}
@@ -0,0 +1,178 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/repotest"
+	"github.com/jailtonjunior/pomelo/internal/application"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+var dbCounter atomic.Int64
+
+func openSQLite(t *testing.T) *Repository {
+	t.Helper()
+	dsn := fmt.Sprintf("file:repotest-%d?mode=memory&cache=shared&_txlock=immediate", dbCounter.Add(1))
+	db, err := sql.Open(SQLite.DriverName, dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	repo, err := Open(context.Background(), db, SQLite)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func TestRepositoryContractSQLite(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) ports.TransactionRepository { return openSQLite(t) })
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	repo := openSQLite(t)
+	if err := Migrate(context.Background(), repo.db, SQLite); err != nil {
+		t.Fatalf("second migrate: %v", err)
+	}
+}
+
+func TestCheckHealthSQLite(t *testing.T) {
+	repo := openSQLite(t)
+	if err := repo.CheckHealth(context.Background()); err != nil {
+		t.Fatalf("expected a healthy database, got %v", err)
+	}
+	repo.Close()
+	if err := repo.CheckHealth(context.Background()); err == nil {
+		t.Error("expected a closed database to be unhealthy")
+	}
+}
+
+func TestAttemptLogContractSQLite(t *testing.T) {
+	repotest.RunAttemptLog(t, func(t *testing.T) ports.AttemptLog { return openSQLite(t) })
+}
+
+func TestDisputeStoreContractSQLite(t *testing.T) {
+	repotest.RunDisputeStore(t, func(t *testing.T) ports.DisputeStore { return openSQLite(t) })
+}
+
+func TestPendingStoreContractSQLite(t *testing.T) {
+	repotest.RunPendingStore(t, func(t *testing.T) ports.PendingAdjustmentStore { return openSQLite(t).PendingAdjustments() })
+}
+
+func TestAuthorizationStoreContractSQLite(t *testing.T) {
+	repotest.RunAuthorizationStore(t, func(t *testing.T) ports.AuthorizationStore { return openSQLite(t) })
+}
+
+func TestDeadLetterStoreContractSQLite(t *testing.T) {
+	repotest.RunDeadLetterStore(t, func(t *testing.T) ports.DeadLetterStore { return openSQLite(t) })
+}
+
+func TestSubscriptionStoreContractSQLite(t *testing.T) {
+	repotest.RunSubscriptionStore(t, func(t *testing.T) ports.SubscriptionStore { return openSQLite(t) })
+}
+
+func TestDeliveryLogContractSQLite(t *testing.T) {
+	repotest.RunDeliveryLog(t, func(t *testing.T, limit int) ports.DeliveryLog { return openSQLite(t).Deliveries(limit) })
+}
+
+func TestIdempotencyStoreContractSQLite(t *testing.T) {
+	repotest.RunIdempotencyStore(t, func(t *testing.T) ports.IdempotencyStore { return openSQLite(t).IdempotencyRecords() })
+}
+
+func TestIdempotencyStoreSweepsExpiredRecordsSQLite(t *testing.T) {
+	repo := openSQLite(t)
+	store := repo.IdempotencyRecords()
+	ctx := context.Background()
+	savedAt := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	claim := func(key string, at time.Time) {
+		t.Helper()
+		rec, _ := domain.NewIdempotencyRecord(key, "hash-"+key, 0, nil, at, time.Hour)
+		if _, err := store.ClaimIdempotencyKey(ctx, rec); err != nil {
+			t.Fatalf("claim %s: %v", key, err)
+		}
+	}
+	count := func() int {
+		t.Helper()
+		var n int
+		if err := repo.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM idempotency_records`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	claim("idem1", savedAt)
+	claim("idem2", savedAt.Add(59*time.Minute+45*time.Second))
+	// idem1 expired, but the last sweep ran less than a sweep interval ago.
+	claim("idem3", savedAt.Add(time.Hour+30*time.Second))
+	if n := count(); n != 3 {
+		t.Fatalf("expected 3 rows before the next sweep, got %d", n)
+	}
+	claim("idem4", savedAt.Add(time.Hour+time.Minute))
+	if n := count(); n != 3 {
+		t.Errorf("expected the sweep to delete idem1 only, got %d rows", n)
+	}
+}
+
+// openSQLiteFile opens the database at path, which outlives the returned repository.
+func openSQLiteFile(t *testing.T, path string) *Repository {
+	t.Helper()
+	db, err := sql.Open(SQLite.DriverName, "file:"+path+"?_txlock=immediate")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	repo, err := Open(context.Background(), db, SQLite)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func webhookCmd(id, txType, originalID string, amount int64) ports.ProcessTransactionCommand {
+	return ports.ProcessTransactionCommand{
+		TransactionID: id, TransactionType: txType, TransactionStatus: "APPROVED", OriginalTransactionID: originalID,
+		LocalAmount: amount, LocalCurrency: "BRL", TxAmount: amount, TxCurrency: "BRL",
+		SettlementAmount: amount, SettlementCurrency: "BRL", OriginalAmount: amount, OriginalCurrency: "BRL",
+		MerchantID: "m1", EventID: "evt-" + id, EventCreatedAt: time.Now(), IdempotencyKey: "idem-" + id,
+		UserID: "u1", CardID: "card1", Country: "BR", Currency: "BRL", PointOfSale: "POS",
+	}
+}
+
+func TestOutOfOrderAdjustmentSurvivesRestartSQLite(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "pomelo.db")
+	newService := func(repo *Repository) *application.Service {
+		return application.NewService(repo, application.WithPendingAdjustments(repo.PendingAdjustments(), time.Hour))
+	}
+
+	before := openSQLiteFile(t, path)
+	res, err := newService(before).ProcessTransaction(ctx, webhookCmd("ref1", "REFUND", "tx1", 400))
+	if err != nil || !res.Parked {
+		t.Fatalf("expected the refund to be parked, got %+v (err %v)", res, err)
+	}
+	before.Close()
+
+	after := openSQLiteFile(t, path)
+	if _, err := newService(after).ProcessTransaction(ctx, webhookCmd("tx1", "PURCHASE", "", 1000)); err != nil {
+		t.Fatalf("process purchase: %v", err)
+	}
+	adjs, err := after.GetAdjustmentsByTransactionID(ctx, "tx1")
+	if err != nil {
+		t.Fatalf("get adjustments: %v", err)
+	}
+	if len(adjs) != 1 || adjs[0].ID != "ref1" {
+		t.Fatalf("expected the parked refund applied after a restart, got %v", adjs)
+	}
+	if _, ok := after.PendingAdjustments().GetByIdempotencyKey(ctx, "idem-ref1"); ok {
+		t.Error("expected the applied refund to leave the parked table")
+	}
+}
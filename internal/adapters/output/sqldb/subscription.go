@@ -0,0 +1,173 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+const subscriptionColumns = "id, url, types, statuses, secret, created_at"
+
+var _ ports.SubscriptionStore = (*Repository)(nil)
+
+// SaveSubscription inserts sub or replaces the subscription with its ID in place, making
+// Repository a ports.SubscriptionStore shared by every instance.
+func (r *Repository) SaveSubscription(ctx context.Context, sub domain.Subscription) error {
+	types, err := json.Marshal(sub.Types)
+	if err != nil {
+		return fmt.Errorf("encode subscription types: %w", err)
+	}
+	statuses, err := json.Marshal(sub.Statuses)
+	if err != nil {
+		return fmt.Errorf("encode subscription statuses: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind(
+		`INSERT INTO subscriptions (`+subscriptionColumns+`) VALUES (`+placeholders(6)+`)
+		ON CONFLICT (id) DO UPDATE SET url = excluded.url, types = excluded.types,
+			statuses = excluded.statuses, secret = excluded.secret, created_at = excluded.created_at`),
+		sub.ID, sub.URL, string(types), string(statuses), sub.Secret, sub.CreatedAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("save subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetSubscription(ctx context.Context, id string) (domain.Subscription, error) {
+	subs, err := r.querySubscriptions(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions WHERE id = ?`, id)
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+	if len(subs) == 0 {
+		return domain.Subscription{}, domain.ErrSubscriptionNotFound
+	}
+	return subs[0], nil
+}
+
+func (r *Repository) DeleteSubscription(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(`DELETE FROM subscriptions WHERE id = ?`), id)
+	if err != nil {
+		return fmt.Errorf("delete subscription: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// ListSubscriptions returns every subscription in creation order.
+func (r *Repository) ListSubscriptions(ctx context.Context) ([]domain.Subscription, error) {
+	return r.querySubscriptions(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions ORDER BY seq`)
+}
+
+func (r *Repository) querySubscriptions(ctx context.Context, query string, args ...any) ([]domain.Subscription, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query subscriptions: %w", err)
+	}
+	defer rows.Close()
+	subs := []domain.Subscription{}
+	for rows.Next() {
+		var sub domain.Subscription
+		var types, statuses string
+		var createdAt int64
+		if err := rows.Scan(&sub.ID, &sub.URL, &types, &statuses, &sub.Secret, &createdAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(types), &sub.Types); err != nil {
+			return nil, fmt.Errorf("decode subscription types: %w", err)
+		}
+		if err := json.Unmarshal([]byte(statuses), &sub.Statuses); err != nil {
+			return nil, fmt.Errorf("decode subscription statuses: %w", err)
+		}
+		sub.CreatedAt = time.Unix(0, createdAt).UTC()
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// DeliveryLog is a ports.DeliveryLog kept in the repository's database. It is a type of its own
+// because it carries the per-subscription limit.
+type DeliveryLog struct {
+	r     *Repository
+	limit int
+}
+
+var _ ports.DeliveryLog = (*DeliveryLog)(nil)
+
+// Deliveries returns the delivery log sharing r's database, keeping up to limit attempts per
+// subscription; limit <= 0 keeps them all.
+func (r *Repository) Deliveries(limit int) *DeliveryLog {
+	return &DeliveryLog{r: r, limit: limit}
+}
+
+// RecordDelivery inserts d and, in the same transaction, deletes the subscription's attempts
+// beyond the limit.
+func (l *DeliveryLog) RecordDelivery(ctx context.Context, d domain.Delivery) error {
+	delivery, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("encode delivery: %w", err)
+	}
+	return l.r.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, l.r.dialect.rebind(
+			`INSERT INTO deliveries (id, subscription_id, delivery) VALUES (?, ?, ?)`),
+			d.ID, d.SubscriptionID, string(delivery)); err != nil {
+			return fmt.Errorf("insert delivery: %w", err)
+		}
+		if l.limit <= 0 {
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, l.r.dialect.rebind(
+			`DELETE FROM deliveries WHERE subscription_id = ? AND seq <= (
+				SELECT seq FROM deliveries WHERE subscription_id = ? ORDER BY seq DESC LIMIT 1 OFFSET ?)`),
+			d.SubscriptionID, d.SubscriptionID, l.limit); err != nil {
+			return fmt.Errorf("trim deliveries: %w", err)
+		}
+		return nil
+	})
+}
+
+func (l *DeliveryLog) GetDelivery(ctx context.Context, id string) (domain.Delivery, error) {
+	deliveries, err := l.query(ctx, `SELECT delivery FROM deliveries WHERE id = ? ORDER BY seq DESC LIMIT 1`, id)
+	if err != nil {
+		return domain.Delivery{}, err
+	}
+	if len(deliveries) == 0 {
+		return domain.Delivery{}, domain.ErrDeliveryNotFound
+	}
+	return deliveries[0], nil
+}
+
+// ListDeliveries returns the subscription's attempts, oldest first.
+func (l *DeliveryLog) ListDeliveries(ctx context.Context, subscriptionID string) ([]domain.Delivery, error) {
+	return l.query(ctx, `SELECT delivery FROM deliveries WHERE subscription_id = ? ORDER BY seq`, subscriptionID)
+}
+
+func (l *DeliveryLog) query(ctx context.Context, query string, args ...any) ([]domain.Delivery, error) {
+	rows, err := l.r.db.QueryContext(ctx, l.r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query deliveries: %w", err)
+	}
+	defer rows.Close()
+	deliveries := []domain.Delivery{}
+	for rows.Next() {
+		var delivery string
+		if err := rows.Scan(&delivery); err != nil {
+			return nil, err
+		}
+		var d domain.Delivery
+		if err := json.Unmarshal([]byte(delivery), &d); err != nil {
+			return nil, fmt.Errorf("decode delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
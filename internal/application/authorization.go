@@ -0,0 +1,131 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+	"github.com/jailtonjunior/pomelo/internal/logging"
+)
+
+var errAuthorizationsDisabled = errors.New("authorizations are not enabled")
+
+// WithAuthorizations enables ports.AuthorizationUseCase. Approved debits hold their amount on the
+// card for holdTTL or until the transaction's webhook arrives and is matched to the decision.
+func WithAuthorizations(store ports.AuthorizationStore, holdTTL time.Duration) Option {
+	return func(s *Service) {
+		s.authorizations = store
+		s.holdTTL = holdTTL
+	}
+}
+
+// Authorize decides cmd against the card's balance, limits and status and records the decision.
+// Balance checks read the card's ledger net inside the store's critical section: a webhook posts
+// to the ledger before it releases the matching hold, so a concurrent decision may count a
+// purchase twice but never misses it. A request whose deadline passes while it waits for its card
+// is rejected with PROCESSING_TIMEOUT without being decided.
+func (s *Service) Authorize(ctx context.Context, cmd ports.AuthorizeCommand) (ports.AuthorizeResult, error) {
+	if s.authorizations == nil {
+		return ports.AuthorizeResult{}, errAuthorizationsDisabled
+	}
+	amount, err := domain.NewMoney(cmd.Amount, cmd.Currency)
+	if err != nil {
+		return ports.AuthorizeResult{}, err
+	}
+	merchant := domain.Merchant{ID: cmd.MerchantID, MCC: cmd.MerchantMCC, Name: cmd.MerchantName}
+	req, err := domain.NewAuthorizationRequestWithLimits(s.purchaseLimits, cmd.TransactionID, domain.TransactionType(cmd.TransactionType), cmd.CardID, cmd.UserID, merchant, amount)
+	if err != nil {
+		return ports.AuthorizeResult{}, err
+	}
+
+	auth, err := s.authorizations.Decide(ctx, req.ID, req.CardID, func(card domain.Card, history []domain.Authorization) (domain.Authorization, error) {
+		spent, err := s.cardSpent(ctx, card)
+		if err != nil {
+			return domain.Authorization{}, err
+		}
+		auth := domain.Authorize(req, card, spent, history, s.now(), s.holdTTL)
+		// Checked last, under the store's lock: what is recorded is what the caller gets back.
+		if ctx.Err() != nil {
+			auth = auth.TimedOut()
+		}
+		return auth, nil
+	})
+	if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+		// The deadline passed before the card was free: reject without deciding, and record the
+		// rejection so a retry gets the same answer.
+		auth, err = s.authorizations.RecordAuthorization(context.WithoutCancel(ctx), domain.Authorization{Request: req, DecidedAt: s.now()}.TimedOut())
+	}
+	if errors.Is(err, domain.ErrDuplicateAuthorization) {
+		return ports.AuthorizeResult{Authorization: auth, Idempotent: true}, err
+	}
+	if err != nil {
+		return ports.AuthorizeResult{}, err
+	}
+	if auth.Decision != domain.StatusApproved {
+		logging.FromContext(ctx).Info("authorization declined", "transaction_id", req.ID, "card_id", req.CardID, "reason", auth.Reason)
+	}
+	return ports.AuthorizeResult{Authorization: auth}, nil
+}
+
+func (s *Service) GetAuthorization(ctx context.Context, id string) (domain.Authorization, error) {
+	if s.authorizations == nil {
+		return domain.Authorization{}, domain.ErrAuthorizationNotFound
+	}
+	return s.authorizations.GetAuthorization(ctx, id)
+}
+
+func (s *Service) SaveCard(ctx context.Context, cmd ports.SaveCardCommand) (domain.Card, error) {
+	if s.authorizations == nil {
+		return domain.Card{}, errAuthorizationsDisabled
+	}
+	card, err := domain.NewCard(cmd.CardID, cmd.UserID, domain.CardStatus(cmd.Status), cmd.Currency, cmd.Balance, cmd.TransactionLimit, cmd.DailyLimit)
+	if err != nil {
+		return domain.Card{}, err
+	}
+	if err := s.authorizations.SaveCard(ctx, card); err != nil {
+		return domain.Card{}, err
+	}
+	return card, nil
+}
+
+func (s *Service) GetCard(ctx context.Context, id string) (domain.Card, error) {
+	if s.authorizations == nil {
+		return domain.Card{}, domain.ErrCardNotFound
+	}
+	return s.authorizations.GetCard(ctx, id)
+}
+
+// cardSpent is the card's ledger net in its own currency: approved debits minus credits.
+func (s *Service) cardSpent(ctx context.Context, card domain.Card) (int64, error) {
+	if s.ledger == nil || card.ID == "" {
+		return 0, nil
+	}
+	balances, err := s.ledger.BalancesByCard(ctx, card.ID)
+	if err != nil {
+		return 0, err
+	}
+	for _, b := range balances {
+		if b.Currency == card.Currency {
+			return b.Net, nil
+		}
+	}
+	return 0, nil
+}
+
+// matchAuthorization records tx against the authorization decided for it, if any. It runs after
+// tx is posted to the ledger, which releases the hold.
+func (s *Service) matchAuthorization(ctx context.Context, tx domain.Transaction) error {
+	if s.authorizations == nil {
+		return nil
+	}
+	now := s.now()
+	_, err := s.authorizations.Match(ctx, tx.ID, func(a domain.Authorization) domain.Authorization {
+		return a.MatchWebhook(tx, now)
+	})
+	if errors.Is(err, domain.ErrAuthorizationNotFound) {
+		return nil
+	}
+	return err
+}
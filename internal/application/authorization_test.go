@@ -0,0 +1,195 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func makeAuthorizeCmd(id string, amount int64) ports.AuthorizeCommand {
+	return ports.AuthorizeCommand{
+		TransactionID:   id,
+		TransactionType: "PURCHASE",
+		Amount:          amount,
+		Currency:        "BRL",
+		CardID:          "card1",
+		UserID:          "u1",
+		MerchantID:      "m1",
+	}
+}
+
+func newAuthorizingService(t *testing.T, balance, dailyLimit int64, opts ...Option) *Service {
+	t.Helper()
+	opts = append([]Option{WithLedger(memory.NewLedgerStore()), WithAuthorizations(memory.NewAuthorizationStore(), time.Hour)}, opts...)
+	svc := NewService(memory.NewRepository(), opts...)
+	_, err := svc.SaveCard(context.Background(), ports.SaveCardCommand{
+		CardID: "card1", UserID: "u1", Status: "ACTIVE", Currency: "BRL", Balance: balance, DailyLimit: dailyLimit,
+	})
+	if err != nil {
+		t.Fatalf("save card: %v", err)
+	}
+	return svc
+}
+
+func TestAuthorizeHoldsUntilWebhookMatches(t *testing.T) {
+	svc := newAuthorizingService(t, 10000, 0)
+	ctx := context.Background()
+
+	res, err := svc.Authorize(ctx, makeAuthorizeCmd("tx1", 6000))
+	if err != nil || res.Authorization.Decision != domain.StatusApproved {
+		t.Fatalf("expected approval, got %+v (%v)", res.Authorization, err)
+	}
+	if res, _ := svc.Authorize(ctx, makeAuthorizeCmd("tx2", 6000)); res.Authorization.Reason != domain.ReasonInsufficientBalance {
+		t.Errorf("expected the hold to block tx2, got %s", res.Authorization.Reason)
+	}
+
+	// The webhook debits the ledger and releases the hold: the available balance is unchanged.
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 6000))
+	auth, _ := svc.GetAuthorization(ctx, "tx1")
+	if auth.Match == nil || len(auth.Match.Discrepancies) != 0 {
+		t.Errorf("expected a clean match, got %+v", auth.Match)
+	}
+	if res, _ := svc.Authorize(ctx, makeAuthorizeCmd("tx3", 4000)); res.Authorization.Decision != domain.StatusApproved {
+		t.Errorf("expected tx3 to fit the remaining balance, got %s", res.Authorization.Reason)
+	}
+	if res, _ := svc.Authorize(ctx, makeAuthorizeCmd("tx4", 100)); res.Authorization.Reason != domain.ReasonInsufficientBalance {
+		t.Errorf("expected the balance to be exhausted, got %s", res.Authorization.Reason)
+	}
+}
+
+func TestAuthorizeRefundRestoresBalance(t *testing.T) {
+	svc := newAuthorizingService(t, 10000, 0)
+	ctx := context.Background()
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 10000))
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 3000))
+
+	if res, _ := svc.Authorize(ctx, makeAuthorizeCmd("tx2", 3000)); res.Authorization.Decision != domain.StatusApproved {
+		t.Errorf("expected refunded amount to be available, got %s", res.Authorization.Reason)
+	}
+}
+
+func TestAuthorizeDuplicateReturnsRecordedDecision(t *testing.T) {
+	svc := newAuthorizingService(t, 10000, 0)
+	ctx := context.Background()
+	first, _ := svc.Authorize(ctx, makeAuthorizeCmd("tx1", 8000))
+	again, err := svc.Authorize(ctx, makeAuthorizeCmd("tx1", 8000))
+	if !errors.Is(err, domain.ErrDuplicateAuthorization) || !again.Idempotent {
+		t.Fatalf("expected idempotent duplicate, got %+v (%v)", again, err)
+	}
+	if again.Authorization.Decision != first.Authorization.Decision || !again.Authorization.DecidedAt.Equal(first.Authorization.DecidedAt) {
+		t.Errorf("expected the recorded decision, got %+v", again.Authorization)
+	}
+}
+
+func TestAuthorizeDailyLimitAndBlockedCard(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC)}
+	svc := newAuthorizingService(t, 100000, 5000, WithClock(clock.now))
+	ctx := context.Background()
+	svc.Authorize(ctx, makeAuthorizeCmd("tx1", 4000))
+	if res, _ := svc.Authorize(ctx, makeAuthorizeCmd("tx2", 2000)); res.Authorization.Reason != domain.ReasonDailyLimit {
+		t.Errorf("expected daily limit, got %s", res.Authorization.Reason)
+	}
+	clock.t = clock.t.Add(3 * time.Hour)
+	if res, _ := svc.Authorize(ctx, makeAuthorizeCmd("tx3", 2000)); res.Authorization.Decision != domain.StatusApproved {
+		t.Errorf("expected the limit to reset the next day, got %s", res.Authorization.Reason)
+	}
+
+	svc.SaveCard(ctx, ports.SaveCardCommand{CardID: "card1", UserID: "u1", Status: "BLOCKED", Currency: "BRL", Balance: 100000})
+	if res, _ := svc.Authorize(ctx, makeAuthorizeCmd("tx4", 1000)); res.Authorization.Reason != domain.ReasonCardBlocked {
+		t.Errorf("expected blocked card, got %s", res.Authorization.Reason)
+	}
+}
+
+func TestAuthorizeInvalidRequestIsNotRecorded(t *testing.T) {
+	svc := newAuthorizingService(t, 10000, 0)
+	ctx := context.Background()
+	cmd := makeAuthorizeCmd("tx1", 50)
+	if _, err := svc.Authorize(ctx, cmd); !errors.Is(err, domain.ErrAmountOutOfRange) {
+		t.Errorf("expected ErrAmountOutOfRange, got %v", err)
+	}
+	if _, err := svc.GetAuthorization(ctx, "tx1"); !errors.Is(err, domain.ErrAuthorizationNotFound) {
+		t.Errorf("expected no recorded decision, got %v", err)
+	}
+}
+
+func TestAuthorizeMissedDeadlineIsRejected(t *testing.T) {
+	svc := newAuthorizingService(t, 10000, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	res, err := svc.Authorize(ctx, makeAuthorizeCmd("tx1", 1000))
+	if err != nil || res.Authorization.Reason != domain.ReasonProcessingTimeout {
+		t.Fatalf("expected PROCESSING_TIMEOUT, got %+v (%v)", res.Authorization, err)
+	}
+	if auth, _ := svc.GetAuthorization(context.Background(), "tx1"); auth.Decision != domain.StatusRejected {
+		t.Errorf("expected the rejection to be recorded, got %s", auth.Decision)
+	}
+}
+
+func TestAuthorizeConcurrentNeverOverspends(t *testing.T) {
+	svc := newAuthorizingService(t, 10000, 0)
+	ctx := context.Background()
+	var approved atomic.Int64
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Go(func() {
+			res, _ := svc.Authorize(ctx, makeAuthorizeCmd(fmt.Sprintf("tx%d", i), 1000))
+			if res.Authorization.Decision == domain.StatusApproved {
+				approved.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+	if approved.Load() != 10 {
+		t.Errorf("expected exactly 10 approvals of 1000 out of 10000, got %d", approved.Load())
+	}
+}
+
+func TestAuthorizationsDisabled(t *testing.T) {
+	svc := NewService(newMockRepo())
+	if _, err := svc.Authorize(context.Background(), makeAuthorizeCmd("tx1", 1000)); err == nil {
+		t.Error("expected an error without an authorization store")
+	}
+	if _, err := svc.GetAuthorization(context.Background(), "tx1"); !errors.Is(err, domain.ErrAuthorizationNotFound) {
+		t.Errorf("expected ErrAuthorizationNotFound, got %v", err)
+	}
+}
+
+func TestAuthorizeDeadlinePassesWhileCardIsBusy(t *testing.T) {
+	store := memory.NewAuthorizationStore()
+	svc := NewService(memory.NewRepository(), WithLedger(memory.NewLedgerStore()), WithAuthorizations(store, time.Hour))
+	svc.SaveCard(context.Background(), ports.SaveCardCommand{CardID: "card1", UserID: "u1", Status: "ACTIVE", Currency: "BRL", Balance: 10000})
+
+	// Another decision holds card1 well past the request's deadline.
+	started, release := make(chan struct{}), make(chan struct{})
+	go store.Decide(context.Background(), "tx0", "card1", func(domain.Card, []domain.Authorization) (domain.Authorization, error) {
+		close(started)
+		<-release
+		return domain.Authorization{}, errors.New("abandoned")
+	})
+	<-started
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	res, err := svc.Authorize(ctx, makeAuthorizeCmd("tx1", 1000))
+	if err != nil || res.Authorization.Reason != domain.ReasonProcessingTimeout {
+		t.Fatalf("expected PROCESSING_TIMEOUT, got %+v (%v)", res.Authorization, err)
+	}
+	if waited := time.Since(begin); waited > time.Second {
+		t.Errorf("expected Authorize to return at its deadline, waited %v", waited)
+	}
+	if auth, _ := svc.GetAuthorization(context.Background(), "tx1"); auth.Reason != domain.ReasonProcessingTimeout {
+		t.Errorf("expected the timeout recorded for retries, got %+v", auth)
+	}
+}
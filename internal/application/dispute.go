@@ -0,0 +1,125 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// WithDisputes keeps disputes in store, enabling the ports.DisputeUseCase methods. Disputed
+// amounts then count against a purchase's budget for reversals and refunds too.
+func WithDisputes(store ports.DisputeStore) Option {
+	return func(s *Service) { s.disputes = store }
+}
+
+// OpenDispute validates and saves under the purchase's budget lock, the one appendAdjustment
+// takes, so neither another dispute nor a reversal or refund can pass against a stale total.
+func (s *Service) OpenDispute(ctx context.Context, cmd ports.OpenDisputeCommand) (domain.Dispute, error) {
+	if s.disputes == nil {
+		return domain.Dispute{}, fmt.Errorf("%w: disputes are not enabled", domain.ErrInvalidInput)
+	}
+	purchase, err := s.repo.GetTransactionByID(ctx, cmd.TransactionID)
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+	currency := cmd.Currency
+	if currency == "" {
+		currency = purchase.Amount.Local.Currency
+	}
+	amount, err := domain.NewMoney(cmd.Amount, currency)
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+
+	defer s.budgets.Lock(purchase.ID)()
+	adjs, err := s.repo.GetAdjustmentsByTransactionID(ctx, purchase.ID)
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+	committed, err := s.sumExistingAdjustments(adjs, purchase.Amount.Local.Currency)
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+	disputed, err := s.sumDisputed(ctx, purchase.ID, purchase.Amount.Local.Currency)
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+	committed.Amount += disputed.Amount
+	d, err := domain.OpenDispute(newID("dsp"), purchase, amount, cmd.Reason, committed, s.now())
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+	if err := s.disputes.SaveDispute(ctx, d); err != nil {
+		return domain.Dispute{}, err
+	}
+	return d, s.postDispute(ctx, d)
+}
+
+func (s *Service) TransitionDispute(ctx context.Context, cmd ports.TransitionDisputeCommand) (domain.Dispute, error) {
+	state := domain.DisputeState(cmd.State)
+	if !state.Valid() {
+		return domain.Dispute{}, fmt.Errorf("%w: unknown dispute state %q", domain.ErrInvalidInput, cmd.State)
+	}
+	s.disputeMu.Lock()
+	defer s.disputeMu.Unlock()
+	d, err := s.GetDispute(ctx, cmd.DisputeID)
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+	if d, err = d.Transition(state, cmd.Note, s.now()); err != nil {
+		return domain.Dispute{}, err
+	}
+	if err := s.disputes.UpdateDispute(ctx, d); err != nil {
+		return domain.Dispute{}, err
+	}
+	return d, s.postDispute(ctx, d)
+}
+
+func (s *Service) GetDispute(ctx context.Context, id string) (domain.Dispute, error) {
+	if s.disputes == nil {
+		return domain.Dispute{}, domain.ErrDisputeNotFound
+	}
+	return s.disputes.GetDispute(ctx, id)
+}
+
+func (s *Service) ListDisputes(ctx context.Context, transactionID string) ([]domain.Dispute, error) {
+	if _, err := s.repo.GetTransactionByID(ctx, transactionID); err != nil {
+		return nil, err
+	}
+	if s.disputes == nil {
+		return []domain.Dispute{}, nil
+	}
+	return s.disputes.ListDisputes(ctx, transactionID)
+}
+
+// sumDisputed totals the amounts still held by the disputes of transactionID. Disputes are in the
+// purchase's local currency; for any other currency the total is zero, and the mismatch is left to
+// the caller's own validation.
+func (s *Service) sumDisputed(ctx context.Context, transactionID, currency string) (domain.Money, error) {
+	total := domain.Money{Currency: currency}
+	if s.disputes == nil {
+		return total, nil
+	}
+	disputes, err := s.disputes.ListDisputes(ctx, transactionID)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	for _, d := range disputes {
+		if d.HoldsAmount() && d.Amount.Currency == currency {
+			total.Amount += d.Amount.Amount
+		}
+	}
+	return total, nil
+}
+
+// postDispute posts whatever the dispute's state implies that is not in the ledger yet.
+func (s *Service) postDispute(ctx context.Context, d domain.Dispute) error {
+	for _, entries := range domain.PostDispute(d) {
+		if err := s.post(ctx, entries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func newDisputeService(t *testing.T) (*Service, *memory.LedgerStore) {
+	t.Helper()
+	ledger := memory.NewLedgerStore()
+	svc := NewService(memory.NewRepository(), WithLedger(ledger), WithDisputes(memory.NewDisputeStore()))
+	if _, err := svc.ProcessTransaction(context.Background(), makePurchaseCmd("tx1", "APPROVED", "idem1", 1000)); err != nil {
+		t.Fatalf("purchase: %v", err)
+	}
+	return svc, ledger
+}
+
+func cardNet(t *testing.T, svc *Service) int64 {
+	t.Helper()
+	l, err := svc.GetCardLedger(context.Background(), "card1")
+	if err != nil || len(l.Balances) != 1 {
+		t.Fatalf("card ledger: %+v (err %v)", l, err)
+	}
+	return l.Balances[0].Net
+}
+
+func TestOpenDisputePostsProvisionalCredit(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newDisputeService(t)
+
+	d, err := svc.OpenDispute(ctx, ports.OpenDisputeCommand{TransactionID: "tx1", Amount: 400, Reason: "item not received"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.State != domain.DisputeOpened || d.Amount.Currency != "BRL" || d.CardID != "card1" {
+		t.Errorf("unexpected dispute %+v", d)
+	}
+	if net := cardNet(t, svc); net != 600 {
+		t.Errorf("expected the card net of the provisional credit (600), got %d", net)
+	}
+	if _, err := svc.VerifyLedger(ctx); err != nil {
+		t.Errorf("ledger unbalanced: %v", err)
+	}
+	summary, _ := svc.GetTransactionSummary(ctx, "tx1")
+	if summary.Balance.Remaining.Amount != 600 || summary.Balance.TotalDisputed.Amount != 400 {
+		t.Errorf("expected 400 disputed and 600 remaining, got %+v", summary.Balance)
+	}
+	list, err := svc.ListDisputes(ctx, "tx1")
+	if err != nil || len(list) != 1 || list[0].ID != d.ID {
+		t.Errorf("expected the dispute listed, got %+v (err %v)", list, err)
+	}
+}
+
+func TestDisputesShareThePurchaseBudget(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newDisputeService(t)
+	svc.ProcessTransaction(ctx, makeAdjustCmd("ref1", "REFUND", "APPROVED", "tx1", "idem-ref1", 600))
+
+	if _, err := svc.OpenDispute(ctx, ports.OpenDisputeCommand{TransactionID: "tx1", Amount: 500, Reason: "fraud"}); !errors.Is(err, domain.ErrExceedsOriginalAmount) {
+		t.Fatalf("expected the refund to count against the dispute, got %v", err)
+	}
+	d, err := svc.OpenDispute(ctx, ports.OpenDisputeCommand{TransactionID: "tx1", Amount: 400, Reason: "fraud"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.ProcessTransaction(ctx, makeAdjustCmd("ref2", "REFUND", "APPROVED", "tx1", "idem-ref2", 100)); !errors.Is(err, domain.ErrExceedsOriginalAmount) {
+		t.Fatalf("expected the open dispute to count against the refund, got %v", err)
+	}
+
+	// A cancelled dispute gives its amount back to the budget.
+	if _, err := svc.TransitionDispute(ctx, ports.TransitionDisputeCommand{DisputeID: d.ID, State: "CANCELLED", Note: "refund agreed"}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if _, err := svc.ProcessTransaction(ctx, makeAdjustCmd("ref2", "REFUND", "APPROVED", "tx1", "idem-ref2", 100)); err != nil {
+		t.Errorf("expected the refund accepted after the cancellation, got %v", err)
+	}
+}
+
+func TestDisputeResolutionPostings(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		state       string
+		cardNet     int64
+		merchantNet int64
+	}{
+		{"WON", 600, -600},
+		{"LOST", 1000, -1000},
+		{"CANCELLED", 1000, -1000},
+	}
+	for _, tc := range cases {
+		t.Run(tc.state, func(t *testing.T) {
+			svc, ledger := newDisputeService(t)
+			d, _ := svc.OpenDispute(ctx, ports.OpenDisputeCommand{TransactionID: "tx1", Amount: 400, Reason: "fraud"})
+			if _, err := svc.TransitionDispute(ctx, ports.TransitionDisputeCommand{DisputeID: d.ID, State: "EVIDENCE_SUBMITTED"}); err != nil {
+				t.Fatalf("evidence: %v", err)
+			}
+			resolved, err := svc.TransitionDispute(ctx, ports.TransitionDisputeCommand{DisputeID: d.ID, State: tc.state})
+			if err != nil || resolved.ResolvedAt.IsZero() || len(resolved.History) != 3 {
+				t.Fatalf("unexpected resolution %+v (err %v)", resolved, err)
+			}
+			if net := cardNet(t, svc); net != tc.cardNet {
+				t.Errorf("expected card net %d, got %d", tc.cardNet, net)
+			}
+			entries, _ := ledger.Entries(ctx)
+			var suspense, merchant int64
+			for _, e := range entries {
+				b := domain.AccountBalance{}.Apply(e)
+				switch e.Account {
+				case domain.DisputeSuspenseAccount:
+					suspense += b.Net
+				case domain.MerchantAccount("m1"):
+					merchant += b.Net
+				}
+			}
+			if suspense != 0 {
+				t.Errorf("expected the suspense account settled, got %d", suspense)
+			}
+			if merchant != tc.merchantNet {
+				t.Errorf("expected merchant net %d, got %d", tc.merchantNet, merchant)
+			}
+			if _, err := svc.VerifyLedger(ctx); err != nil {
+				t.Errorf("ledger unbalanced: %v", err)
+			}
+		})
+	}
+}
+
+func TestRebuildLedgerIncludesDisputes(t *testing.T) {
+	ctx := context.Background()
+	repo, disputes := memory.NewRepository(), memory.NewDisputeStore()
+	svc := NewService(repo, WithLedger(memory.NewLedgerStore()), WithDisputes(disputes))
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	d, _ := svc.OpenDispute(ctx, ports.OpenDisputeCommand{TransactionID: "tx1", Amount: 400, Reason: "fraud"})
+	svc.TransitionDispute(ctx, ports.TransitionDisputeCommand{DisputeID: d.ID, State: "WON"})
+
+	restarted := NewService(repo, WithLedger(memory.NewLedgerStore()), WithDisputes(disputes))
+	posted, err := restarted.RebuildLedger(ctx)
+	if err != nil || posted != 3 {
+		t.Fatalf("expected the purchase, the credit and the chargeback, got %d (err %v)", posted, err)
+	}
+	if net := cardNet(t, restarted); net != 600 {
+		t.Errorf("expected card net 600 after the rebuild, got %d", net)
+	}
+}
+
+func TestDisputeErrors(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newDisputeService(t)
+	d, _ := svc.OpenDispute(ctx, ports.OpenDisputeCommand{TransactionID: "tx1", Amount: 400, Reason: "fraud"})
+	svc.TransitionDispute(ctx, ports.TransitionDisputeCommand{DisputeID: d.ID, State: "LOST"})
+
+	cases := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"unknown purchase", second(svc.OpenDispute(ctx, ports.OpenDisputeCommand{TransactionID: "nope", Amount: 100, Reason: "fraud"})), domain.ErrTransactionNotFound},
+		{"invalid currency", second(svc.OpenDispute(ctx, ports.OpenDisputeCommand{TransactionID: "tx1", Amount: 100, Currency: "XXX1", Reason: "fraud"})), domain.ErrInvalidCurrency},
+		{"unknown state", second(svc.TransitionDispute(ctx, ports.TransitionDisputeCommand{DisputeID: d.ID, State: "APPEALED"})), domain.ErrInvalidInput},
+		{"final state", second(svc.TransitionDispute(ctx, ports.TransitionDisputeCommand{DisputeID: d.ID, State: "WON"})), domain.ErrInvalidDisputeTransition},
+		{"unknown dispute", second(svc.TransitionDispute(ctx, ports.TransitionDisputeCommand{DisputeID: "nope", State: "WON"})), domain.ErrDisputeNotFound},
+		{"unknown transaction list", second(svc.ListDisputes(ctx, "nope")), domain.ErrTransactionNotFound},
+		{"disabled", second(NewService(newMockRepo()).OpenDispute(ctx, ports.OpenDisputeCommand{TransactionID: "tx1"})), domain.ErrInvalidInput},
+	}
+	for _, tc := range cases {
+		if !errors.Is(tc.err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, tc.err)
+		}
+	}
+}
+
+func second[T any](_ T, err error) error { return err }
+
+// slowDisputeStore widens the window between reading the disputed total and saving.
+type slowDisputeStore struct{ *memory.DisputeStore }
+
+func (s slowDisputeStore) ListDisputes(ctx context.Context, transactionID string) ([]domain.Dispute, error) {
+	disputes, err := s.DisputeStore.ListDisputes(ctx, transactionID)
+	time.Sleep(time.Millisecond)
+	return disputes, err
+}
+
+// TestConcurrentDisputeAndRefundNeverExceedPurchase races a dispute against a refund that do not
+// both fit in the purchase and checks exactly one of them is accepted every time.
+func TestConcurrentDisputeAndRefundNeverExceedPurchase(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(memory.NewRepository(), WithDisputes(slowDisputeStore{memory.NewDisputeStore()}))
+	const rounds = 50
+
+	for i := range rounds {
+		txID := fmt.Sprintf("tx%d", i)
+		if _, err := svc.ProcessTransaction(ctx, makePurchaseCmd(txID, "APPROVED", "idem-"+txID, 1000)); err != nil {
+			t.Fatalf("purchase: %v", err)
+		}
+		var wg sync.WaitGroup
+		var accepted atomic.Int64
+		check := func(err error) {
+			switch {
+			case err == nil:
+				accepted.Add(1)
+			case !errors.Is(err, domain.ErrExceedsOriginalAmount):
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+		wg.Go(func() {
+			_, err := svc.OpenDispute(ctx, ports.OpenDisputeCommand{TransactionID: txID, Amount: 600, Reason: "fraud"})
+			check(err)
+		})
+		wg.Go(func() {
+			refID := "ref-" + txID
+			_, err := svc.ProcessTransaction(ctx, makeAdjustCmd(refID, "REFUND", "APPROVED", txID, "idem-"+refID, 600))
+			check(err)
+		})
+		wg.Wait()
+		if accepted.Load() != 1 {
+			t.Fatalf("round %d: expected exactly one of the dispute and the refund accepted, got %d", i, accepted.Load())
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package application
+
+import (
+	"context"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// WithAttemptLog appends every webhook attempt to log, enabling the ports.HistoryUseCase methods.
+func WithAttemptLog(log ports.AttemptLog) Option {
+	return func(s *Service) { s.attempts = log }
+}
+
+func (s *Service) RecordAttempt(ctx context.Context, cmd ports.RecordAttemptCommand) error {
+	if s.attempts == nil {
+		return nil
+	}
+	a, err := domain.NewWebhookAttempt(newID("att"), cmd.TransactionID, cmd.IdempotencyKey, cmd.ReceivedAt, cmd.Outcome, cmd.StatusCode, cmd.Payload)
+	if err != nil {
+		return err
+	}
+	return s.attempts.AppendAttempt(ctx, a)
+}
+
+func (s *Service) ListTransactionEvents(ctx context.Context, transactionID string) ([]domain.WebhookAttempt, error) {
+	attempts := []domain.WebhookAttempt{}
+	if s.attempts != nil {
+		var err error
+		if attempts, err = s.attempts.ListAttempts(ctx, transactionID); err != nil {
+			return nil, err
+		}
+	}
+	if len(attempts) > 0 {
+		return attempts, nil
+	}
+	// A transaction stored before the log existed has an empty history rather than none.
+	if _, err := s.repo.GetTransactionByID(ctx, transactionID); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
@@ -0,0 +1,64 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func TestRecordAttemptAndListEvents(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(newMockRepo(), WithAttemptLog(memory.NewAttemptLog()))
+	receivedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	for i, outcome := range []string{domain.OutcomeProcessed, domain.OutcomeIdempotent} {
+		err := svc.RecordAttempt(ctx, ports.RecordAttemptCommand{
+			TransactionID: "tx1", IdempotencyKey: "idem1", ReceivedAt: receivedAt.Add(time.Duration(i) * time.Second),
+			Outcome: outcome, StatusCode: 200, Payload: []byte(`{"id":"tx1"}`),
+		})
+		if err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+
+	events, err := svc.ListTransactionEvents(ctx, "tx1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 2 || events[0].Outcome != domain.OutcomeProcessed || events[1].Outcome != domain.OutcomeIdempotent {
+		t.Fatalf("expected both attempts in order, got %+v", events)
+	}
+	if events[0].ID == "" || events[0].ID == events[1].ID {
+		t.Errorf("expected distinct attempt IDs, got %q and %q", events[0].ID, events[1].ID)
+	}
+	if _, err := svc.ListTransactionEvents(ctx, "nope"); !errors.Is(err, domain.ErrTransactionNotFound) {
+		t.Errorf("expected ErrTransactionNotFound, got %v", err)
+	}
+}
+
+func TestListEventsOfTransactionStoredBeforeTheLog(t *testing.T) {
+	ctx := context.Background()
+	repo := newMockRepo()
+	NewService(repo).ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+
+	for name, svc := range map[string]*Service{
+		"empty log": NewService(repo, WithAttemptLog(memory.NewAttemptLog())),
+		"no log":    NewService(repo),
+	} {
+		events, err := svc.ListTransactionEvents(ctx, "tx1")
+		if err != nil || events == nil || len(events) != 0 {
+			t.Errorf("%s: expected an empty history, got %v (err %v)", name, events, err)
+		}
+	}
+}
+
+func TestRecordAttemptDisabled(t *testing.T) {
+	svc := NewService(newMockRepo())
+	if err := svc.RecordAttempt(context.Background(), ports.RecordAttemptCommand{TransactionID: "tx1", Outcome: domain.OutcomeProcessed}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// DefaultIdempotencyRetention is how long a response is replayed to retries by default.
+const DefaultIdempotencyRetention = 24 * time.Hour
+
+// WithIdempotencyRecords keeps the response to every request in store for retention, enabling the
+// ports.IdempotencyUseCase methods. Without it retries are recognized only through the
+// repository's idempotency keys.
+func WithIdempotencyRecords(store ports.IdempotencyStore, retention time.Duration) Option {
+	return func(s *Service) {
+		s.idempotency = store
+		s.idempotencyRetention = retention
+	}
+}
+
+// ClaimKey records the payload before processing, whatever the response turns out to be, so a
+// different payload under the key is refused even when no response is kept for it, and the
+// first of two concurrent deliveries decides which payload the key belongs to.
+func (s *Service) ClaimKey(ctx context.Context, key, payloadHash string) (domain.IdempotencyRecord, bool, error) {
+	if s.idempotency == nil {
+		return domain.IdempotencyRecord{}, false, nil
+	}
+	claim, err := domain.NewIdempotencyRecord(key, payloadHash, 0, nil, s.now(), s.idempotencyRetention)
+	if err != nil {
+		return domain.IdempotencyRecord{}, false, err
+	}
+	rec, err := s.idempotency.ClaimIdempotencyKey(ctx, claim)
+	if err != nil {
+		return domain.IdempotencyRecord{}, false, err
+	}
+	if err := rec.Match(payloadHash); err != nil {
+		return domain.IdempotencyRecord{}, false, err
+	}
+	return rec, rec.HasResponse(), nil
+}
+
+func (s *Service) RecordResponse(ctx context.Context, key, payloadHash string, statusCode int, body []byte) error {
+	if s.idempotency == nil {
+		return nil
+	}
+	rec, err := domain.NewIdempotencyRecord(key, payloadHash, statusCode, body, s.now(), s.idempotencyRetention)
+	if err != nil {
+		return err
+	}
+	return s.idempotency.SaveIdempotencyRecord(ctx, rec)
+}
@@ -0,0 +1,66 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func TestIdempotencyRecords(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	svc := NewService(newMockRepo(), WithIdempotencyRecords(memory.NewIdempotencyStore(), time.Hour), WithClock(clock.now))
+
+	if err := svc.RecordResponse(ctx, "idem1", "hash", 200, []byte(`{"transaction_id":"tx1"}`)); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	rec, ok, err := svc.ClaimKey(ctx, "idem1", "hash")
+	if err != nil || !ok || rec.StatusCode != 200 || string(rec.Body) != `{"transaction_id":"tx1"}` {
+		t.Errorf("expected the recorded response, got %+v (ok %v, err %v)", rec, ok, err)
+	}
+	if _, _, err := svc.ClaimKey(ctx, "idem1", "other"); !errors.Is(err, domain.ErrIdempotencyKeyReused) {
+		t.Errorf("expected ErrIdempotencyKeyReused, got %v", err)
+	}
+
+	clock.t = clock.t.Add(time.Hour)
+	if _, ok, err := svc.ClaimKey(ctx, "idem1", "other"); ok || err != nil {
+		t.Errorf("expected an expired record to be ignored, got ok %v err %v", ok, err)
+	}
+}
+
+func TestIdempotencyRecordsDisabled(t *testing.T) {
+	svc := NewService(newMockRepo())
+	if err := svc.RecordResponse(context.Background(), "idem1", "hash", 200, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, ok, _ := svc.ClaimKey(context.Background(), "idem1", "hash"); ok {
+		t.Error("expected no records without a store")
+	}
+}
+
+func TestClaimKeyBeforeAnyResponse(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(newMockRepo(), WithIdempotencyRecords(memory.NewIdempotencyStore(), time.Hour))
+
+	// Two first deliveries race: the first claim decides which payload owns the key, even though
+	// neither has been answered yet.
+	if _, ok, err := svc.ClaimKey(ctx, "idem1", "hash"); ok || err != nil {
+		t.Fatalf("expected a fresh claim, got ok %v err %v", ok, err)
+	}
+	if _, _, err := svc.ClaimKey(ctx, "idem1", "other"); !errors.Is(err, domain.ErrIdempotencyKeyReused) {
+		t.Fatalf("expected ErrIdempotencyKeyReused, got %v", err)
+	}
+	if _, ok, err := svc.ClaimKey(ctx, "idem1", "hash"); ok || err != nil {
+		t.Errorf("expected the same payload processed, got ok %v err %v", ok, err)
+	}
+
+	// The response fills the claim in and is replayed from then on.
+	svc.RecordResponse(ctx, "idem1", "hash", 200, []byte(`{"transaction_id":"tx1"}`))
+	if rec, ok, err := svc.ClaimKey(ctx, "idem1", "hash"); !ok || err != nil || rec.StatusCode != 200 {
+		t.Errorf("expected the response replayed, got %+v (ok %v, err %v)", rec, ok, err)
+	}
+}
@@ -0,0 +1,117 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// WithLedger posts every approved transaction and adjustment to store as balanced debit/credit
+// entries, and enables the ports.LedgerUseCase queries.
+func WithLedger(store ports.LedgerStore) Option {
+	return func(s *Service) { s.ledger = store }
+}
+
+func (s *Service) GetUserBalance(ctx context.Context, userID string) ([]domain.AccountBalance, error) {
+	if s.ledger == nil {
+		return []domain.AccountBalance{}, nil
+	}
+	return s.ledger.BalancesByUser(ctx, userID)
+}
+
+func (s *Service) GetCardLedger(ctx context.Context, cardID string) (ports.CardLedger, error) {
+	out := ports.CardLedger{CardID: cardID, Entries: []domain.LedgerEntry{}, Balances: []domain.AccountBalance{}}
+	if s.ledger == nil {
+		return out, nil
+	}
+	var err error
+	if out.Entries, err = s.ledger.EntriesByCard(ctx, cardID); err != nil {
+		return ports.CardLedger{}, err
+	}
+	if out.Balances, err = s.ledger.BalancesByCard(ctx, cardID); err != nil {
+		return ports.CardLedger{}, err
+	}
+	return out, nil
+}
+
+// VerifyLedger sums every entry and checks the ledger nets to zero in each currency.
+func (s *Service) VerifyLedger(ctx context.Context) ([]domain.AccountBalance, error) {
+	if s.ledger == nil {
+		return []domain.AccountBalance{}, nil
+	}
+	entries, err := s.ledger.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return domain.VerifyBalanced(entries)
+}
+
+// RebuildLedger posts every stored transaction, adjustment and dispute posting that is not in the
+// ledger yet, in event order, and returns how many postings it added. The ledger is a projection
+// of the repository and the dispute store: running it at startup restores an in-memory ledger and
+// repairs postings lost between a save and its Post.
+func (s *Service) RebuildLedger(ctx context.Context) (int, error) {
+	if s.ledger == nil {
+		return 0, nil
+	}
+	page, err := s.repo.ListTransactions(ctx, ports.TransactionQuery{SortBy: ports.SortByCreatedAt})
+	if err != nil {
+		return 0, err
+	}
+	var postings [][]domain.LedgerEntry
+	for _, tx := range page.Transactions {
+		if entries := domain.PostTransaction(tx); entries != nil {
+			postings = append(postings, entries)
+		}
+		adjs, err := s.repo.GetAdjustmentsByTransactionID(ctx, tx.ID)
+		if err != nil {
+			return 0, err
+		}
+		for _, adj := range adjs {
+			if entries := domain.PostAdjustment(adj); entries != nil {
+				postings = append(postings, entries)
+			}
+		}
+	}
+	if s.disputes != nil {
+		disputes, err := s.disputes.AllDisputes(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, d := range disputes {
+			postings = append(postings, domain.PostDispute(d)...)
+		}
+	}
+	// Stable, so an adjustment sharing its purchase's timestamp still follows it.
+	slices.SortStableFunc(postings, func(a, b []domain.LedgerEntry) int {
+		return a[0].PostedAt.Compare(b[0].PostedAt)
+	})
+
+	added := 0
+	for _, entries := range postings {
+		err := s.ledger.Post(ctx, entries)
+		switch {
+		case err == nil:
+			added++
+		case !errors.Is(err, domain.ErrDuplicatePosting):
+			return added, fmt.Errorf("post %s to ledger: %w", entries[0].PostingID, err)
+		}
+	}
+	return added, nil
+}
+
+// post records entries in the ledger. A posting already recorded, e.g. by RebuildLedger, is not
+// an error.
+func (s *Service) post(ctx context.Context, entries []domain.LedgerEntry) error {
+	if s.ledger == nil || len(entries) == 0 {
+		return nil
+	}
+	if err := s.ledger.Post(ctx, entries); err != nil && !errors.Is(err, domain.ErrDuplicatePosting) {
+		return fmt.Errorf("post %s to ledger: %w", entries[0].PostingID, err)
+	}
+	return nil
+}
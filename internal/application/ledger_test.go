@@ -0,0 +1,238 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func assertLedgerBalanced(t *testing.T, svc *Service) []domain.AccountBalance {
+	t.Helper()
+	totals, err := svc.VerifyLedger(context.Background())
+	if err != nil {
+		t.Fatalf("ledger does not sum to zero: %v (totals %+v)", err, totals)
+	}
+	return totals
+}
+
+func TestLedgerPostsApprovedTransactions(t *testing.T) {
+	svc := NewService(newMockRepo(), WithLedger(memory.NewLedgerStore()))
+	ctx := context.Background()
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 300))
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj2", "REFUND", "REJECTED", "tx1", "idem-adj2", 100))
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx2", "REJECTED", "idem2", 5000))
+	svc.ProcessTransaction(ctx, makeOriginalCmd("tx3", "WITHDRAWAL", "APPROVED", "idem3", 20000))
+	svc.ProcessTransaction(ctx, makeOriginalCmd("tx4", "CREDIT_VOUCHER", "APPROVED", "idem4", 3000))
+	svc.ProcessTransaction(ctx, makeOriginalCmd("tx5", "BALANCE_INQUIRY", "APPROVED", "idem5", 0))
+
+	balances, err := svc.GetUserBalance(ctx, "u1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := domain.AccountBalance{Currency: "BRL", Debits: 21000, Credits: 3300, Net: 17700}
+	if len(balances) != 1 || balances[0] != want {
+		t.Errorf("expected %+v, got %+v", want, balances)
+	}
+
+	ledger, _ := svc.GetCardLedger(ctx, "card1")
+	var postings []string
+	for _, e := range ledger.Entries {
+		postings = append(postings, e.PostingID)
+	}
+	if fmt.Sprint(postings) != "[tx1 adj1 tx3 tx4]" {
+		t.Errorf("expected card postings [tx1 adj1 tx3 tx4], got %v", postings)
+	}
+	if last := ledger.Entries[len(ledger.Entries)-1]; last.RunningBalance != 17700 {
+		t.Errorf("expected running balance 17700, got %d", last.RunningBalance)
+	}
+	assertLedgerBalanced(t, svc)
+}
+
+func TestLedgerDuplicateEventPostsOnce(t *testing.T) {
+	svc := NewService(newMockRepo(), WithLedger(memory.NewLedgerStore()))
+	ctx := context.Background()
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+
+	ledger, _ := svc.GetCardLedger(ctx, "card1")
+	if len(ledger.Entries) != 1 {
+		t.Errorf("expected 1 card entry, got %d", len(ledger.Entries))
+	}
+}
+
+func TestLedgerParkedAdjustmentPostedAfterPurchase(t *testing.T) {
+	repo := newMockRepo()
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	svc := NewService(repo, WithPendingAdjustments(memory.NewPendingStore(), time.Hour), WithClock(clock.now), WithLedger(memory.NewLedgerStore()))
+	ctx := context.Background()
+
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 300))
+	if ledger, _ := svc.GetCardLedger(ctx, "card1"); len(ledger.Entries) != 0 {
+		t.Fatalf("a parked adjustment must not be posted, got %d entries", len(ledger.Entries))
+	}
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+
+	ledger, _ := svc.GetCardLedger(ctx, "card1")
+	if len(ledger.Entries) != 2 || ledger.Entries[0].PostingID != "tx1" || ledger.Entries[1].PostingID != "adj1" {
+		t.Fatalf("expected purchase then refund, got %+v", ledger.Entries)
+	}
+	if ledger.Balances[0].Net != 700 {
+		t.Errorf("expected net 700, got %d", ledger.Balances[0].Net)
+	}
+}
+
+func TestParkedAdjustmentAppliedWhenThePurchasePostingFails(t *testing.T) {
+	repo := newMockRepo()
+	svc := NewService(repo, WithPendingAdjustments(memory.NewPendingStore(), time.Hour), WithLedger(failingLedger{memory.NewLedgerStore()}))
+	ctx := context.Background()
+
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 300))
+	if _, err := svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000)); err == nil {
+		t.Fatal("expected the ledger failure to be reported")
+	}
+	// A retry of the purchase is an idempotent hit, so the parked refund must not wait for one.
+	if adjs, _ := repo.GetAdjustmentsByTransactionID(ctx, "tx1"); len(adjs) != 1 {
+		t.Errorf("expected the parked refund applied despite the ledger failure, got %v", adjs)
+	}
+}
+
+// failingLedger refuses every posting.
+type failingLedger struct{ *memory.LedgerStore }
+
+func (failingLedger) Post(context.Context, []domain.LedgerEntry) error {
+	return errors.New("ledger unavailable")
+}
+
+func TestRebuildLedger(t *testing.T) {
+	repo := memory.NewRepository()
+	ctx := context.Background()
+	live := NewService(repo, WithLedger(memory.NewLedgerStore()))
+	live.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	live.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REVERSAL_PURCHASE", "APPROVED", "tx1", "idem-adj1", 400))
+	live.ProcessTransaction(ctx, makePurchaseCmd("tx2", "REJECTED", "idem2", 1000))
+
+	restarted := NewService(repo, WithLedger(memory.NewLedgerStore()))
+	added, err := restarted.RebuildLedger(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if added != 2 {
+		t.Errorf("expected 2 postings, got %d", added)
+	}
+	want, _ := live.GetUserBalance(ctx, "u1")
+	got, _ := restarted.GetUserBalance(ctx, "u1")
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("rebuilt balance %+v differs from live %+v", got, want)
+	}
+	assertLedgerBalanced(t, restarted)
+
+	if added, _ := restarted.RebuildLedger(ctx); added != 0 {
+		t.Errorf("second rebuild should add nothing, added %d", added)
+	}
+}
+
+func TestLedgerDisabled(t *testing.T) {
+	svc := NewService(newMockRepo())
+	ctx := context.Background()
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	balances, err := svc.GetUserBalance(ctx, "u1")
+	if err != nil || len(balances) != 0 {
+		t.Errorf("expected no balances without a ledger, got %+v (%v)", balances, err)
+	}
+	if added, err := svc.RebuildLedger(ctx); added != 0 || err != nil {
+		t.Errorf("expected no-op rebuild, got %d (%v)", added, err)
+	}
+}
+
+// TestLedgerAlwaysSumsToZero runs a random mix of concurrent events, valid and invalid, and checks
+// the invariants: the ledger nets to zero and each card's net equals approved debits minus credits.
+func TestLedgerAlwaysSumsToZero(t *testing.T) {
+	repo := memory.NewRepository()
+	svc := NewService(repo, WithLedger(memory.NewLedgerStore()))
+	ctx := context.Background()
+	types := []string{"PURCHASE", "WITHDRAWAL", "EXTRACASH", "PAYMENT", "CREDIT_VOUCHER"}
+
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Go(func() {
+			rng := rand.New(rand.NewPCG(uint64(w), 1))
+			for i := range 40 {
+				id := fmt.Sprintf("w%d-%d", w, i)
+				txType := types[rng.IntN(len(types))]
+				status := []string{"APPROVED", "APPROVED", "REJECTED"}[rng.IntN(3)]
+				svc.ProcessTransaction(ctx, makeOriginalCmd(id, txType, status, "idem-"+id, int64(1000+rng.IntN(9000))))
+				reversal := "REVERSAL_" + txType
+				if txType == "PURCHASE" && rng.IntN(2) == 0 {
+					reversal = "REFUND"
+				}
+				for j := range rng.IntN(3) {
+					adjID := fmt.Sprintf("%s-adj%d", id, j)
+					svc.ProcessTransaction(ctx, makeAdjustCmd(adjID, reversal, "APPROVED", id, "idem-"+adjID, int64(500+rng.IntN(6000))))
+				}
+			}
+		})
+	}
+	wg.Wait()
+	assertLedgerBalanced(t, svc)
+
+	var expected int64
+	page, _ := repo.ListTransactions(ctx, ports.TransactionQuery{})
+	for _, tx := range page.Transactions {
+		for _, e := range domain.PostTransaction(tx) {
+			expected += signedCardAmount(e)
+		}
+		adjs, _ := repo.GetAdjustmentsByTransactionID(ctx, tx.ID)
+		for _, adj := range adjs {
+			for _, e := range domain.PostAdjustment(adj) {
+				expected += signedCardAmount(e)
+			}
+		}
+	}
+	balances, _ := svc.GetUserBalance(ctx, "u1")
+	if len(balances) != 1 || balances[0].Net != expected {
+		t.Errorf("expected user net %d, got %+v", expected, balances)
+	}
+}
+
+func signedCardAmount(e domain.LedgerEntry) int64 {
+	switch {
+	case e.Account != domain.CardAccount(e.CardID):
+		return 0
+	case e.Side == domain.DirectionDebit:
+		return e.Amount.Amount
+	default:
+		return -e.Amount.Amount
+	}
+}
+
+func TestVerifyLedgerReportsImbalance(t *testing.T) {
+	store := memory.NewLedgerStore()
+	svc := NewService(newMockRepo(), WithLedger(store))
+	svc.ProcessTransaction(context.Background(), makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	if _, err := svc.VerifyLedger(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The store refuses unbalanced postings, so an imbalance can only be observed through a
+	// broken store; VerifyLedger must still report it.
+	broken := NewService(newMockRepo(), WithLedger(unbalancedLedger{store}))
+	if _, err := broken.VerifyLedger(context.Background()); !errors.Is(err, domain.ErrLedgerUnbalanced) {
+		t.Errorf("expected ErrLedgerUnbalanced, got %v", err)
+	}
+}
+
+// unbalancedLedger drops the last entry from Entries.
+type unbalancedLedger struct{ *memory.LedgerStore }
+
+func (l unbalancedLedger) Entries(ctx context.Context) ([]domain.LedgerEntry, error) {
+	entries, err := l.LedgerStore.Entries(ctx)
+	return entries[:len(entries)-1], err
+}
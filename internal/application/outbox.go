@@ -0,0 +1,359 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+const (
+	DefaultDispatchBatchSize   = 500
+	DefaultDispatchMaxAttempts = 8
+	DefaultDispatchBaseBackoff = time.Second
+	DefaultDispatchMaxBackoff  = 5 * time.Minute
+	// DefaultDispatchStallTimeout is how far a pass may overrun the poll interval before
+	// CheckHealth reports the loop stalled.
+	DefaultDispatchStallTimeout = time.Minute
+)
+
+// Dispatcher drains the repository's outbox and delivers every event to each subscriber: the
+// static OUTBOX_SUBSCRIBERS URLs and, with WithSubscriptions, every registered subscription whose
+// filters match the event.
+// Events sharing an ordering key (the card) are delivered strictly in write order: one that a
+// subscriber refuses holds back the events behind it, retried with exponential backoff, until it
+// is delivered or — after maxAttempts — moved to the dead-letter store. Ordering keys are
+// dispatched concurrently. Delivery is at-least-once: a crash between delivery and
+// MarkDispatched delivers the event again after restart.
+//
+// Dispatcher implements ports.OutboxUseCase and ports.SubscriptionUseCase.
+type Dispatcher struct {
+	outbox        ports.OutboxStore
+	publisher     ports.EventPublisher
+	deadLetters   ports.DeadLetterStore
+	static        []domain.Subscription
+	subscriptions ports.SubscriptionStore
+	deliveryLog   ports.DeliveryLog
+	batchSize     int
+	maxAttempts   int
+	baseBackoff   time.Duration
+	maxBackoff    time.Duration
+	stallTimeout  time.Duration
+	now           func() time.Time
+
+	// running serializes DispatchOnce, so each delivery is owned by one goroutine.
+	running sync.Mutex
+	mu      sync.Mutex
+	// deliveries tracks retries per event and subscriber ID until the event leaves the outbox.
+	deliveries map[string]map[string]*delivery
+	// interval and lastPass are set by Run for CheckHealth; a zero interval means Run is not running.
+	interval time.Duration
+	lastPass time.Time
+}
+
+// target is a subscriber of one DispatchOnce. Only registered subscriptions log their attempts.
+type target struct {
+	domain.Subscription
+	logged bool
+}
+
+type delivery struct {
+	attempts  int
+	nextAt    time.Time
+	lastError string
+	done      bool
+}
+
+// DispatcherOption configures a Dispatcher.
+type DispatcherOption func(*Dispatcher)
+
+// WithRetryPolicy sets how many times an event is offered to a subscriber before it is
+// dead-lettered, and the backoff between attempts: base after the first failure, doubling up to
+// maxBackoff.
+func WithRetryPolicy(maxAttempts int, base, maxBackoff time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.maxAttempts = maxAttempts
+		d.baseBackoff = base
+		d.maxBackoff = maxBackoff
+	}
+}
+
+// WithBatchSize caps how many outbox events one DispatchOnce reads.
+func WithBatchSize(n int) DispatcherOption {
+	return func(d *Dispatcher) { d.batchSize = n }
+}
+
+// WithStallTimeout overrides DefaultDispatchStallTimeout.
+func WithStallTimeout(d time.Duration) DispatcherOption {
+	return func(disp *Dispatcher) { disp.stallTimeout = d }
+}
+
+// WithDispatcherClock overrides the time source used for backoff and dead-letter timestamps.
+func WithDispatcherClock(now func() time.Time) DispatcherOption {
+	return func(d *Dispatcher) { d.now = now }
+}
+
+// WithSubscriptions also delivers to the subscriptions in store, logging every attempt to log,
+// and enables the ports.SubscriptionUseCase methods.
+func WithSubscriptions(store ports.SubscriptionStore, log ports.DeliveryLog) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.subscriptions = store
+		d.deliveryLog = log
+	}
+}
+
+// NewDispatcher delivers to the subscriber URLs unsigned and unfiltered.
+func NewDispatcher(outbox ports.OutboxStore, publisher ports.EventPublisher, deadLetters ports.DeadLetterStore, subscribers []string, opts ...DispatcherOption) *Dispatcher {
+	static := make([]domain.Subscription, len(subscribers))
+	for i, url := range subscribers {
+		static[i] = domain.Subscription{ID: url, URL: url}
+	}
+	d := &Dispatcher{
+		outbox:       outbox,
+		publisher:    publisher,
+		deadLetters:  deadLetters,
+		static:       static,
+		batchSize:    DefaultDispatchBatchSize,
+		maxAttempts:  DefaultDispatchMaxAttempts,
+		baseBackoff:  DefaultDispatchBaseBackoff,
+		maxBackoff:   DefaultDispatchMaxBackoff,
+		stallTimeout: DefaultDispatchStallTimeout,
+		now:          time.Now,
+		deliveries:   make(map[string]map[string]*delivery),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Run calls DispatchOnce every interval until ctx is done, passing its errors to report.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration, report func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	d.heartbeat(interval)
+	defer d.heartbeat(0)
+	for {
+		if _, err := d.DispatchOnce(ctx); err != nil && ctx.Err() == nil {
+			report(err)
+		}
+		d.heartbeat(interval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) heartbeat(interval time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.interval, d.lastPass = interval, d.now()
+}
+
+// CheckHealth fails when Run is not running or its last pass ended more than the poll interval
+// plus the stall timeout ago — a delivery stuck past every client timeout.
+func (d *Dispatcher) CheckHealth(context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.interval == 0 {
+		return errors.New("dispatcher is not running")
+	}
+	if since := d.now().Sub(d.lastPass); since > d.interval+d.stallTimeout {
+		return fmt.Errorf("no dispatch pass finished in %s", since.Truncate(time.Second))
+	}
+	return nil
+}
+
+// DispatchOnce offers the pending events that are due to their subscribers and returns how many
+// left the outbox, delivered or dead-lettered.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	d.running.Lock()
+	defer d.running.Unlock()
+	events, err := d.outbox.PendingEvents(ctx, d.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("read outbox: %w", err)
+	}
+	targets, err := d.targets(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var order []string
+	partitions := make(map[string][]domain.OutboxEvent)
+	for _, e := range events {
+		key := e.OrderingKey()
+		if _, seen := partitions[key]; !seen {
+			order = append(order, key)
+		}
+		partitions[key] = append(partitions[key], e)
+	}
+
+	var (
+		wg         sync.WaitGroup
+		resultMu   sync.Mutex
+		dispatched int
+		errs       []error
+	)
+	for _, key := range order {
+		wg.Go(func() {
+			n, err := d.dispatchPartition(ctx, partitions[key], targets)
+			resultMu.Lock()
+			defer resultMu.Unlock()
+			dispatched += n
+			if err != nil {
+				errs = append(errs, err)
+			}
+		})
+	}
+	wg.Wait()
+	return dispatched, errors.Join(errs...)
+}
+
+// ListDeadLetters returns the events no subscriber retry could deliver, oldest first.
+func (d *Dispatcher) ListDeadLetters(ctx context.Context) ([]domain.DeadLetter, error) {
+	return d.deadLetters.ListDeadLetters(ctx)
+}
+
+// dispatchPartition delivers events in order and stops at the first one still awaiting a retry.
+func (d *Dispatcher) dispatchPartition(ctx context.Context, events []domain.OutboxEvent, targets []target) (int, error) {
+	for i, e := range events {
+		done, err := d.deliver(ctx, e, targets)
+		if err != nil || !done {
+			return i, err
+		}
+		if err := d.outbox.MarkDispatched(ctx, e.ID); err != nil {
+			return i, fmt.Errorf("mark %s dispatched: %w", e.ID, err)
+		}
+		d.forget(e.ID)
+	}
+	return len(events), nil
+}
+
+// targets returns the static subscribers followed by the registered subscriptions.
+func (d *Dispatcher) targets(ctx context.Context) ([]target, error) {
+	targets := make([]target, 0, len(d.static))
+	for _, sub := range d.static {
+		targets = append(targets, target{Subscription: sub})
+	}
+	if d.subscriptions == nil {
+		return targets, nil
+	}
+	subs, err := d.subscriptions.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list subscriptions: %w", err)
+	}
+	for _, sub := range subs {
+		targets = append(targets, target{Subscription: sub, logged: true})
+	}
+	return targets, nil
+}
+
+// deliver offers e to every matching subscriber that has neither acknowledged nor dead-lettered it
+// and whose backoff has elapsed. It reports whether e is settled for all of them.
+func (d *Dispatcher) deliver(ctx context.Context, e domain.OutboxEvent, targets []target) (bool, error) {
+	settled := true
+	for _, t := range targets {
+		if !t.Matches(e) {
+			continue
+		}
+		st := d.delivery(e.ID, t.ID)
+		if st.done {
+			continue
+		}
+		if d.now().Before(st.nextAt) {
+			settled = false
+			continue
+		}
+		attempt, err := d.attempt(ctx, t, e, st.attempts+1, false)
+		if err != nil {
+			return false, err
+		}
+		if attempt.Succeeded() {
+			st.done = true
+			continue
+		}
+		st.attempts++
+		st.lastError = attempt.Error
+		if st.attempts < d.maxAttempts {
+			st.nextAt = d.now().Add(d.backoff(st.attempts))
+			settled = false
+			continue
+		}
+		dl := domain.DeadLetter{Event: e, Subscriber: t.URL, Attempts: st.attempts, LastError: st.lastError, FailedAt: d.now()}
+		if err := d.deadLetters.AddDeadLetter(ctx, dl); err != nil {
+			return false, fmt.Errorf("dead-letter %s: %w", e.ID, err)
+		}
+		st.done = true
+	}
+	return settled, nil
+}
+
+// attempt publishes e to t once and logs the attempt when t is a registered subscription. The
+// returned error is not the subscriber's: it is a cancelled ctx or a failure to log.
+func (d *Dispatcher) attempt(ctx context.Context, t target, e domain.OutboxEvent, n int, manual bool) (domain.Delivery, error) {
+	start := d.now()
+	status, err := d.publisher.Publish(ctx, t.Subscription, e)
+	if ctx.Err() != nil {
+		// Shutting down: the attempt did not fail on the subscriber's side.
+		return domain.Delivery{}, ctx.Err()
+	}
+	attempt := domain.Delivery{
+		ID:             newID("dlv"),
+		SubscriptionID: t.ID,
+		Event:          e,
+		Attempt:        n,
+		Manual:         manual,
+		ResponseStatus: status,
+		AttemptedAt:    start,
+		Duration:       d.now().Sub(start),
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	if t.logged {
+		if err := d.deliveryLog.RecordDelivery(ctx, attempt); err != nil {
+			return domain.Delivery{}, fmt.Errorf("log delivery of %s: %w", e.ID, err)
+		}
+	}
+	return attempt, nil
+}
+
+// backoff returns the wait after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.baseBackoff
+	for range attempts - 1 {
+		if wait >= d.maxBackoff/2 {
+			return d.maxBackoff
+		}
+		wait *= 2
+	}
+	return min(wait, d.maxBackoff)
+}
+
+func (d *Dispatcher) delivery(eventID, subscriberID string) *delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	bySubscriber, ok := d.deliveries[eventID]
+	if !ok {
+		bySubscriber = make(map[string]*delivery)
+		d.deliveries[eventID] = bySubscriber
+	}
+	st, ok := bySubscriber[subscriberID]
+	if !ok {
+		st = &delivery{}
+		bySubscriber[subscriberID] = st
+	}
+	return st
+}
+
+func (d *Dispatcher) forget(eventID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.deliveries, eventID)
+}
@@ -0,0 +1,191 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// recordingPublisher records deliveries per subscriber and refuses events listed in failing.
+type recordingPublisher struct {
+	mu        sync.Mutex
+	delivered map[string][]string
+	failing   map[string]bool
+	attempts  map[string]int
+}
+
+func newRecordingPublisher() *recordingPublisher {
+	return &recordingPublisher{delivered: map[string][]string{}, failing: map[string]bool{}, attempts: map[string]int{}}
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, sub domain.Subscription, e domain.OutboxEvent) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.attempts[sub.URL+" "+e.ID]++
+	if p.failing[sub.URL+" "+e.ID] {
+		return 503, errors.New("503 Service Unavailable")
+	}
+	p.delivered[sub.URL] = append(p.delivered[sub.URL], e.ID)
+	return 204, nil
+}
+
+func (p *recordingPublisher) fail(subscriber, eventID string, failing bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failing[subscriber+" "+eventID] = failing
+}
+
+func (p *recordingPublisher) deliveredTo(subscriber string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.delivered[subscriber])
+}
+
+func TestDispatcherDeliversSavedEventsToEverySubscriber(t *testing.T) {
+	repo := memory.NewRepository()
+	svc := NewService(repo)
+	ctx := context.Background()
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 400))
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000)) // duplicate: no event
+
+	pub := newRecordingPublisher()
+	d := NewDispatcher(repo, pub, memory.NewDeadLetterStore(), []string{"http://a", "http://b"})
+	n, err := d.DispatchOnce(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 events dispatched, got %d (%v)", n, err)
+	}
+	want := []string{"TransactionProcessed:tx1", "AdjustmentApplied:adj1"}
+	for _, sub := range []string{"http://a", "http://b"} {
+		if got := pub.deliveredTo(sub); !slices.Equal(got, want) {
+			t.Errorf("%s: expected %v, got %v", sub, want, got)
+		}
+	}
+	if pending, _ := repo.PendingEvents(ctx, 0); len(pending) != 0 {
+		t.Errorf("expected an empty outbox, got %d events", len(pending))
+	}
+}
+
+func TestDispatcherKeepsCardOrderAcrossRetries(t *testing.T) {
+	repo := memory.NewRepository()
+	svc := NewService(repo)
+	ctx := context.Background()
+	other := makePurchaseCmd("tx3", "APPROVED", "idem3", 1000)
+	other.CardID = "card2"
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx2", "APPROVED", "idem2", 1000))
+	svc.ProcessTransaction(ctx, other)
+
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	pub := newRecordingPublisher()
+	pub.fail("http://a", "TransactionProcessed:tx1", true)
+	d := NewDispatcher(repo, pub, memory.NewDeadLetterStore(), []string{"http://a"},
+		WithRetryPolicy(5, time.Second, time.Minute), WithDispatcherClock(clock.now))
+
+	// tx1 fails, so tx2 (same card) waits; tx3 (another card) goes through.
+	if n, _ := d.DispatchOnce(ctx); n != 1 {
+		t.Fatalf("expected only the other card's event, got %d", n)
+	}
+	if got := pub.deliveredTo("http://a"); !slices.Equal(got, []string{"TransactionProcessed:tx3"}) {
+		t.Fatalf("unexpected deliveries %v", got)
+	}
+
+	// Within the backoff nothing is retried, even once the subscriber recovers.
+	pub.fail("http://a", "TransactionProcessed:tx1", false)
+	d.DispatchOnce(ctx)
+	if pub.attempts["http://a TransactionProcessed:tx1"] != 1 {
+		t.Fatalf("expected no retry before the backoff elapsed, got %d attempts", pub.attempts["http://a TransactionProcessed:tx1"])
+	}
+
+	clock.t = clock.t.Add(time.Second)
+	if n, _ := d.DispatchOnce(ctx); n != 2 {
+		t.Fatalf("expected tx1 and tx2 after the backoff, got %d", n)
+	}
+	want := []string{"TransactionProcessed:tx3", "TransactionProcessed:tx1", "TransactionProcessed:tx2"}
+	if got := pub.deliveredTo("http://a"); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestDispatcherDeadLettersAfterMaxAttempts(t *testing.T) {
+	repo := memory.NewRepository()
+	svc := NewService(repo)
+	ctx := context.Background()
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx2", "APPROVED", "idem2", 1000))
+
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	pub := newRecordingPublisher()
+	pub.fail("http://a", "TransactionProcessed:tx1", true)
+	d := NewDispatcher(repo, pub, memory.NewDeadLetterStore(), []string{"http://a", "http://b"},
+		WithRetryPolicy(3, time.Second, time.Minute), WithDispatcherClock(clock.now))
+
+	for range 3 {
+		d.DispatchOnce(ctx)
+		clock.t = clock.t.Add(time.Minute)
+	}
+	letters, _ := d.ListDeadLetters(ctx)
+	if len(letters) != 1 {
+		t.Fatalf("expected 1 dead letter, got %+v", letters)
+	}
+	if dl := letters[0]; dl.Event.ID != "TransactionProcessed:tx1" || dl.Subscriber != "http://a" || dl.Attempts != 3 || dl.LastError == "" {
+		t.Errorf("unexpected dead letter %+v", dl)
+	}
+	// The dead letter unblocks the card; the healthy subscriber got every event exactly once.
+	want := []string{"TransactionProcessed:tx1", "TransactionProcessed:tx2"}
+	if got := pub.deliveredTo("http://b"); !slices.Equal(got, want) {
+		t.Errorf("http://b: expected %v, got %v", want, got)
+	}
+	if got := pub.deliveredTo("http://a"); !slices.Equal(got, want[1:]) {
+		t.Errorf("http://a: expected %v, got %v", want[1:], got)
+	}
+	if pending, _ := repo.PendingEvents(ctx, 0); len(pending) != 0 {
+		t.Errorf("expected an empty outbox, got %d events", len(pending))
+	}
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+	d := NewDispatcher(nil, nil, nil, nil, WithRetryPolicy(10, time.Second, 10*time.Second))
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, w := range want {
+		if got := d.backoff(i + 1); got != w {
+			t.Errorf("attempt %d: expected %v, got %v", i+1, w, got)
+		}
+	}
+}
+
+func TestDispatcherWithoutSubscribersDrainsOutbox(t *testing.T) {
+	repo := memory.NewRepository()
+	NewService(repo).ProcessTransaction(context.Background(), makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	d := NewDispatcher(repo, newRecordingPublisher(), memory.NewDeadLetterStore(), nil)
+	if n, err := d.DispatchOnce(context.Background()); err != nil || n != 1 {
+		t.Errorf("expected the event to be dropped, got %d (%v)", n, err)
+	}
+}
+
+func TestDispatcherCheckHealth(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	d := NewDispatcher(memory.NewRepository(), newRecordingPublisher(), memory.NewDeadLetterStore(), nil,
+		WithStallTimeout(time.Minute), WithDispatcherClock(clock.now))
+	ctx := context.Background()
+	if err := d.CheckHealth(ctx); err == nil {
+		t.Error("expected a dispatcher that never ran to be unhealthy")
+	}
+
+	d.heartbeat(time.Second)
+	clock.t = clock.t.Add(time.Minute)
+	if err := d.CheckHealth(ctx); err != nil {
+		t.Errorf("a pass within interval plus stall timeout should be healthy, got %v", err)
+	}
+	clock.t = clock.t.Add(2 * time.Second)
+	if err := d.CheckHealth(ctx); err == nil || !strings.Contains(err.Error(), "no dispatch pass finished in 1m2s") {
+		t.Errorf("expected a stalled loop, got %v", err)
+	}
+}
@@ -49,10 +49,210 @@ type ProcessTransactionCommand struct {
 type ProcessTransactionResult struct {
 	TransactionID string
 	Idempotent    bool
+	// Parked is set when the adjustment's original purchase has not arrived yet and the
+	// adjustment was accepted for later processing.
+	Parked bool
+}
+
+// TransactionSummary is a purchase with its adjustments, in arrival order, and the balance
+// derived from them.
+type TransactionSummary struct {
+	Transaction domain.Transaction
+	Adjustments []domain.Adjustment
+	Balance     domain.PurchaseBalance
 }
 
 type WebhookUseCase interface {
 	ProcessTransaction(ctx context.Context, cmd ProcessTransactionCommand) (ProcessTransactionResult, error)
 	GetTransaction(ctx context.Context, id string) (domain.Transaction, error)
-	ListTransactions(ctx context.Context) ([]domain.Transaction, error)
+	GetTransactionSummary(ctx context.Context, id string) (TransactionSummary, error)
+	// GetTransactionAdjustments returns domain.ErrTransactionNotFound for an unknown purchase,
+	// so an empty list always means "no adjustments yet".
+	GetTransactionAdjustments(ctx context.Context, id string) ([]domain.Adjustment, error)
+	ListTransactions(ctx context.Context, q TransactionQuery) (TransactionPage, error)
+}
+
+// PendingAdjustmentUseCase exposes parked adjustments that need manual review.
+type PendingAdjustmentUseCase interface {
+	ListAdjustmentsForReview(ctx context.Context) ([]domain.PendingAdjustment, error)
+}
+
+// CardLedger is a card account's entries, oldest first, and its balance per currency.
+type CardLedger struct {
+	CardID   string
+	Entries  []domain.LedgerEntry
+	Balances []domain.AccountBalance
+}
+
+// LedgerUseCase exposes balances and the ledger built from approved transactions.
+type LedgerUseCase interface {
+	GetUserBalance(ctx context.Context, userID string) ([]domain.AccountBalance, error)
+	GetCardLedger(ctx context.Context, cardID string) (CardLedger, error)
+	// VerifyLedger returns the ledger-wide totals per currency and domain.ErrLedgerUnbalanced if
+	// any of them does not sum to zero.
+	VerifyLedger(ctx context.Context) ([]domain.AccountBalance, error)
+}
+
+// AuthorizeCommand asks for a synchronous decision on a transaction before it happens.
+type AuthorizeCommand struct {
+	TransactionID   string
+	TransactionType string
+	Amount          int64
+	Currency        string
+	CardID          string
+	UserID          string
+	MerchantID      string
+	MerchantMCC     string
+	MerchantName    string
+}
+
+type AuthorizeResult struct {
+	Authorization domain.Authorization
+	// Available is the card balance left for debits after the decision, in cents.
+	Available  int64
+	Idempotent bool
+}
+
+// SaveCardCommand creates or replaces a card profile.
+type SaveCardCommand struct {
+	CardID           string
+	UserID           string
+	Status           string
+	Currency         string
+	Balance          int64
+	TransactionLimit int64
+	DailyLimit       int64
+}
+
+// AuthorizationUseCase decides authorizations against card balances and limits.
+type AuthorizationUseCase interface {
+	// Authorize always records and returns a decision for a valid request; ctx's deadline bounds
+	// it, and a decision that misses it is recorded as a PROCESSING_TIMEOUT rejection.
+	Authorize(ctx context.Context, cmd AuthorizeCommand) (AuthorizeResult, error)
+	GetAuthorization(ctx context.Context, id string) (domain.Authorization, error)
+	SaveCard(ctx context.Context, cmd SaveCardCommand) (domain.Card, error)
+	GetCard(ctx context.Context, id string) (domain.Card, error)
+}
+
+// IdempotencyUseCase keeps the response to a request for retries with the same idempotency key.
+type IdempotencyUseCase interface {
+	// ClaimKey ties key to payloadHash before the request is processed, unless a live record
+	// already holds the key, and returns that record when it has a response to replay. It
+	// returns domain.ErrIdempotencyKeyReused when the key belongs to a different payload.
+	ClaimKey(ctx context.Context, key, payloadHash string) (domain.IdempotencyRecord, bool, error)
+	// RecordResponse keeps a response for replay until the retention window closes.
+	RecordResponse(ctx context.Context, key, payloadHash string, statusCode int, body []byte) error
+}
+
+// RecordAttemptCommand is a webhook attempt as the adapter that received it saw it.
+type RecordAttemptCommand struct {
+	TransactionID  string
+	IdempotencyKey string
+	ReceivedAt     time.Time
+	Outcome        string
+	StatusCode     int
+	Payload        []byte
+}
+
+// HistoryUseCase keeps every webhook attempt and returns each transaction's history.
+type HistoryUseCase interface {
+	RecordAttempt(ctx context.Context, cmd RecordAttemptCommand) error
+	// ListTransactionEvents returns the attempts for a transaction or adjustment, oldest first. It
+	// returns domain.ErrTransactionNotFound when there are none and no transaction has the ID.
+	ListTransactionEvents(ctx context.Context, transactionID string) ([]domain.WebhookAttempt, error)
+}
+
+// RecordRejectionCommand is a rejected webhook and the error response it got.
+type RecordRejectionCommand struct {
+	TransactionID  string
+	IdempotencyKey string
+	Payload        []byte
+	StatusCode     int
+	Code           string
+	Error          string
+}
+
+// RejectionUseCase keeps rejected webhooks for inspection and manual reprocessing.
+type RejectionUseCase interface {
+	RecordRejection(ctx context.Context, cmd RecordRejectionCommand) error
+	// ResolveRejection closes the open entry for idempotencyKey, if any, after a retry of it was
+	// accepted.
+	ResolveRejection(ctx context.Context, idempotencyKey string) error
+	// ListRejections returns the entries, oldest first, filtered by status: "", domain.RejectionOpen
+	// or domain.RejectionResolved.
+	ListRejections(ctx context.Context, status string) ([]domain.RejectedWebhook, error)
+	GetRejection(ctx context.Context, id string) (domain.RejectedWebhook, error)
+	// ReprocessRejection runs the webhook through ProcessTransaction again and returns the updated
+	// entry: resolved when it was accepted, with the processing error otherwise. The error is
+	// reserved for the entry itself, such as domain.ErrRejectionResolved.
+	ReprocessRejection(ctx context.Context, id string) (domain.RejectedWebhook, error)
+}
+
+// OpenDisputeCommand disputes Amount of a purchase, in minor units of Currency. An empty Currency
+// is the purchase's local currency.
+type OpenDisputeCommand struct {
+	TransactionID string
+	Amount        int64
+	Currency      string
+	Reason        string
+}
+
+// TransitionDisputeCommand moves a dispute to State, with a note kept in its history.
+type TransitionDisputeCommand struct {
+	DisputeID string
+	State     string
+	Note      string
+}
+
+// DisputeUseCase manages cardholder disputes of purchases and their provisional credits.
+type DisputeUseCase interface {
+	// OpenDispute credits the disputed amount to the card provisionally. The amount shares the
+	// purchase's budget with its reversals and refunds.
+	OpenDispute(ctx context.Context, cmd OpenDisputeCommand) (domain.Dispute, error)
+	// TransitionDispute returns domain.ErrInvalidDisputeTransition for a move the current state does
+	// not allow. A won dispute is charged back to the merchant; a lost or cancelled one reverses the
+	// provisional credit.
+	TransitionDispute(ctx context.Context, cmd TransitionDisputeCommand) (domain.Dispute, error)
+	GetDispute(ctx context.Context, id string) (domain.Dispute, error)
+	// ListDisputes returns domain.ErrTransactionNotFound for an unknown transaction.
+	ListDisputes(ctx context.Context, transactionID string) ([]domain.Dispute, error)
+}
+
+// ReconcileSettlementCommand holds the rows of a settlement file. From (inclusive) and To
+// (exclusive) bound the event created_at of the transactions the file is expected to cover; a zero
+// bound is open.
+type ReconcileSettlementCommand struct {
+	Records []domain.SettlementRecord
+	From    time.Time
+	To      time.Time
+}
+
+// SettlementUseCase cross-checks the processor's settlement files with the stored webhooks.
+type SettlementUseCase interface {
+	ReconcileSettlement(ctx context.Context, cmd ReconcileSettlementCommand) (domain.SettlementReport, error)
+}
+
+// OutboxUseCase exposes the outcome of downstream event delivery.
+type OutboxUseCase interface {
+	ListDeadLetters(ctx context.Context) ([]domain.DeadLetter, error)
+}
+
+// CreateSubscriptionCommand registers a partner endpoint. Empty Types or Statuses match everything.
+type CreateSubscriptionCommand struct {
+	URL      string
+	Types    []string
+	Statuses []string
+	Secret   string
+}
+
+// SubscriptionUseCase manages partner subscriptions and their delivery log.
+type SubscriptionUseCase interface {
+	CreateSubscription(ctx context.Context, cmd CreateSubscriptionCommand) (domain.Subscription, error)
+	GetSubscription(ctx context.Context, id string) (domain.Subscription, error)
+	ListSubscriptions(ctx context.Context) ([]domain.Subscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	ListDeliveries(ctx context.Context, subscriptionID string) ([]domain.Delivery, error)
+	// Redeliver sends the event of a logged delivery again, right away, and returns the new attempt.
+	// A failed attempt is returned with a nil error; it is not retried.
+	Redeliver(ctx context.Context, subscriptionID, deliveryID string) (domain.Delivery, error)
 }
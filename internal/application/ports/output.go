@@ -2,15 +2,240 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/jailtonjunior/pomelo/internal/domain"
 )
 
+// AdjustmentCheck validates a new adjustment against its original transaction and the
+// adjustments already stored for it. Repositories call it inside the same critical section
+// (lock or database transaction) that appends the adjustment.
+type AdjustmentCheck func(original domain.Transaction, existing []domain.Adjustment) error
+
+// TransactionRepository stores transactions and adjustments. Every successful save also appends
+// domain.TransactionProcessed or domain.AdjustmentApplied to the outbox in the same atomic step;
+// a rejected save appends nothing.
 type TransactionRepository interface {
+	OutboxStore
 	SaveTransaction(ctx context.Context, tx domain.Transaction) error
 	SaveAdjustment(ctx context.Context, adj domain.Adjustment) error
+	// AppendAdjustment atomically checks idempotency, loads the original transaction and its
+	// existing adjustments, runs check and saves adj only if check returns nil.
+	// Concurrent appends for the same original transaction are serialized, so the
+	// sum-validate-save sequence cannot be interleaved.
+	AppendAdjustment(ctx context.Context, adj domain.Adjustment, check AdjustmentCheck) error
 	GetTransactionByID(ctx context.Context, id string) (domain.Transaction, error)
 	GetAdjustmentsByTransactionID(ctx context.Context, originalTxID string) ([]domain.Adjustment, error)
+	// GetAdjustmentByID returns domain.ErrTransactionNotFound when no adjustment has id.
+	GetAdjustmentByID(ctx context.Context, id string) (domain.Adjustment, error)
 	GetByIdempotencyKey(ctx context.Context, key string) (string, bool)
-	ListTransactions(ctx context.Context) ([]domain.Transaction, error)
+	// ListTransactions returns the transactions matching q in a deterministic order, one page at a time.
+	ListTransactions(ctx context.Context, q TransactionQuery) (TransactionPage, error)
+}
+
+// OutboxStore is the read side of the transactional outbox the repository writes.
+type OutboxStore interface {
+	// PendingEvents returns up to limit undispatched events in write order; limit <= 0 returns all.
+	PendingEvents(ctx context.Context, limit int) ([]domain.OutboxEvent, error)
+	// MarkDispatched removes the events from the outbox. Unknown IDs are ignored.
+	MarkDispatched(ctx context.Context, ids ...string) error
+}
+
+// TransactionSortField is a field ListTransactions can order by. Ties are always broken by ID.
+type TransactionSortField string
+
+const (
+	SortByCreatedAt TransactionSortField = "created_at"
+	SortByAmount    TransactionSortField = "amount"
+	SortByID        TransactionSortField = "id"
+)
+
+// TransactionQuery filters, orders and pages ListTransactions. Zero-valued filters match everything.
+type TransactionQuery struct {
+	UserID      string
+	CardID      string
+	MerchantID  string
+	MerchantMCC string
+	Status      domain.TransactionStatus
+	Type        domain.TransactionType
+	// Currency and the amount bounds apply to the local amount. Bounds are inclusive, in cents.
+	Currency  string
+	MinAmount *int64
+	MaxAmount *int64
+	// CreatedFrom (inclusive) and CreatedTo (exclusive) bound the event created_at.
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+
+	// SortBy defaults to SortByCreatedAt.
+	SortBy     TransactionSortField
+	Descending bool
+	// After resumes right after this position. It must come from a page read with the same
+	// sort order; filters may differ but then the pages are not a consistent listing.
+	After *TransactionCursor
+	// Limit caps the page size; <= 0 returns every match.
+	Limit int
+}
+
+// TransactionCursor is the keyset position of a transaction in any of the supported orders.
+type TransactionCursor struct {
+	CreatedAt time.Time
+	Amount    int64
+	ID        string
+}
+
+// CursorFor returns the keyset position of tx.
+func CursorFor(tx domain.Transaction) TransactionCursor {
+	return TransactionCursor{CreatedAt: tx.Event.CreatedAt, Amount: tx.Amount.Local.Amount, ID: tx.ID}
+}
+
+type TransactionPage struct {
+	Transactions []domain.Transaction
+	// Next is the position to resume from, or nil when this is the last page.
+	Next *TransactionCursor
+}
+
+// PendingAdjustmentStore holds adjustments received before their original purchase.
+// Entries are keyed by idempotency key; a retried webhook for a parked adjustment finds it here.
+type PendingAdjustmentStore interface {
+	// Park stores p. It returns domain.ErrDuplicateIdempotencyKey if an adjustment with the
+	// same idempotency key is already held.
+	Park(ctx context.Context, p domain.PendingAdjustment) error
+	GetByIdempotencyKey(ctx context.Context, key string) (domain.PendingAdjustment, bool)
+	// TakeParked atomically removes and returns, in arrival order, the adjustments parked for
+	// originalTxID that have not expired at now. Each entry is handed to exactly one caller.
+	// Expired entries are left in place for review.
+	TakeParked(ctx context.Context, originalTxID string, now time.Time) ([]domain.PendingAdjustment, error)
+	// Reject stores p back with the reason it could not be applied, for manual review.
+	Reject(ctx context.Context, p domain.PendingAdjustment, reason string) error
+	// ListForReview returns the entries that expired at now or were rejected, oldest first.
+	ListForReview(ctx context.Context, now time.Time) ([]domain.PendingAdjustment, error)
+}
+
+// IdempotencyStore keeps the response to each idempotency key for replay to retries.
+type IdempotencyStore interface {
+	// ClaimIdempotencyKey stores rec, a record without a response, unless a live record for its
+	// key exists, and returns the record the key holds.
+	ClaimIdempotencyKey(ctx context.Context, rec domain.IdempotencyRecord) (domain.IdempotencyRecord, error)
+	// SaveIdempotencyRecord stores rec unless a live record for its key already holds a response:
+	// the first response wins. Stores may drop records already expired at rec.CreatedAt.
+	SaveIdempotencyRecord(ctx context.Context, rec domain.IdempotencyRecord) error
+	// ClearIdempotencyResponse drops the response of the live record for key, if any, keeping
+	// the payload it was made for.
+	ClearIdempotencyResponse(ctx context.Context, key string, now time.Time) error
+	// GetIdempotencyRecord returns the record for key unless it expired at now.
+	GetIdempotencyRecord(ctx context.Context, key string, now time.Time) (domain.IdempotencyRecord, bool, error)
+}
+
+// AttemptLog is the append-only log of webhook attempts.
+type AttemptLog interface {
+	AppendAttempt(ctx context.Context, a domain.WebhookAttempt) error
+	// ListAttempts returns the attempts for transactionID in append order.
+	ListAttempts(ctx context.Context, transactionID string) ([]domain.WebhookAttempt, error)
+	// AllAttempts returns every attempt in append order.
+	AllAttempts(ctx context.Context) ([]domain.WebhookAttempt, error)
+}
+
+// RejectedWebhookStore keeps webhooks rejected for a reason that can be fixed.
+type RejectedWebhookStore interface {
+	// SaveRejection stores rw, or folds it into the open entry with the same idempotency key, and
+	// returns the stored entry.
+	SaveRejection(ctx context.Context, rw domain.RejectedWebhook) (domain.RejectedWebhook, error)
+	// UpdateRejection replaces the entry with rw's ID. It returns domain.ErrRejectionNotFound if
+	// there is none.
+	UpdateRejection(ctx context.Context, rw domain.RejectedWebhook) error
+	GetRejection(ctx context.Context, id string) (domain.RejectedWebhook, error)
+	// OpenRejection returns the unresolved entry for an idempotency key.
+	OpenRejection(ctx context.Context, idempotencyKey string) (domain.RejectedWebhook, bool, error)
+	// ListRejections returns every entry, oldest first.
+	ListRejections(ctx context.Context) ([]domain.RejectedWebhook, error)
+}
+
+// DisputeStore keeps cardholder disputes. The ledger's provisional credits are rebuilt from it, so
+// it must be as durable as the repository.
+type DisputeStore interface {
+	SaveDispute(ctx context.Context, d domain.Dispute) error
+	// UpdateDispute replaces the dispute with d's ID. It returns domain.ErrDisputeNotFound if there
+	// is none.
+	UpdateDispute(ctx context.Context, d domain.Dispute) error
+	// GetDispute returns domain.ErrDisputeNotFound for an unknown ID.
+	GetDispute(ctx context.Context, id string) (domain.Dispute, error)
+	// ListDisputes returns the disputes of a transaction in the order they were opened.
+	ListDisputes(ctx context.Context, transactionID string) ([]domain.Dispute, error)
+	// AllDisputes returns every dispute in the order they were opened.
+	AllDisputes(ctx context.Context) ([]domain.Dispute, error)
+}
+
+// LedgerStore holds the double-entry ledger and the running balances derived from it.
+type LedgerStore interface {
+	// Post appends the entries of one posting atomically. It returns domain.ErrLedgerUnbalanced if
+	// they do not net to zero and domain.ErrDuplicatePosting if their PostingID was already posted.
+	Post(ctx context.Context, entries []domain.LedgerEntry) error
+	// EntriesByCard returns the card account's entries in posting order, with running balances.
+	EntriesByCard(ctx context.Context, cardID string) ([]domain.LedgerEntry, error)
+	// BalancesByCard and BalancesByUser return one balance per currency, sorted by currency.
+	// A user's balance is the sum of the card accounts it owns.
+	BalancesByCard(ctx context.Context, cardID string) ([]domain.AccountBalance, error)
+	BalancesByUser(ctx context.Context, userID string) ([]domain.AccountBalance, error)
+	// Entries returns every entry in posting order.
+	Entries(ctx context.Context) ([]domain.LedgerEntry, error)
+}
+
+// AuthorizationDecider decides an authorization given the card and the authorizations already
+// recorded for it. card is the zero domain.Card when the card is unknown.
+type AuthorizationDecider func(card domain.Card, history []domain.Authorization) (domain.Authorization, error)
+
+// AuthorizationStore holds card profiles and the authorization decisions taken for them.
+type AuthorizationStore interface {
+	SaveCard(ctx context.Context, card domain.Card) error
+	// GetCard returns domain.ErrCardNotFound for an unknown card.
+	GetCard(ctx context.Context, id string) (domain.Card, error)
+	// Decide atomically loads the card and its authorizations, calls decide and records the
+	// authorization it returns. Decisions for the same card are serialized, so two authorizations
+	// cannot both spend the same balance; decisions for different cards need not wait for each
+	// other. Decide waits for the card only while ctx is live: once ctx is done it returns ctx's
+	// error without calling decide. If id was already decided, Decide returns the recorded
+	// authorization and domain.ErrDuplicateAuthorization without calling decide.
+	Decide(ctx context.Context, id, cardID string, decide AuthorizationDecider) (domain.Authorization, error)
+	// RecordAuthorization records auth, a decision that holds nothing, without waiting for its
+	// card. If its ID was already decided it returns the recorded authorization and
+	// domain.ErrDuplicateAuthorization.
+	RecordAuthorization(ctx context.Context, auth domain.Authorization) (domain.Authorization, error)
+	// GetAuthorization returns domain.ErrAuthorizationNotFound for an unknown transaction ID.
+	GetAuthorization(ctx context.Context, id string) (domain.Authorization, error)
+	// Match replaces authorization id with match(current) atomically. A decision already running
+	// for the card may still count the hold the match releases, which only errs toward declining.
+	Match(ctx context.Context, id string, match func(domain.Authorization) domain.Authorization) (domain.Authorization, error)
+}
+
+// EventPublisher delivers one outbox event to one subscriber, signing it when sub has a secret.
+// A nil error means the subscriber acknowledged it; status is the subscriber's HTTP status, 0 when
+// no response was received.
+type EventPublisher interface {
+	Publish(ctx context.Context, sub domain.Subscription, event domain.OutboxEvent) (status int, err error)
+}
+
+// DeadLetterStore keeps the events subscribers never acknowledged.
+type DeadLetterStore interface {
+	AddDeadLetter(ctx context.Context, dl domain.DeadLetter) error
+	// ListDeadLetters returns every dead letter, oldest first.
+	ListDeadLetters(ctx context.Context) ([]domain.DeadLetter, error)
+}
+
+// SubscriptionStore holds the partner endpoints registered to receive outbox events.
+type SubscriptionStore interface {
+	SaveSubscription(ctx context.Context, sub domain.Subscription) error
+	// GetSubscription and DeleteSubscription return domain.ErrSubscriptionNotFound for an unknown ID.
+	GetSubscription(ctx context.Context, id string) (domain.Subscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	// ListSubscriptions returns every subscription, oldest first.
+	ListSubscriptions(ctx context.Context) ([]domain.Subscription, error)
+}
+
+// DeliveryLog records every attempt to deliver an event to a subscription.
+type DeliveryLog interface {
+	RecordDelivery(ctx context.Context, d domain.Delivery) error
+	// GetDelivery returns domain.ErrDeliveryNotFound for an unknown ID.
+	GetDelivery(ctx context.Context, id string) (domain.Delivery, error)
+	// ListDeliveries returns the subscription's attempts, oldest first.
+	ListDeliveries(ctx context.Context, subscriptionID string) ([]domain.Delivery, error)
 }
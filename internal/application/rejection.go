@@ -0,0 +1,126 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+	"github.com/jailtonjunior/pomelo/internal/logging"
+)
+
+// WithRejectedWebhooks keeps rejected webhooks in store, enabling the ports.RejectionUseCase
+// methods. decode reads a stored payload back for reprocessing.
+func WithRejectedWebhooks(store ports.RejectedWebhookStore, decode PayloadDecoder) Option {
+	return func(s *Service) {
+		s.rejections = store
+		s.decode = decode
+	}
+}
+
+func (s *Service) RecordRejection(ctx context.Context, cmd ports.RecordRejectionCommand) error {
+	if s.rejections == nil {
+		return nil
+	}
+	rw, err := domain.NewRejectedWebhook(newID("rej"), cmd.TransactionID, cmd.IdempotencyKey, cmd.Payload, cmd.StatusCode, cmd.Code, cmd.Error, s.now())
+	if err != nil {
+		return err
+	}
+	_, err = s.rejections.SaveRejection(ctx, rw)
+	return err
+}
+
+func (s *Service) ResolveRejection(ctx context.Context, idempotencyKey string) error {
+	if s.rejections == nil || idempotencyKey == "" {
+		return nil
+	}
+	rw, ok, err := s.rejections.OpenRejection(ctx, idempotencyKey)
+	if err != nil || !ok {
+		return err
+	}
+	return s.rejections.UpdateRejection(ctx, rw.Resolve(s.now()))
+}
+
+func (s *Service) ListRejections(ctx context.Context, status string) ([]domain.RejectedWebhook, error) {
+	switch status {
+	case "", domain.RejectionOpen, domain.RejectionResolved:
+	default:
+		return nil, fmt.Errorf("%w: unknown rejection status %q", domain.ErrInvalidInput, status)
+	}
+	if s.rejections == nil {
+		return []domain.RejectedWebhook{}, nil
+	}
+	all, err := s.rejections.ListRejections(ctx)
+	if err != nil || status == "" {
+		return all, err
+	}
+	return slices.DeleteFunc(all, func(rw domain.RejectedWebhook) bool {
+		return rw.Resolved() != (status == domain.RejectionResolved)
+	}), nil
+}
+
+func (s *Service) GetRejection(ctx context.Context, id string) (domain.RejectedWebhook, error) {
+	if s.rejections == nil {
+		return domain.RejectedWebhook{}, domain.ErrRejectionNotFound
+	}
+	return s.rejections.GetRejection(ctx, id)
+}
+
+// ReprocessRejection treats a duplicate idempotency key as accepted: a retry already got the
+// webhook in. A webhook the reprocess gets in is recorded as if it had just been delivered.
+func (s *Service) ReprocessRejection(ctx context.Context, id string) (domain.RejectedWebhook, error) {
+	rw, err := s.GetRejection(ctx, id)
+	if err != nil {
+		return domain.RejectedWebhook{}, err
+	}
+	if rw.Resolved() {
+		return rw, fmt.Errorf("%w: %s", domain.ErrRejectionResolved, rw.ID)
+	}
+	cmd, err := s.decode(rw.Payload)
+	if err == nil {
+		var result ports.ProcessTransactionResult
+		if result, err = s.ProcessTransaction(ctx, cmd); err == nil {
+			s.recordReprocessed(ctx, rw, result)
+		}
+	}
+	if errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+		err = nil
+	}
+	rw, stateErr := rw.Reprocessed(s.now(), err)
+	if stateErr != nil {
+		return rw, stateErr
+	}
+	if err := s.rejections.UpdateRejection(ctx, rw); err != nil {
+		return domain.RejectedWebhook{}, err
+	}
+	return rw, nil
+}
+
+// recordReprocessed makes an accepted reprocess look like an accepted delivery: the attempt
+// joins the log, so a replay rebuilds it, and the stored error response is dropped, so a retry
+// of the webhook is processed again instead of getting the rejection back. A failure is only
+// logged: the webhook is already in, as with the records the handler keeps.
+func (s *Service) recordReprocessed(ctx context.Context, rw domain.RejectedWebhook, result ports.ProcessTransactionResult) {
+	log := logging.FromContext(ctx)
+	if s.attempts != nil {
+		outcome, status := domain.OutcomeProcessed, http.StatusOK
+		if result.Parked {
+			outcome, status = domain.OutcomeParked, http.StatusAccepted
+		}
+		a, err := domain.NewWebhookAttempt(newID("att"), rw.TransactionID, rw.IdempotencyKey, s.now(), outcome, status, rw.Payload)
+		if err == nil {
+			err = s.attempts.AppendAttempt(ctx, a)
+		}
+		if err != nil {
+			log.Warn("reprocessed attempt not recorded", "transaction_id", rw.TransactionID, "err", err)
+		}
+	}
+	if s.idempotency != nil && rw.IdempotencyKey != "" {
+		if err := s.idempotency.ClearIdempotencyResponse(ctx, rw.IdempotencyKey, s.now()); err != nil {
+			log.Warn("idempotency response not cleared", "idempotency_key", rw.IdempotencyKey, "err", err)
+		}
+	}
+}
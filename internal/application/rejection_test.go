@@ -0,0 +1,163 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func recordRejection(t *testing.T, svc *Service, cmd ports.ProcessTransactionCommand) {
+	t.Helper()
+	payload, _ := json.Marshal(cmd)
+	err := svc.RecordRejection(context.Background(), ports.RecordRejectionCommand{
+		TransactionID: cmd.TransactionID, IdempotencyKey: cmd.IdempotencyKey, Payload: payload,
+		StatusCode: 404, Code: "NOT_FOUND", Error: "transaction not found",
+	})
+	if err != nil {
+		t.Fatalf("record rejection: %v", err)
+	}
+}
+
+func TestReprocessRejectionAfterOriginalArrives(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(memory.NewRepository(), WithRejectedWebhooks(memory.NewRejectedWebhookStore(), decodeCommand))
+	refund := makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 300)
+	recordRejection(t, svc, refund)
+	recordRejection(t, svc, refund)
+
+	open, err := svc.ListRejections(ctx, domain.RejectionOpen)
+	if err != nil || len(open) != 1 || open[0].Rejections != 2 {
+		t.Fatalf("expected one open entry rejected twice, got %+v (err %v)", open, err)
+	}
+	id := open[0].ID
+
+	rw, err := svc.ReprocessRejection(ctx, id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rw.Resolved() || rw.Reprocesses != 1 || rw.LastReprocessError == "" {
+		t.Fatalf("expected the failed reprocess recorded, got %+v", rw)
+	}
+
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	rw, err = svc.ReprocessRejection(ctx, id)
+	if err != nil || !rw.Resolved() || rw.Reprocesses != 2 {
+		t.Fatalf("expected the entry resolved, got %+v (err %v)", rw, err)
+	}
+	if adjs, _ := svc.GetTransactionAdjustments(ctx, "tx1"); len(adjs) != 1 || adjs[0].ID != "adj1" {
+		t.Errorf("expected the refund stored, got %+v", adjs)
+	}
+	if _, err := svc.ReprocessRejection(ctx, id); !errors.Is(err, domain.ErrRejectionResolved) {
+		t.Errorf("expected ErrRejectionResolved, got %v", err)
+	}
+	if resolved, _ := svc.ListRejections(ctx, domain.RejectionResolved); len(resolved) != 1 {
+		t.Errorf("expected one resolved entry, got %+v", resolved)
+	}
+}
+
+func TestReprocessRejectionAcceptedByRetry(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(memory.NewRepository(), WithRejectedWebhooks(memory.NewRejectedWebhookStore(), decodeCommand))
+	purchase := makePurchaseCmd("tx1", "APPROVED", "idem1", 1000)
+	recordRejection(t, svc, purchase)
+	rejections, _ := svc.ListRejections(ctx, "")
+
+	// The provider's own retry went through before anyone reprocessed the entry.
+	svc.ProcessTransaction(ctx, purchase)
+	rw, err := svc.ReprocessRejection(ctx, rejections[0].ID)
+	if err != nil || !rw.Resolved() {
+		t.Errorf("expected a duplicate idempotency key to resolve the entry, got %+v (err %v)", rw, err)
+	}
+}
+
+func TestResolveRejectionOnAcceptedRetry(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(memory.NewRepository(), WithRejectedWebhooks(memory.NewRejectedWebhookStore(), decodeCommand))
+	recordRejection(t, svc, makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 300))
+
+	if err := svc.ResolveRejection(ctx, "idem-other"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if open, _ := svc.ListRejections(ctx, domain.RejectionOpen); len(open) != 1 {
+		t.Fatalf("expected an unrelated key to leave the entry open, got %+v", open)
+	}
+	svc.ResolveRejection(ctx, "idem-adj1")
+	if open, _ := svc.ListRejections(ctx, domain.RejectionOpen); len(open) != 0 {
+		t.Errorf("expected the entry resolved, got %+v", open)
+	}
+}
+
+func TestRejectionsDisabledAndInvalidStatus(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(newMockRepo())
+	if err := svc.RecordRejection(ctx, ports.RecordRejectionCommand{Code: "NOT_FOUND"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := svc.GetRejection(ctx, "rej1"); !errors.Is(err, domain.ErrRejectionNotFound) {
+		t.Errorf("expected ErrRejectionNotFound, got %v", err)
+	}
+	if _, err := svc.ListRejections(ctx, "pending"); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestReprocessedWebhookIsReplayedAndRetried(t *testing.T) {
+	ctx := context.Background()
+	log, records := memory.NewAttemptLog(), memory.NewIdempotencyStore()
+	svc := NewService(memory.NewRepository(),
+		WithRejectedWebhooks(memory.NewRejectedWebhookStore(), decodeCommand),
+		WithAttemptLog(log), WithIdempotencyRecords(records, time.Hour))
+	deliver := func(cmd ports.ProcessTransactionCommand, outcome string, status int) {
+		t.Helper()
+		payload, _ := json.Marshal(cmd)
+		err := svc.RecordAttempt(ctx, ports.RecordAttemptCommand{
+			TransactionID: cmd.TransactionID, IdempotencyKey: cmd.IdempotencyKey, ReceivedAt: time.Now(),
+			Outcome: outcome, StatusCode: status, Payload: payload,
+		})
+		if err != nil {
+			t.Fatalf("record attempt: %v", err)
+		}
+	}
+
+	refund := makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 300)
+	svc.ProcessTransaction(ctx, refund)
+	deliver(refund, "NOT_FOUND", 404)
+	recordRejection(t, svc, refund)
+	svc.RecordResponse(ctx, "idem-adj1", "hash-adj1", 409, []byte(`{"code":"EXCEEDS_ORIGINAL_AMOUNT"}`))
+	purchase := makePurchaseCmd("tx1", "APPROVED", "idem1", 1000)
+	svc.ProcessTransaction(ctx, purchase)
+	deliver(purchase, domain.OutcomeProcessed, 200)
+
+	open, _ := svc.ListRejections(ctx, domain.RejectionOpen)
+	if rw, err := svc.ReprocessRejection(ctx, open[0].ID); err != nil || !rw.Resolved() {
+		t.Fatalf("expected the entry resolved, got %+v (err %v)", rw, err)
+	}
+
+	events, _ := svc.ListTransactionEvents(ctx, "adj1")
+	if len(events) != 2 || events[1].Outcome != domain.OutcomeProcessed || events[1].StatusCode != 200 {
+		t.Fatalf("expected the reprocess recorded as an accepted attempt, got %+v", events)
+	}
+	// A retry is processed again, as a duplicate, rather than answered with the old rejection;
+	// the key still belongs to its payload.
+	if _, ok, err := svc.ClaimKey(ctx, "idem-adj1", "hash-adj1"); ok || err != nil {
+		t.Errorf("expected the stored rejection dropped, got ok %v (err %v)", ok, err)
+	}
+	if _, _, err := svc.ClaimKey(ctx, "idem-adj1", "other"); !errors.Is(err, domain.ErrIdempotencyKeyReused) {
+		t.Errorf("expected ErrIdempotencyKeyReused, got %v", err)
+	}
+
+	target := memory.NewRepository()
+	report, err := Replay(ctx, log, target, decodeCommand)
+	if err != nil || report.Replayed != 2 || len(report.Diverged) != 0 {
+		t.Fatalf("expected the purchase and the refund replayed, got %+v (err %v)", report, err)
+	}
+	if adjs, _ := target.GetAdjustmentsByTransactionID(ctx, "tx1"); len(adjs) != 1 || adjs[0].ID != "adj1" {
+		t.Errorf("expected the refund to survive the replay, got %+v", adjs)
+	}
+}
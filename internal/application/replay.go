@@ -0,0 +1,85 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// PayloadDecoder turns a recorded webhook payload back into the command it was processed as.
+type PayloadDecoder func(payload []byte) (ports.ProcessTransactionCommand, error)
+
+// ReplayReport is what Replay did with the attempt log.
+type ReplayReport struct {
+	// Attempts is the number of attempts read from the log.
+	Attempts int
+	// Replayed counts the accepted attempts processed again; Skipped those already in the target.
+	Replayed int
+	Skipped  int
+	Diverged []ReplayDivergence
+}
+
+// ReplayDivergence is an accepted attempt that did not get the same outcome on replay.
+type ReplayDivergence struct {
+	AttemptID     string
+	TransactionID string
+	// Outcome is the attempt's original outcome, Reason what happened on replay.
+	Outcome string
+	Reason  string
+}
+
+// Replay rebuilds repository state from the attempt log into target. The attempts that were
+// processed or parked are fed again, in arrival order, to a Service built with opts whose clock
+// reads each attempt's received-at time, so parking deadlines and amount rules apply as they did
+// live. Rejected and idempotent attempts changed nothing and are skipped. Replay stops early only
+// when the log cannot be read or ctx is done; an attempt that fails is reported as a divergence.
+func Replay(ctx context.Context, log ports.AttemptLog, target ports.TransactionRepository, decode PayloadDecoder, opts ...Option) (ReplayReport, error) {
+	attempts, err := log.AllAttempts(ctx)
+	if err != nil {
+		return ReplayReport{}, fmt.Errorf("read attempt log: %w", err)
+	}
+	var receivedAt time.Time
+	svc := NewService(target, append(opts, WithClock(func() time.Time { return receivedAt }))...)
+
+	report := ReplayReport{Attempts: len(attempts)}
+	for _, a := range attempts {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if !a.Accepted() {
+			continue
+		}
+		diverge := func(reason string) {
+			report.Diverged = append(report.Diverged, ReplayDivergence{AttemptID: a.ID, TransactionID: a.TransactionID, Outcome: a.Outcome, Reason: reason})
+		}
+		cmd, err := decode(a.Payload)
+		if err != nil {
+			diverge(fmt.Sprintf("decode payload: %v", err))
+			continue
+		}
+		receivedAt = a.ReceivedAt
+		result, err := svc.ProcessTransaction(ctx, cmd)
+		switch {
+		case errors.Is(err, domain.ErrDuplicateIdempotencyKey):
+			report.Skipped++
+		case err != nil:
+			diverge(err.Error())
+		case replayOutcome(result) != a.Outcome:
+			diverge("replayed as " + replayOutcome(result))
+		default:
+			report.Replayed++
+		}
+	}
+	return report, nil
+}
+
+func replayOutcome(result ports.ProcessTransactionResult) string {
+	if result.Parked {
+		return domain.OutcomeParked
+	}
+	return domain.OutcomeProcessed
+}
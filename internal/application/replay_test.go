@@ -0,0 +1,127 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func decodeCommand(payload []byte) (ports.ProcessTransactionCommand, error) {
+	var cmd ports.ProcessTransactionCommand
+	err := json.Unmarshal(payload, &cmd)
+	return cmd, err
+}
+
+// recordLive processes cmds through a live service that logs every attempt, like the webhook
+// handler does, and returns the log.
+func recordLive(t *testing.T, cmds ...ports.ProcessTransactionCommand) *memory.AttemptLog {
+	t.Helper()
+	ctx := context.Background()
+	log := memory.NewAttemptLog()
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	svc := NewService(memory.NewRepository(), WithPendingAdjustments(memory.NewPendingStore(), time.Hour), WithClock(clock.now))
+	for _, cmd := range cmds {
+		clock.t = clock.t.Add(time.Minute)
+		result, err := svc.ProcessTransaction(ctx, cmd)
+		outcome := replayOutcome(result)
+		switch {
+		case err != nil && result.Idempotent:
+			outcome = domain.OutcomeIdempotent
+		case err != nil:
+			outcome = "REJECTED"
+		}
+		payload, _ := json.Marshal(cmd)
+		a, _ := domain.NewWebhookAttempt(newID("att"), cmd.TransactionID, cmd.IdempotencyKey, clock.t, outcome, 200, payload)
+		log.AppendAttempt(ctx, a)
+	}
+	return log
+}
+
+func TestReplayRebuildsRepository(t *testing.T) {
+	ctx := context.Background()
+	log := recordLive(t,
+		makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 300),
+		makePurchaseCmd("tx1", "APPROVED", "idem1", 1000),
+		makePurchaseCmd("tx1", "APPROVED", "idem1", 1000),
+		makeAdjustCmd("adj2", "REFUND", "APPROVED", "tx1", "idem-adj2", 900),
+		makeAdjustCmd("adj3", "REFUND", "APPROVED", "tx1", "idem-adj3", 200),
+	)
+
+	target := memory.NewRepository()
+	report, err := Replay(ctx, log, target, decodeCommand, WithPendingAdjustments(memory.NewPendingStore(), time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Attempts != 5 || report.Replayed != 3 || report.Skipped != 0 || len(report.Diverged) != 0 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if _, err := target.GetTransactionByID(ctx, "tx1"); err != nil {
+		t.Fatalf("expected tx1 rebuilt, got %v", err)
+	}
+	adjs, _ := target.GetAdjustmentsByTransactionID(ctx, "tx1")
+	if len(adjs) != 2 || adjs[0].ID != "adj1" || adjs[1].ID != "adj3" {
+		t.Errorf("expected adj1 applied on purchase then adj3, got %+v", adjs)
+	}
+
+	// Replaying into the rebuilt repository changes nothing.
+	again, err := Replay(ctx, log, target, decodeCommand, WithPendingAdjustments(memory.NewPendingStore(), time.Hour))
+	if err != nil || again.Replayed != 0 || again.Skipped != 3 || len(again.Diverged) != 0 {
+		t.Errorf("expected every accepted attempt skipped, got %+v (err %v)", again, err)
+	}
+}
+
+func TestReplayReportsDivergence(t *testing.T) {
+	ctx := context.Background()
+	log := recordLive(t,
+		makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 300),
+		makePurchaseCmd("tx2", "APPROVED", "idem2", 1000),
+	)
+	log.AppendAttempt(ctx, domain.WebhookAttempt{ID: "att-bad", TransactionID: "tx3", Outcome: domain.OutcomeProcessed, Payload: []byte("{")})
+
+	// Without parking the adjustment that was parked live cannot be applied.
+	report, err := Replay(ctx, log, memory.NewRepository(), decodeCommand)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Replayed != 1 || len(report.Diverged) != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if d := report.Diverged[0]; d.TransactionID != "adj1" || d.Outcome != domain.OutcomeParked || !strings.Contains(d.Reason, "not found") {
+		t.Errorf("unexpected divergence %+v", d)
+	}
+	if d := report.Diverged[1]; d.AttemptID != "att-bad" || !strings.HasPrefix(d.Reason, "decode payload") {
+		t.Errorf("unexpected divergence %+v", d)
+	}
+}
+
+func TestReplayUsesReceivedAtAsClock(t *testing.T) {
+	ctx := context.Background()
+	log := memory.NewAttemptLog()
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	for i, cmd := range []ports.ProcessTransactionCommand{
+		makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 300),
+		makePurchaseCmd("tx1", "APPROVED", "idem1", 1000),
+	} {
+		payload, _ := json.Marshal(cmd)
+		outcome := []string{domain.OutcomeParked, domain.OutcomeProcessed}[i]
+		// The purchase arrived two hours after the adjustment was parked for one.
+		log.AppendAttempt(ctx, domain.WebhookAttempt{ID: newID("att"), TransactionID: cmd.TransactionID, ReceivedAt: start.Add(time.Duration(i) * 2 * time.Hour), Outcome: outcome, Payload: payload})
+	}
+	pending := memory.NewPendingStore()
+	target := memory.NewRepository()
+	if _, err := Replay(ctx, log, target, decodeCommand, WithPendingAdjustments(pending, time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if adjs, _ := target.GetAdjustmentsByTransactionID(ctx, "tx1"); len(adjs) != 0 {
+		t.Errorf("expected the expired adjustment left parked, got %+v", adjs)
+	}
+	if review, _ := pending.ListForReview(ctx, start.Add(2*time.Hour)); len(review) != 1 {
+		t.Errorf("expected adj1 up for review, got %+v", review)
+	}
+}
@@ -4,25 +4,101 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/jailtonjunior/pomelo/internal/application/ports"
 	"github.com/jailtonjunior/pomelo/internal/domain"
+	"github.com/jailtonjunior/pomelo/internal/keylock"
+	"github.com/jailtonjunior/pomelo/internal/logging"
+	"github.com/jailtonjunior/pomelo/internal/tracing"
 )
 
-// Service implements ports.WebhookUseCase.
+const (
+	// DefaultPageSize is the ListTransactions page size when the query sets none.
+	DefaultPageSize = 50
+	// MaxPageSize caps the ListTransactions page size.
+	MaxPageSize = 200
+)
+
+// Service implements ports.WebhookUseCase, ports.PendingAdjustmentUseCase, ports.LedgerUseCase,
+// ports.AuthorizationUseCase, ports.IdempotencyUseCase, ports.HistoryUseCase,
+// ports.RejectionUseCase, ports.DisputeUseCase and ports.SettlementUseCase.
 type Service struct {
-	repo ports.TransactionRepository
+	repo           ports.TransactionRepository
+	pending        ports.PendingAdjustmentStore
+	ledger         ports.LedgerStore
+	authorizations ports.AuthorizationStore
+	pendingTTL     time.Duration
+	holdTTL        time.Duration
+	now            func() time.Time
+	tracer         *tracing.Tracer
+	purchaseLimits domain.PurchaseLimits
+	settlement     string
+
+	idempotency          ports.IdempotencyStore
+	idempotencyRetention time.Duration
+	attempts             ports.AttemptLog
+	rejections           ports.RejectedWebhookStore
+	decode               PayloadDecoder
+
+	disputes ports.DisputeStore
+	// disputeMu serializes dispute transitions. A transition only ever releases a hold, so it
+	// does not need the purchase's budget lock.
+	disputeMu sync.Mutex
+	// budgets serializes, per purchase, every check against the amount the purchase can still
+	// give back: adjustments and dispute openings.
+	budgets keylock.Locks
+}
+
+// Option configures optional Service behaviour.
+type Option func(*Service)
+
+// WithPendingAdjustments parks adjustments whose original purchase has not arrived yet instead of
+// rejecting them with ErrTransactionNotFound. Parked adjustments are applied when the purchase is
+// saved; those not applied within ttl are surfaced for manual review.
+func WithPendingAdjustments(store ports.PendingAdjustmentStore, ttl time.Duration) Option {
+	return func(s *Service) {
+		s.pending = store
+		s.pendingTTL = ttl
+	}
+}
+
+// WithClock overrides the time source used for parking deadlines and authorization holds.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) { s.now = now }
+}
+
+// WithTracer records a span for every processed transaction and adjustment.
+func WithTracer(t *tracing.Tracer) Option {
+	return func(s *Service) { s.tracer = t }
+}
+
+// WithPurchaseLimits replaces the default PURCHASE amount range for the currencies in limits, for
+// webhooks and authorizations alike.
+func WithPurchaseLimits(limits domain.PurchaseLimits) Option {
+	return func(s *Service) { s.purchaseLimits = limits }
+}
+
+// WithSettlementCurrency rejects transactions and adjustments not settled in currency, the
+// program's settlement currency.
+func WithSettlementCurrency(currency string) Option {
+	return func(s *Service) { s.settlement = currency }
 }
 
-func NewService(repo ports.TransactionRepository) *Service {
-	return &Service{repo: repo}
+func NewService(repo ports.TransactionRepository, opts ...Option) *Service {
+	s := &Service{repo: repo, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *Service) ProcessTransaction(ctx context.Context, cmd ports.ProcessTransactionCommand) (ports.ProcessTransactionResult, error) {
-	switch domain.TransactionType(cmd.TransactionType) {
-	case domain.TypePurchase:
-		return s.processPurchase(ctx, cmd)
-	case domain.TypeReversalPurchase, domain.TypeRefund:
+	switch txType := domain.TransactionType(cmd.TransactionType); {
+	case txType.IsOriginal():
+		return s.processOriginal(ctx, cmd)
+	case txType.IsAdjustment():
 		return s.processAdjustment(ctx, cmd)
 	default:
 		return ports.ProcessTransactionResult{}, fmt.Errorf("%w: %s", domain.ErrInvalidTransactionType, cmd.TransactionType)
@@ -33,11 +109,98 @@ func (s *Service) GetTransaction(ctx context.Context, id string) (domain.Transac
 	return s.repo.GetTransactionByID(ctx, id)
 }
 
-func (s *Service) ListTransactions(ctx context.Context) ([]domain.Transaction, error) {
-	return s.repo.ListTransactions(ctx)
+func (s *Service) GetTransactionSummary(ctx context.Context, id string) (ports.TransactionSummary, error) {
+	tx, err := s.repo.GetTransactionByID(ctx, id)
+	if err != nil {
+		return ports.TransactionSummary{}, err
+	}
+	adjs, err := s.repo.GetAdjustmentsByTransactionID(ctx, id)
+	if err != nil {
+		return ports.TransactionSummary{}, err
+	}
+	// Reversals (of any REVERSAL_* type) and refunds are summed separately with the same rules
+	// the budget check uses.
+	var reversals, refunds []domain.Adjustment
+	for _, adj := range adjs {
+		if adj.Type == domain.TypeRefund {
+			refunds = append(refunds, adj)
+		} else {
+			reversals = append(reversals, adj)
+		}
+	}
+	currency := tx.Amount.Local.Currency
+	reversed, err := s.sumExistingAdjustments(reversals, currency)
+	if err != nil {
+		return ports.TransactionSummary{}, err
+	}
+	refunded, err := s.sumExistingAdjustments(refunds, currency)
+	if err != nil {
+		return ports.TransactionSummary{}, err
+	}
+	balance, err := domain.NewPurchaseBalance(tx, reversed, refunded)
+	if err != nil {
+		return ports.TransactionSummary{}, err
+	}
+	disputed, err := s.sumDisputed(ctx, id, currency)
+	if err != nil {
+		return ports.TransactionSummary{}, err
+	}
+	return ports.TransactionSummary{Transaction: tx, Adjustments: adjs, Balance: balance.WithDisputed(disputed)}, nil
 }
 
-func (s *Service) processPurchase(ctx context.Context, cmd ports.ProcessTransactionCommand) (ports.ProcessTransactionResult, error) {
+func (s *Service) GetTransactionAdjustments(ctx context.Context, id string) ([]domain.Adjustment, error) {
+	if _, err := s.repo.GetTransactionByID(ctx, id); err != nil {
+		return nil, err
+	}
+	adjs, err := s.repo.GetAdjustmentsByTransactionID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if adjs == nil {
+		adjs = []domain.Adjustment{}
+	}
+	return adjs, nil
+}
+
+// ListTransactions validates q, applies the page size defaults and delegates to the repository.
+func (s *Service) ListTransactions(ctx context.Context, q ports.TransactionQuery) (ports.TransactionPage, error) {
+	switch q.SortBy {
+	case "":
+		q.SortBy = ports.SortByCreatedAt
+	case ports.SortByCreatedAt, ports.SortByAmount, ports.SortByID:
+	default:
+		return ports.TransactionPage{}, fmt.Errorf("%w: unknown sort field %q", domain.ErrInvalidInput, q.SortBy)
+	}
+	if q.MinAmount != nil && q.MaxAmount != nil && *q.MinAmount > *q.MaxAmount {
+		return ports.TransactionPage{}, fmt.Errorf("%w: min amount greater than max amount", domain.ErrInvalidInput)
+	}
+	if !q.CreatedFrom.IsZero() && !q.CreatedTo.IsZero() && !q.CreatedFrom.Before(q.CreatedTo) {
+		return ports.TransactionPage{}, fmt.Errorf("%w: created_from must be before created_to", domain.ErrInvalidInput)
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageSize
+	}
+	q.Limit = min(q.Limit, MaxPageSize)
+	return s.repo.ListTransactions(ctx, q)
+}
+
+// ListAdjustmentsForReview returns parked adjustments that expired or failed validation.
+func (s *Service) ListAdjustmentsForReview(ctx context.Context) ([]domain.PendingAdjustment, error) {
+	if s.pending == nil {
+		return []domain.PendingAdjustment{}, nil
+	}
+	return s.pending.ListForReview(ctx, s.now())
+}
+
+// processOriginal handles every type that starts a transaction: PURCHASE, WITHDRAWAL, EXTRACASH,
+// BALANCE_INQUIRY, PAYMENT and CREDIT_VOUCHER.
+func (s *Service) processOriginal(ctx context.Context, cmd ports.ProcessTransactionCommand) (result ports.ProcessTransactionResult, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.processOriginal", commandAttributes(cmd)...)
+	defer func() {
+		endProcessingSpan(span, result, err)
+		logDecision(ctx, cmd, result, err)
+	}()
+
 	// 1. Advisory idempotency check (fast path — not atomic, eliminates most duplicates before object construction)
 	if _, exists := s.repo.GetByIdempotencyKey(ctx, cmd.IdempotencyKey); exists {
 		return ports.ProcessTransactionResult{TransactionID: cmd.TransactionID, Idempotent: true}, domain.ErrDuplicateIdempotencyKey
@@ -81,9 +244,11 @@ func (s *Service) processPurchase(ctx context.Context, cmd ports.ProcessTransact
 		IdempotencyKey: cmd.IdempotencyKey,
 	}
 
-	// 3. Create purchase
-	tx, err := domain.NewPurchase(
+	// 3. Create transaction — the type decides the allowed amount range
+	tx, err := domain.NewTransactionWithLimits(
+		s.purchaseLimits,
 		cmd.TransactionID,
+		domain.TransactionType(cmd.TransactionType),
 		domain.TransactionStatus(cmd.TransactionStatus),
 		amount, merchant, event,
 		cmd.UserID, cmd.CardID, cmd.Country, cmd.Currency, cmd.PointOfSale,
@@ -91,6 +256,9 @@ func (s *Service) processPurchase(ctx context.Context, cmd ports.ProcessTransact
 	if err != nil {
 		return ports.ProcessTransactionResult{}, err
 	}
+	if err := s.validateSettlement(amount); err != nil {
+		return ports.ProcessTransactionResult{}, err
+	}
 
 	// 4. Save — atomically re-checks idempotency under WLock (handles the TOCTOU race case)
 	if err := s.repo.SaveTransaction(ctx, tx); err != nil {
@@ -99,10 +267,25 @@ func (s *Service) processPurchase(ctx context.Context, cmd ports.ProcessTransact
 		}
 		return ports.ProcessTransactionResult{}, err
 	}
-	return ports.ProcessTransactionResult{TransactionID: tx.ID}, nil
+
+	// 5. Post to the ledger, release the authorization hold, then apply adjustments that arrived
+	// before this transaction. Every step runs even if an earlier one fails: the transaction is
+	// saved, so a retry is an idempotent hit and would not run them again.
+	err = errors.Join(
+		s.post(ctx, domain.PostTransaction(tx)),
+		s.matchAuthorization(ctx, tx),
+		s.applyParked(ctx, tx.ID),
+	)
+	return ports.ProcessTransactionResult{TransactionID: tx.ID}, err
 }
 
-func (s *Service) processAdjustment(ctx context.Context, cmd ports.ProcessTransactionCommand) (ports.ProcessTransactionResult, error) {
+func (s *Service) processAdjustment(ctx context.Context, cmd ports.ProcessTransactionCommand) (result ports.ProcessTransactionResult, err error) {
+	ctx, span := s.tracer.Start(ctx, "Service.processAdjustment", commandAttributes(cmd)...)
+	defer func() {
+		endProcessingSpan(span, result, err)
+		logDecision(ctx, cmd, result, err)
+	}()
+
 	// 1. Validate original transaction ID before any I/O — prevents 404 masking a 400 validation error
 	if cmd.OriginalTransactionID == "" {
 		return ports.ProcessTransactionResult{}, domain.ErrOriginalTransactionRequired
@@ -113,9 +296,22 @@ func (s *Service) processAdjustment(ctx context.Context, cmd ports.ProcessTransa
 		return ports.ProcessTransactionResult{TransactionID: cmd.TransactionID, Idempotent: true}, domain.ErrDuplicateIdempotencyKey
 	}
 
-	// 3. Get original transaction
-	original, err := s.repo.GetTransactionByID(ctx, cmd.OriginalTransactionID)
-	if err != nil {
+	// A retry of an adjustment that is still parked (or awaiting review) is a duplicate too.
+	if s.pending != nil {
+		if p, exists := s.pending.GetByIdempotencyKey(ctx, cmd.IdempotencyKey); exists {
+			return ports.ProcessTransactionResult{
+				TransactionID: cmd.TransactionID,
+				Idempotent:    true,
+				Parked:        p.State(s.now()) == domain.PendingParked,
+			}, domain.ErrDuplicateIdempotencyKey
+		}
+	}
+
+	// 3. Advisory existence check — keeps 404 ahead of amount validation errors for out-of-order
+	// adjustments. AppendAdjustment re-reads the original under its lock.
+	_, err = s.repo.GetTransactionByID(ctx, cmd.OriginalTransactionID)
+	park := errors.Is(err, domain.ErrTransactionNotFound) && s.pending != nil
+	if err != nil && !park {
 		return ports.ProcessTransactionResult{}, err
 	}
 
@@ -169,30 +365,94 @@ func (s *Service) processAdjustment(ctx context.Context, cmd ports.ProcessTransa
 	if err != nil {
 		return ports.ProcessTransactionResult{}, err
 	}
+	if err := s.validateSettlement(amount); err != nil {
+		return ports.ProcessTransactionResult{}, err
+	}
 
-	// 5. Get existing adjustments and sum approved ones
-	existingAdjs, err := s.repo.GetAdjustmentsByTransactionID(ctx, cmd.OriginalTransactionID)
-	if err != nil {
+	// 5. Original purchase not seen yet — park the adjustment until it arrives
+	if park {
+		return s.parkAdjustment(ctx, adj)
+	}
+
+	// 6. Validate against the purchase and save
+	if err := s.appendAdjustment(ctx, adj); err != nil {
+		if errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+			return ports.ProcessTransactionResult{TransactionID: cmd.TransactionID, Idempotent: true}, err
+		}
 		return ports.ProcessTransactionResult{}, err
 	}
-	existingTotal, err := s.sumExistingAdjustments(existingAdjs, cmd.LocalCurrency)
+	return ports.ProcessTransactionResult{TransactionID: adj.ID}, nil
+}
+
+// appendAdjustment sums existing approved adjustments, validates and saves as one atomic repository
+// operation — concurrent adjustments for the same purchase cannot both pass validation against a
+// stale total. A saved adjustment is then posted to the ledger.
+func (s *Service) appendAdjustment(ctx context.Context, adj domain.Adjustment) error {
+	// The purchase's budget lock spans both stores, so a dispute cannot open between the read
+	// of the disputed total and the save. Disputes are read before the repository operation: on
+	// SQLite the check runs on the only database connection, which a SQL dispute store would
+	// wait for.
+	defer s.budgets.Lock(adj.OriginalTransactionID)()
+	disputed, err := s.sumDisputed(ctx, adj.OriginalTransactionID, adj.Amount.Local.Currency)
+	if err != nil {
+		return err
+	}
+	err = s.repo.AppendAdjustment(ctx, adj, func(original domain.Transaction, existing []domain.Adjustment) error {
+		existingTotal, err := s.sumExistingAdjustments(existing, adj.Amount.Local.Currency)
+		if err != nil {
+			return err
+		}
+		existingTotal.Amount += disputed.Amount
+		return adj.ValidateAgainstOriginal(original, existingTotal)
+	})
 	if err != nil {
-		return ports.ProcessTransactionResult{}, err
+		return err
 	}
+	return s.post(ctx, domain.PostAdjustment(adj))
+}
 
-	// 6. Validate
-	if err := adj.ValidateAgainstPurchase(original, existingTotal); err != nil {
+func (s *Service) parkAdjustment(ctx context.Context, adj domain.Adjustment) (ports.ProcessTransactionResult, error) {
+	p, err := domain.NewPendingAdjustment(adj, s.now(), s.pendingTTL)
+	if err != nil {
 		return ports.ProcessTransactionResult{}, err
 	}
-
-	// 7. Save — atomically re-checks idempotency under WLock (handles the TOCTOU race case)
-	if err := s.repo.SaveAdjustment(ctx, adj); err != nil {
+	if err := s.pending.Park(ctx, p); err != nil {
 		if errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
-			return ports.ProcessTransactionResult{TransactionID: cmd.TransactionID, Idempotent: true}, err
+			return ports.ProcessTransactionResult{TransactionID: adj.ID, Idempotent: true, Parked: true}, err
 		}
 		return ports.ProcessTransactionResult{}, err
 	}
-	return ports.ProcessTransactionResult{TransactionID: adj.ID}, nil
+	// The purchase may have been saved after the existence check and drained the store before
+	// Park ran. Re-check so the adjustment is not stranded until it expires.
+	if _, err := s.repo.GetTransactionByID(ctx, adj.OriginalTransactionID); err == nil {
+		if err := s.applyParked(ctx, adj.OriginalTransactionID); err != nil {
+			return ports.ProcessTransactionResult{}, err
+		}
+	}
+	return ports.ProcessTransactionResult{TransactionID: adj.ID, Parked: true}, nil
+}
+
+// applyParked applies, in arrival order, the adjustments parked for originalTxID. One that fails
+// validation is kept for manual review with the failure reason; a duplicate was already applied
+// by a retried webhook and is dropped.
+func (s *Service) applyParked(ctx context.Context, originalTxID string) error {
+	if s.pending == nil {
+		return nil
+	}
+	parked, takeErr := s.pending.TakeParked(ctx, originalTxID, s.now())
+	var errs []error
+	for _, p := range parked {
+		err := s.appendAdjustment(ctx, p.Adjustment)
+		if err == nil || errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+			continue
+		}
+		logging.FromContext(ctx).Warn("parked adjustment kept for review",
+			"transaction_id", p.Adjustment.ID, "original_transaction_id", originalTxID, "reason", err.Error())
+		if err := s.pending.Reject(ctx, p, err.Error()); err != nil {
+			errs = append(errs, fmt.Errorf("keep adjustment %s for review: %w", p.Adjustment.ID, err))
+		}
+	}
+	return errors.Join(append(errs, takeErr)...)
 }
 
 func (s *Service) sumExistingAdjustments(adjs []domain.Adjustment, currency string) (domain.Money, error) {
@@ -211,3 +471,45 @@ func (s *Service) sumExistingAdjustments(adjs []domain.Adjustment, currency stri
 	}
 	return total, nil
 }
+
+// commandAttributes identifies the webhook a processing span belongs to.
+func commandAttributes(cmd ports.ProcessTransactionCommand) []tracing.Attribute {
+	return []tracing.Attribute{
+		tracing.String("transaction.id", cmd.TransactionID),
+		tracing.String("transaction.type", cmd.TransactionType),
+		tracing.String("transaction.idempotency_key", cmd.IdempotencyKey),
+		tracing.String("card.id", cmd.CardID),
+	}
+}
+
+// endProcessingSpan ends a processing span. A duplicate webhook is an idempotent hit, not a failure.
+func endProcessingSpan(span *tracing.Span, result ports.ProcessTransactionResult, err error) {
+	span.SetAttributes(tracing.Bool("transaction.idempotent", result.Idempotent), tracing.Bool("transaction.parked", result.Parked))
+	if !errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// logDecision logs the outcomes worth following per request: duplicates, parked adjustments and
+// adjustments refused for exceeding what is left of the original amount.
+func logDecision(ctx context.Context, cmd ports.ProcessTransactionCommand, result ports.ProcessTransactionResult, err error) {
+	log := logging.FromContext(ctx).With("transaction_id", cmd.TransactionID, "type", cmd.TransactionType)
+	switch {
+	case result.Idempotent:
+		log.Info("idempotent hit", "idempotency_key", cmd.IdempotencyKey, "parked", result.Parked)
+	case result.Parked:
+		log.Info("adjustment parked", "original_transaction_id", cmd.OriginalTransactionID)
+	case errors.Is(err, domain.ErrExceedsOriginalAmount):
+		log.Warn("adjustment exceeds original amount", "original_transaction_id", cmd.OriginalTransactionID,
+			"amount", cmd.LocalAmount, "currency", cmd.LocalCurrency)
+	}
+}
+
+// validateSettlement enforces the settlement currency, when one is configured.
+func (s *Service) validateSettlement(amount domain.AmountBreakdown) error {
+	if s.settlement == "" {
+		return nil
+	}
+	return amount.ValidateSettlement(s.settlement)
+}
@@ -1,13 +1,22 @@
 package application
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
 	"github.com/jailtonjunior/pomelo/internal/application/ports"
 	"github.com/jailtonjunior/pomelo/internal/domain"
+	"github.com/jailtonjunior/pomelo/internal/logging"
+	"github.com/jailtonjunior/pomelo/internal/tracing"
 )
 
 // --- Mock Repository ---
@@ -16,6 +25,7 @@ type mockRepo struct {
 	transactions    map[string]domain.Transaction
 	adjustments     map[string][]domain.Adjustment
 	idempotencyKeys map[string]string
+	lastQuery       ports.TransactionQuery
 }
 
 func newMockRepo() *mockRepo {
@@ -47,6 +57,22 @@ func (r *mockRepo) SaveAdjustment(_ context.Context, adj domain.Adjustment) erro
 	return nil
 }
 
+func (r *mockRepo) AppendAdjustment(_ context.Context, adj domain.Adjustment, check ports.AdjustmentCheck) error {
+	if _, exists := r.idempotencyKeys[adj.Event.IdempotencyKey]; exists {
+		return domain.ErrDuplicateIdempotencyKey
+	}
+	original, ok := r.transactions[adj.OriginalTransactionID]
+	if !ok {
+		return domain.ErrTransactionNotFound
+	}
+	if err := check(original, r.adjustments[adj.OriginalTransactionID]); err != nil {
+		return err
+	}
+	r.idempotencyKeys[adj.Event.IdempotencyKey] = adj.ID
+	r.adjustments[adj.OriginalTransactionID] = append(r.adjustments[adj.OriginalTransactionID], adj)
+	return nil
+}
+
 func (r *mockRepo) GetTransactionByID(_ context.Context, id string) (domain.Transaction, error) {
 	tx, ok := r.transactions[id]
 	if !ok {
@@ -59,19 +85,35 @@ func (r *mockRepo) GetAdjustmentsByTransactionID(_ context.Context, originalTxID
 	return r.adjustments[originalTxID], nil
 }
 
+func (r *mockRepo) GetAdjustmentByID(_ context.Context, id string) (domain.Adjustment, error) {
+	for _, adjs := range r.adjustments {
+		for _, adj := range adjs {
+			if adj.ID == id {
+				return adj, nil
+			}
+		}
+	}
+	return domain.Adjustment{}, domain.ErrTransactionNotFound
+}
+
 func (r *mockRepo) GetByIdempotencyKey(_ context.Context, key string) (string, bool) {
 	id, ok := r.idempotencyKeys[key]
 	return id, ok
 }
 
-func (r *mockRepo) ListTransactions(_ context.Context) ([]domain.Transaction, error) {
+func (r *mockRepo) ListTransactions(_ context.Context, q ports.TransactionQuery) (ports.TransactionPage, error) {
+	r.lastQuery = q
 	result := make([]domain.Transaction, 0, len(r.transactions))
 	for _, tx := range r.transactions {
 		result = append(result, tx)
 	}
-	return result, nil
+	return ports.TransactionPage{Transactions: result}, nil
 }
 
+// The mock keeps no outbox; dispatcher tests run against memory.Repository.
+func (r *mockRepo) PendingEvents(context.Context, int) ([]domain.OutboxEvent, error) { return nil, nil }
+func (r *mockRepo) MarkDispatched(context.Context, ...string) error                  { return nil }
+
 // --- Helpers ---
 
 func makePurchaseCmd(id, status, idemKey string, amount int64) ports.ProcessTransactionCommand {
@@ -233,6 +275,135 @@ func TestProcessOutOfOrder(t *testing.T) {
 	}
 }
 
+// fakeClock is a manually advanced time source for parking deadlines.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newParkingService(repo ports.TransactionRepository) (*Service, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	return NewService(repo, WithPendingAdjustments(memory.NewPendingStore(), time.Hour), WithClock(clock.now)), clock
+}
+
+func TestProcessOutOfOrderParkedAndAppliedOnPurchase(t *testing.T) {
+	repo := newMockRepo()
+	svc, _ := newParkingService(repo)
+	ctx := context.Background()
+
+	result, err := svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 500))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Parked || result.TransactionID != "adj1" {
+		t.Errorf("expected adj1 parked, got %+v", result)
+	}
+	if len(repo.adjustments["tx1"]) != 0 {
+		t.Fatal("parked adjustment must not be saved before the purchase")
+	}
+
+	if _, err := svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.adjustments["tx1"]) != 1 {
+		t.Fatalf("expected parked adjustment applied on purchase, got %d", len(repo.adjustments["tx1"]))
+	}
+	// The budget now accounts for the applied adjustment.
+	_, err = svc.ProcessTransaction(ctx, makeAdjustCmd("adj2", "REFUND", "APPROVED", "tx1", "idem-adj2", 600))
+	if !errors.Is(err, domain.ErrExceedsOriginalAmount) {
+		t.Errorf("expected ErrExceedsOriginalAmount, got %v", err)
+	}
+}
+
+func TestProcessParkedRetryIsIdempotent(t *testing.T) {
+	svc, _ := newParkingService(newMockRepo())
+	ctx := context.Background()
+	cmd := makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 500)
+	svc.ProcessTransaction(ctx, cmd)
+
+	result, err := svc.ProcessTransaction(ctx, cmd)
+	if !errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+		t.Fatalf("expected ErrDuplicateIdempotencyKey, got %v", err)
+	}
+	if !result.Idempotent || !result.Parked {
+		t.Errorf("expected idempotent parked result, got %+v", result)
+	}
+}
+
+func TestProcessParkedExceedingPurchaseKeptForReview(t *testing.T) {
+	repo := newMockRepo()
+	svc, _ := newParkingService(repo)
+	ctx := context.Background()
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 800))
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj2", "REFUND", "APPROVED", "tx1", "idem-adj2", 800))
+
+	if _, err := svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := repo.adjustments["tx1"]; len(got) != 1 || got[0].ID != "adj1" {
+		t.Fatalf("expected only the first parked adjustment applied, got %v", got)
+	}
+	review, _ := svc.ListAdjustmentsForReview(ctx)
+	if len(review) != 1 || review[0].Adjustment.ID != "adj2" {
+		t.Fatalf("expected adj2 kept for review, got %v", review)
+	}
+	if review[0].Reason != domain.ErrExceedsOriginalAmount.Error() {
+		t.Errorf("expected exceeds reason, got %q", review[0].Reason)
+	}
+}
+
+func TestProcessParkedExpiredNotApplied(t *testing.T) {
+	repo := newMockRepo()
+	svc, clock := newParkingService(repo)
+	ctx := context.Background()
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 500))
+
+	if review, _ := svc.ListAdjustmentsForReview(ctx); len(review) != 0 {
+		t.Fatalf("expected nothing for review before expiry, got %d", len(review))
+	}
+	clock.t = clock.t.Add(2 * time.Hour)
+	if _, err := svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.adjustments["tx1"]) != 0 {
+		t.Error("expired adjustment must not be applied automatically")
+	}
+	review, _ := svc.ListAdjustmentsForReview(ctx)
+	if len(review) != 1 || review[0].State(clock.t) != domain.PendingExpired {
+		t.Errorf("expected expired adjustment surfaced for review, got %v", review)
+	}
+}
+
+func TestProcessParkedValidationErrorNotParked(t *testing.T) {
+	svc, _ := newParkingService(newMockRepo())
+	_, err := svc.ProcessTransaction(context.Background(), makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", -1))
+	if !errors.Is(err, domain.ErrNegativeAmount) {
+		t.Errorf("expected ErrNegativeAmount, got %v", err)
+	}
+}
+
+// TestParkedAdjustmentRacingPurchaseIsApplied sends a purchase and its adjustment concurrently;
+// whichever side wins, the adjustment must end up applied rather than stranded in the store.
+func TestParkedAdjustmentRacingPurchaseIsApplied(t *testing.T) {
+	for i := range 200 {
+		repo := memory.NewRepository()
+		svc, _ := newParkingService(repo)
+		ctx := context.Background()
+
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 500))
+		})
+		wg.Go(func() {
+			svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+		})
+		wg.Wait()
+
+		if adjs, _ := repo.GetAdjustmentsByTransactionID(ctx, "tx1"); len(adjs) != 1 {
+			t.Fatalf("iteration %d: expected adjustment applied, got %d", i, len(adjs))
+		}
+	}
+}
+
 func TestProcessDuplicateTransactionID(t *testing.T) {
 	svc := NewService(newMockRepo())
 	svc.ProcessTransaction(context.Background(), makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
@@ -261,6 +432,123 @@ func TestProcessAdjustmentOnRejectedPurchase(t *testing.T) {
 	}
 }
 
+func makeOriginalCmd(id, txType, status, idemKey string, amount int64) ports.ProcessTransactionCommand {
+	cmd := makePurchaseCmd(id, status, idemKey, amount)
+	cmd.TransactionType = txType
+	return cmd
+}
+
+func TestProcessOriginalTypes(t *testing.T) {
+	svc := NewService(newMockRepo())
+	ctx := context.Background()
+	cases := map[domain.TransactionType]int64{
+		domain.TypeWithdrawal:     20000,
+		domain.TypeExtracash:      5000,
+		domain.TypeBalanceInquiry: 0,
+		domain.TypePayment:        15000,
+		domain.TypeCreditVoucher:  3000,
+	}
+	for txType, amount := range cases {
+		id := "tx-" + string(txType)
+		if _, err := svc.ProcessTransaction(ctx, makeOriginalCmd(id, string(txType), "APPROVED", "idem-"+id, amount)); err != nil {
+			t.Fatalf("%s: unexpected error: %v", txType, err)
+		}
+		tx, err := svc.GetTransaction(ctx, id)
+		if err != nil || tx.Type != txType {
+			t.Errorf("%s: expected stored with its type, got %s (%v)", txType, tx.Type, err)
+		}
+	}
+}
+
+func TestProcessOriginalTypeAmountOutOfRange(t *testing.T) {
+	svc := NewService(newMockRepo())
+	_, err := svc.ProcessTransaction(context.Background(), makeOriginalCmd("tx1", "WITHDRAWAL", "APPROVED", "idem1", 500))
+	if !errors.Is(err, domain.ErrAmountOutOfRange) {
+		t.Errorf("expected ErrAmountOutOfRange, got %v", err)
+	}
+	_, err = svc.ProcessTransaction(context.Background(), makeOriginalCmd("tx2", "BALANCE_INQUIRY", "APPROVED", "idem2", 100))
+	if !errors.Is(err, domain.ErrAmountOutOfRange) {
+		t.Errorf("expected ErrAmountOutOfRange, got %v", err)
+	}
+}
+
+func TestProcessSettlementCurrency(t *testing.T) {
+	svc := NewService(newMockRepo(), WithSettlementCurrency("USD"))
+	_, err := svc.ProcessTransaction(context.Background(), makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	var mismatch *domain.CurrencyMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Field != domain.FieldSettlementCurrency {
+		t.Fatalf("expected a settlement currency mismatch, got %v", err)
+	}
+
+	cmd := makePurchaseCmd("tx1", "APPROVED", "idem1", 1000)
+	cmd.SettlementAmount, cmd.SettlementCurrency = 200, "USD"
+	if _, err := svc.ProcessTransaction(context.Background(), cmd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	adj := makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 500)
+	if _, err := svc.ProcessTransaction(context.Background(), adj); !errors.As(err, &mismatch) {
+		t.Errorf("expected adjustments to be checked too, got %v", err)
+	}
+}
+
+func TestProcessCrossBorderStoresExchangeRate(t *testing.T) {
+	repo := newMockRepo()
+	cmd := makePurchaseCmd("tx1", "APPROVED", "idem1", 5235)
+	cmd.TxAmount, cmd.TxCurrency = 1000, "USD"
+	if _, err := NewService(repo).ProcessTransaction(context.Background(), cmd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx, _ := repo.GetTransactionByID(context.Background(), "tx1"); tx.ExchangeRate != "5.235000" {
+		t.Errorf("expected rate 5.235000, got %q", tx.ExchangeRate)
+	}
+}
+
+func TestProcessWithdrawalReversal(t *testing.T) {
+	svc := NewService(newMockRepo())
+	ctx := context.Background()
+	svc.ProcessTransaction(ctx, makeOriginalCmd("tx1", "WITHDRAWAL", "APPROVED", "idem1", 20000))
+	if _, err := svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REVERSAL_WITHDRAWAL", "APPROVED", "tx1", "idem-adj1", 15000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err := svc.ProcessTransaction(ctx, makeAdjustCmd("adj2", "REVERSAL_WITHDRAWAL", "APPROVED", "tx1", "idem-adj2", 6000))
+	if !errors.Is(err, domain.ErrExceedsOriginalAmount) {
+		t.Errorf("expected ErrExceedsOriginalAmount, got %v", err)
+	}
+
+	summary, _ := svc.GetTransactionSummary(ctx, "tx1")
+	if summary.Balance.TotalReversed.Amount != 15000 || summary.Balance.State != domain.PurchaseStatePartiallyReversed {
+		t.Errorf("expected 15000 reversed and PARTIALLY_REVERSED, got %+v", summary.Balance)
+	}
+}
+
+func TestProcessAdjustmentTypeMismatch(t *testing.T) {
+	svc := NewService(newMockRepo())
+	ctx := context.Background()
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	svc.ProcessTransaction(ctx, makeOriginalCmd("tx2", "WITHDRAWAL", "APPROVED", "idem2", 20000))
+
+	cases := []ports.ProcessTransactionCommand{
+		makeAdjustCmd("adj1", "REVERSAL_WITHDRAWAL", "APPROVED", "tx1", "idem-adj1", 500),
+		makeAdjustCmd("adj2", "REFUND", "APPROVED", "tx2", "idem-adj2", 500),
+		makeAdjustCmd("adj3", "REVERSAL_PURCHASE", "APPROVED", "tx2", "idem-adj3", 500),
+	}
+	for _, cmd := range cases {
+		if _, err := svc.ProcessTransaction(ctx, cmd); !errors.Is(err, domain.ErrOriginalTypeMismatch) {
+			t.Errorf("%s on %s: expected ErrOriginalTypeMismatch, got %v", cmd.TransactionType, cmd.OriginalTransactionID, err)
+		}
+	}
+}
+
+func TestProcessReversalOnRejectedWithdrawal(t *testing.T) {
+	svc := NewService(newMockRepo())
+	ctx := context.Background()
+	svc.ProcessTransaction(ctx, makeOriginalCmd("tx1", "WITHDRAWAL", "REJECTED", "idem1", 20000))
+	_, err := svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REVERSAL_WITHDRAWAL", "APPROVED", "tx1", "idem-adj1", 20000))
+	if !errors.Is(err, domain.ErrPurchaseNotApproved) {
+		t.Errorf("expected ErrPurchaseNotApproved, got %v", err)
+	}
+}
+
 func TestGetTransaction(t *testing.T) {
 	svc := NewService(newMockRepo())
 	svc.ProcessTransaction(context.Background(), makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
@@ -281,16 +569,124 @@ func TestGetTransactionNotFound(t *testing.T) {
 	}
 }
 
+func TestGetTransactionSummary(t *testing.T) {
+	svc := NewService(newMockRepo())
+	ctx := context.Background()
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 300))
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj2", "REVERSAL_PURCHASE", "APPROVED", "tx1", "idem-adj2", 200))
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj3", "REFUND", "REJECTED", "tx1", "idem-adj3", 100))
+
+	summary, err := svc.GetTransactionSummary(ctx, "tx1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(summary.Adjustments) != 3 {
+		t.Errorf("expected 3 adjustments, got %d", len(summary.Adjustments))
+	}
+	b := summary.Balance
+	if b.TotalRefunded.Amount != 300 || b.TotalReversed.Amount != 200 {
+		t.Errorf("expected refunded 300 and reversed 200 (rejected excluded), got %+v", b)
+	}
+	if b.Remaining.Amount != 500 {
+		t.Errorf("expected remaining 500, got %d", b.Remaining.Amount)
+	}
+	if b.State != domain.PurchaseStatePartiallyAdjusted {
+		t.Errorf("expected PARTIALLY_ADJUSTED, got %s", b.State)
+	}
+}
+
+func TestGetTransactionSummaryFullyReversed(t *testing.T) {
+	svc := NewService(newMockRepo())
+	ctx := context.Background()
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REVERSAL_PURCHASE", "APPROVED", "tx1", "idem-adj1", 1000))
+
+	summary, _ := svc.GetTransactionSummary(ctx, "tx1")
+	if summary.Balance.State != domain.PurchaseStateFullyReversed || summary.Balance.Remaining.Amount != 0 {
+		t.Errorf("expected FULLY_REVERSED with nothing remaining, got %+v", summary.Balance)
+	}
+}
+
+func TestGetTransactionSummaryNotFound(t *testing.T) {
+	svc := NewService(newMockRepo())
+	_, err := svc.GetTransactionSummary(context.Background(), "nonexistent")
+	if !errors.Is(err, domain.ErrTransactionNotFound) {
+		t.Errorf("expected ErrTransactionNotFound, got %v", err)
+	}
+}
+
+func TestGetTransactionAdjustments(t *testing.T) {
+	svc := NewService(newMockRepo())
+	ctx := context.Background()
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+
+	adjs, err := svc.GetTransactionAdjustments(ctx, "tx1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if adjs == nil || len(adjs) != 0 {
+		t.Errorf("expected empty non-nil list, got %v", adjs)
+	}
+
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 300))
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj2", "REFUND", "APPROVED", "tx1", "idem-adj2", 300))
+	adjs, _ = svc.GetTransactionAdjustments(ctx, "tx1")
+	if len(adjs) != 2 || adjs[0].ID != "adj1" || adjs[1].ID != "adj2" {
+		t.Errorf("expected [adj1 adj2] in arrival order, got %v", adjs)
+	}
+
+	if _, err := svc.GetTransactionAdjustments(ctx, "nonexistent"); !errors.Is(err, domain.ErrTransactionNotFound) {
+		t.Errorf("expected ErrTransactionNotFound, got %v", err)
+	}
+}
+
 func TestListTransactions(t *testing.T) {
 	svc := NewService(newMockRepo())
 	svc.ProcessTransaction(context.Background(), makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
 	svc.ProcessTransaction(context.Background(), makePurchaseCmd("tx2", "APPROVED", "idem2", 2000))
-	txs, err := svc.ListTransactions(context.Background())
+	page, err := svc.ListTransactions(context.Background(), ports.TransactionQuery{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if len(txs) != 2 {
-		t.Errorf("expected 2 transactions, got %d", len(txs))
+	if len(page.Transactions) != 2 {
+		t.Errorf("expected 2 transactions, got %d", len(page.Transactions))
+	}
+}
+
+func TestListTransactionsPageSize(t *testing.T) {
+	repo := newMockRepo()
+	svc := NewService(repo)
+	cases := []struct{ limit, want int }{
+		{0, DefaultPageSize},
+		{10, 10},
+		{MaxPageSize + 1, MaxPageSize},
+	}
+	for _, tc := range cases {
+		svc.ListTransactions(context.Background(), ports.TransactionQuery{Limit: tc.limit})
+		if repo.lastQuery.Limit != tc.want {
+			t.Errorf("limit %d: expected %d, got %d", tc.limit, tc.want, repo.lastQuery.Limit)
+		}
+	}
+	if repo.lastQuery.SortBy != ports.SortByCreatedAt {
+		t.Errorf("expected default sort created_at, got %q", repo.lastQuery.SortBy)
+	}
+}
+
+func TestListTransactionsInvalidQuery(t *testing.T) {
+	svc := NewService(newMockRepo())
+	low, high := int64(500), int64(100)
+	from := time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)
+	cases := map[string]ports.TransactionQuery{
+		"unknown sort":   {SortBy: "merchant"},
+		"amount range":   {MinAmount: &low, MaxAmount: &high},
+		"created range":  {CreatedFrom: from, CreatedTo: from.Add(-time.Hour)},
+		"empty interval": {CreatedFrom: from, CreatedTo: from},
+	}
+	for name, q := range cases {
+		if _, err := svc.ListTransactions(context.Background(), q); !errors.Is(err, domain.ErrInvalidInput) {
+			t.Errorf("%s: expected ErrInvalidInput, got %v", name, err)
+		}
 	}
 }
 
@@ -320,6 +716,16 @@ func TestProcessAmountOutOfRange(t *testing.T) {
 	})
 }
 
+func TestProcessWithPurchaseLimits(t *testing.T) {
+	svc := NewService(newMockRepo(), WithPurchaseLimits(domain.PurchaseLimits{"BRL": {Min: 1_000, Max: 1_000_000}}))
+	if _, err := svc.ProcessTransaction(context.Background(), makePurchaseCmd("tx1", "APPROVED", "idem1", 600_000)); err != nil {
+		t.Errorf("expected the configured maximum to apply, got %v", err)
+	}
+	if _, err := svc.ProcessTransaction(context.Background(), makePurchaseCmd("tx2", "APPROVED", "idem2", 500)); !errors.Is(err, domain.ErrAmountOutOfRange) {
+		t.Errorf("expected the configured minimum to apply, got %v", err)
+	}
+}
+
 func TestProcessNegativeAmount(t *testing.T) {
 	svc := NewService(newMockRepo())
 	_, err := svc.ProcessTransaction(context.Background(), makePurchaseCmd("tx1", "APPROVED", "idem-neg", -100))
@@ -327,3 +733,116 @@ func TestProcessNegativeAmount(t *testing.T) {
 		t.Errorf("expected ErrNegativeAmount, got %v", err)
 	}
 }
+
+// TestConcurrentPartialRefundsNeverExceedPurchase fires concurrent partial refunds against a
+// real thread-safe repository and checks the approved total never exceeds the purchase amount.
+func TestConcurrentPartialRefundsNeverExceedPurchase(t *testing.T) {
+	repo := memory.NewRepository()
+	svc := NewService(repo)
+	ctx := context.Background()
+	const purchaseAmount, refundAmount, attempts = 10_000, 300, 500
+
+	if _, err := svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", purchaseAmount)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var approved, exceeded atomic.Int64
+	for i := range attempts {
+		wg.Go(func() {
+			id := fmt.Sprintf("ref%d", i)
+			_, err := svc.ProcessTransaction(ctx, makeAdjustCmd(id, "REFUND", "APPROVED", "tx1", "idem-"+id, refundAmount))
+			switch {
+			case err == nil:
+				approved.Add(1)
+			case errors.Is(err, domain.ErrExceedsOriginalAmount):
+				exceeded.Add(1)
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	adjs, _ := repo.GetAdjustmentsByTransactionID(ctx, "tx1")
+	var total int64
+	for _, adj := range adjs {
+		total += adj.Amount.Local.Amount
+	}
+	if total > purchaseAmount {
+		t.Fatalf("refunded %d exceeds purchase amount %d", total, purchaseAmount)
+	}
+	if want := int64(purchaseAmount / refundAmount); approved.Load() != want {
+		t.Errorf("expected %d approved refunds, got %d", want, approved.Load())
+	}
+	if approved.Load()+exceeded.Load() != attempts {
+		t.Errorf("expected %d outcomes, got %d", attempts, approved.Load()+exceeded.Load())
+	}
+}
+
+// spanRecorder is a tracing.Exporter that keeps the exported spans.
+type spanRecorder struct{ spans []tracing.SpanData }
+
+func (r *spanRecorder) Export(_ context.Context, spans []tracing.SpanData) error {
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func TestProcessTransactionRecordsSpans(t *testing.T) {
+	exp := &spanRecorder{}
+	tracer := tracing.NewTracer(exp)
+	svc := NewService(memory.NewRepository(), WithTracer(tracer))
+	ctx, parent := tracer.Start(context.Background(), "Handler.handleWebhook")
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 5000))
+	parent.End()
+	tracer.Flush(ctx)
+
+	if len(exp.spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(exp.spans))
+	}
+	attr := func(s tracing.SpanData, key string) any {
+		for _, a := range s.Attributes {
+			if a.Key == key {
+				return a.Value
+			}
+		}
+		return nil
+	}
+	first, duplicate, refund := exp.spans[0], exp.spans[1], exp.spans[2]
+	if first.Name != "Service.processOriginal" || first.Parent != parent.SpanContext().SpanID || first.Error != "" {
+		t.Errorf("unexpected span %+v", first)
+	}
+	if attr(first, "transaction.id") != "tx1" || attr(first, "transaction.idempotency_key") != "idem1" {
+		t.Errorf("unexpected attributes %+v", first.Attributes)
+	}
+	if duplicate.Error != "" || attr(duplicate, "transaction.idempotent") != true {
+		t.Errorf("a duplicate should be an idempotent hit, got %+v", duplicate)
+	}
+	if refund.Name != "Service.processAdjustment" || refund.Error == "" {
+		t.Errorf("expected the oversized refund to fail its span, got %+v", refund)
+	}
+}
+
+func TestProcessTransactionLogsDecisions(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := logging.NewContext(context.Background(), slog.New(slog.NewTextHandler(&buf, nil)).With("request_id", "req-1"))
+	svc := NewService(memory.NewRepository())
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 5000))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %q", lines)
+	}
+	for i, want := range []string{
+		`level=INFO msg="idempotent hit" request_id=req-1 transaction_id=tx1`,
+		`level=WARN msg="adjustment exceeds original amount" request_id=req-1 transaction_id=adj1`,
+	} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("line %d: expected %q in %q", i, want, lines[i])
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// ReconcileSettlement matches the settlement rows against the stored transactions and adjustments
+// that move money, by ID and AmountBreakdown.Settlement. It loads the transactions created in the
+// file's window and the adjustments of the approved ones, then looks up by ID the rows the window
+// did not cover, such as a late settlement or a refund of an older purchase. Parked adjustments
+// are not in the repository yet, so a row for one is reported missing in the webhooks.
+func (s *Service) ReconcileSettlement(ctx context.Context, cmd ports.ReconcileSettlementCommand) (domain.SettlementReport, error) {
+	if !cmd.From.IsZero() && !cmd.To.IsZero() && !cmd.From.Before(cmd.To) {
+		return domain.SettlementReport{}, fmt.Errorf("%w: from must be before to", domain.ErrInvalidInput)
+	}
+	page, err := s.repo.ListTransactions(ctx, ports.TransactionQuery{
+		CreatedFrom: cmd.From,
+		CreatedTo:   cmd.To,
+		SortBy:      ports.SortByCreatedAt,
+	})
+	if err != nil {
+		return domain.SettlementReport{}, err
+	}
+	var expected []domain.ExpectedSettlement
+	resolved := make(map[string]bool)
+	add := func(e domain.ExpectedSettlement, ok bool) {
+		if ok {
+			expected = append(expected, e)
+			resolved[e.TransactionID] = true
+		}
+	}
+	for _, tx := range page.Transactions {
+		resolved[tx.ID] = true
+		add(domain.ExpectTransaction(tx))
+		if tx.Status != domain.StatusApproved {
+			continue
+		}
+		adjs, err := s.repo.GetAdjustmentsByTransactionID(ctx, tx.ID)
+		if err != nil {
+			return domain.SettlementReport{}, err
+		}
+		for _, adj := range adjs {
+			add(domain.ExpectAdjustment(adj))
+		}
+	}
+	for _, rec := range cmd.Records {
+		if resolved[rec.TransactionID] {
+			continue
+		}
+		e, ok, err := s.expectByID(ctx, rec.TransactionID)
+		if err != nil {
+			return domain.SettlementReport{}, err
+		}
+		add(e, ok)
+	}
+	return domain.Reconcile(cmd.Records, expected, cmd.From, cmd.To)
+}
+
+// expectByID returns what the transaction or adjustment with id settles for, and false when it
+// is unknown or moves no money.
+func (s *Service) expectByID(ctx context.Context, id string) (domain.ExpectedSettlement, bool, error) {
+	tx, err := s.repo.GetTransactionByID(ctx, id)
+	if err == nil {
+		e, ok := domain.ExpectTransaction(tx)
+		return e, ok, nil
+	}
+	if !errors.Is(err, domain.ErrTransactionNotFound) {
+		return domain.ExpectedSettlement{}, false, err
+	}
+	adj, err := s.repo.GetAdjustmentByID(ctx, id)
+	if errors.Is(err, domain.ErrTransactionNotFound) {
+		return domain.ExpectedSettlement{}, false, nil
+	}
+	if err != nil {
+		return domain.ExpectedSettlement{}, false, err
+	}
+	e, ok := domain.ExpectAdjustment(adj)
+	return e, ok, nil
+}
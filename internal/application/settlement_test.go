@@ -0,0 +1,116 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func settlementRow(line int, id string, amount int64) domain.SettlementRecord {
+	return domain.SettlementRecord{Line: line, TransactionID: id, Amount: domain.Money{Amount: amount, Currency: "BRL"}}
+}
+
+func TestReconcileSettlement(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(memory.NewRepository())
+	day := time.Now().Truncate(24 * time.Hour)
+	for _, cmd := range []ports.ProcessTransactionCommand{
+		makePurchaseCmd("tx1", "APPROVED", "idem1", 1000),
+		makePurchaseCmd("tx2", "APPROVED", "idem2", 500),
+		makePurchaseCmd("tx3", "REJECTED", "idem3", 800),
+		makeOriginalCmd("tx4", "BALANCE_INQUIRY", "APPROVED", "idem4", 0),
+		makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem5", 300),
+	} {
+		cmd.EventCreatedAt = day.Add(time.Hour)
+		if _, err := svc.ProcessTransaction(ctx, cmd); err != nil {
+			t.Fatalf("process %s: %v", cmd.TransactionID, err)
+		}
+	}
+
+	report, err := svc.ReconcileSettlement(ctx, ports.ReconcileSettlementCommand{
+		Records: []domain.SettlementRecord{settlementRow(1, "tx1", 1000), settlementRow(2, "adj1", 350), settlementRow(3, "tx3", 800)},
+		From:    day,
+		To:      day.Add(24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, item := range report.Items {
+		got = append(got, item.TransactionID+":"+string(item.Status))
+	}
+	// The rejected purchase and the balance inquiry settle nothing, so a row for tx3 is unknown.
+	want := []string{"tx1:MATCHED", "adj1:AMOUNT_MISMATCH", "tx3:MISSING_IN_WEBHOOKS", "tx2:MISSING_IN_SETTLEMENT"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	later, _ := svc.ReconcileSettlement(ctx, ports.ReconcileSettlementCommand{From: day.Add(24 * time.Hour)})
+	if len(later.Items) != 0 || !later.Clean() {
+		t.Errorf("expected nothing due on the next day, got %+v", later)
+	}
+	if _, err := svc.ReconcileSettlement(ctx, ports.ReconcileSettlementCommand{From: day, To: day}); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for an empty window, got %v", err)
+	}
+}
+
+// countingRepo counts the adjustment reads reconciliation makes.
+type countingRepo struct {
+	*memory.Repository
+	adjustmentReads []string
+}
+
+func (r *countingRepo) GetAdjustmentsByTransactionID(ctx context.Context, originalTxID string) ([]domain.Adjustment, error) {
+	r.adjustmentReads = append(r.adjustmentReads, originalTxID)
+	return r.Repository.GetAdjustmentsByTransactionID(ctx, originalTxID)
+}
+
+func TestReconcileSettlementReadsOnlyTheWindow(t *testing.T) {
+	ctx := context.Background()
+	repo := &countingRepo{Repository: memory.NewRepository()}
+	svc := NewService(repo)
+	day := time.Now().Truncate(24 * time.Hour)
+	for _, c := range []struct {
+		cmd ports.ProcessTransactionCommand
+		at  time.Time
+	}{
+		{makePurchaseCmd("old", "APPROVED", "idem1", 1000), day.Add(-time.Hour)},
+		{makePurchaseCmd("older", "APPROVED", "idem2", 700), day.Add(-2 * time.Hour)},
+		{makePurchaseCmd("today", "APPROVED", "idem3", 500), day.Add(time.Hour)},
+		{makePurchaseCmd("declined", "REJECTED", "idem4", 800), day.Add(2 * time.Hour)},
+		{makeAdjustCmd("refund", "REFUND", "APPROVED", "old", "idem5", 300), day.Add(3 * time.Hour)},
+	} {
+		c.cmd.EventCreatedAt = c.at
+		if _, err := svc.ProcessTransaction(ctx, c.cmd); err != nil {
+			t.Fatalf("process %s: %v", c.cmd.TransactionID, err)
+		}
+	}
+
+	report, err := svc.ReconcileSettlement(ctx, ports.ReconcileSettlementCommand{
+		Records: []domain.SettlementRecord{settlementRow(1, "today", 500), settlementRow(2, "refund", 300), settlementRow(3, "old", 1000)},
+		From:    day,
+		To:      day.Add(24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, item := range report.Items {
+		got = append(got, item.TransactionID+":"+string(item.Status))
+	}
+	// The refund and the late row for "old" are found by ID; "older" is outside the window and
+	// not in the file, so it is not reported.
+	want := []string{"today:MATCHED", "refund:MATCHED", "old:MATCHED"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if want := []string{"today"}; !slices.Equal(repo.adjustmentReads, want) {
+		t.Errorf("expected adjustment reads %v, got %v", want, repo.adjustmentReads)
+	}
+}
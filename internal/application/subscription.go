@@ -0,0 +1,95 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+var errSubscriptionsDisabled = errors.New("subscriptions are not enabled")
+
+func (d *Dispatcher) CreateSubscription(ctx context.Context, cmd ports.CreateSubscriptionCommand) (domain.Subscription, error) {
+	if d.subscriptions == nil {
+		return domain.Subscription{}, errSubscriptionsDisabled
+	}
+	types := make([]domain.TransactionType, len(cmd.Types))
+	for i, t := range cmd.Types {
+		types[i] = domain.TransactionType(t)
+	}
+	statuses := make([]domain.TransactionStatus, len(cmd.Statuses))
+	for i, s := range cmd.Statuses {
+		statuses[i] = domain.TransactionStatus(s)
+	}
+	sub, err := domain.NewSubscription(newID("sub"), cmd.URL, types, statuses, cmd.Secret, d.now())
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+	if err := d.subscriptions.SaveSubscription(ctx, sub); err != nil {
+		return domain.Subscription{}, fmt.Errorf("save subscription: %w", err)
+	}
+	return sub, nil
+}
+
+func (d *Dispatcher) GetSubscription(ctx context.Context, id string) (domain.Subscription, error) {
+	if d.subscriptions == nil {
+		return domain.Subscription{}, errSubscriptionsDisabled
+	}
+	return d.subscriptions.GetSubscription(ctx, id)
+}
+
+func (d *Dispatcher) ListSubscriptions(ctx context.Context) ([]domain.Subscription, error) {
+	if d.subscriptions == nil {
+		return nil, errSubscriptionsDisabled
+	}
+	return d.subscriptions.ListSubscriptions(ctx)
+}
+
+// DeleteSubscription stops future deliveries; events already in flight to it are abandoned.
+func (d *Dispatcher) DeleteSubscription(ctx context.Context, id string) error {
+	if d.subscriptions == nil {
+		return errSubscriptionsDisabled
+	}
+	return d.subscriptions.DeleteSubscription(ctx, id)
+}
+
+func (d *Dispatcher) ListDeliveries(ctx context.Context, subscriptionID string) ([]domain.Delivery, error) {
+	if d.subscriptions == nil {
+		return nil, errSubscriptionsDisabled
+	}
+	if _, err := d.subscriptions.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return d.deliveryLog.ListDeliveries(ctx, subscriptionID)
+}
+
+// Redeliver publishes the logged delivery's event to the subscription's current URL and secret.
+// It bypasses the outbox: ordering and backoff do not apply to manual redeliveries.
+func (d *Dispatcher) Redeliver(ctx context.Context, subscriptionID, deliveryID string) (domain.Delivery, error) {
+	if d.subscriptions == nil {
+		return domain.Delivery{}, errSubscriptionsDisabled
+	}
+	sub, err := d.subscriptions.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return domain.Delivery{}, err
+	}
+	prev, err := d.deliveryLog.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return domain.Delivery{}, err
+	}
+	if prev.SubscriptionID != sub.ID {
+		return domain.Delivery{}, domain.ErrDeliveryNotFound
+	}
+	return d.attempt(ctx, target{Subscription: sub, logged: true}, prev.Event, 1, true)
+}
+
+// newID returns prefix_ followed by 16 random hex digits.
+func newID(prefix string) string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return prefix + "_" + hex.EncodeToString(b)
+}
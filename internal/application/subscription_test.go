@@ -0,0 +1,141 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/adapters/output/memory"
+	"github.com/jailtonjunior/pomelo/internal/application/ports"
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func newSubscriptionDispatcher(repo *memory.Repository, pub *recordingPublisher, opts ...DispatcherOption) *Dispatcher {
+	opts = append(opts, WithSubscriptions(memory.NewSubscriptionStore(), memory.NewDeliveryLog(memory.DefaultDeliveriesPerSubscription)))
+	return NewDispatcher(repo, pub, memory.NewDeadLetterStore(), nil, opts...)
+}
+
+func TestDispatcherDeliversOnlyMatchingEventsToSubscriptions(t *testing.T) {
+	repo := memory.NewRepository()
+	svc := NewService(repo)
+	ctx := context.Background()
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	svc.ProcessTransaction(ctx, makePurchaseCmd("tx2", "REJECTED", "idem2", 1000))
+	svc.ProcessTransaction(ctx, makeAdjustCmd("adj1", "REFUND", "APPROVED", "tx1", "idem-adj1", 400))
+
+	pub := newRecordingPublisher()
+	d := newSubscriptionDispatcher(repo, pub)
+	refunds, err := d.CreateSubscription(ctx, ports.CreateSubscriptionCommand{URL: "http://refunds", Types: []string{"REFUND"}, Secret: "s1"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	approved, _ := d.CreateSubscription(ctx, ports.CreateSubscriptionCommand{URL: "http://approved", Statuses: []string{"APPROVED"}, Secret: "s2"})
+
+	if n, err := d.DispatchOnce(ctx); err != nil || n != 3 {
+		t.Fatalf("expected 3 events dispatched, got %d (%v)", n, err)
+	}
+	if got := pub.deliveredTo("http://refunds"); !slices.Equal(got, []string{"AdjustmentApplied:adj1"}) {
+		t.Errorf("refunds: unexpected deliveries %v", got)
+	}
+	if got := pub.deliveredTo("http://approved"); !slices.Equal(got, []string{"TransactionProcessed:tx1", "AdjustmentApplied:adj1"}) {
+		t.Errorf("approved: unexpected deliveries %v", got)
+	}
+
+	log, err := d.ListDeliveries(ctx, refunds.ID)
+	if err != nil || len(log) != 1 {
+		t.Fatalf("expected 1 logged delivery, got %+v (%v)", log, err)
+	}
+	if dl := log[0]; dl.SubscriptionID != refunds.ID || dl.Event.ID != "AdjustmentApplied:adj1" || dl.Attempt != 1 || dl.ResponseStatus != 204 || !dl.Succeeded() {
+		t.Errorf("unexpected delivery %+v", dl)
+	}
+	if log, _ := d.ListDeliveries(ctx, approved.ID); len(log) != 2 {
+		t.Errorf("expected 2 logged deliveries, got %d", len(log))
+	}
+}
+
+func TestDispatcherLogsFailedSubscriptionAttempts(t *testing.T) {
+	repo := memory.NewRepository()
+	ctx := context.Background()
+	NewService(repo).ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	pub := newRecordingPublisher()
+	pub.fail("http://a", "TransactionProcessed:tx1", true)
+	d := newSubscriptionDispatcher(repo, pub, WithRetryPolicy(5, time.Second, time.Minute), WithDispatcherClock(clock.now))
+	sub, _ := d.CreateSubscription(ctx, ports.CreateSubscriptionCommand{URL: "http://a", Secret: "s"})
+
+	d.DispatchOnce(ctx)
+	pub.fail("http://a", "TransactionProcessed:tx1", false)
+	clock.t = clock.t.Add(time.Second)
+	d.DispatchOnce(ctx)
+
+	log, _ := d.ListDeliveries(ctx, sub.ID)
+	if len(log) != 2 {
+		t.Fatalf("expected 2 attempts, got %+v", log)
+	}
+	if log[0].Succeeded() || log[0].ResponseStatus != 503 || log[0].Attempt != 1 {
+		t.Errorf("unexpected first attempt %+v", log[0])
+	}
+	if !log[1].Succeeded() || log[1].Attempt != 2 || log[1].Manual {
+		t.Errorf("unexpected second attempt %+v", log[1])
+	}
+}
+
+func TestRedeliver(t *testing.T) {
+	repo := memory.NewRepository()
+	ctx := context.Background()
+	NewService(repo).ProcessTransaction(ctx, makePurchaseCmd("tx1", "APPROVED", "idem1", 1000))
+	pub := newRecordingPublisher()
+	d := newSubscriptionDispatcher(repo, pub)
+	sub, _ := d.CreateSubscription(ctx, ports.CreateSubscriptionCommand{URL: "http://a", Secret: "s"})
+	other, _ := d.CreateSubscription(ctx, ports.CreateSubscriptionCommand{URL: "http://b", Secret: "s"})
+	d.DispatchOnce(ctx)
+	log, _ := d.ListDeliveries(ctx, sub.ID)
+
+	// The event already left the outbox; redelivery still reaches the subscriber.
+	got, err := d.Redeliver(ctx, sub.ID, log[0].ID)
+	if err != nil || !got.Manual || got.ID == log[0].ID || got.Event.ID != "TransactionProcessed:tx1" {
+		t.Fatalf("unexpected redelivery %+v (%v)", got, err)
+	}
+	if delivered := pub.deliveredTo("http://a"); len(delivered) != 2 {
+		t.Errorf("expected the event twice, got %v", delivered)
+	}
+	if log, _ := d.ListDeliveries(ctx, sub.ID); len(log) != 2 {
+		t.Errorf("expected the redelivery to be logged, got %d attempts", len(log))
+	}
+
+	if _, err := d.Redeliver(ctx, other.ID, log[0].ID); !errors.Is(err, domain.ErrDeliveryNotFound) {
+		t.Errorf("expected ErrDeliveryNotFound for another subscription's delivery, got %v", err)
+	}
+	if _, err := d.Redeliver(ctx, "sub_missing", log[0].ID); !errors.Is(err, domain.ErrSubscriptionNotFound) {
+		t.Errorf("expected ErrSubscriptionNotFound, got %v", err)
+	}
+}
+
+func TestSubscriptionLifecycle(t *testing.T) {
+	ctx := context.Background()
+	d := newSubscriptionDispatcher(memory.NewRepository(), newRecordingPublisher())
+	if _, err := d.CreateSubscription(ctx, ports.CreateSubscriptionCommand{URL: "ftp://a", Secret: "s"}); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput, got %v", err)
+	}
+	sub, err := d.CreateSubscription(ctx, ports.CreateSubscriptionCommand{URL: "http://a", Types: []string{"PURCHASE"}, Secret: "s"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if got, err := d.GetSubscription(ctx, sub.ID); err != nil || got.URL != "http://a" {
+		t.Errorf("unexpected subscription %+v (%v)", got, err)
+	}
+	if err := d.DeleteSubscription(ctx, sub.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := d.ListDeliveries(ctx, sub.ID); !errors.Is(err, domain.ErrSubscriptionNotFound) {
+		t.Errorf("expected ErrSubscriptionNotFound after delete, got %v", err)
+	}
+
+	plain := NewDispatcher(nil, nil, nil, nil)
+	if _, err := plain.ListSubscriptions(ctx); !errors.Is(err, errSubscriptionsDisabled) {
+		t.Errorf("expected errSubscriptionsDisabled, got %v", err)
+	}
+}
@@ -0,0 +1,497 @@
+// Package config loads the server configuration: defaults, then an optional JSON or YAML file,
+// then environment variables, each layer overriding the previous one. Load validates the result
+// so a misconfigured server refuses to start instead of failing on the first request.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+// Redacted replaces secrets in the output of Config.Redacted.
+const Redacted = "REDACTED"
+
+// Config is the server configuration. The JSON names are the keys of the config file.
+type Config struct {
+	HTTP          HTTP          `json:"http"`
+	Storage       Storage       `json:"storage"`
+	Webhook       Webhook       `json:"webhook"`
+	Limits        Limits        `json:"limits"`
+	Settlement    Settlement    `json:"settlement"`
+	Log           Log           `json:"log"`
+	Pending       Pending       `json:"pending_adjustments"`
+	Idempotency   Idempotency   `json:"idempotency"`
+	Authorization Authorization `json:"authorization"`
+	Outbox        Outbox        `json:"outbox"`
+	Tracing       Tracing       `json:"tracing"`
+}
+
+type HTTP struct {
+	Addr              string   `json:"addr"`
+	ReadHeaderTimeout Duration `json:"read_header_timeout"`
+	ReadTimeout       Duration `json:"read_timeout"`
+	WriteTimeout      Duration `json:"write_timeout"`
+	IdleTimeout       Duration `json:"idle_timeout"`
+	MaxBodyBytes      int64    `json:"max_body_bytes"`
+	// ShutdownDelay keeps serving, with /health reporting 503, before the listener closes.
+	ShutdownDelay Duration `json:"shutdown_delay"`
+	// ShutdownTimeout bounds how long in-flight requests may take to finish.
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+}
+
+type Storage struct {
+	// Backend is memory, file, sqlite or postgres.
+	Backend     string `json:"backend"`
+	DataDir     string `json:"data_dir"`
+	DatabaseURL string `json:"database_url"`
+}
+
+type Webhook struct {
+	// Secrets enables signature verification; more than one allows key rotation.
+	Secrets []string `json:"secrets"`
+	MaxSkew Duration `json:"max_skew"`
+}
+
+type Limits struct {
+	// Purchase overrides the PURCHASE amount range, in minor units, per local currency.
+	Purchase map[string]AmountRange `json:"purchase"`
+}
+
+type AmountRange struct {
+	Min int64 `json:"min"`
+	Max int64 `json:"max"`
+}
+
+type Settlement struct {
+	// Currency is the program's settlement currency; empty accepts any.
+	Currency string `json:"currency"`
+}
+
+type Log struct {
+	// Level is debug, info, warn or error.
+	Level string `json:"level"`
+	// Format is text or json.
+	Format string `json:"format"`
+}
+
+type Pending struct {
+	// TTL is how long an adjustment waits for its original transaction; 0 disables parking.
+	TTL Duration `json:"ttl"`
+}
+
+type Idempotency struct {
+	// Retention is how long a webhook response is replayed to retries; 0 disables the records.
+	Retention Duration `json:"retention"`
+}
+
+type Authorization struct {
+	Deadline Duration `json:"deadline"`
+	HoldTTL  Duration `json:"hold_ttl"`
+}
+
+type Outbox struct {
+	PollInterval Duration `json:"poll_interval"`
+	MaxAttempts  int      `json:"max_attempts"`
+	Subscribers  []string `json:"subscribers"`
+}
+
+type Tracing struct {
+	// Endpoint is the OTLP/HTTP traces URL; empty disables tracing.
+	Endpoint       string   `json:"endpoint"`
+	ServiceName    string   `json:"service_name"`
+	ExportInterval Duration `json:"export_interval"`
+}
+
+// Duration is a time.Duration written as a Go duration string ("5s", "24h") in config files.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"5s\", got %s", b)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Default returns the configuration used when nothing overrides it.
+func Default() Config {
+	return Config{
+		HTTP: HTTP{
+			Addr:              ":8080",
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			ReadTimeout:       Duration(15 * time.Second),
+			WriteTimeout:      Duration(15 * time.Second),
+			IdleTimeout:       Duration(time.Minute),
+			MaxBodyBytes:      1 << 20,
+			ShutdownTimeout:   Duration(30 * time.Second),
+		},
+		Storage:       Storage{Backend: "memory", DataDir: "data"},
+		Webhook:       Webhook{MaxSkew: Duration(5 * time.Minute)},
+		Log:           Log{Level: "info", Format: "text"},
+		Pending:       Pending{TTL: Duration(24 * time.Hour)},
+		Idempotency:   Idempotency{Retention: Duration(24 * time.Hour)},
+		Authorization: Authorization{Deadline: Duration(time.Second), HoldTTL: Duration(7 * 24 * time.Hour)},
+		Outbox:        Outbox{PollInterval: Duration(time.Second), MaxAttempts: 8},
+		Tracing:       Tracing{ServiceName: "pomelo", ExportInterval: Duration(5 * time.Second)},
+	}
+}
+
+// Load returns the defaults overridden by the file at path, when path is not empty, and then by
+// the environment read through getenv. The result is validated.
+func Load(path string, getenv func(string) string) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := cfg.applyEnv(getenv); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// loadFile decodes a .json, .yaml or .yml file over cfg. Keys the file omits keep their value;
+// unknown keys are an error.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+	switch ext := filepath.Ext(path); ext {
+	case ".json":
+	case ".yaml", ".yml":
+		// YAML is converted to JSON so both formats share the decoder below: the same field
+		// names, duration strings and rejection of unknown keys.
+		var tree any
+		if err := yaml.Unmarshal(data, &tree); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if data, err = json.Marshal(tree); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("%s: unsupported config format %q (use .json, .yaml or .yml)", path, ext)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// envVar binds an environment variable to the field set parses it into.
+type envVar struct {
+	name string
+	set  func(string) error
+}
+
+// env lists the variables applyEnv reads, in order. OTEL_EXPORTER_OTLP_TRACES_ENDPOINT comes after
+// OTEL_EXPORTER_OTLP_ENDPOINT so the more specific one wins.
+func (c *Config) env() []envVar {
+	return []envVar{
+		{"HTTP_ADDR", stringVar(&c.HTTP.Addr)},
+		{"HTTP_READ_HEADER_TIMEOUT", durationVar(&c.HTTP.ReadHeaderTimeout)},
+		{"HTTP_READ_TIMEOUT", durationVar(&c.HTTP.ReadTimeout)},
+		{"HTTP_WRITE_TIMEOUT", durationVar(&c.HTTP.WriteTimeout)},
+		{"HTTP_IDLE_TIMEOUT", durationVar(&c.HTTP.IdleTimeout)},
+		{"HTTP_MAX_BODY_BYTES", int64Var(&c.HTTP.MaxBodyBytes)},
+		{"SHUTDOWN_DELAY", durationVar(&c.HTTP.ShutdownDelay)},
+		{"SHUTDOWN_TIMEOUT", durationVar(&c.HTTP.ShutdownTimeout)},
+		{"STORAGE_BACKEND", stringVar(&c.Storage.Backend)},
+		{"DATA_DIR", stringVar(&c.Storage.DataDir)},
+		{"DATABASE_URL", stringVar(&c.Storage.DatabaseURL)},
+		{"WEBHOOK_SECRETS", listVar(&c.Webhook.Secrets)},
+		{"WEBHOOK_MAX_SKEW", durationVar(&c.Webhook.MaxSkew)},
+		{"PURCHASE_LIMITS", c.setPurchaseLimits},
+		{"SETTLEMENT_CURRENCY", stringVar(&c.Settlement.Currency)},
+		{"LOG_LEVEL", stringVar(&c.Log.Level)},
+		{"LOG_FORMAT", stringVar(&c.Log.Format)},
+		{"PENDING_ADJUSTMENT_TTL", durationVar(&c.Pending.TTL)},
+		{"IDEMPOTENCY_RETENTION", durationVar(&c.Idempotency.Retention)},
+		{"AUTHORIZATION_DEADLINE", durationVar(&c.Authorization.Deadline)},
+		{"AUTHORIZATION_HOLD_TTL", durationVar(&c.Authorization.HoldTTL)},
+		{"OUTBOX_POLL_INTERVAL", durationVar(&c.Outbox.PollInterval)},
+		{"OUTBOX_MAX_ATTEMPTS", intVar(&c.Outbox.MaxAttempts)},
+		{"OUTBOX_SUBSCRIBERS", listVar(&c.Outbox.Subscribers)},
+		{"OTEL_EXPORTER_OTLP_ENDPOINT", func(v string) error {
+			c.Tracing.Endpoint = strings.TrimSuffix(v, "/") + "/v1/traces"
+			return nil
+		}},
+		{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", stringVar(&c.Tracing.Endpoint)},
+		{"OTEL_SERVICE_NAME", stringVar(&c.Tracing.ServiceName)},
+		{"OTEL_BSP_SCHEDULE_DELAY", func(v string) error {
+			ms, err := strconv.Atoi(v)
+			if err != nil {
+				return errors.New("must be a number of milliseconds")
+			}
+			c.Tracing.ExportInterval = Duration(time.Duration(ms) * time.Millisecond)
+			return nil
+		}},
+	}
+}
+
+// applyEnv overrides cfg with every variable getenv returns a non-empty value for.
+func (c *Config) applyEnv(getenv func(string) string) error {
+	var errs []error
+	for _, v := range c.env() {
+		if value := getenv(v.name); value != "" {
+			if err := v.set(value); err != nil {
+				errs = append(errs, fmt.Errorf("%s=%q: %w", v.name, value, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// EnvVars returns the names of the environment variables Load reads.
+func EnvVars() []string {
+	var c Config
+	var names []string
+	for _, v := range c.env() {
+		names = append(names, v.name)
+	}
+	return names
+}
+
+func stringVar(dst *string) func(string) error {
+	return func(v string) error { *dst = v; return nil }
+}
+
+func durationVar(dst *Duration) func(string) error {
+	return func(v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return errors.New("must be a duration such as \"5s\"")
+		}
+		*dst = Duration(d)
+		return nil
+	}
+}
+
+func intVar(dst *int) func(string) error {
+	return func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		*dst = n
+		return nil
+	}
+}
+
+func int64Var(dst *int64) func(string) error {
+	return func(v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		*dst = n
+		return nil
+	}
+}
+
+// listVar splits a comma-separated value, dropping blanks.
+func listVar(dst *[]string) func(string) error {
+	return func(v string) error {
+		*dst = nil
+		for item := range strings.SplitSeq(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*dst = append(*dst, item)
+			}
+		}
+		return nil
+	}
+}
+
+// setPurchaseLimits parses CUR=min:max pairs separated by commas, e.g. "BRL=100:500000,USD=50:100000".
+func (c *Config) setPurchaseLimits(v string) error {
+	limits := make(map[string]AmountRange)
+	for pair := range strings.SplitSeq(v, ",") {
+		currency, bounds, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		minS, maxS, ok2 := strings.Cut(bounds, ":")
+		lo, err1 := strconv.ParseInt(minS, 10, 64)
+		hi, err2 := strconv.ParseInt(maxS, 10, 64)
+		if !ok || !ok2 || err1 != nil || err2 != nil {
+			return fmt.Errorf("%q is not CURRENCY=min:max", pair)
+		}
+		limits[currency] = AmountRange{Min: lo, Max: hi}
+	}
+	c.Limits.Purchase = limits
+	return nil
+}
+
+var (
+	backends   = []string{"memory", "file", "sqlite", "postgres"}
+	logLevels  = []string{"debug", "info", "warn", "error"}
+	logFormats = []string{"text", "json"}
+)
+
+// Validate reports every invalid setting, each prefixed with its config file key.
+func (c Config) Validate() error {
+	var errs []error
+	fail := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+	nonNegative := func(key string, d Duration) {
+		if d < 0 {
+			fail(key, "must not be negative")
+		}
+	}
+	positive := func(key string, d Duration) {
+		if d <= 0 {
+			fail(key, "must be positive")
+		}
+	}
+
+	if c.HTTP.Addr == "" {
+		fail("http.addr", "is required")
+	}
+	nonNegative("http.read_header_timeout", c.HTTP.ReadHeaderTimeout)
+	nonNegative("http.read_timeout", c.HTTP.ReadTimeout)
+	nonNegative("http.write_timeout", c.HTTP.WriteTimeout)
+	nonNegative("http.idle_timeout", c.HTTP.IdleTimeout)
+	nonNegative("http.shutdown_delay", c.HTTP.ShutdownDelay)
+	positive("http.shutdown_timeout", c.HTTP.ShutdownTimeout)
+	if c.HTTP.MaxBodyBytes <= 0 {
+		fail("http.max_body_bytes", "must be positive")
+	}
+
+	switch {
+	case !slices.Contains(backends, c.Storage.Backend):
+		fail("storage.backend", "unknown backend %q (want one of %s)", c.Storage.Backend, strings.Join(backends, ", "))
+	case c.Storage.Backend == "file" && c.Storage.DataDir == "":
+		fail("storage.data_dir", "is required by the file backend")
+	case c.Storage.Backend == "postgres" && c.Storage.DatabaseURL == "":
+		fail("storage.database_url", "is required by the postgres backend")
+	}
+
+	if slices.Contains(c.Webhook.Secrets, "") {
+		fail("webhook.secrets", "must not contain empty secrets")
+	}
+	if len(c.Webhook.Secrets) > 0 {
+		positive("webhook.max_skew", c.Webhook.MaxSkew)
+	}
+
+	for _, currency := range slices.Sorted(maps.Keys(c.Limits.Purchase)) {
+		key, r := "limits.purchase."+currency, c.Limits.Purchase[currency]
+		switch {
+		case !isCurrency(currency):
+			fail(key, "%q is not an ISO 4217 currency code", currency)
+		case r.Min < 0:
+			fail(key, "min must not be negative")
+		case r.Max < r.Min:
+			fail(key, "max %d is below min %d", r.Max, r.Min)
+		}
+	}
+
+	if c.Settlement.Currency != "" && !isCurrency(c.Settlement.Currency) {
+		fail("settlement.currency", "%q is not an ISO 4217 currency code", c.Settlement.Currency)
+	}
+
+	if !slices.Contains(logLevels, c.Log.Level) {
+		fail("log.level", "unknown level %q (want one of %s)", c.Log.Level, strings.Join(logLevels, ", "))
+	}
+	if !slices.Contains(logFormats, c.Log.Format) {
+		fail("log.format", "unknown format %q (want one of %s)", c.Log.Format, strings.Join(logFormats, ", "))
+	}
+
+	nonNegative("pending_adjustments.ttl", c.Pending.TTL)
+	nonNegative("idempotency.retention", c.Idempotency.Retention)
+	positive("authorization.deadline", c.Authorization.Deadline)
+	positive("authorization.hold_ttl", c.Authorization.HoldTTL)
+
+	positive("outbox.poll_interval", c.Outbox.PollInterval)
+	if c.Outbox.MaxAttempts <= 0 {
+		fail("outbox.max_attempts", "must be positive")
+	}
+	for _, s := range c.Outbox.Subscribers {
+		if !isHTTPURL(s) {
+			fail("outbox.subscribers", "%q is not an http or https URL", s)
+		}
+	}
+
+	if c.Tracing.Endpoint != "" {
+		if !isHTTPURL(c.Tracing.Endpoint) {
+			fail("tracing.endpoint", "%q is not an http or https URL", c.Tracing.Endpoint)
+		}
+		if c.Tracing.ServiceName == "" {
+			fail("tracing.service_name", "is required when tracing is enabled")
+		}
+		positive("tracing.export_interval", c.Tracing.ExportInterval)
+	}
+	return errors.Join(errs...)
+}
+
+func isCurrency(code string) bool {
+	_, ok := domain.LookupCurrency(code)
+	return ok
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// PurchaseLimits converts Limits.Purchase for the domain.
+func (c Config) PurchaseLimits() domain.PurchaseLimits {
+	if len(c.Limits.Purchase) == 0 {
+		return nil
+	}
+	limits := make(domain.PurchaseLimits, len(c.Limits.Purchase))
+	for currency, r := range c.Limits.Purchase {
+		limits[currency] = domain.AmountRange{Min: r.Min, Max: r.Max}
+	}
+	return limits
+}
+
+// Redacted returns a copy of c safe to print: webhook secrets are replaced and the password of
+// the database URL is masked.
+func (c Config) Redacted() Config {
+	if len(c.Webhook.Secrets) > 0 {
+		c.Webhook.Secrets = slices.Repeat([]string{Redacted}, len(c.Webhook.Secrets))
+	}
+	c.Storage.DatabaseURL = redactDSN(c.Storage.DatabaseURL)
+	return c
+}
+
+// redactDSN masks the password of a URL DSN; a key=value DSN mentioning a password is hidden
+// entirely.
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			return u.Redacted()
+		}
+	}
+	if strings.Contains(strings.ToLower(dsn), "password") {
+		return Redacted
+	}
+	return dsn
+}
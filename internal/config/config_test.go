@@ -0,0 +1,221 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/domain"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load("", env(nil))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.HTTP.Addr != ":8080" || cfg.Storage.Backend != "memory" || cfg.Log.Level != "info" || cfg.Tracing.Endpoint != "" {
+		t.Errorf("unexpected defaults %+v", cfg)
+	}
+	if cfg.PurchaseLimits() != nil {
+		t.Errorf("expected no purchase limit overrides by default")
+	}
+}
+
+func TestLoadLayersFileThenEnvironment(t *testing.T) {
+	for _, name := range []string{"pomelo.yaml", "pomelo.json"} {
+		t.Run(name, func(t *testing.T) {
+			content := `
+http:
+  addr: ":9000"
+  write_timeout: 20s
+storage:
+  backend: file
+  data_dir: /var/lib/pomelo
+webhook:
+  secrets: [old, new]
+limits:
+  purchase:
+    USD:
+      min: 50
+      max: 100000
+log:
+  level: debug
+`
+			if strings.HasSuffix(name, ".json") {
+				content = `{
+  "http": {"addr": ":9000", "write_timeout": "20s"},
+  "storage": {"backend": "file", "data_dir": "/var/lib/pomelo"},
+  "webhook": {"secrets": ["old", "new"]},
+  "limits": {"purchase": {"USD": {"min": 50, "max": 100000}}},
+  "log": {"level": "debug"}
+}`
+			}
+			cfg, err := Load(writeFile(t, name, content), env(map[string]string{
+				"HTTP_ADDR":                   ":7000",
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318/",
+				"OTEL_BSP_SCHEDULE_DELAY":     "250",
+			}))
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if cfg.HTTP.Addr != ":7000" {
+				t.Errorf("expected the environment to win over the file, got %q", cfg.HTTP.Addr)
+			}
+			if time.Duration(cfg.HTTP.WriteTimeout) != 20*time.Second || time.Duration(cfg.HTTP.ReadTimeout) != 15*time.Second {
+				t.Errorf("expected the file to override only the keys it sets, got %+v", cfg.HTTP)
+			}
+			if cfg.Storage.DataDir != "/var/lib/pomelo" || !slices.Equal(cfg.Webhook.Secrets, []string{"old", "new"}) || cfg.Log.Level != "debug" {
+				t.Errorf("unexpected config %+v", cfg)
+			}
+			if got := cfg.PurchaseLimits(); got["USD"] != (domain.AmountRange{Min: 50, Max: 100_000}) {
+				t.Errorf("unexpected purchase limits %v", got)
+			}
+			if cfg.Tracing.Endpoint != "http://collector:4318/v1/traces" || time.Duration(cfg.Tracing.ExportInterval) != 250*time.Millisecond {
+				t.Errorf("unexpected tracing config %+v", cfg.Tracing)
+			}
+		})
+	}
+}
+
+func TestLoadEnvironment(t *testing.T) {
+	cfg, err := Load("", env(map[string]string{
+		"WEBHOOK_SECRETS":                    "a, b ,,",
+		"PURCHASE_LIMITS":                    "BRL=100:1000000, USD=50:100000",
+		"SETTLEMENT_CURRENCY":                "USD",
+		"IDEMPOTENCY_RETENTION":              "72h",
+		"OUTBOX_SUBSCRIBERS":                 "http://a/hook",
+		"OUTBOX_MAX_ATTEMPTS":                "3",
+		"PENDING_ADJUSTMENT_TTL":             "0s",
+		"OTEL_EXPORTER_OTLP_ENDPOINT":        "http://collector:4318",
+		"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://traces:4318/custom",
+	}))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !slices.Equal(cfg.Webhook.Secrets, []string{"a", "b"}) || cfg.Outbox.MaxAttempts != 3 || cfg.Pending.TTL != 0 {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if cfg.Idempotency.Retention != Duration(72*time.Hour) {
+		t.Errorf("unexpected idempotency retention %v", cfg.Idempotency.Retention)
+	}
+	if cfg.Settlement.Currency != "USD" {
+		t.Errorf("unexpected settlement currency %q", cfg.Settlement.Currency)
+	}
+	if len(cfg.Limits.Purchase) != 2 || cfg.Limits.Purchase["BRL"].Max != 1_000_000 {
+		t.Errorf("unexpected purchase limits %v", cfg.Limits.Purchase)
+	}
+	if cfg.Tracing.Endpoint != "http://traces:4318/custom" {
+		t.Errorf("expected the traces endpoint to win, got %q", cfg.Tracing.Endpoint)
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	_, err := Load("", env(map[string]string{
+		"HTTP_READ_TIMEOUT": "soon",
+		"PURCHASE_LIMITS":   "BRL",
+	}))
+	for _, want := range []string{`HTTP_READ_TIMEOUT="soon": must be a duration`, `PURCHASE_LIMITS="BRL": "BRL" is not CURRENCY=min:max`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+
+	_, err = Load("", env(map[string]string{
+		"STORAGE_BACKEND":     "redis",
+		"LOG_LEVEL":           "verbose",
+		"PURCHASE_LIMITS":     "usd=1:2,EUR=500:100",
+		"OUTBOX_SUBSCRIBERS":  "ftp://a",
+		"WEBHOOK_SECRETS":     "s",
+		"WEBHOOK_MAX_SKEW":    "0s",
+		"SETTLEMENT_CURRENCY": "dollar",
+	}))
+	for _, want := range []string{
+		`storage.backend: unknown backend "redis"`,
+		`log.level: unknown level "verbose"`,
+		"limits.purchase.EUR: max 100 is below min 500",
+		`limits.purchase.usd: "usd" is not an ISO 4217 currency code`,
+		`outbox.subscribers: "ftp://a" is not an http or https URL`,
+		`settlement.currency: "dollar" is not an ISO 4217 currency code`,
+		"webhook.max_skew: must be positive",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+}
+
+func TestLoadYAMLFlowAndAnchors(t *testing.T) {
+	content := `
+limits:
+  purchase:
+    USD: &usd {min: 50, max: 100000}
+    EUR: *usd
+webhook:
+  secrets: ["s3cr#t", 'it''s'] # comment
+`
+	cfg, err := Load(writeFile(t, "c.yml", content), env(nil))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := cfg.PurchaseLimits(); got["USD"] != (domain.AmountRange{Min: 50, Max: 100_000}) || got["EUR"] != got["USD"] {
+		t.Errorf("unexpected purchase limits %v", got)
+	}
+	if !slices.Equal(cfg.Webhook.Secrets, []string{"s3cr#t", "it's"}) {
+		t.Errorf("unexpected secrets %q", cfg.Webhook.Secrets)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	if _, err := Load(writeFile(t, "c.json", `{"http": {"adr": ":1"}}`), env(nil)); err == nil || !strings.Contains(err.Error(), `unknown field "adr"`) {
+		t.Errorf("expected unknown keys to be rejected, got %v", err)
+	}
+	if _, err := Load(writeFile(t, "c.yaml", "http:\n  read_timeout: 5\n"), env(nil)); err == nil || !strings.Contains(err.Error(), "duration must be a string") {
+		t.Errorf("expected a numeric duration to be rejected, got %v", err)
+	}
+	if _, err := Load(writeFile(t, "c.yaml", "http:\n  addr: [\n"), env(nil)); err == nil || !strings.Contains(err.Error(), "c.yaml: yaml: line") {
+		t.Errorf("expected malformed YAML to be rejected, got %v", err)
+	}
+	if _, err := Load(writeFile(t, "c.toml", ""), env(nil)); err == nil || !strings.Contains(err.Error(), "unsupported config format") {
+		t.Errorf("expected an unknown extension to be rejected, got %v", err)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), env(nil)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a missing file to be reported, got %v", err)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.Webhook.Secrets = []string{"s1", "s2"}
+	cfg.Storage.DatabaseURL = "postgres://pomelo:hunter2@db:5432/pomelo?sslmode=disable"
+	out, _ := json.Marshal(cfg.Redacted())
+	if s := string(out); strings.Contains(s, "s1") || strings.Contains(s, "hunter2") || !strings.Contains(s, "pomelo:xxxxx@db") {
+		t.Errorf("secrets leaked or DSN mangled: %s", s)
+	}
+	if cfg.Webhook.Secrets[0] != "s1" {
+		t.Error("Redacted modified the original config")
+	}
+	if got := redactDSN("host=db user=pomelo password=hunter2"); got != Redacted {
+		t.Errorf("expected a key=value DSN with a password to be hidden, got %q", got)
+	}
+	if got := redactDSN("file:pomelo.db?_txlock=immediate"); got != "file:pomelo.db?_txlock=immediate" {
+		t.Errorf("expected a DSN without credentials to be kept, got %q", got)
+	}
+}
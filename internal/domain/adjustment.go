@@ -2,7 +2,7 @@ package domain
 
 import "fmt"
 
-// Adjustment is an immutable entity representing REVERSAL_PURCHASE or REFUND.
+// Adjustment is an immutable entity representing a REFUND or a REVERSAL_* of an original transaction.
 type Adjustment struct {
 	ID                    string
 	Type                  TransactionType
@@ -28,7 +28,7 @@ func NewAdjustment(
 	originalTransactionID string,
 	userID, cardID, country, currency, pointOfSale string,
 ) (Adjustment, error) {
-	if txType != TypeReversalPurchase && txType != TypeRefund {
+	if !txType.IsAdjustment() {
 		return Adjustment{}, fmt.Errorf("%w: %s", ErrInvalidTransactionType, txType)
 	}
 	if originalTransactionID == "" {
@@ -37,8 +37,11 @@ func NewAdjustment(
 	if id == "" {
 		return Adjustment{}, fmt.Errorf("%w: adjustment id is required", ErrInvalidInput)
 	}
-	if event.ID == "" || event.IdempotencyKey == "" {
-		return Adjustment{}, fmt.Errorf("%w: event id and idempotency key are required", ErrInvalidInput)
+	if err := event.validate(); err != nil {
+		return Adjustment{}, err
+	}
+	if err := amount.validateCurrencies(currency); err != nil {
+		return Adjustment{}, err
 	}
 	return Adjustment{
 		ID:                    id,
@@ -56,17 +59,30 @@ func NewAdjustment(
 	}, nil
 }
 
-// ValidateAgainstPurchase checks business rules for the adjustment against the original purchase.
-// existingTotal is the sum of all previously approved adjustments for this purchase.
-func (a Adjustment) ValidateAgainstPurchase(original Transaction, existingTotal Money) error {
+// ValidateAgainstOriginal checks business rules for the adjustment against the original transaction.
+// existingTotal is the sum of all previously approved adjustments for that transaction.
+func (a Adjustment) ValidateAgainstOriginal(original Transaction, existingTotal Money) error {
+	if target, _ := a.Type.Target(); original.Type != target {
+		return fmt.Errorf("%w: %s cannot target %s", ErrOriginalTypeMismatch, a.Type, original.Type)
+	}
 	if !original.CanReceiveAdjustment() {
 		return ErrPurchaseNotApproved
 	}
+	if err := a.Amount.validateSameCurrencies(original.Amount); err != nil {
+		return err
+	}
 	if a.Status != StatusApproved {
 		// Rejected adjustments don't consume budget
 		return nil
 	}
-	newTotal, err := existingTotal.Add(a.Amount.Local)
+	return checkBudget(original, existingTotal, a.Amount.Local)
+}
+
+// checkBudget returns ErrExceedsOriginalAmount when amount, on top of committed — what was already
+// given back to the cardholder — exceeds what the original transaction charged. Adjustments and
+// disputes share this budget.
+func checkBudget(original Transaction, committed, amount Money) error {
+	newTotal, err := committed.Add(amount)
 	if err != nil {
 		return err
 	}
@@ -51,27 +51,27 @@ func TestNewAdjustment(t *testing.T) {
 	})
 }
 
-func TestValidateAgainstPurchase(t *testing.T) {
+func TestValidateAgainstOriginal(t *testing.T) {
 	zero, _ := NewMoney(0, "BRL")
 
 	t.Run("total reversal approved", func(t *testing.T) {
 		purchase := makeApprovedPurchase("tx1", 1000)
 		adj := makeAdjustment("adj1", TypeReversalPurchase, 1000, "tx1")
-		if err := adj.ValidateAgainstPurchase(purchase, zero); err != nil {
+		if err := adj.ValidateAgainstOriginal(purchase, zero); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
 	t.Run("partial refund approved", func(t *testing.T) {
 		purchase := makeApprovedPurchase("tx1", 1000)
 		adj := makeAdjustment("adj1", TypeRefund, 500, "tx1")
-		if err := adj.ValidateAgainstPurchase(purchase, zero); err != nil {
+		if err := adj.ValidateAgainstOriginal(purchase, zero); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
 	t.Run("exceeds original amount", func(t *testing.T) {
 		purchase := makeApprovedPurchase("tx1", 1000)
 		adj := makeAdjustment("adj1", TypeRefund, 1500, "tx1")
-		if err := adj.ValidateAgainstPurchase(purchase, zero); !errors.Is(err, ErrExceedsOriginalAmount) {
+		if err := adj.ValidateAgainstOriginal(purchase, zero); !errors.Is(err, ErrExceedsOriginalAmount) {
 			t.Errorf("expected ErrExceedsOriginalAmount, got %v", err)
 		}
 	})
@@ -79,14 +79,14 @@ func TestValidateAgainstPurchase(t *testing.T) {
 		purchase := makeApprovedPurchase("tx1", 1000)
 		adj := makeAdjustment("adj2", TypeRefund, 600, "tx1")
 		existing, _ := NewMoney(500, "BRL")
-		if err := adj.ValidateAgainstPurchase(purchase, existing); !errors.Is(err, ErrExceedsOriginalAmount) {
+		if err := adj.ValidateAgainstOriginal(purchase, existing); !errors.Is(err, ErrExceedsOriginalAmount) {
 			t.Errorf("expected ErrExceedsOriginalAmount, got %v", err)
 		}
 	})
 	t.Run("purchase not approved", func(t *testing.T) {
 		rejected, _ := NewPurchase("tx1", StatusRejected, makeAmountBreakdown(1000, "BRL"), makeMerchant(), makeEvent("idem1"), "u", "c", "BR", "BRL", "POS")
 		adj := makeAdjustment("adj1", TypeRefund, 500, "tx1")
-		if err := adj.ValidateAgainstPurchase(rejected, zero); !errors.Is(err, ErrPurchaseNotApproved) {
+		if err := adj.ValidateAgainstOriginal(rejected, zero); !errors.Is(err, ErrPurchaseNotApproved) {
 			t.Errorf("expected ErrPurchaseNotApproved, got %v", err)
 		}
 	})
@@ -94,8 +94,36 @@ func TestValidateAgainstPurchase(t *testing.T) {
 		purchase := makeApprovedPurchase("tx1", 1000)
 		adj, _ := NewAdjustment("adj1", TypeRefund, StatusRejected, makeAmountBreakdown(1500, "BRL"), makeMerchant(), makeEvent("adj-idem-adj1"), "tx1", "u", "c", "BR", "BRL", "POS")
 		// rejected adjustments with any amount should pass (no budget check)
-		if err := adj.ValidateAgainstPurchase(purchase, zero); err != nil {
+		if err := adj.ValidateAgainstOriginal(purchase, zero); err != nil {
 			t.Fatalf("unexpected error for rejected adjustment: %v", err)
 		}
 	})
+	t.Run("reversal of the matching original type", func(t *testing.T) {
+		withdrawal, _ := NewTransaction("tx1", TypeWithdrawal, StatusApproved, makeAmountBreakdown(20000, "BRL"), makeMerchant(), makeEvent("idem1"), "u", "c", "BR", "BRL", "ATM")
+		adj := makeAdjustment("adj1", TypeReversalWithdrawal, 20000, "tx1")
+		if err := adj.ValidateAgainstOriginal(withdrawal, zero); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("reversal of another original type", func(t *testing.T) {
+		purchase := makeApprovedPurchase("tx1", 1000)
+		adj := makeAdjustment("adj1", TypeReversalWithdrawal, 500, "tx1")
+		if err := adj.ValidateAgainstOriginal(purchase, zero); !errors.Is(err, ErrOriginalTypeMismatch) {
+			t.Errorf("expected ErrOriginalTypeMismatch, got %v", err)
+		}
+	})
+	t.Run("refund only applies to purchases", func(t *testing.T) {
+		withdrawal, _ := NewTransaction("tx1", TypeWithdrawal, StatusApproved, makeAmountBreakdown(20000, "BRL"), makeMerchant(), makeEvent("idem1"), "u", "c", "BR", "BRL", "ATM")
+		adj := makeAdjustment("adj1", TypeRefund, 500, "tx1")
+		if err := adj.ValidateAgainstOriginal(withdrawal, zero); !errors.Is(err, ErrOriginalTypeMismatch) {
+			t.Errorf("expected ErrOriginalTypeMismatch, got %v", err)
+		}
+	})
+	t.Run("rejected withdrawal cannot be reversed", func(t *testing.T) {
+		withdrawal, _ := NewTransaction("tx1", TypeWithdrawal, StatusRejected, makeAmountBreakdown(20000, "BRL"), makeMerchant(), makeEvent("idem1"), "u", "c", "BR", "BRL", "ATM")
+		adj := makeAdjustment("adj1", TypeReversalWithdrawal, 500, "tx1")
+		if err := adj.ValidateAgainstOriginal(withdrawal, zero); !errors.Is(err, ErrPurchaseNotApproved) {
+			t.Errorf("expected ErrPurchaseNotApproved, got %v", err)
+		}
+	})
 }
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Outcomes of a webhook attempt that did not end in an error; any other outcome is the error
+// code the attempt was rejected with.
+const (
+	OutcomeProcessed  = "PROCESSED"
+	OutcomeParked     = "PARKED"
+	OutcomeIdempotent = "IDEMPOTENT"
+)
+
+// WebhookAttempt is one webhook delivery exactly as it was received, with the response it got.
+// Attempts are only ever appended: in arrival order they are the history every transaction
+// and adjustment was built from.
+type WebhookAttempt struct {
+	ID             string
+	TransactionID  string
+	IdempotencyKey string
+	ReceivedAt     time.Time
+	// Outcome is OutcomeProcessed, OutcomeParked, OutcomeIdempotent or an error code.
+	Outcome    string
+	StatusCode int
+	// Payload is the raw request body.
+	Payload []byte
+}
+
+func NewWebhookAttempt(id, transactionID, idempotencyKey string, receivedAt time.Time, outcome string, statusCode int, payload []byte) (WebhookAttempt, error) {
+	if id == "" || transactionID == "" {
+		return WebhookAttempt{}, fmt.Errorf("%w: attempt and transaction IDs are required", ErrInvalidInput)
+	}
+	if outcome == "" {
+		return WebhookAttempt{}, fmt.Errorf("%w: attempt outcome is required", ErrInvalidInput)
+	}
+	return WebhookAttempt{
+		ID:             id,
+		TransactionID:  transactionID,
+		IdempotencyKey: idempotencyKey,
+		ReceivedAt:     receivedAt,
+		Outcome:        outcome,
+		StatusCode:     statusCode,
+		Payload:        payload,
+	}, nil
+}
+
+// Accepted reports whether the attempt changed state: it was processed or parked. Replaying the
+// accepted attempts in arrival order rebuilds the repository.
+func (a WebhookAttempt) Accepted() bool {
+	return a.Outcome == OutcomeProcessed || a.Outcome == OutcomeParked
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewWebhookAttempt(t *testing.T) {
+	receivedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	a, err := NewWebhookAttempt("att1", "tx1", "idem1", receivedAt, OutcomeProcessed, 200, []byte(`{"id":"tx1"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.TransactionID != "tx1" || !a.ReceivedAt.Equal(receivedAt) || string(a.Payload) != `{"id":"tx1"}` {
+		t.Errorf("unexpected attempt %+v", a)
+	}
+	for _, tc := range []struct {
+		name, id, txID, outcome string
+	}{
+		{"missing ID", "", "tx1", OutcomeProcessed},
+		{"missing transaction", "att1", "", OutcomeProcessed},
+		{"missing outcome", "att1", "tx1", ""},
+	} {
+		if _, err := NewWebhookAttempt(tc.id, tc.txID, "idem1", receivedAt, tc.outcome, 200, nil); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%s: expected ErrInvalidInput, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestWebhookAttemptAccepted(t *testing.T) {
+	for outcome, want := range map[string]bool{
+		OutcomeProcessed:          true,
+		OutcomeParked:             true,
+		OutcomeIdempotent:         false,
+		"EXCEEDS_ORIGINAL_AMOUNT": false,
+		"INTERNAL_ERROR":          false,
+	} {
+		if got := (WebhookAttempt{Outcome: outcome}).Accepted(); got != want {
+			t.Errorf("%s: expected accepted=%v, got %v", outcome, want, got)
+		}
+	}
+}
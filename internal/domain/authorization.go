@@ -0,0 +1,212 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// CardStatus is whether a card may be authorized.
+type CardStatus string
+
+const (
+	CardActive  CardStatus = "ACTIVE"
+	CardBlocked CardStatus = "BLOCKED"
+)
+
+// Card is an issued card with the funds and limits authorizations are checked against. Every
+// amount is in cents of Currency.
+type Card struct {
+	ID       string
+	UserID   string
+	Status   CardStatus
+	Currency string
+	// Balance is the funded balance. Spending posted to the ledger and open holds are taken from it.
+	Balance int64
+	// TransactionLimit caps a single authorization and DailyLimit the approved authorizations of
+	// one UTC day. Zero means no limit.
+	TransactionLimit int64
+	DailyLimit       int64
+}
+
+func NewCard(id, userID string, status CardStatus, currency string, balance, transactionLimit, dailyLimit int64) (Card, error) {
+	if id == "" {
+		return Card{}, fmt.Errorf("%w: card ID is required", ErrInvalidInput)
+	}
+	if status != CardActive && status != CardBlocked {
+		return Card{}, fmt.Errorf("%w: card status must be ACTIVE or BLOCKED, got %q", ErrInvalidInput, status)
+	}
+	if currency == "" {
+		return Card{}, fmt.Errorf("%w: card currency is required", ErrInvalidInput)
+	}
+	if balance < 0 || transactionLimit < 0 || dailyLimit < 0 {
+		return Card{}, ErrNegativeAmount
+	}
+	return Card{
+		ID:               id,
+		UserID:           userID,
+		Status:           status,
+		Currency:         currency,
+		Balance:          balance,
+		TransactionLimit: transactionLimit,
+		DailyLimit:       dailyLimit,
+	}, nil
+}
+
+// AuthorizationReason details an authorization decision.
+type AuthorizationReason string
+
+const (
+	ReasonApproved            AuthorizationReason = "APPROVED"
+	ReasonCardNotFound        AuthorizationReason = "CARD_NOT_FOUND"
+	ReasonCardBlocked         AuthorizationReason = "CARD_BLOCKED"
+	ReasonInvalidCurrency     AuthorizationReason = "INVALID_CURRENCY"
+	ReasonTransactionLimit    AuthorizationReason = "TRANSACTION_LIMIT_EXCEEDED"
+	ReasonDailyLimit          AuthorizationReason = "DAILY_LIMIT_EXCEEDED"
+	ReasonInsufficientBalance AuthorizationReason = "INSUFFICIENT_BALANCE"
+	ReasonProcessingTimeout   AuthorizationReason = "PROCESSING_TIMEOUT"
+)
+
+// AuthorizationRequest is a synchronous request to approve a transaction before it happens. ID is
+// the transaction ID the later webhook carries.
+type AuthorizationRequest struct {
+	ID       string
+	Type     TransactionType
+	CardID   string
+	UserID   string
+	Merchant Merchant
+	Amount   Money
+}
+
+func NewAuthorizationRequest(id string, txType TransactionType, cardID, userID string, merchant Merchant, amount Money) (AuthorizationRequest, error) {
+	return NewAuthorizationRequestWithLimits(nil, id, txType, cardID, userID, merchant, amount)
+}
+
+// NewAuthorizationRequestWithLimits is NewAuthorizationRequest with PURCHASE amounts checked
+// against limits.
+func NewAuthorizationRequestWithLimits(limits PurchaseLimits, id string, txType TransactionType, cardID, userID string, merchant Merchant, amount Money) (AuthorizationRequest, error) {
+	if id == "" {
+		return AuthorizationRequest{}, fmt.Errorf("%w: transaction ID is required", ErrInvalidInput)
+	}
+	if cardID == "" {
+		return AuthorizationRequest{}, fmt.Errorf("%w: card ID is required", ErrInvalidInput)
+	}
+	if !txType.IsOriginal() {
+		return AuthorizationRequest{}, fmt.Errorf("%w: %s cannot be authorized", ErrInvalidTransactionType, txType)
+	}
+	if err := txType.validateAmount(amount, limits); err != nil {
+		return AuthorizationRequest{}, err
+	}
+	return AuthorizationRequest{ID: id, Type: txType, CardID: cardID, UserID: userID, Merchant: merchant, Amount: amount}, nil
+}
+
+// Authorization is a recorded authorization decision. An approved debit holds its amount on the
+// card until the webhook for the transaction matches it or the hold expires.
+type Authorization struct {
+	Request       AuthorizationRequest
+	Decision      TransactionStatus
+	Reason        AuthorizationReason
+	DecidedAt     time.Time
+	HoldExpiresAt time.Time
+	// Match is set once the webhook for the transaction arrived.
+	Match *AuthorizationMatch
+}
+
+// AuthorizationMatch compares the webhook of an authorized transaction with the decision.
+type AuthorizationMatch struct {
+	Status    TransactionStatus
+	Amount    Money
+	MatchedAt time.Time
+	// Discrepancies lists how the webhook disagrees with the authorization; empty when it agrees.
+	Discrepancies []string
+}
+
+// Holds reports the amount an authorization keeps reserved on its card at now.
+func (a Authorization) Holds(now time.Time) int64 {
+	if a.Decision != StatusApproved || a.Match != nil || !now.Before(a.HoldExpiresAt) || a.Request.Type.Direction() != DirectionDebit {
+		return 0
+	}
+	return a.Request.Amount.Amount
+}
+
+// Authorize decides req against card. spent is what the ledger already debited from the card net
+// of credits, and history the card's earlier authorizations. card is the zero Card when the card is
+// unknown. An approved authorization holds its amount for holdTTL.
+func Authorize(req AuthorizationRequest, card Card, spent int64, history []Authorization, now time.Time, holdTTL time.Duration) Authorization {
+	auth := Authorization{Request: req, Decision: StatusRejected, DecidedAt: now}
+	auth.Reason = decide(req, card, spent, history, now)
+	if auth.Reason == ReasonApproved {
+		auth.Decision = StatusApproved
+		auth.HoldExpiresAt = now.Add(holdTTL)
+	}
+	return auth
+}
+
+// decide runs the checks in order: card state, currency, then limits and balance for debits only.
+func decide(req AuthorizationRequest, card Card, spent int64, history []Authorization, now time.Time) AuthorizationReason {
+	switch {
+	case card.ID == "":
+		return ReasonCardNotFound
+	case card.Status == CardBlocked:
+		return ReasonCardBlocked
+	case req.Amount.Currency != card.Currency:
+		return ReasonInvalidCurrency
+	case req.Type.Direction() != DirectionDebit:
+		return ReasonApproved
+	}
+	amount := req.Amount.Amount
+	if card.TransactionLimit > 0 && amount > card.TransactionLimit {
+		return ReasonTransactionLimit
+	}
+	var today int64
+	day := now.UTC().Truncate(24 * time.Hour)
+	for _, a := range history {
+		if a.Decision == StatusApproved && a.Request.Type.Direction() == DirectionDebit && !a.DecidedAt.Before(day) {
+			today += a.Request.Amount.Amount
+		}
+	}
+	if card.DailyLimit > 0 && today+amount > card.DailyLimit {
+		return ReasonDailyLimit
+	}
+	if amount > Available(card, spent, history, now) {
+		return ReasonInsufficientBalance
+	}
+	return ReasonApproved
+}
+
+// Available is the card balance left for new debits at now.
+func Available(card Card, spent int64, history []Authorization, now time.Time) int64 {
+	available := card.Balance - spent
+	for _, a := range history {
+		available -= a.Holds(now)
+	}
+	return available
+}
+
+// TimedOut turns a decision that missed its deadline into a rejection, releasing any hold.
+func (a Authorization) TimedOut() Authorization {
+	a.Decision = StatusRejected
+	a.Reason = ReasonProcessingTimeout
+	a.HoldExpiresAt = time.Time{}
+	return a
+}
+
+// MatchWebhook records tx as the outcome of the authorization. The match releases the hold: an
+// approved transaction is debited through the ledger from then on.
+func (a Authorization) MatchWebhook(tx Transaction, now time.Time) Authorization {
+	m := AuthorizationMatch{Status: tx.Status, Amount: tx.Amount.Local, MatchedAt: now}
+	if tx.Status != a.Decision {
+		m.Discrepancies = append(m.Discrepancies, fmt.Sprintf("status %s, authorized %s", tx.Status, a.Decision))
+	}
+	if tx.Amount.Local != a.Request.Amount {
+		m.Discrepancies = append(m.Discrepancies, fmt.Sprintf("amount %d %s, authorized %d %s",
+			tx.Amount.Local.Amount, tx.Amount.Local.Currency, a.Request.Amount.Amount, a.Request.Amount.Currency))
+	}
+	if tx.Type != a.Request.Type {
+		m.Discrepancies = append(m.Discrepancies, fmt.Sprintf("type %s, authorized %s", tx.Type, a.Request.Type))
+	}
+	if tx.CardID != a.Request.CardID {
+		m.Discrepancies = append(m.Discrepancies, fmt.Sprintf("card %s, authorized %s", tx.CardID, a.Request.CardID))
+	}
+	a.Match = &m
+	return a
+}
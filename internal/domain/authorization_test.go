@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var decidedAt = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func makeCard(t *testing.T, status CardStatus, balance, txLimit, dailyLimit int64) Card {
+	t.Helper()
+	card, err := NewCard("card1", "u1", status, "BRL", balance, txLimit, dailyLimit)
+	if err != nil {
+		t.Fatalf("build card: %v", err)
+	}
+	return card
+}
+
+func makeAuthRequest(t *testing.T, id string, txType TransactionType, amount int64) AuthorizationRequest {
+	t.Helper()
+	req, err := NewAuthorizationRequest(id, txType, "card1", "u1", makeMerchant(), Money{Amount: amount, Currency: "BRL"})
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	return req
+}
+
+func TestNewCardValidation(t *testing.T) {
+	if _, err := NewCard("", "u1", CardActive, "BRL", 0, 0, 0); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("missing ID: expected ErrInvalidInput, got %v", err)
+	}
+	if _, err := NewCard("card1", "u1", "FROZEN", "BRL", 0, 0, 0); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("unknown status: expected ErrInvalidInput, got %v", err)
+	}
+	if _, err := NewCard("card1", "u1", CardActive, "BRL", -1, 0, 0); !errors.Is(err, ErrNegativeAmount) {
+		t.Errorf("negative balance: expected ErrNegativeAmount, got %v", err)
+	}
+}
+
+func TestNewAuthorizationRequestValidation(t *testing.T) {
+	amount := Money{Amount: 1000, Currency: "BRL"}
+	if _, err := NewAuthorizationRequest("tx1", TypeRefund, "card1", "u1", makeMerchant(), amount); !errors.Is(err, ErrInvalidTransactionType) {
+		t.Errorf("adjustment type: expected ErrInvalidTransactionType, got %v", err)
+	}
+	if _, err := NewAuthorizationRequest("tx1", TypePurchase, "card1", "u1", makeMerchant(), Money{Amount: 50, Currency: "BRL"}); !errors.Is(err, ErrAmountOutOfRange) {
+		t.Errorf("amount below range: expected ErrAmountOutOfRange, got %v", err)
+	}
+	if _, err := NewAuthorizationRequest("tx1", TypePurchase, "", "u1", makeMerchant(), amount); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("missing card: expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestAuthorizeDecisions(t *testing.T) {
+	approvedToday := Authorize(makeAuthRequest(t, "prev", TypePurchase, 3000), makeCard(t, CardActive, 100000, 0, 0), 0, nil, decidedAt.Add(-time.Hour), 2*time.Hour)
+	tests := []struct {
+		name    string
+		card    Card
+		spent   int64
+		history []Authorization
+		req     AuthorizationRequest
+		want    AuthorizationReason
+	}{
+		{"approved", makeCard(t, CardActive, 10000, 0, 0), 0, nil, makeAuthRequest(t, "tx1", TypePurchase, 10000), ReasonApproved},
+		{"unknown card", Card{}, 0, nil, makeAuthRequest(t, "tx1", TypePurchase, 1000), ReasonCardNotFound},
+		{"blocked", makeCard(t, CardBlocked, 10000, 0, 0), 0, nil, makeAuthRequest(t, "tx1", TypeCreditVoucher, 1000), ReasonCardBlocked},
+		{"insufficient after spending", makeCard(t, CardActive, 10000, 0, 0), 9500, nil, makeAuthRequest(t, "tx1", TypePurchase, 1000), ReasonInsufficientBalance},
+		{"insufficient with open hold", makeCard(t, CardActive, 5000, 0, 0), 0, []Authorization{approvedToday}, makeAuthRequest(t, "tx1", TypePurchase, 2500), ReasonInsufficientBalance},
+		{"transaction limit", makeCard(t, CardActive, 100000, 5000, 0), 0, nil, makeAuthRequest(t, "tx1", TypeWithdrawal, 6000), ReasonTransactionLimit},
+		{"daily limit", makeCard(t, CardActive, 100000, 0, 5000), 0, []Authorization{approvedToday}, makeAuthRequest(t, "tx1", TypePurchase, 2500), ReasonDailyLimit},
+		{"credit skips balance", makeCard(t, CardActive, 0, 0, 0), 0, nil, makeAuthRequest(t, "tx1", TypeCreditVoucher, 1000), ReasonApproved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := Authorize(tt.req, tt.card, tt.spent, tt.history, decidedAt, time.Hour)
+			if auth.Reason != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, auth.Reason)
+			}
+			if approved := auth.Decision == StatusApproved; approved != (tt.want == ReasonApproved) {
+				t.Errorf("unexpected decision %s for %s", auth.Decision, auth.Reason)
+			}
+		})
+	}
+}
+
+func TestAuthorizationHolds(t *testing.T) {
+	card := makeCard(t, CardActive, 10000, 0, 0)
+	auth := Authorize(makeAuthRequest(t, "tx1", TypePurchase, 4000), card, 0, nil, decidedAt, time.Hour)
+	if got := Available(card, 0, []Authorization{auth}, decidedAt); got != 6000 {
+		t.Errorf("expected 6000 available while held, got %d", got)
+	}
+	if got := Available(card, 0, []Authorization{auth}, decidedAt.Add(time.Hour)); got != 10000 {
+		t.Errorf("expected expired hold to be released, got %d", got)
+	}
+	if auth.TimedOut().Holds(decidedAt) != 0 {
+		t.Error("timed out authorization must not hold")
+	}
+
+	tx := makeOriginal(t, TypePurchase, StatusApproved, 4000)
+	matched := auth.MatchWebhook(tx, decidedAt)
+	if matched.Holds(decidedAt) != 0 {
+		t.Error("matched authorization must not hold")
+	}
+}
+
+func TestMatchWebhookDiscrepancies(t *testing.T) {
+	card := makeCard(t, CardActive, 10000, 0, 0)
+	auth := Authorize(makeAuthRequest(t, "tx1", TypePurchase, 1000), card, 0, nil, decidedAt, time.Hour)
+
+	if m := auth.MatchWebhook(makeOriginal(t, TypePurchase, StatusApproved, 1000), decidedAt).Match; len(m.Discrepancies) != 0 {
+		t.Errorf("expected a clean match, got %v", m.Discrepancies)
+	}
+	m := auth.MatchWebhook(makeOriginal(t, TypePurchase, StatusRejected, 1200), decidedAt).Match
+	if len(m.Discrepancies) != 2 {
+		t.Errorf("expected status and amount discrepancies, got %v", m.Discrepancies)
+	}
+}
@@ -0,0 +1,82 @@
+package domain
+
+import "fmt"
+
+// PurchaseState is derived from a purchase's status and the adjustments applied to it.
+type PurchaseState string
+
+const (
+	PurchaseStateApproved          PurchaseState = "APPROVED"
+	PurchaseStateRejected          PurchaseState = "REJECTED"
+	PurchaseStatePartiallyReversed PurchaseState = "PARTIALLY_REVERSED"
+	PurchaseStateFullyReversed     PurchaseState = "FULLY_REVERSED"
+	PurchaseStatePartiallyRefunded PurchaseState = "PARTIALLY_REFUNDED"
+	PurchaseStateFullyRefunded     PurchaseState = "FULLY_REFUNDED"
+	PurchaseStatePartiallyAdjusted PurchaseState = "PARTIALLY_ADJUSTED"
+	PurchaseStateFullyAdjusted     PurchaseState = "FULLY_ADJUSTED"
+)
+
+// PurchaseBalance summarises how much of a purchase has been given back to the cardholder.
+// Amounts are in the purchase's local currency.
+type PurchaseBalance struct {
+	TotalReversed Money
+	TotalRefunded Money
+	// TotalDisputed is held by disputes that are undecided or won.
+	TotalDisputed Money
+	// Remaining is what can still be reversed, refunded or disputed; zero for a purchase that
+	// cannot receive adjustments.
+	Remaining Money
+	State     PurchaseState
+}
+
+// NewPurchaseBalance derives the balance of purchase from the approved reversal and refund totals.
+func NewPurchaseBalance(purchase Transaction, reversed, refunded Money) (PurchaseBalance, error) {
+	adjusted, err := reversed.Add(refunded)
+	if err != nil {
+		return PurchaseBalance{}, err
+	}
+	if adjusted.Currency != purchase.Amount.Local.Currency {
+		return PurchaseBalance{}, fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, adjusted.Currency, purchase.Amount.Local.Currency)
+	}
+
+	b := PurchaseBalance{
+		TotalReversed: reversed,
+		TotalRefunded: refunded,
+		Remaining:     Money{Currency: purchase.Amount.Local.Currency},
+	}
+	if !purchase.CanReceiveAdjustment() {
+		b.State = PurchaseStateRejected
+		return b, nil
+	}
+	b.Remaining.Amount = max(purchase.Amount.Local.Amount-adjusted.Amount, 0)
+
+	full := b.Remaining.Amount == 0
+	switch {
+	case adjusted.Amount == 0:
+		b.State = PurchaseStateApproved
+	case refunded.Amount == 0:
+		b.State = pick(full, PurchaseStateFullyReversed, PurchaseStatePartiallyReversed)
+	case reversed.Amount == 0:
+		b.State = pick(full, PurchaseStateFullyRefunded, PurchaseStatePartiallyRefunded)
+	default:
+		b.State = pick(full, PurchaseStateFullyAdjusted, PurchaseStatePartiallyAdjusted)
+	}
+	return b, nil
+}
+
+// WithDisputed lowers the remaining budget by disputed, the amount held by the purchase's
+// disputes. The state still reflects adjustments only.
+func (b PurchaseBalance) WithDisputed(disputed Money) PurchaseBalance {
+	b.TotalDisputed = disputed
+	if disputed.Currency == b.Remaining.Currency {
+		b.Remaining.Amount = max(b.Remaining.Amount-disputed.Amount, 0)
+	}
+	return b
+}
+
+func pick(full bool, fully, partially PurchaseState) PurchaseState {
+	if full {
+		return fully
+	}
+	return partially
+}
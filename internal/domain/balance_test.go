@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewPurchaseBalance(t *testing.T) {
+	brl := func(amount int64) Money {
+		m, _ := NewMoney(amount, "BRL")
+		return m
+	}
+	approved := makeApprovedPurchase("tx1", 1000)
+	rejected, _ := NewPurchase("tx2", StatusRejected, makeAmountBreakdown(1000, "BRL"), makeMerchant(), makeEvent("idem-tx2"), "u", "c", "BR", "BRL", "POS")
+
+	cases := []struct {
+		name      string
+		purchase  Transaction
+		reversed  int64
+		refunded  int64
+		state     PurchaseState
+		remaining int64
+	}{
+		{"no adjustments", approved, 0, 0, PurchaseStateApproved, 1000},
+		{"partial reversal", approved, 400, 0, PurchaseStatePartiallyReversed, 600},
+		{"full reversal", approved, 1000, 0, PurchaseStateFullyReversed, 0},
+		{"partial refund", approved, 0, 300, PurchaseStatePartiallyRefunded, 700},
+		{"full refund", approved, 0, 1000, PurchaseStateFullyRefunded, 0},
+		{"partial mixed", approved, 200, 300, PurchaseStatePartiallyAdjusted, 500},
+		{"full mixed", approved, 400, 600, PurchaseStateFullyAdjusted, 0},
+		{"rejected purchase", rejected, 0, 0, PurchaseStateRejected, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := NewPurchaseBalance(tc.purchase, brl(tc.reversed), brl(tc.refunded))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if b.State != tc.state {
+				t.Errorf("expected state %s, got %s", tc.state, b.State)
+			}
+			if b.Remaining.Amount != tc.remaining || b.Remaining.Currency != "BRL" {
+				t.Errorf("expected remaining %d BRL, got %d %s", tc.remaining, b.Remaining.Amount, b.Remaining.Currency)
+			}
+			if b.TotalReversed.Amount != tc.reversed || b.TotalRefunded.Amount != tc.refunded {
+				t.Errorf("unexpected totals: %+v", b)
+			}
+		})
+	}
+
+	t.Run("currency mismatch", func(t *testing.T) {
+		usd, _ := NewMoney(100, "USD")
+		_, err := NewPurchaseBalance(approved, usd, usd)
+		if !errors.Is(err, ErrCurrencyMismatch) {
+			t.Errorf("expected ErrCurrencyMismatch, got %v", err)
+		}
+	})
+}
+
+func TestPurchaseBalanceWithDisputed(t *testing.T) {
+	refunded, _ := NewMoney(300, "BRL")
+	zero, _ := NewMoney(0, "BRL")
+	b, _ := NewPurchaseBalance(makeApprovedPurchase("tx1", 1000), zero, refunded)
+
+	disputed, _ := NewMoney(500, "BRL")
+	got := b.WithDisputed(disputed)
+	if got.Remaining.Amount != 200 || got.TotalDisputed != disputed || got.State != PurchaseStatePartiallyRefunded {
+		t.Errorf("expected 200 remaining with the refund state kept, got %+v", got)
+	}
+	disputed.Amount = 900
+	if got := b.WithDisputed(disputed); got.Remaining.Amount != 0 {
+		t.Errorf("expected the remaining budget floored at zero, got %+v", got.Remaining)
+	}
+}
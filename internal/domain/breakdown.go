@@ -0,0 +1,65 @@
+package domain
+
+import "math/big"
+
+// Webhook fields a CurrencyMismatchError can point at.
+const (
+	FieldCurrency            = "currency"
+	FieldLocalCurrency       = "amount.local.currency"
+	FieldTransactionCurrency = "amount.transaction.currency"
+	FieldSettlementCurrency  = "amount.settlement.currency"
+)
+
+// ExchangeRateDecimals is the precision of Transaction.ExchangeRate.
+const ExchangeRateDecimals = 6
+
+// validateCurrencies checks that the local amount is in the currency the transaction declares.
+func (b AmountBreakdown) validateCurrencies(declared string) error {
+	if declared != b.Local.Currency {
+		return &CurrencyMismatchError{Field: FieldCurrency, Currency: declared, Expected: b.Local.Currency}
+	}
+	return nil
+}
+
+// ValidateSettlement checks that the settlement amount is in the program's settlement currency.
+func (b AmountBreakdown) ValidateSettlement(currency string) error {
+	if b.Settlement.Currency != currency {
+		return &CurrencyMismatchError{Field: FieldSettlementCurrency, Currency: b.Settlement.Currency, Expected: currency}
+	}
+	return nil
+}
+
+// validateSameCurrencies checks that an adjustment's breakdown uses the currencies of the
+// transaction it adjusts.
+func (b AmountBreakdown) validateSameCurrencies(original AmountBreakdown) error {
+	for _, f := range []struct {
+		field         string
+		got, expected string
+	}{
+		{FieldLocalCurrency, b.Local.Currency, original.Local.Currency},
+		{FieldTransactionCurrency, b.Transaction.Currency, original.Transaction.Currency},
+		{FieldSettlementCurrency, b.Settlement.Currency, original.Settlement.Currency},
+	} {
+		if f.got != f.expected {
+			return &CurrencyMismatchError{Field: f.field, Currency: f.got, Expected: f.expected}
+		}
+	}
+	return nil
+}
+
+// impliedExchangeRate returns how many local units one transaction-currency unit cost, for a
+// breakdown whose transaction and local currencies differ. It is empty for domestic
+// transactions and for zero amounts, which imply no rate.
+func (b AmountBreakdown) impliedExchangeRate() string {
+	local, okLocal := LookupCurrency(b.Local.Currency)
+	tx, okTx := LookupCurrency(b.Transaction.Currency)
+	if !okLocal || !okTx || local.Code == tx.Code || b.Local.Amount == 0 || b.Transaction.Amount == 0 {
+		return ""
+	}
+	// (local / 10^localMinor) / (tx / 10^txMinor)
+	rate := new(big.Rat).SetFrac(
+		new(big.Int).Mul(big.NewInt(b.Local.Amount), big.NewInt(pow10(tx.MinorUnits))),
+		new(big.Int).Mul(big.NewInt(b.Transaction.Amount), big.NewInt(pow10(local.MinorUnits))),
+	)
+	return rate.FloatString(ExchangeRateDecimals)
+}
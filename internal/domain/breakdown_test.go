@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+// crossBorder is a BRL card paying txAmount of txCurrency, billed localAmount BRL.
+func crossBorder(localAmount int64, txAmount int64, txCurrency string) AmountBreakdown {
+	b := makeAmountBreakdown(localAmount, "BRL")
+	b.Transaction = Money{Amount: txAmount, Currency: txCurrency}
+	return b
+}
+
+func assertMismatch(t *testing.T, err error, field string) {
+	t.Helper()
+	var mismatch *CurrencyMismatchError
+	if !errors.Is(err, ErrCurrencyMismatch) || !errors.As(err, &mismatch) {
+		t.Fatalf("expected a CurrencyMismatchError, got %v", err)
+	}
+	if mismatch.Field != field {
+		t.Errorf("expected field %s, got %s", field, mismatch.Field)
+	}
+}
+
+func TestDeclaredCurrencyMustMatchLocal(t *testing.T) {
+	_, err := NewPurchase("tx1", StatusApproved, makeAmountBreakdown(1000, "BRL"), makeMerchant(), makeEvent("idem1"), "u", "c", "BR", "USD", "POS")
+	assertMismatch(t, err, FieldCurrency)
+
+	_, err = NewPurchase("tx1", StatusApproved, makeAmountBreakdown(1000, "BRL"), makeMerchant(), makeEvent("idem1"), "u", "c", "BR", "", "POS")
+	assertMismatch(t, err, FieldCurrency)
+
+	_, err = NewAdjustment("adj1", TypeRefund, StatusApproved, makeAmountBreakdown(500, "BRL"), makeMerchant(), makeEvent("idem-adj1"), "tx1", "u", "c", "BR", "MXN", "POS")
+	assertMismatch(t, err, FieldCurrency)
+}
+
+func TestValidateSettlement(t *testing.T) {
+	b := makeAmountBreakdown(1000, "BRL")
+	if err := b.ValidateSettlement("BRL"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := b.ValidateSettlement("USD")
+	assertMismatch(t, err, FieldSettlementCurrency)
+	if want := "currency mismatch: amount.settlement.currency is BRL, expected USD"; err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+}
+
+func TestAdjustmentCurrenciesMatchOriginal(t *testing.T) {
+	zero, _ := NewMoney(0, "BRL")
+	purchase, err := NewPurchase("tx1", StatusApproved, crossBorder(5235, 1000, "USD"), makeMerchant(), makeEvent("idem1"), "u", "c", "BR", "BRL", "POS")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	refund := func(amount AmountBreakdown) Adjustment {
+		adj, err := NewAdjustment("adj1", TypeRefund, StatusApproved, amount, makeMerchant(), makeEvent("idem-adj1"), "tx1", "u", "c", "BR", "BRL", "POS")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return adj
+	}
+
+	if err := refund(crossBorder(1000, 200, "USD")).ValidateAgainstOriginal(purchase, zero); err != nil {
+		t.Errorf("same currencies should pass, got %v", err)
+	}
+	err = refund(crossBorder(1000, 180, "EUR")).ValidateAgainstOriginal(purchase, zero)
+	assertMismatch(t, err, FieldTransactionCurrency)
+
+	settledInUSD := crossBorder(1000, 200, "USD")
+	settledInUSD.Settlement = Money{Amount: 200, Currency: "USD"}
+	err = refund(settledInUSD).ValidateAgainstOriginal(purchase, zero)
+	assertMismatch(t, err, FieldSettlementCurrency)
+}
+
+func TestImpliedExchangeRate(t *testing.T) {
+	tests := []struct {
+		name   string
+		txType TransactionType
+		amount AmountBreakdown
+		want   string
+	}{
+		{"domestic", TypeWithdrawal, makeAmountBreakdown(1000, "BRL"), ""},
+		{"USD to BRL", TypeWithdrawal, crossBorder(5235, 1000, "USD"), "5.235000"},
+		{"zero-decimal currency", TypeWithdrawal, crossBorder(3500, 1000, "JPY"), "0.035000"},
+		{"three-decimal currency", TypeWithdrawal, crossBorder(1630, 1000, "KWD"), "16.300000"},
+		{"repeating decimal", TypeWithdrawal, crossBorder(1000, 300, "USD"), "3.333333"},
+		{"zero amount", TypeBalanceInquiry, crossBorder(0, 0, "USD"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := NewTransaction("tx1", tt.txType, StatusApproved, tt.amount, makeMerchant(), makeEvent("idem1"), "u", "c", "BR", "BRL", "POS")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tx.ExchangeRate != tt.want {
+				t.Errorf("expected rate %q, got %q", tt.want, tx.ExchangeRate)
+			}
+		})
+	}
+}
+
+func TestCurrencyMismatchErrorLocalized(t *testing.T) {
+	err := &CurrencyMismatchError{Field: FieldCurrency, Expected: "BRL"}
+	for lang, want := range map[string]string{
+		LangEnglish:    `currency mismatch: currency is "", expected BRL`,
+		LangPortuguese: `moeda divergente: currency é "", esperado BRL`,
+		LangSpanish:    `moneda no coincide: currency es "", se esperaba BRL`,
+	} {
+		if got := err.Localize(lang); got != want {
+			t.Errorf("%s: expected %q, got %q", lang, want, got)
+		}
+	}
+}
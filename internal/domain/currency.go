@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency. Money amounts are integers in its minor unit: a BRL amount
+// of 100 is R$1,00, a CLP amount of 100 is $100.
+type Currency struct {
+	Code       string
+	MinorUnits int
+}
+
+// iso4217 lists the active ISO 4217 codes by number of minor units; funds codes and precious
+// metals without a minor unit are left out.
+var iso4217 = map[int]string{
+	0: "BIF CLP DJF GNF ISK JPY KMF KRW PYG RWF UGX UYI VND VUV XAF XOF XPF",
+	2: "AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BOV BRL BSD BTN BWP " +
+		"BYN BZD CAD CDF CHE CHF CHW CNY COP COU CRC CUP CVE CZK DKK DOP DZD EGP ERN ETB EUR FJD " +
+		"FKP GBP GEL GHS GIP GMD GTQ GYD HKD HNL HTG HUF IDR ILS INR IRR JMD KES KGS KHR KPW KYD " +
+		"KZT LAK LBP LKR LRD LSL MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR MZN NAD " +
+		"NGN NIO NOK NPR NZD PAB PEN PGK PHP PKR PLN QAR RON RSD RUB SAR SBD SCR SDG SEK SGD SHP " +
+		"SLE SOS SRD SSP STN SVC SYP SZL THB TJS TMT TOP TRY TTD TWD TZS UAH USD USN UYU UZS VED " +
+		"VES WST XCD XCG YER ZAR ZMW ZWG",
+	3: "BHD IQD JOD KWD LYD OMR TND",
+	4: "CLF UYW",
+}
+
+var currencies = func() map[string]Currency {
+	m := make(map[string]Currency)
+	for minor, codes := range iso4217 {
+		for _, code := range strings.Fields(codes) {
+			m[code] = Currency{Code: code, MinorUnits: minor}
+		}
+	}
+	return m
+}()
+
+// LookupCurrency returns the ISO 4217 currency with the given upper-case code.
+func LookupCurrency(code string) (Currency, bool) {
+	c, ok := currencies[code]
+	return c, ok
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for range n {
+		p *= 10
+	}
+	return p
+}
+
+// moneyFormat is how a country writes amounts of its currency.
+type moneyFormat struct {
+	symbol    string
+	thousands string
+	decimal   string
+}
+
+// localFormats covers the currencies Pomelo operates in. The symbol is prefixed with the
+// country where "$" alone would be ambiguous in a message.
+var localFormats = map[string]moneyFormat{
+	"BRL": {"R$", ".", ","},
+	"MXN": {"MX$", ",", "."},
+	"COP": {"COL$", ".", ","},
+	"ARS": {"AR$", ".", ","},
+}
+
+// Format renders minor units of c the way its country writes them ("R$1.234,56", "MX$1,234.56"),
+// or as "USD 1,234.56" for currencies without a local format.
+func (c Currency) Format(minor int64) string {
+	f, ok := localFormats[c.Code]
+	if !ok {
+		f = moneyFormat{symbol: c.Code + " ", thousands: ",", decimal: "."}
+	}
+	sign := ""
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+	div := pow10(c.MinorUnits)
+	units := fmt.Sprint(minor / div)
+	for i := len(units) - 3; i > 0; i -= 3 {
+		units = units[:i] + f.thousands + units[i:]
+	}
+	if c.MinorUnits == 0 {
+		return sign + f.symbol + units
+	}
+	return fmt.Sprintf("%s%s%s%s%0*d", sign, f.symbol, units, f.decimal, c.MinorUnits, minor%div)
+}
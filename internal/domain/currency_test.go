@@ -0,0 +1,43 @@
+package domain
+
+import "testing"
+
+func TestLookupCurrency(t *testing.T) {
+	for code, minor := range map[string]int{"BRL": 2, "MXN": 2, "COP": 2, "ARS": 2, "CLP": 0, "JPY": 0, "KWD": 3, "CLF": 4} {
+		c, ok := LookupCurrency(code)
+		if !ok || c.MinorUnits != minor {
+			t.Errorf("%s: got %+v %v, want %d minor units", code, c, ok, minor)
+		}
+	}
+	for _, code := range []string{"", "brl", "BR", "XYZ", "XAU"} {
+		if _, ok := LookupCurrency(code); ok {
+			t.Errorf("%q should not be a currency", code)
+		}
+	}
+}
+
+func TestCurrencyFormat(t *testing.T) {
+	cases := []struct {
+		code  string
+		minor int64
+		want  string
+	}{
+		{"BRL", 0, "R$0,00"},
+		{"BRL", 5, "R$0,05"},
+		{"BRL", 123456, "R$1.234,56"},
+		{"BRL", 123456789, "R$1.234.567,89"},
+		{"MXN", 2_000_000, "MX$20,000.00"},
+		{"COP", 400_000_000, "COL$4.000.000,00"},
+		{"ARS", 20_000, "AR$200,00"},
+		{"USD", 100_000, "USD 1,000.00"},
+		{"CLP", 5_000, "CLP 5,000"},
+		{"KWD", 1_234_567, "KWD 1,234.567"},
+		{"BRL", -150, "-R$1,50"},
+	}
+	for _, tc := range cases {
+		c, _ := LookupCurrency(tc.code)
+		if got := c.Format(tc.minor); got != tc.want {
+			t.Errorf("%s %d: got %q, want %q", tc.code, tc.minor, got, tc.want)
+		}
+	}
+}
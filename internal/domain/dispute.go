@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DisputeState is where a cardholder's dispute of a purchase stands. WON and LOST are from the
+// cardholder's side: a won dispute is charged back to the merchant.
+type DisputeState string
+
+const (
+	DisputeOpened            DisputeState = "OPENED"
+	DisputeEvidenceSubmitted DisputeState = "EVIDENCE_SUBMITTED"
+	DisputeWon               DisputeState = "WON"
+	DisputeLost              DisputeState = "LOST"
+	DisputeCancelled         DisputeState = "CANCELLED"
+)
+
+// disputeTransitions lists the states each state may move to. WON, LOST and CANCELLED are final.
+var disputeTransitions = map[DisputeState][]DisputeState{
+	DisputeOpened:            {DisputeEvidenceSubmitted, DisputeWon, DisputeLost, DisputeCancelled},
+	DisputeEvidenceSubmitted: {DisputeWon, DisputeLost, DisputeCancelled},
+}
+
+// Ledger entry types of dispute postings. They are not webhook transaction types.
+const (
+	TypeDisputeCredit         TransactionType = "DISPUTE_PROVISIONAL_CREDIT"
+	TypeDisputeChargeback     TransactionType = "DISPUTE_CHARGEBACK"
+	TypeDisputeCreditReversal TransactionType = "DISPUTE_CREDIT_REVERSAL"
+)
+
+// DisputeSuspenseAccount funds provisional credits until the dispute is decided: the merchant pays
+// it back on a chargeback, the cardholder on a lost or cancelled dispute.
+const DisputeSuspenseAccount = "suspense:disputes"
+
+// Valid reports whether s is a known dispute state.
+func (s DisputeState) Valid() bool {
+	return s == DisputeWon || s == DisputeLost || s == DisputeCancelled || disputeTransitions[s] != nil
+}
+
+// Final reports whether no further transition is allowed from s.
+func (s DisputeState) Final() bool {
+	return s.Valid() && disputeTransitions[s] == nil
+}
+
+// DisputeTransition is one step in a dispute's history.
+type DisputeTransition struct {
+	From DisputeState
+	To   DisputeState
+	At   time.Time
+	Note string
+}
+
+// Dispute is a cardholder's claim against part or all of an approved purchase. The disputed
+// amount is credited to the card provisionally when the dispute opens.
+type Dispute struct {
+	ID            string
+	TransactionID string
+	UserID        string
+	CardID        string
+	MerchantID    string
+	// Amount is in the purchase's local currency.
+	Amount   Money
+	Reason   string
+	State    DisputeState
+	OpenedAt time.Time
+	// ResolvedAt is when the dispute reached a final state.
+	ResolvedAt time.Time
+	History    []DisputeTransition
+}
+
+// OpenDispute opens a dispute of amount against purchase. committed is what the purchase already
+// gave back: approved reversals and refunds plus the disputes that still hold their amount.
+func OpenDispute(id string, purchase Transaction, amount Money, reason string, committed Money, openedAt time.Time) (Dispute, error) {
+	if id == "" || reason == "" {
+		return Dispute{}, fmt.Errorf("%w: dispute ID and reason are required", ErrInvalidInput)
+	}
+	if purchase.Type != TypePurchase {
+		return Dispute{}, fmt.Errorf("%w: only a PURCHASE can be disputed, not %s", ErrOriginalTypeMismatch, purchase.Type)
+	}
+	if !purchase.CanReceiveAdjustment() {
+		return Dispute{}, ErrPurchaseNotApproved
+	}
+	if amount.Amount <= 0 {
+		return Dispute{}, fmt.Errorf("%w: disputed amount must be positive", ErrInvalidInput)
+	}
+	if amount.Currency != purchase.Amount.Local.Currency {
+		return Dispute{}, fmt.Errorf("%w: disputed %s, purchase is in %s", ErrCurrencyMismatch, amount.Currency, purchase.Amount.Local.Currency)
+	}
+	if err := checkBudget(purchase, committed, amount); err != nil {
+		return Dispute{}, err
+	}
+	return Dispute{
+		ID:            id,
+		TransactionID: purchase.ID,
+		UserID:        purchase.UserID,
+		CardID:        purchase.CardID,
+		MerchantID:    purchase.Merchant.ID,
+		Amount:        amount,
+		Reason:        reason,
+		State:         DisputeOpened,
+		OpenedAt:      openedAt,
+		History:       []DisputeTransition{{To: DisputeOpened, At: openedAt, Note: reason}},
+	}, nil
+}
+
+// Transition moves the dispute to state, recording note in its history.
+func (d Dispute) Transition(to DisputeState, note string, at time.Time) (Dispute, error) {
+	if !slices.Contains(disputeTransitions[d.State], to) {
+		return d, fmt.Errorf("%w: %s to %s", ErrInvalidDisputeTransition, d.State, to)
+	}
+	d.History = append(slices.Clip(d.History), DisputeTransition{From: d.State, To: to, At: at, Note: note})
+	d.State = to
+	if to.Final() {
+		d.ResolvedAt = at
+	}
+	return d, nil
+}
+
+// HoldsAmount reports whether the disputed amount still counts against the purchase: while the
+// dispute is undecided, and for good once it is won.
+func (d Dispute) HoldsAmount() bool {
+	return d.State != DisputeLost && d.State != DisputeCancelled
+}
+
+// PostDispute returns the postings the dispute has produced so far, in order: the provisional
+// credit, then the chargeback of a won dispute or the reversal of the credit of a lost or
+// cancelled one. Posting IDs are derived from the dispute ID, so posting them again is detected
+// as a duplicate.
+func PostDispute(d Dispute) [][]LedgerEntry {
+	postings := [][]LedgerEntry{
+		d.posting(":credit", TypeDisputeCredit, DisputeSuspenseAccount, CardAccount(d.CardID), d.OpenedAt),
+	}
+	switch d.State {
+	case DisputeWon:
+		postings = append(postings, d.posting(":chargeback", TypeDisputeChargeback, MerchantAccount(d.MerchantID), DisputeSuspenseAccount, d.ResolvedAt))
+	case DisputeLost, DisputeCancelled:
+		postings = append(postings, d.posting(":reversal", TypeDisputeCreditReversal, CardAccount(d.CardID), DisputeSuspenseAccount, d.ResolvedAt))
+	}
+	return postings
+}
+
+// posting debits one account and credits the other by the disputed amount.
+func (d Dispute) posting(suffix string, txType TransactionType, debit, credit string, at time.Time) []LedgerEntry {
+	entry := LedgerEntry{PostingID: d.ID + suffix, TransactionID: d.TransactionID, Type: txType, UserID: d.UserID, CardID: d.CardID, Amount: d.Amount, PostedAt: at}
+	debitEntry, creditEntry := entry, entry
+	debitEntry.Account, debitEntry.Side = debit, DirectionDebit
+	creditEntry.Account, creditEntry.Side = credit, DirectionCredit
+	return []LedgerEntry{debitEntry, creditEntry}
+}
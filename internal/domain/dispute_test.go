@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func brlMoney(amount int64) Money {
+	m, _ := NewMoney(amount, "BRL")
+	return m
+}
+
+func TestOpenDispute(t *testing.T) {
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	purchase := makeApprovedPurchase("tx1", 1000)
+
+	d, err := OpenDispute("dsp1", purchase, brlMoney(400), "item not received", brlMoney(600), at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.State != DisputeOpened || d.TransactionID != "tx1" || d.CardID != purchase.CardID || d.MerchantID != "m1" ||
+		len(d.History) != 1 || !d.OpenedAt.Equal(at) {
+		t.Errorf("unexpected dispute %+v", d)
+	}
+
+	rejected, _ := NewPurchase("tx2", StatusRejected, makeAmountBreakdown(1000, "BRL"), makeMerchant(), makeEvent("idem-tx2"), "u", "c", "BR", "BRL", "POS")
+	withdrawal, _ := NewTransaction("tx3", TypeWithdrawal, StatusApproved, makeAmountBreakdown(5000, "BRL"), makeMerchant(), makeEvent("idem-tx3"), "u", "c", "BR", "BRL", "POS")
+	usd, _ := NewMoney(100, "USD")
+	cases := []struct {
+		name      string
+		purchase  Transaction
+		amount    Money
+		reason    string
+		committed Money
+		want      error
+	}{
+		{"budget exhausted by adjustments and disputes", purchase, brlMoney(401), "fraud", brlMoney(600), ErrExceedsOriginalAmount},
+		{"rejected purchase", rejected, brlMoney(100), "fraud", brlMoney(0), ErrPurchaseNotApproved},
+		{"not a purchase", withdrawal, brlMoney(100), "fraud", brlMoney(0), ErrOriginalTypeMismatch},
+		{"other currency", purchase, usd, "fraud", brlMoney(0), ErrCurrencyMismatch},
+		{"zero amount", purchase, brlMoney(0), "fraud", brlMoney(0), ErrInvalidInput},
+		{"no reason", purchase, brlMoney(100), "", brlMoney(0), ErrInvalidInput},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := OpenDispute("dsp1", tc.purchase, tc.amount, tc.reason, tc.committed, at); !errors.Is(err, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestDisputeTransitions(t *testing.T) {
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	d, _ := OpenDispute("dsp1", makeApprovedPurchase("tx1", 1000), brlMoney(400), "fraud", brlMoney(0), at)
+
+	d, err := d.Transition(DisputeEvidenceSubmitted, "receipt attached", at.Add(time.Hour))
+	if err != nil || d.State != DisputeEvidenceSubmitted || !d.ResolvedAt.IsZero() || !d.HoldsAmount() {
+		t.Fatalf("unexpected dispute %+v (err %v)", d, err)
+	}
+	if _, err := d.Transition(DisputeOpened, "", at); !errors.Is(err, ErrInvalidDisputeTransition) {
+		t.Errorf("expected ErrInvalidDisputeTransition going back to OPENED, got %v", err)
+	}
+	lost, err := d.Transition(DisputeLost, "merchant proved delivery", at.Add(2*time.Hour))
+	if err != nil || !lost.ResolvedAt.Equal(at.Add(2*time.Hour)) || lost.HoldsAmount() || len(lost.History) != 3 {
+		t.Fatalf("unexpected lost dispute %+v (err %v)", lost, err)
+	}
+	if h := lost.History[2]; h.From != DisputeEvidenceSubmitted || h.To != DisputeLost || h.Note != "merchant proved delivery" {
+		t.Errorf("unexpected history entry %+v", h)
+	}
+	if _, err := lost.Transition(DisputeWon, "", at); !errors.Is(err, ErrInvalidDisputeTransition) {
+		t.Errorf("expected a final state to stay final, got %v", err)
+	}
+	if len(d.History) != 2 {
+		t.Errorf("transitions must not touch the original history, got %+v", d.History)
+	}
+	if DisputeState("PENDING").Valid() || !DisputeWon.Final() || DisputeOpened.Final() {
+		t.Error("unexpected state classification")
+	}
+}
+
+func TestPostDispute(t *testing.T) {
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	d, _ := OpenDispute("dsp1", makeApprovedPurchase("tx1", 1000), brlMoney(400), "fraud", brlMoney(0), at)
+
+	postings := PostDispute(d)
+	if len(postings) != 1 {
+		t.Fatalf("expected only the provisional credit, got %d postings", len(postings))
+	}
+	credit := postings[0]
+	if credit[0].Account != DisputeSuspenseAccount || credit[0].Side != DirectionDebit ||
+		credit[1].Account != CardAccount(d.CardID) || credit[1].Side != DirectionCredit || credit[1].PostingID != "dsp1:credit" {
+		t.Errorf("unexpected provisional credit %+v", credit)
+	}
+
+	won, _ := d.Transition(DisputeWon, "", at.Add(time.Hour))
+	lost, _ := d.Transition(DisputeCancelled, "", at.Add(time.Hour))
+	for _, tc := range []struct {
+		d       Dispute
+		debit   string
+		posting string
+	}{
+		{won, MerchantAccount("m1"), "dsp1:chargeback"},
+		{lost, CardAccount(d.CardID), "dsp1:reversal"},
+	} {
+		postings := PostDispute(tc.d)
+		if len(postings) != 2 {
+			t.Fatalf("%s: expected two postings, got %d", tc.d.State, len(postings))
+		}
+		second := postings[1]
+		if second[0].Account != tc.debit || second[0].PostingID != tc.posting || second[1].Account != DisputeSuspenseAccount || !second[0].PostedAt.Equal(at.Add(time.Hour)) {
+			t.Errorf("%s: unexpected posting %+v", tc.d.State, second)
+		}
+		var all []LedgerEntry
+		for _, p := range postings {
+			all = append(all, p...)
+		}
+		if _, err := VerifyBalanced(all); err != nil {
+			t.Errorf("%s: %v", tc.d.State, err)
+		}
+	}
+}
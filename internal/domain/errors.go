@@ -4,14 +4,29 @@ import "errors"
 
 var (
 	ErrTransactionNotFound         = errors.New("transaction not found")
-	ErrPurchaseNotApproved         = errors.New("adjustment target must be an approved purchase")
+	ErrPurchaseNotApproved         = errors.New("adjustment target must be an approved transaction")
+	ErrOriginalTypeMismatch        = errors.New("adjustment type does not apply to the original transaction type")
 	ErrExceedsOriginalAmount       = errors.New("total adjustments exceed original purchase amount")
 	ErrNegativeAmount              = errors.New("amount cannot be negative")
-	ErrAmountOutOfRange            = errors.New("purchase amount must be between R$1,00 and R$5.000,00")
+	ErrAmountOutOfRange            = errors.New("amount out of range")
 	ErrCurrencyMismatch            = errors.New("currency mismatch")
+	ErrInvalidCurrency             = errors.New("invalid currency")
 	ErrInvalidTransactionType      = errors.New("invalid transaction type")
 	ErrDuplicateIdempotencyKey     = errors.New("duplicate idempotency key")
+	ErrIdempotencyKeyReused        = errors.New("idempotency key reused with a different payload")
 	ErrOriginalTransactionRequired = errors.New("reversal/refund must reference an original transaction")
 	ErrDuplicateTransactionID      = errors.New("transaction ID already exists with a different event")
 	ErrInvalidInput                = errors.New("invalid input")
+	ErrLedgerUnbalanced            = errors.New("ledger debits and credits do not balance")
+	ErrDuplicatePosting            = errors.New("transaction already posted to the ledger")
+	ErrCardNotFound                = errors.New("card not found")
+	ErrAuthorizationNotFound       = errors.New("authorization not found")
+	ErrDuplicateAuthorization      = errors.New("transaction already authorized")
+	ErrSubscriptionNotFound        = errors.New("subscription not found")
+	ErrDeliveryNotFound            = errors.New("delivery not found")
+	ErrRejectionNotFound           = errors.New("rejected webhook not found")
+	ErrRejectionResolved           = errors.New("rejected webhook already resolved")
+	ErrDisputeNotFound             = errors.New("dispute not found")
+	ErrInvalidDisputeTransition    = errors.New("invalid dispute transition")
+	ErrInvalidSettlementFile       = errors.New("invalid settlement file")
 )
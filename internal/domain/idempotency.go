@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// IdempotencyRecord is the response given to the first request with an idempotency key. A retry
+// with the same payload gets it back unchanged until the record expires.
+type IdempotencyRecord struct {
+	Key string
+	// PayloadHash identifies the request payload; the adapter that receives it decides how the
+	// payload is canonicalized before hashing.
+	PayloadHash string
+	StatusCode  int
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+func NewIdempotencyRecord(key, payloadHash string, statusCode int, body []byte, createdAt time.Time, retention time.Duration) (IdempotencyRecord, error) {
+	if key == "" || payloadHash == "" {
+		return IdempotencyRecord{}, fmt.Errorf("%w: idempotency key and payload hash are required", ErrInvalidInput)
+	}
+	if retention <= 0 {
+		return IdempotencyRecord{}, fmt.Errorf("%w: idempotency retention must be positive", ErrInvalidInput)
+	}
+	return IdempotencyRecord{
+		Key:         key,
+		PayloadHash: payloadHash,
+		StatusCode:  statusCode,
+		Body:        body,
+		CreatedAt:   createdAt,
+		ExpiresAt:   createdAt.Add(retention),
+	}, nil
+}
+
+// Expired reports whether the record may no longer be replayed at now.
+func (r IdempotencyRecord) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// HasResponse reports whether the record holds a response to replay. A record without one only
+// ties the key to its payload.
+func (r IdempotencyRecord) HasResponse() bool {
+	return r.StatusCode != 0
+}
+
+// WithoutResponse keeps the key's payload and expiry but drops the response, so the next request
+// with the same payload is processed again.
+func (r IdempotencyRecord) WithoutResponse() IdempotencyRecord {
+	r.StatusCode, r.Body = 0, nil
+	return r
+}
+
+// Match checks that a retry carries the payload the record was made for.
+func (r IdempotencyRecord) Match(payloadHash string) error {
+	if payloadHash != r.PayloadHash {
+		return fmt.Errorf("%w: %q", ErrIdempotencyKeyReused, r.Key)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewIdempotencyRecord(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	rec, err := NewIdempotencyRecord("idem1", "hash", 200, []byte(`{}`), createdAt, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Expired(createdAt.Add(59*time.Minute)) || !rec.Expired(createdAt.Add(time.Hour)) {
+		t.Errorf("expected the record to expire after exactly one hour, expires at %v", rec.ExpiresAt)
+	}
+	for _, tc := range []struct {
+		name      string
+		key, hash string
+		retention time.Duration
+	}{
+		{"missing key", "", "hash", time.Hour},
+		{"missing hash", "idem1", "", time.Hour},
+		{"no retention", "idem1", "hash", 0},
+	} {
+		if _, err := NewIdempotencyRecord(tc.key, tc.hash, 200, nil, createdAt, tc.retention); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%s: expected ErrInvalidInput, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestIdempotencyRecordMatch(t *testing.T) {
+	rec, _ := NewIdempotencyRecord("idem1", "hash", 200, nil, time.Now(), time.Hour)
+	if err := rec.Match("hash"); err != nil {
+		t.Errorf("same payload should match, got %v", err)
+	}
+	if err := rec.Match("other"); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("expected ErrIdempotencyKeyReused, got %v", err)
+	}
+}
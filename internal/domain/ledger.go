@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// LedgerEntry is one side of a double-entry posting. Every approved transaction or adjustment that
+// moves funds posts two entries of the same amount: one on the card account, the opposite one on the
+// merchant account.
+type LedgerEntry struct {
+	// PostingID is the ID of the transaction or adjustment that produced the entry.
+	PostingID string
+	// TransactionID is the original transaction; equal to PostingID for originals.
+	TransactionID string
+	Type          TransactionType
+	Account       string
+	UserID        string
+	CardID        string
+	Side          Direction
+	Amount        Money
+	PostedAt      time.Time
+	// RunningBalance is the account's debits minus credits, in Amount.Currency, after this entry.
+	// Set by the ledger store.
+	RunningBalance int64
+}
+
+// AccountBalance is the net position of an account, user or the whole ledger in one currency.
+type AccountBalance struct {
+	Currency string
+	Debits   int64
+	Credits  int64
+	// Net is Debits minus Credits. For a card it is what the cardholder spent net of reversals,
+	// refunds and credits.
+	Net int64
+}
+
+// Apply adds entry to the balance.
+func (b AccountBalance) Apply(entry LedgerEntry) AccountBalance {
+	if entry.Side == DirectionDebit {
+		b.Debits += entry.Amount.Amount
+	} else {
+		b.Credits += entry.Amount.Amount
+	}
+	b.Net = b.Debits - b.Credits
+	return b
+}
+
+func CardAccount(cardID string) string         { return "card:" + cardID }
+func MerchantAccount(merchantID string) string { return "merchant:" + merchantID }
+
+// PostTransaction returns the entries for an original transaction, or nil when it does not move
+// funds (rejected, BALANCE_INQUIRY or zero amount).
+func PostTransaction(tx Transaction) []LedgerEntry {
+	if tx.Status != StatusApproved {
+		return nil
+	}
+	return newPosting(tx.ID, tx.ID, tx.Type, tx.Amount.Local, tx.Merchant.ID, tx.UserID, tx.CardID, tx.Event.CreatedAt)
+}
+
+// PostAdjustment returns the entries for an adjustment, or nil when it does not move funds.
+func PostAdjustment(adj Adjustment) []LedgerEntry {
+	if adj.Status != StatusApproved {
+		return nil
+	}
+	return newPosting(adj.ID, adj.OriginalTransactionID, adj.Type, adj.Amount.Local, adj.Merchant.ID, adj.UserID, adj.CardID, adj.Event.CreatedAt)
+}
+
+func newPosting(postingID, txID string, txType TransactionType, amount Money, merchantID, userID, cardID string, at time.Time) []LedgerEntry {
+	cardSide := txType.Direction()
+	if cardSide == DirectionNone || amount.Amount == 0 {
+		return nil
+	}
+	merchantSide := DirectionCredit
+	if cardSide == DirectionCredit {
+		merchantSide = DirectionDebit
+	}
+	entry := LedgerEntry{PostingID: postingID, TransactionID: txID, Type: txType, UserID: userID, CardID: cardID, Amount: amount, PostedAt: at}
+	card, merchant := entry, entry
+	card.Account, card.Side = CardAccount(cardID), cardSide
+	merchant.Account, merchant.Side = MerchantAccount(merchantID), merchantSide
+	return []LedgerEntry{card, merchant}
+}
+
+// VerifyBalanced checks that debits equal credits in every currency and returns the totals.
+func VerifyBalanced(entries []LedgerEntry) ([]AccountBalance, error) {
+	byCurrency := map[string]AccountBalance{}
+	var order []string
+	for _, e := range entries {
+		b, ok := byCurrency[e.Amount.Currency]
+		if !ok {
+			b.Currency = e.Amount.Currency
+			order = append(order, e.Amount.Currency)
+		}
+		byCurrency[e.Amount.Currency] = b.Apply(e)
+	}
+	totals := make([]AccountBalance, 0, len(order))
+	var err error
+	for _, currency := range order {
+		b := byCurrency[currency]
+		totals = append(totals, b)
+		if b.Net != 0 && err == nil {
+			err = fmt.Errorf("%w: %s debits %d, credits %d", ErrLedgerUnbalanced, currency, b.Debits, b.Credits)
+		}
+	}
+	return totals, err
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func makeOriginal(t *testing.T, txType TransactionType, status TransactionStatus, amount int64) Transaction {
+	t.Helper()
+	tx, err := NewTransaction("tx1", txType, status, makeAmountBreakdown(amount, "BRL"), makeMerchant(), makeEvent("idem1"), "u1", "card1", "BR", "BRL", "POS")
+	if err != nil {
+		t.Fatalf("build %s: %v", txType, err)
+	}
+	return tx
+}
+
+func TestPostTransaction(t *testing.T) {
+	entries := PostTransaction(makeOriginal(t, TypePurchase, StatusApproved, 1000))
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	card, merchant := entries[0], entries[1]
+	if card.Account != "card:card1" || card.Side != DirectionDebit || card.Amount.Amount != 1000 {
+		t.Errorf("unexpected card entry %+v", card)
+	}
+	if merchant.Account != "merchant:m1" || merchant.Side != DirectionCredit {
+		t.Errorf("unexpected merchant entry %+v", merchant)
+	}
+	if card.PostingID != "tx1" || card.TransactionID != "tx1" || card.UserID != "u1" {
+		t.Errorf("unexpected posting metadata %+v", card)
+	}
+
+	voucher := PostTransaction(makeOriginal(t, TypeCreditVoucher, StatusApproved, 1000))
+	if voucher[0].Side != DirectionCredit || voucher[1].Side != DirectionDebit {
+		t.Errorf("credit voucher should credit the card, got %s/%s", voucher[0].Side, voucher[1].Side)
+	}
+}
+
+func TestPostTransactionWithoutFundsMovement(t *testing.T) {
+	if entries := PostTransaction(makeOriginal(t, TypePurchase, StatusRejected, 1000)); entries != nil {
+		t.Errorf("rejected transaction should not post, got %+v", entries)
+	}
+	if entries := PostTransaction(makeOriginal(t, TypeBalanceInquiry, StatusApproved, 0)); entries != nil {
+		t.Errorf("balance inquiry should not post, got %+v", entries)
+	}
+}
+
+func TestPostAdjustment(t *testing.T) {
+	entries := PostAdjustment(makeAdjustment("adj1", TypeRefund, 300, "tx1"))
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Side != DirectionCredit || entries[0].PostingID != "adj1" || entries[0].TransactionID != "tx1" {
+		t.Errorf("refund should credit the card under its own posting, got %+v", entries[0])
+	}
+
+	rejected, _ := NewAdjustment("adj2", TypeRefund, StatusRejected, makeAmountBreakdown(300, "BRL"), makeMerchant(), makeEvent("idem-adj2"), "tx1", "u", "c", "BR", "BRL", "POS")
+	if PostAdjustment(rejected) != nil {
+		t.Error("rejected adjustment should not post")
+	}
+}
+
+func TestVerifyBalanced(t *testing.T) {
+	entries := append(PostTransaction(makeOriginal(t, TypePurchase, StatusApproved, 1000)),
+		PostAdjustment(makeAdjustment("adj1", TypeRefund, 300, "tx1"))...)
+	totals, err := VerifyBalanced(entries)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(totals) != 1 || totals[0] != (AccountBalance{Currency: "BRL", Debits: 1300, Credits: 1300}) {
+		t.Errorf("unexpected totals %+v", totals)
+	}
+
+	if _, err := VerifyBalanced(entries[:1]); !errors.Is(err, ErrLedgerUnbalanced) {
+		t.Errorf("expected ErrLedgerUnbalanced, got %v", err)
+	}
+}
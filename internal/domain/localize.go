@@ -0,0 +1,98 @@
+package domain
+
+import "fmt"
+
+// Languages domain errors can be explained in. English is the default.
+const (
+	LangEnglish    = "en"
+	LangPortuguese = "pt"
+	LangSpanish    = "es"
+)
+
+// LocalizedError is an error that can explain itself in another language.
+type LocalizedError interface {
+	error
+	// Localize returns the message in lang, falling back to English.
+	Localize(lang string) string
+}
+
+// AmountOutOfRangeError is an amount outside its type's range for its currency. It matches
+// ErrAmountOutOfRange with errors.Is.
+type AmountOutOfRangeError struct {
+	Type     TransactionType
+	Currency Currency
+	Range    AmountRange
+}
+
+func (e *AmountOutOfRangeError) Error() string { return e.Localize(LangEnglish) }
+func (e *AmountOutOfRangeError) Unwrap() error { return ErrAmountOutOfRange }
+
+func (e *AmountOutOfRangeError) Localize(lang string) string {
+	lo, hi := e.Currency.Format(e.Range.Min), e.Currency.Format(e.Range.Max)
+	switch {
+	case lang == LangPortuguese && e.Range.Max == 0:
+		return fmt.Sprintf("valor fora da faixa: o valor de %s deve ser zero", e.Type)
+	case lang == LangPortuguese:
+		return fmt.Sprintf("valor fora da faixa: o valor de %s deve estar entre %s e %s", e.Type, lo, hi)
+	case lang == LangSpanish && e.Range.Max == 0:
+		return fmt.Sprintf("monto fuera de rango: el monto de %s debe ser cero", e.Type)
+	case lang == LangSpanish:
+		return fmt.Sprintf("monto fuera de rango: el monto de %s debe estar entre %s y %s", e.Type, lo, hi)
+	case e.Range.Max == 0:
+		return fmt.Sprintf("%v: %s amount must be zero", ErrAmountOutOfRange, e.Type)
+	default:
+		return fmt.Sprintf("%v: %s amount must be between %s and %s", ErrAmountOutOfRange, e.Type, lo, hi)
+	}
+}
+
+// CurrencyError is a currency code that is not an active ISO 4217 code. It matches
+// ErrInvalidCurrency with errors.Is.
+type CurrencyError struct {
+	Code string
+}
+
+func (e *CurrencyError) Error() string { return e.Localize(LangEnglish) }
+func (e *CurrencyError) Unwrap() error { return ErrInvalidCurrency }
+
+func (e *CurrencyError) Localize(lang string) string {
+	switch lang {
+	case LangPortuguese:
+		return fmt.Sprintf("moeda inválida: %q não é um código ISO 4217", e.Code)
+	case LangSpanish:
+		return fmt.Sprintf("moneda inválida: %q no es un código ISO 4217", e.Code)
+	default:
+		return fmt.Sprintf("%v: %q is not an ISO 4217 code", ErrInvalidCurrency, e.Code)
+	}
+}
+
+// CurrencyMismatchError is a breakdown field whose currency is not the one it must match. It
+// matches ErrCurrencyMismatch with errors.Is.
+type CurrencyMismatchError struct {
+	// Field is the webhook field at fault, such as amount.settlement.currency.
+	Field    string
+	Currency string
+	Expected string
+}
+
+func (e *CurrencyMismatchError) Error() string { return e.Localize(LangEnglish) }
+func (e *CurrencyMismatchError) Unwrap() error { return ErrCurrencyMismatch }
+
+func (e *CurrencyMismatchError) Localize(lang string) string {
+	got, want := orEmpty(e.Currency), orEmpty(e.Expected)
+	switch lang {
+	case LangPortuguese:
+		return fmt.Sprintf("moeda divergente: %s é %s, esperado %s", e.Field, got, want)
+	case LangSpanish:
+		return fmt.Sprintf("moneda no coincide: %s es %s, se esperaba %s", e.Field, got, want)
+	default:
+		return fmt.Sprintf("%v: %s is %s, expected %s", ErrCurrencyMismatch, e.Field, got, want)
+	}
+}
+
+// orEmpty makes a missing currency visible in a message.
+func orEmpty(code string) string {
+	if code == "" {
+		return `""`
+	}
+	return code
+}
@@ -2,16 +2,21 @@ package domain
 
 import "fmt"
 
-// Money is an immutable value object representing an amount in cents.
+// Money is an immutable value object representing an amount in the minor unit of an ISO 4217
+// currency (centavos for BRL, whole pesos for CLP).
 type Money struct {
 	Amount   int64
 	Currency string
 }
 
+// NewMoney rejects negative amounts and currency codes that are not active ISO 4217 codes.
 func NewMoney(amount int64, currency string) (Money, error) {
 	if amount < 0 {
 		return Money{}, ErrNegativeAmount
 	}
+	if _, ok := LookupCurrency(currency); !ok {
+		return Money{}, &CurrencyError{Code: currency}
+	}
 	return Money{Amount: amount, Currency: currency}, nil
 }
 
@@ -27,6 +27,13 @@ func TestNewMoney(t *testing.T) {
 			t.Errorf("expected ErrNegativeAmount, got %v", err)
 		}
 	})
+	t.Run("unknown currency rejected", func(t *testing.T) {
+		for _, code := range []string{"", "brl", "XYZ"} {
+			if _, err := NewMoney(100, code); !errors.Is(err, ErrInvalidCurrency) {
+				t.Errorf("%q: expected ErrInvalidCurrency, got %v", code, err)
+			}
+		}
+	})
 }
 
 func TestMoneyAdd(t *testing.T) {
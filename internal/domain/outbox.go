@@ -0,0 +1,68 @@
+package domain
+
+import "time"
+
+// OutboxEventType names an event published to downstream subscribers.
+type OutboxEventType string
+
+const (
+	EventTransactionProcessed OutboxEventType = "TransactionProcessed"
+	EventAdjustmentApplied    OutboxEventType = "AdjustmentApplied"
+)
+
+// OutboxEvent announces a saved transaction or adjustment. Repositories write it in the same
+// operation as the save, so an event exists if and only if its entity was stored. Exactly one of
+// Transaction and Adjustment is set.
+type OutboxEvent struct {
+	// ID is derived from the entity, so subscribers can drop redeliveries.
+	ID          string
+	Type        OutboxEventType
+	CardID      string
+	OccurredAt  time.Time
+	Transaction *Transaction
+	Adjustment  *Adjustment
+}
+
+func TransactionProcessed(tx Transaction) OutboxEvent {
+	return OutboxEvent{
+		ID:          string(EventTransactionProcessed) + ":" + tx.ID,
+		Type:        EventTransactionProcessed,
+		CardID:      tx.CardID,
+		OccurredAt:  tx.Event.CreatedAt,
+		Transaction: &tx,
+	}
+}
+
+func AdjustmentApplied(adj Adjustment) OutboxEvent {
+	return OutboxEvent{
+		ID:         string(EventAdjustmentApplied) + ":" + adj.ID,
+		Type:       EventAdjustmentApplied,
+		CardID:     adj.CardID,
+		OccurredAt: adj.Event.CreatedAt,
+		Adjustment: &adj,
+	}
+}
+
+// OrderingKey groups events that must be delivered in write order: those of the same card.
+// Events without a card fall back to their original transaction.
+func (e OutboxEvent) OrderingKey() string {
+	switch {
+	case e.CardID != "":
+		return "card:" + e.CardID
+	case e.Adjustment != nil:
+		return "tx:" + e.Adjustment.OriginalTransactionID
+	case e.Transaction != nil:
+		return "tx:" + e.Transaction.ID
+	default:
+		return "event:" + e.ID
+	}
+}
+
+// DeadLetter is an event a subscriber did not accept within the dispatcher's retry budget.
+type DeadLetter struct {
+	Event      OutboxEvent
+	Subscriber string
+	Attempts   int
+	LastError  string
+	FailedAt   time.Time
+}
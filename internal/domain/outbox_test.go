@@ -0,0 +1,36 @@
+package domain
+
+import "testing"
+
+func TestOutboxEventsForEntities(t *testing.T) {
+	tx := makeOriginal(t, TypePurchase, StatusApproved, 1000)
+	processed := TransactionProcessed(tx)
+	if processed.ID != "TransactionProcessed:tx1" || processed.Type != EventTransactionProcessed {
+		t.Errorf("unexpected event identity %q / %s", processed.ID, processed.Type)
+	}
+	if processed.Transaction == nil || processed.Adjustment != nil || processed.CardID != "card1" {
+		t.Errorf("unexpected event body %+v", processed)
+	}
+	if !processed.OccurredAt.Equal(tx.Event.CreatedAt) {
+		t.Errorf("expected the webhook time, got %v", processed.OccurredAt)
+	}
+
+	applied := AdjustmentApplied(makeAdjustment("adj1", TypeRefund, 300, "tx1"))
+	if applied.ID != "AdjustmentApplied:adj1" || applied.Adjustment == nil || applied.Transaction != nil {
+		t.Errorf("unexpected event %+v", applied)
+	}
+}
+
+func TestOutboxEventOrderingKey(t *testing.T) {
+	tx := makeOriginal(t, TypePurchase, StatusApproved, 1000)
+	adj := makeAdjustment("adj1", TypeRefund, 300, "tx1")
+	adj.CardID = tx.CardID
+	if TransactionProcessed(tx).OrderingKey() != AdjustmentApplied(adj).OrderingKey() {
+		t.Error("events of the same card must share an ordering key")
+	}
+
+	tx.CardID, adj.CardID = "", ""
+	if got := AdjustmentApplied(adj).OrderingKey(); got != "tx:tx1" || got != TransactionProcessed(tx).OrderingKey() {
+		t.Errorf("cardless events should fall back to the original transaction, got %q", got)
+	}
+}
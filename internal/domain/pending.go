@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// PendingState is the derived lifecycle state of a parked adjustment.
+type PendingState string
+
+const (
+	// PendingParked waits for its original purchase.
+	PendingParked PendingState = "PARKED"
+	// PendingExpired outlived its TTL without the purchase arriving and needs manual review.
+	PendingExpired PendingState = "EXPIRED"
+	// PendingRejected failed validation when its purchase arrived and needs manual review.
+	PendingRejected PendingState = "REJECTED"
+)
+
+// PendingAdjustment is an adjustment that arrived before its original purchase.
+// It is applied automatically when the purchase is saved, unless it expires first.
+type PendingAdjustment struct {
+	Adjustment Adjustment
+	ParkedAt   time.Time
+	ExpiresAt  time.Time
+	// Reason records why applying the adjustment failed once the purchase arrived.
+	Reason string
+}
+
+func NewPendingAdjustment(adj Adjustment, parkedAt time.Time, ttl time.Duration) (PendingAdjustment, error) {
+	if ttl <= 0 {
+		return PendingAdjustment{}, fmt.Errorf("%w: pending adjustment ttl must be positive", ErrInvalidInput)
+	}
+	return PendingAdjustment{
+		Adjustment: adj,
+		ParkedAt:   parkedAt,
+		ExpiresAt:  parkedAt.Add(ttl),
+	}, nil
+}
+
+// State derives the lifecycle state at now. A rejection takes precedence over expiry.
+func (p PendingAdjustment) State(now time.Time) PendingState {
+	if p.Reason != "" {
+		return PendingRejected
+	}
+	if !now.Before(p.ExpiresAt) {
+		return PendingExpired
+	}
+	return PendingParked
+}
+
+// NeedsReview reports whether the adjustment can no longer be applied automatically.
+func (p PendingAdjustment) NeedsReview(now time.Time) bool {
+	return p.State(now) != PendingParked
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewPendingAdjustment(t *testing.T) {
+	parkedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	adj := makeAdjustment("adj1", TypeRefund, 500, "tx1")
+
+	t.Run("expiry derived from ttl", func(t *testing.T) {
+		p, err := NewPendingAdjustment(adj, parkedAt, time.Hour)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !p.ExpiresAt.Equal(parkedAt.Add(time.Hour)) {
+			t.Errorf("expected expiry %v, got %v", parkedAt.Add(time.Hour), p.ExpiresAt)
+		}
+	})
+	t.Run("non-positive ttl rejected", func(t *testing.T) {
+		_, err := NewPendingAdjustment(adj, parkedAt, 0)
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("expected ErrInvalidInput, got %v", err)
+		}
+	})
+}
+
+func TestPendingAdjustmentState(t *testing.T) {
+	parkedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	p, _ := NewPendingAdjustment(makeAdjustment("adj1", TypeRefund, 500, "tx1"), parkedAt, time.Hour)
+
+	cases := []struct {
+		name   string
+		reason string
+		now    time.Time
+		want   PendingState
+	}{
+		{"before expiry", "", parkedAt.Add(59 * time.Minute), PendingParked},
+		{"at expiry", "", parkedAt.Add(time.Hour), PendingExpired},
+		{"after expiry", "", parkedAt.Add(2 * time.Hour), PendingExpired},
+		{"rejected before expiry", "exceeds", parkedAt, PendingRejected},
+		{"rejected after expiry", "exceeds", parkedAt.Add(2 * time.Hour), PendingRejected},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := p
+			p.Reason = tc.reason
+			if got := p.State(tc.now); got != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, got)
+			}
+			if got := p.NeedsReview(tc.now); got != (tc.want != PendingParked) {
+				t.Errorf("NeedsReview = %v for state %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Filters of the rejected webhook list.
+const (
+	RejectionOpen     = "open"
+	RejectionResolved = "resolved"
+)
+
+// RejectedWebhook is a webhook rejected for a reason that can go away — a purchase not received
+// yet, an exhausted refund budget — kept with its raw body so support staff can see what was
+// sent and process it again once the cause is fixed. Retries of the same event fold into one
+// entry while it is open.
+type RejectedWebhook struct {
+	ID             string
+	TransactionID  string
+	IdempotencyKey string
+	// Payload is the raw request body.
+	Payload []byte
+	// StatusCode, Code and Error are the response of the latest rejection.
+	StatusCode      int
+	Code            string
+	Error           string
+	FirstRejectedAt time.Time
+	LastRejectedAt  time.Time
+	Rejections      int
+
+	// Reprocesses counts manual reprocessing runs; LastReprocessError is why the latest one failed.
+	Reprocesses        int
+	LastReprocessedAt  time.Time
+	LastReprocessError string
+	// ResolvedAt is when the webhook was finally accepted, by a reprocess or a retry.
+	ResolvedAt time.Time
+}
+
+func NewRejectedWebhook(id, transactionID, idempotencyKey string, payload []byte, statusCode int, code, errMsg string, rejectedAt time.Time) (RejectedWebhook, error) {
+	if id == "" || code == "" {
+		return RejectedWebhook{}, fmt.Errorf("%w: rejection ID and code are required", ErrInvalidInput)
+	}
+	return RejectedWebhook{
+		ID:              id,
+		TransactionID:   transactionID,
+		IdempotencyKey:  idempotencyKey,
+		Payload:         payload,
+		StatusCode:      statusCode,
+		Code:            code,
+		Error:           errMsg,
+		FirstRejectedAt: rejectedAt,
+		LastRejectedAt:  rejectedAt,
+		Rejections:      1,
+	}, nil
+}
+
+// Resolved reports whether the webhook has since been accepted.
+func (r RejectedWebhook) Resolved() bool {
+	return !r.ResolvedAt.IsZero()
+}
+
+// Repeat folds a later rejection of the same event into r: its response and payload replace
+// r's, and the count grows.
+func (r RejectedWebhook) Repeat(later RejectedWebhook) RejectedWebhook {
+	r.Payload = later.Payload
+	r.StatusCode, r.Code, r.Error = later.StatusCode, later.Code, later.Error
+	r.LastRejectedAt = later.LastRejectedAt
+	r.Rejections++
+	return r
+}
+
+// Resolve marks the entry resolved at `at`, when the webhook was accepted.
+func (r RejectedWebhook) Resolve(at time.Time) RejectedWebhook {
+	if !r.Resolved() {
+		r.ResolvedAt = at
+	}
+	return r
+}
+
+// Reprocessed records a manual reprocessing run at `at` that failed with err, or resolves the
+// entry when err is nil. A resolved entry cannot be reprocessed.
+func (r RejectedWebhook) Reprocessed(at time.Time, err error) (RejectedWebhook, error) {
+	if r.Resolved() {
+		return r, fmt.Errorf("%w: %s", ErrRejectionResolved, r.ID)
+	}
+	r.Reprocesses++
+	r.LastReprocessedAt = at
+	r.LastReprocessError = ""
+	if err != nil {
+		r.LastReprocessError = err.Error()
+		return r, nil
+	}
+	return r.Resolve(at), nil
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRejectedWebhookLifecycle(t *testing.T) {
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	rw, err := NewRejectedWebhook("rej1", "adj1", "idem1", []byte(`{}`), 404, "NOT_FOUND", "transaction not found", at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rw.Rejections != 1 || rw.Resolved() {
+		t.Fatalf("unexpected new entry %+v", rw)
+	}
+
+	rw, err = rw.Reprocessed(at.Add(time.Minute), ErrTransactionNotFound)
+	if err != nil || rw.Resolved() || rw.Reprocesses != 1 || rw.LastReprocessError != "transaction not found" {
+		t.Fatalf("expected a failed reprocess recorded, got %+v (err %v)", rw, err)
+	}
+	rw, err = rw.Reprocessed(at.Add(2*time.Minute), nil)
+	if err != nil || !rw.Resolved() || rw.Reprocesses != 2 || rw.LastReprocessError != "" || !rw.ResolvedAt.Equal(at.Add(2*time.Minute)) {
+		t.Fatalf("expected the entry resolved, got %+v (err %v)", rw, err)
+	}
+	if _, err := rw.Reprocessed(at.Add(3*time.Minute), nil); !errors.Is(err, ErrRejectionResolved) {
+		t.Errorf("expected ErrRejectionResolved, got %v", err)
+	}
+	if again := rw.Resolve(at.Add(time.Hour)); !again.ResolvedAt.Equal(rw.ResolvedAt) {
+		t.Errorf("resolving twice must keep the first time, got %v", again.ResolvedAt)
+	}
+}
+
+func TestRejectedWebhookRepeat(t *testing.T) {
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	first, _ := NewRejectedWebhook("rej1", "adj1", "idem1", []byte(`{"v":1}`), 404, "NOT_FOUND", "transaction not found", at)
+	later, _ := NewRejectedWebhook("rej2", "adj1", "idem1", []byte(`{"v":2}`), 409, "EXCEEDS_ORIGINAL_AMOUNT", "exceeded", at.Add(time.Minute))
+	got := first.Repeat(later)
+	if got.ID != "rej1" || got.Rejections != 2 || got.StatusCode != 409 || string(got.Payload) != `{"v":2}` ||
+		!got.FirstRejectedAt.Equal(at) || !got.LastRejectedAt.Equal(at.Add(time.Minute)) {
+		t.Errorf("unexpected folded entry %+v", got)
+	}
+	if _, err := NewRejectedWebhook("rej1", "adj1", "idem1", nil, 404, "", "", at); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput without a code, got %v", err)
+	}
+}
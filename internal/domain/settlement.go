@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReconciliationStatus is how a settlement row and the webhooks compare.
+type ReconciliationStatus string
+
+const (
+	ReconciledMatched             ReconciliationStatus = "MATCHED"
+	ReconciledAmountMismatch      ReconciliationStatus = "AMOUNT_MISMATCH"
+	ReconciledMissingInWebhooks   ReconciliationStatus = "MISSING_IN_WEBHOOKS"
+	ReconciledMissingInSettlement ReconciliationStatus = "MISSING_IN_SETTLEMENT"
+)
+
+// SettlementRecord is a row of the processor's settlement file. Line is its 1-based line number
+// in the file; Amount is unsigned, the direction follows from the transaction's type.
+type SettlementRecord struct {
+	Line          int
+	TransactionID string
+	Amount        Money
+}
+
+// ExpectedSettlement is what a stored transaction or adjustment should settle for.
+type ExpectedSettlement struct {
+	TransactionID string
+	Type          TransactionType
+	Amount        Money
+	OccurredAt    time.Time
+}
+
+// ExpectTransaction returns what tx settles for, and false when it moves no money: rejected,
+// or of a type without a direction such as BALANCE_INQUIRY.
+func ExpectTransaction(tx Transaction) (ExpectedSettlement, bool) {
+	if tx.Status != StatusApproved || tx.Type.Direction() == DirectionNone {
+		return ExpectedSettlement{}, false
+	}
+	return ExpectedSettlement{TransactionID: tx.ID, Type: tx.Type, Amount: tx.Amount.Settlement, OccurredAt: tx.Event.CreatedAt}, true
+}
+
+// ExpectAdjustment is ExpectTransaction for an adjustment.
+func ExpectAdjustment(adj Adjustment) (ExpectedSettlement, bool) {
+	if adj.Status != StatusApproved || adj.Type.Direction() == DirectionNone {
+		return ExpectedSettlement{}, false
+	}
+	return ExpectedSettlement{TransactionID: adj.ID, Type: adj.Type, Amount: adj.Amount.Settlement, OccurredAt: adj.Event.CreatedAt}, true
+}
+
+// ReconciliationItem is one line of a SettlementReport. Expected is zero when the webhook is
+// missing, and Settled and Line are zero when the settlement row is.
+type ReconciliationItem struct {
+	TransactionID string
+	Status        ReconciliationStatus
+	Type          TransactionType
+	Line          int
+	Expected      Money
+	Settled       Money
+}
+
+// SettlementReport compares a settlement file with the stored webhooks: rows first, in file
+// order, then the transactions the file left out, in the order given to Reconcile.
+type SettlementReport struct {
+	Records             int
+	Items               []ReconciliationItem
+	Matched             int
+	AmountMismatched    int
+	MissingInWebhooks   int
+	MissingInSettlement int
+}
+
+// Clean reports whether every row matched and nothing was left out.
+func (r SettlementReport) Clean() bool {
+	return r.AmountMismatched == 0 && r.MissingInWebhooks == 0 && r.MissingInSettlement == 0
+}
+
+// Reconcile matches records to expected by transaction ID and settlement amount. Every record is
+// matched against all of expected, but only the transactions that occurred in [from, to) are
+// reported missing from the file, since a daily file only covers its own day; a zero bound is
+// open. A transaction ID repeated in records is ErrInvalidSettlementFile.
+func Reconcile(records []SettlementRecord, expected []ExpectedSettlement, from, to time.Time) (SettlementReport, error) {
+	byID := make(map[string]ExpectedSettlement, len(expected))
+	for _, e := range expected {
+		byID[e.TransactionID] = e
+	}
+	report := SettlementReport{Records: len(records), Items: []ReconciliationItem{}}
+	seen := make(map[string]int, len(records))
+	for _, rec := range records {
+		if line, ok := seen[rec.TransactionID]; ok {
+			return SettlementReport{}, fmt.Errorf("%w: line %d: transaction %s already settled on line %d", ErrInvalidSettlementFile, rec.Line, rec.TransactionID, line)
+		}
+		seen[rec.TransactionID] = rec.Line
+		item := ReconciliationItem{TransactionID: rec.TransactionID, Line: rec.Line, Settled: rec.Amount}
+		e, ok := byID[rec.TransactionID]
+		switch {
+		case !ok:
+			item.Status = ReconciledMissingInWebhooks
+			report.MissingInWebhooks++
+		case e.Amount != rec.Amount:
+			item.Status, item.Type, item.Expected = ReconciledAmountMismatch, e.Type, e.Amount
+			report.AmountMismatched++
+		default:
+			item.Status, item.Type, item.Expected = ReconciledMatched, e.Type, e.Amount
+			report.Matched++
+		}
+		report.Items = append(report.Items, item)
+	}
+	for _, e := range expected {
+		if _, ok := seen[e.TransactionID]; ok || !inWindow(e.OccurredAt, from, to) {
+			continue
+		}
+		report.Items = append(report.Items, ReconciliationItem{TransactionID: e.TransactionID, Status: ReconciledMissingInSettlement, Type: e.Type, Expected: e.Amount})
+		report.MissingInSettlement++
+	}
+	return report, nil
+}
+
+func inWindow(at, from, to time.Time) bool {
+	return (from.IsZero() || !at.Before(from)) && (to.IsZero() || at.Before(to))
+}
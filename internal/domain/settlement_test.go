@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExpectSettlement(t *testing.T) {
+	purchase := makeApprovedPurchase("tx1", 1000)
+	purchase.Amount.Settlement = brlMoney(990)
+	e, ok := ExpectTransaction(purchase)
+	if !ok || e.TransactionID != "tx1" || e.Type != TypePurchase || e.Amount != brlMoney(990) {
+		t.Errorf("expected the settlement amount of tx1, got %+v (%v)", e, ok)
+	}
+	if e, ok := ExpectAdjustment(makeAdjustment("adj1", TypeRefund, 300, "tx1")); !ok || e.Amount != brlMoney(300) {
+		t.Errorf("expected adj1 to settle 300, got %+v (%v)", e, ok)
+	}
+
+	rejected, _ := NewPurchase("tx2", StatusRejected, makeAmountBreakdown(1000, "BRL"), makeMerchant(), makeEvent("idem-tx2"), "u", "c", "BR", "BRL", "POS")
+	inquiry, _ := NewTransaction("tx3", TypeBalanceInquiry, StatusApproved, makeAmountBreakdown(0, "BRL"), makeMerchant(), makeEvent("idem-tx3"), "u", "c", "BR", "BRL", "POS")
+	for _, tx := range []Transaction{rejected, inquiry} {
+		if _, ok := ExpectTransaction(tx); ok {
+			t.Errorf("expected %s not to settle", tx.ID)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	expected := []ExpectedSettlement{
+		{TransactionID: "tx1", Type: TypePurchase, Amount: brlMoney(1000), OccurredAt: day.Add(time.Hour)},
+		{TransactionID: "tx2", Type: TypePurchase, Amount: brlMoney(500), OccurredAt: day.Add(2 * time.Hour)},
+		{TransactionID: "adj1", Type: TypeRefund, Amount: brlMoney(200), OccurredAt: day.Add(3 * time.Hour)},
+		{TransactionID: "tx3", Type: TypePurchase, Amount: brlMoney(700), OccurredAt: day.Add(4 * time.Hour)},
+		// Outside the day: matched when present, never reported missing.
+		{TransactionID: "old", Type: TypePurchase, Amount: brlMoney(100), OccurredAt: day.Add(-time.Hour)},
+		{TransactionID: "older", Type: TypePurchase, Amount: brlMoney(100), OccurredAt: day.Add(-2 * time.Hour)},
+	}
+	usd, _ := NewMoney(500, "USD")
+	records := []SettlementRecord{
+		{Line: 2, TransactionID: "tx1", Amount: brlMoney(1000)},
+		{Line: 3, TransactionID: "tx2", Amount: usd},
+		{Line: 4, TransactionID: "ghost", Amount: brlMoney(50)},
+		{Line: 5, TransactionID: "adj1", Amount: brlMoney(250)},
+		{Line: 6, TransactionID: "old", Amount: brlMoney(100)},
+	}
+
+	report, err := Reconcile(records, expected, day, day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, item := range report.Items {
+		got = append(got, item.TransactionID+":"+string(item.Status))
+	}
+	want := []string{"tx1:MATCHED", "tx2:AMOUNT_MISMATCH", "ghost:MISSING_IN_WEBHOOKS", "adj1:AMOUNT_MISMATCH", "old:MATCHED", "tx3:MISSING_IN_SETTLEMENT"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if report.Records != 5 || report.Matched != 2 || report.AmountMismatched != 2 || report.MissingInWebhooks != 1 || report.MissingInSettlement != 1 || report.Clean() {
+		t.Errorf("unexpected counts %+v", report)
+	}
+	if adj := report.Items[3]; adj.Type != TypeRefund || adj.Line != 5 || adj.Expected != brlMoney(200) || adj.Settled != brlMoney(250) {
+		t.Errorf("unexpected mismatch item %+v", adj)
+	}
+	if missing := report.Items[5]; missing.Line != 0 || missing.Settled != (Money{}) || missing.Expected != brlMoney(700) {
+		t.Errorf("unexpected missing item %+v", missing)
+	}
+
+	open, _ := Reconcile(nil, expected, time.Time{}, time.Time{})
+	if open.MissingInSettlement != len(expected) || open.Items == nil {
+		t.Errorf("expected an open window to report every transaction, got %+v", open)
+	}
+	clean, _ := Reconcile(records[:1], expected[:1], time.Time{}, time.Time{})
+	if !clean.Clean() {
+		t.Errorf("expected a clean report, got %+v", clean)
+	}
+
+	dup := append(slices.Clone(records), SettlementRecord{Line: 7, TransactionID: "tx1", Amount: brlMoney(1000)})
+	if _, err := Reconcile(dup, expected, day, time.Time{}); !errors.Is(err, ErrInvalidSettlementFile) {
+		t.Errorf("expected ErrInvalidSettlementFile for a repeated row, got %v", err)
+	}
+}
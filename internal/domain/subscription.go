@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// Subscription is a partner endpoint registered to receive outbox events. Empty filters match
+// everything; otherwise an event must match one of the listed types and one of the statuses.
+type Subscription struct {
+	ID       string
+	URL      string
+	Types    []TransactionType
+	Statuses []TransactionStatus
+	// Secret signs every delivery with the scheme Pomelo uses for inbound webhooks.
+	Secret    string
+	CreatedAt time.Time
+}
+
+func NewSubscription(id, rawURL string, types []TransactionType, statuses []TransactionStatus, secret string, createdAt time.Time) (Subscription, error) {
+	if id == "" {
+		return Subscription{}, fmt.Errorf("%w: subscription id is required", ErrInvalidInput)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Subscription{}, fmt.Errorf("%w: subscription url must be an absolute http(s) URL", ErrInvalidInput)
+	}
+	for _, t := range types {
+		if !t.IsOriginal() && !t.IsAdjustment() {
+			return Subscription{}, fmt.Errorf("%w: %s", ErrInvalidTransactionType, t)
+		}
+	}
+	for _, s := range statuses {
+		if s != StatusApproved && s != StatusRejected {
+			return Subscription{}, fmt.Errorf("%w: unknown status %q", ErrInvalidInput, s)
+		}
+	}
+	if secret == "" {
+		return Subscription{}, fmt.Errorf("%w: subscription secret is required", ErrInvalidInput)
+	}
+	return Subscription{
+		ID:        id,
+		URL:       rawURL,
+		Types:     slices.Clone(types),
+		Statuses:  slices.Clone(statuses),
+		Secret:    secret,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// Matches reports whether e passes the subscription's filters.
+func (s Subscription) Matches(e OutboxEvent) bool {
+	var txType TransactionType
+	var status TransactionStatus
+	switch {
+	case e.Transaction != nil:
+		txType, status = e.Transaction.Type, e.Transaction.Status
+	case e.Adjustment != nil:
+		txType, status = e.Adjustment.Type, e.Adjustment.Status
+	}
+	return (len(s.Types) == 0 || slices.Contains(s.Types, txType)) &&
+		(len(s.Statuses) == 0 || slices.Contains(s.Statuses, status))
+}
+
+// Delivery is one attempt to deliver an event to a subscription.
+type Delivery struct {
+	ID             string
+	SubscriptionID string
+	Event          OutboxEvent
+	// Attempt counts the dispatcher's tries for this event and subscription; a manual redelivery
+	// is its own attempt 1.
+	Attempt int
+	Manual  bool
+	// ResponseStatus is the subscriber's HTTP status, 0 when none was received.
+	ResponseStatus int
+	Error          string
+	AttemptedAt    time.Time
+	Duration       time.Duration
+}
+
+func (d Delivery) Succeeded() bool {
+	return d.Error == ""
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewSubscription(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		url      string
+		types    []TransactionType
+		statuses []TransactionStatus
+		secret   string
+		wantErr  error
+	}{
+		{"valid without filters", "https://partner.example/hooks", nil, nil, "s3cret", nil},
+		{"valid with filters", "http://localhost:9000/events", []TransactionType{TypePurchase, TypeRefund}, []TransactionStatus{StatusApproved}, "s3cret", nil},
+		{"relative url", "/hooks", nil, nil, "s3cret", ErrInvalidInput},
+		{"unsupported scheme", "ftp://partner.example", nil, nil, "s3cret", ErrInvalidInput},
+		{"unknown type", "https://partner.example", []TransactionType{"CHARGEBACK"}, nil, "s3cret", ErrInvalidTransactionType},
+		{"unknown status", "https://partner.example", nil, []TransactionStatus{"PENDING"}, "s3cret", ErrInvalidInput},
+		{"missing secret", "https://partner.example", nil, nil, "", ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := NewSubscription("sub1", tt.url, tt.types, tt.statuses, tt.secret, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && (sub.URL != tt.url || !sub.CreatedAt.Equal(now)) {
+				t.Errorf("unexpected subscription %+v", sub)
+			}
+		})
+	}
+}
+
+func TestSubscriptionMatches(t *testing.T) {
+	approved := TransactionProcessed(makeOriginal(t, TypePurchase, StatusApproved, 1000))
+	rejected := TransactionProcessed(makeOriginal(t, TypePurchase, StatusRejected, 1000))
+	refund := AdjustmentApplied(makeAdjustment("adj1", TypeRefund, 300, "tx1"))
+
+	all := Subscription{}
+	if !all.Matches(approved) || !all.Matches(refund) {
+		t.Error("a subscription without filters should match everything")
+	}
+	refundsOnly := Subscription{Types: []TransactionType{TypeRefund}}
+	if refundsOnly.Matches(approved) || !refundsOnly.Matches(refund) {
+		t.Error("type filter should select adjustments by their own type")
+	}
+	approvedPurchases := Subscription{Types: []TransactionType{TypePurchase}, Statuses: []TransactionStatus{StatusApproved}}
+	if !approvedPurchases.Matches(approved) || approvedPurchases.Matches(rejected) || approvedPurchases.Matches(refund) {
+		t.Error("type and status filters should both apply")
+	}
+}
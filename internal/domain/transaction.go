@@ -2,24 +2,10 @@ package domain
 
 import (
 	"fmt"
+	"math"
 	"time"
 )
 
-const (
-	// MinPurchaseAmount é R$1,00 em centavos.
-	MinPurchaseAmount int64 = 100
-	// MaxPurchaseAmount é R$5.000,00 em centavos.
-	MaxPurchaseAmount int64 = 500_000
-)
-
-type TransactionType string
-
-const (
-	TypePurchase         TransactionType = "PURCHASE"
-	TypeReversalPurchase TransactionType = "REVERSAL_PURCHASE"
-	TypeRefund           TransactionType = "REFUND"
-)
-
 type TransactionStatus string
 
 const (
@@ -49,7 +35,26 @@ type Event struct {
 	IdempotencyKey string
 }
 
-// Transaction is the aggregate root for PURCHASE events.
+// minEventTime and maxEventTime bound the CreatedAt that Unix nanoseconds can hold, which is how
+// the SQL backend stores it: roughly 1677 to 2262.
+var (
+	minEventTime = time.Unix(0, math.MinInt64).UTC()
+	maxEventTime = time.Unix(0, math.MaxInt64).UTC()
+)
+
+func (e Event) validate() error {
+	if e.ID == "" || e.IdempotencyKey == "" {
+		return fmt.Errorf("%w: event id and idempotency key are required", ErrInvalidInput)
+	}
+	if e.CreatedAt.Before(minEventTime) || e.CreatedAt.After(maxEventTime) {
+		return fmt.Errorf("%w: event created_at must be between %s and %s", ErrInvalidInput,
+			minEventTime.Format(time.RFC3339), maxEventTime.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Transaction is the aggregate root for original events — PURCHASE, WITHDRAWAL, EXTRACASH,
+// BALANCE_INQUIRY, PAYMENT and CREDIT_VOUCHER.
 type Transaction struct {
 	ID                    string
 	Type                  TransactionType
@@ -63,6 +68,9 @@ type Transaction struct {
 	Country               string
 	Currency              string
 	PointOfSale           string
+	// ExchangeRate is the rate implied by a cross-border breakdown: local units per unit of the
+	// transaction currency, to ExchangeRateDecimals places. Empty when both currencies match.
+	ExchangeRate string
 }
 
 func NewPurchase(
@@ -73,27 +81,62 @@ func NewPurchase(
 	event Event,
 	userID, cardID, country, currency, pointOfSale string,
 ) (Transaction, error) {
+	return NewTransaction(id, TypePurchase, status, amount, merchant, event, userID, cardID, country, currency, pointOfSale)
+}
+
+// NewTransaction creates an original transaction of txType, enforcing that type's amount range
+// and that the local amount is in the declared currency.
+func NewTransaction(
+	id string,
+	txType TransactionType,
+	status TransactionStatus,
+	amount AmountBreakdown,
+	merchant Merchant,
+	event Event,
+	userID, cardID, country, currency, pointOfSale string,
+) (Transaction, error) {
+	return NewTransactionWithLimits(nil, id, txType, status, amount, merchant, event, userID, cardID, country, currency, pointOfSale)
+}
+
+// NewTransactionWithLimits is NewTransaction with PURCHASE amounts checked against limits.
+func NewTransactionWithLimits(
+	limits PurchaseLimits,
+	id string,
+	txType TransactionType,
+	status TransactionStatus,
+	amount AmountBreakdown,
+	merchant Merchant,
+	event Event,
+	userID, cardID, country, currency, pointOfSale string,
+) (Transaction, error) {
+	if !txType.IsOriginal() {
+		return Transaction{}, fmt.Errorf("%w: %s", ErrInvalidTransactionType, txType)
+	}
 	if id == "" {
 		return Transaction{}, fmt.Errorf("%w: transaction id is required", ErrInvalidInput)
 	}
-	if event.ID == "" || event.IdempotencyKey == "" {
-		return Transaction{}, fmt.Errorf("%w: event id and idempotency key are required", ErrInvalidInput)
+	if err := event.validate(); err != nil {
+		return Transaction{}, err
+	}
+	if err := txType.validateAmount(amount.Local, limits); err != nil {
+		return Transaction{}, err
 	}
-	if amount.Local.Amount < MinPurchaseAmount || amount.Local.Amount > MaxPurchaseAmount {
-		return Transaction{}, ErrAmountOutOfRange
+	if err := amount.validateCurrencies(currency); err != nil {
+		return Transaction{}, err
 	}
 	return Transaction{
-		ID:          id,
-		Type:        TypePurchase,
-		Status:      status,
-		Amount:      amount,
-		Merchant:    merchant,
-		Event:       event,
-		UserID:      userID,
-		CardID:      cardID,
-		Country:     country,
-		Currency:    currency,
-		PointOfSale: pointOfSale,
+		ID:           id,
+		Type:         txType,
+		Status:       status,
+		Amount:       amount,
+		Merchant:     merchant,
+		Event:        event,
+		UserID:       userID,
+		CardID:       cardID,
+		Country:      country,
+		Currency:     currency,
+		PointOfSale:  pointOfSale,
+		ExchangeRate: amount.impliedExchangeRate(),
 	}, nil
 }
 
@@ -101,6 +144,8 @@ func (t Transaction) IsApprovedPurchase() bool {
 	return t.Type == TypePurchase && t.Status == StatusApproved
 }
 
+// CanReceiveAdjustment reports whether t is an approved original transaction. Which adjustment
+// types it accepts is decided by TransactionType.Target.
 func (t Transaction) CanReceiveAdjustment() bool {
-	return t.IsApprovedPurchase()
+	return t.Type.IsOriginal() && t.Status == StatusApproved
 }
@@ -72,6 +72,20 @@ func TestNewPurchase(t *testing.T) {
 			t.Error("expected error for empty idempotency key")
 		}
 	})
+	t.Run("created_at outside the storable range rejected", func(t *testing.T) {
+		for _, at := range []time.Time{{}, time.Date(1600, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2300, 1, 1, 0, 0, 0, 0, time.UTC)} {
+			evt := Event{ID: "evt1", CreatedAt: at, IdempotencyKey: "idem1"}
+			_, err := NewPurchase(
+				"tx1", StatusApproved,
+				makeAmountBreakdown(1000, "BRL"),
+				makeMerchant(), evt,
+				"user1", "card1", "BR", "BRL", "POS",
+			)
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("%s: expected ErrInvalidInput, got %v", at.Format(time.RFC3339), err)
+			}
+		}
+	})
 }
 
 func TestNewPurchaseAmountRange(t *testing.T) {
@@ -0,0 +1,185 @@
+package domain
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+const (
+	// MinPurchaseAmount é R$1,00 em centavos.
+	MinPurchaseAmount int64 = 100
+	// MaxPurchaseAmount é R$5.000,00 em centavos.
+	MaxPurchaseAmount int64 = 500_000
+	// MinWithdrawalAmount é R$10,00 em centavos.
+	MinWithdrawalAmount int64 = 1_000
+	// MaxWithdrawalAmount é R$3.000,00 em centavos.
+	MaxWithdrawalAmount int64 = 300_000
+	// MaxExtracashAmount é R$1.000,00 em centavos.
+	MaxExtracashAmount int64 = 100_000
+)
+
+type TransactionType string
+
+const (
+	TypePurchase       TransactionType = "PURCHASE"
+	TypeWithdrawal     TransactionType = "WITHDRAWAL"
+	TypeExtracash      TransactionType = "EXTRACASH"
+	TypeBalanceInquiry TransactionType = "BALANCE_INQUIRY"
+	TypePayment        TransactionType = "PAYMENT"
+	TypeCreditVoucher  TransactionType = "CREDIT_VOUCHER"
+
+	TypeReversalPurchase       TransactionType = "REVERSAL_PURCHASE"
+	TypeRefund                 TransactionType = "REFUND"
+	TypeReversalWithdrawal     TransactionType = "REVERSAL_WITHDRAWAL"
+	TypeReversalExtracash      TransactionType = "REVERSAL_EXTRACASH"
+	TypeReversalBalanceInquiry TransactionType = "REVERSAL_BALANCE_INQUIRY"
+	TypeReversalPayment        TransactionType = "REVERSAL_PAYMENT"
+	TypeReversalCreditVoucher  TransactionType = "REVERSAL_CREDIT_VOUCHER"
+)
+
+// Direction is the effect an approved original transaction has on the cardholder's funds.
+// Its adjustments move funds the opposite way.
+type Direction string
+
+const (
+	DirectionDebit  Direction = "DEBIT"
+	DirectionCredit Direction = "CREDIT"
+	// DirectionNone is for informational transactions such as BALANCE_INQUIRY.
+	DirectionNone Direction = "NONE"
+)
+
+// originalSpec holds the rules of a type that starts a transaction (not an adjustment).
+type originalSpec struct {
+	direction Direction
+	// limits is the type's default amount range per local currency.
+	limits AmountLimits
+}
+
+var originalSpecs = map[TransactionType]originalSpec{
+	TypePurchase:       {DirectionDebit, defaultPurchaseLimits},
+	TypeWithdrawal:     {DirectionDebit, defaultWithdrawalLimits},
+	TypeExtracash:      {DirectionDebit, defaultExtracashLimits},
+	TypeBalanceInquiry: {DirectionNone, nil},
+	TypePayment:        {DirectionDebit, defaultPurchaseLimits},
+	TypeCreditVoucher:  {DirectionCredit, defaultPurchaseLimits},
+}
+
+// adjustmentTargets maps each adjustment type to the only original type it may be applied to.
+var adjustmentTargets = map[TransactionType]TransactionType{
+	TypeReversalPurchase:       TypePurchase,
+	TypeRefund:                 TypePurchase,
+	TypeReversalWithdrawal:     TypeWithdrawal,
+	TypeReversalExtracash:      TypeExtracash,
+	TypeReversalBalanceInquiry: TypeBalanceInquiry,
+	TypeReversalPayment:        TypePayment,
+	TypeReversalCreditVoucher:  TypeCreditVoucher,
+}
+
+// IsOriginal reports whether t starts a transaction, as opposed to adjusting one.
+func (t TransactionType) IsOriginal() bool {
+	_, ok := originalSpecs[t]
+	return ok
+}
+
+// IsAdjustment reports whether t reverses or refunds an original transaction.
+func (t TransactionType) IsAdjustment() bool {
+	_, ok := adjustmentTargets[t]
+	return ok
+}
+
+// Target returns the original type an adjustment of type t may be applied to.
+func (t TransactionType) Target() (TransactionType, bool) {
+	target, ok := adjustmentTargets[t]
+	return target, ok
+}
+
+// Direction returns how an approved transaction of type t moves the cardholder's funds.
+// Adjustments move them opposite to their target.
+func (t TransactionType) Direction() Direction {
+	if target, ok := adjustmentTargets[t]; ok {
+		switch originalSpecs[target].direction {
+		case DirectionDebit:
+			return DirectionCredit
+		case DirectionCredit:
+			return DirectionDebit
+		}
+		return DirectionNone
+	}
+	return originalSpecs[t].direction
+}
+
+// AmountRange bounds an amount in minor units, both ends inclusive.
+type AmountRange struct {
+	Min int64
+	Max int64
+}
+
+// AmountLimits is an amount range per local currency, in each currency's minor unit.
+type AmountLimits map[string]AmountRange
+
+// PurchaseLimits overrides the PURCHASE ranges of the currencies it lists.
+type PurchaseLimits = AmountLimits
+
+// The default ranges of the currencies Pomelo operates in. An amount in any other currency is
+// not range-checked unless PurchaseLimits configures it for a PURCHASE.
+var (
+	defaultPurchaseLimits = AmountLimits{
+		"BRL": {Min: MinPurchaseAmount, Max: MaxPurchaseAmount}, // R$1,00 – R$5.000,00
+		"MXN": {Min: 500, Max: 2_000_000},                       // MX$5.00 – MX$20,000.00
+		"COP": {Min: 100_000, Max: 400_000_000},                 // COL$1.000,00 – COL$4.000.000,00
+		"ARS": {Min: 20_000, Max: 100_000_000},                  // AR$200,00 – AR$1.000.000,00
+	}
+	defaultWithdrawalLimits = AmountLimits{
+		"BRL": {Min: MinWithdrawalAmount, Max: MaxWithdrawalAmount}, // R$10,00 – R$3.000,00
+		"MXN": {Min: 5_000, Max: 1_200_000},                         // MX$50.00 – MX$12,000.00
+		"COP": {Min: 1_000_000, Max: 240_000_000},                   // COL$10.000,00 – COL$2.400.000,00
+		"ARS": {Min: 200_000, Max: 60_000_000},                      // AR$2.000,00 – AR$600.000,00
+	}
+	defaultExtracashLimits = AmountLimits{
+		"BRL": {Min: MinPurchaseAmount, Max: MaxExtracashAmount}, // R$1,00 – R$1.000,00
+		"MXN": {Min: 500, Max: 400_000},                          // MX$5.00 – MX$4,000.00
+		"COP": {Min: 100_000, Max: 80_000_000},                   // COL$1.000,00 – COL$800.000,00
+		"ARS": {Min: 20_000, Max: 20_000_000},                    // AR$200,00 – AR$200.000,00
+	}
+)
+
+// DefaultPurchaseLimits returns a copy of the built-in PURCHASE ranges.
+func DefaultPurchaseLimits() PurchaseLimits {
+	return maps.Clone(defaultPurchaseLimits)
+}
+
+// Validate rejects unknown currencies and negative or empty ranges.
+func (l AmountLimits) Validate() error {
+	for _, code := range slices.Sorted(maps.Keys(l)) {
+		if _, ok := LookupCurrency(code); !ok {
+			return &CurrencyError{Code: code}
+		}
+		if r := l[code]; r.Min < 0 || r.Max < r.Min {
+			return fmt.Errorf("%w: purchase limits for %s: min %d and max %d do not form a range", ErrInvalidInput, code, r.Min, r.Max)
+		}
+	}
+	return nil
+}
+
+// validateAmount checks the local amount of an original transaction against its type's range for
+// the currency: for a PURCHASE the one in limits, then the default one. A currency without a range
+// is not limited. Informational types carry no amount in any currency.
+func (t TransactionType) validateAmount(amount Money, limits PurchaseLimits) error {
+	currency, ok := LookupCurrency(amount.Currency)
+	if !ok {
+		return &CurrencyError{Code: amount.Currency}
+	}
+	spec := originalSpecs[t]
+	r, ok := spec.limits[currency.Code]
+	if custom, found := limits[currency.Code]; found && t == TypePurchase {
+		r, ok = custom, true
+	}
+	if spec.direction == DirectionNone {
+		r, ok = AmountRange{}, true
+	}
+	if !ok || amount.Amount >= r.Min && amount.Amount <= r.Max {
+		return nil
+	}
+	return &AmountOutOfRangeError{Type: t, Currency: currency, Range: r}
+}
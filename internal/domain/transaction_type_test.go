@@ -0,0 +1,243 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTransactionTypeClassification(t *testing.T) {
+	originals := []TransactionType{TypePurchase, TypeWithdrawal, TypeExtracash, TypeBalanceInquiry, TypePayment, TypeCreditVoucher}
+	for _, tt := range originals {
+		if !tt.IsOriginal() || tt.IsAdjustment() {
+			t.Errorf("%s should be an original type", tt)
+		}
+	}
+
+	targets := map[TransactionType]TransactionType{
+		TypeReversalPurchase:       TypePurchase,
+		TypeRefund:                 TypePurchase,
+		TypeReversalWithdrawal:     TypeWithdrawal,
+		TypeReversalExtracash:      TypeExtracash,
+		TypeReversalBalanceInquiry: TypeBalanceInquiry,
+		TypeReversalPayment:        TypePayment,
+		TypeReversalCreditVoucher:  TypeCreditVoucher,
+	}
+	for adj, want := range targets {
+		if !adj.IsAdjustment() || adj.IsOriginal() {
+			t.Errorf("%s should be an adjustment type", adj)
+		}
+		if got, ok := adj.Target(); !ok || got != want {
+			t.Errorf("%s: expected target %s, got %s", adj, want, got)
+		}
+	}
+
+	unknown := TransactionType("CASHBACK")
+	if unknown.IsOriginal() || unknown.IsAdjustment() {
+		t.Error("unknown type should be neither original nor adjustment")
+	}
+	if _, ok := TypePurchase.Target(); ok {
+		t.Error("an original type has no target")
+	}
+}
+
+func TestTransactionTypeDirection(t *testing.T) {
+	cases := map[TransactionType]Direction{
+		TypePurchase:               DirectionDebit,
+		TypeWithdrawal:             DirectionDebit,
+		TypeCreditVoucher:          DirectionCredit,
+		TypeBalanceInquiry:         DirectionNone,
+		TypeRefund:                 DirectionCredit,
+		TypeReversalWithdrawal:     DirectionCredit,
+		TypeReversalCreditVoucher:  DirectionDebit,
+		TypeReversalBalanceInquiry: DirectionNone,
+	}
+	for tt, want := range cases {
+		if got := tt.Direction(); got != want {
+			t.Errorf("%s: expected %s, got %s", tt, want, got)
+		}
+	}
+}
+
+func TestNewTransactionAmountRanges(t *testing.T) {
+	newTx := func(txType TransactionType, amount int64) error {
+		_, err := NewTransaction("tx1", txType, StatusApproved, makeAmountBreakdown(amount, "BRL"),
+			makeMerchant(), makeEvent("idem1"), "u", "c", "BR", "BRL", "ATM")
+		return err
+	}
+
+	cases := []struct {
+		txType TransactionType
+		amount int64
+		ok     bool
+	}{
+		{TypeWithdrawal, MinWithdrawalAmount, true},
+		{TypeWithdrawal, MinWithdrawalAmount - 1, false},
+		{TypeWithdrawal, MaxWithdrawalAmount, true},
+		{TypeWithdrawal, MaxWithdrawalAmount + 1, false},
+		{TypeExtracash, MaxExtracashAmount, true},
+		{TypeExtracash, MaxExtracashAmount + 1, false},
+		{TypeBalanceInquiry, 0, true},
+		{TypeBalanceInquiry, 1, false},
+		{TypePayment, MaxPurchaseAmount, true},
+		{TypeCreditVoucher, MinPurchaseAmount - 1, false},
+	}
+	for _, tc := range cases {
+		err := newTx(tc.txType, tc.amount)
+		if tc.ok && err != nil {
+			t.Errorf("%s %d: unexpected error: %v", tc.txType, tc.amount, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrAmountOutOfRange) {
+			t.Errorf("%s %d: expected ErrAmountOutOfRange, got %v", tc.txType, tc.amount, err)
+		}
+	}
+
+	err := newTx(TypeWithdrawal, 1)
+	if err == nil || !strings.Contains(err.Error(), "between R$10,00 and R$3.000,00") {
+		t.Errorf("expected the withdrawal range in the message, got %v", err)
+	}
+}
+
+func TestNewTransactionAmountRangesPerCurrency(t *testing.T) {
+	newTx := func(txType TransactionType, amount int64, currency string) error {
+		_, err := NewTransaction("tx1", txType, StatusApproved, makeAmountBreakdown(amount, currency),
+			makeMerchant(), makeEvent("idem1"), "u", "c", "MX", currency, "ATM")
+		return err
+	}
+	cases := []struct {
+		txType   TransactionType
+		amount   int64
+		currency string
+		ok       bool
+	}{
+		{TypeWithdrawal, 4_999, "MXN", false},
+		{TypeWithdrawal, 1_200_000, "MXN", true},
+		{TypeWithdrawal, 240_000_001, "COP", false},
+		{TypeExtracash, 20_000_000, "ARS", true},
+		{TypeExtracash, 20_000_001, "ARS", false},
+		{TypePayment, 2_000_000, "MXN", true},
+		{TypeCreditVoucher, 99_999, "COP", false},
+		// Currencies outside the tables are not limited; a balance inquiry carries no amount in any.
+		{TypeWithdrawal, 1, "USD", true},
+		{TypeExtracash, 10_000_000, "CLP", true},
+		{TypeBalanceInquiry, 1, "USD", false},
+	}
+	for _, tc := range cases {
+		err := newTx(tc.txType, tc.amount, tc.currency)
+		if tc.ok && err != nil {
+			t.Errorf("%s %s %d: unexpected error: %v", tc.txType, tc.currency, tc.amount, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrAmountOutOfRange) {
+			t.Errorf("%s %s %d: expected ErrAmountOutOfRange, got %v", tc.txType, tc.currency, tc.amount, err)
+		}
+	}
+
+	err := newTx(TypeWithdrawal, 1, "MXN")
+	if err == nil || !strings.Contains(err.Error(), "between MX$50.00 and MX$12,000.00") {
+		t.Errorf("expected the MXN withdrawal range in the message, got %v", err)
+	}
+}
+
+func TestNewTransactionRejectsAdjustmentType(t *testing.T) {
+	_, err := NewTransaction("tx1", TypeReversalWithdrawal, StatusApproved, makeAmountBreakdown(1000, "BRL"),
+		makeMerchant(), makeEvent("idem1"), "u", "c", "BR", "BRL", "ATM")
+	if !errors.Is(err, ErrInvalidTransactionType) {
+		t.Errorf("expected ErrInvalidTransactionType, got %v", err)
+	}
+}
+
+func TestPurchaseLimits(t *testing.T) {
+	limits := PurchaseLimits{"USD": {Min: 50, Max: 100_000}, "BRL": {Min: 100, Max: 1_000_000}}
+	newPurchase := func(amount int64, currency string) error {
+		_, err := NewTransactionWithLimits(limits, "tx1", TypePurchase, StatusApproved, makeAmountBreakdown(amount, currency),
+			makeMerchant(), makeEvent("idem1"), "u", "c", "US", currency, "ONLINE")
+		return err
+	}
+	if err := newPurchase(MaxPurchaseAmount+1, "BRL"); err != nil {
+		t.Errorf("expected the BRL override to raise the maximum, got %v", err)
+	}
+	if err := newPurchase(50, "USD"); err != nil {
+		t.Errorf("expected the USD minimum to be 50, got %v", err)
+	}
+	err := newPurchase(100_001, "USD")
+	if !errors.Is(err, ErrAmountOutOfRange) || !strings.Contains(err.Error(), "between USD 0.50 and USD 1,000.00") {
+		t.Errorf("expected the USD range in the error, got %v", err)
+	}
+	if err := newPurchase(1, "EUR"); err != nil {
+		t.Errorf("expected a currency without limits not to be limited, got %v", err)
+	}
+	// Only PURCHASE is overridden.
+	_, err = NewTransactionWithLimits(limits, "tx2", TypePayment, StatusApproved, makeAmountBreakdown(MaxPurchaseAmount+1, "BRL"),
+		makeMerchant(), makeEvent("idem2"), "u", "c", "BR", "BRL", "ONLINE")
+	if !errors.Is(err, ErrAmountOutOfRange) {
+		t.Errorf("expected PAYMENT to keep the default range, got %v", err)
+	}
+
+	if err := (PurchaseLimits{"BRL": {Min: 500, Max: 100}}).Validate(); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected an inverted range to be rejected, got %v", err)
+	}
+	if err := (PurchaseLimits{"BRX": {Min: 1, Max: 100}}).Validate(); !errors.Is(err, ErrInvalidCurrency) {
+		t.Errorf("expected an unknown currency to be rejected, got %v", err)
+	}
+}
+
+func TestDefaultPurchaseLimitsPerCurrency(t *testing.T) {
+	newPurchase := func(amount int64, currency string) error {
+		_, err := NewPurchase("tx1", StatusApproved, makeAmountBreakdown(amount, currency),
+			makeMerchant(), makeEvent("idem1"), "u", "c", "MX", currency, "POS")
+		return err
+	}
+	cases := []struct {
+		currency string
+		amount   int64
+		ok       bool
+	}{
+		{"BRL", MinPurchaseAmount, true},
+		{"BRL", MaxPurchaseAmount + 1, false},
+		{"MXN", 499, false},
+		{"MXN", 2_000_000, true},
+		{"COP", 99_999, false},
+		{"COP", 400_000_000, true},
+		{"ARS", 20_000, true},
+		{"ARS", 100_000_001, false},
+		// Currencies outside the table are not limited.
+		{"USD", 1, true},
+		{"CLP", 1_000_000_000, true},
+		{"KWD", 1, true},
+	}
+	for _, tc := range cases {
+		err := newPurchase(tc.amount, tc.currency)
+		if tc.ok && err != nil {
+			t.Errorf("%s %d: unexpected error %v", tc.currency, tc.amount, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrAmountOutOfRange) {
+			t.Errorf("%s %d: expected ErrAmountOutOfRange, got %v", tc.currency, tc.amount, err)
+		}
+	}
+	if err := newPurchase(1_000, "XYZ"); !errors.Is(err, ErrInvalidCurrency) {
+		t.Errorf("expected ErrInvalidCurrency, got %v", err)
+	}
+}
+
+func TestAmountOutOfRangeErrorLocalized(t *testing.T) {
+	_, err := NewPurchase("tx1", StatusApproved, makeAmountBreakdown(100, "MXN"),
+		makeMerchant(), makeEvent("idem1"), "u", "c", "MX", "MXN", "POS")
+	var rangeErr *AmountOutOfRangeError
+	if !errors.As(err, &rangeErr) {
+		t.Fatalf("expected *AmountOutOfRangeError, got %v", err)
+	}
+	want := map[string]string{
+		LangEnglish:    "amount out of range: PURCHASE amount must be between MX$5.00 and MX$20,000.00",
+		LangSpanish:    "monto fuera de rango: el monto de PURCHASE debe estar entre MX$5.00 y MX$20,000.00",
+		LangPortuguese: "valor fora da faixa: o valor de PURCHASE deve estar entre MX$5.00 e MX$20,000.00",
+		"de":           "amount out of range: PURCHASE amount must be between MX$5.00 and MX$20,000.00",
+	}
+	for lang, msg := range want {
+		if got := rangeErr.Localize(lang); got != msg {
+			t.Errorf("%s: got %q, want %q", lang, got, msg)
+		}
+	}
+	if err.Error() != want[LangEnglish] {
+		t.Errorf("Error() should be the English message, got %q", err.Error())
+	}
+}
@@ -0,0 +1,161 @@
+// Package health runs the checks behind the liveness and readiness endpoints. Storage adapters
+// and background workers are registered as Checkers; a Report lists every check with its status
+// and latency.
+//
+// Registering two checks with the same name is a programming error and panics.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds each check; a check still running after it fails.
+const DefaultTimeout = 2 * time.Second
+
+// Report statuses. A check is StatusOK or StatusFail; a report is StatusFail when any check is.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Checker reports whether a dependency is usable. Storage adapters and workers implement it.
+type Checker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) CheckHealth(ctx context.Context) error { return f(ctx) }
+
+// Report is the JSON body of /health/live and /health/ready.
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Healthy reports whether every check passed.
+func (r Report) Healthy() bool { return r.Status == StatusOK }
+
+// Result is the outcome of one check.
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type check struct {
+	name     string
+	checker  Checker
+	liveness bool
+}
+
+// Registry holds the registered checks. It is safe for concurrent use.
+type Registry struct {
+	timeout time.Duration
+	mu      sync.Mutex
+	checks  []check
+}
+
+// Option configures a Registry.
+type Option func(*Registry)
+
+// WithTimeout overrides DefaultTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(r *Registry) { r.timeout = d }
+}
+
+func NewRegistry(opts ...Option) *Registry {
+	r := &Registry{timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Register adds a readiness check: while it fails the instance should get no traffic, but a
+// restart would not help (a database that is down).
+func (r *Registry) Register(name string, c Checker) {
+	r.add(check{name: name, checker: c})
+}
+
+// RegisterLiveness adds a check that fails only when restarting the process is the fix (a
+// worker loop that stopped). Liveness checks are part of readiness too.
+func (r *Registry) RegisterLiveness(name string, c Checker) {
+	r.add(check{name: name, checker: c, liveness: true})
+}
+
+func (r *Registry) add(c check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.checks {
+		if existing.name == c.name {
+			panic("health: duplicate check " + c.name)
+		}
+	}
+	r.checks = append(r.checks, c)
+}
+
+// Live runs the liveness checks.
+func (r *Registry) Live(ctx context.Context) Report {
+	return r.run(ctx, true)
+}
+
+// Ready runs every check.
+func (r *Registry) Ready(ctx context.Context) Report {
+	return r.run(ctx, false)
+}
+
+// run executes the selected checks concurrently, in registration order in the report. A nil
+// Registry has no checks and is always healthy.
+func (r *Registry) run(ctx context.Context, livenessOnly bool) Report {
+	report := Report{Status: StatusOK, Checks: []Result{}}
+	if r == nil {
+		return report
+	}
+	r.mu.Lock()
+	var checks []check
+	for _, c := range r.checks {
+		if c.liveness || !livenessOnly {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.Unlock()
+
+	report.Checks = make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Go(func() { report.Checks[i] = r.runOne(ctx, c) })
+	}
+	wg.Wait()
+	for _, res := range report.Checks {
+		if res.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// runOne stops waiting after the timeout even if the checker ignores its context.
+func (r *Registry) runOne(ctx context.Context, c check) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- c.checker.CheckHealth(ctx) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", r.timeout)
+	}
+	res := Result{Name: c.name, Status: StatusOK, LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		res.Status, res.Error = StatusFail, err.Error()
+	}
+	return res
+}
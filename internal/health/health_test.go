@@ -0,0 +1,62 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func ok(context.Context) error { return nil }
+
+func TestRegistryReports(t *testing.T) {
+	r := NewRegistry()
+	r.Register("storage", CheckerFunc(func(context.Context) error { return errors.New("connection refused") }))
+	r.RegisterLiveness("dispatcher", CheckerFunc(ok))
+
+	live := r.Live(context.Background())
+	if !live.Healthy() || len(live.Checks) != 1 || live.Checks[0].Name != "dispatcher" {
+		t.Errorf("liveness should run only the liveness checks, got %+v", live)
+	}
+
+	ready := r.Ready(context.Background())
+	if ready.Healthy() || len(ready.Checks) != 2 {
+		t.Fatalf("readiness should fail with both checks, got %+v", ready)
+	}
+	if c := ready.Checks[0]; c.Name != "storage" || c.Status != StatusFail || c.Error != "connection refused" {
+		t.Errorf("unexpected storage result %+v", c)
+	}
+	if c := ready.Checks[1]; c.Name != "dispatcher" || c.Status != StatusOK || c.Error != "" {
+		t.Errorf("unexpected dispatcher result %+v", c)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	r := NewRegistry(WithTimeout(10 * time.Millisecond))
+	block := make(chan struct{})
+	defer close(block)
+	r.Register("stuck", CheckerFunc(func(context.Context) error { <-block; return nil }))
+
+	rep := r.Ready(context.Background())
+	if rep.Healthy() || rep.Checks[0].Error != "timed out after 10ms" {
+		t.Errorf("a check ignoring its context should time out, got %+v", rep)
+	}
+}
+
+func TestNilRegistryIsHealthy(t *testing.T) {
+	var r *Registry
+	if rep := r.Ready(context.Background()); !rep.Healthy() || rep.Checks == nil {
+		t.Errorf("expected an empty healthy report, got %+v", rep)
+	}
+}
+
+func TestDuplicateCheckPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	r := NewRegistry()
+	r.Register("storage", CheckerFunc(ok))
+	r.RegisterLiveness("storage", CheckerFunc(ok))
+}
@@ -0,0 +1,80 @@
+// Package keylock serializes work per key while letting different keys run in parallel.
+package keylock
+
+import (
+	"context"
+	"sync"
+)
+
+// Locks hands out one lock per key. Entries are dropped once nobody holds or waits for them,
+// so the map only holds keys in use. The zero value is ready to use.
+type Locks struct {
+	mu    sync.Mutex
+	locks map[string]*lock
+}
+
+// lock is a one-slot semaphore, so a waiter can give up when its context ends.
+type lock struct {
+	slot chan struct{}
+	refs int
+}
+
+// Lock blocks until key is free and returns the function that releases it.
+func (k *Locks) Lock(key string) (unlock func()) {
+	unlock, _ = k.LockContext(context.Background(), key)
+	return unlock
+}
+
+// LockContext waits for key while ctx is live and returns the function that releases it.
+func (k *Locks) LockContext(ctx context.Context, key string) (unlock func(), err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	l := k.acquire(key)
+	select {
+	case l.slot <- struct{}{}:
+	case <-ctx.Done():
+		k.release(key, l)
+		return nil, ctx.Err()
+	}
+	// select picks at random when both are ready; a deadline that passed while waiting wins.
+	if err := ctx.Err(); err != nil {
+		<-l.slot
+		k.release(key, l)
+		return nil, err
+	}
+	return func() {
+		<-l.slot
+		k.release(key, l)
+	}, nil
+}
+
+func (k *Locks) acquire(key string) *lock {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.locks == nil {
+		k.locks = make(map[string]*lock)
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = &lock{slot: make(chan struct{}, 1)}
+		k.locks[key] = l
+	}
+	l.refs++
+	return l
+}
+
+func (k *Locks) release(key string, l *lock) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if l.refs--; l.refs == 0 {
+		delete(k.locks, key)
+	}
+}
+
+// Len reports how many keys are held or waited for.
+func (k *Locks) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.locks)
+}
@@ -0,0 +1,60 @@
+package keylock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockSerializesAKeyAndDropsIdleEntries(t *testing.T) {
+	var locks Locks
+	unlock := locks.Lock("a")
+
+	other := locks.Lock("b")
+	other()
+
+	acquired := make(chan struct{})
+	go func() {
+		locks.Lock("a")()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("second Lock on a held key should block")
+	case <-time.After(20 * time.Millisecond):
+	}
+	unlock()
+	<-acquired
+
+	if n := locks.Len(); n != 0 {
+		t.Errorf("Len() = %d after every lock was released, want 0", n)
+	}
+}
+
+func TestLockContextGivesUpWhenTheContextEnds(t *testing.T) {
+	var locks Locks
+	unlock := locks.Lock("a")
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := locks.LockContext(ctx, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("LockContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if n := locks.Len(); n != 1 {
+		t.Errorf("Len() = %d, want 1: the waiter that gave up must drop its reference", n)
+	}
+}
+
+func TestLockContextRejectsAnEndedContext(t *testing.T) {
+	var locks Locks
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := locks.LockContext(ctx, "a"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("LockContext() error = %v, want %v", err, context.Canceled)
+	}
+	if n := locks.Len(); n != 0 {
+		t.Errorf("Len() = %d, want 0", n)
+	}
+}
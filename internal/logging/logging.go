@@ -0,0 +1,31 @@
+// Package logging carries a request-scoped slog.Logger through a context.Context, so code below
+// the HTTP adapter logs with the same correlation attributes as the request that called it.
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+type loggerKey struct{}
+
+var discard = slog.New(slog.DiscardHandler)
+
+// NewContext returns ctx carrying log.
+func NewContext(ctx context.Context, log *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, log)
+}
+
+// FromContext returns the logger in ctx. Outside a logged request — background jobs, tests — it
+// returns a logger that discards everything.
+func FromContext(ctx context.Context) *slog.Logger {
+	if log, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return log
+	}
+	return discard
+}
+
+// With returns ctx carrying the logger in ctx extended with args.
+func With(ctx context.Context, args ...any) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(args...))
+}
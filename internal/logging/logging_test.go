@@ -0,0 +1,23 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := NewContext(context.Background(), slog.New(slog.NewTextHandler(&buf, nil)))
+	ctx = With(ctx, "request_id", "req-1")
+	FromContext(ctx).Info("idempotent hit")
+	if got := buf.String(); !strings.Contains(got, "msg=\"idempotent hit\" request_id=req-1") {
+		t.Errorf("unexpected log line %q", got)
+	}
+
+	// Without a logger nothing is written and nothing panics.
+	FromContext(context.Background()).Info("dropped")
+	With(context.Background(), "k", "v")
+}
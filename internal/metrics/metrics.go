@@ -0,0 +1,303 @@
+// Package metrics implements the Prometheus instruments Pomelo exposes — counters, histograms
+// and gauges computed at scrape time — and the text exposition format, without third-party
+// dependencies.
+//
+// Registering two instruments with the same name, or recording with the wrong number of label
+// values, is a programming error and panics.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets are latency buckets in seconds, from 5ms to 10s.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry holds the instruments served on /metrics. It implements http.Handler.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+type collector interface {
+	desc() *desc
+	write(w *bufio.Writer)
+}
+
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.collectors {
+		if existing.desc().name == c.desc().name {
+			panic("metrics: duplicate metric " + c.desc().name)
+		}
+	}
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteTo writes every instrument in the text exposition format, sorted by name.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.Unlock()
+	slices.SortFunc(collectors, func(a, b collector) int { return strings.Compare(a.desc().name, b.desc().name) })
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, c := range collectors {
+		d := c.desc()
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", d.name, helpEscaper.Replace(d.help), d.name, d.kind)
+		c.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	r.WriteTo(w)
+}
+
+// CounterVec is a monotonically increasing value per label combination.
+type CounterVec struct {
+	d      desc
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values []string
+	value  float64
+}
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{d: desc{name: name, help: help, kind: "counter", labels: labels}, series: make(map[string]*counterSeries)}
+	r.register(c)
+	return c
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add panics if v is negative: counters only go up.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic("metrics: counter " + c.d.name + " decreased")
+	}
+	key := c.d.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &counterSeries{values: slices.Clone(labelValues)}
+		c.series[key] = s
+	}
+	s.value += v
+}
+
+// Value returns the current value for the label values, 0 if never recorded.
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	key := c.d.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.series[key]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (c *CounterVec) desc() *desc { return &c.d }
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		writeSample(w, c.d.name, c.d.labels, s.values, "", "", s.value)
+	}
+}
+
+// HistogramVec counts observations into cumulative buckets per label combination.
+type HistogramVec struct {
+	d       desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+	// counts holds the non-cumulative count per bucket; the last slot is +Inf.
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec uses buckets as upper bounds, which must be sorted; +Inf is implied.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if !slices.IsSorted(buckets) {
+		panic("metrics: unsorted buckets for " + name)
+	}
+	h := &HistogramVec{
+		d:       desc{name: name, help: help, kind: "histogram", labels: labels},
+		buckets: slices.Clone(buckets),
+		series:  make(map[string]*histogramSeries),
+	}
+	r.register(h)
+	return h
+}
+
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := h.d.key(labelValues)
+	i, _ := slices.BinarySearch(h.buckets, v)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{values: slices.Clone(labelValues), counts: make([]uint64, len(h.buckets)+1)}
+		h.series[key] = s
+	}
+	s.counts[i]++
+	s.sum += v
+	s.count++
+}
+
+// Count returns how many observations were recorded for the label values.
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	key := h.d.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s, ok := h.series[key]; ok {
+		return s.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) desc() *desc { return &h.d }
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.d.name+"_bucket", h.d.labels, s.values, "le", formatFloat(upper), float64(cumulative))
+		}
+		writeSample(w, h.d.name+"_bucket", h.d.labels, s.values, "le", "+Inf", float64(s.count))
+		writeSample(w, h.d.name+"_sum", h.d.labels, s.values, "", "", s.sum)
+		writeSample(w, h.d.name+"_count", h.d.labels, s.values, "", "", float64(s.count))
+	}
+}
+
+// GaugeFunc reports values computed at scrape time.
+type GaugeFunc struct {
+	d       desc
+	collect func(set func(value float64, labelValues ...string))
+}
+
+// NewGaugeFunc calls collect on every scrape; collect reports one value per label combination
+// through set.
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func(set func(value float64, labelValues ...string))) {
+	r.register(&GaugeFunc{d: desc{name: name, help: help, kind: "gauge", labels: labels}, collect: collect})
+}
+
+func (g *GaugeFunc) desc() *desc { return &g.d }
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	samples := make(map[string]*counterSeries)
+	g.collect(func(value float64, labelValues ...string) {
+		samples[g.d.key(labelValues)] = &counterSeries{values: slices.Clone(labelValues), value: value}
+	})
+	for _, key := range sortedKeys(samples) {
+		s := samples[key]
+		writeSample(w, g.d.name, g.d.labels, s.values, "", "", s.value)
+	}
+}
+
+func (d *desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+// writeSample writes one line; extraName/extraValue add the histogram's le label.
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraName, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, l, labelEscaper.Replace(values[i]))
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func scrape(t *testing.T, r *Registry) string {
+	t.Helper()
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return b.String()
+}
+
+func TestCounterVec(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("requests_total", "Requests handled.", "type", "code")
+	c.Inc("PURCHASE", "OK")
+	c.Inc("PURCHASE", "OK")
+	c.Add(3, "REFUND", `EXCEEDS"ORIGINAL`)
+
+	want := `# HELP requests_total Requests handled.
+# TYPE requests_total counter
+requests_total{type="PURCHASE",code="OK"} 2
+requests_total{type="REFUND",code="EXCEEDS\"ORIGINAL"} 3
+`
+	if got := scrape(t, r); got != want {
+		t.Errorf("unexpected exposition:\n%s", got)
+	}
+	if c.Value("PURCHASE", "OK") != 2 || c.Value("PURCHASE", "NONE") != 0 {
+		t.Errorf("unexpected values")
+	}
+}
+
+func TestHistogramVec(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("duration_seconds", "Latency.", []float64{0.1, 1}, "op")
+	h.Observe(0.05, "save")
+	h.Observe(0.1, "save")
+	h.Observe(5, "save")
+
+	want := `# HELP duration_seconds Latency.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{op="save",le="0.1"} 2
+duration_seconds_bucket{op="save",le="1"} 2
+duration_seconds_bucket{op="save",le="+Inf"} 3
+duration_seconds_sum{op="save"} 5.15
+duration_seconds_count{op="save"} 3
+`
+	if got := scrape(t, r); got != want {
+		t.Errorf("unexpected exposition:\n%s", got)
+	}
+	if h.Count("save") != 3 {
+		t.Errorf("expected 3 observations, got %d", h.Count("save"))
+	}
+}
+
+func TestGaugeFuncIsCollectedOnScrape(t *testing.T) {
+	r := NewRegistry()
+	size := 1
+	r.NewGaugeFunc("store_size", "Entries held.", []string{"store"}, func(set func(float64, ...string)) {
+		set(float64(size), "transactions")
+		set(0, "outbox")
+	})
+	size = 7
+	want := `# HELP store_size Entries held.
+# TYPE store_size gauge
+store_size{store="outbox"} 0
+store_size{store="transactions"} 7
+`
+	if got := scrape(t, r); got != want {
+		t.Errorf("unexpected exposition:\n%s", got)
+	}
+}
+
+func TestRegistryServesSortedByName(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("b_total", "B.").Inc()
+	r.NewCounterVec("a_total", "A.").Inc()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Header().Get("Content-Type") != ContentType {
+		t.Errorf("unexpected content type %q", w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	if strings.Index(body, "a_total 1") > strings.Index(body, "b_total 1") || !strings.Contains(body, "a_total 1\n") {
+		t.Errorf("unexpected exposition:\n%s", body)
+	}
+}
+
+func TestMisuseInstrumentPanics(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("x_total", "X.", "a")
+	for name, f := range map[string]func(){
+		"duplicate name":    func() { r.NewCounterVec("x_total", "X.") },
+		"wrong label count": func() { c.Inc("1", "2") },
+		"negative add":      func() { c.Add(-1, "1") },
+		"unsorted buckets":  func() { r.NewHistogramVec("y", "Y.", []float64{2, 1}) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
@@ -0,0 +1,55 @@
+// Package signature implements the HMAC-SHA256 scheme Pomelo uses to sign webhooks.
+//
+// The signed message is timestamp + endpoint + raw body, and the X-Signature header
+// carries "hmac-sha256 <base64 digest>". The same helpers sign outgoing requests
+// (simulator) and verify incoming ones (HTTP adapter).
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+const (
+	HeaderSignature = "X-Signature"
+	HeaderTimestamp = "X-Timestamp"
+	HeaderEndpoint  = "X-Endpoint"
+
+	scheme = "hmac-sha256 "
+)
+
+// Sign returns the X-Signature header value for the given request parts.
+func Sign(secret []byte, timestamp, endpoint string, body []byte) string {
+	return scheme + base64.StdEncoding.EncodeToString(digest(secret, timestamp, endpoint, body))
+}
+
+// Verify reports whether header is a valid signature under any of the secrets.
+// Accepting several secrets lets an old and a new key be active during rotation.
+func Verify(secrets [][]byte, timestamp, endpoint string, body []byte, header string) bool {
+	encoded, ok := strings.CutPrefix(header, scheme)
+	if !ok {
+		return false
+	}
+	got, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+	valid := false
+	for _, secret := range secrets {
+		// Check every secret so timing does not reveal which key matched.
+		if hmac.Equal(got, digest(secret, timestamp, endpoint, body)) {
+			valid = true
+		}
+	}
+	return valid
+}
+
+func digest(secret []byte, timestamp, endpoint string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte(endpoint))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
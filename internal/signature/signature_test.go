@@ -0,0 +1,47 @@
+package signature
+
+import "testing"
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"id":"tx1"}`)
+	sig := Sign([]byte("secret-a"), "1717236000", "/webhook/transactions", body)
+
+	t.Run("valid signature", func(t *testing.T) {
+		if !Verify([][]byte{[]byte("secret-a")}, "1717236000", "/webhook/transactions", body, sig) {
+			t.Error("expected signature to verify")
+		}
+	})
+	t.Run("rotated secrets accept old key", func(t *testing.T) {
+		secrets := [][]byte{[]byte("secret-b"), []byte("secret-a")}
+		if !Verify(secrets, "1717236000", "/webhook/transactions", body, sig) {
+			t.Error("expected signature to verify against second secret")
+		}
+	})
+	t.Run("wrong secret", func(t *testing.T) {
+		if Verify([][]byte{[]byte("secret-b")}, "1717236000", "/webhook/transactions", body, sig) {
+			t.Error("expected verification to fail")
+		}
+	})
+	t.Run("tampered body", func(t *testing.T) {
+		if Verify([][]byte{[]byte("secret-a")}, "1717236000", "/webhook/transactions", []byte(`{"id":"tx2"}`), sig) {
+			t.Error("expected verification to fail")
+		}
+	})
+	t.Run("different timestamp", func(t *testing.T) {
+		if Verify([][]byte{[]byte("secret-a")}, "1717236001", "/webhook/transactions", body, sig) {
+			t.Error("expected verification to fail")
+		}
+	})
+	t.Run("different endpoint", func(t *testing.T) {
+		if Verify([][]byte{[]byte("secret-a")}, "1717236000", "/other", body, sig) {
+			t.Error("expected verification to fail")
+		}
+	})
+	t.Run("malformed header", func(t *testing.T) {
+		for _, h := range []string{"", "sha1 abc", "hmac-sha256 not-base64!"} {
+			if Verify([][]byte{[]byte("secret-a")}, "1717236000", "/webhook/transactions", body, h) {
+				t.Errorf("expected %q to fail", h)
+			}
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultExportTimeout bounds one export when NewOTLPExporter is given no client.
+const DefaultExportTimeout = 10 * time.Second
+
+// OTLPExporter implements Exporter with OTLP/HTTP and the JSON encoding, as accepted by the
+// OpenTelemetry Collector on :4318/v1/traces.
+type OTLPExporter struct {
+	url     string
+	service string
+	client  *http.Client
+}
+
+// NewOTLPExporter posts to url, the full traces endpoint, tagging every span with the
+// service.name resource attribute.
+func NewOTLPExporter(url, service string, client *http.Client) *OTLPExporter {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultExportTimeout}
+	}
+	return &OTLPExporter{url: url, service: service, client: client}
+}
+
+func (e *OTLPExporter) Export(ctx context.Context, spans []SpanData) error {
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return fmt.Errorf("encode spans: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector responded %s", resp.Status)
+	}
+	return nil
+}
+
+// The types below mirror the JSON mapping of the OTLP ExportTraceServiceRequest. IDs are hex and
+// 64-bit integers are strings, as the protobuf JSON mapping requires.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string `json:"stringValue,omitempty"`
+		IntValue    *string `json:"intValue,omitempty"`
+		BoolValue   *bool   `json:"boolValue,omitempty"`
+	}
+)
+
+// Status codes of the OTLP Status message.
+const (
+	statusUnset = 0
+	statusError = 2
+)
+
+// instrumentationScope names the library that produced the spans.
+const instrumentationScope = "github.com/jailtonjunior/pomelo"
+
+func (e *OTLPExporter) request(spans []SpanData) otlpRequest {
+	out := make([]otlpSpan, len(spans))
+	for i, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.SpanContext.TraceID.String(),
+			SpanID:            s.SpanContext.SpanID.String(),
+			Name:              s.Name,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        otlpAttributes(s.Attributes),
+			Status:            otlpStatus{Code: statusUnset},
+		}
+		if s.Parent.IsValid() {
+			span.ParentSpanID = s.Parent.String()
+		}
+		if s.Error != "" {
+			span.Status = otlpStatus{Code: statusError, Message: s.Error}
+		}
+		out[i] = span
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: otlpAttributes([]Attribute{String("service.name", e.service)})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: instrumentationScope}, Spans: out}},
+	}}}
+}
+
+func otlpAttributes(attrs []Attribute) []otlpKeyValue {
+	out := make([]otlpKeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		kv := otlpKeyValue{Key: a.Key}
+		switch v := a.Value.(type) {
+		case string:
+			kv.Value.StringValue = &v
+		case int64:
+			s := strconv.FormatInt(v, 10)
+			kv.Value.IntValue = &s
+		case bool:
+			kv.Value.BoolValue = &v
+		default:
+			s := fmt.Sprint(v)
+			kv.Value.StringValue = &s
+		}
+		out = append(out, kv)
+	}
+	return out
+}
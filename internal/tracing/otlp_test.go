@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestOTLPExporterAgainstCollectorStandIn exports to a local HTTP server that decodes the
+// OTLP/HTTP JSON request the way a collector would.
+func TestOTLPExporterAgainstCollectorStandIn(t *testing.T) {
+	var got otlpRequest
+	var contentType string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			http.NotFound(w, r)
+			return
+		}
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+	}))
+	defer collector.Close()
+
+	start := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	clock := start
+	tracer := NewTracer(NewOTLPExporter(collector.URL+"/v1/traces", "pomelo", nil), WithClock(func() time.Time { return clock }))
+	ctx, root := tracer.Start(context.Background(), "Handler.handleWebhook", String("transaction.id", "tx1"), Int("http.status_code", 409))
+	_, child := tracer.Start(ctx, "TransactionRepository.SaveTransaction", Bool("idempotent", false))
+	child.RecordError(errors.New("duplicate transaction id"))
+	clock = clock.Add(3 * time.Millisecond)
+	child.End()
+	root.End()
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	if contentType != "application/json" || len(got.ResourceSpans) != 1 {
+		t.Fatalf("unexpected request %q %+v", contentType, got)
+	}
+	rs := got.ResourceSpans[0]
+	if attrs := rs.Resource.Attributes; len(attrs) != 1 || attrs[0].Key != "service.name" || *attrs[0].Value.StringValue != "pomelo" {
+		t.Errorf("unexpected resource %+v", rs.Resource)
+	}
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	c, r := spans[0], spans[1]
+	if c.ParentSpanID != r.SpanID || c.TraceID != r.TraceID || r.ParentSpanID != "" || len(r.TraceID) != 32 {
+		t.Errorf("unexpected ids: root %+v child %+v", r, c)
+	}
+	if c.Status.Code != statusError || c.Status.Message != "duplicate transaction id" || r.Status.Code != statusUnset {
+		t.Errorf("unexpected statuses %+v / %+v", c.Status, r.Status)
+	}
+	if c.StartTimeUnixNano != "1717236000000000000" || c.EndTimeUnixNano != "1717236000003000000" {
+		t.Errorf("unexpected timestamps %s..%s", c.StartTimeUnixNano, c.EndTimeUnixNano)
+	}
+	if a := r.Attributes; len(a) != 2 || *a[0].Value.StringValue != "tx1" || *a[1].Value.IntValue != "409" {
+		t.Errorf("unexpected attributes %+v", a)
+	}
+}
+
+func TestOTLPExporterFailsOnNon2xx(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+	err := NewOTLPExporter(collector.URL, "pomelo", nil).Export(context.Background(), []SpanData{{Name: "op"}})
+	if err == nil {
+		t.Error("expected an error for a 503")
+	}
+}
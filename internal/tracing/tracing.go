@@ -0,0 +1,314 @@
+// Package tracing implements the part of OpenTelemetry tracing Pomelo uses — spans with
+// attributes, W3C traceparent propagation and batched export — without third-party
+// dependencies.
+//
+// A nil *Tracer and a nil *Span are valid and do nothing, so instrumented code needs no checks
+// when tracing is disabled.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HeaderTraceparent is the W3C Trace Context header.
+const HeaderTraceparent = "traceparent"
+
+// DefaultMaxQueueSize caps how many ended spans wait for export; later spans are dropped.
+const DefaultMaxQueueSize = 2048
+
+type TraceID [16]byte
+
+type SpanID [8]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+func (s SpanID) String() string  { return hex.EncodeToString(s[:]) }
+func (t TraceID) IsValid() bool  { return t != TraceID{} }
+func (s SpanID) IsValid() bool   { return s != SpanID{} }
+
+// SpanContext identifies a span across process boundaries.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent formats sc as a version 00 traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent parses a traceparent header value. Versions above 00 are accepted as long as
+// they start with the version 00 fields, as the specification requires.
+func ParseTraceparent(v string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, fmt.Errorf("malformed traceparent %q", v)
+	}
+	var sc SpanContext
+	var flags [1]byte
+	if !decodeHex(sc.TraceID[:], parts[1]) || !decodeHex(sc.SpanID[:], parts[2]) || !decodeHex(flags[:], parts[3]) {
+		return SpanContext{}, fmt.Errorf("malformed traceparent %q", v)
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("traceparent %q has a zero trace or span id", v)
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, nil
+}
+
+// decodeHex decodes exactly len(dst) bytes of lowercase hex.
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != 2*len(dst) || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+// Extract returns ctx carrying the remote parent from h's traceparent, if valid.
+func Extract(ctx context.Context, h http.Header) context.Context {
+	sc, err := ParseTraceparent(h.Get(HeaderTraceparent))
+	if err != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Inject sets the traceparent of the span in ctx on h.
+func Inject(ctx context.Context, h http.Header) {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		h.Set(HeaderTraceparent, sc.Traceparent())
+	}
+}
+
+type (
+	spanKey   struct{}
+	remoteKey struct{}
+)
+
+// SpanFromContext returns the active span, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// SpanContextFromContext returns the active span's context, else the extracted remote parent.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if s := SpanFromContext(ctx); s != nil {
+		return s.data.SpanContext
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// Attribute is a span attribute. Value is a string, int64 or bool.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Attribute    { return Attribute{key, value} }
+func Int(key string, value int64) Attribute { return Attribute{key, value} }
+func Bool(key string, value bool) Attribute { return Attribute{key, value} }
+
+// SpanData is an ended span, as handed to the Exporter.
+type SpanData struct {
+	Name        string
+	SpanContext SpanContext
+	Parent      SpanID
+	Start       time.Time
+	End         time.Time
+	Attributes  []Attribute
+	// Error is the recorded error message; empty when the span succeeded.
+	Error string
+}
+
+// Exporter ships a batch of ended spans to a backend.
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+}
+
+// Tracer starts spans and buffers the ended ones until Flush exports them.
+type Tracer struct {
+	exporter     Exporter
+	maxQueueSize int
+	now          func() time.Time
+
+	mu      sync.Mutex
+	queue   []SpanData
+	dropped int
+}
+
+// Option configures a Tracer.
+type Option func(*Tracer)
+
+func WithMaxQueueSize(n int) Option {
+	return func(t *Tracer) { t.maxQueueSize = n }
+}
+
+// WithClock overrides the time source of span timestamps.
+func WithClock(now func() time.Time) Option {
+	return func(t *Tracer) { t.now = now }
+}
+
+func NewTracer(exporter Exporter, opts ...Option) *Tracer {
+	t := &Tracer{exporter: exporter, maxQueueSize: DefaultMaxQueueSize, now: time.Now}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Start begins a span as a child of the span or remote parent in ctx, or as a new sampled trace.
+// A parent that was not sampled yields a span that propagates its IDs but is never exported.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{SpanID: newSpanID(), Sampled: true}
+	if parent.IsValid() {
+		sc.TraceID, sc.Sampled = parent.TraceID, parent.Sampled
+	} else {
+		rand.Read(sc.TraceID[:])
+	}
+	s := &Span{tracer: t, data: SpanData{
+		Name:        name,
+		SpanContext: sc,
+		Parent:      parent.SpanID,
+		Start:       t.now(),
+		Attributes:  attrs,
+	}}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// Flush exports the buffered spans. Spans the exporter rejects are lost.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	batch, dropped := t.queue, t.dropped
+	t.queue, t.dropped = nil, 0
+	t.mu.Unlock()
+	var errs []error
+	if dropped > 0 {
+		errs = append(errs, fmt.Errorf("dropped %d spans: export queue full", dropped))
+	}
+	if len(batch) > 0 {
+		if err := t.exporter.Export(ctx, batch); err != nil {
+			errs = append(errs, fmt.Errorf("export %d spans: %w", len(batch), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run calls Flush every interval until ctx is done, then flushes one last time with a fresh
+// context bounded by interval. Errors go to report.
+func (t *Tracer) Run(ctx context.Context, interval time.Duration, report func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			final, cancel := context.WithTimeout(context.Background(), interval)
+			err := t.Flush(final)
+			cancel()
+			if err != nil {
+				report(err)
+			}
+			return
+		case <-ticker.C:
+			if err := t.Flush(ctx); err != nil {
+				report(err)
+			}
+		}
+	}
+}
+
+func (t *Tracer) enqueue(d SpanData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.queue) >= t.maxQueueSize {
+		t.dropped++
+		return
+	}
+	t.queue = append(t.queue, d)
+}
+
+// Span is an operation in progress. Its methods are safe for concurrent use.
+type Span struct {
+	tracer *Tracer
+	mu     sync.Mutex
+	data   SpanData
+	ended  bool
+}
+
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+}
+
+// RecordError marks the span failed with err; a nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Error = err.Error()
+}
+
+// End queues the span for export if it is sampled. Calls after the first are ignored.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = s.tracer.now()
+	data := s.data
+	s.mu.Unlock()
+	if data.SpanContext.Sampled {
+		s.tracer.enqueue(data)
+	}
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
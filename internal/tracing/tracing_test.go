@@ -0,0 +1,133 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// memoryExporter keeps every exported span.
+type memoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+	err   error
+}
+
+func (e *memoryExporter) Export(_ context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err != nil {
+		return e.err
+	}
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+const remote = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent(remote)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" || !sc.Sampled {
+		t.Errorf("unexpected span context %+v", sc)
+	}
+	if sc.Traceparent() != remote {
+		t.Errorf("expected round trip, got %q", sc.Traceparent())
+	}
+	if _, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future"); err != nil {
+		t.Errorf("expected a future version to be accepted, got %v", err)
+	}
+	for _, bad := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, err := ParseTraceparent(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestSpansJoinRemoteTrace(t *testing.T) {
+	exp := &memoryExporter{}
+	tracer := NewTracer(exp)
+	h := http.Header{}
+	h.Set(HeaderTraceparent, remote)
+
+	ctx, root := tracer.Start(Extract(context.Background(), h), "handler", String("transaction.id", "tx1"))
+	_, child := tracer.Start(ctx, "repository")
+	child.RecordError(errors.New("boom"))
+	child.End()
+	root.End()
+	root.End() // ignored
+
+	out := http.Header{}
+	Inject(ctx, out)
+	if got := out.Get(HeaderTraceparent); got != root.SpanContext().Traceparent() {
+		t.Errorf("expected the active span to be injected, got %q", got)
+	}
+
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if len(exp.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(exp.spans))
+	}
+	c, r := exp.spans[0], exp.spans[1]
+	if r.SpanContext.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || r.Parent.String() != "00f067aa0ba902b7" {
+		t.Errorf("root should continue the remote trace, got %+v", r)
+	}
+	if c.SpanContext.TraceID != r.SpanContext.TraceID || c.Parent != r.SpanContext.SpanID || c.Error != "boom" {
+		t.Errorf("unexpected child %+v", c)
+	}
+	if len(r.Attributes) != 1 || r.Attributes[0] != String("transaction.id", "tx1") {
+		t.Errorf("unexpected attributes %+v", r.Attributes)
+	}
+}
+
+func TestUnsampledParentIsNotExported(t *testing.T) {
+	exp := &memoryExporter{}
+	tracer := NewTracer(exp)
+	h := http.Header{}
+	h.Set(HeaderTraceparent, remote[:len(remote)-2]+"00")
+	_, span := tracer.Start(Extract(context.Background(), h), "handler")
+	span.End()
+	tracer.Flush(context.Background())
+	if len(exp.spans) != 0 {
+		t.Errorf("expected no exported spans, got %d", len(exp.spans))
+	}
+}
+
+func TestQueueOverflowIsReported(t *testing.T) {
+	exp := &memoryExporter{}
+	tracer := NewTracer(exp, WithMaxQueueSize(1))
+	for range 3 {
+		_, span := tracer.Start(context.Background(), "op")
+		span.End()
+	}
+	if err := tracer.Flush(context.Background()); err == nil {
+		t.Error("expected the dropped spans to be reported")
+	}
+	if len(exp.spans) != 1 {
+		t.Errorf("expected 1 exported span, got %d", len(exp.spans))
+	}
+}
+
+func TestNilTracerIsNoop(t *testing.T) {
+	var tracer *Tracer
+	ctx, span := tracer.Start(context.Background(), "op")
+	span.SetAttributes(Bool("ok", true))
+	span.RecordError(errors.New("ignored"))
+	span.End()
+	if SpanFromContext(ctx) != nil || tracer.Flush(ctx) != nil {
+		t.Error("a nil tracer should not record anything")
+	}
+}
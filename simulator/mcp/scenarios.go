@@ -6,7 +6,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/jailtonjunior/pomelo/internal/signature"
 )
 
 // StepResult captures the outcome of a single HTTP step in a scenario.
@@ -30,19 +34,39 @@ type ScenarioResult struct {
 	Summary  string       `json:"summary"`
 }
 
+// webhookTarget is the server under test. When secret is set, webhook requests are signed
+// the way Pomelo signs them (see internal/signature).
+type webhookTarget struct {
+	baseURL string
+	secret  string
+}
+
 type scenarioRunner struct {
 	baseURL string
+	secret  string
 	client  *http.Client
 	steps   []StepResult
 }
 
-func newRunner(baseURL string) *scenarioRunner {
+func newRunner(target webhookTarget) *scenarioRunner {
 	return &scenarioRunner{
-		baseURL: baseURL,
+		baseURL: target.baseURL,
+		secret:  target.secret,
 		client:  &http.Client{Timeout: 10 * time.Second},
 	}
 }
 
+// sign adds X-Signature / X-Timestamp / X-Endpoint headers when a secret is configured.
+func (r *scenarioRunner) sign(req *http.Request, body []byte) {
+	if r.secret == "" {
+		return
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(signature.HeaderTimestamp, ts)
+	req.Header.Set(signature.HeaderEndpoint, req.URL.Path)
+	req.Header.Set(signature.HeaderSignature, signature.Sign([]byte(r.secret), ts, req.URL.Path, body))
+}
+
 func (r *scenarioRunner) post(desc string, body map[string]any, expectedStatus int) (map[string]any, error) {
 	return r.request(http.MethodPost, r.baseURL+"/webhook/transactions", desc, body, expectedStatus)
 }
@@ -51,6 +75,21 @@ func (r *scenarioRunner) get(desc, url string, expectedStatus int) (map[string]a
 	return r.request(http.MethodGet, url, desc, nil, expectedStatus)
 }
 
+func (r *scenarioRunner) authorize(desc string, body map[string]any, expectedStatus int) (map[string]any, error) {
+	return r.request(http.MethodPost, r.baseURL+"/transactions/authorizations", desc, body, expectedStatus)
+}
+
+func (r *scenarioRunner) putCard(desc, cardID string, body map[string]any, expectedStatus int) (map[string]any, error) {
+	return r.request(http.MethodPut, r.baseURL+"/cards/"+cardID, desc, body, expectedStatus)
+}
+
+// expectDecision fails the most recent step unless the authorizer answered status/statusDetail.
+func (r *scenarioRunner) expectDecision(body map[string]any, status, statusDetail string) {
+	if body["status"] != status || body["status_detail"] != statusDetail {
+		r.failLast(fmt.Sprintf("got %v/%v", body["status"], body["status_detail"]))
+	}
+}
+
 func (r *scenarioRunner) postRaw(desc string, rawBody []byte, expectedStatus int) (map[string]any, error) {
 	step := StepResult{
 		Step:           len(r.steps) + 1,
@@ -66,6 +105,7 @@ func (r *scenarioRunner) postRaw(desc string, rawBody []byte, expectedStatus int
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	r.sign(req, rawBody)
 
 	resp, err := r.client.Do(req)
 	if err != nil {
@@ -95,11 +135,13 @@ func (r *scenarioRunner) request(method, url, desc string, body any, expectedSta
 	}
 
 	var reqBody io.Reader
+	var raw []byte
 	if body != nil {
 		b, err := json.Marshal(body)
 		if err != nil {
 			return nil, err
 		}
+		raw = b
 		reqBody = bytes.NewReader(b)
 	}
 
@@ -109,6 +151,7 @@ func (r *scenarioRunner) request(method, url, desc string, body any, expectedSta
 	}
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
+		r.sign(req, raw)
 	}
 
 	resp, err := r.client.Do(req)
@@ -128,6 +171,35 @@ func (r *scenarioRunner) request(method, url, desc string, body any, expectedSta
 	return respBody, nil
 }
 
+// lastStatus returns the response status of the most recent step, or 0 if none ran.
+func (r *scenarioRunner) lastStatus() int {
+	if len(r.steps) == 0 {
+		return 0
+	}
+	return r.steps[len(r.steps)-1].ResponseStatus
+}
+
+// acceptLast re-evaluates the most recent step against another expected status, for steps whose
+// outcome depends on how the server is configured.
+func (r *scenarioRunner) acceptLast(expectedStatus int) {
+	if len(r.steps) == 0 {
+		return
+	}
+	step := &r.steps[len(r.steps)-1]
+	step.ExpectedStatus = expectedStatus
+	step.Passed = step.ResponseStatus == expectedStatus
+}
+
+// failLast marks the most recent step as failed for a reason the status code does not capture.
+func (r *scenarioRunner) failLast(reason string) {
+	if len(r.steps) == 0 {
+		return
+	}
+	step := &r.steps[len(r.steps)-1]
+	step.Passed = false
+	step.Description += " — " + reason
+}
+
 func (r *scenarioRunner) result(scenario string) ScenarioResult {
 	success := true
 	for _, s := range r.steps {
@@ -153,15 +225,30 @@ func countPassed(steps []StepResult) int {
 // --- Payload builders ---
 
 func purchasePayload(txID, idemKey, status string, amount int64) map[string]any {
+	return transactionPayload(txID, "PURCHASE", idemKey, status, amount)
+}
+
+// transactionPayload builds an event of any type. Withdrawals and balance inquiries (and their
+// reversals) come from an ATM.
+func transactionPayload(txID, txType, idemKey, status string, amount int64) map[string]any {
+	merchant := map[string]any{
+		"id": "merchant-001", "mcc": "5411",
+		"name": "Test Store", "city": "São Paulo", "state": "SP",
+	}
+	pointOfSale := "ONLINE"
+	if strings.HasSuffix(txType, "WITHDRAWAL") || strings.HasSuffix(txType, "BALANCE_INQUIRY") {
+		merchant = map[string]any{
+			"id": "atm-001", "mcc": "6011",
+			"name": "ATM Paulista", "city": "São Paulo", "state": "SP",
+		}
+		pointOfSale = "ATM"
+	}
 	return map[string]any{
-		"id":     txID,
-		"type":   "PURCHASE",
-		"status": status,
-		"amount": amountBlock(amount, "BRL"),
-		"merchant": map[string]any{
-			"id": "merchant-001", "mcc": "5411",
-			"name": "Test Store", "city": "São Paulo", "state": "SP",
-		},
+		"id":       txID,
+		"type":     txType,
+		"status":   status,
+		"amount":   amountBlock(amount, "BRL"),
+		"merchant": merchant,
 		"event": map[string]any{
 			"id":              "evt-" + txID,
 			"created_at":      time.Now().UTC().Format(time.RFC3339),
@@ -171,17 +258,47 @@ func purchasePayload(txID, idemKey, status string, amount int64) map[string]any
 		"card_id":       "card-001",
 		"country":       "BR",
 		"currency":      "BRL",
-		"point_of_sale": "ONLINE",
+		"point_of_sale": pointOfSale,
 	}
 }
 
 func adjustmentPayload(txID, txType, idemKey, originalTxID, status string, amount int64) map[string]any {
-	p := purchasePayload(txID, idemKey, status, amount)
-	p["type"] = txType
+	p := transactionPayload(txID, txType, idemKey, status, amount)
 	p["original_transaction_id"] = originalTxID
 	return p
 }
 
+// withCardholder moves a payload to its own user and card, so balance assertions are not affected
+// by other scenarios.
+func withCardholder(p map[string]any, userID, cardID string) map[string]any {
+	p["user_id"] = userID
+	p["card_id"] = cardID
+	return p
+}
+
+// authorizationPayload builds an authorizer request for a transaction that has not happened yet.
+func authorizationPayload(txID, txType, userID, cardID string, amount int64) map[string]any {
+	return map[string]any{
+		"transaction": map[string]any{"id": txID, "type": txType},
+		"merchant":    map[string]any{"id": "merchant-001", "mcc": "5411", "name": "Test Store"},
+		"card":        map[string]any{"id": cardID},
+		"user":        map[string]any{"id": userID},
+		"amount":      map[string]any{"local": map[string]any{"total": amount, "currency": "BRL"}},
+	}
+}
+
+// cardPayload builds a BRL card profile; zero limits are disabled.
+func cardPayload(userID, status string, balance, transactionLimit, dailyLimit int64) map[string]any {
+	return map[string]any{
+		"user_id":           userID,
+		"status":            status,
+		"currency":          "BRL",
+		"balance":           balance,
+		"transaction_limit": transactionLimit,
+		"daily_limit":       dailyLimit,
+	}
+}
+
 func amountBlock(amount int64, currency string) map[string]any {
 	newBlock := func() map[string]any {
 		return map[string]any{"total": amount, "currency": currency}
@@ -196,73 +313,110 @@ func amountBlock(amount int64, currency string) map[string]any {
 
 // --- Scenario implementations ---
 
-func runScenario(baseURL, scenario string) (ScenarioResult, error) {
+func runScenario(target webhookTarget, scenario string) (ScenarioResult, error) {
 	switch scenario {
 	// ── Basic purchase flows ──────────────────────────────────────────────
 	case "purchase_approved":
-		return scenarioPurchaseApproved(baseURL)
+		return scenarioPurchaseApproved(target)
 	case "purchase_rejected":
-		return scenarioPurchaseRejected(baseURL)
+		return scenarioPurchaseRejected(target)
 	case "purchase_at_min_amount":
-		return scenarioPurchaseAtMinAmount(baseURL)
+		return scenarioPurchaseAtMinAmount(target)
 	case "purchase_at_max_amount":
-		return scenarioPurchaseAtMaxAmount(baseURL)
+		return scenarioPurchaseAtMaxAmount(target)
 	case "purchase_amount_too_low":
-		return scenarioPurchaseAmountTooLow(baseURL)
+		return scenarioPurchaseAmountTooLow(target)
 	case "purchase_amount_too_high":
-		return scenarioPurchaseAmountTooHigh(baseURL)
+		return scenarioPurchaseAmountTooHigh(target)
 	case "purchase_negative_amount":
-		return scenarioPurchaseNegativeAmount(baseURL)
+		return scenarioPurchaseNegativeAmount(target)
 	// ── Reversal flows ────────────────────────────────────────────────────
 	case "reversal_total":
-		return scenarioReversalTotal(baseURL)
+		return scenarioReversalTotal(target)
 	case "reversal_partial":
-		return scenarioReversalPartial(baseURL)
+		return scenarioReversalPartial(target)
 	case "reversal_exceeds_amount":
-		return scenarioReversalExceedsAmount(baseURL)
+		return scenarioReversalExceedsAmount(target)
 	case "reversal_on_rejected_purchase":
-		return scenarioReversalOnRejectedPurchase(baseURL)
+		return scenarioReversalOnRejectedPurchase(target)
 	// ── Refund flows ──────────────────────────────────────────────────────
 	case "refund_total":
-		return scenarioRefundTotal(baseURL)
+		return scenarioRefundTotal(target)
 	case "refund_partial_single":
-		return scenarioRefundPartialSingle(baseURL)
+		return scenarioRefundPartialSingle(target)
 	case "refund_partial_multiple":
-		return scenarioRefundPartialMultiple(baseURL)
+		return scenarioRefundPartialMultiple(target)
 	case "refund_exceeds_amount":
-		return scenarioRefundExceedsAmount(baseURL)
+		return scenarioRefundExceedsAmount(target)
 	case "refund_on_rejected_purchase":
-		return scenarioRefundOnRejectedPurchase(baseURL)
+		return scenarioRefundOnRejectedPurchase(target)
 	case "multiple_adjustments_exceed":
-		return scenarioMultipleAdjustmentsExceed(baseURL)
+		return scenarioMultipleAdjustmentsExceed(target)
 	// ── Mixed adjustment flows ────────────────────────────────────────────
 	case "reversal_after_partial_refund":
-		return scenarioReversalAfterPartialRefund(baseURL)
+		return scenarioReversalAfterPartialRefund(target)
 	// ── Idempotency & delivery flows ─────────────────────────────────────
 	case "duplicate_event":
-		return scenarioDuplicateEvent(baseURL)
+		return scenarioDuplicateEvent(target)
 	case "out_of_order":
-		return scenarioOutOfOrder(baseURL)
+		return scenarioOutOfOrder(target)
 	case "webhook_retry":
-		return scenarioWebhookRetry(baseURL)
+		return scenarioWebhookRetry(target)
 	// ── Validation error flows ────────────────────────────────────────────
 	case "missing_original_transaction_id":
-		return scenarioMissingOriginalTransactionID(baseURL)
+		return scenarioMissingOriginalTransactionID(target)
 	case "missing_id":
-		return scenarioMissingID(baseURL)
+		return scenarioMissingID(target)
 	case "missing_idempotency_key":
-		return scenarioMissingIdempotencyKey(baseURL)
+		return scenarioMissingIdempotencyKey(target)
 	case "invalid_created_at":
-		return scenarioInvalidCreatedAt(baseURL)
+		return scenarioInvalidCreatedAt(target)
 	case "invalid_json_body":
-		return scenarioInvalidJSONBody(baseURL)
+		return scenarioInvalidJSONBody(target)
+	// ── Other transaction types ───────────────────────────────────────────
+	case "withdrawal_reversal":
+		return scenarioWithdrawalReversal(target)
+	case "withdrawal_reversal_on_rejected":
+		return scenarioWithdrawalReversalOnRejected(target)
+	case "withdrawal_amount_out_of_range":
+		return scenarioWithdrawalAmountOutOfRange(target)
+	case "extracash_reversal_exceeds":
+		return scenarioExtracashReversalExceeds(target)
+	case "balance_inquiry":
+		return scenarioBalanceInquiry(target)
+	case "payment_reversal_partial":
+		return scenarioPaymentReversalPartial(target)
+	case "credit_voucher_reversal":
+		return scenarioCreditVoucherReversal(target)
+	case "reversal_type_mismatch":
+		return scenarioReversalTypeMismatch(target)
 	// ── Query flows ───────────────────────────────────────────────────────
 	case "list_transactions":
-		return scenarioListTransactions(baseURL)
+		return scenarioListTransactions(target)
 	case "get_transaction_existing":
-		return scenarioGetTransactionExisting(baseURL)
+		return scenarioGetTransactionExisting(target)
 	case "get_transaction_not_found":
-		return scenarioGetTransactionNotFound(baseURL)
+		return scenarioGetTransactionNotFound(target)
+	case "get_transaction_adjustments":
+		return scenarioGetTransactionAdjustments(target)
+	// ── Ledger flows ──────────────────────────────────────────────────────
+	case "ledger_balance":
+		return scenarioLedgerBalance(target)
+	// ── Authorization flows ───────────────────────────────────────────────
+	case "authorization_approved_then_purchase":
+		return scenarioAuthorizationApprovedThenPurchase(target)
+	case "authorization_insufficient_balance":
+		return scenarioAuthorizationInsufficientBalance(target)
+	case "authorization_card_blocked":
+		return scenarioAuthorizationCardBlocked(target)
+	case "authorization_limits":
+		return scenarioAuthorizationLimits(target)
+	case "authorization_unknown_card":
+		return scenarioAuthorizationUnknownCard(target)
+	case "authorization_duplicate":
+		return scenarioAuthorizationDuplicate(target)
+	case "authorization_webhook_mismatch":
+		return scenarioAuthorizationWebhookMismatch(target)
 	default:
 		return ScenarioResult{}, fmt.Errorf("unknown scenario: %s", scenario)
 	}
@@ -270,53 +424,53 @@ func runScenario(baseURL, scenario string) (ScenarioResult, error) {
 
 // ── Basic purchase flows ──────────────────────────────────────────────────────
 
-func scenarioPurchaseApproved(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioPurchaseApproved(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE APPROVED (R$100,00)", purchasePayload("tx-pa-001", "idem-pa-001", "APPROVED", 10000), 200)
 	return r.result("purchase_approved"), nil
 }
 
-func scenarioPurchaseRejected(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioPurchaseRejected(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE REJECTED (R$100,00)", purchasePayload("tx-pr-001", "idem-pr-001", "REJECTED", 10000), 200)
 	return r.result("purchase_rejected"), nil
 }
 
 // scenarioPurchaseAtMinAmount validates boundary: minimum allowed amount R$1,00 (100 cents).
-func scenarioPurchaseAtMinAmount(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioPurchaseAtMinAmount(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE at minimum amount R$1,00 (100 cents) → expect 200",
 		purchasePayload("tx-pmin-001", "idem-pmin-001", "APPROVED", 100), 200)
 	return r.result("purchase_at_min_amount"), nil
 }
 
 // scenarioPurchaseAtMaxAmount validates boundary: maximum allowed amount R$5.000,00 (500000 cents).
-func scenarioPurchaseAtMaxAmount(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioPurchaseAtMaxAmount(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE at maximum amount R$5.000,00 (500000 cents) → expect 200",
 		purchasePayload("tx-pmax-001", "idem-pmax-001", "APPROVED", 500_000), 200)
 	return r.result("purchase_at_max_amount"), nil
 }
 
 // scenarioPurchaseAmountTooLow validates that amounts below R$1,00 are rejected with 422.
-func scenarioPurchaseAmountTooLow(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioPurchaseAmountTooLow(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE with amount R$0,50 (50 cents, below minimum) → expect 422",
 		purchasePayload("tx-plow-001", "idem-plow-001", "APPROVED", 50), 422)
 	return r.result("purchase_amount_too_low"), nil
 }
 
 // scenarioPurchaseAmountTooHigh validates that amounts above R$5.000,00 are rejected with 422.
-func scenarioPurchaseAmountTooHigh(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioPurchaseAmountTooHigh(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE with amount R$6.000,00 (600000 cents, above maximum) → expect 422",
 		purchasePayload("tx-phigh-001", "idem-phigh-001", "APPROVED", 600_000), 422)
 	return r.result("purchase_amount_too_high"), nil
 }
 
 // scenarioPurchaseNegativeAmount validates that negative amounts are rejected with 400.
-func scenarioPurchaseNegativeAmount(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioPurchaseNegativeAmount(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE with negative amount → expect 400",
 		purchasePayload("tx-pneg-001", "idem-pneg-001", "APPROVED", -100), 400)
 	return r.result("purchase_negative_amount"), nil
@@ -324,16 +478,16 @@ func scenarioPurchaseNegativeAmount(baseURL string) (ScenarioResult, error) {
 
 // ── Reversal flows ────────────────────────────────────────────────────────────
 
-func scenarioReversalTotal(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioReversalTotal(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE APPROVED (R$100,00)", purchasePayload("tx-rt-001", "idem-rt-001", "APPROVED", 10000), 200)
 	r.post("POST REVERSAL_PURCHASE (total amount R$100,00) → expect 200",
 		adjustmentPayload("tx-rt-002", "REVERSAL_PURCHASE", "idem-rt-002", "tx-rt-001", "APPROVED", 10000), 200)
 	return r.result("reversal_total"), nil
 }
 
-func scenarioReversalPartial(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioReversalPartial(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE APPROVED (R$100,00)", purchasePayload("tx-rp-001", "idem-rp-001", "APPROVED", 10000), 200)
 	r.post("POST REVERSAL_PURCHASE (partial R$50,00) → expect 200",
 		adjustmentPayload("tx-rp-002", "REVERSAL_PURCHASE", "idem-rp-002", "tx-rp-001", "APPROVED", 5000), 200)
@@ -341,8 +495,8 @@ func scenarioReversalPartial(baseURL string) (ScenarioResult, error) {
 }
 
 // scenarioReversalExceedsAmount validates that a reversal exceeding the original amount is rejected with 409.
-func scenarioReversalExceedsAmount(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioReversalExceedsAmount(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE APPROVED (R$100,00)", purchasePayload("tx-rea-001", "idem-rea-001", "APPROVED", 10000), 200)
 	r.post("POST REVERSAL_PURCHASE exceeding original amount (R$150,00) → expect 409",
 		adjustmentPayload("tx-rea-002", "REVERSAL_PURCHASE", "idem-rea-002", "tx-rea-001", "APPROVED", 15000), 409)
@@ -350,8 +504,8 @@ func scenarioReversalExceedsAmount(baseURL string) (ScenarioResult, error) {
 }
 
 // scenarioReversalOnRejectedPurchase validates that reversals on rejected purchases are rejected with 409.
-func scenarioReversalOnRejectedPurchase(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioReversalOnRejectedPurchase(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE REJECTED (R$100,00)", purchasePayload("tx-rorp-001", "idem-rorp-001", "REJECTED", 10000), 200)
 	r.post("POST REVERSAL_PURCHASE on rejected purchase → expect 409",
 		adjustmentPayload("tx-rorp-002", "REVERSAL_PURCHASE", "idem-rorp-002", "tx-rorp-001", "APPROVED", 10000), 409)
@@ -360,16 +514,16 @@ func scenarioReversalOnRejectedPurchase(baseURL string) (ScenarioResult, error)
 
 // ── Refund flows ──────────────────────────────────────────────────────────────
 
-func scenarioRefundTotal(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioRefundTotal(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE APPROVED (R$100,00)", purchasePayload("tx-rft-001", "idem-rft-001", "APPROVED", 10000), 200)
 	r.post("POST REFUND total amount (R$100,00) → expect 200",
 		adjustmentPayload("tx-rft-002", "REFUND", "idem-rft-002", "tx-rft-001", "APPROVED", 10000), 200)
 	return r.result("refund_total"), nil
 }
 
-func scenarioRefundPartialSingle(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioRefundPartialSingle(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE APPROVED (R$100,00)", purchasePayload("tx-rfps-001", "idem-rfps-001", "APPROVED", 10000), 200)
 	r.post("POST REFUND partial (R$40,00) → expect 200",
 		adjustmentPayload("tx-rfps-002", "REFUND", "idem-rfps-002", "tx-rfps-001", "APPROVED", 4000), 200)
@@ -377,8 +531,8 @@ func scenarioRefundPartialSingle(baseURL string) (ScenarioResult, error) {
 }
 
 // scenarioRefundPartialMultiple validates multiple partial refunds that sum exactly to the total purchase amount.
-func scenarioRefundPartialMultiple(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioRefundPartialMultiple(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	// Purchase: R$300,00 (30000 cents)
 	// REFUND #1: R$150,00 + REFUND #2: R$150,00 = R$300,00 (total)
 	r.post("POST PURCHASE APPROVED (R$300,00)", purchasePayload("tx-rfpm-001", "idem-rfpm-001", "APPROVED", 30000), 200)
@@ -389,8 +543,8 @@ func scenarioRefundPartialMultiple(baseURL string) (ScenarioResult, error) {
 	return r.result("refund_partial_multiple"), nil
 }
 
-func scenarioRefundExceedsAmount(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioRefundExceedsAmount(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE APPROVED (R$100,00)", purchasePayload("tx-rfea-001", "idem-rfea-001", "APPROVED", 10000), 200)
 	r.post("POST REFUND exceeding original amount (R$150,00) → expect 409",
 		adjustmentPayload("tx-rfea-002", "REFUND", "idem-rfea-002", "tx-rfea-001", "APPROVED", 15000), 409)
@@ -398,8 +552,8 @@ func scenarioRefundExceedsAmount(baseURL string) (ScenarioResult, error) {
 }
 
 // scenarioRefundOnRejectedPurchase validates that refunds on rejected purchases are rejected with 409.
-func scenarioRefundOnRejectedPurchase(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioRefundOnRejectedPurchase(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE REJECTED (R$100,00)", purchasePayload("tx-rforp-001", "idem-rforp-001", "REJECTED", 10000), 200)
 	r.post("POST REFUND on rejected purchase → expect 409",
 		adjustmentPayload("tx-rforp-002", "REFUND", "idem-rforp-002", "tx-rforp-001", "APPROVED", 10000), 409)
@@ -407,8 +561,8 @@ func scenarioRefundOnRejectedPurchase(baseURL string) (ScenarioResult, error) {
 }
 
 // scenarioMultipleAdjustmentsExceed validates that the cumulative sum of adjustments cannot exceed the original amount.
-func scenarioMultipleAdjustmentsExceed(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioMultipleAdjustmentsExceed(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	// Purchase: R$100,00. Two refunds each R$60,00 → second one exceeds remaining R$40,00.
 	r.post("POST PURCHASE APPROVED (R$100,00)", purchasePayload("tx-mae-001", "idem-mae-001", "APPROVED", 10000), 200)
 	r.post("POST REFUND #1 (R$60,00) → expect 200",
@@ -420,8 +574,8 @@ func scenarioMultipleAdjustmentsExceed(baseURL string) (ScenarioResult, error) {
 
 // ── Mixed adjustment flows ────────────────────────────────────────────────────
 
-func scenarioReversalAfterPartialRefund(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioReversalAfterPartialRefund(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE APPROVED (R$100,00)", purchasePayload("tx-rapf-001", "idem-rapf-001", "APPROVED", 10000), 200)
 	r.post("POST REFUND partial (R$60,00) → expect 200",
 		adjustmentPayload("tx-rapf-002", "REFUND", "idem-rapf-002", "tx-rapf-001", "APPROVED", 6000), 200)
@@ -432,27 +586,42 @@ func scenarioReversalAfterPartialRefund(baseURL string) (ScenarioResult, error)
 
 // ── Idempotency & delivery flows ──────────────────────────────────────────────
 
-func scenarioDuplicateEvent(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioDuplicateEvent(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE APPROVED (first delivery)", purchasePayload("tx-de-001", "idem-de-001", "APPROVED", 10000), 200)
 	r.post("POST PURCHASE APPROVED (duplicate idempotency_key, same event) → expect 200 with idempotent=true",
 		purchasePayload("tx-de-001", "idem-de-001", "APPROVED", 10000), 200)
 	return r.result("duplicate_event"), nil
 }
 
-func scenarioOutOfOrder(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
-	r.post("POST REFUND before original PURCHASE (out-of-order) → expect 404",
-		adjustmentPayload("tx-ooo-002", "REFUND", "idem-ooo-002", "tx-ooo-001", "APPROVED", 5000), 404)
-	r.post("POST PURCHASE APPROVED (original arrives late) → expect 200",
+// scenarioOutOfOrder sends a REFUND before its PURCHASE. By default the server parks it (202)
+// and applies it when the purchase arrives; with parking disabled (PENDING_ADJUSTMENT_TTL=0) it
+// answers 404 and relies on Pomelo retrying. The first response selects which flow is checked.
+func scenarioOutOfOrder(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	refund := adjustmentPayload("tx-ooo-002", "REFUND", "idem-ooo-002", "tx-ooo-001", "APPROVED", 5000)
+	r.post("POST REFUND before original PURCHASE (out-of-order) → expect 202 parked (404 when parking is disabled)",
+		refund, http.StatusAccepted)
+	if r.lastStatus() == http.StatusNotFound {
+		r.acceptLast(http.StatusNotFound)
+		r.post("POST PURCHASE APPROVED (original arrives late) → expect 200",
+			purchasePayload("tx-ooo-001", "idem-ooo-001", "APPROVED", 10000), 200)
+		r.post("POST REFUND retry (now original exists) → expect 200",
+			adjustmentPayload("tx-ooo-002", "REFUND", "idem-ooo-003", "tx-ooo-001", "APPROVED", 5000), 200)
+		return r.result("out_of_order"), nil
+	}
+	r.post("POST REFUND retry while parked (same idempotency_key) → expect 202 idempotent=true", refund, http.StatusAccepted)
+	r.post("POST PURCHASE APPROVED R$100,00 (original arrives late, parked REFUND applied) → expect 200",
 		purchasePayload("tx-ooo-001", "idem-ooo-001", "APPROVED", 10000), 200)
-	r.post("POST REFUND retry (now original exists) → expect 200",
-		adjustmentPayload("tx-ooo-002", "REFUND", "idem-ooo-003", "tx-ooo-001", "APPROVED", 5000), 200)
+	r.post("POST REFUND R$60,00 → expect 409 (parked R$50,00 already consumed the budget)",
+		adjustmentPayload("tx-ooo-004", "REFUND", "idem-ooo-004", "tx-ooo-001", "APPROVED", 6000), 409)
+	r.post("POST REFUND retry after apply (same idempotency_key) → expect 200 idempotent=true", refund, 200)
+	r.get("GET /adjustments/review → expect 200", r.baseURL+"/adjustments/review", 200)
 	return r.result("out_of_order"), nil
 }
 
-func scenarioWebhookRetry(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioWebhookRetry(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	// Step 1: first delivery — success.
 	r.post("POST PURCHASE APPROVED (first delivery) → expect 200",
 		purchasePayload("tx-wr-001", "idem-wr-001", "APPROVED", 10000), 200)
@@ -466,8 +635,8 @@ func scenarioWebhookRetry(baseURL string) (ScenarioResult, error) {
 // ── Validation error flows ────────────────────────────────────────────────────
 
 // scenarioMissingOriginalTransactionID validates that adjustments without original_transaction_id are rejected with 400.
-func scenarioMissingOriginalTransactionID(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioMissingOriginalTransactionID(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	// Build a REFUND payload with empty original_transaction_id.
 	payload := adjustmentPayload("tx-moti-001", "REFUND", "idem-moti-001", "", "APPROVED", 5000)
 	r.post("POST REFUND without original_transaction_id → expect 400", payload, 400)
@@ -475,8 +644,8 @@ func scenarioMissingOriginalTransactionID(baseURL string) (ScenarioResult, error
 }
 
 // scenarioMissingID validates that requests without id are rejected with 400.
-func scenarioMissingID(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioMissingID(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	payload := purchasePayload("tx-mid-001", "idem-mid-001", "APPROVED", 1000)
 	delete(payload, "id")
 	r.post("POST PURCHASE without id → expect 400", payload, 400)
@@ -484,16 +653,16 @@ func scenarioMissingID(baseURL string) (ScenarioResult, error) {
 }
 
 // scenarioMissingIdempotencyKey validates that requests without idempotency_key are rejected with 400.
-func scenarioMissingIdempotencyKey(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioMissingIdempotencyKey(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	payload := purchasePayload("tx-mik-001", "", "APPROVED", 1000)
 	r.post("POST PURCHASE with empty idempotency_key → expect 400", payload, 400)
 	return r.result("missing_idempotency_key"), nil
 }
 
 // scenarioInvalidCreatedAt validates that requests with non-RFC3339 created_at are rejected with 400.
-func scenarioInvalidCreatedAt(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioInvalidCreatedAt(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	payload := purchasePayload("tx-ica-001", "idem-ica-001", "APPROVED", 1000)
 	payload["event"] = map[string]any{
 		"id":              "evt-tx-ica-001",
@@ -505,37 +674,291 @@ func scenarioInvalidCreatedAt(baseURL string) (ScenarioResult, error) {
 }
 
 // scenarioInvalidJSONBody validates that non-JSON request bodies are rejected with 400.
-func scenarioInvalidJSONBody(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioInvalidJSONBody(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.postRaw("POST non-JSON body → expect 400", []byte("this is not json"), 400)
 	return r.result("invalid_json_body"), nil
 }
 
+// ── Other transaction types ───────────────────────────────────────────────────
+
+// scenarioWithdrawalReversal validates a full REVERSAL_WITHDRAWAL of an ATM withdrawal.
+func scenarioWithdrawalReversal(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.post("POST WITHDRAWAL APPROVED (R$200,00)",
+		transactionPayload("tx-wdr-001", "WITHDRAWAL", "idem-wdr-001", "APPROVED", 20000), 200)
+	r.post("POST REVERSAL_WITHDRAWAL (total R$200,00) → expect 200",
+		adjustmentPayload("tx-wdr-002", "REVERSAL_WITHDRAWAL", "idem-wdr-002", "tx-wdr-001", "APPROVED", 20000), 200)
+	detail, _ := r.get("GET /transactions/tx-wdr-001 → expect 200 with state FULLY_REVERSED",
+		target.baseURL+"/transactions/tx-wdr-001", 200)
+	if detail["state"] != "FULLY_REVERSED" {
+		r.failLast(fmt.Sprintf("got state %v", detail["state"]))
+	}
+	return r.result("withdrawal_reversal"), nil
+}
+
+// scenarioWithdrawalReversalOnRejected validates that a rejected withdrawal cannot be reversed (409).
+func scenarioWithdrawalReversalOnRejected(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.post("POST WITHDRAWAL REJECTED (R$200,00)",
+		transactionPayload("tx-wrr-001", "WITHDRAWAL", "idem-wrr-001", "REJECTED", 20000), 200)
+	r.post("POST REVERSAL_WITHDRAWAL on rejected withdrawal → expect 409",
+		adjustmentPayload("tx-wrr-002", "REVERSAL_WITHDRAWAL", "idem-wrr-002", "tx-wrr-001", "APPROVED", 20000), 409)
+	return r.result("withdrawal_reversal_on_rejected"), nil
+}
+
+// scenarioWithdrawalAmountOutOfRange validates the R$10,00–R$3.000,00 withdrawal range (422).
+func scenarioWithdrawalAmountOutOfRange(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.post("POST WITHDRAWAL R$5,00 (below minimum) → expect 422",
+		transactionPayload("tx-wor-001", "WITHDRAWAL", "idem-wor-001", "APPROVED", 500), 422)
+	r.post("POST WITHDRAWAL R$3.500,00 (above maximum) → expect 422",
+		transactionPayload("tx-wor-002", "WITHDRAWAL", "idem-wor-002", "APPROVED", 350_000), 422)
+	return r.result("withdrawal_amount_out_of_range"), nil
+}
+
+// scenarioExtracashReversalExceeds validates that a REVERSAL_EXTRACASH is capped by the extracash amount.
+func scenarioExtracashReversalExceeds(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.post("POST EXTRACASH APPROVED (R$50,00)",
+		transactionPayload("tx-ere-001", "EXTRACASH", "idem-ere-001", "APPROVED", 5000), 200)
+	r.post("POST REVERSAL_EXTRACASH R$60,00 (exceeds) → expect 409",
+		adjustmentPayload("tx-ere-002", "REVERSAL_EXTRACASH", "idem-ere-002", "tx-ere-001", "APPROVED", 6000), 409)
+	return r.result("extracash_reversal_exceeds"), nil
+}
+
+// scenarioBalanceInquiry validates that a BALANCE_INQUIRY carries no amount.
+func scenarioBalanceInquiry(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.post("POST BALANCE_INQUIRY with amount 0 → expect 200",
+		transactionPayload("tx-bi-001", "BALANCE_INQUIRY", "idem-bi-001", "APPROVED", 0), 200)
+	r.post("POST BALANCE_INQUIRY with amount R$1,00 → expect 422",
+		transactionPayload("tx-bi-002", "BALANCE_INQUIRY", "idem-bi-002", "APPROVED", 100), 422)
+	return r.result("balance_inquiry"), nil
+}
+
+func scenarioPaymentReversalPartial(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.post("POST PAYMENT APPROVED (R$150,00)",
+		transactionPayload("tx-prp-001", "PAYMENT", "idem-prp-001", "APPROVED", 15000), 200)
+	r.post("POST REVERSAL_PAYMENT (partial R$50,00) → expect 200",
+		adjustmentPayload("tx-prp-002", "REVERSAL_PAYMENT", "idem-prp-002", "tx-prp-001", "APPROVED", 5000), 200)
+	return r.result("payment_reversal_partial"), nil
+}
+
+func scenarioCreditVoucherReversal(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.post("POST CREDIT_VOUCHER APPROVED (R$80,00)",
+		transactionPayload("tx-cvr-001", "CREDIT_VOUCHER", "idem-cvr-001", "APPROVED", 8000), 200)
+	r.post("POST REVERSAL_CREDIT_VOUCHER (total R$80,00) → expect 200",
+		adjustmentPayload("tx-cvr-002", "REVERSAL_CREDIT_VOUCHER", "idem-cvr-002", "tx-cvr-001", "APPROVED", 8000), 200)
+	return r.result("credit_voucher_reversal"), nil
+}
+
+// scenarioReversalTypeMismatch validates that an adjustment may only target its own original type (422).
+func scenarioReversalTypeMismatch(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.post("POST PURCHASE APPROVED (R$100,00)", purchasePayload("tx-rtm-001", "idem-rtm-001", "APPROVED", 10000), 200)
+	r.post("POST REVERSAL_WITHDRAWAL on a PURCHASE → expect 422",
+		adjustmentPayload("tx-rtm-002", "REVERSAL_WITHDRAWAL", "idem-rtm-002", "tx-rtm-001", "APPROVED", 5000), 422)
+	r.post("POST WITHDRAWAL APPROVED (R$100,00)",
+		transactionPayload("tx-rtm-003", "WITHDRAWAL", "idem-rtm-003", "APPROVED", 10000), 200)
+	r.post("POST REFUND on a WITHDRAWAL → expect 422",
+		adjustmentPayload("tx-rtm-004", "REFUND", "idem-rtm-004", "tx-rtm-003", "APPROVED", 5000), 422)
+	return r.result("reversal_type_mismatch"), nil
+}
+
 // ── Query flows ───────────────────────────────────────────────────────────────
 
-// scenarioListTransactions validates that GET /transactions returns a JSON array.
-func scenarioListTransactions(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+// scenarioListTransactions validates that GET /transactions returns a JSON array and accepts
+// filters, sorting and a page size.
+func scenarioListTransactions(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE APPROVED (seed data)", purchasePayload("tx-lt-001", "idem-lt-001", "APPROVED", 10000), 200)
-	r.get("GET /transactions → expect 200 with array", baseURL+"/transactions", 200)
+	r.get("GET /transactions → expect 200 with array", target.baseURL+"/transactions", 200)
+	r.get("GET /transactions?user_id=user-001&status=APPROVED&sort=-amount&limit=1 → expect 200 (next page in X-Next-Cursor)",
+		target.baseURL+"/transactions?user_id=user-001&status=APPROVED&sort=-amount&limit=1", 200)
+	r.get("GET /transactions?limit=abc → expect 400 INVALID_QUERY", target.baseURL+"/transactions?limit=abc", 400)
 	return r.result("list_transactions"), nil
 }
 
 // scenarioGetTransactionExisting validates that GET /transactions/:id returns 200 for an existing transaction.
-func scenarioGetTransactionExisting(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
+func scenarioGetTransactionExisting(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
 	r.post("POST PURCHASE APPROVED (seed data)", purchasePayload("tx-gte-001", "idem-gte-001", "APPROVED", 10000), 200)
-	r.get("GET /transactions/tx-gte-001 → expect 200", baseURL+"/transactions/tx-gte-001", 200)
+	r.get("GET /transactions/tx-gte-001 → expect 200", target.baseURL+"/transactions/tx-gte-001", 200)
 	return r.result("get_transaction_existing"), nil
 }
 
 // scenarioGetTransactionNotFound validates that GET /transactions/:id with unknown id returns 404.
-func scenarioGetTransactionNotFound(baseURL string) (ScenarioResult, error) {
-	r := newRunner(baseURL)
-	r.get("GET /transactions/non-existent-id → expect 404", baseURL+"/transactions/non-existent-tx-id", 404)
+func scenarioGetTransactionNotFound(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.get("GET /transactions/non-existent-id → expect 404", target.baseURL+"/transactions/non-existent-tx-id", 404)
 	return r.result("get_transaction_not_found"), nil
 }
 
+// scenarioGetTransactionAdjustments validates the adjustment list and the balance fields of the
+// purchase detail after a partial refund.
+func scenarioGetTransactionAdjustments(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.post("POST PURCHASE APPROVED R$100,00 (seed data)", purchasePayload("tx-gta-001", "idem-gta-001", "APPROVED", 10000), 200)
+	r.post("POST REFUND R$30,00 (seed data)",
+		adjustmentPayload("tx-gta-002", "REFUND", "idem-gta-002", "tx-gta-001", "APPROVED", 3000), 200)
+	r.get("GET /transactions/tx-gta-001/adjustments → expect 200 with 1 adjustment",
+		target.baseURL+"/transactions/tx-gta-001/adjustments", 200)
+	detail, _ := r.get("GET /transactions/tx-gta-001 → expect 200 with state PARTIALLY_REFUNDED",
+		target.baseURL+"/transactions/tx-gta-001", 200)
+	if detail["state"] != "PARTIALLY_REFUNDED" {
+		r.failLast(fmt.Sprintf("got state %v", detail["state"]))
+	}
+	r.get("GET /transactions/non-existent-tx-id/adjustments → expect 404",
+		target.baseURL+"/transactions/non-existent-tx-id/adjustments", 404)
+	return r.result("get_transaction_adjustments"), nil
+}
+
+// ── Ledger flows ──────────────────────────────────────────────────────────────
+
+// scenarioLedgerBalance validates the double-entry ledger on a dedicated card: a purchase, a partial
+// refund and a rejected withdrawal leave a net of R$70,00, and the whole ledger still sums to zero.
+func scenarioLedgerBalance(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.post("POST PURCHASE APPROVED R$100,00 on card-ldg-001",
+		withCardholder(purchasePayload("tx-ldg-001", "idem-ldg-001", "APPROVED", 10000), "user-ldg-001", "card-ldg-001"), 200)
+	r.post("POST REFUND R$30,00",
+		withCardholder(adjustmentPayload("tx-ldg-002", "REFUND", "idem-ldg-002", "tx-ldg-001", "APPROVED", 3000), "user-ldg-001", "card-ldg-001"), 200)
+	r.post("POST WITHDRAWAL REJECTED R$50,00 (not posted)",
+		withCardholder(transactionPayload("tx-ldg-003", "WITHDRAWAL", "idem-ldg-003", "REJECTED", 5000), "user-ldg-001", "card-ldg-001"), 200)
+
+	balance, _ := r.get("GET /users/user-ldg-001/balance → expect 200 with net 7000",
+		target.baseURL+"/users/user-ldg-001/balance", 200)
+	if net := firstBalanceNet(balance); net != 7000 {
+		r.failLast(fmt.Sprintf("got net %v", net))
+	}
+	ledger, _ := r.get("GET /cards/card-ldg-001/ledger → expect 200 with 2 entries",
+		target.baseURL+"/cards/card-ldg-001/ledger", 200)
+	if entries, _ := ledger["entries"].([]any); len(entries) != 2 {
+		r.failLast(fmt.Sprintf("got %d entries", len(entries)))
+	}
+	r.get("GET /ledger/verify → expect 200 (ledger sums to zero)", target.baseURL+"/ledger/verify", 200)
+	return r.result("ledger_balance"), nil
+}
+
+// firstBalanceNet reads balances[0].net from a decoded balance response; JSON numbers are float64.
+func firstBalanceNet(body map[string]any) float64 {
+	balances, _ := body["balances"].([]any)
+	if len(balances) == 0 {
+		return 0
+	}
+	first, _ := balances[0].(map[string]any)
+	net, _ := first["net"].(float64)
+	return net
+}
+
+// ── Authorization flows ───────────────────────────────────────────────────────
+//
+// Each scenario configures its own card first. Decisions are idempotent by transaction ID, so a
+// scenario run twice against the same server gets the same answers.
+
+// scenarioAuthorizationApprovedThenPurchase authorizes R$200,00, sends the PURCHASE webhook for the
+// same transaction and checks the webhook matched the decision.
+func scenarioAuthorizationApprovedThenPurchase(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.putCard("PUT /cards/card-auth-001 balance R$500,00", "card-auth-001", cardPayload("user-auth-001", "ACTIVE", 50000, 0, 0), 200)
+	body, _ := r.authorize("POST authorization R$200,00 → expect APPROVED",
+		authorizationPayload("tx-auth-001", "PURCHASE", "user-auth-001", "card-auth-001", 20000), 200)
+	r.expectDecision(body, "APPROVED", "APPROVED")
+	r.post("POST PURCHASE APPROVED R$200,00 (webhook for the authorized transaction)",
+		withCardholder(purchasePayload("tx-auth-001", "idem-auth-001", "APPROVED", 20000), "user-auth-001", "card-auth-001"), 200)
+	auth, _ := r.get("GET /authorizations/tx-auth-001 → expect consistent match",
+		target.baseURL+"/authorizations/tx-auth-001", 200)
+	if match, _ := auth["match"].(map[string]any); match["consistent"] != true {
+		r.failLast(fmt.Sprintf("got match %v", auth["match"]))
+	}
+	return r.result("authorization_approved_then_purchase"), nil
+}
+
+// scenarioAuthorizationInsufficientBalance shows an approved authorization holding its amount.
+func scenarioAuthorizationInsufficientBalance(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.putCard("PUT /cards/card-auth-002 balance R$100,00", "card-auth-002", cardPayload("user-auth-002", "ACTIVE", 10000, 0, 0), 200)
+	body, _ := r.authorize("POST authorization R$80,00 → expect APPROVED",
+		authorizationPayload("tx-auth-002", "PURCHASE", "user-auth-002", "card-auth-002", 8000), 200)
+	r.expectDecision(body, "APPROVED", "APPROVED")
+	body, _ = r.authorize("POST authorization R$30,00 → expect REJECTED (R$80,00 held)",
+		authorizationPayload("tx-auth-003", "PURCHASE", "user-auth-002", "card-auth-002", 3000), 200)
+	r.expectDecision(body, "REJECTED", "INSUFFICIENT_BALANCE")
+	return r.result("authorization_insufficient_balance"), nil
+}
+
+func scenarioAuthorizationCardBlocked(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.putCard("PUT /cards/card-auth-003 BLOCKED", "card-auth-003", cardPayload("user-auth-003", "BLOCKED", 50000, 0, 0), 200)
+	body, _ := r.authorize("POST authorization R$10,00 → expect REJECTED",
+		authorizationPayload("tx-auth-004", "PURCHASE", "user-auth-003", "card-auth-003", 1000), 200)
+	r.expectDecision(body, "REJECTED", "CARD_BLOCKED")
+	return r.result("authorization_card_blocked"), nil
+}
+
+// scenarioAuthorizationLimits runs into the per-transaction limit, then the daily limit.
+func scenarioAuthorizationLimits(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.putCard("PUT /cards/card-auth-004 limits R$100,00 per transaction, R$150,00 per day", "card-auth-004",
+		cardPayload("user-auth-004", "ACTIVE", 100000, 10000, 15000), 200)
+	body, _ := r.authorize("POST WITHDRAWAL authorization R$120,00 → expect REJECTED",
+		authorizationPayload("tx-auth-005", "WITHDRAWAL", "user-auth-004", "card-auth-004", 12000), 200)
+	r.expectDecision(body, "REJECTED", "TRANSACTION_LIMIT_EXCEEDED")
+	body, _ = r.authorize("POST authorization R$90,00 → expect APPROVED",
+		authorizationPayload("tx-auth-006", "PURCHASE", "user-auth-004", "card-auth-004", 9000), 200)
+	r.expectDecision(body, "APPROVED", "APPROVED")
+	body, _ = r.authorize("POST authorization R$90,00 → expect REJECTED (R$180,00 today)",
+		authorizationPayload("tx-auth-007", "PURCHASE", "user-auth-004", "card-auth-004", 9000), 200)
+	r.expectDecision(body, "REJECTED", "DAILY_LIMIT_EXCEEDED")
+	return r.result("authorization_limits"), nil
+}
+
+func scenarioAuthorizationUnknownCard(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	body, _ := r.authorize("POST authorization for an unconfigured card → expect REJECTED",
+		authorizationPayload("tx-auth-008", "PURCHASE", "user-auth-005", "card-auth-unknown", 1000), 200)
+	r.expectDecision(body, "REJECTED", "CARD_NOT_FOUND")
+	r.get("GET /cards/card-auth-unknown → expect 404", target.baseURL+"/cards/card-auth-unknown", 404)
+	r.get("GET /authorizations/tx-auth-inexistente → expect 404", target.baseURL+"/authorizations/tx-auth-inexistente", 404)
+	return r.result("authorization_unknown_card"), nil
+}
+
+// scenarioAuthorizationDuplicate retries an authorization: the recorded decision is returned and
+// no second hold is taken.
+func scenarioAuthorizationDuplicate(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.putCard("PUT /cards/card-auth-006 balance R$100,00", "card-auth-006", cardPayload("user-auth-006", "ACTIVE", 10000, 0, 0), 200)
+	payload := authorizationPayload("tx-auth-009", "PURCHASE", "user-auth-006", "card-auth-006", 6000)
+	body, _ := r.authorize("POST authorization R$60,00 → expect APPROVED", payload, 200)
+	r.expectDecision(body, "APPROVED", "APPROVED")
+	body, _ = r.authorize("POST same authorization again → expect APPROVED, idempotent", payload, 200)
+	r.expectDecision(body, "APPROVED", "APPROVED")
+	if body["idempotent"] != true {
+		r.failLast("expected idempotent=true")
+	}
+	return r.result("authorization_duplicate"), nil
+}
+
+// scenarioAuthorizationWebhookMismatch sends a webhook that disagrees with the approved decision.
+func scenarioAuthorizationWebhookMismatch(target webhookTarget) (ScenarioResult, error) {
+	r := newRunner(target)
+	r.putCard("PUT /cards/card-auth-007 balance R$500,00", "card-auth-007", cardPayload("user-auth-007", "ACTIVE", 50000, 0, 0), 200)
+	body, _ := r.authorize("POST authorization R$100,00 → expect APPROVED",
+		authorizationPayload("tx-auth-010", "PURCHASE", "user-auth-007", "card-auth-007", 10000), 200)
+	r.expectDecision(body, "APPROVED", "APPROVED")
+	r.post("POST PURCHASE APPROVED R$120,00 (amount differs from the authorization)",
+		withCardholder(purchasePayload("tx-auth-010", "idem-auth-010", "APPROVED", 12000), "user-auth-007", "card-auth-007"), 200)
+	auth, _ := r.get("GET /authorizations/tx-auth-010 → expect inconsistent match",
+		target.baseURL+"/authorizations/tx-auth-010", 200)
+	if match, _ := auth["match"].(map[string]any); match["consistent"] != false {
+		r.failLast(fmt.Sprintf("got match %v", auth["match"]))
+	}
+	return r.result("authorization_webhook_mismatch"), nil
+}
+
 // availableScenarios returns all scenario names.
 func availableScenarios() []string {
 	return []string{
@@ -571,9 +994,29 @@ func availableScenarios() []string {
 		"missing_idempotency_key",
 		"invalid_created_at",
 		"invalid_json_body",
+		// Other transaction types
+		"withdrawal_reversal",
+		"withdrawal_reversal_on_rejected",
+		"withdrawal_amount_out_of_range",
+		"extracash_reversal_exceeds",
+		"balance_inquiry",
+		"payment_reversal_partial",
+		"credit_voucher_reversal",
+		"reversal_type_mismatch",
 		// Query flows
 		"list_transactions",
 		"get_transaction_existing",
 		"get_transaction_not_found",
+		"get_transaction_adjustments",
+		// Ledger flows
+		"ledger_balance",
+		// Authorization flows
+		"authorization_approved_then_purchase",
+		"authorization_insufficient_balance",
+		"authorization_card_blocked",
+		"authorization_limits",
+		"authorization_unknown_card",
+		"authorization_duplicate",
+		"authorization_webhook_mismatch",
 	}
 }
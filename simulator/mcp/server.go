@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"sync/atomic"
 	"time"
 )
@@ -58,22 +59,40 @@ type toolCallResult struct {
 // Server runs the MCP JSON-RPC 2.0 server over stdin/stdout.
 type Server struct {
 	baseURL string
+	secret  string
 	logger  *slog.Logger
 	writer  *bufio.Writer
 }
 
+// Option configures optional Server behaviour.
+type Option func(*Server)
+
+// WithSigningSecret signs every webhook with the given secret, matching a server started
+// with WEBHOOK_SECRETS.
+func WithSigningSecret(secret string) Option {
+	return func(s *Server) { s.secret = secret }
+}
+
 // NewServer creates an MCP server that calls baseURL for all HTTP requests.
-func NewServer(baseURL string) *Server {
-	return &Server{
+func NewServer(baseURL string, opts ...Option) *Server {
+	s := &Server{
 		baseURL: baseURL,
 		logger:  slog.New(slog.NewTextHandler(os.Stderr, nil)),
 		writer:  bufio.NewWriter(os.Stdout),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Server) target() webhookTarget {
+	return webhookTarget{baseURL: s.baseURL, secret: s.secret}
 }
 
 // Run starts reading JSON-RPC requests from stdin and writing responses to stdout.
 func (s *Server) Run() {
-	s.logger.Info("MCP server started", "baseURL", s.baseURL)
+	s.logger.Info("MCP server started", "baseURL", s.baseURL, "signed", s.secret != "")
 	scanner := bufio.NewScanner(os.Stdin)
 	// Increase buffer for large payloads
 	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
@@ -131,12 +150,18 @@ func (s *Server) handleToolCall(req jsonRPCRequest) {
 	switch params.Name {
 	case "simulate_purchase":
 		resultText, toolErr = s.toolSimulatePurchase(params.Arguments)
+	case "simulate_transaction":
+		resultText, toolErr = s.toolSimulateTransaction(params.Arguments)
 	case "simulate_reversal":
 		resultText, toolErr = s.toolSimulateReversal(params.Arguments)
 	case "simulate_refund":
 		resultText, toolErr = s.toolSimulateRefund(params.Arguments)
 	case "simulate_scenario":
 		resultText, toolErr = s.toolSimulateScenario(params.Arguments)
+	case "simulate_authorization":
+		resultText, toolErr = s.toolSimulateAuthorization(params.Arguments)
+	case "configure_card":
+		resultText, toolErr = s.toolConfigureCard(params.Arguments)
 	default:
 		s.writeError(req.ID, -32601, fmt.Sprintf("unknown tool: %s", params.Name))
 		return
@@ -180,14 +205,56 @@ func (s *Server) toolSimulatePurchase(args json.RawMessage) (string, error) {
 		p.Currency = "BRL"
 	}
 
-	r := newRunner(s.baseURL)
+	r := newRunner(s.target())
 	r.post("simulate_purchase", purchasePayload(p.TransactionID, p.IdempotencyKey, p.Status, p.Amount), 200)
 	result := r.result("simulate_purchase")
 	return marshalResult(result)
 }
 
+// originalTypes are the types simulate_transaction sends; reversalTypes those simulate_reversal sends.
+var (
+	originalTypes = []string{"PURCHASE", "WITHDRAWAL", "EXTRACASH", "BALANCE_INQUIRY", "PAYMENT", "CREDIT_VOUCHER"}
+	reversalTypes = []string{"REVERSAL_PURCHASE", "REVERSAL_WITHDRAWAL", "REVERSAL_EXTRACASH",
+		"REVERSAL_BALANCE_INQUIRY", "REVERSAL_PAYMENT", "REVERSAL_CREDIT_VOUCHER"}
+)
+
+func (s *Server) toolSimulateTransaction(args json.RawMessage) (string, error) {
+	var p struct {
+		Type           string `json:"type"`
+		TransactionID  string `json:"transaction_id"`
+		IdempotencyKey string `json:"idempotency_key"`
+		Status         string `json:"status"`
+		Amount         int64  `json:"amount"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if !slices.Contains(originalTypes, p.Type) {
+		return "", fmt.Errorf("type must be one of %v", originalTypes)
+	}
+	if p.TransactionID == "" {
+		p.TransactionID = generateID("tx")
+	}
+	if p.IdempotencyKey == "" {
+		p.IdempotencyKey = generateID("idem")
+	}
+	if p.Status == "" {
+		p.Status = "APPROVED"
+	}
+	// A balance inquiry carries no amount.
+	if p.Amount == 0 && p.Type != "BALANCE_INQUIRY" {
+		p.Amount = 10000
+	}
+
+	r := newRunner(s.target())
+	r.post("simulate_transaction", transactionPayload(p.TransactionID, p.Type, p.IdempotencyKey, p.Status, p.Amount), 200)
+	result := r.result("simulate_transaction")
+	return marshalResult(result)
+}
+
 func (s *Server) toolSimulateReversal(args json.RawMessage) (string, error) {
 	var p struct {
+		Type                  string `json:"type"`
 		TransactionID         string `json:"transaction_id"`
 		IdempotencyKey        string `json:"idempotency_key"`
 		OriginalTransactionID string `json:"original_transaction_id"`
@@ -200,6 +267,12 @@ func (s *Server) toolSimulateReversal(args json.RawMessage) (string, error) {
 	if p.OriginalTransactionID == "" {
 		return "", fmt.Errorf("original_transaction_id is required")
 	}
+	if p.Type == "" {
+		p.Type = "REVERSAL_PURCHASE"
+	}
+	if !slices.Contains(reversalTypes, p.Type) {
+		return "", fmt.Errorf("type must be one of %v", reversalTypes)
+	}
 	if p.TransactionID == "" {
 		p.TransactionID = generateID("rev")
 	}
@@ -213,8 +286,8 @@ func (s *Server) toolSimulateReversal(args json.RawMessage) (string, error) {
 		p.Currency = "BRL"
 	}
 
-	r := newRunner(s.baseURL)
-	r.post("simulate_reversal", adjustmentPayload(p.TransactionID, "REVERSAL_PURCHASE", p.IdempotencyKey, p.OriginalTransactionID, "APPROVED", p.Amount), 200)
+	r := newRunner(s.target())
+	r.post("simulate_reversal", adjustmentPayload(p.TransactionID, p.Type, p.IdempotencyKey, p.OriginalTransactionID, "APPROVED", p.Amount), 200)
 	result := r.result("simulate_reversal")
 	return marshalResult(result)
 }
@@ -246,7 +319,7 @@ func (s *Server) toolSimulateRefund(args json.RawMessage) (string, error) {
 		p.Currency = "BRL"
 	}
 
-	r := newRunner(s.baseURL)
+	r := newRunner(s.target())
 	r.post("simulate_refund", adjustmentPayload(p.TransactionID, "REFUND", p.IdempotencyKey, p.OriginalTransactionID, "APPROVED", p.Amount), 200)
 	result := r.result("simulate_refund")
 	return marshalResult(result)
@@ -262,13 +335,77 @@ func (s *Server) toolSimulateScenario(args json.RawMessage) (string, error) {
 	if p.Scenario == "" {
 		return "", fmt.Errorf("scenario is required. available: %v", availableScenarios())
 	}
-	result, err := runScenario(s.baseURL, p.Scenario)
+	result, err := runScenario(s.target(), p.Scenario)
 	if err != nil {
 		return "", err
 	}
 	return marshalResult(result)
 }
 
+func (s *Server) toolSimulateAuthorization(args json.RawMessage) (string, error) {
+	var p struct {
+		Type          string `json:"type"`
+		TransactionID string `json:"transaction_id"`
+		CardID        string `json:"card_id"`
+		UserID        string `json:"user_id"`
+		Amount        int64  `json:"amount"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if p.CardID == "" {
+		return "", fmt.Errorf("card_id is required")
+	}
+	if p.Type == "" {
+		p.Type = "PURCHASE"
+	}
+	if !slices.Contains(originalTypes, p.Type) {
+		return "", fmt.Errorf("type must be one of %v", originalTypes)
+	}
+	if p.TransactionID == "" {
+		p.TransactionID = generateID("tx")
+	}
+	if p.UserID == "" {
+		p.UserID = "user-001"
+	}
+	if p.Amount == 0 && p.Type != "BALANCE_INQUIRY" {
+		p.Amount = 10000
+	}
+
+	r := newRunner(s.target())
+	r.authorize("simulate_authorization", authorizationPayload(p.TransactionID, p.Type, p.UserID, p.CardID, p.Amount), 200)
+	result := r.result("simulate_authorization")
+	return marshalResult(result)
+}
+
+func (s *Server) toolConfigureCard(args json.RawMessage) (string, error) {
+	var p struct {
+		CardID           string `json:"card_id"`
+		UserID           string `json:"user_id"`
+		Status           string `json:"status"`
+		Balance          int64  `json:"balance"`
+		TransactionLimit int64  `json:"transaction_limit"`
+		DailyLimit       int64  `json:"daily_limit"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if p.CardID == "" {
+		return "", fmt.Errorf("card_id is required")
+	}
+	if p.UserID == "" {
+		p.UserID = "user-001"
+	}
+	if p.Status == "" {
+		p.Status = "ACTIVE"
+	}
+
+	r := newRunner(s.target())
+	r.putCard("configure_card", p.CardID, cardPayload(p.UserID, p.Status, p.Balance, p.TransactionLimit, p.DailyLimit), 200)
+	result := r.result("configure_card")
+	return marshalResult(result)
+}
+
 // --- Tool definitions ---
 
 func (s *Server) toolDefinitions() []toolDefinition {
@@ -287,16 +424,32 @@ func (s *Server) toolDefinitions() []toolDefinition {
 				},
 			},
 		},
+		{
+			Name:        "simulate_transaction",
+			Description: "Send a WITHDRAWAL, EXTRACASH, BALANCE_INQUIRY, PAYMENT, CREDIT_VOUCHER or PURCHASE webhook to the Pomelo server",
+			InputSchema: map[string]any{
+				"type": "object",
+				"required": []string{"type"},
+				"properties": map[string]any{
+					"type":            map[string]any{"type": "string", "enum": originalTypes, "description": "Transaction type"},
+					"transaction_id":  map[string]any{"type": "string", "description": "Transaction ID (auto-generated if empty)"},
+					"idempotency_key": map[string]any{"type": "string", "description": "Idempotency key (auto-generated if empty)"},
+					"status":          map[string]any{"type": "string", "enum": []string{"APPROVED", "REJECTED"}, "description": "Transaction status"},
+					"amount":          map[string]any{"type": "integer", "description": "Amount in cents (default: 10000; 0 for BALANCE_INQUIRY)"},
+				},
+			},
+		},
 		{
 			Name:        "simulate_reversal",
-			Description: "Send a REVERSAL_PURCHASE webhook to the Pomelo server",
+			Description: "Send a REVERSAL_* webhook (REVERSAL_PURCHASE by default) to the Pomelo server",
 			InputSchema: map[string]any{
 				"type": "object",
 				"required": []string{"original_transaction_id"},
 				"properties": map[string]any{
+					"type":                    map[string]any{"type": "string", "enum": reversalTypes, "description": "Reversal type matching the original (default: REVERSAL_PURCHASE)"},
 					"transaction_id":          map[string]any{"type": "string"},
 					"idempotency_key":         map[string]any{"type": "string"},
-					"original_transaction_id": map[string]any{"type": "string", "description": "ID of the original PURCHASE"},
+					"original_transaction_id": map[string]any{"type": "string", "description": "ID of the original transaction"},
 					"amount":                  map[string]any{"type": "integer", "description": "Amount in cents"},
 					"currency":                map[string]any{"type": "string"},
 				},
@@ -317,6 +470,37 @@ func (s *Server) toolDefinitions() []toolDefinition {
 				},
 			},
 		},
+		{
+			Name:        "configure_card",
+			Description: "Create or replace a card profile (balance, limits, status) used by authorizations",
+			InputSchema: map[string]any{
+				"type": "object",
+				"required": []string{"card_id"},
+				"properties": map[string]any{
+					"card_id":           map[string]any{"type": "string"},
+					"user_id":           map[string]any{"type": "string", "description": "Card owner (default: user-001)"},
+					"status":            map[string]any{"type": "string", "enum": []string{"ACTIVE", "BLOCKED"}, "description": "Card status (default: ACTIVE)"},
+					"balance":           map[string]any{"type": "integer", "description": "Funded balance in cents"},
+					"transaction_limit": map[string]any{"type": "integer", "description": "Max amount per authorization in cents (0: no limit)"},
+					"daily_limit":       map[string]any{"type": "integer", "description": "Max approved amount per UTC day in cents (0: no limit)"},
+				},
+			},
+		},
+		{
+			Name:        "simulate_authorization",
+			Description: "Ask the Pomelo server to authorize a transaction before it happens; answers APPROVED or REJECTED with the reason",
+			InputSchema: map[string]any{
+				"type": "object",
+				"required": []string{"card_id"},
+				"properties": map[string]any{
+					"card_id":        map[string]any{"type": "string", "description": "Card configured with configure_card"},
+					"type":           map[string]any{"type": "string", "enum": originalTypes, "description": "Transaction type (default: PURCHASE)"},
+					"transaction_id": map[string]any{"type": "string", "description": "Transaction ID the later webhook will carry (auto-generated if empty)"},
+					"user_id":        map[string]any{"type": "string", "description": "Cardholder (default: user-001)"},
+					"amount":         map[string]any{"type": "integer", "description": "Amount in cents (default: 10000)"},
+				},
+			},
+		},
 		{
 			Name:        "simulate_scenario",
 			Description: fmt.Sprintf("Run a predefined end-to-end scenario. Available: %v", availableScenarios()),